@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -12,9 +15,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/module"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/hashicorp/go-version"
 )
 
@@ -22,31 +28,100 @@ const (
 	moduleSpecFileName = "boring-registry.hcl"
 )
 
-func archiveModules(root string, storage module.Storage) error {
-	if flagRecursive {
-		err := filepath.Walk(root, func(path string, fi os.FileInfo, _ error) error {
-			// FYI we conciously ignore all walk-related errors
+// archiveModules uploads the modules below the root. Failed modules don't stop the upload of the remaining modules,
+// unless --fail-fast is set, their errors are returned joined.
+func archiveModules(root string, storage module.Storage) (*uploadResult, error) {
+	result := newUploadResult()
+	if !flagRecursive {
+		path := filepath.Join(root, moduleSpecFileName)
+		if processErr := processModule(path, storage, result); processErr != nil {
+			result.failed++
+			return result, fmt.Errorf("failed to process module at %s:\n%w", path, processErr)
+		}
+		return result, nil
+	}
 
-			if fi.Name() != moduleSpecFileName {
-				return nil
-			}
-			if processErr := processModule(path, storage); processErr != nil {
-				return fmt.Errorf("failed to process module at %s:\n%w", path, processErr)
-			}
+	var errs []error
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, _ error) error {
+		// FYI we conciously ignore all walk-related errors
 
+		if fi.Name() != moduleSpecFileName {
 			return nil
-		})
-		return err
+		}
+		if processErr := processModule(path, storage, result); processErr != nil {
+			result.failed++
+			err := fmt.Errorf("failed to process module at %s:\n%w", path, processErr)
+			if flagUploadFailFast {
+				return err
+			}
+			slog.Error("failed to upload module", slog.String("path", filepath.Dir(path)), slog.String("err", processErr.Error()))
+			errs = append(errs, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
 	}
 
-	path := filepath.Join(root, moduleSpecFileName)
-	if processErr := processModule(path, storage); processErr != nil {
-		return fmt.Errorf("failed to process module at %s:\n%w", path, processErr)
+	slog.Info("finished uploading modules",
+		slog.Int("published", len(result.Published)),
+		slog.Int("skipped", len(result.Skipped)),
+		slog.Int("failed", result.failed),
+	)
+	return result, errors.Join(errs...)
+}
+
+// uploadResult lists the modules which were published or skipped by the upload command
+type uploadResult struct {
+	Published []moduleUpload `json:"published"`
+	Skipped   []moduleUpload `json:"skipped"`
+
+	// failed counts the modules which failed to be uploaded, their errors are returned by archiveModules
+	failed int
+}
+
+// moduleUpload is a module processed by the upload command, skipped modules have the reason why they weren't uploaded
+type moduleUpload struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Version   string `json:"version"`
+	Path      string `json:"path"`
+	Checksum  string `json:"checksum,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func newUploadResult() *uploadResult {
+	return &uploadResult{
+		Published: []moduleUpload{},
+		Skipped:   []moduleUpload{},
 	}
-	return nil
 }
 
-func processModule(path string, storage module.Storage) error {
+func (r *uploadResult) publish(path string, spec *module.Spec, checksum string) {
+	m := newModuleUpload(path, spec)
+	m.Checksum = checksum
+	r.Published = append(r.Published, m)
+}
+
+func (r *uploadResult) skip(path string, spec *module.Spec, reason string) {
+	m := newModuleUpload(path, spec)
+	m.Reason = reason
+	r.Skipped = append(r.Skipped, m)
+}
+
+func newModuleUpload(path string, spec *module.Spec) moduleUpload {
+	return moduleUpload{
+		Namespace: spec.Metadata.Namespace,
+		Name:      spec.Metadata.Name,
+		Provider:  spec.Metadata.Provider,
+		Version:   spec.Metadata.Version,
+		Path:      filepath.Dir(path),
+	}
+}
+
+func processModule(path string, storage module.Storage, result *uploadResult) error {
 	spec, err := module.ParseFile(path)
 	if err != nil {
 		return err
@@ -54,6 +129,30 @@ func processModule(path string, storage module.Storage) error {
 
 	slog.Debug("parsed module spec", slog.String("path", path), slog.String("name", spec.Name()))
 
+	// The publisher is the identity of the upload, e.g. in the input of the admission policy
+	ctx := module.WithArchiveFormat(core.WithIdentity(context.Background(), flagUploadPublisher), flagUploadArchiveFormat)
+	moduleRoot := filepath.Dir(path)
+
+	// The digest is recorded for every uploaded version, so that later uploads can detect whether the module changed
+	var digest string
+	digestStorage, recordDigest := storage.(module.DigestStorage)
+	if recordDigest || flagUploadChangedOnly {
+		if digest, err = moduleDigest(moduleRoot); err != nil {
+			return err
+		}
+	}
+	// Changes are detected before the version constraints are checked, as the version may be bumped
+	if flagUploadChangedOnly {
+		changed, err := detectModuleChanges(ctx, storage, spec, digest)
+		if err != nil {
+			return err
+		} else if !changed {
+			slog.Info("module is unchanged since its latest version, skipped", slog.String("name", spec.Name()))
+			result.skip(path, spec, "unchanged")
+			return nil
+		}
+	}
+
 	// Check if the module meets version constraints
 	if versionConstraintsSemver != nil {
 		ok, err := meetsSemverConstraints(spec)
@@ -62,6 +161,7 @@ func processModule(path string, storage module.Storage) error {
 		} else if !ok {
 			// Skip the module, as it didn't pass the version constraints
 			slog.Info("module doesn't meet semver version constraints, skipped", slog.String("name", spec.Name()))
+			result.skip(path, spec, "version constraints")
 			return nil
 		}
 	}
@@ -70,14 +170,38 @@ func processModule(path string, storage module.Storage) error {
 		if !meetsRegexConstraints(spec) {
 			// Skip the module, as it didn't pass the regex version constraints
 			slog.Info("module doesn't meet regex version constraints, skipped", slog.String("name", spec.Name()))
+			result.skip(path, spec, "version constraints")
 			return nil
 		}
 	}
 
-	ctx := context.Background()
+	if flagUploadStage {
+		stagingStorage, ok := storage.(module.StagingStorage)
+		if !ok {
+			return errors.New("the storage backend doesn't support staging modules")
+		}
+		// Staged versions are hidden from GetModule, so they're looked up separately
+		if _, err := stagingStorage.ModuleStaging(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version); err == nil {
+			if flagIgnoreExistingModule {
+				slog.Info("module is already staged", slog.String("name", spec.Name()))
+				result.skip(path, spec, "already staged")
+				return nil
+			}
+			return errors.New("module is already staged")
+		}
+		ctx = module.WithStaging(ctx, &module.Staging{StagedAt: time.Now().UTC(), Publisher: flagUploadPublisher})
+	}
+	replace := false
 	if res, err := storage.GetModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version); err == nil {
-		if flagIgnoreExistingModule {
+		if module.NewOverwritePolicy(flagAllowOverwrite).Allowed(spec.Metadata.Namespace) {
+			if _, ok := storage.(module.ReplaceStorage); !ok {
+				return errors.New("the storage backend doesn't support replacing modules")
+			}
+			slog.Warn("module already exists and is replaced", slog.String("download_url", res.DownloadURL))
+			replace = true
+		} else if flagIgnoreExistingModule {
 			slog.Info("module already exists", slog.String("download_url", res.DownloadURL))
+			result.skip(path, spec, "already exists")
 			return nil
 		} else {
 			slog.Error("module already exists", slog.String("download_url", res.DownloadURL))
@@ -85,25 +209,128 @@ func processModule(path string, storage module.Storage) error {
 		}
 	}
 
-	moduleRoot := filepath.Dir(path)
+	// The SBOM is read before the upload, so that a module of a namespace requiring one isn't published without it
+	sbomDocument, err := readSBOM(filepath.Join(moduleRoot, moduleSBOMFile), spec.Metadata.Namespace)
+	if err != nil {
+		return err
+	}
+	sbomStorage, ok := storage.(module.SBOMStorage)
+	if sbomDocument != nil && !ok {
+		return errors.New("the storage backend doesn't support SBOMs")
+	}
 
-	buf, err := archiveModule(moduleRoot)
+	buf, err := archiveModule(moduleRoot, flagUploadArchiveFormat)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(buf)
 	if err != nil {
 		return err
 	}
 
-	res, err := storage.UploadModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, buf)
+	// The archive is signed before it's uploaded, so that an invalid key doesn't leave an unsigned module behind
+	var signature []byte
+	signatureStorage, ok := storage.(module.SignatureStorage)
+	if moduleSigningKey != nil {
+		if !ok {
+			return errors.New("the storage backend doesn't support module signatures")
+		}
+		if signature, err = signModule(data, moduleSigningKey); err != nil {
+			return err
+		}
+	}
+
+	var res core.Module
+	if replace {
+		res, err = storage.(module.ReplaceStorage).ReplaceModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, bytes.NewReader(data))
+	} else {
+		res, err = storage.UploadModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, bytes.NewReader(data))
+	}
 	if err != nil {
 		return err
 	}
 
-	slog.Info("module successfully uploaded", slog.String("download_url", res.DownloadURL))
+	if signature != nil {
+		if err := signatureStorage.UploadModuleSignature(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, bytes.NewReader(signature)); err != nil {
+			return err
+		}
+	}
+
+	// The checksum is computed locally, so that an archive which was altered on the way to the storage isn't recorded in the manifest
+	checksum := sha256.Sum256(data)
+	if manifestStorage, ok := storage.(module.ChecksumManifestStorage); ok {
+		if err := manifestStorage.UploadModuleChecksum(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, hex.EncodeToString(checksum[:])); err != nil {
+			return fmt.Errorf("failed to record the module checksum: %w", err)
+		}
+	}
+
+	if recordDigest {
+		if err := digestStorage.UploadModuleDigest(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, digest); err != nil {
+			return fmt.Errorf("failed to record the module digest: %w", err)
+		}
+	}
+
+	if sbomDocument != nil {
+		if err := sbomStorage.UploadModuleSBOM(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, bytes.NewReader(sbomDocument)); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("module successfully uploaded",
+		slog.String("download_url", res.DownloadURL),
+		slog.String("checksum", module.FormatChecksum(hex.EncodeToString(checksum[:]))),
+		slog.Bool("signed", signature != nil),
+		slog.Bool("sbom", sbomDocument != nil),
+	)
 
+	result.publish(path, spec, module.FormatChecksum(hex.EncodeToString(checksum[:])))
 	return nil
 
 }
 
-func archiveModule(root string) (io.Reader, error) {
+// readSigningKey reads the first private key of the ASCII-armored key ring and decrypts it with the passphrase
+func readSigningKey(path, passphrase string) (*openpgp.Entity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signing key: %w", err)
+	}
+	defer f.Close()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	for _, entity := range keyring {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if entity.PrivateKey.Encrypted {
+			if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("failed to decrypt signing key: %w", err)
+			}
+		}
+		return entity, nil
+	}
+
+	return nil, fmt.Errorf("%s doesn't contain a private key", path)
+}
+
+// signModule creates an ASCII-armored detached signature of the module archive
+func signModule(archive []byte, key *openpgp.Entity) ([]byte, error) {
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, key, bytes.NewReader(archive), nil); err != nil {
+		return nil, fmt.Errorf("failed to sign module archive: %w", err)
+	}
+	return signature.Bytes(), nil
+}
+
+// archiveModule packages the module in the archive format, which is either tar.gz or zip
+func archiveModule(root, format string) (io.Reader, error) {
+	if format == module.ArchiveFormatZip {
+		return archiveModuleZip(root)
+	}
+
 	buf := new(bytes.Buffer)
 	// ensure the src actually exists before trying to tar it
 	if _, err := os.Stat(root); err != nil {
@@ -159,6 +386,50 @@ func archiveModule(root string) (io.Reader, error) {
 	return buf, err
 }
 
+func archiveModuleZip(root string) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	// ensure the src actually exists before trying to zip it
+	if _, err := os.Stat(root); err != nil {
+		return buf, fmt.Errorf("unable to zip files - %v", err.Error())
+	}
+
+	zw := zip.NewWriter(buf)
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+		header.Name = archiveFileHeaderName(path, root)
+		header.Method = zip.Deflate
+
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer data.Close()
+
+		_, err = io.Copy(w, data)
+		return err
+	})
+	if err != nil {
+		return buf, err
+	}
+
+	return buf, zw.Close()
+}
+
 // meetsSemverConstraints checks whether a module version matches the semver version constraints.
 // Returns an unrecoverable error if there's an internal error.
 // Otherwise, it returns a boolean indicating if the module meets the constraints
@@ -1,8 +1,17 @@
 package cmd
 
 import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -55,3 +64,53 @@ func TestArchiveFileHeaderName(t *testing.T) {
 	}
 
 }
+
+func TestSignModule(t *testing.T) {
+	entity, err := openpgp.NewEntity("boring-registry", "", "modules@example.com", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.PrivateKey.Encrypt([]byte("passphrase")))
+
+	var key bytes.Buffer
+	w, err := armor.Encode(&key, openpgp.PrivateKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	assert.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "signing-key.asc")
+	assert.NoError(t, os.WriteFile(path, key.Bytes(), 0600))
+
+	_, err = readSigningKey(path, "wrong")
+	assert.ErrorContains(t, err, "failed to decrypt signing key")
+
+	signingKey, err := readSigningKey(path, "passphrase")
+	assert.NoError(t, err)
+
+	signature, err := signModule([]byte("archive"), signingKey)
+	assert.NoError(t, err)
+
+	keyring := openpgp.EntityList{entity}
+	_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader([]byte("archive")), bytes.NewReader(signature), nil)
+	assert.NoError(t, err)
+	_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader([]byte("tampered")), bytes.NewReader(signature), nil)
+	assert.Error(t, err)
+}
+
+func TestArchiveModuleZip(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "modules", "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "main.tf"), []byte("main"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "modules", "sub", "sub.tf"), []byte("sub"), 0644))
+
+	buf, err := archiveModule(root, module.ArchiveFormatZip)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(buf)
+	assert.NoError(t, err)
+
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	var names []string
+	for _, f := range z.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"main.tf", "modules/sub/sub.tf"}, names)
+}
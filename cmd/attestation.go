@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/spf13/cobra"
+)
+
+var uploadModuleAttestationCmd = &cobra.Command{
+	Use:          "module-attestation NAMESPACE/NAME/PROVIDER/VERSION BUNDLE",
+	Short:        "Upload the Sigstore bundle of a published module archive",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE:         uploadModuleAttestation,
+}
+
+func uploadModuleAttestation(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 4 {
+		return fmt.Errorf("module %q must be in the form <namespace>/<name>/<provider>/<version>", args[0])
+	}
+	namespace, name, provider, version := parts[0], parts[1], parts[2], parts[3]
+
+	bundle, err := os.ReadFile(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to read file at path %s: %w", args[1], err)
+	}
+
+	ctx := context.Background()
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+
+	storageBackend, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+	attestationStorage, ok := storageBackend.(module.AttestationStorage)
+	if !ok {
+		return errors.New("the storage backend doesn't support module attestations")
+	}
+
+	// The bundle is verified against the checksum recorded on upload, so that the archive doesn't have to be downloaded
+	verifier, err := attestationVerifier()
+	if err != nil {
+		return err
+	} else if verifier != nil {
+		checksum, err := storageBackend.GetModuleChecksum(ctx, namespace, name, provider, version)
+		if err != nil {
+			return err
+		}
+		digest, err := hex.DecodeString(checksum)
+		if err != nil {
+			return fmt.Errorf("invalid checksum of module %s: %w", args[0], err)
+		}
+		if err := verifier.VerifyDigest(digest, bundle); err != nil {
+			return err
+		}
+	}
+
+	if err := attestationStorage.UploadModuleAttestation(ctx, namespace, name, provider, version, bytes.NewReader(bundle)); err != nil {
+		return err
+	}
+
+	slog.Info("module attestation successfully uploaded", slog.String("module", args[0]), slog.Bool("verified", verifier != nil))
+	return nil
+}
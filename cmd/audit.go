@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var flagAuditSampleRate float64
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().Float64Var(&flagAuditSampleRate, "audit-sample-rate", 1, "Fraction of the stored archives whose checksum is verified, 1 verifies all archives")
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the integrity of the stored modules and providers",
+	Long: `Audit the integrity of the stored modules and providers.
+The SHA256SUMS file of every provider version has to be signed by the signing keys of the provider, and every file it advertises has to be stored.
+The checksums of a sample of the module and provider archives are verified, which downloads the archives.
+The command fails if any discrepancy is found.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         auditStorage,
+}
+
+func auditStorage(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	s, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	auditor, ok := s.(storage.Auditor)
+	if !ok {
+		return errors.New("the storage backend doesn't support audits")
+	}
+
+	// The discrepancies are logged by the auditor
+	return runAudit(ctx, auditor, nil)
+}
+
+// runAudit audits the storage, records the result in the metrics if they aren't nil,
+// and returns an error if any discrepancy is found
+func runAudit(ctx context.Context, auditor storage.Auditor, metrics *o11y.AuditMetrics) error {
+	result, err := auditor.Audit(ctx, flagAuditSampleRate)
+	if err != nil {
+		return err
+	}
+
+	if metrics != nil {
+		counts := map[string]int{storage.AuditChecksum: 0, storage.AuditSignature: 0, storage.AuditPlatform: 0}
+		for _, d := range result.Discrepancies {
+			counts[d.Check]++
+		}
+		for check, count := range counts {
+			metrics.Discrepancies.WithLabelValues(check).Set(float64(count))
+		}
+		metrics.Archives.Set(float64(result.Archives))
+		metrics.LastRun.Set(float64(time.Now().Unix()))
+	}
+
+	if len(result.Discrepancies) > 0 {
+		return fmt.Errorf("%d stored artifacts failed the audit", len(result.Discrepancies))
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagBackfillArchivePaths      []string
+	flagBackfillSigningKeyFile    string
+	flagBackfillSigningPassphrase string
+)
+
+func init() {
+	rootCmd.AddCommand(backfillCmd)
+	backfillCmd.AddCommand(backfillProviderCmd)
+
+	backfillProviderCmd.Flags().StringSliceVar(&flagBackfillArchivePaths, "filenames-provider-archives", []string{}, "A list of file paths to the provider ZIP archives of the added platforms")
+	backfillProviderCmd.Flags().StringVar(&flagBackfillSigningKeyFile, "signing-key-file", "", "Path to the ASCII-armored OpenPGP private key to sign the extended SHA256SUMS file")
+	backfillProviderCmd.Flags().StringVar(&flagBackfillSigningPassphrase, "signing-key-passphrase", "", "Passphrase of the OpenPGP private key to sign the SHA256SUMS file")
+}
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Add artifacts to existing versions",
+}
+
+var backfillProviderCmd = &cobra.Command{
+	Use:   "provider NAMESPACE/NAME VERSION",
+	Short: "Add platforms to an existing provider version",
+	Long: `Add platforms to an existing provider version, e.g. darwin_arm64 builds which were released after the fact.
+The archives are uploaded, and the SHA256SUMS file of the version is extended with their checksums and signed again with the signing key,
+whose public key has to be one of the signing keys of the namespace.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         backfillProvider,
+}
+
+func backfillProvider(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 2 || slices.Contains(parts, "") {
+		return fmt.Errorf("invalid reference %q, expected NAMESPACE/NAME", args[0])
+	}
+	namespace, name, version := parts[0], parts[1], args[1]
+
+	if len(flagBackfillArchivePaths) == 0 {
+		return errors.New("no provider archives to backfill, use --filenames-provider-archives")
+	}
+	if flagBackfillSigningKeyFile == "" {
+		return errors.New("the SHA256SUMS file can't be signed without --signing-key-file")
+	}
+	key, err := readSigningKey(flagBackfillSigningKeyFile, flagBackfillSigningPassphrase)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+
+	s, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+	backfiller, ok := s.(storage.ProviderBackfiller)
+	if !ok {
+		return errors.New("the storage backend doesn't support backfilling providers")
+	}
+
+	data, err := backfiller.ProviderSha256Sums(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	p := &core.Provider{Name: name, Version: version}
+	sums, err := core.NewSha256Sums(p.ShasumFileName(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	archivePaths, err := extendShaSums(sums, flagBackfillArchivePaths, name, version)
+	if err != nil {
+		return err
+	}
+	if len(archivePaths) == 0 {
+		slog.Info("all platforms exist already", slog.String("name", sums.Filename))
+		return nil
+	}
+
+	// The signature is validated before anything is uploaded, as the version would otherwise fail the verification of the Terraform CLI
+	extended := sums.Bytes()
+	signature, err := signShaSums(extended, key)
+	if err != nil {
+		return err
+	}
+	signingKeys, err := s.SigningKeys(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := signingKeys.IsValidSha256Sums(extended, signature); err != nil {
+		return fmt.Errorf("the signing key doesn't match the signing keys of provider %s/%s: %w", namespace, name, err)
+	}
+
+	if err := uploadProviderReleaseFilesParallel(ctx, s, archivePaths, namespace, name); err != nil {
+		return err
+	}
+	if err := backfiller.ReplaceProviderSha256Sums(ctx, namespace, name, version, extended, signature); err != nil {
+		return err
+	}
+	slog.Info("successfully backfilled provider platforms", slog.String("name", sums.Filename), slog.Int("platforms", len(archivePaths)))
+
+	return nil
+}
+
+// extendShaSums adds the checksums of the archives to the SHA256SUMS file and returns the archives which aren't listed yet.
+// Archives which are listed with a different checksum are rejected, as existing platforms can't be replaced.
+func extendShaSums(sums *core.Sha256Sums, paths []string, name, version string) ([]string, error) {
+	var added []string
+	for _, path := range paths {
+		fileName := filepath.Base(path)
+		provider, err := core.NewProviderFromArchive(fileName)
+		if err != nil {
+			return nil, err
+		}
+		if provider.Name != name || provider.Version != version {
+			return nil, fmt.Errorf("the archive %s doesn't belong to version %s of provider %s", fileName, version, name)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file at path %s: %w", path, err)
+		}
+		checksum, err := core.Sha256Checksum(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute checksum of %s: %w", path, err)
+		}
+
+		if existing, ok := sums.Entries[fileName]; ok {
+			if !bytes.Equal(existing, checksum) {
+				return nil, fmt.Errorf("%s is listed already with a different checksum: %w", fileName, core.ErrObjectAlreadyExists)
+			}
+			slog.Info("skipping existing platform", slog.String("name", fileName))
+			continue
+		}
+		sums.Entries[fileName] = checksum
+		added = append(added, path)
+	}
+	return added, nil
+}
+
+// signShaSums creates a binary detached signature of the SHA256SUMS file, like gpg --detach-sign
+func signShaSums(sums []byte, key *openpgp.Entity) ([]byte, error) {
+	var signature bytes.Buffer
+	if err := openpgp.DetachSign(&signature, key, bytes.NewReader(sums), nil); err != nil {
+		return nil, fmt.Errorf("failed to sign SHA256SUMS file: %w", err)
+	}
+	return signature.Bytes(), nil
+}
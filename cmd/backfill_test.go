@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtendShaSums(t *testing.T) {
+	dir := t.TempDir()
+	linux := filepath.Join(dir, "terraform-provider-random_2.0.0_linux_amd64.zip")
+	darwin := filepath.Join(dir, "terraform-provider-random_2.0.0_darwin_arm64.zip")
+	other := filepath.Join(dir, "terraform-provider-random_2.1.0_darwin_arm64.zip")
+	for _, path := range []string{linux, darwin, other} {
+		assert.NoError(t, os.WriteFile(path, []byte(filepath.Base(path)), 0o644))
+	}
+	linuxChecksum := sha256.Sum256([]byte(filepath.Base(linux)))
+
+	newSums := func() *core.Sha256Sums {
+		return &core.Sha256Sums{
+			Filename: "terraform-provider-random_2.0.0_SHA256SUMS",
+			Entries:  map[string][]byte{filepath.Base(linux): linuxChecksum[:]},
+		}
+	}
+
+	sums := newSums()
+	added, err := extendShaSums(sums, []string{linux, darwin}, "random", "2.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{darwin}, added)
+	assert.Len(t, sums.Entries, 2)
+
+	_, err = extendShaSums(newSums(), []string{other}, "random", "2.0.0")
+	assert.Error(t, err)
+
+	// Existing platforms can't be replaced
+	assert.NoError(t, os.WriteFile(linux, []byte("rebuilt"), 0o644))
+	_, err = extendShaSums(newSums(), []string{linux}, "random", "2.0.0")
+	assert.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+}
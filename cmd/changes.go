@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/hashicorp/go-version"
+)
+
+// moduleDigest returns the hex-encoded SHA-256 digest of the files of the module directory, which are packaged in its archive.
+// Unlike the checksum of the archive, the digest doesn't depend on file modification times or the archive format.
+// The boring-registry.hcl file is excluded, so that changing the version of a module alone isn't a change.
+func moduleDigest(root string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		name := filepath.ToSlash(archiveFileHeaderName(path, root))
+		if name == moduleSpecFileName {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		checksum, err := core.Sha256Checksum(f)
+		if err != nil {
+			return err
+		}
+		// The files are listed like in a SHA256SUMS file, filepath.Walk visits them in lexical order
+		_, err = fmt.Fprintf(h, "%x  %s\n", checksum, name)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to compute the digest of the module at %s: %w", root, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// detectModuleChanges reports whether the digest differs from the one recorded for the latest published version of the module.
+// Modules without published versions, or whose latest version has no recorded digest, are considered changed.
+// With --bump-patch, the version of a changed module is set to the next patch version of the latest version, unless the spec declares a higher version.
+func detectModuleChanges(ctx context.Context, storage module.Storage, spec *module.Spec, digest string) (bool, error) {
+	digestStorage, ok := storage.(module.DigestStorage)
+	if !ok {
+		return false, errors.New("the storage backend doesn't support module digests")
+	}
+
+	modules, err := storage.ListModuleVersions(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider)
+	if err != nil {
+		return false, err
+	}
+	latest, err := module.LatestVersion(modules)
+	if errors.Is(err, module.ErrModuleNotFound) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	recorded, err := digestStorage.ModuleDigest(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, latest.Version)
+	if errors.Is(err, core.ErrObjectNotFound) {
+		slog.Debug("no digest is recorded for the latest version of the module", slog.String("name", spec.Name()), slog.String("latest", latest.Version))
+	} else if err != nil {
+		return false, err
+	} else if recorded == digest {
+		return false, nil
+	}
+
+	if flagUploadBumpPatch {
+		bumped, err := bumpPatchVersion(spec.Metadata.Version, latest.Version)
+		if err != nil {
+			return false, err
+		}
+		if bumped != spec.Metadata.Version {
+			slog.Info("bumped the patch version of the changed module", slog.String("name", spec.Name()), slog.String("version", bumped))
+			spec.Metadata.Version = bumped
+		}
+	}
+
+	return true, nil
+}
+
+// bumpPatchVersion returns the next patch version of the latest version, or the declared version if it's higher
+func bumpPatchVersion(declared, latest string) (string, error) {
+	d, err := version.NewVersion(declared)
+	if err != nil {
+		return "", err
+	}
+	l, err := version.NewVersion(latest)
+	if err != nil {
+		return "", err
+	}
+	if d.GreaterThan(l) {
+		return declared, nil
+	}
+
+	segments := l.Segments()
+	return fmt.Sprintf("%d.%d.%d", segments[0], segments[1], segments[2]+1), nil
+}
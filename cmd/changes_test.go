@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleDigest(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "modules", "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "main.tf"), []byte("main"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "modules", "sub", "sub.tf"), []byte("sub"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, moduleSpecFileName), []byte(`version = "1.0.0"`), 0644))
+
+	digest, err := moduleDigest(root)
+	assert.NoError(t, err)
+
+	// The spec file and modification times don't change the digest
+	assert.NoError(t, os.WriteFile(filepath.Join(root, moduleSpecFileName), []byte(`version = "1.0.1"`), 0644))
+	assert.NoError(t, os.Chtimes(filepath.Join(root, "main.tf"), time.Unix(0, 0), time.Unix(0, 0)))
+	unchanged, err := moduleDigest(root)
+	assert.NoError(t, err)
+	assert.Equal(t, digest, unchanged)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "modules", "sub", "sub.tf"), []byte("changed"), 0644))
+	changed, err := moduleDigest(root)
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, changed)
+}
+
+func TestDetectModuleChanges(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemoryStorage()
+	newSpec := func(version string) *module.Spec {
+		return &module.Spec{Metadata: module.Metadata{Namespace: "acme", Name: "vpc", Provider: "aws", Version: version}}
+	}
+
+	flagUploadBumpPatch = true
+	t.Cleanup(func() { flagUploadBumpPatch = false })
+
+	// Modules without published versions are changed
+	spec := newSpec("1.0.0")
+	changed, err := detectModuleChanges(ctx, s, spec, "digest-1")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "1.0.0", spec.Metadata.Version)
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(t, err)
+
+	// Versions without digest are considered changed
+	spec = newSpec("1.0.0")
+	changed, err = detectModuleChanges(ctx, s, spec, "digest-1")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "1.0.1", spec.Metadata.Version)
+
+	assert.NoError(t, s.UploadModuleDigest(ctx, "acme", "vpc", "aws", "1.0.0", "digest-1"))
+	changed, err = detectModuleChanges(ctx, s, newSpec("1.0.0"), "digest-1")
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// A declared version higher than the latest version isn't bumped
+	spec = newSpec("1.1.0")
+	changed, err = detectModuleChanges(ctx, s, spec, "digest-2")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "1.1.0", spec.Metadata.Version)
+}
+
+func TestBumpPatchVersion(t *testing.T) {
+	tests := []struct {
+		declared string
+		latest   string
+		want     string
+	}{
+		{declared: "1.0.0", latest: "1.0.0", want: "1.0.1"},
+		{declared: "0.9.0", latest: "1.2.3", want: "1.2.4"},
+		{declared: "2.0.0", latest: "1.2.3", want: "2.0.0"},
+		{declared: "1.0.0", latest: "1.2", want: "1.2.1"},
+	}
+	for _, tt := range tests {
+		got, err := bumpPatchVersion(tt.declared, tt.latest)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/channel"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(channelCmd)
+	channelCmd.AddCommand(channelListCmd)
+	channelCmd.AddCommand(channelSetCmd)
+	channelCmd.AddCommand(channelDeleteCmd)
+}
+
+var channelCmd = &cobra.Command{
+	Use:   "channel",
+	Short: "Manage channels like stable, which point to a version of a module or provider",
+	Long: `Manage channels like stable, which point to a version of a module or provider.
+Modules are referenced as NAMESPACE/NAME/PROVIDER and providers as NAMESPACE/NAME.`,
+}
+
+var channelListCmd = &cobra.Command{
+	Use:          "list MODULE|PROVIDER",
+	Short:        "List the channels of a module or provider",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var res core.Channels
+		err := withChannels(args[0], func(ctx context.Context, c channels) error {
+			var err error
+			res, err = c.list(ctx)
+			return err
+		})
+
+		return printResult(res, err, func() {
+			names := make([]string, 0, len(res))
+			for name := range res {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				fmt.Printf("%s\t%s\n", name, res[name])
+			}
+		})
+	},
+}
+
+var channelSetCmd = &cobra.Command{
+	Use:          "set MODULE|PROVIDER CHANNEL VERSION",
+	Short:        "Point the channel of a module or provider to a version",
+	Args:         cobra.ExactArgs(3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withChannels(args[0], func(ctx context.Context, c channels) error {
+			return c.set(ctx, args[1], args[2])
+		})
+	},
+}
+
+var channelDeleteCmd = &cobra.Command{
+	Use:          "delete MODULE|PROVIDER CHANNEL",
+	Short:        "Delete the channel of a module or provider",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withChannels(args[0], func(ctx context.Context, c channels) error {
+			return c.delete(ctx, args[1])
+		})
+	},
+}
+
+// channels manages the channels of a single module or provider
+type channels struct {
+	list   func(ctx context.Context) (core.Channels, error)
+	set    func(ctx context.Context, channel, version string) error
+	delete func(ctx context.Context, channel string) error
+}
+
+// withChannels calls fn with the channels of the module in the form NAMESPACE/NAME/PROVIDER or the provider in the form NAMESPACE/NAME
+func withChannels(ref string, fn func(ctx context.Context, c channels) error) error {
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return fmt.Errorf("invalid reference %q, expected NAMESPACE/NAME/PROVIDER for modules or NAMESPACE/NAME for providers", ref)
+	}
+
+	ctx := context.Background()
+	storageBackend, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+	manager := channel.NewManager(storageBackend)
+	proxy := core.NewProxyUrlService(false, "")
+
+	var c channels
+	if len(parts) == 3 {
+		namespace, name, p := parts[0], parts[1], parts[2]
+		svc := module.NewService(storageBackend, proxy, module.WithChannels(manager))
+		c = channels{
+			list: func(ctx context.Context) (core.Channels, error) {
+				return svc.ListChannels(ctx, namespace, name, p)
+			},
+			set: func(ctx context.Context, ch, version string) error {
+				return svc.SetChannel(ctx, namespace, name, p, ch, version)
+			},
+			delete: func(ctx context.Context, ch string) error {
+				return svc.DeleteChannel(ctx, namespace, name, p, ch)
+			},
+		}
+	} else {
+		namespace, name := parts[0], parts[1]
+		svc := provider.NewService(storageBackend, proxy, provider.WithChannels(manager))
+		c = channels{
+			list: func(ctx context.Context) (core.Channels, error) {
+				return svc.ListChannels(ctx, namespace, name)
+			},
+			set: func(ctx context.Context, ch, version string) error {
+				return svc.SetChannel(ctx, namespace, name, ch, version)
+			},
+			delete: func(ctx context.Context, ch string) error {
+				return svc.DeleteChannel(ctx, namespace, name, ch)
+			},
+		}
+	}
+
+	return fn(ctx, c)
+}
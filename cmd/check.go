@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate the access to the configured storage backend",
+	Long: `Validate that the bucket of the configured storage backend exists, and that objects can be listed, downloaded, presigned, uploaded, and deleted.
+Every missing permission is reported, e.g. in an init container before the server is started. The upload and delete permissions aren't checked with --read-only.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		s, err := setupStorage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to set up storage: %w", err)
+		}
+
+		if err := checkStorage(ctx, s); err != nil {
+			return err
+		}
+		slog.Info("storage check passed")
+		return nil
+	},
+}
+
+// checkStorage validates the access to the storage, the write permissions are only required without --read-only
+func checkStorage(ctx context.Context, s storage.Storage) error {
+	checker, ok := s.(storage.Checker)
+	if !ok {
+		return errors.New("the storage backend doesn't support checks")
+	}
+
+	if err := checker.Check(ctx, !flagReadOnly); err != nil {
+		return fmt.Errorf("storage check failed:\n%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// readConfigFile reads a YAML, TOML, or JSON configuration file and returns the values by flag name.
+// Keys are either the flag names or nested, e.g. storage-s3-bucket can be configured as storage.s3.bucket.
+func readConfigFile(path string, known map[string]struct{}) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	values := make(map[string]interface{})
+	for _, key := range v.AllKeys() {
+		name := strings.ReplaceAll(key, ".", "-")
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("unknown key %q in config file %s", key, path)
+		}
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("key %q is configured more than once in config file %s", key, path)
+		}
+		values[name] = v.Get(key)
+	}
+
+	return values, nil
+}
+
+// knownFlags returns the names of the flags of the command and all its subcommands,
+// so that a config file shared by all commands may contain flags of other commands.
+func knownFlags(cmd *cobra.Command) map[string]struct{} {
+	known := make(map[string]struct{})
+	visit := func(f *pflag.Flag) { known[f.Name] = struct{}{} }
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		c.Flags().VisitAll(visit)
+		c.PersistentFlags().VisitAll(visit)
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(cmd)
+
+	return known
+}
+
+// setConfigValue sets the flag to the value of the config file, lists are used for slice flags
+func setConfigValue(f *pflag.Flag, value interface{}) error {
+	list, ok := value.([]interface{})
+	if !ok {
+		return setFlag(f, fmt.Sprintf("%v", value))
+	}
+
+	s, ok := f.Value.(pflag.SliceValue)
+	if !ok {
+		return fmt.Errorf("expected a single value, but got a list")
+	}
+
+	values := make([]string, 0, len(list))
+	for _, v := range list {
+		values = append(values, fmt.Sprintf("%v", v))
+	}
+	if err := s.Replace(values); err != nil {
+		return err
+	}
+
+	f.Changed = true
+	return nil
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfigCommand() (*cobra.Command, *string, *[]string, *time.Duration) {
+	var (
+		bucket string
+		tokens []string
+		expiry time.Duration
+	)
+	root := &cobra.Command{Use: "root"}
+	root.PersistentFlags().StringVar(&bucket, "storage-s3-bucket", "", "")
+	root.PersistentFlags().DurationVar(&expiry, "storage-s3-signedurl-expiry", time.Minute, "")
+
+	server := &cobra.Command{Use: "server"}
+	server.Flags().StringSliceVar(&tokens, "auth-static-token", nil, "")
+	server.Flags().AddFlagSet(root.PersistentFlags())
+	root.AddCommand(server, &cobra.Command{Use: "upload"})
+
+	return server, &bucket, &tokens, &expiry
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfigFile(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml with flag names",
+			file: "config.yaml",
+			content: `
+storage-s3-bucket: registry
+storage-s3-signedurl-expiry: 10m
+auth-static-token:
+  - token-a
+  - token-b
+`,
+		},
+		{
+			name: "nested yaml",
+			file: "config.yaml",
+			content: `
+storage:
+  s3:
+    bucket: registry
+    signedurl-expiry: 10m
+auth:
+  static-token: [token-a, token-b]
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `
+auth-static-token = ["token-a", "token-b"]
+
+[storage.s3]
+bucket = "registry"
+signedurl-expiry = "10m"
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, bucket, tokens, expiry := testConfigCommand()
+
+			config, err := readConfigFile(writeConfigFile(t, tc.file, tc.content), knownFlags(cmd.Root()))
+			assert.NoError(t, err)
+			assert.NoError(t, bindFlags(cmd, viper.New(), config))
+
+			assert.Equal(t, "registry", *bucket)
+			assert.Equal(t, []string{"token-a", "token-b"}, *tokens)
+			assert.Equal(t, 10*time.Minute, *expiry)
+		})
+	}
+}
+
+func TestReadConfigFile_Precedence(t *testing.T) {
+	cmd, bucket, _, expiry := testConfigCommand()
+	assert.NoError(t, cmd.Flags().Set("storage-s3-signedurl-expiry", "1h"))
+	t.Setenv("BORING_REGISTRY_STORAGE_S3_BUCKET", "from-env")
+
+	config, err := readConfigFile(writeConfigFile(t, "config.yaml", "storage-s3-bucket: from-file\nstorage-s3-signedurl-expiry: 10m\n"), knownFlags(cmd.Root()))
+	assert.NoError(t, err)
+	assert.NoError(t, bindFlags(cmd, viper.New(), config))
+
+	assert.Equal(t, "from-env", *bucket)
+	assert.Equal(t, time.Hour, *expiry)
+}
+
+func TestReadConfigFile_Errors(t *testing.T) {
+	cmd, _, _, _ := testConfigCommand()
+
+	_, err := readConfigFile(writeConfigFile(t, "config.yaml", "storage-s3-buckett: registry\n"), knownFlags(cmd.Root()))
+	assert.ErrorContains(t, err, `unknown key "storage-s3-buckett"`)
+
+	_, err = readConfigFile(writeConfigFile(t, "config.yaml", "storage-s3-bucket: a\nstorage:\n  s3:\n    bucket: b\n"), knownFlags(cmd.Root()))
+	assert.ErrorContains(t, err, "more than once")
+
+	_, err = readConfigFile(filepath.Join(t.TempDir(), "missing.yaml"), knownFlags(cmd.Root()))
+	assert.ErrorContains(t, err, "failed to read config file")
+
+	config, err := readConfigFile(writeConfigFile(t, "config.yaml", "storage-s3-signedurl-expiry: soon\n"), knownFlags(cmd.Root()))
+	assert.NoError(t, err)
+	assert.ErrorContains(t, bindFlags(cmd, viper.New(), config), `invalid value of key "storage-s3-signedurl-expiry"`)
+
+	config, err = readConfigFile(writeConfigFile(t, "config.yaml", "storage-s3-bucket: [a, b]\n"), knownFlags(cmd.Root()))
+	assert.NoError(t, err)
+	assert.ErrorContains(t, bindFlags(cmd, viper.New(), config), "expected a single value")
+}
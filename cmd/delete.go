@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var flagGCGracePeriod time.Duration
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(gcCmd)
+
+	gcCmd.Flags().DurationVar(&flagGCGracePeriod, "grace-period", 30*24*time.Hour, "Time after which deleted versions are purged from the storage backend")
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete MODULE|PROVIDER VERSION",
+	Short: "Delete a version of a module or provider",
+	Long: `Delete a version of a module or provider.
+Modules are referenced as NAMESPACE/NAME/PROVIDER and providers as NAMESPACE/NAME.
+The version is hidden immediately, but its objects are kept until they are purged by the gc command.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := withLifecycle(args[0], args[1], func(ctx context.Context, l lifecycle) error {
+			return l.delete(ctx)
+		})
+		return printResult(lifecycleResult{Reference: args[0], Version: args[1]}, err, nil)
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore MODULE|PROVIDER VERSION",
+	Short: "Restore a deleted version of a module or provider, which hasn't been purged yet",
+	Long: `Restore a deleted version of a module or provider, which hasn't been purged yet.
+Modules are referenced as NAMESPACE/NAME/PROVIDER and providers as NAMESPACE/NAME.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := withLifecycle(args[0], args[1], func(ctx context.Context, l lifecycle) error {
+			return l.restore(ctx)
+		})
+		return printResult(lifecycleResult{Reference: args[0], Version: args[1]}, err, nil)
+	},
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Purge the objects of versions deleted longer ago than the grace period",
+	Long: `Purge the objects of versions deleted longer ago than the grace period.
+Versions which failed to be purged are retried by the next run.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		s, waitForReplication, err := setupLifecycleStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer waitForReplication()
+
+		result, err := s.Purge(ctx, time.Now().Add(-flagGCGracePeriod))
+		if err != nil {
+			return printResult(nil, err, nil)
+		}
+		if result.Failed > 0 {
+			err = fmt.Errorf("failed to purge %d deleted versions", result.Failed)
+		}
+		return printResult(result, err, func() {
+			fmt.Printf("purged: %d, pending: %d, failed: %d\n", result.Purged, result.Pending, result.Failed)
+		})
+	},
+}
+
+// lifecycleStorage is implemented by storage backends deleting versions with tombstones
+type lifecycleStorage interface {
+	DeleteModule(ctx context.Context, namespace, name, provider, version string) error
+	RestoreModule(ctx context.Context, namespace, name, provider, version string) error
+	DeleteProvider(ctx context.Context, namespace, name, version string) error
+	RestoreProvider(ctx context.Context, namespace, name, version string) error
+	Purge(ctx context.Context, before time.Time) (storage.PurgeResult, error)
+}
+
+// lifecycleResult is the version of the module or provider which was deleted or restored
+type lifecycleResult struct {
+	Reference string `json:"reference"`
+	Version   string `json:"version"`
+}
+
+// lifecycle deletes and restores a single version of a module or provider
+type lifecycle struct {
+	delete  func(ctx context.Context) error
+	restore func(ctx context.Context) error
+}
+
+// withLifecycle calls fn with the lifecycle of the version of the module in the form NAMESPACE/NAME/PROVIDER or the provider in the form NAMESPACE/NAME
+func withLifecycle(ref, version string, fn func(ctx context.Context, l lifecycle) error) error {
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return fmt.Errorf("invalid reference %q, expected NAMESPACE/NAME/PROVIDER for modules or NAMESPACE/NAME for providers", ref)
+	}
+
+	ctx := context.Background()
+	s, waitForReplication, err := setupLifecycleStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+
+	var l lifecycle
+	if len(parts) == 3 {
+		namespace, name, p := parts[0], parts[1], parts[2]
+		l = lifecycle{
+			delete: func(ctx context.Context) error {
+				return s.DeleteModule(ctx, namespace, name, p, version)
+			},
+			restore: func(ctx context.Context) error {
+				return s.RestoreModule(ctx, namespace, name, p, version)
+			},
+		}
+	} else {
+		namespace, name := parts[0], parts[1]
+		l = lifecycle{
+			delete: func(ctx context.Context) error {
+				return s.DeleteProvider(ctx, namespace, name, version)
+			},
+			restore: func(ctx context.Context) error {
+				return s.RestoreProvider(ctx, namespace, name, version)
+			},
+		}
+	}
+
+	return fn(ctx, l)
+}
+
+// setupLifecycleStorage replicates tombstones and purges to the replication targets, so that deleted versions are hidden there as well
+func setupLifecycleStorage(ctx context.Context) (lifecycleStorage, func(), error) {
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storageBackend, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		waitForReplication()
+		return nil, nil, fmt.Errorf("failed to set up storage: %w", err)
+	}
+	s, ok := storageBackend.(lifecycleStorage)
+	if !ok {
+		waitForReplication()
+		return nil, nil, errors.New("the storage backend doesn't support deleting versions")
+	}
+
+	return s, waitForReplication, nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagDevFixtures            string
+	flagDevListenAddr          string
+	flagDevTelemetryListenAddr string
+)
+
+func init() {
+	rootCmd.AddCommand(devCmd)
+
+	devCmd.Flags().StringVar(&flagDevFixtures, "fixtures", "", "Directory with the modules and providers the registry is seeded with, see test/fixtures/dev for an example")
+	devCmd.Flags().StringVar(&flagDevListenAddr, "listen-address", "localhost:5601", "Address to listen on")
+	devCmd.Flags().StringVar(&flagDevTelemetryListenAddr, "listen-telemetry-address", "localhost:7801", "Telemetry address to listen on")
+}
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Starts a throwaway server with the in-memory storage seeded with fixtures",
+	Long: `Starts the server with the in-memory storage seeded with the modules and providers of the fixtures directory.
+The modules in the modules directory are uploaded like with 'upload module'.
+The providers directory contains a directory per namespace with a signing-keys.json file and the release files of the providers.
+All data is lost once the server is stopped.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Storage backends configured in the environment are ignored, so that the fixtures never end up in a real bucket
+		flagS3Bucket, flagGCSBucket, flagAzureStorageContainer, flagStorageURL, flagRepositoryURL, flagOCIRepository = "", "", "", "", "", ""
+		flagReplicationTargets = nil
+		flagStorageInmem = true
+		flagListenAddr = flagDevListenAddr
+		flagTelemetryListenAddr = flagDevTelemetryListenAddr
+
+		fmt.Print(devCLIConfig(flagDevListenAddr))
+
+		return serverCmd.RunE(cmd, args)
+	},
+}
+
+// devCLIConfig returns a Terraform CLI configuration pointing the hostname of the listen address to the plain HTTP server
+func devCLIConfig(listenAddr string) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	hostname := net.JoinHostPort(host, port)
+
+	return fmt.Sprintf(`Terraform and OpenTofu only discover registries via HTTPS. Save the following as a CLI configuration file
+and point TF_CLI_CONFIG_FILE to it to use the registry without TLS:
+
+host "%[1]s" {
+  services = {
+    "modules.v1"   = "http://%[1]s%[2]s/"
+    "providers.v1" = "http://%[1]s%[3]s/"
+  }
+}
+
+Modules are then referenced as %[1]s/<namespace>/<name>/<provider>.
+
+`, hostname, prefixModules, prefixProviders)
+}
+
+// signingKeysStorage is implemented by storages which can store the signing keys of a namespace
+type signingKeysStorage interface {
+	UploadSigningKeys(ctx context.Context, namespace, name string, signingKeys *core.SigningKeys) error
+}
+
+// seedFixtures uploads the modules and providers of the fixtures directory to the storage
+func seedFixtures(ctx context.Context, s storage.Storage, dir string) error {
+	modulesDir := filepath.Join(dir, "modules")
+	if _, err := os.Stat(modulesDir); err == nil {
+		if _, err := archiveModules(modulesDir, s); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	providersDir := filepath.Join(dir, "providers")
+	namespaces, err := os.ReadDir(providersDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		if !namespace.IsDir() {
+			continue
+		}
+		if err := seedProviders(ctx, s, namespace.Name(), filepath.Join(providersDir, namespace.Name())); err != nil {
+			return fmt.Errorf("failed to seed providers of namespace %s: %w", namespace.Name(), err)
+		}
+	}
+	return nil
+}
+
+// seedProviders uploads the signing keys of the namespace and the releases of all *_SHA256SUMS files in the directory
+func seedProviders(ctx context.Context, s storage.Storage, namespace, dir string) error {
+	keysStorage, ok := s.(signingKeysStorage)
+	if !ok {
+		return errors.New("the storage backend doesn't support uploading signing keys")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "signing-keys.json"))
+	if err != nil {
+		return err
+	}
+	var signingKeys core.SigningKeys
+	if err := json.Unmarshal(b, &signingKeys); err != nil {
+		return fmt.Errorf("failed to parse signing-keys.json: %w", err)
+	}
+	if err := keysStorage.UploadSigningKeys(ctx, namespace, "", &signingKeys); err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, "_SHA256SUMS") {
+			return err
+		}
+
+		sumsBytes, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sigBytes, err := os.ReadFile(path + ".sig")
+		if err != nil {
+			return err
+		}
+		if err := signingKeys.IsValidSha256Sums(sumsBytes, sigBytes); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		sums, err := core.NewSha256Sums(filepath.Base(path), bytes.NewReader(sumsBytes))
+		if err != nil {
+			return err
+		}
+		name, err := sums.Name()
+		if err != nil {
+			return err
+		}
+
+		// The archives are uploaded first, so that the SHA256SUMS file only references archives which exist already
+		var paths []string
+		for fileName := range sums.Entries {
+			paths = append(paths, filepath.Join(filepath.Dir(path), fileName))
+		}
+		manifestPath := strings.TrimSuffix(path, "_SHA256SUMS") + core.ProviderManifestSuffix
+		if _, err := os.Stat(manifestPath); err == nil && !slices.Contains(paths, manifestPath) {
+			paths = append(paths, manifestPath)
+		}
+		paths = append(paths, path, path+".sig")
+		for _, p := range paths {
+			if err := uploadProviderReleaseFile(ctx, s, p, namespace, name); err != nil {
+				return err
+			}
+		}
+
+		slog.Info("seeded provider", slog.String("namespace", namespace), slog.String("name", name), slog.String("sha256sums", filepath.Base(path)))
+		return nil
+	})
+}
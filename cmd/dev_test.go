@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedFixtures(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemoryStorage()
+
+	// The providers are generated, as their SHA256SUMS files have to be signed
+	dir := t.TempDir()
+	assert.NoError(t, os.CopyFS(filepath.Join(dir, "modules"), os.DirFS("../test/fixtures/dev/modules")))
+	providerDir := filepath.Join(dir, "providers", "acme", "dummy")
+	assert.NoError(t, os.MkdirAll(providerDir, 0755))
+
+	entity, err := openpgp.NewEntity("boring-registry", "", "providers@example.com", nil)
+	assert.NoError(t, err)
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(w))
+	assert.NoError(t, w.Close())
+	keys, err := json.Marshal(core.SigningKeys{GPGPublicKeys: []core.GPGPublicKey{{KeyID: entity.PrimaryKey.KeyIdString(), ASCIIArmor: publicKey.String()}}})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "providers", "acme", "signing-keys.json"), keys, 0644))
+
+	archive := []byte("archive")
+	assert.NoError(t, os.WriteFile(filepath.Join(providerDir, "terraform-provider-dummy_1.0.0_linux_amd64.zip"), archive, 0644))
+	sums := []byte(fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256(archive)))
+	assert.NoError(t, os.WriteFile(filepath.Join(providerDir, "terraform-provider-dummy_1.0.0_SHA256SUMS"), sums, 0644))
+	var signature bytes.Buffer
+	assert.NoError(t, openpgp.DetachSign(&signature, entity, bytes.NewReader(sums), nil))
+	assert.NoError(t, os.WriteFile(filepath.Join(providerDir, "terraform-provider-dummy_1.0.0_SHA256SUMS.sig"), signature.Bytes(), 0644))
+
+	assert.NoError(t, seedFixtures(ctx, s, dir))
+
+	modules, err := s.ListModuleVersions(ctx, "acme", "hello", "null")
+	assert.NoError(t, err)
+	assert.Len(t, modules, 2)
+
+	p, err := s.GetProvider(ctx, "acme", "dummy", "1.0.0", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "terraform-provider-dummy_1.0.0_linux_amd64.zip", p.Filename)
+
+	// Tampered SHA256SUMS files aren't seeded
+	assert.NoError(t, os.WriteFile(filepath.Join(providerDir, "terraform-provider-dummy_1.0.0_SHA256SUMS"), []byte("tampered"), 0644))
+	assert.ErrorContains(t, seedFixtures(ctx, storage.NewMemoryStorage(), dir), "failed to seed providers of namespace acme")
+}
+
+func TestDevCLIConfig(t *testing.T) {
+	assert.Contains(t, devCLIConfig(":5601"), `"modules.v1"   = "http://localhost:5601/v1/modules/"`)
+	assert.Contains(t, devCLIConfig("127.0.0.1:8080"), `host "127.0.0.1:8080"`)
+	assert.Empty(t, devCLIConfig("invalid"))
+}
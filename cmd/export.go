@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagExportArchives  bool
+	flagImportOverwrite bool
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	exportCmd.Flags().BoolVar(&flagExportArchives, "archives", true, "Export the module and provider archives, without them only the metadata is exported")
+	importCmd.Flags().BoolVar(&flagImportOverwrite, "overwrite", false, "Overwrite existing objects instead of skipping them")
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export PATH",
+	Short: "Export the catalog of the registry to a tarball or directory",
+	Long: `Export all modules and providers of the registry to a portable tarball or directory.
+The export is written to a directory if PATH ends with a slash or is an existing directory, and to a tarball otherwise, which is compressed if PATH ends with .gz or .tgz.
+The export can be imported with the import command, e.g. to restore a backup, to migrate to another storage backend, or to seed an air-gapped environment.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         exportStorage,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import PATH",
+	Short: "Import an export of the catalog into the storage",
+	Long: `Import a tarball or directory written by the export command into the storage.
+Existing objects are skipped unless --overwrite is set.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         importStorage,
+}
+
+func setupExporter(ctx context.Context) (storage.Exporter, error) {
+	s, err := setupStorage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	exporter, ok := s.(storage.Exporter)
+	if !ok {
+		return nil, errors.New("the storage backend doesn't support exports")
+	}
+	return exporter, nil
+}
+
+func exportStorage(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	exporter, err := setupExporter(ctx)
+	if err != nil {
+		return err
+	}
+
+	w, err := storage.NewExportWriter(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to create export: %w", err)
+	}
+	if _, err := exporter.Export(ctx, w, flagExportArchives); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	slog.Info("wrote export", slog.String("path", args[0]))
+	return nil
+}
+
+func importStorage(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	exporter, err := setupExporter(ctx)
+	if err != nil {
+		return err
+	}
+
+	r, err := storage.OpenExport(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+	defer r.Close()
+
+	// The result is logged by the importer
+	_, err = exporter.Import(ctx, r, flagImportOverwrite)
+	return err
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/attestation"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// githubRelease is the subset of a GitHub release needed to download its assets
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	Name string `json:"name"`
+
+	// URL is the API URL of the asset, which also works for releases of private repositories
+	URL string `json:"url"`
+}
+
+// githubReleaseClient downloads the assets of provider releases built with goreleaser from GitHub
+type githubReleaseClient struct {
+	client *http.Client
+	apiURL string
+	token  string
+}
+
+// release fetches the release of the repository in the form <owner>/<repo> by its tag
+func (c *githubReleaseClient) release(ctx context.Context, repository, tag string) (*githubRelease, error) {
+	owner, repo, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("GitHub repository %q must be in the form <owner>/<repo>", repository)
+	}
+
+	u := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", strings.TrimSuffix(c.apiURL, "/"), url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(tag))
+	resp, err := c.do(ctx, u, "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %s of %s: %w", tag, repository, err)
+	}
+	defer resp.Body.Close()
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release %s of %s: %w", tag, repository, err)
+	}
+	return &release, nil
+}
+
+// download writes the asset to the directory and returns the path of the file
+func (c *githubReleaseClient) download(ctx context.Context, asset githubAsset, dir string) (string, error) {
+	resp, err := c.do(ctx, asset.URL, "application/octet-stream")
+	if err != nil {
+		return "", fmt.Errorf("failed to download asset %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+
+	p := filepath.Join(dir, filepath.Base(asset.Name))
+	f, err := os.Create(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to download asset %s: %w", asset.Name, err)
+	}
+	return p, f.Close()
+}
+
+func (c *githubReleaseClient) do(ctx context.Context, u, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, u)
+	}
+	return resp, nil
+}
+
+// downloadGitHubRelease downloads the *_SHA256SUMS file, its signature, its Sigstore bundle and manifest if there are any, and the archives listed in it
+// from the assets of the release matching the pattern, and returns the path of the *_SHA256SUMS file.
+func downloadGitHubRelease(ctx context.Context, c *githubReleaseClient, repository, tag string, pattern *regexp.Regexp, dir string) (string, error) {
+	release, err := c.release(ctx, repository, tag)
+	if err != nil {
+		return "", err
+	}
+
+	assets := make(map[string]githubAsset)
+	var sumsAssets []githubAsset
+	for _, asset := range release.Assets {
+		if !pattern.MatchString(asset.Name) {
+			continue
+		}
+		assets[asset.Name] = asset
+		if strings.HasSuffix(asset.Name, "_SHA256SUMS") {
+			sumsAssets = append(sumsAssets, asset)
+		}
+	}
+
+	if len(sumsAssets) != 1 {
+		return "", fmt.Errorf("expected exactly one *_SHA256SUMS asset matching %q in release %s of %s, found %d", pattern.String(), tag, repository, len(sumsAssets))
+	}
+	sumsAsset := sumsAssets[0]
+	signatureAsset, ok := assets[sumsAsset.Name+".sig"]
+	if !ok {
+		return "", fmt.Errorf("release %s of %s is missing the signature %s.sig", tag, repository, sumsAsset.Name)
+	}
+
+	sumsPath, err := c.download(ctx, sumsAsset, dir)
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.download(ctx, signatureAsset, dir); err != nil {
+		return "", err
+	}
+	if bundleAsset, ok := assets[sumsAsset.Name+attestation.BundleSuffix]; ok {
+		if _, err := c.download(ctx, bundleAsset, dir); err != nil {
+			return "", err
+		}
+	}
+	if sbomAsset, ok := assets[sumsAsset.Name+sbom.Suffix]; ok {
+		if _, err := c.download(ctx, sbomAsset, dir); err != nil {
+			return "", err
+		}
+	}
+	manifestName := strings.TrimSuffix(sumsAsset.Name, "_SHA256SUMS") + core.ProviderManifestSuffix
+	if manifestAsset, ok := assets[manifestName]; ok {
+		if _, err := c.download(ctx, manifestAsset, dir); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := os.Open(sumsPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sums, err := core.NewSha256Sums(sumsAsset.Name, f)
+	if err != nil {
+		return "", err
+	}
+
+	for fileName := range sums.Entries {
+		if fileName == manifestName {
+			continue
+		}
+		asset, ok := assets[fileName]
+		if !ok {
+			return "", fmt.Errorf("release %s of %s is missing the asset %s listed in %s", tag, repository, fileName, sumsAsset.Name)
+		}
+		if _, err := c.download(ctx, asset, dir); err != nil {
+			return "", err
+		}
+		slog.Debug("downloaded release asset", slog.String("name", fileName))
+	}
+
+	slog.Info("downloaded GitHub release", slog.String("repository", repository), slog.String("tag", tag), slog.Int("archives", len(sums.Entries)))
+	return sumsPath, nil
+}
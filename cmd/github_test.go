@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadGitHubRelease(t *testing.T) {
+	files := map[string]string{
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip":  "linux",
+		"terraform-provider-dummy_1.0.0_darwin_arm64.zip": "darwin",
+		"terraform-provider-other_2.0.0_linux_amd64.zip":  "other",
+	}
+	var sums strings.Builder
+	for _, name := range []string{"terraform-provider-dummy_1.0.0_darwin_arm64.zip", "terraform-provider-dummy_1.0.0_linux_amd64.zip"} {
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256([]byte(files[name])), name)
+	}
+	files["terraform-provider-dummy_1.0.0_SHA256SUMS"] = sums.String()
+	files["terraform-provider-dummy_1.0.0_SHA256SUMS.sig"] = "signature"
+	files["terraform-provider-dummy_1.0.0_SHA256SUMS.sigstore.json"] = "{}"
+	files["terraform-provider-dummy_1.0.0_manifest.json"] = `{"version": 1, "metadata": {"protocol_versions": ["6.0"]}}`
+	files["terraform-provider-other_2.0.0_SHA256SUMS"] = "sums"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/repos/acme/terraform-provider-dummy/releases/tags/v1.0.0":
+			var assets []githubAsset
+			for name := range files {
+				assets = append(assets, githubAsset{Name: name, URL: server.URL + "/assets/" + name})
+			}
+			_ = json.NewEncoder(w).Encode(githubRelease{TagName: "v1.0.0", Assets: assets})
+		case strings.HasPrefix(r.URL.Path, "/assets/"):
+			assert.Equal(t, "application/octet-stream", r.Header.Get("Accept"))
+			_, _ = w.Write([]byte(files[strings.TrimPrefix(r.URL.Path, "/assets/")]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := &githubReleaseClient{client: server.Client(), apiURL: server.URL, token: "secret"}
+	ctx := context.Background()
+
+	t.Run("multiple providers require a pattern", func(t *testing.T) {
+		_, err := downloadGitHubRelease(ctx, c, "acme/terraform-provider-dummy", "v1.0.0", regexp.MustCompile("^terraform-provider-"), t.TempDir())
+		assert.ErrorContains(t, err, "expected exactly one *_SHA256SUMS asset")
+	})
+
+	t.Run("assets matching the pattern are downloaded", func(t *testing.T) {
+		dir := t.TempDir()
+		sumsPath, err := downloadGitHubRelease(ctx, c, "acme/terraform-provider-dummy", "v1.0.0", regexp.MustCompile("^terraform-provider-dummy_"), dir)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "terraform-provider-dummy_1.0.0_SHA256SUMS"), sumsPath)
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 6)
+
+		data, err := os.ReadFile(filepath.Join(dir, "terraform-provider-dummy_1.0.0_linux_amd64.zip"))
+		assert.NoError(t, err)
+		assert.Equal(t, "linux", string(data))
+	})
+
+	t.Run("unknown release", func(t *testing.T) {
+		_, err := downloadGitHubRelease(ctx, c, "acme/terraform-provider-dummy", "v2.0.0", regexp.MustCompile(".*"), t.TempDir())
+		assert.ErrorContains(t, err, "unexpected status code 404")
+	})
+
+	t.Run("invalid repository", func(t *testing.T) {
+		_, err := downloadGitHubRelease(ctx, c, "terraform-provider-dummy", "v1.0.0", regexp.MustCompile(".*"), t.TempDir())
+		assert.ErrorContains(t, err, "must be in the form <owner>/<repo>")
+	})
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+
+	"github.com/boring-registry/boring-registry/pkg/admin"
+	"github.com/boring-registry/boring-registry/pkg/admin/adminv1"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/stats"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/go-kit/kit/endpoint"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// newGRPCServer returns the server of the gRPC admin API, or nil if --grpc-listen-address isn't set.
+// The server uses the TLS certificate of the HTTP server.
+func newGRPCServer() (*grpc.Server, error) {
+	if flagGRPCListenAddr == "" {
+		return nil, nil
+	}
+	if !authEnabled() {
+		return nil, errors.New("the gRPC admin API requires authentication to be configured")
+	}
+	if flagTenantsFile != "" {
+		return nil, errors.New("the gRPC admin API can't be enabled with --tenants-file")
+	}
+
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(flagGRPCMaxMessageSize),
+		grpc.MaxSendMsgSize(flagGRPCMaxMessageSize),
+	}
+	if flagTLSCertFile != "" || flagTLSKeyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(flagTLSCertFile, flagTLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+
+	return grpc.NewServer(options...), nil
+}
+
+func registerAdminGRPC(server *grpc.Server, s storage.Storage, authMiddleware endpoint.Middleware, recorder stats.Recorder) {
+	options := []admin.ServiceOption{
+		admin.WithOverwritePolicy(module.NewOverwritePolicy(flagAllowOverwrite)),
+	}
+	if recorder != nil {
+		options = append(options, admin.WithDownloadStats(recorder))
+	}
+
+	svc := admin.LoggingMiddleware()(admin.NewService(s, options...))
+	if flagReadOnly {
+		svc = admin.ReadOnlyMiddleware()(svc)
+	}
+	adminv1.RegisterAdminServiceServer(server, admin.NewGRPCServer(svc, authMiddleware))
+}
+
+// serveGRPC serves the gRPC admin API until the server is stopped
+func serveGRPC(server *grpc.Server) error {
+	logger := slog.Default().With(slog.String("listen", flagGRPCListenAddr))
+	logger.Info("starting gRPC server")
+	defer logger.Info("shutting down gRPC server")
+
+	listener, err := net.Listen("tcp", flagGRPCListenAddr)
+	if err != nil {
+		return err
+	}
+	// Serve returns nil once the server is stopped
+	return server.Serve(listener)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+)
+
+// setupInventory returns nil if --storage-inventory-url isn't set.
+// The reports are refreshed by the scheduler instead of the interval if the inventory-refresh task is scheduled.
+func setupInventory(ctx context.Context, schedules map[string]string) (*storage.Inventory, error) {
+	if flagStorageInventoryURL == "" {
+		return nil, nil
+	}
+
+	source, err := storage.NewBlobBackend(ctx, flagStorageInventoryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up inventory bucket %s: %w", flagStorageInventoryURL, err)
+	}
+
+	interval := flagStorageInventoryRefreshInterval
+	if _, ok := schedules[taskInventoryRefresh]; ok {
+		interval = 0
+	}
+
+	return storage.NewInventory(ctx, source, flagStorageInventoryPrefix, storage.InventoryFormat(flagStorageInventoryFormat), interval,
+		storage.WithInventoryMaxAge(flagStorageInventoryMaxAge),
+	)
+}
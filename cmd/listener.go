@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+)
+
+// errWriteListener is returned by the read listener for the administration endpoints if --listen-write-address is set
+var errWriteListener = errors.New("the administration endpoints are served on a separate listener")
+
+// apiServer returns the HTTP server of the registry API listening on addr, which resolves the clients behind the trusted proxies
+func apiServer(addr string, handler http.Handler, proxies core.TrustedProxies) *http.Server {
+	if flagServerMaxBodySize > 0 {
+		handler = core.MaxBodySize(handler, flagServerMaxBodySize)
+	}
+	if len(flagCORSAllowedOrigins) > 0 {
+		handler = core.CORS(handler, core.CORSPolicy{
+			AllowedOrigins: flagCORSAllowedOrigins,
+			AllowedMethods: flagCORSAllowedMethods,
+			AllowedHeaders: flagCORSAllowedHeaders,
+			MaxAge:         flagCORSMaxAge,
+		})
+	}
+	if flagAccessLog {
+		handler = o11y.AccessLog(handler)
+	}
+	// The client address is resolved first, so that it's logged instead of the address of the load balancer
+	handler = core.RealClientIP(handler, proxies)
+	// The deadlines are extended on the connection itself, before any wrapping of the response writer
+	if flagServerUploadTimeout > 0 {
+		handler = core.UploadTimeout(handler, flagServerUploadTimeout)
+	}
+
+	return &http.Server{
+		Addr:           addr,
+		ReadTimeout:    flagServerReadTimeout,
+		WriteTimeout:   flagServerWriteTimeout,
+		IdleTimeout:    flagServerIdleTimeout,
+		MaxHeaderBytes: flagServerMaxHeaderSize,
+		Handler:        handler,
+	}
+}
+
+// serveAPI serves the registry API with TLS if configured until the server is shut down
+func serveAPI(server *http.Server, name string) error {
+	logger := slog.Default().With(slog.String("listen", server.Addr))
+	logger.Info("starting " + name)
+	defer logger.Info("shutting down " + name)
+
+	var err error
+	if flagTLSCertFile != "" || flagTLSKeyFile != "" {
+		err = server.ListenAndServeTLS(flagTLSCertFile, flagTLSKeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// withoutAdmin responds with 404 Not Found to requests below one of the administration prefixes,
+// so that the read listener doesn't serve uploads, deletions, and the other mutating endpoints
+func withoutAdmin(handler http.Handler, adminPrefixes []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range adminPrefixes {
+			if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+				core.HandleErrorResponse(errWriteListener, http.StatusNotFound, w)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
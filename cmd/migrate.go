@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagMigrateFrom        string
+	flagMigrateTo          string
+	flagMigrateConcurrency int
+
+	// migrate tfe flags
+	flagTFEAddress      string
+	flagTFEToken        string
+	flagTFEOrganization string
+	flagTFENamespace    string
+	flagTFEModules      bool
+	flagTFEProviders    bool
+)
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateStorageCmd, migrateTFECmd)
+
+	migrateStorageCmd.Flags().StringVar(&flagMigrateFrom, "from", "", "Bucket URL of the storage to migrate from, e.g. s3://boring-registry?region=eu-central-1")
+	migrateStorageCmd.Flags().StringVar(&flagMigrateTo, "to", "", "Bucket URL of the storage to migrate to, e.g. gs://boring-registry")
+	migrateStorageCmd.Flags().IntVar(&flagMigrateConcurrency, "concurrency", storage.DefaultMigrationConcurrency, "Number of objects copied in parallel")
+
+	migrateTFECmd.Flags().StringVar(&flagTFEAddress, "tfe-address", defaultTFEAddress, "Address of Terraform Cloud or the Terraform Enterprise instance")
+	migrateTFECmd.Flags().StringVar(&flagTFEToken, "tfe-token", "", "Team or user token with read access to the private registry, defaults to the TFE_TOKEN environment variable")
+	migrateTFECmd.Flags().StringVar(&flagTFEOrganization, "tfe-organization", "", "Organization whose private registry is imported")
+	migrateTFECmd.Flags().StringVar(&flagTFENamespace, "namespace", "", "Namespace the modules and providers are imported into, defaults to the namespace in the private registry, which is the organization")
+	migrateTFECmd.Flags().BoolVar(&flagTFEModules, "modules", true, "Import the private modules")
+	migrateTFECmd.Flags().BoolVar(&flagTFEProviders, "providers", true, "Import the private providers and their signing keys")
+	if err := migrateTFECmd.MarkFlagRequired("tfe-organization"); err != nil {
+		panic(fmt.Errorf("failed to mark flag tfe-organization as required: %w", err))
+	}
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate the registry",
+}
+
+var migrateStorageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Copy all objects of the registry from one storage backend to another",
+	Long: `Copy all modules, providers, signing keys, and metadata from one storage backend to another, e.g. when switching cloud providers.
+The backends are configured with bucket URLs of the Go CDK, see https://gocloud.dev/howto/blob/.
+Every copied object is verified with its checksum. Objects which exist in the target with the same size are skipped,
+so that an interrupted migration is resumed by running the command again.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         migrateStorage,
+}
+
+var migrateTFECmd = &cobra.Command{
+	Use:   "tfe",
+	Short: "Import the private registry of a Terraform Cloud/Enterprise organization",
+	Long: `Import all versions of the private modules and providers of a Terraform Cloud/Enterprise organization into the storage.
+The GPG keys of the organization are added to the signing keys of the namespace, and every provider release is verified
+with its signature and the checksums of its SHA256SUMS file before it's uploaded.
+Versions which exist in the storage already are skipped, so that an interrupted import is resumed by running the command again.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         migrateTFE,
+}
+
+func migrateStorage(cmd *cobra.Command, args []string) error {
+	if flagMigrateFrom == "" || flagMigrateTo == "" {
+		return errors.New("both --from and --to have to be set")
+	}
+	if flagMigrateFrom == flagMigrateTo {
+		return errors.New("--from and --to have to be different storage backends")
+	}
+
+	ctx := context.Background()
+	source, err := storage.NewBlobBackend(ctx, flagMigrateFrom)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage %s: %w", flagMigrateFrom, err)
+	}
+	target, err := storage.NewBlobBackend(ctx, flagMigrateTo)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage %s: %w", flagMigrateTo, err)
+	}
+
+	// The result is logged by the migrator
+	result, err := storage.NewMigrator(source, target, storage.WithMigrationConcurrency(flagMigrateConcurrency)).Migrate(ctx)
+	return printResult(result, err, nil)
+}
+
+func migrateTFE(cmd *cobra.Command, args []string) error {
+	token := flagTFEToken
+	if token == "" {
+		token = os.Getenv("TFE_TOKEN")
+	}
+	if token == "" {
+		return errors.New("a token has to be set with --tfe-token or TFE_TOKEN")
+	}
+
+	ctx := context.Background()
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+	s, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "boring-registry-tfe-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	importer := &tfeImporter{
+		client: &tfeClient{
+			client:       &http.Client{Timeout: 10 * time.Minute},
+			address:      flagTFEAddress,
+			token:        token,
+			organization: flagTFEOrganization,
+		},
+		storage:   s,
+		namespace: flagTFENamespace,
+		dir:       dir,
+	}
+
+	var result tfeImportResult
+	var errs []error
+	if flagTFEModules {
+		errs = append(errs, importer.importModules(ctx, &result))
+	}
+	if flagTFEProviders {
+		errs = append(errs, importer.importProviders(ctx, &result))
+	}
+
+	slog.Info("imported private registry",
+		slog.String("organization", flagTFEOrganization),
+		slog.Int("modules", result.Modules),
+		slog.Int("providers", result.Providers),
+		slog.Int("skipped", result.Skipped),
+	)
+	return printResult(result, errors.Join(errs...), nil)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagSnippetHostnameName = "hostname"
+)
+
+var (
+	// module snippet flags
+	flagSnippetHostname  string
+	flagSnippetVersion   string
+	flagSnippetWorkspace string
+)
+
+func init() {
+	rootCmd.AddCommand(moduleCmd)
+	moduleCmd.AddCommand(moduleSnippetCmd)
+
+	moduleSnippetCmd.Flags().StringVar(&flagSnippetHostname, flagSnippetHostnameName, "", "The hostname under which the boring-registry is reachable by Terraform/OpenTofu")
+	moduleSnippetCmd.Flags().StringVar(&flagSnippetVersion, "version", "", "The module version to use in the snippet. Defaults to the latest version")
+	moduleSnippetCmd.Flags().StringVar(&flagSnippetWorkspace, "workspace", "terraform", "The workspace type to generate the snippet for (terraform, opentofu, terragrunt or all)")
+	if err := moduleSnippetCmd.MarkFlagRequired(flagSnippetHostnameName); err != nil {
+		panic(fmt.Errorf("failed to mark flag %s as required: %w", flagSnippetHostnameName, err))
+	}
+}
+
+var moduleCmd = &cobra.Command{
+	Use:   "module",
+	Short: "Inspect and manage modules in the storage backend",
+}
+
+var moduleSnippetCmd = &cobra.Command{
+	Use:          "snippet NAMESPACE/NAME/PROVIDER",
+	Short:        "Print a ready-to-paste usage snippet of a module",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         moduleSnippet,
+}
+
+func moduleSnippet(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid module %q, expected NAMESPACE/NAME/PROVIDER", args[0])
+	}
+	namespace, name, provider := parts[0], parts[1], parts[2]
+
+	ctx := context.Background()
+	storageBackend, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	modules, err := storageBackend.ListModuleVersions(ctx, namespace, name, provider)
+	if err != nil {
+		return err
+	}
+
+	m, err := module.LatestVersion(modules)
+	if err != nil {
+		return err
+	}
+	if flagSnippetVersion != "" {
+		if _, err := storageBackend.GetModule(ctx, namespace, name, provider, flagSnippetVersion); err != nil {
+			return err
+		}
+		m.Version = flagSnippetVersion
+	}
+
+	snippets := module.NewSnippets(flagSnippetHostname, m)
+	if flagSnippetWorkspace == "all" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snippets)
+	}
+
+	snippet, err := snippets.Get(flagSnippetWorkspace)
+	if err != nil {
+		return err
+	}
+	fmt.Print(snippet)
+	return nil
+}
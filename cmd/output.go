@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// commandOutput is written to stdout with --output json, so that CI can gate on the result instead of parsing the logs.
+// The errors are included even if the command failed, the exit code is non-zero in that case.
+type commandOutput struct {
+	Result any      `json:"result"`
+	Errors []string `json:"errors"`
+}
+
+func validateOutput() error {
+	if flagOutput != outputText && flagOutput != outputJSON {
+		return fmt.Errorf("invalid --output %q, expected text or json", flagOutput)
+	}
+	return nil
+}
+
+// printResult writes the result and the error of the command as JSON to stdout with --output json.
+// Otherwise text is called to print the result for humans, unless it's nil. The error is returned in both cases.
+func printResult(result any, err error, text func()) error {
+	if flagOutput != outputJSON {
+		if text != nil {
+			text()
+		}
+		return err
+	}
+
+	output := commandOutput{
+		Result: result,
+		Errors: []string{},
+	}
+	if err != nil {
+		output.Errors = errorMessages(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(output); encodeErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to write the output: %w", encodeErr))
+	}
+	return err
+}
+
+// errorMessages lists the errors of errors.Join separately, e.g. the failed versions of an import.
+// Errors wrapping multiple errors with fmt.Errorf are kept as a single message.
+func errorMessages(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	var messages []string
+	for _, e := range joined.Unwrap() {
+		messages = append(messages, errorMessages(e)...)
+	}
+	if strings.Join(messages, "\n") != err.Error() {
+		return []string{err.Error()}
+	}
+	return messages
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMessages(t *testing.T) {
+	errA, errB, errC := errors.New("a"), errors.New("b"), errors.New("c")
+
+	assert.Equal(t, []string{"a"}, errorMessages(errA))
+	assert.Equal(t, []string{"a", "b", "c"}, errorMessages(errors.Join(errA, errors.Join(errB, errC))))
+	// Errors wrapping multiple errors aren't split
+	assert.Equal(t, []string{"a: b"}, errorMessages(fmt.Errorf("%w: %w", errA, errB)))
+}
+
+func TestArchiveModules_Result(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "vpc")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("main"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, moduleSpecFileName), []byte(`metadata {
+  namespace = "acme"
+  name      = "vpc"
+  provider  = "aws"
+  version   = "1.0.0"
+}
+`), 0644))
+
+	s := storage.NewMemoryStorage()
+	result, err := archiveModules(root, s)
+	assert.NoError(t, err)
+	assert.Len(t, result.Published, 1)
+	assert.Empty(t, result.Skipped)
+	assert.Equal(t, "1.0.0", result.Published[0].Version)
+	assert.Equal(t, dir, result.Published[0].Path)
+	assert.NotEmpty(t, result.Published[0].Checksum)
+
+	// Existing versions are skipped with --ignore-existing
+	result, err = archiveModules(root, s)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Published)
+	assert.Len(t, result.Skipped, 1)
+	assert.Equal(t, "already exists", result.Skipped[0].Reason)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// module approve and promote flags
+	flagPromotionRequiredApprovals int
+	flagReviewer                   string
+)
+
+func init() {
+	moduleCmd.AddCommand(moduleApproveCmd)
+	moduleCmd.AddCommand(modulePromoteCmd)
+
+	moduleApproveCmd.Flags().StringVar(&flagReviewer, "reviewer", defaultPublisher(), "Identity of the reviewer approving the module version, which must differ from its publisher")
+	modulePromoteCmd.Flags().IntVar(&flagPromotionRequiredApprovals, "promotion-required-approvals", 0, "Number of distinct reviewers who have to approve the module version before it can be promoted")
+}
+
+var moduleApproveCmd = &cobra.Command{
+	Use:          "approve NAMESPACE/NAME/PROVIDER VERSION",
+	Short:        "Approve a staged module version",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := core.WithIdentity(context.Background(), flagReviewer)
+		return withPromoter(ctx, args[0], args[1], func(p *module.Promoter, namespace, name, provider, version string) error {
+			staging, err := p.Approve(ctx, namespace, name, provider, version, flagReviewer)
+			if err != nil {
+				return err
+			}
+			slog.Info("module version approved", slog.String("module", args[0]), slog.String("version", version), slog.Any("reviewers", staging.Reviewers()))
+			return nil
+		})
+	},
+}
+
+var modulePromoteCmd = &cobra.Command{
+	Use:   "promote NAMESPACE/NAME/PROVIDER VERSION",
+	Short: "Publish a staged module version",
+	Long: `Publish a staged module version, which is listed and can be downloaded afterwards.
+The version has to be approved by the number of reviewers set with --promotion-required-approvals first.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := core.WithIdentity(context.Background(), defaultPublisher())
+		return withPromoter(ctx, args[0], args[1], func(p *module.Promoter, namespace, name, provider, version string) error {
+			if _, err := p.Promote(ctx, namespace, name, provider, version); err != nil {
+				return err
+			}
+			slog.Info("module version promoted", slog.String("module", args[0]), slog.String("version", version))
+			return nil
+		})
+	},
+}
+
+// withPromoter calls fn with the Promoter of the storage and the module version referenced as NAMESPACE/NAME/PROVIDER
+func withPromoter(ctx context.Context, ref, version string, fn func(p *module.Promoter, namespace, name, provider, version string) error) error {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid module %q, expected NAMESPACE/NAME/PROVIDER", ref)
+	}
+
+	// The staging markers are written to the replication targets as well, so that staged versions are hidden there
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+
+	storageBackend, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+	stagingStorage, ok := storageBackend.(module.StagingStorage)
+	if !ok {
+		return errors.New("the storage backend doesn't support staging modules")
+	}
+
+	return fn(module.NewPromoter(stagingStorage, flagPromotionRequiredApprovals), parts[0], parts[1], parts[2], version)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// mutatingCommands change the storage and fail in read-only mode, which applies to their subcommands as well
+var mutatingCommands = []*cobra.Command{
+	uploadCmd,
+	deleteCmd,
+	restoreCmd,
+	gcCmd,
+	channelSetCmd,
+	channelDeleteCmd,
+	importCmd,
+	backfillCmd,
+	migrateStorageCmd,
+	migrateTFECmd,
+	moduleApproveCmd,
+	modulePromoteCmd,
+}
+
+// checkReadOnly returns core.ErrReadOnly if the command changes the storage in read-only mode
+func checkReadOnly(cmd *cobra.Command) error {
+	if !flagReadOnly {
+		return nil
+	}
+
+	for c := cmd; c != nil; c = c.Parent() {
+		for _, mutating := range mutatingCommands {
+			if c == mutating {
+				return fmt.Errorf("%s: %w", cmd.CommandPath(), core.ErrReadOnly)
+			}
+		}
+	}
+	return nil
+}
+
+// readOnly rejects requests changing the registry with 403 Forbidden if the server runs in read-only mode
+func readOnly(handler http.Handler) http.Handler {
+	if !flagReadOnly {
+		return handler
+	}
+	return core.ReadOnly(handler)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	t.Cleanup(func() { flagReadOnly = false })
+
+	flagReadOnly = false
+	assert.NoError(t, checkReadOnly(uploadModuleCmd))
+
+	flagReadOnly = true
+	for _, cmd := range []*cobra.Command{uploadModuleCmd, uploadProviderCmd, uploadModuleAttestationCmd, deleteCmd, restoreCmd, gcCmd, channelSetCmd, channelDeleteCmd} {
+		assert.ErrorIs(t, checkReadOnly(cmd), core.ErrReadOnly, cmd.CommandPath())
+	}
+	for _, cmd := range []*cobra.Command{serverCmd, channelListCmd, reindexCmd, replicateCmd, verifyCmd, moduleSnippetCmd} {
+		assert.NoError(t, checkReadOnly(cmd), cmd.CommandPath())
+	}
+
+	rec := httptest.NewRecorder()
+	readOnly(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/providers/hashicorp/random/channels/stable", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
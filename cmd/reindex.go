@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var flagReindexFailOnDrift bool
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+
+	reindexCmd.Flags().BoolVar(&flagReindexFailOnDrift, "fail-on-drift", false, "Exit with an error if any object deviates from the storage layout")
+}
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Walk the storage and report objects deviating from the storage layout",
+	Long: `Walk the storage, count the stored module and provider versions, and report objects deviating from the storage layout.
+This validates the storage after objects have been changed manually, e.g. archives without their checksum or objects outside of the layout.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         reindexStorage,
+}
+
+func reindexStorage(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	s, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	reindexer, ok := s.(storage.Reindexer)
+	if !ok {
+		return errors.New("the storage backend doesn't support re-indexing")
+	}
+
+	// The drift and the result are logged by the reindexer
+	result, err := reindexer.Reindex(ctx)
+	if err != nil {
+		return err
+	}
+
+	if flagReindexFailOnDrift && len(result.Drift) > 0 {
+		return fmt.Errorf("%d objects deviate from the storage layout", len(result.Drift))
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// configFile is the path of the config file, which is empty if none is used
+	configFile string
+
+	// loadedConfig holds the values of the config file by flag name, as they were last applied
+	loadedConfig map[string]interface{}
+
+	// commandLineFlags holds the names of the flags set on the command line, which aren't changed by reloads
+	commandLineFlags = make(map[string]struct{})
+
+	// reloadMu serializes reloads, as they change the global flags
+	reloadMu sync.Mutex
+)
+
+// reloadableFlags can be changed at runtime, changes to other flags require a restart
+var reloadableFlags = []string{
+	"auth-static-token",
+	"debug",
+	"log-component-levels",
+}
+
+// reloadHooks apply the reloaded flags to the running server
+type reloadHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+func (r *reloadHooks) add(hook func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+func (r *reloadHooks) run() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, hook := range r.hooks {
+		hook()
+	}
+}
+
+// reloadConfig reads the config file again and applies the reloadable flags.
+// Flags set on the command line or by environment variables keep their values, as they take precedence over the config file.
+// The previous configuration is kept if the config file is invalid.
+func reloadConfig(flags *pflag.FlagSet, known map[string]struct{}, hooks *reloadHooks) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if configFile == "" {
+		return fmt.Errorf("no config file is configured")
+	}
+
+	config, err := readConfigFile(configFile, known)
+	if err != nil {
+		return err
+	}
+
+	reloadable := make(map[string]struct{}, len(reloadableFlags))
+	for _, name := range reloadableFlags {
+		reloadable[name] = struct{}{}
+	}
+	if changed := changedKeys(loadedConfig, config, reloadable); len(changed) > 0 {
+		slog.Warn("config changes require a restart to take effect", slog.Any("keys", changed))
+	}
+
+	for _, name := range reloadableFlags {
+		f := flags.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if _, ok := commandLineFlags[name]; ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(envVarName(name)); ok {
+			continue
+		}
+
+		if value, ok := config[name]; ok {
+			if err = setConfigValue(f, value); err == nil {
+				err = resolveFlagSecrets(context.Background(), f)
+			}
+		} else {
+			err = resetFlag(f)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value of key %q in config file: %w", name, err)
+		}
+	}
+
+	loadedConfig = config
+	hooks.run()
+	if err := setLogLevel(); err != nil {
+		return err
+	}
+	slog.Info("reloaded config file", slog.String("path", configFile))
+
+	return nil
+}
+
+// changedKeys returns the keys whose values differ between both configs and which aren't reloadable
+func changedKeys(previous, current map[string]interface{}, reloadable map[string]struct{}) []string {
+	var changed []string
+	for _, config := range []map[string]interface{}{previous, current} {
+		for name := range config {
+			if _, ok := reloadable[name]; ok {
+				continue
+			}
+			if !reflect.DeepEqual(previous[name], current[name]) && !contains(changed, name) {
+				changed = append(changed, name)
+			}
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// resetFlag restores the default value of a flag, which was removed from the config file
+func resetFlag(f *pflag.Flag) error {
+	if s, ok := f.Value.(pflag.SliceValue); ok {
+		if err := s.Replace(nil); err != nil {
+			return err
+		}
+	} else if err := f.Value.Set(f.DefValue); err != nil {
+		return err
+	}
+
+	f.Changed = false
+	return nil
+}
+
+func envVarName(flag string) string {
+	return fmt.Sprintf("%s_%s", envPrefix, strings.ToUpper(strings.ReplaceAll(flag, "-", "_")))
+}
+
+// watchConfig calls reload whenever the content of the config file changes.
+// The directory is watched instead of the file, as editors and Kubernetes replace config files instead of writing them.
+func watchConfig(ctx context.Context, path string, reload func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	checksum := fileChecksum(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Error("failed to watch config file", slog.String("err", err.Error()))
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			// Events of other files in the directory are ignored, as long as the content of the config file is unchanged
+			if current := fileChecksum(path); current != nil && !bytes.Equal(current, checksum) {
+				checksum = current
+				reload()
+			}
+		}
+	}
+}
+
+func fileChecksum(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadConfig(t *testing.T) {
+	cmd, bucket, tokens, _ := testConfigCommand()
+	known := knownFlags(cmd.Root())
+
+	configFile = writeConfigFile(t, "config.yaml", "storage-s3-bucket: registry\nauth-static-token: [token-a]\n")
+	t.Cleanup(func() { configFile, loadedConfig = "", nil })
+
+	var err error
+	loadedConfig, err = readConfigFile(configFile, known)
+	assert.NoError(t, err)
+	assert.NoError(t, bindFlags(cmd, viper.New(), loadedConfig))
+
+	var reloaded int
+	hooks := &reloadHooks{}
+	hooks.add(func() { reloaded++ })
+
+	// Only reloadable flags are changed
+	assert.NoError(t, os.WriteFile(configFile, []byte("storage-s3-bucket: other\nauth-static-token: [token-b, token-c]\n"), 0o600))
+	assert.NoError(t, reloadConfig(cmd.Flags(), known, hooks))
+	assert.Equal(t, "registry", *bucket)
+	assert.Equal(t, []string{"token-b", "token-c"}, *tokens)
+	assert.Equal(t, 1, reloaded)
+
+	// Removed keys are reset to their default
+	assert.NoError(t, os.WriteFile(configFile, []byte("storage-s3-bucket: other\n"), 0o600))
+	assert.NoError(t, reloadConfig(cmd.Flags(), known, hooks))
+	assert.Empty(t, *tokens)
+	assert.Equal(t, 2, reloaded)
+
+	// The previous configuration is kept if the config file is invalid
+	assert.NoError(t, os.WriteFile(configFile, []byte("auth-static-token: [token-d]\nunknown: value\n"), 0o600))
+	assert.ErrorContains(t, reloadConfig(cmd.Flags(), known, hooks), "unknown key")
+	assert.Empty(t, *tokens)
+	assert.Equal(t, 2, reloaded)
+}
+
+func TestReloadConfig_Precedence(t *testing.T) {
+	cmd, _, tokens, _ := testConfigCommand()
+	known := knownFlags(cmd.Root())
+
+	configFile = writeConfigFile(t, "config.yaml", "auth-static-token: [token-a]\n")
+	t.Cleanup(func() { configFile, loadedConfig = "", nil })
+	commandLineFlags["auth-static-token"] = struct{}{}
+	t.Cleanup(func() { delete(commandLineFlags, "auth-static-token") })
+	assert.NoError(t, cmd.Flags().Set("auth-static-token", "from-flag"))
+
+	assert.NoError(t, reloadConfig(cmd.Flags(), known, &reloadHooks{}))
+	assert.Equal(t, []string{"from-flag"}, *tokens)
+
+	delete(commandLineFlags, "auth-static-token")
+	t.Setenv("BORING_REGISTRY_AUTH_STATIC_TOKEN", "from-env")
+	assert.NoError(t, reloadConfig(cmd.Flags(), known, &reloadHooks{}))
+	assert.Equal(t, []string{"from-flag"}, *tokens)
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "debug: false\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- watchConfig(ctx, path, func() { reloaded <- struct{}{} })
+	}()
+
+	// Give the watcher time to start, as changes before are missed
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(path, []byte("debug: true\n"), 0o600))
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("config file wasn't reloaded")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
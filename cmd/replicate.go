@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(replicateCmd)
+}
+
+var replicateCmd = &cobra.Command{
+	Use:   "replicate",
+	Short: "Copy all objects of the storage backend to the replication targets",
+	Long: `Copy all objects of the storage backend to the replication targets configured with --replication-target.
+Objects which are missing in a target or differ in size are copied, e.g. to backfill a newly added target.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         replicate,
+}
+
+func replicate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	replicator, err := setupReplicator(ctx)
+	if err != nil {
+		return err
+	}
+	if replicator == nil {
+		return errors.New("no replication targets are configured, use --replication-target")
+	}
+	defer replicator.Close()
+
+	if _, err := setupStorage(ctx, replicator.Decorator()); err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	// The result is logged by the replicator
+	_, err = replicator.Reconcile(ctx)
+	return err
+}
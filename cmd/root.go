@@ -13,6 +13,12 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/boring-registry/boring-registry/pkg/admission"
+	"github.com/boring-registry/boring-registry/pkg/lock"
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/quota"
+	"github.com/boring-registry/boring-registry/pkg/scan"
 	"github.com/boring-registry/boring-registry/pkg/storage"
 )
 
@@ -22,16 +28,27 @@ const (
 )
 
 var (
-	flagJSON  bool
-	flagDebug bool
+	flagConfigFile         string
+	flagJSON               bool
+	flagDebug              bool
+	flagLogComponentLevels []string
+	flagReadOnly           bool
+	flagOutput             string
 
 	// S3 options.
-	flagS3Bucket          string
-	flagS3Prefix          string
-	flagS3Region          string
-	flagS3Endpoint        string
-	flagS3PathStyle       bool
-	flagS3SignedURLExpiry time.Duration
+	flagS3Bucket            string
+	flagS3Prefix            string
+	flagS3Region            string
+	flagS3Endpoint          string
+	flagS3PathStyle         bool
+	flagS3Accelerate        bool
+	flagS3DualStack         bool
+	flagS3RequestPayer      bool
+	flagS3ConditionalWrites bool
+	flagS3SignedURLExpiry   time.Duration
+	flagS3RoleARN           string
+	flagS3ExternalID        string
+	flagS3SessionName       string
 
 	// GCS options.
 	flagGCSBucket          string
@@ -44,6 +61,71 @@ var (
 	flagAzureStorageContainer       string
 	flagAzureStoragePrefix          string
 	flagAzureStorageSignedURLExpiry time.Duration
+
+	// Generic blob storage
+	flagStorageURL                string
+	flagStorageURLSignedURLExpiry time.Duration
+	flagStorageURLBaseURL         string
+
+	// Artifactory or Nexus repository storage
+	flagRepositoryURL             string
+	flagRepositoryType            string
+	flagRepositoryPrefix          string
+	flagRepositoryAPIKey          string
+	flagRepositoryToken           string
+	flagRepositoryUsername        string
+	flagRepositoryPassword        string
+	flagRepositorySignedURLExpiry time.Duration
+
+	// OCI registry storage
+	flagOCIRepository      string
+	flagOCIUsername        string
+	flagOCIPassword        string
+	flagOCIPlainHTTP       bool
+	flagOCISignedURLExpiry time.Duration
+	flagOCISignedURLSecret string
+
+	// In-memory storage
+	flagStorageInmem bool
+
+	// Storage retries
+	flagStorageRetryMaxAttempts    int
+	flagStorageRetryMaxElapsedTime time.Duration
+
+	// Storage replication
+	flagReplicationTargets   []string
+	flagNamespaceTargets     map[string]string
+	flagReplicationMode      string
+	flagReplicationQueueSize int
+
+	// Quotas
+	flagPolicyFile string
+
+	// Provider scanning
+	flagProviderScanHook       string
+	flagProviderScanTimeout    time.Duration
+	flagProviderScanQuarantine bool
+
+	// Admission control
+	flagAdmissionPolicyURL string
+	flagAdmissionTimeout   time.Duration
+
+	// Publish locking
+	flagPublishLockURL     string
+	flagPublishLockTimeout time.Duration
+	flagPublishLockTTL     time.Duration
+
+	// Module digests
+	flagModuleImmutableDigests bool
+
+	// Object tagging options
+	flagStorageTagging bool
+	flagStorageTags    map[string]string
+
+	// Storage HTTP clients
+	flagStorageHTTPMaxIdleConnsPerHost int
+	flagStorageHTTPIdleConnTimeout     time.Duration
+	flagStorageHTTP2                   bool
 )
 
 var rootCmd = &cobra.Command{
@@ -54,32 +136,67 @@ var rootCmd = &cobra.Command{
 			return err
 		}
 
-		setupLogger()
+		if err := setupLogger(); err != nil {
+			return err
+		}
+		if err := validateOutput(); err != nil {
+			return err
+		}
 
 		if flagDebug {
 			slog.Debug("debug mode enabled")
 		}
 
-		return nil
+		return checkReadOnly(cmd)
 	},
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
+
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError exits the command with a specific exit code instead of 1
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&flagConfigFile, "config", "", "Path to a YAML, TOML, or JSON config file with the values of flags, which are overridden by environment variables and flags")
 	rootCmd.PersistentFlags().BoolVar(&flagJSON, "json", false, "Enable json logging")
 	rootCmd.PersistentFlags().BoolVar(&flagDebug, "debug", false, "Enable debug logging")
+	rootCmd.PersistentFlags().StringVar(&flagOutput, "output", outputText, "Format of the results printed to stdout by the upload, migrate, delete, restore, gc, and list commands, either text or json. Logs are written to stderr")
+	rootCmd.PersistentFlags().StringSliceVar(&flagLogComponentLevels, "log-component-levels", nil, "Log levels of single components in the form component=level overriding the default level, e.g. storage=debug,scheduler=warn")
+	rootCmd.PersistentFlags().BoolVar(&flagReadOnly, "read-only", false, "Disable all changes to the registry, the server rejects them with 403 Forbidden and commands changing the storage fail")
 	rootCmd.PersistentFlags().StringVar(&flagS3Bucket, "storage-s3-bucket", "", "S3 bucket to use for the registry")
 	rootCmd.PersistentFlags().StringVar(&flagS3Prefix, "storage-s3-prefix", "", "S3 bucket prefix to use for the registry")
 	rootCmd.PersistentFlags().StringVar(&flagS3Region, "storage-s3-region", "", "S3 bucket region to use for the registry")
 	rootCmd.PersistentFlags().StringVar(&flagS3Endpoint, "storage-s3-endpoint", "", "S3 bucket endpoint URL (required for MINIO)")
 	rootCmd.PersistentFlags().BoolVar(&flagS3PathStyle, "storage-s3-pathstyle", false, "S3 use PathStyle (required for MINIO)")
+	rootCmd.PersistentFlags().BoolVar(&flagS3Accelerate, "storage-s3-accelerate", false, "S3 use Transfer Acceleration endpoints, which need to be enabled on the bucket")
+	rootCmd.PersistentFlags().BoolVar(&flagS3DualStack, "storage-s3-dualstack", false, "S3 use dual-stack endpoints supporting IPv6")
+	rootCmd.PersistentFlags().BoolVar(&flagS3RequestPayer, "storage-s3-requester-pays", false, "S3 pay for the requests to a requester-pays bucket, including downloads through signed URLs")
+	rootCmd.PersistentFlags().BoolVar(&flagS3ConditionalWrites, "storage-s3-conditional-writes", true, "S3 reject uploads of existing modules and providers atomically with conditional writes, which has to be disabled for S3-compatible storages not supporting the If-None-Match header")
 	rootCmd.PersistentFlags().DurationVar(&flagS3SignedURLExpiry, "storage-s3-signedurl-expiry", 5*time.Minute, "Generate S3 signed URL valid for X seconds.")
+	rootCmd.PersistentFlags().StringVar(&flagS3RoleARN, "storage-s3-role-arn", "", "ARN of the IAM role to assume for accessing the S3 bucket, e.g. in another account")
+	rootCmd.PersistentFlags().StringVar(&flagS3ExternalID, "storage-s3-external-id", "", "External ID to use when assuming the IAM role")
+	rootCmd.PersistentFlags().StringVar(&flagS3SessionName, "storage-s3-session-name", "boring-registry", "Session name to use when assuming the IAM role")
 	rootCmd.PersistentFlags().StringVar(&flagGCSBucket, "storage-gcs-bucket", "", "Bucket to use when using the GCS registry type")
 	rootCmd.PersistentFlags().StringVar(&flagGCSPrefix, "storage-gcs-prefix", "", "Prefix to use when using the GCS registry type")
 	rootCmd.PersistentFlags().StringVar(&flagGCSServiceAccount, "storage-gcs-sa-email", "", `Google service account email to be used for Application Default Credentials (ADC).
@@ -90,20 +207,93 @@ For GCS presigned URLs this SA needs the iam.serviceAccountTokenCreator role.`)
 	rootCmd.PersistentFlags().StringVar(&flagAzureStorageContainer, "storage-azure-container", "", "Azure Storage Container to use for the registry")
 	rootCmd.PersistentFlags().StringVar(&flagAzureStoragePrefix, "storage-azure-prefix", "", "Azure Storage prefix to use for the registry")
 	rootCmd.PersistentFlags().DurationVar(&flagAzureStorageSignedURLExpiry, "storage-azure-signedurl-expiry", 5*time.Minute, "Generate Azure Storage signed URL valid for X seconds.")
+	rootCmd.PersistentFlags().StringVar(&flagStorageURL, "storage-url", "", `Bucket URL of any gocloud.dev/blob driver to use for the registry, e.g. s3://bucket, gs://bucket, azblob://container, file:///path, or mem://.
+See https://gocloud.dev/howto/blob/ for the supported URL parameters, a prefix can be configured with the prefix parameter`)
+	rootCmd.PersistentFlags().DurationVar(&flagStorageURLSignedURLExpiry, "storage-url-signedurl-expiry", 5*time.Minute, "Generate signed URL valid for X seconds when using the storage URL")
+	rootCmd.PersistentFlags().StringVar(&flagStorageURLBaseURL, "storage-url-base-url", "", "URL the objects of the storage URL are served from, used if the driver doesn't support signed URLs and by the download proxy")
+	rootCmd.PersistentFlags().StringVar(&flagRepositoryURL, "storage-repository-url", "", "URL of an Artifactory generic repository or a Nexus raw repository to use for the registry, e.g. https://acme.jfrog.io/artifactory/terraform")
+	rootCmd.PersistentFlags().StringVar(&flagRepositoryType, "storage-repository-type", string(storage.RepositoryTypeArtifactory), "Type of the repository manager, either artifactory or nexus")
+	rootCmd.PersistentFlags().StringVar(&flagRepositoryPrefix, "storage-repository-prefix", "", "Repository path prefix to use for the registry")
+	rootCmd.PersistentFlags().StringVar(&flagRepositoryAPIKey, "storage-repository-api-key", "", "Artifactory API key to authenticate with the repository")
+	rootCmd.PersistentFlags().StringVar(&flagRepositoryToken, "storage-repository-token", "", "Access token to authenticate with the repository")
+	rootCmd.PersistentFlags().StringVar(&flagRepositoryUsername, "storage-repository-username", "", "Username to authenticate with the repository")
+	rootCmd.PersistentFlags().StringVar(&flagRepositoryPassword, "storage-repository-password", "", "Password to authenticate with the repository")
+	rootCmd.PersistentFlags().DurationVar(&flagRepositorySignedURLExpiry, "storage-repository-signedurl-expiry", 0, "Generate Artifactory signed URL valid for X seconds, the plain artifact URLs are used if 0")
+	rootCmd.PersistentFlags().StringVar(&flagOCIRepository, "storage-oci-repository", "", "OCI repository below which modules and providers are stored as OCI artifacts, e.g. ghcr.io/acme/terraform")
+	rootCmd.PersistentFlags().StringVar(&flagOCIUsername, "storage-oci-username", "", "Username to authenticate with the OCI registry, the Docker credentials are used if empty")
+	rootCmd.PersistentFlags().StringVar(&flagOCIPassword, "storage-oci-password", "", "Password or token to authenticate with the OCI registry")
+	rootCmd.PersistentFlags().BoolVar(&flagOCIPlainHTTP, "storage-oci-plain-http", false, "Access the OCI registry via HTTP instead of HTTPS")
+	rootCmd.PersistentFlags().DurationVar(&flagOCISignedURLExpiry, "storage-oci-signedurl-expiry", 5*time.Minute, "Generate signed URL valid for X seconds, under which the registry serves the OCI artifacts")
+	rootCmd.PersistentFlags().StringVar(&flagOCISignedURLSecret, "storage-oci-signedurl-secret", "", "Secret used to sign the URLs of the OCI artifacts, a random secret is generated if empty")
+	rootCmd.PersistentFlags().BoolVar(&flagStorageInmem, "storage-inmem", false, "Keep modules and providers in memory, which is useful for tests and demos as all data is lost on restart")
+	rootCmd.PersistentFlags().IntVar(&flagStorageRetryMaxAttempts, "storage-retry-max-attempts", storage.DefaultRetryMaxAttempts, "Maximum number of attempts of storage operations failing with transient errors, retries are disabled with 1")
+	rootCmd.PersistentFlags().DurationVar(&flagStorageRetryMaxElapsedTime, "storage-retry-max-elapsed-time", storage.DefaultRetryMaxElapsedTime, "Maximum time spent on retrying a storage operation, unlimited if 0")
+	rootCmd.PersistentFlags().IntVar(&flagStorageHTTPMaxIdleConnsPerHost, "storage-http-max-idle-conns-per-host", storage.DefaultHTTPMaxIdleConnsPerHost, "Number of idle connections per host kept open for reuse by the S3 and GCS clients and the download proxy")
+	rootCmd.PersistentFlags().DurationVar(&flagStorageHTTPIdleConnTimeout, "storage-http-idle-conn-timeout", storage.DefaultHTTPIdleConnTimeout, "Time after which idle connections of the S3 and GCS clients and the download proxy are closed")
+	rootCmd.PersistentFlags().BoolVar(&flagStorageHTTP2, "storage-http2", true, "Use HTTP/2 for storage endpoints supporting it, which multiplexes requests over a single connection")
+	rootCmd.PersistentFlags().StringSliceVar(&flagReplicationTargets, "replication-target", nil, "Bucket URL of a gocloud.dev/blob driver to which all uploads are replicated, e.g. s3://bucket?region=eu-west-1")
+	rootCmd.PersistentFlags().StringVar(&flagReplicationMode, "replication-mode", string(storage.ReplicationModeSync), "Replicate uploads synchronously (sync), failing the upload if a target fails, or in the background (async)")
+	rootCmd.PersistentFlags().IntVar(&flagReplicationQueueSize, "replication-queue-size", storage.DefaultReplicationQueueSize, "Number of uploads waiting for asynchronous replication, further uploads are left to the reconciliation")
+	rootCmd.PersistentFlags().StringToStringVar(&flagNamespaceTargets, "storage-namespace-targets", nil, "Bucket URLs of gocloud.dev/blob drivers in the form namespace=url, in which the modules and providers of the namespaces are stored instead of the configured storage")
+	rootCmd.PersistentFlags().StringVar(&flagPolicyFile, "policy-file", "", "Path to a YAML or JSON policy file with the quotas of namespaces, which are enforced on uploads")
+	rootCmd.PersistentFlags().StringVar(&flagProviderScanHook, "provider-scan-hook", "", "Scan uploaded provider archives with an http(s) URL of a scanning endpoint or a command prefixed with exec:, e.g. \"exec:clamdscan --no-summary -\"")
+	rootCmd.PersistentFlags().DurationVar(&flagProviderScanTimeout, "provider-scan-timeout", 5*time.Minute, "Maximum duration of the scan of a provider archive")
+	rootCmd.PersistentFlags().BoolVar(&flagProviderScanQuarantine, "provider-scan-quarantine", false, "Move provider archives rejected by the scanner to the quarantine prefix of the storage instead of discarding them")
+	rootCmd.PersistentFlags().StringVar(&flagAdmissionPolicyURL, "admission-policy-url", "", "URL of the OPA Data API evaluating the admission policy of module and provider uploads, e.g. http://localhost:8181/v1/data/boring_registry/admission")
+	rootCmd.PersistentFlags().DurationVar(&flagAdmissionTimeout, "admission-timeout", 10*time.Second, "Maximum duration of the evaluation of the admission policy")
+	rootCmd.PersistentFlags().StringVar(&flagPublishLockURL, "publish-lock-url", "", "URL of a Redis server serializing uploads of the same module or provider version across processes, e.g. rediss://:password@redis.example.com:6379/0. Uploads are only serialized within the process if empty")
+	rootCmd.PersistentFlags().DurationVar(&flagPublishLockTimeout, "publish-lock-timeout", lock.DefaultTimeout, "Maximum time an upload waits for another upload of the same version, unlimited if 0")
+	rootCmd.PersistentFlags().DurationVar(&flagPublishLockTTL, "publish-lock-ttl", lock.DefaultTTL, "Time after which the lock of a crashed upload expires, locks of running uploads are renewed")
+	rootCmd.PersistentFlags().BoolVar(&flagModuleImmutableDigests, "module-immutable-digests", true, "Reject uploads of module versions whose archive differs from the digest recorded when the version was first published, even if overwrites are allowed")
+	rootCmd.PersistentFlags().BoolVar(&flagStorageTagging, "storage-tagging", false, "Tag uploaded S3 objects and GCS objects with the namespace, name, and version of the artifact and the publisher")
+	rootCmd.PersistentFlags().StringToStringVar(&flagStorageTags, "storage-tags", nil, "Static tags in the form key=value added to uploaded S3 objects and GCS objects, enables --storage-tagging")
 }
 
 func initializeConfig(cmd *cobra.Command) error {
 	v := viper.New()
 	v.SetEnvPrefix(envPrefix)
 	v.AutomaticEnv()
-	bindFlags(cmd, v)
-	return nil
+
+	// The flags set on the command line are remembered, as they take precedence over reloaded config files
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		commandLineFlags[f.Name] = struct{}{}
+	})
+
+	configFile = flagConfigFile
+	if configFile == "" {
+		configFile = os.Getenv(fmt.Sprintf("%s_CONFIG", envPrefix))
+	}
+
+	if configFile != "" {
+		var err error
+		loadedConfig, err = readConfigFile(configFile, knownFlags(cmd.Root()))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := bindFlags(cmd, v, loadedConfig); err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return resolveSecrets(ctx, cmd.Flags())
 }
 
-func setupLogger() {
-	handlerOptions := &slog.HandlerOptions{}
+// logLevels are shared by all handlers, so that the levels can be changed when the configuration is reloaded
+// and through the admin API
+var logLevels = loglevel.NewLevels(slog.LevelInfo)
+
+func setupLogger() error {
+	if err := setLogLevel(); err != nil {
+		return err
+	}
+	// The records are filtered by the level of their component before reaching the handler
+	handlerOptions := &slog.HandlerOptions{Level: slog.LevelDebug}
 	if flagDebug {
-		handlerOptions.Level = slog.LevelDebug
 		handlerOptions.AddSource = true
 	}
 
@@ -117,10 +307,157 @@ func setupLogger() {
 	if hostname, err := os.Hostname(); err == nil {
 		handler = handler.WithAttrs([]slog.Attr{slog.String("hostname", hostname)})
 	}
-	slog.SetDefault(slog.New(handler))
+
+	// Records logged while handling a request contain its request ID
+	slog.SetDefault(slog.New(o11y.NewContextHandler(loglevel.NewHandler(handler, logLevels))))
+	return nil
+}
+
+// setLogLevel applies --debug and --log-component-levels, which discards the levels changed through the admin API
+func setLogLevel() error {
+	components, err := loglevel.ParseComponents(flagLogComponentLevels)
+	if err != nil {
+		return fmt.Errorf("invalid --log-component-levels: %w", err)
+	}
+
+	level := slog.LevelInfo
+	if flagDebug {
+		level = slog.LevelDebug
+	}
+	logLevels.Reset(level, components)
+	return nil
+}
+
+// setupReplicator returns nil if no replication targets are configured
+func setupReplicator(ctx context.Context) (*storage.Replicator, error) {
+	if len(flagReplicationTargets) == 0 {
+		return nil, nil
+	}
+
+	var targets []storage.Backend
+	for _, url := range flagReplicationTargets {
+		target, err := storage.NewBlobBackend(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up replication target %s: %w", url, err)
+		}
+		targets = append(targets, target)
+	}
+
+	return storage.NewReplicator(ctx, targets,
+		storage.WithReplicationMode(storage.ReplicationMode(flagReplicationMode)),
+		storage.WithReplicationQueueSize(flagReplicationQueueSize),
+	)
+}
+
+// replicationDecorators returns the decorators replicating the uploads of short-lived commands.
+// The returned function waits for queued replications and has to be called before the command exits.
+func replicationDecorators(ctx context.Context) ([]storage.Decorator, func(), error) {
+	replicator, err := setupReplicator(ctx)
+	if err != nil || replicator == nil {
+		return nil, func() {}, err
+	}
+
+	return []storage.Decorator{replicator.Decorator()}, replicator.Close, nil
+}
+
+// namespaceTargets opens the backends of --storage-namespace-targets, namespaces sharing a bucket URL share the backend
+func namespaceTargets(ctx context.Context) (map[string]storage.Backend, error) {
+	targets := make(map[string]storage.Backend, len(flagNamespaceTargets))
+	opened := make(map[string]storage.Backend)
+	for namespace, url := range flagNamespaceTargets {
+		if _, ok := opened[url]; !ok {
+			target, err := storage.NewBlobBackend(ctx, url)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up storage of namespace %s: %w", namespace, err)
+			}
+			opened[url] = target
+		}
+		targets[namespace] = opened[url]
+	}
+	return targets, nil
 }
 
-func setupStorage(ctx context.Context) (storage.Storage, error) {
+// storageHTTPClientConfig returns the configuration of the connection pools of the storage HTTP clients
+func storageHTTPClientConfig() storage.HTTPClientConfig {
+	return storage.HTTPClientConfig{
+		MaxIdleConnsPerHost: flagStorageHTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     flagStorageHTTPIdleConnTimeout,
+		HTTP2:               flagStorageHTTP2,
+	}
+}
+
+// storageTags returns the static tags of uploaded objects, or nil if tagging is disabled
+func storageTags() map[string]string {
+	if !flagStorageTagging && len(flagStorageTags) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string, len(flagStorageTags))
+	for k, v := range flagStorageTags {
+		tags[k] = v
+	}
+	return tags
+}
+
+func setupStorage(ctx context.Context, decorators ...storage.Decorator) (storage.Storage, error) {
+	// The retry decorator is the outermost one, so that every attempt passes through the remaining decorators
+	decorators = append([]storage.Decorator{storage.RetryDecorator(storage.RetryPolicy{
+		MaxAttempts:    flagStorageRetryMaxAttempts,
+		MaxElapsedTime: flagStorageRetryMaxElapsedTime,
+	})}, decorators...)
+
+	var quotas *quota.Policy
+	if flagPolicyFile != "" {
+		var err error
+		if quotas, err = quota.LoadPolicy(flagPolicyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var scanner scan.Scanner
+	if flagProviderScanHook != "" {
+		var err error
+		if scanner, err = scan.New(flagProviderScanHook, flagProviderScanTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	// The controller is only set if enabled, as a nil *admission.OPA would not be a nil admission.Controller
+	var controller admission.Controller
+	if flagAdmissionPolicyURL != "" {
+		opa, err := admission.NewOPA(flagAdmissionPolicyURL, flagAdmissionTimeout)
+		if err != nil {
+			return nil, err
+		}
+		controller = opa
+	}
+
+	locker, err := lock.New(flagPublishLockURL, flagPublishLockTimeout, flagPublishLockTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The options are shared by all storage backends, which apply them to the storage of modules and providers
+	objectOptions := []storage.ObjectStorageOption{
+		storage.WithObjectStorageQuotas(quotas),
+		storage.WithObjectStorageScanner(scanner, flagProviderScanQuarantine),
+		storage.WithObjectStorageAdmission(controller),
+		storage.WithObjectStorageLocker(locker),
+		storage.WithObjectStorageImmutableDigests(flagModuleImmutableDigests),
+	}
+
+	targets, err := namespaceTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tracing decorator wraps the routing of the namespaces to log every request sent to one of the storage backends.
+	// It logs at debug level, so that it can be enabled at runtime with the log level of the storage component.
+	decorators = append(decorators,
+		storage.TracingDecorator(slog.Default().With(slog.String(loglevel.ComponentKey, "storage"))),
+		storage.NamespaceDecorator(targets),
+	)
+
 	switch {
 	case flagS3Bucket != "":
 		return storage.NewS3Storage(ctx,
@@ -129,8 +466,18 @@ func setupStorage(ctx context.Context) (storage.Storage, error) {
 			storage.WithS3StorageBucketRegion(flagS3Region),
 			storage.WithS3StorageBucketEndpoint(flagS3Endpoint),
 			storage.WithS3StoragePathStyle(flagS3PathStyle),
+			storage.WithS3StorageAccelerate(flagS3Accelerate),
+			storage.WithS3StorageDualStack(flagS3DualStack),
+			storage.WithS3StorageRequestPayer(flagS3RequestPayer),
+			storage.WithS3StorageConditionalWrites(flagS3ConditionalWrites),
 			storage.WithS3ArchiveFormat(flagModuleArchiveFormat),
+			storage.WithS3ArchiveConversion(flagModuleArchiveConvert),
 			storage.WithS3StorageSignedUrlExpiry(flagS3SignedURLExpiry),
+			storage.WithS3StorageAssumeRole(flagS3RoleARN, flagS3ExternalID, flagS3SessionName),
+			storage.WithS3StorageHTTPClient(storageHTTPClientConfig()),
+			storage.WithS3StorageObjectOptions(objectOptions...),
+			storage.WithS3StorageTags(storageTags(), flagUploadPublisher),
+			storage.WithS3StorageDecorators(decorators...),
 		)
 	case flagGCSBucket != "":
 		return storage.NewGCSStorage(flagGCSBucket,
@@ -138,30 +485,101 @@ func setupStorage(ctx context.Context) (storage.Storage, error) {
 			storage.WithGCSServiceAccount(flagGCSServiceAccount),
 			storage.WithGCSSignedUrlExpiry(flagGCSSignedURLExpiry),
 			storage.WithGCSArchiveFormat(flagModuleArchiveFormat),
+			storage.WithGCSArchiveConversion(flagModuleArchiveConvert),
+			storage.WithGCSStorageHTTPClient(storageHTTPClientConfig()),
+			storage.WithGCSStorageObjectOptions(objectOptions...),
+			storage.WithGCSStorageTags(storageTags(), flagUploadPublisher),
+			storage.WithGCSStorageDecorators(decorators...),
 		)
 	case flagAzureStorageContainer != "":
 		return storage.NewAzureStorage(flagAzureStorageAccount,
 			flagAzureStorageContainer,
 			storage.WithAzureStoragePrefix(flagAzureStoragePrefix),
 			storage.WithAzureStorageArchiveFormat(flagModuleArchiveFormat),
+			storage.WithAzureStorageArchiveConversion(flagModuleArchiveConvert),
 			storage.WithAzureStorageSignedUrlExpiry(flagAzureStorageSignedURLExpiry),
+			storage.WithAzureStorageObjectOptions(objectOptions...),
+			storage.WithAzureStorageDecorators(decorators...),
+		)
+	case flagStorageURL != "":
+		return storage.NewBlobStorage(ctx,
+			flagStorageURL,
+			storage.WithBlobStorageArchiveFormat(flagModuleArchiveFormat),
+			storage.WithBlobStorageArchiveConversion(flagModuleArchiveConvert),
+			storage.WithBlobStorageSignedUrlExpiry(flagStorageURLSignedURLExpiry),
+			storage.WithBlobStorageBaseURL(flagStorageURLBaseURL),
+			storage.WithBlobStorageObjectOptions(objectOptions...),
+			storage.WithBlobStorageDecorators(decorators...),
+		)
+	case flagRepositoryURL != "":
+		return storage.NewRepositoryStorage(flagRepositoryURL,
+			storage.WithRepositoryStorageType(storage.RepositoryType(flagRepositoryType)),
+			storage.WithRepositoryStoragePrefix(flagRepositoryPrefix),
+			storage.WithRepositoryStorageAPIKey(flagRepositoryAPIKey),
+			storage.WithRepositoryStorageToken(flagRepositoryToken),
+			storage.WithRepositoryStorageBasicAuth(flagRepositoryUsername, flagRepositoryPassword),
+			storage.WithRepositoryStorageArchiveFormat(flagModuleArchiveFormat),
+			storage.WithRepositoryStorageArchiveConversion(flagModuleArchiveConvert),
+			storage.WithRepositoryStorageSignedUrlExpiry(flagRepositorySignedURLExpiry),
+			storage.WithRepositoryStorageObjectOptions(objectOptions...),
+			storage.WithRepositoryStorageDecorators(decorators...),
+		)
+	case flagOCIRepository != "":
+		if flagOCISignedURLSecret == "" {
+			slog.Warn("no OCI signed URL secret configured, generated a random one. All replicas need to share the same secret")
+		}
+		return storage.NewOCIStorage(flagOCIRepository,
+			storage.WithOCIStorageURLPrefix(prefixOCI),
+			storage.WithOCIStorageBasicAuth(flagOCIUsername, flagOCIPassword),
+			storage.WithOCIStoragePlainHTTP(flagOCIPlainHTTP),
+			storage.WithOCIStorageSignedUrlExpiry(flagOCISignedURLExpiry),
+			storage.WithOCIStorageSignedUrlSecret([]byte(flagOCISignedURLSecret)),
+			storage.WithOCIStorageArchiveFormat(flagModuleArchiveFormat),
+			storage.WithOCIStorageArchiveConversion(flagModuleArchiveConvert),
+			storage.WithOCIStorageObjectOptions(objectOptions...),
+			storage.WithOCIStorageDecorators(decorators...),
 		)
+	case flagStorageInmem:
+		return storage.NewMemoryStorage(
+			storage.WithMemoryStorageURLPrefix(prefixInmem),
+			storage.WithMemoryStorageArchiveFormat(flagModuleArchiveFormat),
+			storage.WithMemoryStorageArchiveConversion(flagModuleArchiveConvert),
+			storage.WithMemoryStorageObjectOptions(objectOptions...),
+			storage.WithMemoryStorageDecorators(decorators...),
+		), nil
 	default:
 		return nil, errors.New("storage provider is not specified")
 	}
 }
 
-func bindFlags(cmd *cobra.Command, v *viper.Viper) {
+// bindFlags sets the flags which aren't set on the command line from environment variables or the config file
+func bindFlags(cmd *cobra.Command, v *viper.Viper, config map[string]interface{}) error {
+	var err error
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		envVarSuffix := strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
-		if err := v.BindEnv(f.Name, fmt.Sprintf("%s_%s", envPrefix, envVarSuffix)); err != nil {
-			panic(fmt.Errorf("failed to bind key to environment variable: %w", err))
+		if err != nil {
+			return
 		}
-		if !f.Changed && v.IsSet(f.Name) {
+
+		envVar := fmt.Sprintf("%s_%s", envPrefix, strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_")))
+		if bindErr := v.BindEnv(f.Name, envVar); bindErr != nil {
+			err = fmt.Errorf("failed to bind key to environment variable: %w", bindErr)
+			return
+		}
+		if f.Changed {
+			return
+		}
+
+		if v.IsSet(f.Name) {
 			val := v.Get(f.Name)
-			if err := cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val)); err != nil {
-				panic(fmt.Errorf("failed to set value of flag: %w", err))
+			if setErr := cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val)); setErr != nil {
+				err = fmt.Errorf("invalid value of environment variable %s: %w", envVar, setErr)
+			}
+		} else if val, ok := config[f.Name]; ok {
+			if setErr := setConfigValue(f, val); setErr != nil {
+				err = fmt.Errorf("invalid value of key %q in config file: %w", f.Name, setErr)
 			}
 		}
 	})
+
+	return err
 }
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
+
+	"github.com/spf13/cobra"
+)
+
+// moduleSBOMFile is the SBOM in the module directory, which is uploaded together with the module
+const moduleSBOMFile = "sbom.json"
+
+var uploadModuleSBOMCmd = &cobra.Command{
+	Use:          "module-sbom NAMESPACE/NAME/PROVIDER/VERSION SBOM",
+	Short:        "Attach an SPDX or CycloneDX SBOM to a published module version",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE:         uploadModuleSBOM,
+}
+
+var uploadProviderSBOMCmd = &cobra.Command{
+	Use:          "provider-sbom NAMESPACE/NAME/VERSION SBOM",
+	Short:        "Attach an SPDX or CycloneDX SBOM to a published provider version",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE:         uploadProviderSBOM,
+}
+
+func uploadModuleSBOM(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 4 {
+		return fmt.Errorf("module %q must be in the form <namespace>/<name>/<provider>/<version>", args[0])
+	}
+	namespace, name, providerName, version := parts[0], parts[1], parts[2], parts[3]
+
+	document, err := readSBOM(args[1], namespace)
+	if err != nil {
+		return err
+	}
+
+	ctx := core.WithIdentity(context.Background(), flagUploadPublisher)
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+
+	storageBackend, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+	sbomStorage, ok := storageBackend.(module.SBOMStorage)
+	if !ok {
+		return errors.New("the storage backend doesn't support SBOMs")
+	}
+
+	if err := sbomStorage.UploadModuleSBOM(ctx, namespace, name, providerName, version, bytes.NewReader(document)); err != nil {
+		return err
+	}
+
+	slog.Info("module SBOM successfully uploaded", slog.String("module", args[0]))
+	return nil
+}
+
+func uploadProviderSBOM(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("provider %q must be in the form <namespace>/<name>/<version>", args[0])
+	}
+	namespace, name, version := parts[0], parts[1], parts[2]
+
+	document, err := readSBOM(args[1], namespace)
+	if err != nil {
+		return err
+	}
+
+	ctx := core.WithIdentity(context.Background(), flagUploadPublisher)
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+
+	storageBackend, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+	sbomStorage, ok := storageBackend.(provider.SBOMStorage)
+	if !ok {
+		return errors.New("the storage backend doesn't support SBOMs")
+	}
+
+	if err := sbomStorage.UploadProviderSBOM(ctx, namespace, name, version, bytes.NewReader(document)); err != nil {
+		return err
+	}
+
+	slog.Info("provider SBOM successfully uploaded", slog.String("provider", args[0]))
+	return nil
+}
+
+// readSBOM reads and validates the SBOM at the path.
+// A missing SBOM is only an error if the namespace requires one, otherwise nil is returned.
+func readSBOM(path, namespace string) ([]byte, error) {
+	document, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if sbom.NewPolicy(flagSBOMRequired).Required(namespace) {
+			return nil, fmt.Errorf("%w: namespace %s requires an SBOM at %s", sbom.ErrSBOMMissing, namespace, path)
+		}
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file at path %s: %w", path, err)
+	}
+
+	if _, err := sbom.Detect(document); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return document, nil
+}
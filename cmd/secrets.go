@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/spf13/pflag"
+)
+
+// secretTimeout limits the time spent on resolving a single secret reference
+const secretTimeout = 30 * time.Second
+
+// secretResolvers resolve the references in the form @<scheme>:<reference> by scheme
+var secretResolvers = map[string]func(ctx context.Context, ref string) (string, error){
+	"file":  resolveFileSecret,
+	"vault": resolveVaultSecret,
+	"awssm": resolveAWSSecret,
+}
+
+// secretHTTPClient is used to fetch secrets from Vault and AWS Secrets Manager
+var secretHTTPClient = &http.Client{Timeout: secretTimeout}
+
+// resolveSecrets replaces the secret references in the values of string and string slice flags with the referenced secrets,
+// so that tokens and keys don't need to be passed as arguments or environment variables.
+func resolveSecrets(ctx context.Context, flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err == nil {
+			err = resolveFlagSecrets(ctx, f)
+		}
+	})
+	return err
+}
+
+// resolveFlagSecrets replaces the secret references in the value of a single flag, other flags are left unchanged
+func resolveFlagSecrets(ctx context.Context, f *pflag.Flag) error {
+	if s, ok := f.Value.(pflag.SliceValue); ok && f.Value.Type() == "stringSlice" {
+		values := s.GetSlice()
+		resolved := make([]string, len(values))
+		var changed bool
+		for i, value := range values {
+			secret, ok, err := resolveSecret(ctx, value)
+			if err != nil {
+				return fmt.Errorf("failed to resolve the secret of flag %s: %w", f.Name, err)
+			}
+			resolved[i] = value
+			if ok {
+				resolved[i] = secret
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		return s.Replace(resolved)
+	}
+
+	if f.Value.Type() != "string" {
+		return nil
+	}
+	secret, ok, err := resolveSecret(ctx, f.Value.String())
+	if err != nil {
+		return fmt.Errorf("failed to resolve the secret of flag %s: %w", f.Name, err)
+	}
+	if !ok {
+		return nil
+	}
+	return f.Value.Set(secret)
+}
+
+// resolveSecret returns the secret if the value is a reference like @file:/path, and false if the value is used as is
+func resolveSecret(ctx context.Context, value string) (string, bool, error) {
+	scheme, ref, ok := strings.Cut(strings.TrimPrefix(value, "@"), ":")
+	if !strings.HasPrefix(value, "@") || !ok {
+		return "", false, nil
+	}
+	resolve, ok := secretResolvers[scheme]
+	if !ok {
+		return "", false, nil
+	}
+	if ref == "" {
+		return "", false, fmt.Errorf("empty %s secret reference", scheme)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretTimeout)
+	defer cancel()
+	secret, err := resolve(ctx, ref)
+	if err != nil {
+		return "", false, fmt.Errorf("%s secret %s: %w", scheme, ref, err)
+	}
+	return secret, true, nil
+}
+
+// resolveFileSecret reads the secret from a file, e.g. mounted from a Kubernetes secret, and trims the trailing newline
+func resolveFileSecret(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// splitSecretKey splits a reference in the form <path>#<key>, the key is empty if the reference has none
+func splitSecretKey(ref string) (string, string) {
+	path, key, _ := strings.Cut(ref, "#")
+	return path, key
+}
+
+// secretField returns the field of a secret with multiple key-value pairs as string
+func secretField(fields map[string]interface{}, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found", key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("key %q is null", key)
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// resolveVaultSecret reads a key of a secret of the KV version 2 secrets engine in the form <mount>/<path>#<key>.
+// The address and token are taken from VAULT_ADDR and VAULT_TOKEN or ~/.vault-token like the Vault CLI does.
+func resolveVaultSecret(ctx context.Context, ref string) (string, error) {
+	path, key := splitSecretKey(ref)
+	mount, secretPath, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || secretPath == "" || key == "" {
+		return "", errors.New("expected a reference in the form <mount>/<path>#<key>")
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.JoinPath(addr, "v1", mount, "data", secretPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid VAULT_ADDR: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := doSecretRequest(req, &secret); err != nil {
+		return "", err
+	}
+	return secretField(secret.Data.Data, key)
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("VAULT_TOKEN is not set")
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return "", errors.New("VAULT_TOKEN is not set and ~/.vault-token can't be read")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// resolveAWSSecret reads a secret of AWS Secrets Manager by name or ARN in the form <secret-id> or <secret-id>#<key>.
+// With a key, the secret is parsed as JSON object and the value of the key is returned.
+// The credentials and region are taken from the default credential chain, the region of an ARN takes precedence.
+func resolveAWSSecret(ctx context.Context, ref string) (string, error) {
+	secretID, key := splitSecretKey(ref)
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	region := cfg.Region
+	if a, err := arn.Parse(secretID); err == nil {
+		region = a.Region
+	}
+	if region == "" {
+		return "", errors.New("the AWS region is not configured")
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	if cfg.BaseEndpoint != nil {
+		endpoint = *cfg.BaseEndpoint
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "secretsmanager", region, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := doSecretRequest(req, &secret); err != nil {
+		return "", err
+	}
+	if secret.SecretString == nil {
+		return "", errors.New("binary secrets aren't supported")
+	}
+	if key == "" {
+		return *secret.SecretString, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(*secret.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("the secret isn't a JSON object: %w", err)
+	}
+	return secretField(fields, key)
+}
+
+// doSecretRequest sends the request and decodes the JSON response, the body of error responses is part of the error
+func doSecretRequest(req *http.Request, v interface{}) error {
+	resp, err := secretHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
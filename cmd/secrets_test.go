@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	secretFile := writeConfigFile(t, "token", "file-secret\n")
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" || r.URL.Path != "/v1/kv/data/registry/oci" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"password":"vault-secret","port":5000}}}`))
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	secretsManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct{ SecretId string }
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-central-1/secretsmanager/") ||
+			json.NewDecoder(r.Body).Decode(&input) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		secrets := map[string]string{
+			"registry/token": "aws-secret",
+			"registry/json":  `{"token":"aws-json-secret"}`,
+		}
+		secret, ok := secrets[input.SecretId]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": secret})
+	}))
+	defer secretsManager.Close()
+	t.Setenv("AWS_ENDPOINT_URL", secretsManager.URL)
+	t.Setenv("AWS_REGION", "eu-central-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	testCases := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "plain value", value: "plain", want: "plain"},
+		{name: "unknown scheme", value: "@other:value", want: "@other:value"},
+		{name: "file", value: "@file:" + secretFile, want: "file-secret"},
+		{name: "missing file", value: "@file:/does/not/exist", wantErr: "no such file"},
+		{name: "vault", value: "@vault:kv/registry/oci#password", want: "vault-secret"},
+		{name: "vault non-string value", value: "@vault:kv/registry/oci#port", want: "5000"},
+		{name: "vault missing key", value: "@vault:kv/registry/oci#user", wantErr: `key "user" not found`},
+		{name: "vault without key", value: "@vault:kv/registry/oci", wantErr: "<mount>/<path>#<key>"},
+		{name: "vault denied", value: "@vault:kv/other#password", wantErr: "403 Forbidden"},
+		{name: "aws secrets manager", value: "@awssm:registry/token", want: "aws-secret"},
+		{name: "aws secrets manager json key", value: "@awssm:registry/json#token", want: "aws-json-secret"},
+		{name: "aws secrets manager not json", value: "@awssm:registry/token#token", wantErr: "isn't a JSON object"},
+		{name: "aws secrets manager missing", value: "@awssm:registry/other", wantErr: "ResourceNotFoundException"},
+		{name: "empty reference", value: "@file:", wantErr: "empty file secret reference"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var value string
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.StringVar(&value, "storage-oci-password", "", "")
+			assert.NoError(t, flags.Set("storage-oci-password", tc.value))
+
+			err := resolveSecrets(context.Background(), flags)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				assert.ErrorContains(t, err, "storage-oci-password")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, value)
+		})
+	}
+}
+
+func TestResolveSecrets_Slice(t *testing.T) {
+	secretFile := writeConfigFile(t, "token", "file-secret")
+
+	var tokens []string
+	var expiry int
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringSliceVar(&tokens, "auth-static-token", nil, "")
+	flags.IntVar(&expiry, "expiry", 0, "")
+	assert.NoError(t, flags.Set("auth-static-token", "plain,@file:"+secretFile))
+
+	assert.NoError(t, resolveSecrets(context.Background(), flags))
+	assert.Equal(t, []string{"plain", "file-secret"}, tokens)
+}
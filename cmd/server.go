@@ -2,69 +2,169 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/pprof"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/boring-registry/boring-registry/pkg/auth"
+	"github.com/boring-registry/boring-registry/pkg/channel"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/discovery"
+	"github.com/boring-registry/boring-registry/pkg/events"
+	"github.com/boring-registry/boring-registry/pkg/login"
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+	"github.com/boring-registry/boring-registry/pkg/maintenance"
 	"github.com/boring-registry/boring-registry/pkg/mirror"
 	"github.com/boring-registry/boring-registry/pkg/module"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
 	"github.com/boring-registry/boring-registry/pkg/provider"
 	"github.com/boring-registry/boring-registry/pkg/proxy"
+	"github.com/boring-registry/boring-registry/pkg/redirect"
+	"github.com/boring-registry/boring-registry/pkg/reindex"
+	"github.com/boring-registry/boring-registry/pkg/resumable"
+	"github.com/boring-registry/boring-registry/pkg/scheduler"
+	"github.com/boring-registry/boring-registry/pkg/stats"
 	"github.com/boring-registry/boring-registry/pkg/storage"
+	"github.com/boring-registry/boring-registry/pkg/tenant"
+	"github.com/boring-registry/boring-registry/pkg/usage"
 
 	"github.com/go-kit/kit/endpoint"
 	httptransport "github.com/go-kit/kit/transport/http"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/oauth2"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 )
 
 const (
 	apiVersion = "v1"
+
+	// Names of the maintenance tasks which can be scheduled with --schedule
+	taskStatsFlush = "stats-flush"
+	taskReplicate  = "replicate"
+	taskReindex    = "reindex"
+	taskAudit      = "audit"
+	taskUsageScan  = "usage-scan"
+	taskMirrorSync = "mirror-sync"
+
+	taskInventoryRefresh = "inventory-refresh"
 )
 
+// The route prefixes are set by setRoutePrefixes
 var (
-	prefix          = fmt.Sprintf("/%s", apiVersion)
-	prefixModules   = fmt.Sprintf("%s/modules", prefix)
+	prefix          string
+	prefixModules   string
+	prefixProviders string
+	prefixV2        string
+	prefixMirror    string
+	prefixProxy     string
+	prefixRedirect  string
+	prefixInmem     string
+	prefixOCI       string
+	prefixLogin     string
+	prefixAdmin     string
+	prefixDiscovery string
+)
+
+// setRoutePrefixes serves the registry below the root path, which is only set for tenants selected by a path prefix
+func setRoutePrefixes(root string) {
+	prefix = fmt.Sprintf("%s/%s", root, apiVersion)
+	prefixModules = fmt.Sprintf("%s/modules", prefix)
 	prefixProviders = fmt.Sprintf("%s/providers", prefix)
-	prefixMirror    = fmt.Sprintf("%s/mirror", prefix)
-	prefixProxy     = fmt.Sprintf("%s/proxy", prefix)
+	prefixV2 = fmt.Sprintf("%s/v2", root)
+	prefixMirror = fmt.Sprintf("%s/mirror", prefix)
+	prefixProxy = fmt.Sprintf("%s/proxy", prefix)
+	prefixRedirect = fmt.Sprintf("%s/redirect", prefix)
+	prefixInmem = fmt.Sprintf("%s/inmem", prefix)
+	prefixOCI = fmt.Sprintf("%s/oci", prefix)
+	prefixLogin = fmt.Sprintf("%s/login", prefix)
+	prefixAdmin = fmt.Sprintf("%s/admin", root)
+	prefixDiscovery = fmt.Sprintf("%s/.well-known/terraform.json", root)
+}
+
+const (
+	// telemetryDebugWriteTimeout allows profiles of up to a few minutes to be taken
+	telemetryDebugWriteTimeout = 5 * time.Minute
 )
 
 var (
 	// Proxy options
-	flagProxy bool
+	flagProxy                  bool
+	flagProxyVaultTransitKey   string
+	flagProxyVaultTransitMount string
+	flagProxySignedURLExpiry   time.Duration
+
+	// Redirect options
+	flagRedirect       bool
+	flagRedirectSecret string
+	flagRedirectExpiry time.Duration
+
+	// CDN options
+	flagCDNBaseURL   string
+	flagCDNSigner    string
+	flagCDNKeyID     string
+	flagCDNKeyFile   string
+	flagCDNURLExpiry time.Duration
+	flagCDNModules   bool
 
 	// General server options
-	flagTLSCertFile         string
-	flagTLSKeyFile          string
-	flagListenAddr          string
-	flagTelemetryListenAddr string
-	flagModuleArchiveFormat string
+	flagTLSCertFile          string
+	flagTLSKeyFile           string
+	flagListenAddr           string
+	flagListenWriteAddr      string
+	flagHidePrereleases      bool
+	flagPrereleaseNamespaces []string
+	flagTelemetryListenAddr  string
+	flagServerReadTimeout    time.Duration
+	flagServerWriteTimeout   time.Duration
+	flagServerUploadTimeout  time.Duration
+	flagServerIdleTimeout    time.Duration
+	flagServerMaxHeaderSize  int
+	flagServerMaxBodySize    int64
+	flagModuleArchiveFormat  string
+	flagModuleArchiveConvert bool
+
+	// CORS options
+	flagCORSAllowedOrigins []string
+	flagCORSAllowedMethods []string
+	flagCORSAllowedHeaders []string
+	flagCORSMaxAge         time.Duration
+
+	// Trusted proxies
+	flagTrustedProxies []string
 
 	// Login options
 	flagLoginGrantTypes []string
 	flagLoginPorts      []int
 
+	// Service discovery options
+	flagDiscoveryBaseURL  string
+	flagDiscoveryServices []string
+
 	// Static auth
 	flagAuthStaticTokens []string
 
 	// OIDC auth
-	flagAuthOidcIssuer   string
-	flagAuthOidcClientId string
-	flagAuthOidcScopes   []string
+	flagAuthOidcIssuer     string
+	flagAuthOidcClientId   string
+	flagAuthOidcScopes     []string
+	flagAuthOidcDeviceFlow bool
 
 	// Okta auth
 	flagAuthOktaIssuer   string
@@ -74,9 +174,88 @@ var (
 	flagAuthOktaToken    string
 	flagLoginScopes      []string
 
+	// Group authorization
+	flagAuthGroupsClaim      string
+	flagAuthGroupPermissions []string
+
+	// Module upstream
+	flagModuleUpstream             string
+	flagModuleUpstreamToken        string
+	flagModuleUpstreamAllowedHosts []string
+
 	// Provider Network Mirror
 	flagProviderNetworkMirrorEnabled            bool
 	flagProviderNetworkMirrorPullThroughEnabled bool
+	flagProviderNetworkMirrorSync               []string
+	flagProviderNetworkMirrorSyncInterval       time.Duration
+	flagProviderNetworkMirrorSyncPlatforms      []string
+	flagProviderNetworkMirrorSyncWebhookURL     string
+	flagProviderNetworkMirrorBlocklistFile      string
+
+	// Download statistics
+	flagDownloadStatsEnabled       bool
+	flagDownloadStatsFlushInterval time.Duration
+	flagConsumerTracking           bool
+
+	// Scheduler
+	flagSchedules []string
+
+	// Multi-tenancy
+	flagTenantsFile string
+
+	// Provider aliases
+	flagProviderAliasesFile string
+
+	// HTTP caching
+	flagCacheMaxAge time.Duration
+
+	// Storage cache
+	flagStorageCacheTTL time.Duration
+
+	// Startup storage check
+	flagStorageCheck bool
+
+	// Bucket inventory
+	flagStorageInventoryURL             string
+	flagStorageInventoryPrefix          string
+	flagStorageInventoryFormat          string
+	flagStorageInventoryRefreshInterval time.Duration
+	flagStorageInventoryMaxAge          time.Duration
+
+	// Storage usage
+	flagUsageScanInterval time.Duration
+
+	// Resumable uploads
+	flagResumableUploads   bool
+	flagResumableUploadDir string
+	flagResumableUploadTTL time.Duration
+
+	// Event feed
+	flagEvents            bool
+	flagEventsHistorySize int
+	flagEventsDownloads   bool
+
+	// Event bus
+	flagEventBus       string
+	flagEventBusURL    string
+	flagEventBusTopic  string
+	flagEventBusSchema string
+
+	// Config reloading
+	flagConfigWatch bool
+
+	// Access logging
+	flagAccessLog bool
+
+	// Telemetry options
+	flagTelemetryDebug bool
+
+	// Maintenance mode
+	flagMaintenanceRetryAfter time.Duration
+
+	// gRPC admin API
+	flagGRPCListenAddr     string
+	flagGRPCMaxMessageSize int
 )
 
 var serverCmd = &cobra.Command{
@@ -88,23 +267,44 @@ var serverCmd = &cobra.Command{
 
 		group, ctx := errgroup.WithContext(ctx)
 
-		mux, err := serveMux(ctx)
+		hooks := &reloadHooks{}
+		mode := maintenance.NewMode(flagMaintenanceRetryAfter)
+		grpcServer, err := newGRPCServer()
+		if err != nil {
+			return fmt.Errorf("failed to setup gRPC server: %w", err)
+		}
+
+		mux, adminPrefixes, err := serveMux(ctx, cmd.Flags(), hooks, mode, grpcServer)
 		if err != nil {
 			return fmt.Errorf("failed to setup server: %w", err)
 		}
 
-		server := &http.Server{
-			Addr:         flagListenAddr,
-			ReadTimeout:  5 * time.Second,
-			WriteTimeout: 5 * time.Second,
-			Handler:      mux,
+		proxies, err := core.ParseTrustedProxies(flagTrustedProxies)
+		if err != nil {
+			return err
+		}
+
+		// The administration endpoints are only served on the write listener if there is one
+		var readHandler http.Handler = mux
+		var writeServer *http.Server
+		if flagListenWriteAddr != "" {
+			if flagListenWriteAddr == flagListenAddr {
+				return errors.New("--listen-write-address has to be different from --listen-address")
+			}
+			readHandler = withoutAdmin(mux, adminPrefixes)
+			writeServer = apiServer(flagListenWriteAddr, mux, proxies)
 		}
+		server := apiServer(flagListenAddr, readHandler, proxies)
 
 		telemetryServer := &http.Server{
 			Addr:         flagTelemetryListenAddr,
 			ReadTimeout:  5 * time.Second,
 			WriteTimeout: 5 * time.Second,
-			Handler:      mux,
+			Handler:      telemetryMux(mode),
+		}
+		if flagTelemetryDebug {
+			// CPU profiles and execution traces are written after the requested duration has passed
+			telemetryServer.WriteTimeout = telemetryDebugWriteTimeout
 		}
 
 		sigint := make(chan os.Signal, 1)
@@ -121,6 +321,38 @@ var serverCmd = &cobra.Command{
 			return nil
 		})
 
+		reload := func() {
+			if err := reloadConfig(cmd.Flags(), knownFlags(cmd.Root()), hooks); err != nil {
+				slog.Error("failed to reload config file, keeping the previous configuration", slog.String("error", err.Error()))
+			}
+		}
+
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+
+		// Reload handler.
+		group.Go(func() error {
+			for {
+				select {
+				case <-sighup:
+					reload()
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		})
+
+		if flagConfigWatch {
+			if configFile == "" {
+				return errors.New("--config-watch requires a config file")
+			}
+
+			// Config file watcher.
+			group.Go(func() error {
+				return watchConfig(ctx, configFile, reload)
+			})
+		}
+
 		// Server handler.
 		group.Go(func() error {
 			<-ctx.Done()
@@ -131,37 +363,46 @@ var serverCmd = &cobra.Command{
 				}
 			}
 
+			if writeServer != nil {
+				if err := writeServer.Shutdown(ctx); err != nil {
+					if err != context.Canceled {
+						slog.Error("failed to terminate write server", slog.String("error", err.Error()))
+					}
+				}
+			}
+
 			if err := telemetryServer.Shutdown(ctx); err != nil {
 				if err != context.Canceled {
 					slog.Error("failed to terminate telemetry server", slog.String("error", err.Error()))
 				}
 			}
 
+			if grpcServer != nil {
+				grpcServer.GracefulStop()
+			}
+
 			return nil
 		})
 
 		// Main server.
 		group.Go(func() error {
-			logger := slog.Default().With(slog.String("listen", flagListenAddr))
-			logger.Info("starting server")
-			defer logger.Info("shutting down server")
-
-			if flagTLSCertFile != "" || flagTLSKeyFile != "" {
-				if err := server.ListenAndServeTLS(flagTLSCertFile, flagTLSKeyFile); err != nil {
-					if err != http.ErrServerClosed {
-						return err
-					}
-				}
-			} else {
-				if err := server.ListenAndServe(); err != nil {
-					if err != http.ErrServerClosed {
-						return err
-					}
-				}
-			}
-			return nil
+			return serveAPI(server, "server")
 		})
 
+		if writeServer != nil {
+			// Write server.
+			group.Go(func() error {
+				return serveAPI(writeServer, "write server")
+			})
+		}
+
+		if grpcServer != nil {
+			// gRPC admin API server.
+			group.Go(func() error {
+				return serveGRPC(grpcServer)
+			})
+		}
+
 		// Telemetry server.
 		group.Go(func() error {
 			logger := slog.Default().With(slog.String("listen", flagTelemetryListenAddr))
@@ -181,17 +422,69 @@ var serverCmd = &cobra.Command{
 }
 
 func init() {
+	setRoutePrefixes("")
 	rootCmd.AddCommand(serverCmd)
+	expvar.Publish("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
 
 	// General options.
 	serverCmd.Flags().StringVar(&flagTLSKeyFile, "tls-key-file", "", "TLS private key to serve")
 	serverCmd.Flags().StringVar(&flagTLSCertFile, "tls-cert-file", "", "TLS certificate to serve")
 	serverCmd.Flags().StringVar(&flagListenAddr, "listen-address", ":5601", "Address to listen on")
+	serverCmd.Flags().StringVar(&flagListenWriteAddr, "listen-write-address", "", "Address the administration endpoints like uploads, deletions, and channels are served on, instead of on --listen-address. All endpoints are served on --listen-address if empty")
 	serverCmd.Flags().StringVar(&flagTelemetryListenAddr, "listen-telemetry-address", ":7801", "Telemetry address to listen on")
-	serverCmd.Flags().StringVar(&flagModuleArchiveFormat, "storage-module-archive-format", storage.DefaultModuleArchiveFormat, "Archive file format for modules, specified without the leading dot")
+	serverCmd.Flags().DurationVar(&flagServerReadTimeout, "server-read-timeout", 5*time.Second, "Maximum duration for reading a request including its body, disabled if 0")
+	serverCmd.Flags().DurationVar(&flagServerWriteTimeout, "server-write-timeout", 5*time.Second, "Maximum duration for writing a response including proxied downloads, disabled if 0")
+	serverCmd.Flags().DurationVar(&flagServerUploadTimeout, "server-upload-timeout", 30*time.Minute, "Maximum duration for reading and answering requests with a body like uploads, which replaces the read and write timeouts for them. The read and write timeouts apply if 0")
+	serverCmd.Flags().DurationVar(&flagServerIdleTimeout, "server-idle-timeout", 60*time.Second, "Maximum duration to wait for the next request on a keep-alive connection, the read timeout is used if 0")
+	serverCmd.Flags().IntVar(&flagServerMaxHeaderSize, "server-max-header-bytes", http.DefaultMaxHeaderBytes, "Maximum size of the request headers in bytes")
+	serverCmd.Flags().Int64Var(&flagServerMaxBodySize, "server-max-body-size", 1<<20, "Maximum size of request bodies in bytes, unlimited if 0")
+	serverCmd.Flags().BoolVar(&flagTelemetryDebug, "telemetry-debug", false, "Expose the pprof and expvar endpoints under /debug on the telemetry listener")
+	serverCmd.Flags().DurationVar(&flagMaintenanceRetryAfter, "maintenance-retry-after", 30*time.Second, "Duration after which clients rejected in maintenance mode are asked to retry")
+	serverCmd.Flags().StringVar(&flagModuleArchiveFormat, "storage-module-archive-format", storage.DefaultModuleArchiveFormat, "Archive file format of uploaded modules, specified without the leading dot. Modules stored as tar.gz, tgz, or zip are detected as well")
+	serverCmd.Flags().BoolVar(&flagModuleArchiveConvert, "storage-module-archive-convert", false, "Repackage uploaded tar.gz and zip module archives in the format of --storage-module-archive-format while they're uploaded")
+	serverCmd.Flags().DurationVar(&flagStorageCacheTTL, "storage-cache-ttl", 0, "Duration for which lookups and objects up to 1 MiB fetched from the storage backend are cached in memory, disabled if 0")
+	serverCmd.Flags().StringVar(&flagStorageInventoryURL, "storage-inventory-url", "", "URL of the bucket with the inventory reports of the storage bucket, e.g. s3://inventory-bucket?region=eu-central-1. Listings are served from the latest report if set")
+	serverCmd.Flags().StringVar(&flagStorageInventoryPrefix, "storage-inventory-prefix", "", "Prefix of the inventory reports in the bucket, i.e. <destination prefix>/<source bucket>/<configuration ID> for S3 Inventory and the destination path for GCS Storage Insights")
+	serverCmd.Flags().StringVar(&flagStorageInventoryFormat, "storage-inventory-format", string(storage.InventoryFormatS3), "Format of the inventory reports, either s3 for S3 Inventory or gcs for GCS Storage Insights")
+	serverCmd.Flags().DurationVar(&flagStorageInventoryRefreshInterval, "storage-inventory-refresh-interval", time.Hour, "Interval in which the latest inventory report is read, the inventory-refresh task can be scheduled instead")
+	serverCmd.Flags().DurationVar(&flagStorageInventoryMaxAge, "storage-inventory-max-age", storage.DefaultInventoryMaxAge, "Age of the latest inventory report after which the objects are listed live again")
+	serverCmd.Flags().DurationVar(&flagUsageScanInterval, "usage-scan-interval", 0, "Interval in which the storage usage of the namespaces is computed, disabled if 0. The usage-scan task can be scheduled instead")
+	serverCmd.Flags().BoolVar(&flagStorageCheck, "storage-check", true, "Validate on startup that the storage backend allows listing, downloading, presigning, uploading, and deleting objects, and fail with every missing permission")
+	serverCmd.Flags().DurationVar(&flagCacheMaxAge, "cache-max-age", 0, "Duration for which clients may cache the service discovery and version list responses without revalidation")
+	serverCmd.Flags().BoolVar(&flagConfigWatch, "config-watch", false, "Reload the config file when it changes, the config file is always reloaded on SIGHUP")
+	serverCmd.Flags().BoolVar(&flagAccessLog, "access-log", true, "Log every request with its status code, latency, size, and request ID")
+
+	// gRPC admin API options
+	serverCmd.Flags().StringVar(&flagGRPCListenAddr, "grpc-listen-address", "", "Address the gRPC admin API listens on, which requires authentication. The gRPC admin API is disabled if empty")
+	serverCmd.Flags().IntVar(&flagGRPCMaxMessageSize, "grpc-max-message-size", 256<<20, "Maximum size of gRPC admin API messages in bytes, which contain whole module and provider archives")
+
+	// CORS options
+	serverCmd.Flags().StringSliceVar(&flagCORSAllowedOrigins, "cors-allowed-origins", nil, "Origins which may call the API from browsers, may contain a wildcard like https://*.example.com, * allows all origins, CORS is disabled if empty")
+	serverCmd.Flags().StringSliceVar(&flagCORSAllowedMethods, "cors-allowed-methods", core.DefaultCORSAllowedMethods, "Methods which browsers may use to call the API from allowed origins")
+	serverCmd.Flags().StringSliceVar(&flagCORSAllowedHeaders, "cors-allowed-headers", core.DefaultCORSAllowedHeaders, "Request headers which browsers may send to the API from allowed origins")
+	serverCmd.Flags().DurationVar(&flagCORSMaxAge, "cors-max-age", 10*time.Minute, "Duration for which browsers may cache the result of a preflight request")
+
+	// Trusted proxy options
+	serverCmd.Flags().StringSliceVar(&flagTrustedProxies, "trusted-proxies", nil, "IP addresses and CIDR ranges of the reverse proxies and load balancers whose Forwarded and X-Forwarded-For headers determine the address of the client. Forwarded headers are ignored if empty")
 
 	// Proxy options.
 	serverCmd.PersistentFlags().BoolVar(&flagProxy, "download-proxy", false, "Enable proxying download request to remote storage")
+	serverCmd.Flags().StringVar(&flagProxyVaultTransitKey, "download-proxy-vault-transit-key", "", "Name of the Vault Transit key signing the download proxy URLs, which are verified on every download. The URLs aren't signed if empty")
+	serverCmd.Flags().StringVar(&flagProxyVaultTransitMount, "download-proxy-vault-transit-mount", "transit", "Path of the Vault Transit secrets engine of the key signing the download proxy URLs")
+	serverCmd.Flags().DurationVar(&flagProxySignedURLExpiry, "download-proxy-signedurl-expiry", 5*time.Minute, "Duration for which signed download proxy URLs are valid")
+
+	// Redirect options.
+	serverCmd.Flags().BoolVar(&flagRedirect, "download-redirect", false, "Enable redirecting download requests to presigned URLs of the remote storage through signed registry URLs")
+	serverCmd.Flags().StringVar(&flagRedirectSecret, "download-redirect-secret", "", "Secret used to sign the download redirect URLs, a random secret is generated if empty")
+	serverCmd.Flags().DurationVar(&flagRedirectExpiry, "download-redirect-expiry", 5*time.Minute, "Duration for which signed download redirect URLs are valid")
+
+	// CDN options
+	serverCmd.Flags().StringVar(&flagCDNBaseURL, "download-cdn-base-url", "", "Base URL of a CDN in front of the bucket, which serves the provider downloads instead of presigned URLs of the bucket. Disabled if empty")
+	serverCmd.Flags().StringVar(&flagCDNSigner, "download-cdn-signer", "", "Signer of the CDN URLs, either cloudfront or cloudcdn. The URLs aren't signed if empty")
+	serverCmd.Flags().StringVar(&flagCDNKeyID, "download-cdn-key-id", "", "ID of the CloudFront public key or name of the Cloud CDN signed URL key")
+	serverCmd.Flags().StringVar(&flagCDNKeyFile, "download-cdn-key-file", "", "Path to the PEM-encoded CloudFront private key or to the base64url-encoded Cloud CDN signed URL key")
+	serverCmd.Flags().DurationVar(&flagCDNURLExpiry, "download-cdn-url-expiry", 15*time.Minute, "Duration for which signed CDN URLs are valid")
+	serverCmd.Flags().BoolVar(&flagCDNModules, "download-cdn-modules", false, "Serve the module downloads from the CDN as well, instead of only the provider downloads")
 
 	// Static auth options.
 	serverCmd.Flags().StringSliceVar(&flagAuthStaticTokens, "auth-static-token", nil, "Static API token to protect the boring-registry")
@@ -205,6 +498,11 @@ func init() {
 	serverCmd.Flags().StringVar(&flagAuthOidcIssuer, "auth-oidc-issuer", "", "OIDC issuer URL")
 	serverCmd.Flags().StringVar(&flagAuthOidcClientId, "auth-oidc-clientid", "", "OIDC client identifier")
 	serverCmd.Flags().StringSliceVar(&flagAuthOidcScopes, "auth-oidc-scopes", nil, "List of OAuth2 scopes")
+	serverCmd.Flags().BoolVar(&flagAuthOidcDeviceFlow, "auth-oidc-device-flow", false, "Serve the authorization and token endpoints of the Terraform login protocol, which log in with the device authorization grant of the OIDC provider")
+
+	// Group authorization options
+	serverCmd.Flags().StringVar(&flagAuthGroupsClaim, "auth-groups-claim", "groups", "Claim of the OIDC or Okta tokens containing the directory groups of the client, nested claims are separated by dots")
+	serverCmd.Flags().StringArrayVar(&flagAuthGroupPermissions, "auth-group-permission", nil, "Permission of a directory group in a namespace in the form of <group>=<namespace>:<read|write>, can be passed multiple times. Requests are only authorized by the groups of the client if set")
 
 	// Terraform Login Protocol options.
 	serverCmd.Flags().StringVar(&flagAuthOktaClientId, "login-client", "", "The client_id value to use when making requests")
@@ -213,61 +511,394 @@ func init() {
 	serverCmd.Flags().StringVar(&flagAuthOktaToken, "login-token", "", "The server's token endpoint")
 	serverCmd.Flags().IntSliceVar(&flagLoginPorts, "login-ports", []int{10000, 10010}, "Inclusive range of TCP ports that Terraform/OpenTofu CLI may use")
 
+	// Service discovery options
+	serverCmd.Flags().StringVar(&flagDiscoveryBaseURL, "discovery-base-url", "", "Base URL the module and provider registry are advertised at in the service discovery document, e.g. a separate download host. The services are relative to the host of the document if empty")
+	serverCmd.Flags().StringArrayVar(&flagDiscoveryServices, "discovery-service", nil, "Additional service advertised in the service discovery document in the form <name>=<value>, e.g. tfe.v2=https://tfe.example.com/api/v2/, can be passed multiple times")
+
+	// Module upstream options
+	serverCmd.Flags().StringVar(&flagModuleUpstream, "module-upstream", "", "Hostname of a registry from which modules missing in the storage are fetched and cached, e.g. registry.terraform.io")
+	serverCmd.Flags().StringVar(&flagModuleUpstreamToken, "module-upstream-token", "", "API token to authenticate with the module upstream registry")
+	serverCmd.Flags().StringSliceVar(&flagModuleUpstreamAllowedHosts, "module-upstream-allowed-hosts", nil, "Hosts from which module archives are downloaded in addition to the module upstream registry, e.g. codeload.github.com for modules hosted on GitHub")
+
 	// Provider Network Mirror options
 	serverCmd.Flags().BoolVar(&flagProviderNetworkMirrorEnabled, "network-mirror", true, "Enable the provider network mirror")
 	serverCmd.Flags().BoolVar(&flagProviderNetworkMirrorPullThroughEnabled, "network-mirror-pull-through", false, "Enable the pull-through provider network mirror. This setting takes no effect if network-mirror is disabled")
+	serverCmd.Flags().StringVar(&flagProviderNetworkMirrorBlocklistFile, "network-mirror-blocklist-file", "", "Path to a YAML or JSON file listing recalled or vulnerable provider releases which are never served nor mirrored by the network mirror")
+	serverCmd.Flags().StringArrayVar(&flagProviderNetworkMirrorSync, "network-mirror-sync", nil, "Upstream provider whose releases matching the version constraint are mirrored in the background, e.g. \"registry.terraform.io/hashicorp/aws >= 5.0\". Can be repeated")
+	serverCmd.Flags().DurationVar(&flagProviderNetworkMirrorSyncInterval, "network-mirror-sync-interval", time.Hour, "Interval in which the upstream providers of --network-mirror-sync are checked for new releases. The mirror-sync task can be scheduled instead")
+	serverCmd.Flags().StringSliceVar(&flagProviderNetworkMirrorSyncPlatforms, "network-mirror-sync-platforms", nil, "Platforms in the form os_arch which are mirrored by --network-mirror-sync, e.g. linux_amd64,darwin_arm64. All platforms are mirrored if empty")
+	serverCmd.Flags().StringVar(&flagProviderNetworkMirrorSyncWebhookURL, "network-mirror-sync-webhook-url", "", "URL to which a JSON notification is posted whenever --network-mirror-sync mirrors a new provider version")
+
+	// Download statistics options
+	serverCmd.Flags().BoolVar(&flagDownloadStatsEnabled, "download-stats", false, "Enable recording download statistics of modules and providers")
+	serverCmd.Flags().DurationVar(&flagDownloadStatsFlushInterval, "download-stats-flush-interval", stats.DefaultFlushInterval, "Interval in which recorded downloads are persisted to the storage backend")
+	serverCmd.Flags().BoolVar(&flagConsumerTracking, "consumer-tracking", false, "Enable recording which consumers download which versions of modules and providers, requires authentication or the download proxy")
+
+	// Module immutability options
+	serverCmd.Flags().StringSliceVar(&flagAllowOverwrite, "allow-overwrite", nil, "Namespaces in which existing module versions can be republished through the admin API, * allows overwrites in all namespaces")
+
+	// Resumable upload options
+	serverCmd.Flags().BoolVar(&flagResumableUploads, "resumable-uploads", false, "Serve the API to upload provider release files in chunks, which can be resumed after a connection failure. Requires authentication")
+	serverCmd.Flags().StringVar(&flagResumableUploadDir, "resumable-upload-dir", filepath.Join(os.TempDir(), "boring-registry-uploads"), "Directory in which the received chunks of resumable uploads are kept until the upload is completed")
+	serverCmd.Flags().DurationVar(&flagResumableUploadTTL, "resumable-upload-ttl", resumable.DefaultSessionTTL, "Duration after which resumable uploads without progress are discarded")
+
+	// Event feed options
+	serverCmd.Flags().BoolVar(&flagEvents, "events", false, "Serve a feed of the module and provider versions published, deleted, and restored through the server as Server-Sent Events. Requires authentication")
+	serverCmd.Flags().IntVar(&flagEventsHistorySize, "events-history-size", events.DefaultHistorySize, "Number of events kept in memory, so that subscribers can resume the feed after reconnecting")
+	serverCmd.Flags().BoolVar(&flagEventsDownloads, "events-downloads", false, "Publish an event for every download of a module or provider version to the event feed and the event bus. Requires --download-stats")
+
+	// Event bus options
+	serverCmd.Flags().StringVar(&flagEventBus, "event-bus", "", "Event bus the events are published to, either nats or kafka-rest for the Kafka REST proxy. Disabled if empty")
+	serverCmd.Flags().StringVar(&flagEventBusURL, "event-bus-url", "", "URL of the NATS server, e.g. nats://nats.example.com:4222, or of the Kafka REST proxy, e.g. https://kafka-rest.example.com:8082")
+	serverCmd.Flags().StringVar(&flagEventBusTopic, "event-bus-topic", events.DefaultTopic, "NATS subject or Kafka topic the events are published to, {type} is replaced with the type of the event")
+	serverCmd.Flags().StringVar(&flagEventBusSchema, "event-bus-schema", events.SchemaNative, "Schema of the published messages, either native or cloudevents")
+
+	// Promotion options
+	serverCmd.Flags().IntVar(&flagPromotionRequiredApprovals, "promotion-required-approvals", 0, "Number of distinct reviewers who have to approve a staged module version before it can be promoted")
+
+	// Pre-release options
+	serverCmd.Flags().BoolVar(&flagHidePrereleases, "hide-prereleases", false, "Omit pre-release versions like 1.2.0-rc.1 from the version listings of modules and providers, unless the prereleases query parameter is set")
+	serverCmd.Flags().StringSliceVar(&flagPrereleaseNamespaces, "prerelease-namespaces", nil, "Namespaces whose pre-release versions are listed even if --hide-prereleases is set")
+
+	// Scheduler options
+	serverCmd.Flags().StringArrayVar(&flagSchedules, "schedule", nil, "Schedule of a maintenance task in the form of <task>=<cron expression>, multiple schedules can be separated by a semicolon")
+	serverCmd.Flags().Float64Var(&flagAuditSampleRate, "audit-sample-rate", 1, "Fraction of the stored archives whose checksum is verified by the scheduled audit task, 1 verifies all archives")
+
+	// Provider alias options
+	serverCmd.Flags().StringVar(&flagProviderAliasesFile, "provider-aliases-file", "", "Path to a YAML or JSON file describing forked providers which are served under the namespace and name of the original providers")
+
+	// Multi-tenancy options
+	serverCmd.Flags().StringVar(&flagTenantsFile, "tenants-file", "", "Path to a YAML or JSON file describing tenants which are served as isolated registries selected by the Host header or a path prefix")
 }
 
-func serveMux(ctx context.Context) (*http.ServeMux, error) {
+// serveMux returns the handler of all registries and the path prefixes of their administration endpoints
+func serveMux(ctx context.Context, flags *pflag.FlagSet, hooks *reloadHooks, mode *maintenance.Mode, grpcServer *grpc.Server) (*http.ServeMux, []string, error) {
 	mux := http.NewServeMux()
 
-	authMiddleware, login, err := authMiddleware(ctx)
+	metrics := o11y.NewMetrics(nil)
+	registerMetrics(mux)
+
+	if flagTenantsFile == "" {
+		if err := registerRegistry(ctx, mux, metrics, hooks, mode, grpcServer); err != nil {
+			return nil, nil, err
+		}
+		return mux, []string{prefixAdmin}, nil
+	}
+
+	tenants, err := tenant.LoadConfig(flagTenantsFile)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	router := tenant.NewRouter()
+	var adminPrefixes []string
+	for _, t := range tenants {
+		tenantMux := http.NewServeMux()
+		tenantHooks := &reloadHooks{}
+		err := withTenantFlags(flags, t.Flags, func() error {
+			return withRoutePrefixes(t.Path, func() error {
+				adminPrefixes = append(adminPrefixes, prefixAdmin)
+				return registerRegistry(ctx, tenantMux, metrics, tenantHooks, mode, nil)
+			})
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to setup tenant %s: %w", t.Name(), err)
+		}
+
+		// The hooks of a tenant see the flags of the tenant, which take precedence over the reloaded config file
+		name, overrides := t.Name(), t.Flags
+		hooks.add(func() {
+			err := withTenantFlags(flags, overrides, func() error {
+				tenantHooks.run()
+				return nil
+			})
+			if err != nil {
+				slog.Error("failed to reload tenant", slog.String("tenant", name), slog.String("error", err.Error()))
+			}
+		})
+
+		if err := router.Handle(t.Host, t.Path, tenantMux); err != nil {
+			return nil, nil, err
+		}
+		slog.Info("registered tenant", slog.String("host", t.Host), slog.String("path", t.Path))
+	}
+	mux.Handle("/", router)
+
+	return mux, adminPrefixes, nil
+}
+
+// registerRegistry registers all endpoints of a single registry based on the flags,
+// and the gRPC admin API on the gRPC server if it isn't nil
+func registerRegistry(ctx context.Context, mux *http.ServeMux, metrics *o11y.ServerMetrics, hooks *reloadHooks, mode *maintenance.Mode, grpcServer *grpc.Server) error {
+	authMiddleware, login, err := authMiddleware(ctx, hooks)
+	if err != nil {
+		return err
 	}
 
-	metrics := o11y.NewMetrics(nil)
 	instrumentation := o11y.NewMiddleware(metrics.Http)
 
-	registerMetrics(mux)
-	registerDiscovery(mux, login)
+	// Version lists of an authenticated registry must not be stored by shared caches
+	cache := core.NewCacheMiddleware(flagCacheMaxAge, !authEnabled())
+
+	if err := registerDiscovery(mux, login, mode); err != nil {
+		return err
+	}
+	registerMaintenance(mux, mode, authMiddleware, instrumentation)
+	registerLogLevel(mux, authMiddleware, instrumentation)
 
-	s, err := setupStorage(ctx)
+	if flagAuthOidcDeviceFlow {
+		if err := registerLogin(ctx, mux, instrumentation); err != nil {
+			return err
+		}
+	}
+
+	schedules, err := scheduler.ParseSchedules(flagSchedules)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	proxyUrlService := core.NewProxyUrlService(flagProxy, prefixProxy)
+	var decorators []storage.Decorator
+	if flagStorageCacheTTL > 0 {
+		decorators = append(decorators, storage.CacheDecorator(flagStorageCacheTTL))
+	}
 
-	if err := registerModule(mux, s, authMiddleware, metrics.Module, instrumentation, proxyUrlService); err != nil {
-		return nil, err
+	inventory, err := setupInventory(ctx, schedules)
+	if err != nil {
+		return err
+	}
+	if inventory != nil {
+		// The inventory is applied outside of the metrics, so that only the live listings are counted
+		decorators = append(decorators, inventory.Decorator())
 	}
+	decorators = append(decorators, storage.MetricsDecorator(metrics.Storage))
 
-	if err := registerProvider(mux, s, authMiddleware, metrics.Provider, instrumentation, proxyUrlService); err != nil {
-		return nil, err
+	replicator, err := setupReplicator(ctx)
+	if err != nil {
+		return err
+	}
+	if replicator != nil {
+		decorators = append(decorators, replicator.Decorator())
+	}
+
+	if flagCDNBaseURL != "" {
+		cdn, err := cdnDecorator()
+		if err != nil {
+			return err
+		}
+		decorators = append(decorators, cdn)
+	}
+
+	if flagEvents && !authEnabled() {
+		return errors.New("--events requires authentication to be configured")
+	}
+	var broker *events.Broker
+	if flagEvents || flagEventBus != "" {
+		broker = events.NewBroker(flagEventsHistorySize)
+		decorators = append(decorators, storage.EventsDecorator(broker))
+	}
+	if err := setupEventBus(ctx, broker); err != nil {
+		return err
+	}
+
+	s, err := setupStorage(ctx, decorators...)
+	if err != nil {
+		return err
+	}
+
+	if flagStorageCheck {
+		if err := checkStorage(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	if flagDevFixtures != "" {
+		if err := seedFixtures(ctx, s, flagDevFixtures); err != nil {
+			return fmt.Errorf("failed to seed fixtures: %w", err)
+		}
+	}
+
+	// The in-memory and the OCI storage serve the archives themselves
+	if handler, ok := s.(http.Handler); ok {
+		handlerPrefix := prefixInmem
+		if flagOCIRepository != "" {
+			handlerPrefix = prefixOCI
+		}
+		mux.Handle(fmt.Sprintf(`%s/`, handlerPrefix), http.StripPrefix(handlerPrefix, handler))
+	}
+
+	proxyUrlService, err := setupProxyUrlService()
+	if err != nil {
+		return err
+	}
+
+	redirector, err := setupRedirector()
+	if err != nil {
+		return err
+	}
+
+	sched := scheduler.New()
+
+	if inventory != nil {
+		sched.Register(taskInventoryRefresh, inventory.Refresh)
+	}
+
+	if replicator != nil {
+		sched.Register(taskReplicate, func(ctx context.Context) error {
+			_, err := replicator.Reconcile(ctx)
+			return err
+		})
+	}
+
+	flushInterval := flagDownloadStatsFlushInterval
+	if _, ok := schedules[taskStatsFlush]; ok {
+		// The scheduler takes care of flushing the download statistics and consumers
+		flushInterval = 0
+	}
+	var flushes []func(ctx context.Context) error
+
+	var recorder stats.Recorder
+	if flagDownloadStatsEnabled {
+		recorder = stats.NewRecorder(ctx, s, metrics.Stats, flushInterval)
+		flushes = append(flushes, recorder.Flush)
+	}
+	if flagEventsDownloads {
+		if recorder == nil || broker == nil {
+			return errors.New("--events-downloads requires --download-stats and either --events or --event-bus")
+		}
+		recorder = events.DownloadRecorder(recorder, broker)
+	}
+
+	var tracker stats.ConsumerTracker
+	if flagConsumerTracking {
+		if !authEnabled() && !flagProxy {
+			return errors.New("consumer tracking requires authentication or the download proxy to identify consumers")
+		}
+		// Without authentication, the consumers are identified by the address downloading through the proxy
+		tracker = stats.NewConsumerTracker(ctx, s, flushInterval, flagProxy)
+		flushes = append(flushes, tracker.Flush)
+	}
+
+	if len(flushes) > 0 {
+		sched.Register(taskStatsFlush, func(ctx context.Context) error {
+			var errs []error
+			for _, flush := range flushes {
+				errs = append(errs, flush(ctx))
+			}
+			return errors.Join(errs...)
+		})
+	}
+
+	if err := registerModule(mux, s, authMiddleware, metrics.Module, instrumentation, cache, proxyUrlService, redirector, recorder, tracker); err != nil {
+		return err
+	}
+
+	if err := registerProvider(mux, s, authMiddleware, metrics.Provider, instrumentation, cache, proxyUrlService, redirector, recorder, tracker); err != nil {
+		return err
+	}
+
+	if redirector != nil {
+		registerRedirect(mux, redirector, s, recorder, metrics.Redirect, instrumentation)
 	}
 
 	if flagProxy {
-		if err := registerProxy(mux, s, metrics.Proxy, instrumentation); err != nil {
-			return nil, err
+		if err := registerProxy(mux, s, proxyUrlService, metrics.Proxy, instrumentation); err != nil {
+			return err
+		}
+	}
+
+	var blocklist *mirror.Blocklist
+	if flagProviderNetworkMirrorBlocklistFile != "" {
+		var err error
+		if blocklist, err = mirror.LoadBlocklist(flagProviderNetworkMirrorBlocklistFile); err != nil {
+			return err
 		}
 	}
 
 	if flagProviderNetworkMirrorEnabled {
 		var svc mirror.Service
 		if flagProviderNetworkMirrorPullThroughEnabled {
+			if flagReadOnly {
+				return errors.New("the pull-through network mirror stores providers and can't be enabled in read-only mode")
+			}
 			copier := mirror.NewCopier(ctx, s)
 			svc = mirror.NewPullThroughMirror(s, copier)
 		} else {
 			svc = mirror.NewMirror(s)
 		}
+		if blocklist != nil {
+			svc = mirror.BlocklistMiddleware(blocklist)(svc)
+		}
 
 		if err := registerMirror(mux, s, svc, authMiddleware, metrics.Mirror, instrumentation); err != nil {
-			return nil, err
+			return err
+		}
+	}
+
+	if len(flagProviderNetworkMirrorSync) > 0 {
+		if flagReadOnly {
+			return errors.New("the network mirror sync stores providers and can't be enabled in read-only mode")
+		}
+		syncer, err := setupMirrorSync(ctx, s, schedules, blocklist)
+		if err != nil {
+			return err
+		}
+		sched.Register(taskMirrorSync, syncer.Sync)
+	}
+
+	if reindexer, ok := s.(storage.Reindexer); ok {
+		sched.Register(taskReindex, func(ctx context.Context) error {
+			// The drift is logged by the reindexer
+			_, err := reindexer.Reindex(ctx)
+			return err
+		})
+		registerReindex(mux, reindexer, authMiddleware, instrumentation)
+	}
+
+	if reporter, ok := s.(storage.UsageReporter); ok {
+		_, scheduled := schedules[taskUsageScan]
+		if flagUsageScanInterval > 0 || scheduled {
+			interval := flagUsageScanInterval
+			if scheduled {
+				interval = 0
+			}
+			scanner := usage.NewScanner(ctx, reporter, metrics.Usage, interval)
+			sched.Register(taskUsageScan, scanner.Scan)
+			registerUsage(mux, scanner, authMiddleware, instrumentation)
+		}
+	}
+
+	if auditor, ok := s.(storage.Auditor); ok {
+		sched.Register(taskAudit, func(ctx context.Context) error {
+			// The discrepancies are logged by the auditor
+			return runAudit(ctx, auditor, metrics.Audit)
+		})
+	}
+
+	if flagResumableUploads {
+		if err := registerResumableUploads(ctx, mux, s, authMiddleware, instrumentation); err != nil {
+			return err
+		}
+	}
+
+	if broker != nil {
+		registerEvents(mux, broker, authMiddleware, instrumentation)
+	}
+
+	if grpcServer != nil {
+		registerAdminGRPC(grpcServer, s, authMiddleware, recorder)
+	}
+
+	for name, spec := range schedules {
+		if err := sched.Schedule(name, spec); err != nil {
+			return fmt.Errorf("failed to schedule task: %w", err)
 		}
 	}
+	if sched.Scheduled() {
+		sched.Start(ctx)
+		registerScheduler(mux, sched, authMiddleware, instrumentation)
+	}
 
-	return mux, nil
+	return nil
 }
 
 func setupOidc(ctx context.Context) (auth.Provider, *discovery.LoginV1, error) {
@@ -296,6 +927,17 @@ func setupOidc(ctx context.Context) (auth.Provider, *discovery.LoginV1, error) {
 		Scopes:     flagAuthOidcScopes,
 	}
 
+	if flagAuthOidcDeviceFlow {
+		if provider.DeviceAuthURL() == "" {
+			return nil, nil, errors.New("the OIDC provider doesn't support the device authorization grant")
+		}
+
+		// The CLI is sent to the login endpoints of the registry, which are resolved relative to the discovery document
+		login.GrantTypes = []string{"authz_code"}
+		login.Authz = fmt.Sprintf("%s/authorization", prefixLogin)
+		login.Token = fmt.Sprintf("%s/token", prefixLogin)
+	}
+
 	return provider, login, nil
 }
 
@@ -315,11 +957,15 @@ func setupOkta() (auth.Provider, *discovery.LoginV1) {
 	return p, login
 }
 
-func authMiddleware(ctx context.Context) (endpoint.Middleware, *discovery.LoginV1, error) {
+func authMiddleware(ctx context.Context, hooks *reloadHooks) (endpoint.Middleware, *discovery.LoginV1, error) {
 	providers := []auth.Provider{}
 
 	if flagAuthStaticTokens != nil {
-		providers = append(providers, auth.NewStaticProvider(flagAuthStaticTokens...))
+		p := auth.NewStaticProvider(flagAuthStaticTokens...).(*auth.StaticProvider)
+		hooks.add(func() {
+			p.SetTokens(flagAuthStaticTokens...)
+		})
+		providers = append(providers, p)
 	}
 
 	// Check if OIDC or Okta are configured, we only want to allow one at a time.
@@ -328,6 +974,10 @@ func authMiddleware(ctx context.Context) (endpoint.Middleware, *discovery.LoginV
 		return nil, nil, errors.New("both OIDC and Okta are configured, only one is allowed at a time")
 	}
 
+	if flagAuthOidcDeviceFlow && flagAuthOidcIssuer == "" {
+		return nil, nil, errors.New("the device flow login requires OIDC to be configured")
+	}
+
 	// We construct the discovery.LoginV1 on this level, as we need the OIDC provider to look up the
 	// authorization and token endpoints dynamically to populate the LoginV1
 	var login *discovery.LoginV1
@@ -345,34 +995,79 @@ func authMiddleware(ctx context.Context) (endpoint.Middleware, *discovery.LoginV
 		providers = append(providers, p)
 	}
 
+	// The login endpoints of an external identity provider can be advertised without OIDC or Okta
+	if login == nil && (flagAuthOktaAuthz != "" || flagAuthOktaToken != "") {
+		login = &discovery.LoginV1{
+			Client:     flagAuthOktaClientId,
+			GrantTypes: flagLoginGrantTypes,
+			Authz:      flagAuthOktaAuthz,
+			Token:      flagAuthOktaToken,
+			Ports:      flagLoginPorts,
+			Scopes:     flagLoginScopes,
+		}
+	}
+
 	if login != nil { // Can be nil if neither Oidc, Okta, or API token are configured
 		if err := login.Validate(); err != nil {
 			return nil, nil, err
 		}
 	}
 
-	return auth.Middleware(providers...), login, nil
+	middleware := auth.Middleware(providers...)
+	if len(flagAuthGroupPermissions) > 0 {
+		if len(providers) == 0 {
+			return nil, nil, errors.New("--auth-group-permission requires authentication to be configured")
+		}
+		policy, err := auth.ParseGroupPolicy(flagAuthGroupPermissions)
+		if err != nil {
+			return nil, nil, err
+		}
+		middleware = endpoint.Chain(middleware, auth.GroupMiddleware(policy, auth.NewClaimGroupResolver(flagAuthGroupsClaim)))
+	}
+
+	return middleware, login, nil
 }
 
 func registerMetrics(mux *http.ServeMux) {
 	mux.Handle("/metrics", promhttp.Handler())
+}
+
+// telemetryMux serves the metrics, the readiness, and the pprof and expvar endpoints if enabled, on the telemetry listener
+func telemetryMux(mode *maintenance.Mode) *http.ServeMux {
+	mux := http.NewServeMux()
+	registerMetrics(mux)
+	mux.Handle("/readyz", mode.ReadinessHandler())
+	if flagTelemetryDebug {
+		registerDebug(mux)
+	}
+	return mux
+}
+
+// registerDebug registers the pprof and expvar endpoints to profile and inspect the running process
+func registerDebug(mux *http.ServeMux) {
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
 	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/pprof/allocs", pprof.Handler("allocs"))
 	mux.Handle("/debug/pprof/block", pprof.Handler("block"))
 	mux.Handle("/debug/pprof/goroutine", pprof.Handler("goroutine"))
 	mux.Handle("/debug/pprof/heap", pprof.Handler("heap"))
 	mux.Handle("/debug/pprof/mutex", pprof.Handler("mutex"))
 	mux.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
+	mux.Handle("/debug/vars", expvar.Handler())
 }
 
-func registerDiscovery(mux *http.ServeMux, login *discovery.LoginV1) error {
-	options := []discovery.Option{
-		discovery.WithModulesV1(fmt.Sprintf("%s/", prefixModules)),
-		discovery.WithProvidersV1(fmt.Sprintf("%s/", prefixProviders)),
-		discovery.WithLoginV1(login),
+// authEnabled returns true if any authentication provider is configured
+func authEnabled() bool {
+	return len(flagAuthStaticTokens) > 0 || flagAuthOidcIssuer != "" || flagAuthOktaIssuer != ""
+}
+
+func registerDiscovery(mux *http.ServeMux, login *discovery.LoginV1, mode *maintenance.Mode) error {
+	options, err := discoveryOptions(login)
+	if err != nil {
+		return err
 	}
 
 	terraformJSON, err := json.Marshal(discovery.NewDiscovery(options...))
@@ -380,54 +1075,183 @@ func registerDiscovery(mux *http.ServeMux, login *discovery.LoginV1) error {
 		return err
 	}
 
-	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+	// The discovery document is never protected by authentication.
+	// New clients are turned away in maintenance mode, while clients which discovered the registry before can finish their downloads.
+	cache := core.NewCacheMiddleware(flagCacheMaxAge, true)
+	mux.Handle(prefixDiscovery, mode.WrapDiscovery(cache.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-type", "application/json")
 		w.Write(terraformJSON)
-	})
+	}))))
 
 	return nil
 }
 
-func registerModule(mux *http.ServeMux, s storage.Storage, auth endpoint.Middleware, metrics *o11y.ModuleMetrics, instrumentation o11y.Middleware, proxyUrlService core.ProxyUrlService) error {
-	service := module.NewService(s, proxyUrlService)
-	{
-		service = module.LoggingMiddleware()(service)
+// discoveryOptions returns the services of the discovery document, which are relative to the document unless --discovery-base-url is set
+func discoveryOptions(login *discovery.LoginV1) ([]discovery.Option, error) {
+	baseURL := ""
+	if flagDiscoveryBaseURL != "" {
+		u, err := url.Parse(flagDiscoveryBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("--discovery-base-url %q must be an absolute http or https URL", flagDiscoveryBaseURL)
+		}
+		baseURL = strings.TrimSuffix(u.String(), "/")
 	}
 
-	opts := []httptransport.ServerOption{
-		httptransport.ServerErrorEncoder(module.ErrorEncoder),
-		httptransport.ServerBefore(
-			httptransport.PopulateRequestContext,
-		),
+	options := []discovery.Option{
+		discovery.WithModulesV1(fmt.Sprintf("%s%s/", baseURL, prefixModules)),
+		discovery.WithProvidersV1(fmt.Sprintf("%s%s/", baseURL, prefixProviders)),
+		discovery.WithLoginV1(login),
+	}
+	for _, s := range flagDiscoveryServices {
+		name, value, err := discovery.ParseService(s)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, discovery.WithService(name, value))
 	}
+	return options, nil
+}
 
-	mux.Handle(
-		fmt.Sprintf(`%s/`, prefixModules),
-		http.StripPrefix(
-			prefixModules,
-			module.MakeHandler(
+func registerLogin(ctx context.Context, mux *http.ServeMux, instrumentation o11y.Middleware) error {
+	authCtx, cancelAuthCtx := context.WithTimeout(ctx, 15*time.Second)
+	defer cancelAuthCtx()
+
+	provider, err := auth.NewOidcProvider(authCtx, flagAuthOidcIssuer, flagAuthOidcClientId)
+	if err != nil {
+		return fmt.Errorf("failed to set up oidc provider: %w", err)
+	}
+
+	// The openid scope is required for the OIDC provider to issue the ID token passed to the CLI
+	scopes := flagAuthOidcScopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid"}
+	}
+
+	svc := login.NewDeviceFlowService(ctx, &oauth2.Config{
+		ClientID: flagAuthOidcClientId,
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: provider.DeviceAuthURL(),
+			TokenURL:      provider.TokenURL(),
+		},
+		Scopes: scopes,
+	}, login.WithPorts(flagLoginPorts))
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixLogin),
+		http.StripPrefix(
+			prefixLogin,
+			login.MakeHandler(
+				svc,
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+
+	return nil
+}
+
+func registerModule(mux *http.ServeMux, s storage.Storage, authMiddleware endpoint.Middleware, metrics *o11y.ModuleMetrics, instrumentation o11y.Middleware, cache core.CacheMiddleware, proxyUrlService core.ProxyUrlService, redirector core.DownloadRedirector, recorder stats.Recorder, tracker stats.ConsumerTracker) error {
+	var options []module.ServiceOption
+	if recorder != nil {
+		options = append(options, module.WithDownloadStats(recorder))
+	}
+	if tracker != nil {
+		options = append(options, module.WithConsumerTracking(tracker))
+	}
+	if redirector != nil {
+		options = append(options, module.WithDownloadRedirect(redirector))
+	}
+	if flagReadOnly {
+		options = append(options, module.WithReadOnly())
+	}
+	if flagModuleUpstream != "" {
+		if flagReadOnly {
+			return errors.New("the module upstream stores modules and can't be enabled in read-only mode")
+		}
+		upstream := module.NewUpstreamRegistry(
+			flagModuleUpstream,
+			module.WithUpstreamToken(flagModuleUpstreamToken),
+			module.WithUpstreamAllowedHosts(flagModuleUpstreamAllowedHosts...),
+		)
+		options = append(options, module.WithUpstream(upstream))
+	}
+
+	options = append(options, module.WithChannels(channel.NewManager(s)))
+
+	service := module.NewService(s, proxyUrlService, options...)
+	{
+		service = module.LoggingMiddleware()(service)
+		if flagHidePrereleases {
+			service = module.PrereleaseMiddleware(core.NewPrereleasePolicy(true, flagPrereleaseNamespaces))(service)
+		}
+	}
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(module.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+			auth.NamespaceToContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixModules),
+		http.StripPrefix(
+			prefixModules,
+			module.MakeHandler(
 				service,
-				auth,
+				authMiddleware,
 				metrics,
 				instrumentation,
+				cache,
 				opts...,
 			),
 		),
 	)
 
-	return nil
+	return registerModuleAdmin(mux, s, service, authMiddleware, instrumentation)
 }
 
-func registerProvider(mux *http.ServeMux, s storage.Storage, authMiddleware endpoint.Middleware, metrics *o11y.ProviderMetrics, instrumentation o11y.Middleware, proxyUrlService core.ProxyUrlService) error {
-	service := provider.NewService(s, proxyUrlService)
+func registerProvider(mux *http.ServeMux, s storage.Storage, authMiddleware endpoint.Middleware, metrics *o11y.ProviderMetrics, instrumentation o11y.Middleware, cache core.CacheMiddleware, proxyUrlService core.ProxyUrlService, redirector core.DownloadRedirector, recorder stats.Recorder, tracker stats.ConsumerTracker) error {
+	var options []provider.ServiceOption
+	if recorder != nil {
+		options = append(options, provider.WithDownloadStats(recorder))
+	}
+	if tracker != nil {
+		options = append(options, provider.WithConsumerTracking(tracker))
+	}
+	if redirector != nil {
+		options = append(options, provider.WithDownloadRedirect(redirector))
+	}
+	if flagProviderAliasesFile != "" {
+		aliases, err := provider.LoadAliases(flagProviderAliasesFile)
+		if err != nil {
+			return err
+		}
+		options = append(options, provider.WithAliases(aliases...))
+	}
+	options = append(options, provider.WithChannels(channel.NewManager(s)))
+
+	service := provider.NewService(s, proxyUrlService, options...)
 	{
 		service = provider.LoggingMiddleware()(service)
+		if flagHidePrereleases {
+			service = provider.PrereleaseMiddleware(core.NewPrereleasePolicy(true, flagPrereleaseNamespaces))(service)
+		}
 	}
 
 	opts := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(provider.ErrorEncoder),
 		httptransport.ServerBefore(
 			httptransport.PopulateRequestContext,
+			auth.NamespaceToContext,
 		),
 	}
 
@@ -440,11 +1264,43 @@ func registerProvider(mux *http.ServeMux, s storage.Storage, authMiddleware endp
 				authMiddleware,
 				metrics,
 				instrumentation,
+				cache,
+				opts...,
+			),
+		),
+	)
+
+	// The v2 API is consumed by tooling built against the Terraform Registry, like tfplugindocs
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixV2),
+		http.StripPrefix(
+			prefixV2,
+			provider.MakeV2Handler(
+				service,
+				authMiddleware,
+				instrumentation,
+				cache,
 				opts...,
 			),
 		),
 	)
 
+	// Channels are only managed through the API with authentication
+	if authEnabled() {
+		mux.Handle(
+			fmt.Sprintf(`%s/providers/`, prefixAdmin),
+			readOnly(http.StripPrefix(
+				prefixAdmin,
+				provider.MakeAdminHandler(
+					service,
+					authMiddleware,
+					instrumentation,
+					opts...,
+				),
+			)),
+		)
+	}
+
 	return nil
 }
 
@@ -475,7 +1331,316 @@ func registerMirror(mux *http.ServeMux, _ storage.Storage, svc mirror.Service, a
 	return nil
 }
 
-func registerProxy(mux *http.ServeMux, storage storage.Storage, metrics *o11y.ProxyMetrics, instrumentation o11y.Middleware) error {
+// registerReindex registers the re-indexing API, which is only served with authentication
+func registerReindex(mux *http.ServeMux, reindexer storage.Reindexer, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) {
+	if !authEnabled() {
+		slog.Debug("the reindex API is disabled, as authentication isn't configured")
+		return
+	}
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(reindex.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/reindex`, prefixAdmin),
+		http.StripPrefix(
+			prefixAdmin,
+			reindex.MakeHandler(
+				reindexer,
+				authMiddleware,
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+}
+
+// setupMirrorSync starts syncing the upstream providers of --network-mirror-sync to the network mirror
+func setupMirrorSync(ctx context.Context, s mirror.Storage, schedules map[string]string, blocklist *mirror.Blocklist) (*mirror.Syncer, error) {
+	var targets []mirror.SyncTarget
+	for _, raw := range flagProviderNetworkMirrorSync {
+		t, err := mirror.ParseSyncTarget(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --network-mirror-sync: %w", err)
+		}
+		targets = append(targets, t)
+	}
+
+	var opts []mirror.SyncOption
+	if len(flagProviderNetworkMirrorSyncPlatforms) > 0 {
+		var platforms []core.Platform
+		for _, raw := range flagProviderNetworkMirrorSyncPlatforms {
+			goos, goarch, ok := strings.Cut(raw, "_")
+			if !ok || goos == "" || goarch == "" {
+				return nil, fmt.Errorf("invalid --network-mirror-sync-platforms %q, expected os_arch", raw)
+			}
+			platforms = append(platforms, core.Platform{OS: goos, Arch: goarch})
+		}
+		opts = append(opts, mirror.WithSyncPlatforms(platforms))
+	}
+	if blocklist != nil {
+		opts = append(opts, mirror.WithSyncBlocklist(blocklist))
+	}
+	if flagProviderNetworkMirrorSyncWebhookURL != "" {
+		opts = append(opts, mirror.WithSyncNotifier(mirror.NewWebhookNotifier(flagProviderNetworkMirrorSyncWebhookURL)))
+	}
+
+	interval := flagProviderNetworkMirrorSyncInterval
+	if _, scheduled := schedules[taskMirrorSync]; scheduled {
+		interval = 0
+	}
+	return mirror.NewSyncer(ctx, s, targets, interval, opts...), nil
+}
+
+// registerUsage registers the storage usage API, which is only served with authentication
+func registerUsage(mux *http.ServeMux, scanner *usage.Scanner, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) {
+	if !authEnabled() {
+		slog.Debug("the usage API is disabled, as authentication isn't configured")
+		return
+	}
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(usage.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/usage`, prefixAdmin),
+		http.StripPrefix(
+			prefixAdmin,
+			usage.MakeHandler(
+				scanner,
+				authMiddleware,
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+}
+
+// registerMaintenance registers the API to toggle the maintenance mode, which is only served with authentication
+func registerMaintenance(mux *http.ServeMux, mode *maintenance.Mode, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) {
+	if !authEnabled() {
+		slog.Debug("the maintenance API is disabled, as authentication isn't configured")
+		return
+	}
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(maintenance.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/maintenance`, prefixAdmin),
+		http.StripPrefix(
+			prefixAdmin,
+			maintenance.MakeHandler(
+				mode,
+				authMiddleware,
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+}
+
+// registerLogLevel registers the API to change the log levels at runtime, which is only served with authentication
+func registerLogLevel(mux *http.ServeMux, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) {
+	if !authEnabled() {
+		slog.Debug("the log level API is disabled, as authentication isn't configured")
+		return
+	}
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(loglevel.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/log-level`, prefixAdmin),
+		http.StripPrefix(
+			prefixAdmin,
+			loglevel.MakeHandler(
+				logLevels,
+				authMiddleware,
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+}
+
+func registerScheduler(mux *http.ServeMux, sched *scheduler.Scheduler, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) {
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(scheduler.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	// The tasks can still be listed in read-only mode
+	handler := readOnly(http.StripPrefix(
+		prefixAdmin,
+		scheduler.MakeHandler(
+			sched,
+			authMiddleware,
+			instrumentation,
+			opts...,
+		),
+	))
+
+	// The task list is served without a trailing slash, which the http.ServeMux would redirect otherwise
+	mux.Handle(fmt.Sprintf(`%s/tasks`, prefixAdmin), handler)
+	mux.Handle(fmt.Sprintf(`%s/tasks/`, prefixAdmin), handler)
+}
+
+// registerModuleAdmin registers the API to manage channels, republish and promote module versions, which is only served with authentication
+func registerModuleAdmin(mux *http.ServeMux, s storage.Storage, service module.Service, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) error {
+	if !authEnabled() {
+		// Replacing module versions must never be possible anonymously
+		if len(flagAllowOverwrite) > 0 {
+			return errors.New("--allow-overwrite requires authentication to be configured")
+		}
+		slog.Debug("the module admin API is disabled, as authentication isn't configured")
+		return nil
+	}
+	replacer, ok := s.(module.ReplaceStorage)
+	if !ok {
+		return errors.New("the storage backend doesn't support replacing modules")
+	}
+
+	// Staged module versions can only be promoted if the storage backend supports staging
+	var promoter *module.Promoter
+	if stagingStorage, ok := s.(module.StagingStorage); ok {
+		promoter = module.NewPromoter(stagingStorage, flagPromotionRequiredApprovals)
+	}
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(module.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+			auth.NamespaceToContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/modules/`, prefixAdmin),
+		readOnly(http.StripPrefix(
+			prefixAdmin,
+			module.MakeAdminHandler(
+				service,
+				s,
+				replacer,
+				module.NewOverwritePolicy(flagAllowOverwrite),
+				promoter,
+				authMiddleware,
+				instrumentation,
+				opts...,
+			),
+		)),
+	)
+
+	return nil
+}
+
+// registerResumableUploads registers the API to upload provider release files in chunks, which is only served with authentication
+func registerResumableUploads(ctx context.Context, mux *http.ServeMux, s storage.Storage, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) error {
+	if !authEnabled() {
+		return errors.New("--resumable-uploads requires authentication to be configured")
+	}
+
+	manager, err := resumable.NewManager(ctx, s, flagResumableUploadDir, flagResumableUploadTTL, resumable.WithMaxLength(flagServerMaxBodySize))
+	if err != nil {
+		return err
+	}
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(resumable.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+			auth.NamespaceToContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/uploads/`, prefixAdmin),
+		readOnly(http.StripPrefix(
+			prefixAdmin,
+			resumable.MakeHandler(
+				manager,
+				authMiddleware,
+				instrumentation,
+				opts...,
+			),
+		)),
+	)
+
+	return nil
+}
+
+// setupEventBus forwards the events of the broker to the event bus, if one is configured
+func setupEventBus(ctx context.Context, broker *events.Broker) error {
+	var sink events.Sink
+	var err error
+	switch flagEventBus {
+	case "":
+		return nil
+	case "nats":
+		sink, err = events.NewNATSSink(flagEventBusURL)
+	case "kafka-rest":
+		sink, err = events.NewKafkaRESTSink(flagEventBusURL)
+	default:
+		return fmt.Errorf("invalid --event-bus %q, expected nats or kafka-rest", flagEventBus)
+	}
+	if err != nil {
+		return err
+	}
+	if err := events.ValidateSchema(flagEventBusSchema); err != nil {
+		return err
+	}
+
+	events.NewForwarder(ctx, broker, sink,
+		events.WithTopic(flagEventBusTopic),
+		events.WithSchema(flagEventBusSchema),
+	)
+	slog.Info("publishing events to the event bus", slog.String("event-bus", flagEventBus), slog.String("topic", flagEventBusTopic))
+	return nil
+}
+
+// registerEvents registers the event feed, which is only served with authentication
+func registerEvents(mux *http.ServeMux, broker *events.Broker, authMiddleware endpoint.Middleware, instrumentation o11y.Middleware) {
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(events.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/events`, prefixAdmin),
+		http.StripPrefix(
+			prefixAdmin,
+			events.MakeHandler(
+				broker,
+				authMiddleware,
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+}
+
+func registerProxy(mux *http.ServeMux, storage storage.Storage, urls core.ProxyUrlService, metrics *o11y.ProxyMetrics, instrumentation o11y.Middleware) error {
 	opts := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(proxy.ErrorEncoder),
 		httptransport.ServerBefore(
@@ -489,6 +1654,8 @@ func registerProxy(mux *http.ServeMux, storage storage.Storage, metrics *o11y.Pr
 			prefixProxy,
 			proxy.MakeHandler(
 				storage,
+				urls,
+				storageHTTPClientConfig().Client(),
 				metrics,
 				instrumentation,
 				opts...,
@@ -498,3 +1665,106 @@ func registerProxy(mux *http.ServeMux, storage storage.Storage, metrics *o11y.Pr
 
 	return nil
 }
+
+// setupProxyUrlService returns the ProxyUrlService, which signs the proxy URLs with Vault Transit if a key is configured
+func setupProxyUrlService() (core.ProxyUrlService, error) {
+	if flagProxyVaultTransitKey == "" {
+		return core.NewProxyUrlService(flagProxy, prefixProxy), nil
+	}
+
+	if !flagProxy {
+		return nil, errors.New("download-proxy-vault-transit-key requires download-proxy")
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, errors.New("VAULT_ADDR is required to sign download proxy URLs with Vault Transit")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return nil, err
+	}
+
+	signer := core.NewVaultTransitSigner(&http.Client{Timeout: secretTimeout}, core.VaultTransitConfig{
+		Address:   addr,
+		Token:     token,
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Mount:     flagProxyVaultTransitMount,
+		Key:       flagProxyVaultTransitKey,
+	})
+
+	return core.NewProxyUrlService(flagProxy, prefixProxy, core.WithProxyUrlSigner(signer, flagProxySignedURLExpiry)), nil
+}
+
+// cdnDecorator returns the Decorator serving the provider downloads, and optionally the module downloads, from the CDN with URLs signed by the configured signer
+func cdnDecorator() (storage.Decorator, error) {
+	var signer storage.URLSigner
+	if flagCDNSigner != "" {
+		if flagCDNKeyID == "" || flagCDNKeyFile == "" {
+			return nil, errors.New("--download-cdn-signer requires --download-cdn-key-id and --download-cdn-key-file")
+		}
+		key, err := os.ReadFile(flagCDNKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CDN key: %w", err)
+		}
+
+		switch flagCDNSigner {
+		case "cloudfront":
+			signer, err = storage.NewCloudFrontSigner(flagCDNKeyID, key)
+		case "cloudcdn":
+			signer, err = storage.NewCloudCDNSigner(flagCDNKeyID, key)
+		default:
+			return nil, fmt.Errorf("unknown CDN signer %q, expected cloudfront or cloudcdn", flagCDNSigner)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return storage.CDNDecorator(flagCDNBaseURL, signer, flagCDNURLExpiry, flagCDNModules), nil
+}
+
+// setupRedirector returns the DownloadRedirector if download redirects are enabled, and nil otherwise
+func setupRedirector() (core.DownloadRedirector, error) {
+	if !flagRedirect {
+		return nil, nil
+	}
+
+	if flagProxy {
+		return nil, errors.New("download-proxy and download-redirect are mutually exclusive")
+	}
+
+	secret := []byte(flagRedirectSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate download redirect secret: %w", err)
+		}
+		slog.Warn("no download redirect secret configured, generated a random one. All replicas need to share the same secret")
+	}
+
+	return core.NewDownloadRedirector(prefixRedirect, secret, flagRedirectExpiry), nil
+}
+
+func registerRedirect(mux *http.ServeMux, redirector core.DownloadRedirector, s storage.Storage, recorder stats.Recorder, metrics *o11y.RedirectMetrics, instrumentation o11y.Middleware) {
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(redirect.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixRedirect),
+		http.StripPrefix(
+			prefixRedirect,
+			redirect.MakeHandler(
+				redirector,
+				s,
+				recorder,
+				metrics,
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+}
@@ -7,8 +7,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/boring-registry/boring-registry/pkg/maintenance"
 )
 
 func TestAuthMiddleware(t *testing.T) {
@@ -62,6 +65,12 @@ func TestAuthMiddleware(t *testing.T) {
 			authOktaAuthz:    "/authz",
 			authOktaToken:    "/token",
 		},
+		{
+			name:             "external login endpoints are configured",
+			authOktaClientId: "boring-registry",
+			authOktaAuthz:    "https://login.example.com/authz",
+			authOktaToken:    "https://login.example.com/token",
+		},
 	}
 
 	for _, test := range tests {
@@ -73,7 +82,7 @@ func TestAuthMiddleware(t *testing.T) {
 		flagAuthOktaAuthz = test.authOktaAuthz
 		flagAuthOktaToken = test.authOktaToken
 
-		mw, login, err := authMiddleware(context.Background())
+		mw, login, err := authMiddleware(context.Background(), &reloadHooks{})
 		if test.wantErr {
 			assert.Error(t, err)
 			assert.ErrorContains(t, err, test.errMessage)
@@ -94,3 +103,78 @@ func TestAuthMiddleware(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterDiscovery(t *testing.T) {
+	defer func() { flagDiscoveryBaseURL, flagDiscoveryServices = "", nil }()
+	setRoutePrefixes("")
+
+	flagDiscoveryBaseURL = "https://downloads.example.com/"
+	flagDiscoveryServices = []string{"tfe.v2=https://tfe.example.com/api/v2/", `state.v1={"url": "https://state.example.com/"}`}
+	mux := http.NewServeMux()
+	assert.NoError(t, registerDiscovery(mux, nil, maintenance.NewMode(time.Minute)))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/terraform.json", nil))
+	assert.JSONEq(t, `{
+		"modules.v1": "https://downloads.example.com/v1/modules/",
+		"providers.v1": "https://downloads.example.com/v1/providers/",
+		"tfe.v2": "https://tfe.example.com/api/v2/",
+		"state.v1": {"url": "https://state.example.com/"}
+	}`, rec.Body.String())
+
+	flagDiscoveryBaseURL = "downloads.example.com"
+	assert.Error(t, registerDiscovery(http.NewServeMux(), nil, maintenance.NewMode(time.Minute)))
+
+	flagDiscoveryBaseURL = ""
+	flagDiscoveryServices = []string{"providers.v1=/providers/"}
+	assert.Error(t, registerDiscovery(http.NewServeMux(), nil, maintenance.NewMode(time.Minute)))
+}
+
+func TestTelemetryMux(t *testing.T) {
+	tests := []struct {
+		name   string
+		debug  bool
+		path   string
+		status int
+	}{
+		{name: "metrics", path: "/metrics", status: http.StatusOK},
+		{name: "readiness", path: "/readyz", status: http.StatusOK},
+		{name: "pprof is disabled by default", path: "/debug/pprof/", status: http.StatusNotFound},
+		{name: "expvar is disabled by default", path: "/debug/vars", status: http.StatusNotFound},
+		{name: "pprof", debug: true, path: "/debug/pprof/heap", status: http.StatusOK},
+		{name: "expvar", debug: true, path: "/debug/vars", status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			flagTelemetryDebug = tc.debug
+			defer func() { flagTelemetryDebug = false }()
+
+			rec := httptest.NewRecorder()
+			telemetryMux(maintenance.NewMode(time.Minute)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, tc.status, rec.Code)
+			if tc.path == "/debug/vars" && tc.status == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"goroutines"`)
+			}
+		})
+	}
+}
+
+func TestWithoutAdmin(t *testing.T) {
+	handler := withoutAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), []string{"/admin", "/team-a/admin"})
+
+	for path, status := range map[string]int{
+		"/v1/modules/acme/vpc/aws/versions":    http.StatusNoContent,
+		"/administration":                      http.StatusNoContent,
+		"/admin":                               http.StatusNotFound,
+		"/admin/v1/modules/acme/vpc/aws/1.0.0": http.StatusNotFound,
+		"/team-a/admin/maintenance":            http.StatusNotFound,
+		"/team-b/admin/maintenance":            http.StatusNoContent,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, path, nil))
+		assert.Equal(t, status, rec.Code, path)
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// processFlags configure the process as a whole and can't be overridden by a tenant
+var processFlags = map[string]struct{}{
+	"debug":                    {},
+	"json":                     {},
+	"listen-address":           {},
+	"listen-telemetry-address": {},
+	"telemetry-debug":          {},
+	"access-log":               {},
+	"server-read-timeout":      {},
+	"server-write-timeout":     {},
+	"server-idle-timeout":      {},
+	"server-max-header-bytes":  {},
+	"server-max-body-size":     {},
+	"tls-cert-file":            {},
+	"tls-key-file":             {},
+	"tenants-file":             {},
+	"maintenance-retry-after":  {},
+}
+
+type flagSnapshot struct {
+	value   string
+	slice   []string
+	changed bool
+}
+
+// withTenantFlags overrides the flags with the values of a tenant while fn is executed.
+// The original values are restored afterward, so that every tenant starts from the same configuration.
+func withTenantFlags(flags *pflag.FlagSet, overrides map[string]string, fn func() error) (err error) {
+	snapshots := make(map[*pflag.Flag]flagSnapshot, len(overrides))
+	defer func() {
+		for f, snapshot := range snapshots {
+			if restoreErr := restoreFlag(f, snapshot); restoreErr != nil && err == nil {
+				err = restoreErr
+			}
+		}
+	}()
+
+	for name, value := range overrides {
+		if _, ok := processFlags[name]; ok {
+			return fmt.Errorf("flag %s can't be configured per tenant", name)
+		}
+
+		f := flags.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag %s", name)
+		}
+
+		snapshot := flagSnapshot{value: f.Value.String(), changed: f.Changed}
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			snapshot.slice = s.GetSlice()
+		}
+		snapshots[f] = snapshot
+
+		if err := setFlag(f, value); err != nil {
+			return fmt.Errorf("invalid value of flag %s: %w", name, err)
+		}
+	}
+
+	return fn()
+}
+
+// withRoutePrefixes registers the routes of a tenant below its path prefix while fn is executed,
+// so that the service discovery and the download URLs point to the paths of the tenant
+func withRoutePrefixes(root string, fn func() error) error {
+	setRoutePrefixes(root)
+	defer setRoutePrefixes("")
+
+	return fn()
+}
+
+// setFlag replaces the value of the flag, slices are given as comma-separated values.
+// String arrays are not split, as their values may contain commas.
+func setFlag(f *pflag.Flag, value string) error {
+	if s, ok := f.Value.(pflag.SliceValue); ok {
+		values := []string{value}
+		if f.Value.Type() != "stringArray" {
+			values = splitValues(value)
+		}
+		if err := s.Replace(values); err != nil {
+			return err
+		}
+	} else if err := f.Value.Set(value); err != nil {
+		return err
+	}
+
+	f.Changed = true
+	return nil
+}
+
+func splitValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func restoreFlag(f *pflag.Flag, snapshot flagSnapshot) error {
+	var err error
+	if s, ok := f.Value.(pflag.SliceValue); ok {
+		err = s.Replace(snapshot.slice)
+	} else {
+		err = f.Value.Set(snapshot.value)
+	}
+
+	f.Changed = snapshot.changed
+	return err
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTenantFlags(t *testing.T) {
+	var (
+		prefix    string
+		tokens    []string
+		ttl       time.Duration
+		schedules []string
+	)
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&prefix, "storage-s3-prefix", "default", "")
+	flags.StringSliceVar(&tokens, "auth-static-token", nil, "")
+	flags.DurationVar(&ttl, "storage-cache-ttl", time.Minute, "")
+	flags.StringArrayVar(&schedules, "schedule", nil, "")
+	flags.StringVar(new(string), "listen-address", ":5601", "")
+
+	err := withTenantFlags(flags, map[string]string{
+		"storage-s3-prefix": "team-a",
+		"auth-static-token": "token-a, token-b",
+		"storage-cache-ttl": "5m",
+		"schedule":          "stats-flush=0,30 * * * *",
+	}, func() error {
+		assert.Equal(t, "team-a", prefix)
+		assert.Equal(t, []string{"token-a", "token-b"}, tokens)
+		assert.Equal(t, 5*time.Minute, ttl)
+		assert.Equal(t, []string{"stats-flush=0,30 * * * *"}, schedules)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// The original values are restored
+	assert.Equal(t, "default", prefix)
+	assert.Nil(t, tokens)
+	assert.Equal(t, time.Minute, ttl)
+	assert.Empty(t, schedules)
+
+	called := false
+	fn := func() error {
+		called = true
+		return nil
+	}
+	assert.ErrorContains(t, withTenantFlags(flags, map[string]string{"listen-address": ":8080"}, fn), "can't be configured per tenant")
+	assert.ErrorContains(t, withTenantFlags(flags, map[string]string{"unknown": "value"}, fn), "unknown flag")
+	assert.ErrorContains(t, withTenantFlags(flags, map[string]string{"storage-cache-ttl": "invalid"}, fn), "invalid value")
+	assert.False(t, called)
+	assert.Equal(t, time.Minute, ttl)
+}
+
+func TestWithRoutePrefixes(t *testing.T) {
+	err := withRoutePrefixes("/sandbox", func() error {
+		assert.Equal(t, "/sandbox/v1/modules", prefixModules)
+		assert.Equal(t, "/sandbox/v1/proxy", prefixProxy)
+		assert.Equal(t, "/sandbox/admin", prefixAdmin)
+		assert.Equal(t, "/sandbox/.well-known/terraform.json", prefixDiscovery)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// The prefixes are restored for the next tenant
+	assert.Equal(t, "/v1/modules", prefixModules)
+	assert.Equal(t, "/admin", prefixAdmin)
+	assert.Equal(t, "/.well-known/terraform.json", prefixDiscovery)
+}
@@ -0,0 +1,493 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+)
+
+const (
+	defaultTFEAddress = "https://app.terraform.io"
+
+	// tfePageSize is the maximum page size of the API
+	tfePageSize = 100
+
+	// tfeRegistryPrivate is the registry of the modules and providers published in the organization,
+	// public ones are only referenced from the public Terraform Registry
+	tfeRegistryPrivate = "private"
+)
+
+// tfeModule is the subset of a registry module of the Terraform Cloud/Enterprise API needed to import it
+type tfeModule struct {
+	Attributes struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		Provider        string `json:"provider"`
+		RegistryName    string `json:"registry-name"`
+		VersionStatuses []struct {
+			Version string `json:"version"`
+			Status  string `json:"status"`
+		} `json:"version-statuses"`
+	} `json:"attributes"`
+}
+
+type tfeProvider struct {
+	Attributes struct {
+		Name         string `json:"name"`
+		Namespace    string `json:"namespace"`
+		RegistryName string `json:"registry-name"`
+	} `json:"attributes"`
+}
+
+type tfeProviderVersion struct {
+	Attributes struct {
+		Version            string   `json:"version"`
+		Protocols          []string `json:"protocols"`
+		ShasumsUploaded    bool     `json:"shasums-uploaded"`
+		ShasumsSigUploaded bool     `json:"shasums-sig-uploaded"`
+	} `json:"attributes"`
+	Links struct {
+		ShasumsDownload    string `json:"shasums-download"`
+		ShasumsSigDownload string `json:"shasums-sig-download"`
+	} `json:"links"`
+}
+
+type tfeProviderPlatform struct {
+	Attributes struct {
+		Filename string `json:"filename"`
+	} `json:"attributes"`
+	Links struct {
+		ProviderBinaryDownload string `json:"provider-binary-download"`
+	} `json:"links"`
+}
+
+type tfeGPGKey struct {
+	Attributes struct {
+		KeyID      string `json:"key-id"`
+		ASCIIArmor string `json:"ascii-armor"`
+	} `json:"attributes"`
+}
+
+// tfeClient reads the private registry of an organization from the Terraform Cloud/Enterprise API
+type tfeClient struct {
+	client       *http.Client
+	address      string
+	token        string
+	organization string
+}
+
+// tfeList returns the resources of all pages of the API endpoint
+func tfeList[T any](ctx context.Context, c *tfeClient, endpoint string, query url.Values) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("page[size]", fmt.Sprint(tfePageSize))
+
+	var res []T
+	for page := 1; page > 0; {
+		query.Set("page[number]", fmt.Sprint(page))
+		resp, err := c.do(ctx, c.url(endpoint)+"?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+
+		var document struct {
+			Data []T `json:"data"`
+			Meta struct {
+				Pagination struct {
+					NextPage *int `json:"next-page"`
+				} `json:"pagination"`
+			} `json:"meta"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&document)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", endpoint, err)
+		}
+
+		res = append(res, document.Data...)
+		page = 0
+		if document.Meta.Pagination.NextPage != nil {
+			page = *document.Meta.Pagination.NextPage
+		}
+	}
+	return res, nil
+}
+
+func (c *tfeClient) modules(ctx context.Context) ([]tfeModule, error) {
+	return tfeList[tfeModule](ctx, c, fmt.Sprintf("/api/v2/organizations/%s/registry-modules", url.PathEscape(c.organization)), nil)
+}
+
+func (c *tfeClient) providers(ctx context.Context) ([]tfeProvider, error) {
+	return tfeList[tfeProvider](ctx, c, fmt.Sprintf("/api/v2/organizations/%s/registry-providers", url.PathEscape(c.organization)), url.Values{"filter[registry_name]": {tfeRegistryPrivate}})
+}
+
+func (c *tfeClient) providerVersions(ctx context.Context, namespace, name string) ([]tfeProviderVersion, error) {
+	return tfeList[tfeProviderVersion](ctx, c, c.providerEndpoint(namespace, name)+"/versions", nil)
+}
+
+func (c *tfeClient) providerPlatforms(ctx context.Context, namespace, name, version string) ([]tfeProviderPlatform, error) {
+	return tfeList[tfeProviderPlatform](ctx, c, fmt.Sprintf("%s/versions/%s/platforms", c.providerEndpoint(namespace, name), url.PathEscape(version)), nil)
+}
+
+func (c *tfeClient) providerEndpoint(namespace, name string) string {
+	return fmt.Sprintf("/api/v2/organizations/%s/registry-providers/%s/%s/%s", url.PathEscape(c.organization), tfeRegistryPrivate, url.PathEscape(namespace), url.PathEscape(name))
+}
+
+// gpgKeys returns the keys providers of the namespace are signed with
+func (c *tfeClient) gpgKeys(ctx context.Context, namespace string) ([]tfeGPGKey, error) {
+	return tfeList[tfeGPGKey](ctx, c, "/api/registry/private/v2/gpg-keys", url.Values{"filter[namespace]": {namespace}})
+}
+
+// moduleArchive downloads the archive of the module version, which the module registry protocol redirects to with the X-Terraform-Get header
+func (c *tfeClient) moduleArchive(ctx context.Context, namespace, name, provider, version string) ([]byte, error) {
+	u := c.url(fmt.Sprintf("/api/registry/v1/modules/%s/%s/%s/%s/download", url.PathEscape(namespace), url.PathEscape(name), url.PathEscape(provider), url.PathEscape(version)))
+	resp, err := c.do(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("X-Terraform-Get")
+	if location == "" {
+		return nil, fmt.Errorf("missing X-Terraform-Get header in the response of %s", u)
+	}
+	archiveURL, err := resp.Request.URL.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid X-Terraform-Get header %q: %w", location, err)
+	}
+	return c.download(ctx, archiveURL.String())
+}
+
+// download returns the content of the URL, which are presigned URLs of the archivist in case of files of providers
+func (c *tfeClient) download(ctx context.Context, u string) ([]byte, error) {
+	resp, err := c.do(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", u, err)
+	}
+	return data, nil
+}
+
+func (c *tfeClient) url(endpoint string) string {
+	return strings.TrimSuffix(c.address, "/") + endpoint
+}
+
+func (c *tfeClient) do(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.api+json")
+	// The token isn't sent to other hosts, like the storage the archivist redirects to
+	if address, err := url.Parse(c.address); err == nil && address.Host == req.URL.Host {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, u)
+	}
+	return resp, nil
+}
+
+// tfeImportResult counts the imported versions, versions which exist already are skipped
+type tfeImportResult struct {
+	Modules   int `json:"modules"`
+	Providers int `json:"providers"`
+	Skipped   int `json:"skipped"`
+}
+
+// tfeImporter imports the private registry of a Terraform Cloud/Enterprise organization into the storage
+type tfeImporter struct {
+	client  *tfeClient
+	storage storage.Storage
+
+	// namespace replaces the namespace of the organization if set
+	namespace string
+
+	// dir is the directory the files of provider releases are downloaded to
+	dir string
+}
+
+func (i *tfeImporter) targetNamespace(namespace string) string {
+	if i.namespace != "" {
+		return i.namespace
+	}
+	return namespace
+}
+
+// importModules imports all versions of the private modules, failed versions don't stop the import of the others
+func (i *tfeImporter) importModules(ctx context.Context, result *tfeImportResult) error {
+	modules, err := i.client.modules(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Archives downloaded from the API are always gzipped tarballs
+	ctx = module.WithArchiveFormat(ctx, module.ArchiveFormatTarGz)
+	var errs []error
+	for _, m := range modules {
+		if m.Attributes.RegistryName != tfeRegistryPrivate {
+			continue
+		}
+
+		for _, v := range m.Attributes.VersionStatuses {
+			logger := slog.Default().With(
+				slog.String("namespace", m.Attributes.Namespace),
+				slog.String("name", m.Attributes.Name),
+				slog.String("provider", m.Attributes.Provider),
+				slog.String("version", v.Version),
+			)
+			// Versions which failed to ingest from the VCS don't have an archive
+			if v.Status != "ok" {
+				logger.Warn("skipping module version", slog.String("status", v.Status))
+				continue
+			}
+
+			archive, err := i.client.moduleArchive(ctx, m.Attributes.Namespace, m.Attributes.Name, m.Attributes.Provider, v.Version)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			_, err = i.storage.UploadModule(ctx, i.targetNamespace(m.Attributes.Namespace), m.Attributes.Name, m.Attributes.Provider, v.Version, bytes.NewReader(archive))
+			if errors.Is(err, module.ErrModuleAlreadyExists) {
+				logger.Debug("module version exists already")
+				result.Skipped++
+				continue
+			} else if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			logger.Info("imported module version")
+			result.Modules++
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// importProviders imports the signing keys and all versions of the private providers, failed versions don't stop the import of the others
+func (i *tfeImporter) importProviders(ctx context.Context, result *tfeImportResult) error {
+	providers, err := i.client.providers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	imported := make(map[string]bool)
+	for _, p := range providers {
+		if p.Attributes.RegistryName != tfeRegistryPrivate {
+			continue
+		}
+		namespace := i.targetNamespace(p.Attributes.Namespace)
+		if !imported[namespace] {
+			if err := i.importSigningKeys(ctx, p.Attributes.Namespace, namespace); err != nil {
+				return err
+			}
+			imported[namespace] = true
+		}
+
+		versions, err := i.client.providerVersions(ctx, p.Attributes.Namespace, p.Attributes.Name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, v := range versions {
+			logger := slog.Default().With(
+				slog.String("namespace", p.Attributes.Namespace),
+				slog.String("name", p.Attributes.Name),
+				slog.String("version", v.Attributes.Version),
+			)
+			if !v.Attributes.ShasumsUploaded || !v.Attributes.ShasumsSigUploaded {
+				logger.Warn("skipping provider version without SHA256SUMS or signature")
+				continue
+			}
+
+			if exists, err := i.providerVersionExists(ctx, namespace, p.Attributes.Name, v.Attributes.Version); err != nil {
+				errs = append(errs, err)
+				continue
+			} else if exists {
+				logger.Debug("provider version exists already")
+				result.Skipped++
+				continue
+			}
+
+			if err := i.importProviderVersion(ctx, p, v); err != nil {
+				errs = append(errs, fmt.Errorf("failed to import provider %s/%s %s: %w", p.Attributes.Namespace, p.Attributes.Name, v.Attributes.Version, err))
+				continue
+			}
+			logger.Info("imported provider version")
+			result.Providers++
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// importSigningKeys adds the GPG keys of the organization to the signing keys of the namespace
+func (i *tfeImporter) importSigningKeys(ctx context.Context, tfeNamespace, namespace string) error {
+	keysStorage, ok := i.storage.(signingKeysStorage)
+	if !ok {
+		return errors.New("the storage backend doesn't support uploading signing keys")
+	}
+
+	keys, err := i.client.gpgKeys(ctx, tfeNamespace)
+	if err != nil {
+		return err
+	}
+	signingKeys, err := i.storage.SigningKeys(ctx, namespace, "")
+	if errors.Is(err, core.ErrObjectNotFound) {
+		signingKeys = &core.SigningKeys{}
+	} else if err != nil {
+		return err
+	}
+
+	added := 0
+	for _, key := range keys {
+		known := slices.ContainsFunc(signingKeys.GPGPublicKeys, func(k core.GPGPublicKey) bool {
+			return strings.EqualFold(k.KeyID, key.Attributes.KeyID)
+		})
+		if !known {
+			signingKeys.GPGPublicKeys = append(signingKeys.GPGPublicKeys, core.GPGPublicKey{KeyID: key.Attributes.KeyID, ASCIIArmor: key.Attributes.ASCIIArmor})
+			added++
+		}
+	}
+	if added == 0 {
+		return nil
+	}
+
+	if err := keysStorage.UploadSigningKeys(ctx, namespace, "", signingKeys); err != nil {
+		return err
+	}
+	slog.Info("imported signing keys", slog.String("namespace", namespace), slog.Int("keys", added))
+	return nil
+}
+
+func (i *tfeImporter) providerVersionExists(ctx context.Context, namespace, name, version string) (bool, error) {
+	res, err := i.storage.ListProviderVersions(ctx, namespace, name)
+	var providerError *core.ProviderError
+	if errors.Is(err, provider.ErrProviderNotFound) || (errors.As(err, &providerError) && providerError.StatusCode == http.StatusNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(res.Versions, func(v core.ProviderVersion) bool { return v.Version == version }), nil
+}
+
+// importProviderVersion downloads the release files, verifies them with the SHA256SUMS file and its signature,
+// and uploads them like `upload provider`, with the SHA256SUMS and signature files last.
+func (i *tfeImporter) importProviderVersion(ctx context.Context, p tfeProvider, v tfeProviderVersion) error {
+	namespace, name, version := i.targetNamespace(p.Attributes.Namespace), p.Attributes.Name, v.Attributes.Version
+
+	sumsBytes, err := i.client.download(ctx, v.Links.ShasumsDownload)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := i.client.download(ctx, v.Links.ShasumsSigDownload)
+	if err != nil {
+		return err
+	}
+	signingKeys, err := i.storage.SigningKeys(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := signingKeys.IsValidSha256Sums(sumsBytes, sigBytes); err != nil {
+		return err
+	}
+
+	sumsName := fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", name, version)
+	sums, err := core.NewSha256Sums(sumsName, bytes.NewReader(sumsBytes))
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(i.dir, "provider-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	platforms, err := i.client.providerPlatforms(ctx, p.Attributes.Namespace, name, version)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, platform := range platforms {
+		archive, err := i.client.download(ctx, platform.Links.ProviderBinaryDownload)
+		if err != nil {
+			return err
+		}
+		checksum, ok := sums.Entries[platform.Attributes.Filename]
+		if !ok {
+			return fmt.Errorf("%s isn't listed in %s", platform.Attributes.Filename, sumsName)
+		}
+		if actual := sha256.Sum256(archive); !bytes.Equal(actual[:], checksum) {
+			return fmt.Errorf("checksum of %s doesn't match %s", platform.Attributes.Filename, sumsName)
+		}
+
+		path := filepath.Join(dir, filepath.Base(platform.Attributes.Filename))
+		if err := os.WriteFile(path, archive, 0o600); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+
+	// The protocols are stored in the manifest, as goreleaser would publish it
+	if len(v.Attributes.Protocols) > 0 {
+		manifest := core.ProviderManifest{Version: 1}
+		manifest.Metadata.ProtocolVersions = v.Attributes.Protocols
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, manifestFileName(sums))
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	if err := uploadProviderReleaseFilesParallel(ctx, i.storage, paths, namespace, name); err != nil {
+		return err
+	}
+
+	// The SHA256SUMS and signature files are uploaded last, so that they only reference archives which exist already
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{{sumsName, sumsBytes}, {sumsName + ".sig", sigBytes}} {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, f.data, 0o600); err != nil {
+			return err
+		}
+		if err := uploadProviderReleaseFileWithRetry(ctx, i.storage, path, namespace, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
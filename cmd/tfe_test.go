@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTFEImporter(t *testing.T) {
+	ctx := context.Background()
+
+	entity, err := openpgp.NewEntity("boring-registry", "", "providers@example.com", nil)
+	assert.NoError(t, err)
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(w))
+	assert.NoError(t, w.Close())
+
+	archive := []byte("archive")
+	sums := []byte(fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256(archive)))
+	var signature bytes.Buffer
+	assert.NoError(t, openpgp.DetachSign(&signature, entity, bytes.NewReader(sums), nil))
+
+	// The modules are served on two pages
+	pages := map[string]string{
+		"1": `{"data": [{"attributes": {"name": "vpc", "namespace": "acme", "provider": "aws", "registry-name": "private", "version-statuses": [{"version": "1.0.0", "status": "ok"}, {"version": "1.1.0", "status": "reg_ingress_failed"}]}}], "meta": {"pagination": {"next-page": 2}}}`,
+		"2": `{"data": [{"attributes": {"name": "consul", "namespace": "hashicorp", "provider": "aws", "registry-name": "public", "version-statuses": [{"version": "0.1.0", "status": "ok"}]}}], "meta": {"pagination": {"next-page": null}}}`,
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		providerPath := "/api/v2/organizations/acme/registry-providers/private/acme/dummy"
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/registry-modules":
+			_, _ = w.Write([]byte(pages[r.URL.Query().Get("page[number]")]))
+		case "/api/registry/v1/modules/acme/vpc/aws/1.0.0/download":
+			w.Header().Set("X-Terraform-Get", "/archivist/vpc.tar.gz")
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v2/organizations/acme/registry-providers":
+			assert.Equal(t, "private", r.URL.Query().Get("filter[registry_name]"))
+			_, _ = w.Write([]byte(`{"data": [{"attributes": {"name": "dummy", "namespace": "acme", "registry-name": "private"}}]}`))
+		case "/api/registry/private/v2/gpg-keys":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{"attributes": map[string]string{
+				"key-id":      entity.PrimaryKey.KeyIdString(),
+				"ascii-armor": publicKey.String(),
+			}}}})
+		case providerPath + "/versions":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{
+				"attributes": map[string]any{"version": "1.0.0", "protocols": []string{"6.0"}, "shasums-uploaded": true, "shasums-sig-uploaded": true},
+				"links":      map[string]string{"shasums-download": server.URL + "/archivist/sums", "shasums-sig-download": server.URL + "/archivist/sig"},
+			}}})
+		case providerPath + "/versions/1.0.0/platforms":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{
+				"attributes": map[string]any{"filename": "terraform-provider-dummy_1.0.0_linux_amd64.zip"},
+				"links":      map[string]string{"provider-binary-download": server.URL + "/archivist/archive"},
+			}}})
+		case "/archivist/vpc.tar.gz":
+			_, _ = w.Write([]byte("module"))
+		case "/archivist/sums":
+			_, _ = w.Write(sums)
+		case "/archivist/sig":
+			_, _ = w.Write(signature.Bytes())
+		case "/archivist/archive":
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s := storage.NewMemoryStorage()
+	importer := &tfeImporter{
+		client:  &tfeClient{client: server.Client(), address: server.URL, token: "secret", organization: "acme"},
+		storage: s,
+		dir:     t.TempDir(),
+	}
+
+	var result tfeImportResult
+	assert.NoError(t, importer.importModules(ctx, &result))
+	assert.NoError(t, importer.importProviders(ctx, &result))
+	assert.Equal(t, tfeImportResult{Modules: 1, Providers: 1}, result)
+
+	modules, err := s.ListModuleVersions(ctx, "acme", "vpc", "aws")
+	assert.NoError(t, err)
+	assert.Len(t, modules, 1)
+	p, err := s.GetProvider(ctx, "acme", "dummy", "1.0.0", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "terraform-provider-dummy_1.0.0_linux_amd64.zip", p.Filename)
+	assert.Equal(t, []string{"6.0"}, p.Protocols)
+
+	// Imported versions are skipped when the import is resumed
+	result = tfeImportResult{}
+	assert.NoError(t, importer.importModules(ctx, &result))
+	assert.NoError(t, importer.importProviders(ctx, &result))
+	assert.Equal(t, tfeImportResult{Skipped: 2}, result)
+
+	// Archives which don't match the SHA256SUMS file aren't imported
+	archive = []byte("tampered")
+	importer.storage = storage.NewMemoryStorage()
+	assert.ErrorContains(t, importer.importProviders(ctx, &result), "checksum of terraform-provider-dummy_1.0.0_linux_amd64.zip doesn't match")
+}
@@ -3,19 +3,28 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/user"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/attestation"
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
 	"github.com/boring-registry/boring-registry/pkg/provider"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/hashicorp/go-version"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -28,16 +37,43 @@ var (
 	flagIgnoreExistingModule     bool
 	flagVersionConstraintsRegex  string
 	flagVersionConstraintsSemver string
+	flagModuleSigningKeyFile     string
+	flagModuleSigningPassphrase  string
+	flagAttestationIdentities    []string
+	flagAttestationTrustedRoot   string
+	flagAllowOverwrite           []string
+	flagUploadPublisher          string
+	flagUploadArchiveFormat      string
+	flagSBOMRequired             []string
+	flagUploadStage              bool
+	flagUploadChangedOnly        bool
+	flagUploadBumpPatch          bool
+	flagUploadFailFast           bool
+	flagUploadDetailedExitCodes  bool
 
 	// upload provider flags
 	flagFileSha256Sums       string
 	flagProviderArchivePaths []string
+	flagProviderManifest     string
 	flagProviderNamespace    string
+	flagProviderDocsDir      string
+	flagUploadParallelism    int
+	flagUploadRetries        int
+
+	// upload provider from GitHub release flags
+	flagGitHubRelease      string
+	flagGitHubToken        string
+	flagGitHubAPIURL       string
+	flagGitHubAssetPattern string
 )
 
 var (
 	versionConstraintsRegex  *regexp.Regexp
 	versionConstraintsSemver version.Constraints
+	moduleSigningKey         *openpgp.Entity
+
+	// uploadRetryBackoff is the initial delay between retries of a failed upload, it doubles on every attempt
+	uploadRetryBackoff = time.Second
 )
 
 func init() {
@@ -45,21 +81,45 @@ func init() {
 
 	uploadProviderCmd.Flags().StringVar(&flagFileSha256Sums, flagFileSha256SumsName, "", "The absolute path to the *_SHA256SUMS file")
 	uploadProviderCmd.Flags().StringSliceVar(&flagProviderArchivePaths, "filenames-provider-archives", []string{}, "A list of file paths to provider ZIP archives")
+	uploadProviderCmd.Flags().StringVar(&flagProviderManifest, "filename-manifest", "", "The absolute path to the terraform-registry-manifest.json file declaring the protocol versions, defaults to the *_manifest.json file next to the *_SHA256SUMS file")
 	uploadProviderCmd.Flags().StringVar(&flagProviderNamespace, flagProviderNamespaceName, "", "The namespace under which the provider will be uploaded")
-	for _, f := range []string{flagFileSha256SumsName, flagProviderNamespaceName} {
-		if err := uploadProviderCmd.MarkFlagRequired(f); err != nil {
-			panic(fmt.Errorf("failed to mark flag %s as required: %w", f, err))
-		}
+	uploadProviderCmd.Flags().StringVar(&flagProviderDocsDir, "docs-dir", "", "Path to the docs directory of the provider in the layout of tfplugindocs, which is served by the v2 provider API")
+	uploadProviderCmd.Flags().IntVar(&flagUploadParallelism, "parallelism", 4, "The number of provider archives which are uploaded in parallel")
+	uploadProviderCmd.Flags().IntVar(&flagUploadRetries, "retries", 3, "The number of times a failed upload of a single file is retried")
+	uploadProviderCmd.Flags().StringVar(&flagGitHubRelease, "github-release", "", "Download the provider release from the GitHub repository in the form <owner>/<repo>, the tag of the release is passed as argument")
+	uploadProviderCmd.Flags().StringVar(&flagGitHubToken, "github-token", "", "GitHub token to download releases of private repositories, defaults to the GITHUB_TOKEN environment variable")
+	uploadProviderCmd.Flags().StringVar(&flagGitHubAPIURL, "github-api-url", defaultGitHubAPIURL, "URL of the GitHub API, e.g. https://github.example.com/api/v3 for GitHub Enterprise Server")
+	uploadProviderCmd.Flags().StringVar(&flagGitHubAssetPattern, "github-asset-pattern", "^terraform-provider-", "Regular expression the names of the release assets have to match, e.g. to select one of multiple providers released together")
+	uploadProviderCmd.MarkFlagsMutuallyExclusive("github-release", flagFileSha256SumsName)
+	uploadProviderCmd.MarkFlagsMutuallyExclusive("github-release", "filenames-provider-archives")
+	uploadProviderCmd.MarkFlagsMutuallyExclusive("github-release", "filename-manifest")
+	uploadProviderCmd.MarkFlagsOneRequired("github-release", flagFileSha256SumsName)
+	if err := uploadProviderCmd.MarkFlagRequired(flagProviderNamespaceName); err != nil {
+		panic(fmt.Errorf("failed to mark flag %s as required: %w", flagProviderNamespaceName, err))
 	}
-	uploadCmd.AddCommand(uploadModuleCmd, uploadProviderCmd)
+	uploadCmd.AddCommand(uploadModuleCmd, uploadProviderCmd, uploadModuleAttestationCmd, uploadModuleSBOMCmd, uploadProviderSBOMCmd)
 
 	uploadCmd.PersistentFlags().BoolVar(&flagRecursive, "recursive", true, "Recursively traverse <dir> and upload all modules in subdirectories")
-	uploadCmd.PersistentFlags().BoolVar(&flagIgnoreExistingModule, "ignore-existing", true, "Ignore already existing modules. If set to false upload will fail immediately if a module already exists in that version")
+	uploadCmd.PersistentFlags().BoolVar(&flagIgnoreExistingModule, "ignore-existing", true, "Ignore already existing modules. If set to false modules which already exist in that version fail the upload")
 	uploadCmd.PersistentFlags().StringVar(&flagVersionConstraintsRegex, "version-constraints-regex", "", `Limit the module versions that are eligible for upload with a regex that a version has to match.
 Can be combined with the -version-constraints-semver flag`)
 	uploadCmd.PersistentFlags().StringVar(&flagVersionConstraintsSemver, "version-constraints-semver", "", `Limit the module versions that are eligible for upload with version constraints.
 The version string has to be formatted as a string literal containing one or more conditions, which are separated by commas.
 Can be combined with the -version-constrained-regex flag`)
+	uploadCmd.PersistentFlags().StringVar(&flagModuleSigningKeyFile, "module-signing-key-file", "", "Path to an ASCII-armored OpenPGP private key to create detached signatures of the uploaded module archives")
+	uploadCmd.PersistentFlags().StringVar(&flagModuleSigningPassphrase, "module-signing-key-passphrase", "", "Passphrase of the OpenPGP private key to sign module archives")
+	uploadCmd.PersistentFlags().StringSliceVar(&flagAllowOverwrite, "allow-overwrite", nil, "Namespaces in which existing module versions are replaced instead of skipped or rejected, * allows overwrites in all namespaces")
+	uploadCmd.PersistentFlags().StringArrayVar(&flagAttestationIdentities, "attestation-identity", nil, "Require Sigstore bundles signed by the identity in the form <issuer>=<subject regex>, can be passed multiple times")
+	uploadCmd.PersistentFlags().StringVar(&flagUploadPublisher, "publisher", defaultPublisher(), "Identity of the publisher, which uploaded objects are tagged with if --storage-tagging is enabled")
+	uploadCmd.PersistentFlags().StringVar(&flagUploadArchiveFormat, "archive-format", module.ArchiveFormatTarGz, "Format of the uploaded module archives, either tar.gz or zip")
+	uploadCmd.PersistentFlags().BoolVar(&flagUploadStage, "stage", false, "Stage the uploaded module versions, which are only published once they're promoted with the module promote command")
+	uploadCmd.PersistentFlags().BoolVar(&flagUploadChangedOnly, "changed-only", false, "Only upload modules whose files changed since their latest published version, which is detected by comparing the digests of the module directories")
+	uploadCmd.PersistentFlags().BoolVar(&flagUploadBumpPatch, "bump-patch", false, "Upload changed modules as the next patch version of their latest published version, unless boring-registry.hcl declares a higher version. Requires --changed-only")
+	uploadCmd.PersistentFlags().BoolVar(&flagUploadFailFast, "fail-fast", false, "Stop at the first module which fails to be uploaded instead of uploading the remaining modules")
+	uploadCmd.PersistentFlags().BoolVar(&flagUploadDetailedExitCodes, "detailed-exit-codes", false, `Exit with distinct codes for the outcome of the module upload:
+0 if modules were uploaded, 1 if all modules failed, 2 if some modules failed, and 3 if all modules were skipped`)
+	uploadCmd.PersistentFlags().StringSliceVar(&flagSBOMRequired, "sbom-required", nil, "Namespaces in which modules and providers can only be published with an SBOM, * requires SBOMs in all namespaces")
+	uploadCmd.PersistentFlags().StringVar(&flagAttestationTrustedRoot, "attestation-trusted-root", "", "Path to the Sigstore trusted root to verify bundles, the trusted root of the public-good instance is fetched if empty")
 }
 
 // uploadCmd uploads modules for legacy reasons.
@@ -79,13 +139,21 @@ var uploadModuleCmd = &cobra.Command{
 }
 
 var uploadProviderCmd = &cobra.Command{
-	Use:          "provider PROVIDER",
+	Use:          "provider [TAG]",
 	SilenceUsage: true,
+	Args:         cobra.MaximumNArgs(1),
 	RunE:         uploadProvider,
 }
 
 func uploadModule(cmd *cobra.Command, args []string) error {
-	storageBackend, err := setupStorage(context.Background())
+	ctx := context.Background()
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
+
+	storageBackend, err := setupStorage(ctx, decorators...)
 	if err != nil {
 		return fmt.Errorf("failed to set up storage: %w", err)
 	}
@@ -98,6 +166,14 @@ func uploadModule(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if flagUploadArchiveFormat != module.ArchiveFormatTarGz && flagUploadArchiveFormat != module.ArchiveFormatZip {
+		return fmt.Errorf("%w: %s, expected tar.gz or zip", module.ErrInvalidArchiveFormat, flagUploadArchiveFormat)
+	}
+
+	if flagUploadBumpPatch && !flagUploadChangedOnly {
+		return errors.New("--bump-patch requires --changed-only")
+	}
+
 	// Validate the semver version constraints
 	if flagVersionConstraintsSemver != "" {
 		constraints, err := version.NewConstraint(flagVersionConstraintsSemver)
@@ -108,6 +184,13 @@ func uploadModule(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate the regex version constraints
+	if flagModuleSigningKeyFile != "" {
+		moduleSigningKey, err = readSigningKey(flagModuleSigningKeyFile, flagModuleSigningPassphrase)
+		if err != nil {
+			return err
+		}
+	}
+
 	if flagVersionConstraintsRegex != "" {
 		constraints, err := regexp.Compile(flagVersionConstraintsRegex)
 		if err != nil {
@@ -116,10 +199,67 @@ func uploadModule(cmd *cobra.Command, args []string) error {
 		versionConstraintsRegex = constraints
 	}
 
-	return archiveModules(args[0], storageBackend)
+	result, err := archiveModules(args[0], storageBackend)
+	return uploadExitCode(result, printResult(result, err, nil))
+}
+
+const (
+	exitCodeTotalFailure   = 1
+	exitCodePartialFailure = 2
+	exitCodeNothingToDo    = 3
+)
+
+// uploadExitCode returns the error of the module upload with the exit code of its outcome if --detailed-exit-codes is set.
+// The upload failed partially if some modules failed while others were uploaded or skipped.
+func uploadExitCode(result *uploadResult, err error) error {
+	if !flagUploadDetailedExitCodes {
+		return err
+	}
+
+	processed := len(result.Published) + len(result.Skipped)
+	switch {
+	case err != nil && result.failed > 0 && processed > 0:
+		return &exitError{code: exitCodePartialFailure, err: err}
+	case err != nil:
+		return &exitError{code: exitCodeTotalFailure, err: err}
+	case len(result.Published) == 0:
+		return &exitError{code: exitCodeNothingToDo, err: errors.New("nothing to upload, all modules were skipped or none were found")}
+	}
+	return nil
+}
+
+// providerUploadResult lists the files of the provider release published by the upload provider command
+type providerUploadResult struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Published []string `json:"published"`
 }
 
 func uploadProvider(cmd *cobra.Command, args []string) error {
+	result := &providerUploadResult{Namespace: flagProviderNamespace, Published: []string{}}
+	err := uploadProviderRelease(args, result)
+	return printResult(result, err, nil)
+}
+
+func uploadProviderRelease(args []string, result *providerUploadResult) error {
+	if flagGitHubRelease != "" {
+		if len(args) != 1 {
+			return errors.New("the tag of the GitHub release has to be passed as argument")
+		}
+		dir, err := os.MkdirTemp("", "boring-registry-release-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		sumsPath, err := downloadProviderGitHubRelease(context.Background(), args[0], dir)
+		if err != nil {
+			return err
+		}
+		flagFileSha256Sums = sumsPath
+	}
+
 	if !filepath.IsAbs(flagFileSha256Sums) {
 		return fmt.Errorf("file path is not absolute: %s", flagFileSha256Sums)
 	}
@@ -138,18 +278,36 @@ func uploadProvider(cmd *cobra.Command, args []string) error {
 	if err := validateShaSums(sums); err != nil {
 		return err
 	}
+	result.Name, _ = sums.Name()
+	result.Version, _ = sums.Version()
+	manifest, err := readProviderManifest(sums)
+	if err != nil {
+		return err
+	}
+
+	// The publisher is the identity of the upload, e.g. in the input of the admission policy
+	ctx := core.WithIdentity(context.Background(), flagUploadPublisher)
+	decorators, waitForReplication, err := replicationDecorators(ctx)
+	if err != nil {
+		return err
+	}
+	defer waitForReplication()
 
-	ctx := context.Background()
 	setupCtx, cancelSetupCtx := context.WithTimeout(ctx, 15*time.Second)
 	defer cancelSetupCtx()
-	storageBackend, err := setupStorage(setupCtx)
+	storageBackend, err := setupStorage(setupCtx, decorators...)
 	if err != nil {
 		return fmt.Errorf("failed to set up storage: %w", err)
 	}
 
+	providerName, err := sums.Name()
+	if err != nil {
+		return fmt.Errorf("failed to parse provider name: %v", err)
+	}
+
 	validateCtx, cancelValidateCtx := context.WithTimeout(ctx, 15*time.Second)
 	defer cancelValidateCtx()
-	signingKeys, err := storageBackend.SigningKeys(validateCtx, flagProviderNamespace)
+	signingKeys, err := storageBackend.SigningKeys(validateCtx, flagProviderNamespace, providerName)
 	if err != nil {
 		return err
 	}
@@ -171,51 +329,225 @@ func uploadProvider(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	providerName, err := sums.Name()
+	// The Sigstore bundle is optional, unless identities are required
+	bundlePath := flagFileSha256Sums + attestation.BundleSuffix
+	bundle, err := os.ReadFile(bundlePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read file at path %s: %w", bundlePath, err)
+	}
+	verifier, err := attestationVerifier()
 	if err != nil {
-		return fmt.Errorf("failed to parse provider name: %v", err)
+		return err
+	} else if verifier != nil {
+		if bundle == nil {
+			return fmt.Errorf("%w: %s", attestation.ErrBundleMissing, bundlePath)
+		}
+		if err := verifier.Verify(sumsBytes, bundle); err != nil {
+			return err
+		}
+		slog.Info("verified Sigstore bundle", slog.String("name", filepath.Base(bundlePath)))
 	}
 
-	// Upload provider binary .zip archives
-	if len(flagProviderArchivePaths) > 0 {
-		for _, archivePath := range flagProviderArchivePaths {
-			if err := uploadProviderReleaseFile(ctx, storageBackend, archivePath, flagProviderNamespace, providerName); err != nil {
-				return err
-			}
-			slog.Info("successfully published provider binary", slog.String("name", filepath.Base(archivePath)))
+	// The SBOM is optional, unless the namespace requires one
+	sbomPath := flagFileSha256Sums + sbom.Suffix
+	sbomDocument, err := readSBOM(sbomPath, flagProviderNamespace)
+	if err != nil {
+		return err
+	}
+	sbomStorage, ok := storageBackend.(provider.SBOMStorage)
+	if sbomDocument != nil && !ok {
+		return errors.New("the storage backend doesn't support SBOMs")
+	}
+
+	// The docs are loaded before the upload, so that invalid docs don't leave a partially published provider
+	var docs []provider.Doc
+	docsStorage, ok := storageBackend.(provider.DocsStorage)
+	if flagProviderDocsDir != "" {
+		if !ok {
+			return errors.New("the storage backend doesn't support provider docs")
 		}
-	} else {
+		if docs, err = provider.LoadDocs(flagProviderDocsDir); err != nil {
+			return err
+		}
+	}
+
+	// Upload provider binary .zip archives
+	archivePaths := flagProviderArchivePaths
+	if len(archivePaths) == 0 {
 		baseDir := filepath.Dir(flagFileSha256Sums)
 		for fileName := range sums.Entries {
-			archivePath := filepath.Join(baseDir, fileName)
-			if err := uploadProviderReleaseFile(ctx, storageBackend, archivePath, flagProviderNamespace, providerName); err != nil {
-				return err
+			// goreleaser lists the manifest in the SHA256SUMS file, it's uploaded separately
+			if !strings.HasSuffix(fileName, core.ProviderManifestSuffix) {
+				archivePaths = append(archivePaths, filepath.Join(baseDir, fileName))
 			}
-			slog.Info("successfully published provider binary", slog.String("name", fileName))
 		}
 	}
+	if err := uploadProviderReleaseFilesParallel(ctx, storageBackend, archivePaths, flagProviderNamespace, providerName); err != nil {
+		return err
+	}
+	for _, path := range archivePaths {
+		result.Published = append(result.Published, filepath.Base(path))
+	}
+
+	if manifest != nil {
+		fileName := manifestFileName(sums)
+		err = retryUpload(ctx, fileName, func() error {
+			uploadCtx, uploadCtxCancel := context.WithTimeout(ctx, 120*time.Second)
+			defer uploadCtxCancel()
+			return storageBackend.UploadProviderReleaseFiles(uploadCtx, flagProviderNamespace, providerName, fileName, bytes.NewReader(manifest))
+		})
+		if err != nil {
+			return err
+		}
+		slog.Info("successfully published provider manifest", slog.String("name", fileName))
+		result.Published = append(result.Published, fileName)
+	}
 
+	// The SHA256SUMS and signature files are uploaded last, so that they only reference archives which exist already
 	// Upload *_SHA256SUMS file
-	if err = uploadProviderReleaseFile(ctx, storageBackend, flagFileSha256Sums, flagProviderNamespace, providerName); err != nil {
+	if err = uploadProviderReleaseFileWithRetry(ctx, storageBackend, flagFileSha256Sums, flagProviderNamespace, providerName); err != nil {
 		return err
 	}
 	slog.Info("successfully published provider SHA256SUMS file", slog.String("name", filepath.Base(flagFileSha256Sums)))
+	result.Published = append(result.Published, filepath.Base(flagFileSha256Sums))
 
 	// Upload *_SHA256SUMS.sig file
 	signaturePath := fmt.Sprintf("%s.sig", flagFileSha256Sums)
-	if err = uploadProviderReleaseFile(ctx, storageBackend, signaturePath, flagProviderNamespace, providerName); err != nil {
+	if err = uploadProviderReleaseFileWithRetry(ctx, storageBackend, signaturePath, flagProviderNamespace, providerName); err != nil {
 		return err
 	}
 	slog.Info("successfully published provider SHA256SUMS.sig file", slog.String("name", filepath.Base(signaturePath)))
+	result.Published = append(result.Published, filepath.Base(signaturePath))
+
+	if bundle != nil {
+		if err = uploadProviderReleaseFileWithRetry(ctx, storageBackend, bundlePath, flagProviderNamespace, providerName); err != nil {
+			return err
+		}
+		slog.Info("successfully published provider Sigstore bundle", slog.String("name", filepath.Base(bundlePath)))
+		result.Published = append(result.Published, filepath.Base(bundlePath))
+	}
+
+	if sbomDocument != nil {
+		providerVersion, err := sums.Version()
+		if err != nil {
+			return fmt.Errorf("failed to parse provider version: %v", err)
+		}
+		if err := sbomStorage.UploadProviderSBOM(ctx, flagProviderNamespace, providerName, providerVersion, bytes.NewReader(sbomDocument)); err != nil {
+			return err
+		}
+		slog.Info("successfully published provider SBOM", slog.String("name", filepath.Base(sbomPath)))
+		result.Published = append(result.Published, filepath.Base(sbomPath))
+	}
+
+	if docs != nil {
+		providerVersion, err := sums.Version()
+		if err != nil {
+			return fmt.Errorf("failed to parse provider version: %v", err)
+		}
+		if err := docsStorage.UploadProviderDocs(ctx, flagProviderNamespace, providerName, providerVersion, docs); err != nil {
+			return err
+		}
+		slog.Info("successfully published provider docs", slog.Int("docs", len(docs)))
+	}
 
 	return nil
 }
 
+// attestationVerifier returns a verifier of Sigstore bundles, or nil if no identities are required
+// defaultPublisher returns the name of the current user, which identifies the publisher if --publisher isn't set
+func defaultPublisher() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+func attestationVerifier() (*attestation.Verifier, error) {
+	if len(flagAttestationIdentities) == 0 {
+		return nil, nil
+	}
+
+	var identities []attestation.Identity
+	for _, s := range flagAttestationIdentities {
+		identity, err := attestation.ParseIdentity(s)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	trustedRoot, err := attestation.NewTrustedRoot(flagAttestationTrustedRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Sigstore trusted root: %w", err)
+	}
+	return attestation.NewVerifier(trustedRoot, identities)
+}
+
+// downloadProviderGitHubRelease downloads the release with the tag of the --github-release repository to the directory
+func downloadProviderGitHubRelease(ctx context.Context, tag, dir string) (string, error) {
+	pattern, err := regexp.Compile(flagGitHubAssetPattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid --github-asset-pattern: %w", err)
+	}
+
+	token := flagGitHubToken
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+
+	c := &githubReleaseClient{
+		client: &http.Client{Timeout: 10 * time.Minute},
+		apiURL: flagGitHubAPIURL,
+		token:  token,
+	}
+	return downloadGitHubRelease(ctx, c, flagGitHubRelease, tag, pattern, dir)
+}
+
+// manifestFileName returns the name under which the manifest of the release is stored, which is the name goreleaser uses
+func manifestFileName(sums *core.Sha256Sums) string {
+	return strings.TrimSuffix(sums.Filename, "_SHA256SUMS") + core.ProviderManifestSuffix
+}
+
+// readProviderManifest returns the validated manifest of the release, or nil if the release doesn't have one.
+// The manifest is read from --filename-manifest, or from the *_manifest.json file next to the *_SHA256SUMS file.
+func readProviderManifest(sums *core.Sha256Sums) ([]byte, error) {
+	manifestPath := flagProviderManifest
+	if manifestPath == "" {
+		manifestPath = filepath.Join(filepath.Dir(flagFileSha256Sums), manifestFileName(sums))
+	} else if !filepath.IsAbs(manifestPath) {
+		return nil, fmt.Errorf("file path is not absolute: %s", manifestPath)
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if errors.Is(err, os.ErrNotExist) && flagProviderManifest == "" {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file at path %s: %w", manifestPath, err)
+	}
+
+	if _, err := core.NewProviderManifest(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestPath, err)
+	}
+	// The manifest has to match the SHA256SUMS file if it's listed there, like the archives
+	if checksum, ok := sums.Entries[manifestFileName(sums)]; ok {
+		if c := sha256.Sum256(data); !bytes.Equal(checksum, c[:]) {
+			return nil, fmt.Errorf("failed to validate checksum for file %s", filepath.Base(manifestPath))
+		}
+	}
+	return data, nil
+}
+
 func validateShaSums(sums *core.Sha256Sums) error {
 	// Check whether the user has given archive paths to upload on the command line as flags.
 	// If not, we try to determine the locations of the provider zip archives based on the path of the *_SHA256SUMS file and the filenames in that file
 	if len(flagProviderArchivePaths) != 0 {
-		if len(sums.Entries) != len(flagProviderArchivePaths) {
+		// The manifest is listed in the SHA256SUMS file of goreleaser, but it's not an archive
+		archives := len(sums.Entries)
+		if _, ok := sums.Entries[manifestFileName(sums)]; ok {
+			archives--
+		}
+		if archives != len(flagProviderArchivePaths) {
 			return fmt.Errorf("the number of provided archive paths doesn't match the number of entries in %s", flagFileSha256Sums)
 		}
 
@@ -232,6 +564,10 @@ func validateShaSums(sums *core.Sha256Sums) error {
 	} else {
 		baseDir := filepath.Dir(flagFileSha256Sums)
 		for fileName, checksum := range sums.Entries {
+			if fileName == manifestFileName(sums) {
+				// The manifest is validated by readProviderManifest, as it may be passed with --filename-manifest
+				continue
+			}
 			if err := validateShaSumsEntry(filepath.Join(baseDir, fileName), checksum); err != nil {
 				return fmt.Errorf("failed to validate checksum for file %s", fileName)
 			}
@@ -265,6 +601,60 @@ func validateShaSumsEntry(path string, checksum []byte) error {
 	return nil
 }
 
+// uploadProviderReleaseFilesParallel uploads the files with a pool of flagUploadParallelism workers.
+// The first file which can't be uploaded after all retries cancels the remaining uploads.
+func uploadProviderReleaseFilesParallel(ctx context.Context, storage provider.Storage, paths []string, namespace, name string) error {
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(max(flagUploadParallelism, 1))
+
+	for _, path := range paths {
+		group.Go(func() error {
+			if err := uploadProviderReleaseFileWithRetry(groupCtx, storage, path, namespace, name); err != nil {
+				return err
+			}
+			slog.Info("successfully published provider binary", slog.String("name", filepath.Base(path)))
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+// uploadProviderReleaseFileWithRetry retries failed uploads with an exponential backoff.
+// Files which exist already in the storage backend are not retried, as the error is permanent.
+func uploadProviderReleaseFileWithRetry(ctx context.Context, storage provider.Storage, path, namespace, name string) error {
+	return retryUpload(ctx, filepath.Base(path), func() error {
+		return uploadProviderReleaseFile(ctx, storage, path, namespace, name)
+	})
+}
+
+// retryUpload calls upload until it succeeds, fails permanently, or the retries are exhausted
+func retryUpload(ctx context.Context, fileName string, upload func() error) error {
+	backoff := uploadRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := upload()
+		if err == nil {
+			return nil
+		} else if errors.Is(err, core.ErrObjectAlreadyExists) || errors.Is(err, core.ErrArtifactTooLarge) || errors.Is(err, core.ErrQuotaExceeded) || errors.Is(err, core.ErrArtifactRejected) || errors.Is(err, core.ErrAdmissionDenied) || attempt >= flagUploadRetries {
+			return fmt.Errorf("failed to upload %s: %w", fileName, err)
+		}
+
+		slog.Warn("failed to upload provider release file, retrying",
+			slog.String("name", fileName),
+			slog.Int("attempt", attempt+1),
+			slog.String("backoff", backoff.String()),
+			slog.String("err", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
 func uploadProviderReleaseFile(ctx context.Context, storage provider.Storage, path, namespace, name string) error {
 	archiveFile, err := os.Open(path)
 	if err != nil {
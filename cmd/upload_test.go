@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockedProviderStorage fails the first failures uploads of every file
+type mockedProviderStorage struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	attempts map[string]int
+	uploaded map[string][]byte
+}
+
+func (m *mockedProviderStorage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockedProviderStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockedProviderStorage) ProviderHashes(ctx context.Context, namespace, name, version, os, arch string) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockedProviderStorage) SigningKeys(ctx context.Context, namespace, name string) (*core.SigningKeys, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockedProviderStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	b, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts[filename]++
+	if m.attempts[filename] <= m.failures {
+		return m.err
+	}
+	m.uploaded[filename] = b
+	return nil
+}
+
+func TestUploadProviderReleaseFilesParallel(t *testing.T) {
+	uploadRetryBackoff = 0
+	flagUploadParallelism = 3
+
+	dir := t.TempDir()
+	var paths []string
+	for _, platform := range []string{"linux_amd64", "linux_arm64", "darwin_amd64", "darwin_arm64", "windows_amd64"} {
+		p := filepath.Join(dir, fmt.Sprintf("terraform-provider-dummy_0.1.0_%s.zip", platform))
+		assert.NoError(t, os.WriteFile(p, []byte(platform), 0o600))
+		paths = append(paths, p)
+	}
+
+	testCases := []struct {
+		name             string
+		retries          int
+		failures         int
+		err              error
+		expectedAttempts int
+		expectError      bool
+	}{
+		{
+			name:             "all uploads succeed",
+			retries:          3,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "transient failures are retried",
+			retries:          3,
+			failures:         2,
+			err:              errors.New("connection reset"),
+			expectedAttempts: 3,
+		},
+		{
+			name:             "retries are exhausted",
+			retries:          1,
+			failures:         2,
+			err:              errors.New("connection reset"),
+			expectedAttempts: 2,
+			expectError:      true,
+		},
+		{
+			name:             "existing objects are not retried",
+			retries:          3,
+			failures:         1,
+			err:              core.ErrObjectAlreadyExists,
+			expectedAttempts: 1,
+			expectError:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			flagUploadRetries = tc.retries
+			s := &mockedProviderStorage{
+				failures: tc.failures,
+				err:      tc.err,
+				attempts: make(map[string]int),
+				uploaded: make(map[string][]byte),
+			}
+
+			err := uploadProviderReleaseFilesParallel(context.Background(), s, paths, "acme", "dummy")
+			if tc.expectError {
+				assert.Error(t, err)
+				if errors.Is(tc.err, core.ErrObjectAlreadyExists) {
+					assert.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, s.uploaded, len(paths))
+			}
+
+			for _, attempts := range s.attempts {
+				if tc.expectError {
+					// A failed upload cancels the uploads of the other workers
+					assert.LessOrEqual(t, attempts, tc.expectedAttempts)
+				} else {
+					assert.Equal(t, tc.expectedAttempts, attempts)
+				}
+			}
+		})
+	}
+}
+
+func TestReadProviderManifest(t *testing.T) {
+	manifest := []byte(`{"version": 1, "metadata": {"protocol_versions": ["6.0"]}}`)
+	checksum := sha256.Sum256(manifest)
+
+	dir := t.TempDir()
+	flagFileSha256Sums = filepath.Join(dir, "terraform-provider-dummy_0.1.0_SHA256SUMS")
+	sums := &core.Sha256Sums{Filename: "terraform-provider-dummy_0.1.0_SHA256SUMS", Entries: map[string][]byte{}}
+	defer func() {
+		flagFileSha256Sums = ""
+		flagProviderManifest = ""
+	}()
+
+	// Releases without manifest are uploaded without one
+	res, err := readProviderManifest(sums)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	// The manifest next to the SHA256SUMS file is detected and validated against it
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "terraform-provider-dummy_0.1.0_manifest.json"), manifest, 0o600))
+	sums.Entries["terraform-provider-dummy_0.1.0_manifest.json"] = checksum[:]
+	res, err = readProviderManifest(sums)
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, res)
+
+	sums.Entries["terraform-provider-dummy_0.1.0_manifest.json"] = make([]byte, sha256.Size)
+	_, err = readProviderManifest(sums)
+	assert.ErrorContains(t, err, "failed to validate checksum")
+	delete(sums.Entries, "terraform-provider-dummy_0.1.0_manifest.json")
+
+	// The manifest of the provider repository can be passed explicitly, it has to exist then
+	flagProviderManifest = filepath.Join(dir, "terraform-registry-manifest.json")
+	_, err = readProviderManifest(sums)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.NoError(t, os.WriteFile(flagProviderManifest, []byte(`{"version": 1}`), 0o600))
+	_, err = readProviderManifest(sums)
+	assert.ErrorContains(t, err, "doesn't declare protocol versions")
+}
+
+func TestArchiveModules_Failures(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		dir := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		spec := fmt.Sprintf("metadata {\n  namespace = \"acme\"\n  name = %q\n  provider = \"aws\"\n  version = \"1.0.0\"\n}\n", name)
+		if name == "b" {
+			spec = "metadata {}"
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, moduleSpecFileName), []byte(spec), 0644))
+	}
+
+	// The modules after the failed module are uploaded
+	result, err := archiveModules(root, storage.NewMemoryStorage())
+	assert.ErrorContains(t, err, filepath.Join(root, "b"))
+	assert.Len(t, result.Published, 2)
+	assert.Equal(t, 1, result.failed)
+
+	flagUploadFailFast = true
+	t.Cleanup(func() { flagUploadFailFast = false })
+	result, err = archiveModules(root, storage.NewMemoryStorage())
+	assert.Error(t, err)
+	assert.Len(t, result.Published, 1)
+}
+
+func TestUploadExitCode(t *testing.T) {
+	flagUploadDetailedExitCodes = true
+	t.Cleanup(func() { flagUploadDetailedExitCodes = false })
+
+	errFailed := errors.New("failed")
+	published := []moduleUpload{{Name: "a"}}
+	tests := []struct {
+		name     string
+		result   *uploadResult
+		err      error
+		wantCode int
+	}{
+		{
+			name:   "uploaded",
+			result: &uploadResult{Published: published},
+		},
+		{
+			name:     "nothing to do",
+			result:   &uploadResult{Skipped: published},
+			wantCode: exitCodeNothingToDo,
+		},
+		{
+			name:     "partial failure",
+			result:   &uploadResult{Published: published, failed: 1},
+			err:      errFailed,
+			wantCode: exitCodePartialFailure,
+		},
+		{
+			name:     "total failure",
+			result:   &uploadResult{failed: 2},
+			err:      errFailed,
+			wantCode: exitCodeTotalFailure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uploadExitCode(tt.result, tt.err)
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var exitErr *exitError
+			assert.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, tt.wantCode, exitErr.code)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify all stored archives against their recorded digests",
+	Long: `Verify all stored archives against the digests recorded on upload.
+Module archives are compared with the SHA-256 checksum stored next to them, provider archives with the SHA256SUMS file of the release.
+The command fails if any archive doesn't match, archives without a recorded digest are only reported.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         verify,
+}
+
+func verify(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	s, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	verifier, ok := s.(storage.Verifier)
+	if !ok {
+		return errors.New("the storage backend doesn't support the verification of archives")
+	}
+
+	// The result is logged by the verifier
+	_, err = verifier.Verify(ctx)
+	return err
+}
@@ -60,3 +60,90 @@ Create the name of the service account to use
 {{- default "default" .Values.server.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Environment variables of the server, which are shared with the storage check
+*/}}
+{{- define "boring-registry.serverEnv" -}}
+- name: BORING_REGISTRY_DEBUG
+  value:  {{ .Values.server.debug | quote }}
+- name: BORING_REGISTRY_JSON
+  value:  {{ .Values.server.jsonLogFormat | quote }}
+- name: BORING_REGISTRY_LISTEN_ADDRESS
+  value: {{ printf ":%v" .Values.server.port | quote }}
+- name: BORING_REGISTRY_LISTEN_TELEMETRY_ADDRESS
+  value: {{ printf ":%v" .Values.server.telemetryPort | quote }}
+
+- name: BORING_REGISTRY_AUTH_STATIC_TOKEN
+{{- if .Values.server.auth.createSecret }}
+  valueFrom:
+    secretKeyRef:
+      name: {{ include "boring-registry.fullname" . }}
+      key: apiKey
+{{- else if .Values.server.auth.existingSecret }}
+  valueFrom:
+    secretKeyRef:
+      name: {{ .Values.server.auth.existingSecret | quote }}
+      key: {{ .Values.server.auth.existingSecretKey | quote }}
+{{- else }}
+  value: {{ .Values.server.auth.value | quote }}
+{{- end }}
+
+{{- if .Values.server.tlsCertFile }}
+- name: BORING_REGISTRY_TLS_CERT_FILE
+  value:  {{ .Values.server.tlsCertFile | quote }}
+{{- end }}
+
+{{- if .Values.server.tlsKeyFile }}
+- name: BORING_REGISTRY_TLS_KEY_FILE
+  value:  {{ .Values.server.tlsKeyFile | quote }}
+{{- end }}
+
+# Storage configuration.
+{{- with .Values.server.storage.s3 }}
+- name: BORING_REGISTRY_STORAGE_S3_BUCKET
+  value:  {{ .bucket | quote }}
+{{- if .prefix }}
+- name: BORING_REGISTRY_STORAGE_S3_PREFIX
+  value:  {{ .prefix | quote }}
+{{- end }}
+{{- if .region }}
+- name: BORING_REGISTRY_STORAGE_S3_REGION
+  value:  {{ .region | quote }}
+{{- end }}
+{{- if .endpoint }}
+- name: BORING_REGISTRY_STORAGE_S3_ENDPOINT
+  value:  {{ .endpoint | quote }}
+{{- end }}
+{{- if .pathStyle }}
+- name: BORING_REGISTRY_STORAGE_S3_PATHSTYLE
+  value:  {{ .pathStyle | quote }}
+{{- end }}
+{{- end }}
+
+{{- with .Values.server.storage.gcs }}
+- name: BORING_REGISTRY_STORAGE_GCS_BUCKET
+  value:  {{ .bucket | quote }}
+{{- if .prefix }}
+- name: BORING_REGISTRY_STORAGE_GCS_PREFIX
+  value:  {{ .prefix | quote }}
+{{- end }}
+{{- if .saEmail }}
+- name: BORING_REGISTRY_STORAGE_GCS_SA_EMAIL
+  value:  {{ .saEmail | quote }}
+{{- end }}
+{{- if .signedURL }}
+- name: BORING_REGISTRY_STORAGE_GCS_SIGNED_URL
+  value:  {{ .signedURL | quote }}
+{{- end }}
+{{- end }}
+
+{{- range .Values.server.extraEnvs }}
+- name: {{ .name | quote }}
+{{- if .value }}
+  value: {{ .value | quote }}
+{{- else if .valueFrom }}
+  valueFrom: {{ toYaml .valueFrom | nindent 16 }}
+{{- end }}
+{{- end }}
+{{- end }}
@@ -0,0 +1,1429 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/admin/adminv1/admin.proto
+
+package adminv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Module identifies a module version.
+type Module struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Provider      string                 `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Module) Reset() {
+	*x = Module{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Module) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Module) ProtoMessage() {}
+
+func (x *Module) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Module.ProtoReflect.Descriptor instead.
+func (*Module) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Module) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Module) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Module) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Module) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type ListModuleVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Provider      string                 `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModuleVersionsRequest) Reset() {
+	*x = ListModuleVersionsRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModuleVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModuleVersionsRequest) ProtoMessage() {}
+
+func (x *ListModuleVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModuleVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListModuleVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListModuleVersionsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ListModuleVersionsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListModuleVersionsRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+type ListModuleVersionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Modules       []*Module              `protobuf:"bytes,1,rep,name=modules,proto3" json:"modules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModuleVersionsResponse) Reset() {
+	*x = ListModuleVersionsResponse{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModuleVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModuleVersionsResponse) ProtoMessage() {}
+
+func (x *ListModuleVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModuleVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListModuleVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListModuleVersionsResponse) GetModules() []*Module {
+	if x != nil {
+		return x.Modules
+	}
+	return nil
+}
+
+type PublishModuleRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Module *Module                `protobuf:"bytes,1,opt,name=module,proto3" json:"module,omitempty"`
+	// Archive is the tar.gz or zip archive of the module.
+	Archive []byte `protobuf:"bytes,2,opt,name=archive,proto3" json:"archive,omitempty"`
+	// Replace replaces an existing module version in namespaces allowing overwrites.
+	Replace bool `protobuf:"varint,3,opt,name=replace,proto3" json:"replace,omitempty"`
+	// Reason is recorded in the audit event of a replaced module version.
+	Reason        string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishModuleRequest) Reset() {
+	*x = PublishModuleRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishModuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishModuleRequest) ProtoMessage() {}
+
+func (x *PublishModuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishModuleRequest.ProtoReflect.Descriptor instead.
+func (*PublishModuleRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PublishModuleRequest) GetModule() *Module {
+	if x != nil {
+		return x.Module
+	}
+	return nil
+}
+
+func (x *PublishModuleRequest) GetArchive() []byte {
+	if x != nil {
+		return x.Archive
+	}
+	return nil
+}
+
+func (x *PublishModuleRequest) GetReplace() bool {
+	if x != nil {
+		return x.Replace
+	}
+	return false
+}
+
+func (x *PublishModuleRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type PublishModuleResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Module *Module                `protobuf:"bytes,1,opt,name=module,proto3" json:"module,omitempty"`
+	// Checksum is the SHA-256 checksum of the stored archive in the form sha256:<hex>.
+	Checksum string `protobuf:"bytes,2,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// Replaced is true if an existing module version was replaced.
+	Replaced      bool `protobuf:"varint,3,opt,name=replaced,proto3" json:"replaced,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishModuleResponse) Reset() {
+	*x = PublishModuleResponse{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishModuleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishModuleResponse) ProtoMessage() {}
+
+func (x *PublishModuleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishModuleResponse.ProtoReflect.Descriptor instead.
+func (*PublishModuleResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PublishModuleResponse) GetModule() *Module {
+	if x != nil {
+		return x.Module
+	}
+	return nil
+}
+
+func (x *PublishModuleResponse) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *PublishModuleResponse) GetReplaced() bool {
+	if x != nil {
+		return x.Replaced
+	}
+	return false
+}
+
+type DeleteModuleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Module        *Module                `protobuf:"bytes,1,opt,name=module,proto3" json:"module,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteModuleRequest) Reset() {
+	*x = DeleteModuleRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteModuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteModuleRequest) ProtoMessage() {}
+
+func (x *DeleteModuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteModuleRequest.ProtoReflect.Descriptor instead.
+func (*DeleteModuleRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteModuleRequest) GetModule() *Module {
+	if x != nil {
+		return x.Module
+	}
+	return nil
+}
+
+type DeleteModuleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteModuleResponse) Reset() {
+	*x = DeleteModuleResponse{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteModuleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteModuleResponse) ProtoMessage() {}
+
+func (x *DeleteModuleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteModuleResponse.ProtoReflect.Descriptor instead.
+func (*DeleteModuleResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{6}
+}
+
+// Platform is an operating system and architecture a provider version was released for.
+type Platform struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Os            string                 `protobuf:"bytes,1,opt,name=os,proto3" json:"os,omitempty"`
+	Arch          string                 `protobuf:"bytes,2,opt,name=arch,proto3" json:"arch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Platform) Reset() {
+	*x = Platform{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Platform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Platform) ProtoMessage() {}
+
+func (x *Platform) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Platform.ProtoReflect.Descriptor instead.
+func (*Platform) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Platform) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *Platform) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+type ProviderVersion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Protocols     []string               `protobuf:"bytes,2,rep,name=protocols,proto3" json:"protocols,omitempty"`
+	Platforms     []*Platform            `protobuf:"bytes,3,rep,name=platforms,proto3" json:"platforms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProviderVersion) Reset() {
+	*x = ProviderVersion{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProviderVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderVersion) ProtoMessage() {}
+
+func (x *ProviderVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderVersion.ProtoReflect.Descriptor instead.
+func (*ProviderVersion) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ProviderVersion) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ProviderVersion) GetProtocols() []string {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *ProviderVersion) GetPlatforms() []*Platform {
+	if x != nil {
+		return x.Platforms
+	}
+	return nil
+}
+
+type ListProviderVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProviderVersionsRequest) Reset() {
+	*x = ListProviderVersionsRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProviderVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProviderVersionsRequest) ProtoMessage() {}
+
+func (x *ListProviderVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProviderVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListProviderVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListProviderVersionsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ListProviderVersionsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListProviderVersionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*ProviderVersion     `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProviderVersionsResponse) Reset() {
+	*x = ListProviderVersionsResponse{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProviderVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProviderVersionsResponse) ProtoMessage() {}
+
+func (x *ListProviderVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProviderVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListProviderVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListProviderVersionsResponse) GetVersions() []*ProviderVersion {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+// File is a file of a provider release.
+type File struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *File) Reset() {
+	*x = File{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*File) ProtoMessage() {}
+
+func (x *File) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use File.ProtoReflect.Descriptor instead.
+func (*File) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *File) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *File) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type PublishProviderRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Namespace string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Sha256sums is the terraform-provider-<name>_<version>_SHA256SUMS file listing the archives.
+	Sha256Sums *File `protobuf:"bytes,2,opt,name=sha256sums,proto3" json:"sha256sums,omitempty"`
+	// Sha256sumsSignature is the detached signature of the SHA256SUMS file by one of the signing keys of the namespace.
+	Sha256SumsSignature []byte `protobuf:"bytes,3,opt,name=sha256sums_signature,json=sha256sumsSignature,proto3" json:"sha256sums_signature,omitempty"`
+	// Archives are the provider archives listed in the SHA256SUMS file.
+	Archives []*File `protobuf:"bytes,4,rep,name=archives,proto3" json:"archives,omitempty"`
+	// Manifest is the optional terraform-provider-<name>_<version>_manifest.json file declaring the protocol versions.
+	Manifest      *File `protobuf:"bytes,5,opt,name=manifest,proto3" json:"manifest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishProviderRequest) Reset() {
+	*x = PublishProviderRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishProviderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishProviderRequest) ProtoMessage() {}
+
+func (x *PublishProviderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishProviderRequest.ProtoReflect.Descriptor instead.
+func (*PublishProviderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PublishProviderRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *PublishProviderRequest) GetSha256Sums() *File {
+	if x != nil {
+		return x.Sha256Sums
+	}
+	return nil
+}
+
+func (x *PublishProviderRequest) GetSha256SumsSignature() []byte {
+	if x != nil {
+		return x.Sha256SumsSignature
+	}
+	return nil
+}
+
+func (x *PublishProviderRequest) GetArchives() []*File {
+	if x != nil {
+		return x.Archives
+	}
+	return nil
+}
+
+func (x *PublishProviderRequest) GetManifest() *File {
+	if x != nil {
+		return x.Manifest
+	}
+	return nil
+}
+
+type PublishProviderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Platforms     []*Platform            `protobuf:"bytes,4,rep,name=platforms,proto3" json:"platforms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishProviderResponse) Reset() {
+	*x = PublishProviderResponse{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishProviderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishProviderResponse) ProtoMessage() {}
+
+func (x *PublishProviderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishProviderResponse.ProtoReflect.Descriptor instead.
+func (*PublishProviderResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PublishProviderResponse) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *PublishProviderResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PublishProviderResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PublishProviderResponse) GetPlatforms() []*Platform {
+	if x != nil {
+		return x.Platforms
+	}
+	return nil
+}
+
+type DeleteProviderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteProviderRequest) Reset() {
+	*x = DeleteProviderRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteProviderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteProviderRequest) ProtoMessage() {}
+
+func (x *DeleteProviderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteProviderRequest.ProtoReflect.Descriptor instead.
+func (*DeleteProviderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteProviderRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *DeleteProviderRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DeleteProviderRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type DeleteProviderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteProviderResponse) Reset() {
+	*x = DeleteProviderResponse{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteProviderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteProviderResponse) ProtoMessage() {}
+
+func (x *DeleteProviderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteProviderResponse.ProtoReflect.Descriptor instead.
+func (*DeleteProviderResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{15}
+}
+
+type ReindexRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReindexRequest) Reset() {
+	*x = ReindexRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReindexRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReindexRequest) ProtoMessage() {}
+
+func (x *ReindexRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReindexRequest.ProtoReflect.Descriptor instead.
+func (*ReindexRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{16}
+}
+
+// Drift is an object which doesn't fit the storage layout, or which lacks an object it depends on.
+type Drift struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Drift) Reset() {
+	*x = Drift{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Drift) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Drift) ProtoMessage() {}
+
+func (x *Drift) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Drift.ProtoReflect.Descriptor instead.
+func (*Drift) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Drift) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Drift) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ReindexResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Modules       int64                  `protobuf:"varint,1,opt,name=modules,proto3" json:"modules,omitempty"`
+	Providers     int64                  `protobuf:"varint,2,opt,name=providers,proto3" json:"providers,omitempty"`
+	Mirrored      int64                  `protobuf:"varint,3,opt,name=mirrored,proto3" json:"mirrored,omitempty"`
+	Drift         []*Drift               `protobuf:"bytes,4,rep,name=drift,proto3" json:"drift,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReindexResponse) Reset() {
+	*x = ReindexResponse{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReindexResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReindexResponse) ProtoMessage() {}
+
+func (x *ReindexResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReindexResponse.ProtoReflect.Descriptor instead.
+func (*ReindexResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ReindexResponse) GetModules() int64 {
+	if x != nil {
+		return x.Modules
+	}
+	return 0
+}
+
+func (x *ReindexResponse) GetProviders() int64 {
+	if x != nil {
+		return x.Providers
+	}
+	return 0
+}
+
+func (x *ReindexResponse) GetMirrored() int64 {
+	if x != nil {
+		return x.Mirrored
+	}
+	return 0
+}
+
+func (x *ReindexResponse) GetDrift() []*Drift {
+	if x != nil {
+		return x.Drift
+	}
+	return nil
+}
+
+type GetModuleDownloadStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Provider      string                 `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetModuleDownloadStatsRequest) Reset() {
+	*x = GetModuleDownloadStatsRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetModuleDownloadStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetModuleDownloadStatsRequest) ProtoMessage() {}
+
+func (x *GetModuleDownloadStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetModuleDownloadStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetModuleDownloadStatsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetModuleDownloadStatsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *GetModuleDownloadStatsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetModuleDownloadStatsRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+type GetProviderDownloadStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProviderDownloadStatsRequest) Reset() {
+	*x = GetProviderDownloadStatsRequest{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProviderDownloadStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProviderDownloadStatsRequest) ProtoMessage() {}
+
+func (x *GetProviderDownloadStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProviderDownloadStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetProviderDownloadStatsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetProviderDownloadStatsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *GetProviderDownloadStatsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DownloadStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Versions      map[string]int64       `protobuf:"bytes,2,rep,name=versions,proto3" json:"versions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadStats) Reset() {
+	*x = DownloadStats{}
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadStats) ProtoMessage() {}
+
+func (x *DownloadStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_admin_adminv1_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadStats.ProtoReflect.Descriptor instead.
+func (*DownloadStats) Descriptor() ([]byte, []int) {
+	return file_pkg_admin_adminv1_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DownloadStats) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *DownloadStats) GetVersions() map[string]int64 {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+var File_pkg_admin_adminv1_admin_proto protoreflect.FileDescriptor
+
+const file_pkg_admin_adminv1_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x1dpkg/admin/adminv1/admin.proto\x12\x17boringregistry.admin.v1\"p\n" +
+	"\x06Module\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\"i\n" +
+	"\x19ListModuleVersionsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\"W\n" +
+	"\x1aListModuleVersionsResponse\x129\n" +
+	"\amodules\x18\x01 \x03(\v2\x1f.boringregistry.admin.v1.ModuleR\amodules\"\x9b\x01\n" +
+	"\x14PublishModuleRequest\x127\n" +
+	"\x06module\x18\x01 \x01(\v2\x1f.boringregistry.admin.v1.ModuleR\x06module\x12\x18\n" +
+	"\aarchive\x18\x02 \x01(\fR\aarchive\x12\x18\n" +
+	"\areplace\x18\x03 \x01(\bR\areplace\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x88\x01\n" +
+	"\x15PublishModuleResponse\x127\n" +
+	"\x06module\x18\x01 \x01(\v2\x1f.boringregistry.admin.v1.ModuleR\x06module\x12\x1a\n" +
+	"\bchecksum\x18\x02 \x01(\tR\bchecksum\x12\x1a\n" +
+	"\breplaced\x18\x03 \x01(\bR\breplaced\"N\n" +
+	"\x13DeleteModuleRequest\x127\n" +
+	"\x06module\x18\x01 \x01(\v2\x1f.boringregistry.admin.v1.ModuleR\x06module\"\x16\n" +
+	"\x14DeleteModuleResponse\".\n" +
+	"\bPlatform\x12\x0e\n" +
+	"\x02os\x18\x01 \x01(\tR\x02os\x12\x12\n" +
+	"\x04arch\x18\x02 \x01(\tR\x04arch\"\x8a\x01\n" +
+	"\x0fProviderVersion\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1c\n" +
+	"\tprotocols\x18\x02 \x03(\tR\tprotocols\x12?\n" +
+	"\tplatforms\x18\x03 \x03(\v2!.boringregistry.admin.v1.PlatformR\tplatforms\"O\n" +
+	"\x1bListProviderVersionsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"d\n" +
+	"\x1cListProviderVersionsResponse\x12D\n" +
+	"\bversions\x18\x01 \x03(\v2(.boringregistry.admin.v1.ProviderVersionR\bversions\"4\n" +
+	"\x04File\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"\x9e\x02\n" +
+	"\x16PublishProviderRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12=\n" +
+	"\n" +
+	"sha256sums\x18\x02 \x01(\v2\x1d.boringregistry.admin.v1.FileR\n" +
+	"sha256sums\x121\n" +
+	"\x14sha256sums_signature\x18\x03 \x01(\fR\x13sha256sumsSignature\x129\n" +
+	"\barchives\x18\x04 \x03(\v2\x1d.boringregistry.admin.v1.FileR\barchives\x129\n" +
+	"\bmanifest\x18\x05 \x01(\v2\x1d.boringregistry.admin.v1.FileR\bmanifest\"\xa6\x01\n" +
+	"\x17PublishProviderResponse\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12?\n" +
+	"\tplatforms\x18\x04 \x03(\v2!.boringregistry.admin.v1.PlatformR\tplatforms\"c\n" +
+	"\x15DeleteProviderRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\"\x18\n" +
+	"\x16DeleteProviderResponse\"\x10\n" +
+	"\x0eReindexRequest\"1\n" +
+	"\x05Drift\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\x9b\x01\n" +
+	"\x0fReindexResponse\x12\x18\n" +
+	"\amodules\x18\x01 \x01(\x03R\amodules\x12\x1c\n" +
+	"\tproviders\x18\x02 \x01(\x03R\tproviders\x12\x1a\n" +
+	"\bmirrored\x18\x03 \x01(\x03R\bmirrored\x124\n" +
+	"\x05drift\x18\x04 \x03(\v2\x1e.boringregistry.admin.v1.DriftR\x05drift\"m\n" +
+	"\x1dGetModuleDownloadStatsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\"S\n" +
+	"\x1fGetProviderDownloadStatsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\xb4\x01\n" +
+	"\rDownloadStats\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12P\n" +
+	"\bversions\x18\x02 \x03(\v24.boringregistry.admin.v1.DownloadStats.VersionsEntryR\bversions\x1a;\n" +
+	"\rVersionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x012\xaf\b\n" +
+	"\fAdminService\x12}\n" +
+	"\x12ListModuleVersions\x122.boringregistry.admin.v1.ListModuleVersionsRequest\x1a3.boringregistry.admin.v1.ListModuleVersionsResponse\x12n\n" +
+	"\rPublishModule\x12-.boringregistry.admin.v1.PublishModuleRequest\x1a..boringregistry.admin.v1.PublishModuleResponse\x12k\n" +
+	"\fDeleteModule\x12,.boringregistry.admin.v1.DeleteModuleRequest\x1a-.boringregistry.admin.v1.DeleteModuleResponse\x12\x83\x01\n" +
+	"\x14ListProviderVersions\x124.boringregistry.admin.v1.ListProviderVersionsRequest\x1a5.boringregistry.admin.v1.ListProviderVersionsResponse\x12t\n" +
+	"\x0fPublishProvider\x12/.boringregistry.admin.v1.PublishProviderRequest\x1a0.boringregistry.admin.v1.PublishProviderResponse\x12q\n" +
+	"\x0eDeleteProvider\x12..boringregistry.admin.v1.DeleteProviderRequest\x1a/.boringregistry.admin.v1.DeleteProviderResponse\x12\\\n" +
+	"\aReindex\x12'.boringregistry.admin.v1.ReindexRequest\x1a(.boringregistry.admin.v1.ReindexResponse\x12x\n" +
+	"\x16GetModuleDownloadStats\x126.boringregistry.admin.v1.GetModuleDownloadStatsRequest\x1a&.boringregistry.admin.v1.DownloadStats\x12|\n" +
+	"\x18GetProviderDownloadStats\x128.boringregistry.admin.v1.GetProviderDownloadStatsRequest\x1a&.boringregistry.admin.v1.DownloadStatsBFZDgithub.com/boring-registry/boring-registry/pkg/admin/adminv1;adminv1b\x06proto3"
+
+var (
+	file_pkg_admin_adminv1_admin_proto_rawDescOnce sync.Once
+	file_pkg_admin_adminv1_admin_proto_rawDescData []byte
+)
+
+func file_pkg_admin_adminv1_admin_proto_rawDescGZIP() []byte {
+	file_pkg_admin_adminv1_admin_proto_rawDescOnce.Do(func() {
+		file_pkg_admin_adminv1_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_admin_adminv1_admin_proto_rawDesc), len(file_pkg_admin_adminv1_admin_proto_rawDesc)))
+	})
+	return file_pkg_admin_adminv1_admin_proto_rawDescData
+}
+
+var file_pkg_admin_adminv1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_pkg_admin_adminv1_admin_proto_goTypes = []any{
+	(*Module)(nil),                          // 0: boringregistry.admin.v1.Module
+	(*ListModuleVersionsRequest)(nil),       // 1: boringregistry.admin.v1.ListModuleVersionsRequest
+	(*ListModuleVersionsResponse)(nil),      // 2: boringregistry.admin.v1.ListModuleVersionsResponse
+	(*PublishModuleRequest)(nil),            // 3: boringregistry.admin.v1.PublishModuleRequest
+	(*PublishModuleResponse)(nil),           // 4: boringregistry.admin.v1.PublishModuleResponse
+	(*DeleteModuleRequest)(nil),             // 5: boringregistry.admin.v1.DeleteModuleRequest
+	(*DeleteModuleResponse)(nil),            // 6: boringregistry.admin.v1.DeleteModuleResponse
+	(*Platform)(nil),                        // 7: boringregistry.admin.v1.Platform
+	(*ProviderVersion)(nil),                 // 8: boringregistry.admin.v1.ProviderVersion
+	(*ListProviderVersionsRequest)(nil),     // 9: boringregistry.admin.v1.ListProviderVersionsRequest
+	(*ListProviderVersionsResponse)(nil),    // 10: boringregistry.admin.v1.ListProviderVersionsResponse
+	(*File)(nil),                            // 11: boringregistry.admin.v1.File
+	(*PublishProviderRequest)(nil),          // 12: boringregistry.admin.v1.PublishProviderRequest
+	(*PublishProviderResponse)(nil),         // 13: boringregistry.admin.v1.PublishProviderResponse
+	(*DeleteProviderRequest)(nil),           // 14: boringregistry.admin.v1.DeleteProviderRequest
+	(*DeleteProviderResponse)(nil),          // 15: boringregistry.admin.v1.DeleteProviderResponse
+	(*ReindexRequest)(nil),                  // 16: boringregistry.admin.v1.ReindexRequest
+	(*Drift)(nil),                           // 17: boringregistry.admin.v1.Drift
+	(*ReindexResponse)(nil),                 // 18: boringregistry.admin.v1.ReindexResponse
+	(*GetModuleDownloadStatsRequest)(nil),   // 19: boringregistry.admin.v1.GetModuleDownloadStatsRequest
+	(*GetProviderDownloadStatsRequest)(nil), // 20: boringregistry.admin.v1.GetProviderDownloadStatsRequest
+	(*DownloadStats)(nil),                   // 21: boringregistry.admin.v1.DownloadStats
+	nil,                                     // 22: boringregistry.admin.v1.DownloadStats.VersionsEntry
+}
+var file_pkg_admin_adminv1_admin_proto_depIdxs = []int32{
+	0,  // 0: boringregistry.admin.v1.ListModuleVersionsResponse.modules:type_name -> boringregistry.admin.v1.Module
+	0,  // 1: boringregistry.admin.v1.PublishModuleRequest.module:type_name -> boringregistry.admin.v1.Module
+	0,  // 2: boringregistry.admin.v1.PublishModuleResponse.module:type_name -> boringregistry.admin.v1.Module
+	0,  // 3: boringregistry.admin.v1.DeleteModuleRequest.module:type_name -> boringregistry.admin.v1.Module
+	7,  // 4: boringregistry.admin.v1.ProviderVersion.platforms:type_name -> boringregistry.admin.v1.Platform
+	8,  // 5: boringregistry.admin.v1.ListProviderVersionsResponse.versions:type_name -> boringregistry.admin.v1.ProviderVersion
+	11, // 6: boringregistry.admin.v1.PublishProviderRequest.sha256sums:type_name -> boringregistry.admin.v1.File
+	11, // 7: boringregistry.admin.v1.PublishProviderRequest.archives:type_name -> boringregistry.admin.v1.File
+	11, // 8: boringregistry.admin.v1.PublishProviderRequest.manifest:type_name -> boringregistry.admin.v1.File
+	7,  // 9: boringregistry.admin.v1.PublishProviderResponse.platforms:type_name -> boringregistry.admin.v1.Platform
+	17, // 10: boringregistry.admin.v1.ReindexResponse.drift:type_name -> boringregistry.admin.v1.Drift
+	22, // 11: boringregistry.admin.v1.DownloadStats.versions:type_name -> boringregistry.admin.v1.DownloadStats.VersionsEntry
+	1,  // 12: boringregistry.admin.v1.AdminService.ListModuleVersions:input_type -> boringregistry.admin.v1.ListModuleVersionsRequest
+	3,  // 13: boringregistry.admin.v1.AdminService.PublishModule:input_type -> boringregistry.admin.v1.PublishModuleRequest
+	5,  // 14: boringregistry.admin.v1.AdminService.DeleteModule:input_type -> boringregistry.admin.v1.DeleteModuleRequest
+	9,  // 15: boringregistry.admin.v1.AdminService.ListProviderVersions:input_type -> boringregistry.admin.v1.ListProviderVersionsRequest
+	12, // 16: boringregistry.admin.v1.AdminService.PublishProvider:input_type -> boringregistry.admin.v1.PublishProviderRequest
+	14, // 17: boringregistry.admin.v1.AdminService.DeleteProvider:input_type -> boringregistry.admin.v1.DeleteProviderRequest
+	16, // 18: boringregistry.admin.v1.AdminService.Reindex:input_type -> boringregistry.admin.v1.ReindexRequest
+	19, // 19: boringregistry.admin.v1.AdminService.GetModuleDownloadStats:input_type -> boringregistry.admin.v1.GetModuleDownloadStatsRequest
+	20, // 20: boringregistry.admin.v1.AdminService.GetProviderDownloadStats:input_type -> boringregistry.admin.v1.GetProviderDownloadStatsRequest
+	2,  // 21: boringregistry.admin.v1.AdminService.ListModuleVersions:output_type -> boringregistry.admin.v1.ListModuleVersionsResponse
+	4,  // 22: boringregistry.admin.v1.AdminService.PublishModule:output_type -> boringregistry.admin.v1.PublishModuleResponse
+	6,  // 23: boringregistry.admin.v1.AdminService.DeleteModule:output_type -> boringregistry.admin.v1.DeleteModuleResponse
+	10, // 24: boringregistry.admin.v1.AdminService.ListProviderVersions:output_type -> boringregistry.admin.v1.ListProviderVersionsResponse
+	13, // 25: boringregistry.admin.v1.AdminService.PublishProvider:output_type -> boringregistry.admin.v1.PublishProviderResponse
+	15, // 26: boringregistry.admin.v1.AdminService.DeleteProvider:output_type -> boringregistry.admin.v1.DeleteProviderResponse
+	18, // 27: boringregistry.admin.v1.AdminService.Reindex:output_type -> boringregistry.admin.v1.ReindexResponse
+	21, // 28: boringregistry.admin.v1.AdminService.GetModuleDownloadStats:output_type -> boringregistry.admin.v1.DownloadStats
+	21, // 29: boringregistry.admin.v1.AdminService.GetProviderDownloadStats:output_type -> boringregistry.admin.v1.DownloadStats
+	21, // [21:30] is the sub-list for method output_type
+	12, // [12:21] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_pkg_admin_adminv1_admin_proto_init() }
+func file_pkg_admin_adminv1_admin_proto_init() {
+	if File_pkg_admin_adminv1_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_admin_adminv1_admin_proto_rawDesc), len(file_pkg_admin_adminv1_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_admin_adminv1_admin_proto_goTypes,
+		DependencyIndexes: file_pkg_admin_adminv1_admin_proto_depIdxs,
+		MessageInfos:      file_pkg_admin_adminv1_admin_proto_msgTypes,
+	}.Build()
+	File_pkg_admin_adminv1_admin_proto = out.File
+	file_pkg_admin_adminv1_admin_proto_goTypes = nil
+	file_pkg_admin_adminv1_admin_proto_depIdxs = nil
+}
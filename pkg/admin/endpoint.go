@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type moduleRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+}
+
+type publishModuleRequest struct {
+	moduleRequest
+	archive []byte
+	replace bool
+	reason  string
+}
+
+type publishModuleResponse struct {
+	moduleRequest
+	*PublishedModule
+}
+
+type providerRequest struct {
+	namespace string
+	name      string
+	version   string
+}
+
+type publishProviderRequest struct {
+	namespace string
+	release   ProviderRelease
+}
+
+func listModuleVersionsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(moduleRequest)
+		return svc.ListModuleVersions(ctx, req.namespace, req.name, req.provider)
+	}
+}
+
+func publishModuleEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(publishModuleRequest)
+		res, err := svc.PublishModule(ctx, req.namespace, req.name, req.provider, req.version, req.archive, req.replace, req.reason)
+		if err != nil {
+			return nil, err
+		}
+
+		return publishModuleResponse{req.moduleRequest, res}, nil
+	}
+}
+
+func deleteModuleEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(moduleRequest)
+		return nil, svc.DeleteModule(ctx, req.namespace, req.name, req.provider, req.version)
+	}
+}
+
+func listProviderVersionsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(providerRequest)
+		return svc.ListProviderVersions(ctx, req.namespace, req.name)
+	}
+}
+
+func publishProviderEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(publishProviderRequest)
+		return svc.PublishProvider(ctx, req.namespace, req.release)
+	}
+}
+
+func deleteProviderEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(providerRequest)
+		return nil, svc.DeleteProvider(ctx, req.namespace, req.name, req.version)
+	}
+}
+
+func reindexEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return svc.Reindex(ctx)
+	}
+}
+
+func moduleDownloadStatsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(moduleRequest)
+		return svc.GetModuleDownloadStats(ctx, req.namespace, req.name, req.provider)
+	}
+}
+
+func providerDownloadStatsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(providerRequest)
+		return svc.GetProviderDownloadStats(ctx, req.namespace, req.name)
+	}
+}
@@ -0,0 +1,6 @@
+package admin
+
+import "errors"
+
+// ErrInvalidRelease is returned if the files of a provider release don't match its SHA256SUMS file or signature
+var ErrInvalidRelease = errors.New("invalid provider release")
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+)
+
+// Middleware is a Service middleware.
+type Middleware func(Service) Service
+
+type loggingMiddleware struct {
+	next Service
+}
+
+// LoggingMiddleware is a logging Service middleware.
+func LoggingMiddleware() Middleware {
+	return func(next Service) Service {
+		return &loggingMiddleware{
+			next: next,
+		}
+	}
+}
+
+func moduleGroup(namespace, name, provider string) slog.Attr {
+	return slog.Group("module",
+		slog.String("namespace", namespace),
+		slog.String("name", name),
+		slog.String("provider", provider),
+	)
+}
+
+func providerGroup(namespace, name string) slog.Attr {
+	return slog.Group("provider",
+		slog.String("namespace", namespace),
+		slog.String("name", name),
+	)
+}
+
+func (mw loggingMiddleware) ListModuleVersions(ctx context.Context, namespace, name, provider string) (modules []core.Module, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(slog.String("op", "ListModuleVersions"), moduleGroup(namespace, name, provider))
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to list module versions", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "list module versions", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.ListModuleVersions(ctx, namespace, name, provider)
+}
+
+func (mw loggingMiddleware) PublishModule(ctx context.Context, namespace, name, provider, version string, archive []byte, replace bool, reason string) (res *PublishedModule, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "PublishModule"),
+			moduleGroup(namespace, name, provider),
+			slog.String("version", version),
+			slog.Bool("replace", replace),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to publish module", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "publish module", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.PublishModule(ctx, namespace, name, provider, version, archive, replace, reason)
+}
+
+func (mw loggingMiddleware) DeleteModule(ctx context.Context, namespace, name, provider, version string) (err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(slog.String("op", "DeleteModule"), moduleGroup(namespace, name, provider), slog.String("version", version))
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to delete module", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "delete module", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.DeleteModule(ctx, namespace, name, provider, version)
+}
+
+func (mw loggingMiddleware) ListProviderVersions(ctx context.Context, namespace, name string) (versions *core.ProviderVersions, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(slog.String("op", "ListProviderVersions"), providerGroup(namespace, name))
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to list provider versions", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "list provider versions", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.ListProviderVersions(ctx, namespace, name)
+}
+
+func (mw loggingMiddleware) PublishProvider(ctx context.Context, namespace string, release ProviderRelease) (res *core.ProviderVersion, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "PublishProvider"),
+			slog.String("namespace", namespace),
+			slog.String("sha256sums", release.Sha256Sums.Name),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to publish provider", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "publish provider", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.PublishProvider(ctx, namespace, release)
+}
+
+func (mw loggingMiddleware) DeleteProvider(ctx context.Context, namespace, name, version string) (err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(slog.String("op", "DeleteProvider"), providerGroup(namespace, name), slog.String("version", version))
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to delete provider", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "delete provider", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.DeleteProvider(ctx, namespace, name, version)
+}
+
+func (mw loggingMiddleware) Reindex(ctx context.Context) (res storage.ReindexResult, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(slog.String("op", "Reindex"))
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to reindex", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "reindex", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.Reindex(ctx)
+}
+
+func (mw loggingMiddleware) GetModuleDownloadStats(ctx context.Context, namespace, name, provider string) (stats *core.DownloadStats, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(slog.String("op", "GetModuleDownloadStats"), moduleGroup(namespace, name, provider))
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get module download stats", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get module download stats", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetModuleDownloadStats(ctx, namespace, name, provider)
+}
+
+func (mw loggingMiddleware) GetProviderDownloadStats(ctx context.Context, namespace, name string) (stats *core.DownloadStats, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(slog.String("op", "GetProviderDownloadStats"), providerGroup(namespace, name))
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get provider download stats", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get provider download stats", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetProviderDownloadStats(ctx, namespace, name)
+}
+
+type readOnlyMiddleware struct {
+	Service
+}
+
+// ReadOnlyMiddleware rejects the operations changing the storage with core.ErrReadOnly
+func ReadOnlyMiddleware() Middleware {
+	return func(next Service) Service {
+		return &readOnlyMiddleware{next}
+	}
+}
+
+func (readOnlyMiddleware) PublishModule(context.Context, string, string, string, string, []byte, bool, string) (*PublishedModule, error) {
+	return nil, core.ErrReadOnly
+}
+
+func (readOnlyMiddleware) DeleteModule(context.Context, string, string, string, string) error {
+	return core.ErrReadOnly
+}
+
+func (readOnlyMiddleware) PublishProvider(context.Context, string, ProviderRelease) (*core.ProviderVersion, error) {
+	return nil, core.ErrReadOnly
+}
+
+func (readOnlyMiddleware) DeleteProvider(context.Context, string, string, string) error {
+	return core.ErrReadOnly
+}
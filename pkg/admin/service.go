@@ -0,0 +1,320 @@
+// Package admin implements the administrative operations of the gRPC admin API,
+// which lets other services publish, delete, and list modules and providers without the registry protocols.
+package admin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/stats"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+)
+
+// Service manages the modules and providers of the storage
+type Service interface {
+	ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error)
+
+	// PublishModule uploads the archive of the module version, an existing version is only replaced with replace set
+	PublishModule(ctx context.Context, namespace, name, provider, version string, archive []byte, replace bool, reason string) (*PublishedModule, error)
+
+	// DeleteModule hides the module version until it's purged
+	DeleteModule(ctx context.Context, namespace, name, provider, version string) error
+
+	ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error)
+
+	// PublishProvider verifies the signature and the checksums of the release before its files are uploaded
+	PublishProvider(ctx context.Context, namespace string, release ProviderRelease) (*core.ProviderVersion, error)
+
+	// DeleteProvider hides the provider version until it's purged
+	DeleteProvider(ctx context.Context, namespace, name, version string) error
+
+	Reindex(ctx context.Context) (storage.ReindexResult, error)
+	GetModuleDownloadStats(ctx context.Context, namespace, name, provider string) (*core.DownloadStats, error)
+	GetProviderDownloadStats(ctx context.Context, namespace, name string) (*core.DownloadStats, error)
+}
+
+// PublishedModule is the result of publishing a module version
+type PublishedModule struct {
+	// Checksum is the hex-encoded SHA-256 checksum of the stored archive
+	Checksum string
+
+	// Replaced is true if an existing module version was replaced
+	Replaced bool
+}
+
+// File is a file of a provider release
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// ProviderRelease holds the files of a provider version to publish
+type ProviderRelease struct {
+	Sha256Sums          File
+	Sha256SumsSignature []byte
+	Archives            []File
+
+	// Manifest is optional, its Name is empty if the release doesn't have a manifest
+	Manifest File
+}
+
+// deleteStorage is implemented by storages deleting versions with tombstones
+type deleteStorage interface {
+	DeleteModule(ctx context.Context, namespace, name, provider, version string) error
+	DeleteProvider(ctx context.Context, namespace, name, version string) error
+}
+
+type service struct {
+	storage   storage.Storage
+	stats     stats.Recorder
+	overwrite module.OverwritePolicy
+}
+
+// ServiceOption provides additional options for the Service.
+type ServiceOption func(*service)
+
+// WithDownloadStats returns the download statistics of the recorder
+func WithDownloadStats(recorder stats.Recorder) ServiceOption {
+	return func(s *service) {
+		s.stats = recorder
+	}
+}
+
+// WithOverwritePolicy allows replacing module versions in the namespaces of the policy
+func WithOverwritePolicy(policy module.OverwritePolicy) ServiceOption {
+	return func(s *service) {
+		s.overwrite = policy
+	}
+}
+
+// NewService returns a fully initialized Service.
+func NewService(storage storage.Storage, options ...ServiceOption) Service {
+	s := &service{
+		storage: storage,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+func (s *service) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	if err := required(namespace, name, provider); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ListModuleVersions(ctx, namespace, name, provider)
+}
+
+func (s *service) PublishModule(ctx context.Context, namespace, name, provider, version string, archive []byte, replace bool, reason string) (*PublishedModule, error) {
+	if err := required(namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+
+	if !replace {
+		if _, err := s.storage.UploadModule(ctx, namespace, name, provider, version, bytes.NewReader(archive)); err != nil {
+			return nil, err
+		}
+		checksum, err := s.storage.GetModuleChecksum(ctx, namespace, name, provider, version)
+		if err != nil {
+			return nil, err
+		}
+		return &PublishedModule{Checksum: checksum}, nil
+	}
+
+	replacer, ok := s.storage.(module.ReplaceStorage)
+	if !ok {
+		return nil, fmt.Errorf("%w: the storage backend doesn't support replacing module versions", module.ErrModuleImmutable)
+	}
+	if !s.overwrite.Allowed(namespace) {
+		return nil, fmt.Errorf("%w: overwrites aren't allowed in namespace %s", module.ErrModuleImmutable, namespace)
+	}
+
+	previous, err := s.storage.GetModuleChecksum(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := replacer.ReplaceModule(ctx, namespace, name, provider, version, bytes.NewReader(archive)); err != nil {
+		return nil, err
+	}
+	checksum, err := s.storage.GetModuleChecksum(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+
+	o11y.Audit(ctx, "module.republish",
+		slog.String("module", path.Join(namespace, name, provider, version)),
+		slog.String("previous-checksum", module.FormatChecksum(previous)),
+		slog.String("checksum", module.FormatChecksum(checksum)),
+		slog.String("reason", reason),
+	)
+
+	return &PublishedModule{Checksum: checksum, Replaced: true}, nil
+}
+
+func (s *service) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	if err := required(namespace, name, provider, version); err != nil {
+		return err
+	}
+	deleter, ok := s.storage.(deleteStorage)
+	if !ok {
+		return fmt.Errorf("%w: the storage backend doesn't support deleting versions", core.ErrObjectNotFound)
+	}
+
+	if err := deleter.DeleteModule(ctx, namespace, name, provider, version); err != nil {
+		return err
+	}
+
+	o11y.Audit(ctx, "module.delete", slog.String("module", path.Join(namespace, name, provider, version)))
+	return nil
+}
+
+func (s *service) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	if err := required(namespace, name); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ListProviderVersions(ctx, namespace, name)
+}
+
+func (s *service) PublishProvider(ctx context.Context, namespace string, release ProviderRelease) (*core.ProviderVersion, error) {
+	if err := required(namespace); err != nil {
+		return nil, err
+	}
+
+	sums, err := core.NewSha256Sums(release.Sha256Sums.Name, bytes.NewReader(release.Sha256Sums.Content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRelease, err)
+	}
+	name, err := sums.Name()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRelease, err)
+	}
+	version, err := sums.Version()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRelease, err)
+	}
+
+	signingKeys, err := s.storage.SigningKeys(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := signingKeys.IsValidSha256Sums(release.Sha256Sums.Content, release.Sha256SumsSignature); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRelease, err)
+	}
+
+	// All files are verified before the first one is uploaded, so that an invalid release doesn't leave files behind
+	if len(release.Archives) == 0 {
+		return nil, fmt.Errorf("%w: the release doesn't contain archives", ErrInvalidRelease)
+	}
+	res := &core.ProviderVersion{Namespace: namespace, Name: name, Version: version}
+	files := release.Archives
+	for _, archive := range release.Archives {
+		p, err := core.NewProviderFromArchive(archive.Name)
+		if err != nil || p.Name != name || p.Version != version || path.Ext(archive.Name) != core.ProviderExtension {
+			return nil, fmt.Errorf("%w: %s isn't an archive of %s %s", ErrInvalidRelease, archive.Name, name, version)
+		}
+		if err := verifyChecksum(sums, archive); err != nil {
+			return nil, err
+		}
+		res.Platforms = append(res.Platforms, core.Platform{OS: p.OS, Arch: p.Arch})
+	}
+	if release.Manifest.Name != "" {
+		if release.Manifest.Name != fmt.Sprintf("%s%s_%s%s", core.ProviderPrefix, name, version, core.ProviderManifestSuffix) {
+			return nil, fmt.Errorf("%w: %s isn't the manifest of %s %s", ErrInvalidRelease, release.Manifest.Name, name, version)
+		}
+		if err := verifyChecksum(sums, release.Manifest); err != nil {
+			return nil, err
+		}
+		files = append(files, release.Manifest)
+	}
+
+	// The SHA256SUMS file and its signature are uploaded last, as the version is only listed once they exist
+	files = append(files,
+		release.Sha256Sums,
+		File{Name: release.Sha256Sums.Name + ".sig", Content: release.Sha256SumsSignature},
+	)
+	for _, f := range files {
+		if err := s.storage.UploadProviderReleaseFiles(ctx, namespace, name, f.Name, bytes.NewReader(f.Content)); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func (s *service) DeleteProvider(ctx context.Context, namespace, name, version string) error {
+	if err := required(namespace, name, version); err != nil {
+		return err
+	}
+	deleter, ok := s.storage.(deleteStorage)
+	if !ok {
+		return fmt.Errorf("%w: the storage backend doesn't support deleting versions", core.ErrObjectNotFound)
+	}
+
+	if err := deleter.DeleteProvider(ctx, namespace, name, version); err != nil {
+		return err
+	}
+
+	o11y.Audit(ctx, "provider.delete", slog.String("provider", path.Join(namespace, name, version)))
+	return nil
+}
+
+func (s *service) Reindex(ctx context.Context) (storage.ReindexResult, error) {
+	reindexer, ok := s.storage.(storage.Reindexer)
+	if !ok {
+		return storage.ReindexResult{}, fmt.Errorf("%w: the storage backend doesn't support reindexing", core.ErrObjectNotFound)
+	}
+
+	return reindexer.Reindex(ctx)
+}
+
+func (s *service) GetModuleDownloadStats(ctx context.Context, namespace, name, provider string) (*core.DownloadStats, error) {
+	if s.stats == nil {
+		return nil, stats.ErrStatsDisabled
+	}
+
+	return s.stats.Stats(ctx, stats.ModuleArtifact(namespace, name, provider))
+}
+
+func (s *service) GetProviderDownloadStats(ctx context.Context, namespace, name string) (*core.DownloadStats, error) {
+	if s.stats == nil {
+		return nil, stats.ErrStatsDisabled
+	}
+
+	return s.stats.Stats(ctx, stats.ProviderArtifact(namespace, name))
+}
+
+// verifyChecksum verifies the checksum of the file against its entry in the SHA256SUMS file
+func verifyChecksum(sums *core.Sha256Sums, f File) error {
+	expected, err := sums.Checksum(f.Name)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRelease, err)
+	}
+	checksum, err := core.Sha256Checksum(bytes.NewReader(f.Content))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprintf("%x", checksum) != expected {
+		return fmt.Errorf("%w: the checksum of %s doesn't match the SHA256SUMS file", ErrInvalidRelease, f.Name)
+	}
+	return nil
+}
+
+// required returns a core.ErrVarMissing error if any of the values is empty
+func required(values ...string) error {
+	for _, v := range values {
+		if v == "" {
+			return fmt.Errorf("%w: namespace, name, provider, and version are required", core.ErrVarMissing)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/admin/adminv1"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/stats"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type grpcServer struct {
+	adminv1.UnimplementedAdminServiceServer
+
+	listModuleVersions       grpctransport.Handler
+	publishModule            grpctransport.Handler
+	deleteModule             grpctransport.Handler
+	listProviderVersions     grpctransport.Handler
+	publishProvider          grpctransport.Handler
+	deleteProvider           grpctransport.Handler
+	reindex                  grpctransport.Handler
+	getModuleDownloadStats   grpctransport.Handler
+	getProviderDownloadStats grpctransport.Handler
+}
+
+// NewGRPCServer returns the adminv1.AdminServiceServer of the Service.
+// The token of the authorization metadata is verified by the auth middleware.
+func NewGRPCServer(svc Service, auth endpoint.Middleware, options ...grpctransport.ServerOption) adminv1.AdminServiceServer {
+	options = append(options, grpctransport.ServerBefore(jwt.GRPCToContext()))
+	handler := func(e endpoint.Endpoint, dec grpctransport.DecodeRequestFunc, enc grpctransport.EncodeResponseFunc) grpctransport.Handler {
+		return grpctransport.NewServer(auth(e), dec, enc, options...)
+	}
+
+	return &grpcServer{
+		listModuleVersions:       handler(listModuleVersionsEndpoint(svc), decodeListModuleVersionsRequest, encodeListModuleVersionsResponse),
+		publishModule:            handler(publishModuleEndpoint(svc), decodePublishModuleRequest, encodePublishModuleResponse),
+		deleteModule:             handler(deleteModuleEndpoint(svc), decodeDeleteModuleRequest, encodeDeleteModuleResponse),
+		listProviderVersions:     handler(listProviderVersionsEndpoint(svc), decodeListProviderVersionsRequest, encodeListProviderVersionsResponse),
+		publishProvider:          handler(publishProviderEndpoint(svc), decodePublishProviderRequest, encodePublishProviderResponse),
+		deleteProvider:           handler(deleteProviderEndpoint(svc), decodeDeleteProviderRequest, encodeDeleteProviderResponse),
+		reindex:                  handler(reindexEndpoint(svc), decodeReindexRequest, encodeReindexResponse),
+		getModuleDownloadStats:   handler(moduleDownloadStatsEndpoint(svc), decodeModuleDownloadStatsRequest, encodeDownloadStatsResponse),
+		getProviderDownloadStats: handler(providerDownloadStatsEndpoint(svc), decodeProviderDownloadStatsRequest, encodeDownloadStatsResponse),
+	}
+}
+
+func (s *grpcServer) ListModuleVersions(ctx context.Context, req *adminv1.ListModuleVersionsRequest) (*adminv1.ListModuleVersionsResponse, error) {
+	_, res, err := s.listModuleVersions.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.ListModuleVersionsResponse), nil
+}
+
+func (s *grpcServer) PublishModule(ctx context.Context, req *adminv1.PublishModuleRequest) (*adminv1.PublishModuleResponse, error) {
+	_, res, err := s.publishModule.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.PublishModuleResponse), nil
+}
+
+func (s *grpcServer) DeleteModule(ctx context.Context, req *adminv1.DeleteModuleRequest) (*adminv1.DeleteModuleResponse, error) {
+	_, res, err := s.deleteModule.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.DeleteModuleResponse), nil
+}
+
+func (s *grpcServer) ListProviderVersions(ctx context.Context, req *adminv1.ListProviderVersionsRequest) (*adminv1.ListProviderVersionsResponse, error) {
+	_, res, err := s.listProviderVersions.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.ListProviderVersionsResponse), nil
+}
+
+func (s *grpcServer) PublishProvider(ctx context.Context, req *adminv1.PublishProviderRequest) (*adminv1.PublishProviderResponse, error) {
+	_, res, err := s.publishProvider.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.PublishProviderResponse), nil
+}
+
+func (s *grpcServer) DeleteProvider(ctx context.Context, req *adminv1.DeleteProviderRequest) (*adminv1.DeleteProviderResponse, error) {
+	_, res, err := s.deleteProvider.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.DeleteProviderResponse), nil
+}
+
+func (s *grpcServer) Reindex(ctx context.Context, req *adminv1.ReindexRequest) (*adminv1.ReindexResponse, error) {
+	_, res, err := s.reindex.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.ReindexResponse), nil
+}
+
+func (s *grpcServer) GetModuleDownloadStats(ctx context.Context, req *adminv1.GetModuleDownloadStatsRequest) (*adminv1.DownloadStats, error) {
+	_, res, err := s.getModuleDownloadStats.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.DownloadStats), nil
+}
+
+func (s *grpcServer) GetProviderDownloadStats(ctx context.Context, req *adminv1.GetProviderDownloadStatsRequest) (*adminv1.DownloadStats, error) {
+	_, res, err := s.getProviderDownloadStats.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, Status(err)
+	}
+	return res.(*adminv1.DownloadStats), nil
+}
+
+// Status translates domain specific errors to gRPC status codes
+func Status(err error) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, module.ErrModuleNotFound):
+		code = codes.NotFound
+	case errors.Is(err, module.ErrModuleAlreadyExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, module.ErrModuleImmutable) || errors.Is(err, stats.ErrStatsDisabled):
+		code = codes.FailedPrecondition
+	case errors.Is(err, module.ErrInvalidArchiveFormat) || errors.Is(err, ErrInvalidRelease):
+		code = codes.InvalidArgument
+	default:
+		var ok bool
+		if code, ok = httpStatusCodes[core.GenericError(err)]; !ok {
+			code = codes.Unknown
+		}
+	}
+	return status.Error(code, err.Error())
+}
+
+// httpStatusCodes maps the HTTP status codes of core.GenericError to gRPC status codes, unknown status codes map to codes.Unknown
+var httpStatusCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusUnprocessableEntity:   codes.FailedPrecondition,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusInternalServerError:   codes.Internal,
+}
+
+func decodeModule(m *adminv1.Module) moduleRequest {
+	return moduleRequest{
+		namespace: m.GetNamespace(),
+		name:      m.GetName(),
+		provider:  m.GetProvider(),
+		version:   m.GetVersion(),
+	}
+}
+
+func decodeListModuleVersionsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*adminv1.ListModuleVersionsRequest)
+	return moduleRequest{namespace: req.GetNamespace(), name: req.GetName(), provider: req.GetProvider()}, nil
+}
+
+func encodeListModuleVersionsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	modules := response.([]core.Module)
+	res := &adminv1.ListModuleVersionsResponse{Modules: make([]*adminv1.Module, 0, len(modules))}
+	for _, m := range modules {
+		res.Modules = append(res.Modules, &adminv1.Module{
+			Namespace: m.Namespace,
+			Name:      m.Name,
+			Provider:  m.Provider,
+			Version:   m.Version,
+		})
+	}
+	return res, nil
+}
+
+func decodePublishModuleRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*adminv1.PublishModuleRequest)
+	return publishModuleRequest{
+		moduleRequest: decodeModule(req.GetModule()),
+		archive:       req.GetArchive(),
+		replace:       req.GetReplace(),
+		reason:        req.GetReason(),
+	}, nil
+}
+
+func encodePublishModuleResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(publishModuleResponse)
+	return &adminv1.PublishModuleResponse{
+		Module: &adminv1.Module{
+			Namespace: res.namespace,
+			Name:      res.name,
+			Provider:  res.provider,
+			Version:   res.version,
+		},
+		Checksum: module.FormatChecksum(res.Checksum),
+		Replaced: res.Replaced,
+	}, nil
+}
+
+func decodeDeleteModuleRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return decodeModule(request.(*adminv1.DeleteModuleRequest).GetModule()), nil
+}
+
+func encodeDeleteModuleResponse(context.Context, interface{}) (interface{}, error) {
+	return &adminv1.DeleteModuleResponse{}, nil
+}
+
+func encodePlatforms(platforms []core.Platform) []*adminv1.Platform {
+	res := make([]*adminv1.Platform, 0, len(platforms))
+	for _, p := range platforms {
+		res = append(res, &adminv1.Platform{Os: p.OS, Arch: p.Arch})
+	}
+	return res
+}
+
+func decodeListProviderVersionsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*adminv1.ListProviderVersionsRequest)
+	return providerRequest{namespace: req.GetNamespace(), name: req.GetName()}, nil
+}
+
+func encodeListProviderVersionsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	versions := response.(*core.ProviderVersions)
+	res := &adminv1.ListProviderVersionsResponse{Versions: make([]*adminv1.ProviderVersion, 0, len(versions.Versions))}
+	for _, v := range versions.Versions {
+		res.Versions = append(res.Versions, &adminv1.ProviderVersion{
+			Version:   v.Version,
+			Protocols: v.Protocols,
+			Platforms: encodePlatforms(v.Platforms),
+		})
+	}
+	return res, nil
+}
+
+func decodeFile(f *adminv1.File) File {
+	return File{Name: f.GetName(), Content: f.GetContent()}
+}
+
+func decodePublishProviderRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*adminv1.PublishProviderRequest)
+	release := ProviderRelease{
+		Sha256Sums:          decodeFile(req.GetSha256Sums()),
+		Sha256SumsSignature: req.GetSha256SumsSignature(),
+		Manifest:            decodeFile(req.GetManifest()),
+	}
+	for _, archive := range req.GetArchives() {
+		release.Archives = append(release.Archives, decodeFile(archive))
+	}
+	return publishProviderRequest{namespace: req.GetNamespace(), release: release}, nil
+}
+
+func encodePublishProviderResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(*core.ProviderVersion)
+	return &adminv1.PublishProviderResponse{
+		Namespace: res.Namespace,
+		Name:      res.Name,
+		Version:   res.Version,
+		Platforms: encodePlatforms(res.Platforms),
+	}, nil
+}
+
+func decodeDeleteProviderRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*adminv1.DeleteProviderRequest)
+	return providerRequest{namespace: req.GetNamespace(), name: req.GetName(), version: req.GetVersion()}, nil
+}
+
+func encodeDeleteProviderResponse(context.Context, interface{}) (interface{}, error) {
+	return &adminv1.DeleteProviderResponse{}, nil
+}
+
+func decodeReindexRequest(context.Context, interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func encodeReindexResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(storage.ReindexResult)
+	res := &adminv1.ReindexResponse{
+		Modules:   int64(result.Modules),
+		Providers: int64(result.Providers),
+		Mirrored:  int64(result.Mirrored),
+		Drift:     make([]*adminv1.Drift, 0, len(result.Drift)),
+	}
+	for _, d := range result.Drift {
+		res.Drift = append(res.Drift, &adminv1.Drift{Key: d.Key, Reason: d.Reason})
+	}
+	return res, nil
+}
+
+func decodeModuleDownloadStatsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*adminv1.GetModuleDownloadStatsRequest)
+	return moduleRequest{namespace: req.GetNamespace(), name: req.GetName(), provider: req.GetProvider()}, nil
+}
+
+func decodeProviderDownloadStatsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*adminv1.GetProviderDownloadStatsRequest)
+	return providerRequest{namespace: req.GetNamespace(), name: req.GetName()}, nil
+}
+
+func encodeDownloadStatsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	s := response.(*core.DownloadStats)
+	return &adminv1.DownloadStats{Total: s.Total, Versions: s.Versions}, nil
+}
@@ -0,0 +1,166 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/admin/adminv1"
+	"github.com/boring-registry/boring-registry/pkg/auth"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testToken = "secret"
+
+// newTestClient serves the Service on a local listener and returns a client of it
+func newTestClient(t *testing.T, svc Service) adminv1.AdminServiceClient {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	adminv1.RegisterAdminServiceServer(server, NewGRPCServer(svc, auth.Middleware(auth.NewStaticProvider(testToken))))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return adminv1.NewAdminServiceClient(conn)
+}
+
+func authenticated() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testToken)
+}
+
+func TestGRPCServer_Modules(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	client := newTestClient(t, NewService(s, WithOverwritePolicy(module.NewOverwritePolicy([]string{"acme"}))))
+	ctx := authenticated()
+	m := &adminv1.Module{Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"}
+
+	_, err := client.PublishModule(context.Background(), &adminv1.PublishModuleRequest{Module: m, Archive: []byte("v1")})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	res, err := client.PublishModule(ctx, &adminv1.PublishModuleRequest{Module: m, Archive: []byte("v1")})
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("v1"))), res.GetChecksum())
+	assert.False(t, res.GetReplaced())
+
+	_, err = client.PublishModule(ctx, &adminv1.PublishModuleRequest{Module: m, Archive: []byte("v2")})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	res, err = client.PublishModule(ctx, &adminv1.PublishModuleRequest{Module: m, Archive: []byte("v2"), Replace: true, Reason: "rebuild"})
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("v2"))), res.GetChecksum())
+	assert.True(t, res.GetReplaced())
+
+	list, err := client.ListModuleVersions(ctx, &adminv1.ListModuleVersionsRequest{Namespace: "acme", Name: "vpc", Provider: "aws"})
+	assert.NoError(t, err)
+	assert.Len(t, list.GetModules(), 1)
+	assert.Equal(t, "1.0.0", list.GetModules()[0].GetVersion())
+
+	_, err = client.DeleteModule(ctx, &adminv1.DeleteModuleRequest{Module: m})
+	assert.NoError(t, err)
+	_, err = client.DeleteModule(ctx, &adminv1.DeleteModuleRequest{Module: m})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteModule(ctx, &adminv1.DeleteModuleRequest{Module: &adminv1.Module{Namespace: "acme"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.GetModuleDownloadStats(ctx, &adminv1.GetModuleDownloadStatsRequest{Namespace: "acme", Name: "vpc", Provider: "aws"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestGRPCServer_PublishProvider(t *testing.T) {
+	ctx := authenticated()
+	s := storage.NewMemoryStorage()
+	client := newTestClient(t, NewService(s))
+
+	entity, err := openpgp.NewEntity("boring-registry", "", "providers@example.com", nil)
+	assert.NoError(t, err)
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(w))
+	assert.NoError(t, w.Close())
+	assert.NoError(t, s.UploadSigningKeys(context.Background(), "acme", "", &core.SigningKeys{
+		GPGPublicKeys: []core.GPGPublicKey{{KeyID: entity.PrimaryKey.KeyIdString(), ASCIIArmor: publicKey.String()}},
+	}))
+
+	archive := &adminv1.File{Name: "terraform-provider-dummy_1.0.0_linux_amd64.zip", Content: []byte("archive")}
+	sums := &adminv1.File{
+		Name:    "terraform-provider-dummy_1.0.0_SHA256SUMS",
+		Content: []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256(archive.Content), archive.Name)),
+	}
+	var signature bytes.Buffer
+	assert.NoError(t, openpgp.DetachSign(&signature, entity, bytes.NewReader(sums.Content), nil))
+
+	tampered := &adminv1.File{Name: archive.Name, Content: []byte("tampered")}
+	_, err = client.PublishProvider(ctx, &adminv1.PublishProviderRequest{
+		Namespace:           "acme",
+		Sha256Sums:          sums,
+		Sha256SumsSignature: signature.Bytes(),
+		Archives:            []*adminv1.File{tampered},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.PublishProvider(ctx, &adminv1.PublishProviderRequest{
+		Namespace:           "acme",
+		Sha256Sums:          sums,
+		Sha256SumsSignature: []byte("invalid"),
+		Archives:            []*adminv1.File{archive},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := client.PublishProvider(ctx, &adminv1.PublishProviderRequest{
+		Namespace:           "acme",
+		Sha256Sums:          sums,
+		Sha256SumsSignature: signature.Bytes(),
+		Archives:            []*adminv1.File{archive},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "dummy", res.GetName())
+	assert.Equal(t, "1.0.0", res.GetVersion())
+
+	list, err := client.ListProviderVersions(ctx, &adminv1.ListProviderVersionsRequest{Namespace: "acme", Name: "dummy"})
+	assert.NoError(t, err)
+	assert.Len(t, list.GetVersions(), 1)
+	assert.Equal(t, "linux", list.GetVersions()[0].GetPlatforms()[0].GetOs())
+
+	_, err = client.DeleteProvider(ctx, &adminv1.DeleteProviderRequest{Namespace: "acme", Name: "dummy", Version: "1.0.0"})
+	assert.NoError(t, err)
+
+	reindex, err := client.Reindex(ctx, &adminv1.ReindexRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), reindex.GetProviders())
+}
+
+func TestGRPCServer_ReadOnly(t *testing.T) {
+	client := newTestClient(t, ReadOnlyMiddleware()(NewService(storage.NewMemoryStorage())))
+	ctx := authenticated()
+
+	_, err := client.PublishModule(ctx, &adminv1.PublishModuleRequest{
+		Module:  &adminv1.Module{Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"},
+		Archive: []byte("v1"),
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.ListModuleVersions(ctx, &adminv1.ListModuleVersionsRequest{Namespace: "acme", Name: "vpc", Provider: "aws"})
+	assert.NoError(t, err)
+}
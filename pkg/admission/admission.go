@@ -0,0 +1,229 @@
+// Package admission evaluates uploads of modules and providers against a policy, which is written in Rego
+// and evaluated by an Open Policy Agent server, before the artifacts are stored.
+package admission
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+const (
+	// OperationUpload is the first upload of an artifact
+	OperationUpload = "upload"
+
+	// OperationReplace is the replacement of an existing module version, e.g. when it's republished
+	OperationReplace = "replace"
+
+	ArtifactModule   = "module"
+	ArtifactProvider = "provider"
+)
+
+// ErrEvaluationFailed is returned if the policy couldn't be evaluated, the upload is denied in that case
+var ErrEvaluationFailed = errors.New("policy evaluation failed")
+
+// Input is the input document of the policy
+type Input struct {
+	Operation string   `json:"operation"`
+	Artifact  Artifact `json:"artifact"`
+	Uploader  Uploader `json:"uploader"`
+
+	// Versions are the versions of the module or provider which are stored already, e.g. to enforce version bumps
+	Versions []string `json:"versions"`
+
+	// Files is the inventory of the uploaded archive
+	Files []File `json:"files"`
+}
+
+// Artifact is the metadata of the uploaded module or provider archive
+type Artifact struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider,omitempty"`
+	Version   string `json:"version"`
+	OS        string `json:"os,omitempty"`
+	Arch      string `json:"arch,omitempty"`
+	Filename  string `json:"filename"`
+	Size      int64  `json:"size"`
+}
+
+// Uploader identifies who uploads the artifact
+type Uploader struct {
+	// Identity is the identity of the client authenticated by the server, or the publisher of the upload command
+	Identity string `json:"identity"`
+
+	// ClientAddress is the IP address of the client, which is only known to the server
+	ClientAddress string `json:"client_address,omitempty"`
+}
+
+// File is a regular file of the uploaded archive
+type File struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Mode string `json:"mode"`
+}
+
+// Controller admits or denies uploads
+type Controller interface {
+	// Admit returns an error wrapping core.ErrAdmissionDenied if the upload is denied by the policy
+	Admit(ctx context.Context, input *Input) error
+}
+
+// OPA evaluates the policy with the Data API of an Open Policy Agent server.
+// The result of the policy is either a boolean, or an object with an optional allow boolean and a list of deny messages:
+//
+//	package boring_registry.admission
+//
+//	deny contains msg if {
+//		not startswith(input.artifact.name, "platform-")
+//		msg := "module names have to start with platform-"
+//	}
+type OPA struct {
+	url    string
+	client *http.Client
+}
+
+// NewOPA returns a Controller evaluating the policy at the URL of the Data API,
+// e.g. http://localhost:8181/v1/data/boring_registry/admission
+func NewOPA(policyURL string, timeout time.Duration) (*OPA, error) {
+	u, err := url.Parse(policyURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("admission policy URL %q must be an http(s) URL of the OPA Data API", policyURL)
+	}
+	return &OPA{url: u.String(), client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (o *OPA) Admit(ctx context.Context, input *Input) error {
+	body, err := json.Marshal(map[string]*Input{"input": input})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEvaluationFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s responded with %s", ErrEvaluationFailed, o.url, resp.Status)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("%w: invalid response of %s: %w", ErrEvaluationFailed, o.url, err)
+	}
+	return decide(response.Result)
+}
+
+// decide interprets the result of the policy, an undefined result denies the upload
+func decide(result json.RawMessage) error {
+	if len(result) == 0 {
+		return fmt.Errorf("%w: the policy is undefined", core.ErrAdmissionDenied)
+	}
+
+	var allow bool
+	if err := json.Unmarshal(result, &allow); err == nil {
+		if !allow {
+			return fmt.Errorf("%w: the policy doesn't allow the upload", core.ErrAdmissionDenied)
+		}
+		return nil
+	}
+
+	var decision struct {
+		Allow *bool    `json:"allow"`
+		Deny  []string `json:"deny"`
+	}
+	if err := json.Unmarshal(result, &decision); err != nil {
+		return fmt.Errorf("%w: the policy result is neither a boolean nor an object with allow and deny: %w", ErrEvaluationFailed, err)
+	}
+	if len(decision.Deny) > 0 {
+		return fmt.Errorf("%w: %s", core.ErrAdmissionDenied, strings.Join(decision.Deny, ", "))
+	}
+	if decision.Allow != nil && !*decision.Allow {
+		return fmt.Errorf("%w: the policy doesn't allow the upload", core.ErrAdmissionDenied)
+	}
+	return nil
+}
+
+// Inventory returns the regular files of a tar.gz or zip archive.
+// Archives in other formats have no inventory, so that the policy can still decide on the metadata.
+func Inventory(archive io.ReaderAt, size int64) ([]File, error) {
+	header := make([]byte, 4)
+	if _, err := archive.ReadAt(header, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return tarGzInventory(io.NewSectionReader(archive, 0, size))
+	case bytes.HasPrefix(header, []byte("PK")):
+		return zipInventory(archive, size)
+	}
+	return []File{}, nil
+}
+
+func tarGzInventory(r io.Reader) ([]File, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gr.Close()
+
+	files := []File{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			files = append(files, File{
+				Name: strings.TrimPrefix(header.Name, "./"),
+				Size: header.Size,
+				Mode: fmt.Sprintf("%04o", header.Mode&0o7777),
+			})
+		}
+	}
+}
+
+func zipInventory(r io.ReaderAt, size int64) ([]File, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	files := []File{}
+	for _, zf := range zr.File {
+		if zf.Mode().IsRegular() {
+			files = append(files, File{
+				Name: zf.Name,
+				Size: int64(zf.UncompressedSize64),
+				Mode: fmt.Sprintf("%04o", zf.Mode().Perm()),
+			})
+		}
+	}
+	return files, nil
+}
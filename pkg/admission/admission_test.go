@@ -0,0 +1,114 @@
+package admission
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecide(t *testing.T) {
+	testCases := []struct {
+		name   string
+		result string
+		err    error
+	}{
+		{name: "undefined", err: core.ErrAdmissionDenied},
+		{name: "allowed", result: `true`},
+		{name: "not allowed", result: `false`, err: core.ErrAdmissionDenied},
+		{name: "empty object", result: `{}`},
+		{name: "allow and deny", result: `{"allow": true, "deny": ["missing README.md"]}`, err: core.ErrAdmissionDenied},
+		{name: "allow false", result: `{"allow": false, "deny": []}`, err: core.ErrAdmissionDenied},
+		{name: "invalid", result: `"allow"`, err: ErrEvaluationFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := decide(json.RawMessage(tc.result))
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestOPA_Admit(t *testing.T) {
+	var received map[string]*Input
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/data/boring_registry/admission", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received["input"].Artifact.Name == "denied" {
+			_, _ = w.Write([]byte(`{"result": {"deny": ["module names have to start with platform-"]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result": {"allow": true}}`))
+	}))
+	defer server.Close()
+
+	opa, err := NewOPA(server.URL+"/v1/data/boring_registry/admission", time.Second)
+	assert.NoError(t, err)
+
+	input := &Input{
+		Operation: OperationUpload,
+		Artifact:  Artifact{Type: ArtifactModule, Namespace: "acme", Name: "platform-vpc", Provider: "aws", Version: "1.0.0"},
+		Uploader:  Uploader{Identity: "ci"},
+		Versions:  []string{"0.9.0"},
+		Files:     []File{{Name: "main.tf", Size: 10, Mode: "0644"}},
+	}
+	assert.NoError(t, opa.Admit(context.Background(), input))
+	assert.Equal(t, input, received["input"])
+
+	input.Artifact.Name = "denied"
+	err = opa.Admit(context.Background(), input)
+	assert.ErrorIs(t, err, core.ErrAdmissionDenied)
+	assert.ErrorContains(t, err, "module names have to start with platform-")
+
+	_, err = NewOPA("localhost:8181", time.Second)
+	assert.Error(t, err)
+}
+
+func TestInventory(t *testing.T) {
+	var tarGz bytes.Buffer
+	gw := gzip.NewWriter(&tarGz)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "./modules/", Mode: 0o755, Typeflag: tar.TypeDir}))
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "./main.tf", Mode: 0o644, Size: 4, Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte("main"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	files, err := Inventory(bytes.NewReader(tarGz.Bytes()), int64(tarGz.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, []File{{Name: "main.tf", Size: 4, Mode: "0644"}}, files)
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	fh := &zip.FileHeader{Name: "terraform-provider-random_v2.0.0"}
+	fh.SetMode(0o755)
+	fw, err := zw.CreateHeader(fh)
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte("binary"))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	files, err = Inventory(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, []File{{Name: "terraform-provider-random_v2.0.0", Size: 6, Mode: "0755"}}, files)
+
+	files, err = Inventory(bytes.NewReader([]byte("archive")), 7)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
@@ -0,0 +1,112 @@
+// Package attestation verifies Sigstore bundles, which contain cosign signatures or in-toto attestations like SLSA provenance,
+// against the artifacts they were created for.
+package attestation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// BundleSuffix is the suffix of the Sigstore bundle stored next to an artifact, following the naming of cosign
+const BundleSuffix = ".sigstore.json"
+
+var (
+	// ErrBundleMissing is returned if an artifact requires an attestation but none was provided
+	ErrBundleMissing = errors.New("sigstore bundle is missing")
+
+	// ErrInvalidBundle is returned if the bundle can't be parsed or doesn't verify against the artifact and identities
+	ErrInvalidBundle = errors.New("sigstore bundle is invalid")
+)
+
+// Identity is the identity of a keyless signer, which is recorded in the certificate issued by Fulcio
+type Identity struct {
+	// Issuer is the OIDC issuer of the signer, e.g. https://token.actions.githubusercontent.com
+	Issuer string
+
+	// SubjectRegex is a regular expression the subject of the signer has to match,
+	// e.g. the workflow ^https://github.com/acme/.+/.github/workflows/release.yml@refs/tags/v.+$
+	SubjectRegex string
+}
+
+// ParseIdentity parses an identity in the form <issuer>=<subject regex>
+func ParseIdentity(s string) (Identity, error) {
+	issuer, subject, ok := strings.Cut(s, "=")
+	if !ok || issuer == "" || subject == "" {
+		return Identity{}, fmt.Errorf("identity %q must be in the form <issuer>=<subject regex>", s)
+	}
+	return Identity{Issuer: issuer, SubjectRegex: subject}, nil
+}
+
+// Verifier verifies Sigstore bundles against the trusted root and accepts only bundles signed by one of the identities
+type Verifier struct {
+	verifier   *verify.SignedEntityVerifier
+	identities []verify.CertificateIdentity
+}
+
+// NewVerifier returns a Verifier of bundles signed by one of the identities.
+// Bundles have to be recorded in the transparency log of the trusted root.
+func NewVerifier(trustedMaterial root.TrustedMaterial, identities []Identity) (*Verifier, error) {
+	if len(identities) == 0 {
+		return nil, errors.New("at least one identity is required to verify sigstore bundles")
+	}
+
+	v := &Verifier{}
+	for _, identity := range identities {
+		certificateIdentity, err := verify.NewShortCertificateIdentity(identity.Issuer, "", "", identity.SubjectRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity %s=%s: %w", identity.Issuer, identity.SubjectRegex, err)
+		}
+		v.identities = append(v.identities, certificateIdentity)
+	}
+
+	var err error
+	v.verifier, err = verify.NewSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// NewTrustedRoot reads the trusted root from the file, or fetches the trusted root of the Sigstore public-good instance if the path is empty
+func NewTrustedRoot(path string) (root.TrustedMaterial, error) {
+	if path != "" {
+		return root.NewTrustedRootFromPath(path)
+	}
+	return root.FetchTrustedRoot()
+}
+
+// Verify verifies the JSON-encoded Sigstore bundle against the artifact
+func (v *Verifier) Verify(artifact, bundleJSON []byte) error {
+	return v.verify(bundleJSON, verify.WithArtifact(bytes.NewReader(artifact)))
+}
+
+// VerifyDigest verifies the JSON-encoded Sigstore bundle against the SHA-256 digest of the artifact
+func (v *Verifier) VerifyDigest(digest, bundleJSON []byte) error {
+	return v.verify(bundleJSON, verify.WithArtifactDigest("sha256", digest))
+}
+
+func (v *Verifier) verify(bundleJSON []byte, artifact verify.ArtifactPolicyOption) error {
+	var b bundle.Bundle
+	if err := b.UnmarshalJSON(bundleJSON); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	return v.verifyEntity(&b, artifact)
+}
+
+func (v *Verifier) verifyEntity(entity verify.SignedEntity, artifact verify.ArtifactPolicyOption) error {
+	options := make([]verify.PolicyOption, 0, len(v.identities))
+	for _, identity := range v.identities {
+		options = append(options, verify.WithCertificateIdentity(identity))
+	}
+
+	if _, err := v.verifier.Verify(entity, verify.NewPolicy(artifact, options...)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package attestation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	issuer   = "https://token.actions.githubusercontent.com"
+	workflow = "https://github.com/acme/terraform-provider-dummy/.github/workflows/release.yml@refs/tags/v1.0.0"
+)
+
+func TestParseIdentity(t *testing.T) {
+	identity, err := ParseIdentity(issuer + "=^https://github.com/acme/.+$")
+	assert.NoError(t, err)
+	assert.Equal(t, Identity{Issuer: issuer, SubjectRegex: "^https://github.com/acme/.+$"}, identity)
+
+	_, err = ParseIdentity(issuer)
+	assert.ErrorContains(t, err, "<issuer>=<subject regex>")
+}
+
+func TestVerifier(t *testing.T) {
+	sigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	v, err := NewVerifier(sigstore, []Identity{
+		{Issuer: "https://accounts.google.com", SubjectRegex: "^releases@acme.com$"},
+		{Issuer: issuer, SubjectRegex: "^https://github.com/acme/"},
+	})
+	assert.NoError(t, err)
+
+	artifact := []byte("terraform-provider-dummy_1.0.0_SHA256SUMS")
+	digest := sha256.Sum256(artifact)
+
+	entity, err := sigstore.Sign(workflow, issuer, artifact)
+	assert.NoError(t, err)
+	assert.NoError(t, v.verifyEntity(entity, verify.WithArtifact(bytes.NewReader(artifact))))
+	assert.NoError(t, v.verifyEntity(entity, verify.WithArtifactDigest("sha256", digest[:])))
+
+	// Signatures of other artifacts are rejected
+	err = v.verifyEntity(entity, verify.WithArtifact(bytes.NewReader([]byte("tampered"))))
+	assert.ErrorIs(t, err, ErrInvalidBundle)
+
+	// Signatures of unknown identities are rejected
+	entity, err = sigstore.Sign("https://github.com/evil/terraform-provider-dummy/.github/workflows/release.yml@refs/tags/v1.0.0", issuer, artifact)
+	assert.NoError(t, err)
+	err = v.verifyEntity(entity, verify.WithArtifact(bytes.NewReader(artifact)))
+	assert.ErrorIs(t, err, ErrInvalidBundle)
+
+	err = v.Verify(artifact, []byte("{}"))
+	assert.ErrorIs(t, err, ErrInvalidBundle)
+
+	_, err = NewVerifier(sigstore, nil)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -26,10 +27,14 @@ func Middleware(providers ...Provider) endpoint.Middleware {
 					err := provider.Verify(ctx, token)
 					if err != nil {
 						slog.Debug("failed to verify token", slog.String("err", err.Error()))
-						return nil, fmt.Errorf("failed to verify token: %w", err)
+						// Errors of the providers are returned as invalid token, so that clients receive a 401 instead of a 500
+						if !errors.Is(err, core.ErrInvalidToken) {
+							err = fmt.Errorf("%w: %w", core.ErrInvalidToken, err)
+						}
+						return nil, err
 					} else {
 						slog.Debug("successfully verified token")
-						return next(ctx, request)
+						return next(core.WithIdentity(ctx, identify(token)), request)
 					}
 				}
 			} else {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/boring-registry/boring-registry/pkg/core"
+
 	"github.com/go-kit/kit/auth/jwt"
 	"github.com/stretchr/testify/assert"
 )
@@ -52,3 +54,21 @@ func TestAuthMiddleware(t *testing.T) {
 func nopEndpoint(ctx context.Context, request interface{}) (interface{}, error) {
 	return true, nil
 }
+
+func TestAuthMiddleware_Identity(t *testing.T) {
+	// The payload of the JWT is {"sub":"organization:acme:workspace:prod"}
+	token := "eyJhbGciOiJub25lIn0.eyJzdWIiOiJvcmdhbml6YXRpb246YWNtZTp3b3Jrc3BhY2U6cHJvZCJ9.c2ln"
+	for token, identity := range map[string]string{
+		token: "organization:acme:workspace:prod",
+		"foo": "token:2c26b46b68ff",
+	} {
+		ctx := context.WithValue(context.Background(), jwt.JWTContextKey, token)
+		res, err := Middleware(NewStaticProvider(token))(identityEndpoint)(ctx, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, identity, res)
+	}
+}
+
+func identityEndpoint(ctx context.Context, request interface{}) (interface{}, error) {
+	return core.Identity(ctx), nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// allNamespaces grants a permission in all namespaces, and is required by requests which don't address a single namespace
+const allNamespaces = "*"
+
+// Permission is the access of a group to a namespace
+type Permission int
+
+const (
+	PermissionNone Permission = iota
+	PermissionRead
+	PermissionWrite // implies PermissionRead
+)
+
+var permissions = map[string]Permission{
+	"read":  PermissionRead,
+	"write": PermissionWrite,
+}
+
+func (p Permission) String() string {
+	for name, permission := range permissions {
+		if p == permission {
+			return name
+		}
+	}
+	return "none"
+}
+
+// GroupResolver returns the directory groups of the client of a verified token
+type GroupResolver interface {
+	Groups(ctx context.Context, token string) ([]string, error)
+}
+
+// ClaimGroupResolver reads the groups from a claim of the verified JWT.
+// Identity providers federating LDAP directories or SAML IdPs, like Okta, Keycloak, or Dex, populate such a claim with the directory groups.
+type ClaimGroupResolver struct {
+	claim []string
+}
+
+// NewClaimGroupResolver returns a GroupResolver reading the claim, nested claims like realm_access.roles are separated by dots
+func NewClaimGroupResolver(claim string) *ClaimGroupResolver {
+	return &ClaimGroupResolver{claim: strings.Split(claim, ".")}
+}
+
+func (r *ClaimGroupResolver) Groups(_ context.Context, token string) ([]string, error) {
+	claims, ok := tokenClaims(token)
+	if !ok {
+		// Tokens which aren't JWTs, like the static API tokens, don't belong to any group
+		return nil, nil
+	}
+
+	var value any = claims
+	for _, key := range r.claim {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		value = nested[key]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		groups := make([]string, 0, len(v))
+		for _, group := range v {
+			s, ok := group.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: the %s claim must be a list of strings", core.ErrInvalidToken, strings.Join(r.claim, "."))
+			}
+			groups = append(groups, s)
+		}
+		return groups, nil
+	default:
+		return nil, fmt.Errorf("%w: the %s claim must be a list of strings", core.ErrInvalidToken, strings.Join(r.claim, "."))
+	}
+}
+
+// GroupPolicy maps directory groups to their permissions in the namespaces
+type GroupPolicy struct {
+	grants map[string]map[string]Permission
+}
+
+// ParseGroupPolicy parses grants in the form of <group>=<namespace>:<permission>, the namespace * grants the permission in all namespaces
+func ParseGroupPolicy(grants []string) (*GroupPolicy, error) {
+	p := &GroupPolicy{grants: make(map[string]map[string]Permission)}
+	for _, grant := range grants {
+		group, scope, ok := strings.Cut(grant, "=")
+		namespace, name, ok2 := strings.Cut(scope, ":")
+		if !ok || !ok2 || group == "" || namespace == "" {
+			return nil, fmt.Errorf("invalid group permission %q, expected <group>=<namespace>:<permission>", grant)
+		}
+		permission, ok := permissions[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid permission %q of group %s, expected read or write", name, group)
+		}
+
+		if p.grants[group] == nil {
+			p.grants[group] = make(map[string]Permission)
+		}
+		p.grants[group][namespace] = max(p.grants[group][namespace], permission)
+	}
+	return p, nil
+}
+
+// Allowed returns true if any of the groups has the required permission in the namespace.
+// An empty namespace requires the permission in all namespaces.
+func (p *GroupPolicy) Allowed(groups []string, namespace string, required Permission) bool {
+	if namespace == "" {
+		namespace = allNamespaces
+	}
+	for _, group := range groups {
+		grants := p.grants[group]
+		if grants[namespace] >= required || grants[allNamespaces] >= required {
+			return true
+		}
+	}
+	return false
+}
+
+type namespaceKey struct{}
+
+// NamespaceToContext moves the namespace of the route into the context, so that GroupMiddleware can authorize the request
+func NamespaceToContext(ctx context.Context, r *http.Request) context.Context {
+	if namespace, ok := mux.Vars(r)["namespace"]; ok {
+		return context.WithValue(ctx, namespaceKey{}, namespace)
+	}
+	return ctx
+}
+
+type permissionKey struct{}
+
+// ReadRequest declares the request as read, so that GroupMiddleware only requires the read permission.
+// It's used by endpoints which only read, but don't use the GET method, like the batch version listing.
+func ReadRequest(ctx context.Context, _ *http.Request) context.Context {
+	return context.WithValue(ctx, permissionKey{}, PermissionRead)
+}
+
+// requiredPermission returns the permission the request requires.
+// Requests which don't declare their permission are reads if they use the GET or HEAD method.
+func requiredPermission(ctx context.Context) Permission {
+	if permission, ok := ctx.Value(permissionKey{}).(Permission); ok {
+		return permission
+	}
+
+	switch ctx.Value(httptransport.ContextKeyRequestMethod) {
+	case http.MethodGet, http.MethodHead:
+		return PermissionRead
+	default:
+		return PermissionWrite
+	}
+}
+
+// GroupMiddleware authorizes the requests verified by Middleware with the permissions of the groups of the client.
+// Requests which don't address a single namespace, like the batch version listing or the gRPC admin API,
+// require the permission in all namespaces.
+func GroupMiddleware(policy *GroupPolicy, resolver GroupResolver) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			token, ok := ctx.Value(jwt.JWTContextKey).(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: request does not contain a token", core.ErrUnauthorized)
+			}
+
+			groups, err := resolver.Groups(ctx, token)
+			if err != nil {
+				return nil, err
+			}
+
+			namespace, _ := ctx.Value(namespaceKey{}).(string)
+			required := requiredPermission(ctx)
+			if !policy.Allowed(groups, namespace, required) {
+				slog.Debug("denied request of groups",
+					slog.String("identity", core.Identity(ctx)),
+					slog.Any("groups", groups),
+					slog.String("namespace", namespace),
+					slog.String("permission", required.String()),
+				)
+				if namespace == "" {
+					return nil, fmt.Errorf("%w: %s permission in all namespaces required", core.ErrForbidden, required)
+				}
+				return nil, fmt.Errorf("%w: %s permission in namespace %s required", core.ErrForbidden, required, namespace)
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/go-kit/kit/auth/jwt"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+// unsignedToken returns a JWT with the payload, which is only decoded after a Provider verified the token
+func unsignedToken(payload string) string {
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2ln"
+}
+
+func TestClaimGroupResolver(t *testing.T) {
+	for name, tc := range map[string]struct {
+		claim   string
+		token   string
+		groups  []string
+		wantErr bool
+	}{
+		"list":         {claim: "groups", token: unsignedToken(`{"groups":["platform","network"]}`), groups: []string{"platform", "network"}},
+		"single group": {claim: "groups", token: unsignedToken(`{"groups":"platform"}`), groups: []string{"platform"}},
+		"nested claim": {claim: "realm_access.roles", token: unsignedToken(`{"realm_access":{"roles":["platform"]}}`), groups: []string{"platform"}},
+		"no claim":     {claim: "groups", token: unsignedToken(`{"sub":"alice"}`)},
+		"static token": {claim: "groups", token: "secret"},
+		"invalid":      {claim: "groups", token: unsignedToken(`{"groups":[1]}`), wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			groups, err := NewClaimGroupResolver(tc.claim).Groups(context.Background(), tc.token)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, core.ErrInvalidToken)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.groups, groups)
+		})
+	}
+}
+
+func TestParseGroupPolicy(t *testing.T) {
+	for _, grant := range []string{"platform", "platform=acme", "=acme:read", "platform=acme:admin"} {
+		_, err := ParseGroupPolicy([]string{grant})
+		assert.Error(t, err, grant)
+	}
+
+	policy, err := ParseGroupPolicy([]string{"platform=acme:write", "platform=acme:read", "auditors=*:read"})
+	assert.NoError(t, err)
+	assert.True(t, policy.Allowed([]string{"platform"}, "acme", PermissionWrite))
+	assert.True(t, policy.Allowed([]string{"platform"}, "acme", PermissionRead))
+	assert.False(t, policy.Allowed([]string{"platform"}, "example", PermissionRead))
+	assert.False(t, policy.Allowed([]string{"platform"}, "", PermissionRead))
+	assert.True(t, policy.Allowed([]string{"network", "auditors"}, "example", PermissionRead))
+	assert.True(t, policy.Allowed([]string{"auditors"}, "", PermissionRead))
+	assert.False(t, policy.Allowed([]string{"auditors"}, "acme", PermissionWrite))
+	assert.False(t, policy.Allowed(nil, "acme", PermissionRead))
+}
+
+func TestGroupMiddleware(t *testing.T) {
+	policy, err := ParseGroupPolicy([]string{"platform=acme:write", "developers=acme:read", "auditors=*:read"})
+	assert.NoError(t, err)
+	middleware := GroupMiddleware(policy, NewClaimGroupResolver("groups"))
+
+	for name, tc := range map[string]struct {
+		groups    string
+		method    string
+		namespace string
+		read      bool
+		wantErr   bool
+	}{
+		"write":             {groups: `["platform"]`, method: http.MethodPut, namespace: "acme"},
+		"read":              {groups: `["developers"]`, method: http.MethodGet, namespace: "acme"},
+		"read-only group":   {groups: `["developers"]`, method: http.MethodPost, namespace: "acme", wantErr: true},
+		"other namespace":   {groups: `["platform"]`, method: http.MethodGet, namespace: "example", wantErr: true},
+		"without namespace": {groups: `["platform"]`, method: http.MethodGet, wantErr: true},
+		"batch listing":     {groups: `["auditors"]`, method: http.MethodPost, read: true},
+		"batch upload":      {groups: `["auditors"]`, method: http.MethodPost, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), jwt.JWTContextKey, unsignedToken(`{"groups":`+tc.groups+`}`))
+			ctx = context.WithValue(ctx, httptransport.ContextKeyRequestMethod, tc.method)
+			if tc.namespace != "" {
+				ctx = context.WithValue(ctx, namespaceKey{}, tc.namespace)
+			}
+			if tc.read {
+				ctx = ReadRequest(ctx, nil)
+			}
+
+			_, err := middleware(nopEndpoint)(ctx, nil)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, core.ErrForbidden)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
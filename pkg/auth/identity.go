@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// identify returns the identity of a verified token, which is the subject of JWTs.
+// Other tokens are identified by a prefix of their hash, so that they don't end up in the reports or logs.
+func identify(token string) string {
+	if claims, ok := tokenClaims(token); ok {
+		if subject, ok := claims["sub"].(string); ok && subject != "" {
+			return subject
+		}
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// tokenClaims returns the claims of a JWT without verifying it, which has to be done by a Provider first
+func tokenClaims(token string) (map[string]any, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
@@ -37,6 +37,11 @@ func (o *OidcProvider) TokenURL() string {
 	return o.provider.Endpoint().TokenURL
 }
 
+// DeviceAuthURL returns the device authorization endpoint, which is empty if the provider doesn't support the device authorization grant
+func (o *OidcProvider) DeviceAuthURL() string {
+	return o.provider.Endpoint().DeviceAuthURL
+}
+
 func NewOidcProvider(ctx context.Context, issuer, clientIdentifier string) (*OidcProvider, error) {
 	logger := slog.Default()
 	start := time.Now()
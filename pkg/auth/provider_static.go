@@ -3,17 +3,22 @@ package auth
 import (
 	"context"
 	"strings"
+	"sync"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 )
 
 type StaticProvider struct {
+	mu     sync.RWMutex
 	tokens []string
 }
 
 func (p *StaticProvider) String() string { return "static" }
 
 func (p *StaticProvider) Verify(ctx context.Context, token string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	for _, validToken := range p.tokens {
 		if token == validToken {
 			return nil
@@ -23,12 +28,27 @@ func (p *StaticProvider) Verify(ctx context.Context, token string) error {
 	return core.ErrInvalidToken
 }
 
+// SetTokens replaces the valid tokens, so that tokens can be rotated without a restart
+func (p *StaticProvider) SetTokens(tokens ...string) {
+	parsed := parseTokens(tokens)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tokens = parsed
+}
+
 func NewStaticProvider(tokens ...string) Provider {
-	// spf13/viper and spf13/pflag currently do not support reading multiple values from environment variables and
-	// extracting them into a StringSlice/StringArray.
-	// This workaround extracts comma-separated tokens into separate tokens
-	//
-	// See https://github.com/spf13/viper/issues/339 and https://github.com/spf13/viper/issues/380
+	return &StaticProvider{
+		tokens: parseTokens(tokens),
+	}
+}
+
+// spf13/viper and spf13/pflag currently do not support reading multiple values from environment variables and
+// extracting them into a StringSlice/StringArray.
+// This workaround extracts comma-separated tokens into separate tokens
+//
+// See https://github.com/spf13/viper/issues/339 and https://github.com/spf13/viper/issues/380
+func parseTokens(tokens []string) []string {
 	var parsed []string
 	for _, t := range tokens {
 		if strings.ContainsAny(t, ",") {
@@ -44,8 +64,5 @@ func NewStaticProvider(tokens ...string) Provider {
 			parsed = append(parsed, t)
 		}
 	}
-
-	return &StaticProvider{
-		tokens: parsed,
-	}
+	return parsed
 }
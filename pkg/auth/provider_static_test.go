@@ -1,8 +1,10 @@
 package auth
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewStaticProvider(t *testing.T) {
@@ -37,3 +39,13 @@ func TestNewStaticProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestStaticProvider_SetTokens(t *testing.T) {
+	p := NewStaticProvider("old").(*StaticProvider)
+	assert.NoError(t, p.Verify(context.Background(), "old"))
+
+	p.SetTokens("new,newer")
+	assert.Error(t, p.Verify(context.Background(), "old"))
+	assert.NoError(t, p.Verify(context.Background(), "new"))
+	assert.NoError(t, p.Verify(context.Background(), "newer"))
+}
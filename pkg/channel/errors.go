@@ -0,0 +1,14 @@
+package channel
+
+import "errors"
+
+var (
+	// ErrChannelNotFound is returned if a channel that doesn't exist is deleted
+	ErrChannelNotFound = errors.New("channel not found")
+
+	// ErrInvalidChannel is returned if the name of a channel could be confused with a version
+	ErrInvalidChannel = errors.New("invalid channel")
+
+	// ErrChannelsDisabled is returned if channels are managed while the storage doesn't support them
+	ErrChannelsDisabled = errors.New("channels are disabled")
+)
@@ -0,0 +1,105 @@
+// Package channel manages named channels like stable, which point to a version of a module or provider.
+// Consumers can track a channel, while the versions themselves stay immutable and can still be pinned.
+package channel
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/hashicorp/go-version"
+)
+
+var channelNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{0,63}$`)
+
+// Validate returns an error if the name isn't a valid channel name.
+// Names have to start with a lowercase letter and must not be a version, so that versions and channels can't be confused.
+func Validate(name string) error {
+	if !channelNameRegex.MatchString(name) {
+		return fmt.Errorf("%w: %q must start with a lowercase letter followed by lowercase letters, digits or dashes", ErrInvalidChannel, name)
+	}
+	if _, err := version.NewVersion(name); err == nil {
+		return fmt.Errorf("%w: %q is a version", ErrInvalidChannel, name)
+	}
+	return nil
+}
+
+// Manager reads and updates the channels of artifacts.
+type Manager struct {
+	storage Storage
+
+	// mu serializes updates of the same instance, concurrent updates by multiple instances can still overwrite each other
+	mu sync.Mutex
+}
+
+// NewManager returns a Manager persisting the channels in the storage.
+func NewManager(storage Storage) *Manager {
+	return &Manager{storage: storage}
+}
+
+// List returns the channels of the artifact
+func (m *Manager) List(ctx context.Context, artifact string) (core.Channels, error) {
+	channels, err := m.storage.Channels(ctx, artifact)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channels of %s: %w", artifact, err)
+	}
+	if channels == nil {
+		channels = core.Channels{}
+	}
+	return channels, nil
+}
+
+// Resolve returns the version the channel points to.
+// Versions and unknown channels are returned unchanged, so that they fail the regular lookup of the version.
+func (m *Manager) Resolve(ctx context.Context, artifact, versionOrChannel string) (string, error) {
+	if Validate(versionOrChannel) != nil {
+		return versionOrChannel, nil
+	}
+
+	channels, err := m.List(ctx, artifact)
+	if err != nil {
+		return "", err
+	}
+	if v, ok := channels[versionOrChannel]; ok {
+		return v, nil
+	}
+	return versionOrChannel, nil
+}
+
+// Set points the channel of the artifact to the version
+func (m *Manager) Set(ctx context.Context, artifact, channel, v string) error {
+	if err := Validate(channel); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channels, err := m.List(ctx, artifact)
+	if err != nil {
+		return err
+	}
+	channels[channel] = v
+
+	return m.storage.UploadChannels(ctx, artifact, channels)
+}
+
+// Delete removes the channel of the artifact
+func (m *Manager) Delete(ctx context.Context, artifact, channel string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channels, err := m.List(ctx, artifact)
+	if err != nil {
+		return err
+	}
+	if _, ok := channels[channel]; !ok {
+		return fmt.Errorf("%w: %s of %s", ErrChannelNotFound, channel, artifact)
+	}
+	delete(channels, channel)
+
+	return m.storage.UploadChannels(ctx, artifact, channels)
+}
@@ -0,0 +1,54 @@
+package channel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("stable"))
+	assert.NoError(t, Validate("release-2"))
+	assert.ErrorIs(t, Validate("1.2.3"), ErrInvalidChannel)
+	assert.ErrorIs(t, Validate("v1"), ErrInvalidChannel)
+	assert.ErrorIs(t, Validate("Stable"), ErrInvalidChannel)
+	assert.ErrorIs(t, Validate(""), ErrInvalidChannel)
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(NewInmemStorage())
+	artifact := "modules/acme/vpc/aws"
+
+	channels, err := m.List(ctx, artifact)
+	assert.NoError(t, err)
+	assert.Empty(t, channels)
+
+	assert.NoError(t, m.Set(ctx, artifact, "stable", "2.3.1"))
+	assert.NoError(t, m.Set(ctx, artifact, "beta", "3.0.0-beta.1"))
+	assert.ErrorIs(t, m.Set(ctx, artifact, "2.0.0", "2.3.1"), ErrInvalidChannel)
+
+	channels, err = m.List(ctx, artifact)
+	assert.NoError(t, err)
+	assert.Equal(t, core.Channels{"stable": "2.3.1", "beta": "3.0.0-beta.1"}, channels)
+
+	for input, expected := range map[string]string{
+		"stable":  "2.3.1",
+		"2.0.0":   "2.0.0",
+		"unknown": "unknown",
+	} {
+		v, err := m.Resolve(ctx, artifact, input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, v)
+	}
+
+	assert.NoError(t, m.Delete(ctx, artifact, "beta"))
+	assert.ErrorIs(t, m.Delete(ctx, artifact, "beta"), ErrChannelNotFound)
+
+	channels, err = m.List(ctx, artifact)
+	assert.NoError(t, err)
+	assert.Equal(t, core.Channels{"stable": "2.3.1"}, channels)
+}
@@ -0,0 +1,16 @@
+package channel
+
+import (
+	"context"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Storage represents the Storage of channels.
+type Storage interface {
+	// Channels returns the channels of an artifact, which are empty if no channel has been set yet.
+	Channels(ctx context.Context, artifact string) (core.Channels, error)
+
+	// UploadChannels persists the channels of an artifact and overwrites existing ones.
+	UploadChannels(ctx context.Context, artifact string, channels core.Channels) error
+}
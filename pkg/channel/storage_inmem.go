@@ -0,0 +1,38 @@
+package channel
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// InmemStorage is a Storage implementation
+// This storage is typically used for testing purposes.
+type InmemStorage struct {
+	mu       sync.RWMutex
+	channels map[string]core.Channels
+}
+
+func (s *InmemStorage) Channels(_ context.Context, artifact string) (core.Channels, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.channels[artifact]), nil
+}
+
+func (s *InmemStorage) UploadChannels(_ context.Context, artifact string, channels core.Channels) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.channels[artifact] = maps.Clone(channels)
+	return nil
+}
+
+// NewInmemStorage returns a fully initialized in-memory storage.
+func NewInmemStorage() Storage {
+	return &InmemStorage{
+		channels: make(map[string]core.Channels),
+	}
+}
@@ -0,0 +1,190 @@
+// Package client implements the module and provider registry protocols, as well as the publishing APIs of the boring-registry,
+// so that tools can list, fetch and publish modules and providers without shelling out to the CLI.
+// Listing and fetching works against any server implementing the registry protocols.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/discovery"
+)
+
+// defaultChunkSize is the number of bytes sent per request of a resumable upload
+const defaultChunkSize = 8 << 20
+
+// Client talks to a single registry host, whose services are discovered with the remote service discovery protocol
+type Client struct {
+	hostname   string
+	token      string
+	httpClient *http.Client
+	resolver   discovery.ServiceDiscoveryResolver
+
+	// adminURL is the base URL of the admin API, which isn't advertised by the discovery document
+	adminURL  *url.URL
+	chunkSize int64
+}
+
+type Option func(*Client)
+
+// WithToken authenticates the requests with the token as bearer token
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, e.g. to configure timeouts or custom CAs
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithAdminURL sets the base URL of the admin API, e.g. https://boring-registry.example.com:5601/admin.
+// It defaults to https://<hostname>/admin, which is where the server mounts it without --listen-write-address.
+func WithAdminURL(u *url.URL) Option {
+	return func(c *Client) {
+		if u != nil {
+			c.adminURL = u
+		}
+	}
+}
+
+// WithChunkSize sets the number of bytes sent per request of a resumable upload
+func WithChunkSize(size int64) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.chunkSize = size
+		}
+	}
+}
+
+// New returns a Client for the registry served at hostname, which may include a port, e.g. boring-registry.example.com:8443
+func New(hostname string, options ...Option) (*Client, error) {
+	if hostname == "" {
+		return nil, fmt.Errorf("the hostname of the registry is required")
+	}
+
+	c := &Client{
+		hostname:   hostname,
+		httpClient: http.DefaultClient,
+		adminURL:   &url.URL{Scheme: "https", Host: hostname, Path: "/admin"},
+		chunkSize:  defaultChunkSize,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	c.resolver = discovery.NewRemoteServiceDiscovery(c.httpClient)
+
+	return c, nil
+}
+
+// serviceURL returns the URL of the modules.v1 or providers.v1 service joined with the path elements
+func (c *Client) serviceURL(ctx context.Context, service string, elem ...string) (*url.URL, error) {
+	discovered, err := c.resolver.Resolve(ctx, c.hostname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the services of %s: %w", c.hostname, err)
+	}
+
+	var p string
+	switch service {
+	case "modules.v1":
+		p = discovered.ModulesV1
+	case "providers.v1":
+		p = discovered.ProvidersV1
+	}
+	if p == "" {
+		return nil, fmt.Errorf("%w: %s isn't advertised by %s", ErrServiceNotSupported, service, c.hostname)
+	}
+
+	// The path is either absolute or relative to the host serving the discovery document
+	base, err := url.Parse(strings.TrimSuffix(p, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s path: %w", service, err)
+	}
+	return discovered.URL.ResolveReference(base).JoinPath(elem...), nil
+}
+
+func (c *Client) adminEndpoint(elem ...string) *url.URL {
+	return c.adminURL.JoinPath(elem...)
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, u *url.URL, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+	return req, nil
+}
+
+// do sends the request and decodes the JSON response into v, unless v is nil.
+// Responses with other status codes than expected are returned as errors.
+func (c *Client) do(req *http.Request, v any, expected ...int) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to %s: %w", req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp, expected...); err != nil {
+		return nil, err
+	}
+	if v != nil {
+		if err := decodeJSON(resp, v); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func decodeJSON(resp *http.Response, v any) error {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", resp.Request.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// checkStatus returns an error containing the messages of the error response, which the boring-registry returns as {"errors": [...]}
+func checkStatus(resp *http.Response, expected ...int) error {
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+
+	msg := fmt.Sprintf("status code %d for %s %s", resp.StatusCode, resp.Request.Method, resp.Request.URL.Redacted())
+	var body core.ErrorResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err == nil && len(body.Errors) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(body.Errors, ", "))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, msg)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrConflict, msg)
+	default:
+		return fmt.Errorf("unexpected %s", msg)
+	}
+}
+
+// escape escapes the path elements, so that they can't traverse to other endpoints
+func escape(elem ...string) []string {
+	escaped := make([]string, len(elem))
+	for i, e := range elem {
+		escaped[i] = url.PathEscape(e)
+	}
+	return escaped
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/resumable"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"modules.v1":"/v1/modules/","providers.v1":"/v1/providers/"}`))
+	})
+	mux.Handle("/", handler)
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	c, err := New(u.Host, WithHTTPClient(server.Client()), WithToken("secret"), WithChunkSize(4))
+	assert.NoError(t, err)
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestClient_ListModuleVersions(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/modules/acme/vpc/aws/versions":
+			_, _ = w.Write([]byte(`{"modules":[{"versions":[{"version":"1.0.0"},{"version":"1.1.0"}]}]}`))
+		default:
+			writeJSON(w, http.StatusNotFound, core.ErrorResponse{Errors: []string{"failed to locate module"}})
+		}
+	}))
+
+	versions, err := c.ListModuleVersions(context.Background(), "acme", "vpc", "aws")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.0.0", "1.1.0"}, versions)
+
+	_, err = c.ListModuleVersions(context.Background(), "acme", "unknown", "aws")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorContains(t, err, "failed to locate module")
+}
+
+func TestClient_ModuleDownloadURL(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/modules/acme/vpc/aws/1.0.0/download":
+			w.Header().Set("X-Terraform-Get", "/archives/vpc-1.0.0.tar.gz")
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/modules/acme/vpc/aws/1.1.0/download":
+			_, _ = w.Write([]byte(`{"location":"git::https://example.com/vpc.git?ref=v1.1.0"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	location, err := c.ModuleDownloadURL(context.Background(), "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("https://%s/archives/vpc-1.0.0.tar.gz", c.hostname), location)
+
+	location, err = c.ModuleDownloadURL(context.Background(), "acme", "vpc", "aws", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "git::https://example.com/vpc.git?ref=v1.1.0", location)
+
+	_, err = c.ModuleDownloadURL(context.Background(), "acme", "vpc", "aws", "2.0.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_Providers(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/providers/acme/dummy/versions":
+			writeJSON(w, http.StatusOK, core.ProviderVersions{Versions: []core.ProviderVersion{
+				{Version: "1.0.0", Protocols: []string{"5.0"}, Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}}},
+			}})
+		case "/v1/providers/acme/dummy/1.0.0/download/linux/amd64":
+			writeJSON(w, http.StatusOK, core.Provider{Filename: "terraform-provider-dummy_1.0.0_linux_amd64.zip", Shasum: "abc"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	versions, err := c.ListProviderVersions(context.Background(), "acme", "dummy")
+	assert.NoError(t, err)
+	assert.Len(t, versions.Versions, 1)
+	assert.Equal(t, "1.0.0", versions.Versions[0].Version)
+
+	provider, err := c.GetProvider(context.Background(), "acme", "dummy", "1.0.0", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", provider.Shasum)
+
+	_, err = c.GetProvider(context.Background(), "acme", "dummy", "1.0.0", "darwin", "arm64")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_RepublishModule(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/admin/modules/acme/vpc/aws/1.0.0", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("force"))
+		assert.Equal(t, "fix broken release", r.URL.Query().Get("reason"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "archive", string(body))
+		writeJSON(w, http.StatusOK, RepublishedModule{Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0", Checksum: "sha256:new", PreviousChecksum: "sha256:old"})
+	}))
+
+	republished, err := c.RepublishModule(context.Background(), "acme", "vpc", "aws", "1.0.0", strings.NewReader("archive"), "fix broken release")
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:new", republished.Checksum)
+	assert.Equal(t, "sha256:old", republished.PreviousChecksum)
+}
+
+// fakeUploads implements the offset semantics of the resumable upload API and fails the first chunk after receiving part of it
+type fakeUploads struct {
+	mu       sync.Mutex
+	session  resumable.Session
+	data     []byte
+	failOnce bool
+}
+
+func (f *fakeUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	base := "/admin/uploads/providers/acme/dummy"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == base:
+		var req struct {
+			Filename string `json:"filename"`
+			Length   int64  `json:"length"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.session = resumable.Session{ID: "1", Namespace: "acme", Name: "dummy", Filename: req.Filename, Length: req.Length}
+		writeJSON(w, http.StatusCreated, f.session)
+	case r.Method == http.MethodGet && r.URL.Path == base+"/1":
+		writeJSON(w, http.StatusOK, f.session)
+	case r.Method == http.MethodPatch && r.URL.Path == base+"/1":
+		offset, _ := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+		if offset != f.session.Offset {
+			writeJSON(w, http.StatusConflict, core.ErrorResponse{Errors: []string{"upload offset doesn't match the received bytes"}})
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if f.failOnce {
+			f.failOnce = false
+			body = body[:1]
+			f.data = append(f.data, body...)
+			f.session.Offset += int64(len(body))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.data = append(f.data, body...)
+		f.session.Offset += int64(len(body))
+		writeJSON(w, http.StatusOK, f.session)
+	case r.Method == http.MethodPost && r.URL.Path == base+"/1/complete":
+		if f.session.Offset != f.session.Length {
+			writeJSON(w, http.StatusConflict, core.ErrorResponse{Errors: []string{"upload is incomplete"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, f.session)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient_UploadProviderFile(t *testing.T) {
+	uploads := &fakeUploads{failOnce: true}
+	c := newTestClient(t, uploads)
+
+	content := "terraform-provider-dummy"
+	session, err := c.UploadProviderFile(context.Background(), "acme", "dummy", "terraform-provider-dummy_1.0.0_linux_amd64.zip", strings.NewReader(content), int64(len(content)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), session.Offset)
+	assert.Equal(t, content, string(uploads.data))
+}
@@ -0,0 +1,14 @@
+package client
+
+import "errors"
+
+var (
+	// ErrNotFound is returned if the registry responds with 404 Not Found, e.g. for unknown modules or providers
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is returned if the registry responds with 409 Conflict, e.g. for upload offsets which don't match
+	ErrConflict = errors.New("conflict")
+
+	// ErrServiceNotSupported is returned if the registry doesn't advertise the modules.v1 or providers.v1 service
+	ErrServiceNotSupported = errors.New("service isn't supported by the registry")
+)
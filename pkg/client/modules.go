@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ModuleVersion is a version of a module, as listed by the module registry protocol
+type ModuleVersion struct {
+	Version string `json:"version"`
+}
+
+type moduleVersionsResponse struct {
+	Modules []struct {
+		Versions []ModuleVersion `json:"versions"`
+	} `json:"modules"`
+}
+
+// RepublishedModule is the result of replacing the archive of an existing module version
+type RepublishedModule struct {
+	Namespace        string `json:"namespace"`
+	Name             string `json:"name"`
+	Provider         string `json:"provider"`
+	Version          string `json:"version"`
+	Checksum         string `json:"checksum"`
+	PreviousChecksum string `json:"previous_checksum"`
+}
+
+// ListModuleVersions returns the versions of the module, or an ErrNotFound error if the registry doesn't know the module
+func (c *Client) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]string, error) {
+	u, err := c.serviceURL(ctx, "modules.v1", escape(namespace, name, provider, "versions")...)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list moduleVersionsResponse
+	if _, err := c.do(req, &list, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, m := range list.Modules {
+		for _, v := range m.Versions {
+			versions = append(versions, v.Version)
+		}
+	}
+	return versions, nil
+}
+
+// ModuleDownloadURL returns the source address of the module version, which Terraform downloads the module from.
+// Relative locations are resolved against the download endpoint, other source addresses like git::https://... are returned as they are.
+func (c *Client) ModuleDownloadURL(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	u, err := c.serviceURL(ctx, "modules.v1", escape(namespace, name, provider, version, "download")...)
+	if err != nil {
+		return "", err
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// The location is either returned in the X-Terraform-Get header or in the body
+	var body struct {
+		Location string `json:"location"`
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request to %s: %w", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusNoContent, http.StatusOK); err != nil {
+		return "", err
+	}
+
+	location := resp.Header.Get("X-Terraform-Get")
+	if location == "" && resp.StatusCode == http.StatusOK {
+		if err := decodeJSON(resp, &body); err != nil {
+			return "", err
+		}
+		location = body.Location
+	}
+	if location == "" {
+		return "", fmt.Errorf("the registry didn't return the location of module %s/%s/%s/%s", namespace, name, provider, version)
+	}
+
+	if strings.HasPrefix(location, "/") || strings.HasPrefix(location, "./") || strings.HasPrefix(location, "../") {
+		resolved, err := resp.Request.URL.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve module location %s: %w", location, err)
+		}
+		return resolved.String(), nil
+	}
+	return location, nil
+}
+
+// RepublishModule replaces the archive of an existing module version through the admin API of the boring-registry.
+// The server has to allow overwrites in the namespace with --allow-overwrite, the reason is recorded in the audit log.
+// New module versions are published with the upload command, which writes to the storage backend directly.
+func (c *Client) RepublishModule(ctx context.Context, namespace, name, provider, version string, archive io.Reader, reason string) (*RepublishedModule, error) {
+	u := c.adminEndpoint(escape("modules", namespace, name, provider, version)...)
+	u.RawQuery = url.Values{"force": {"true"}, "reason": {reason}}.Encode()
+
+	req, err := c.newRequest(ctx, http.MethodPut, u, archive)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	var republished RepublishedModule
+	if _, err := c.do(req, &republished, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &republished, nil
+}
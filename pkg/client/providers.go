@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// ListProviderVersions returns the versions of the provider and the platforms they were released for,
+// or an ErrNotFound error if the registry doesn't know the provider
+func (c *Client) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	u, err := c.serviceURL(ctx, "providers.v1", escape(namespace, name, "versions")...)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions core.ProviderVersions
+	if _, err := c.do(req, &versions, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &versions, nil
+}
+
+// GetProvider returns the download URL, checksum, and signing keys of the provider release for the platform
+func (c *Client) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	u, err := c.serviceURL(ctx, "providers.v1", escape(namespace, name, version, "download", os, arch)...)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider core.Provider
+	if _, err := c.do(req, &provider, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &provider, nil
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/boring-registry/boring-registry/pkg/resumable"
+)
+
+// maxUploadRetries is the number of consecutive chunks which may fail before an upload is given up
+const maxUploadRetries = 3
+
+// UploadProviderFile uploads a file of a provider release in chunks through the resumable upload API of the boring-registry,
+// which has to be enabled with --resumable-uploads. Failed chunks are resent from the offset the server received.
+// The files are stored like with the upload provider command, so the archives have to be uploaded before the SHA256SUMS file and its signature.
+func (c *Client) UploadProviderFile(ctx context.Context, namespace, name, filename string, r io.ReaderAt, length int64) (*resumable.Session, error) {
+	body, err := json.Marshal(map[string]any{"filename": filename, "length": length})
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, c.adminEndpoint(escape("uploads", "providers", namespace, name)...), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var session resumable.Session
+	if _, err := c.do(req, &session, http.StatusCreated); err != nil {
+		return nil, fmt.Errorf("failed to start the upload of %s: %w", filename, err)
+	}
+
+	return c.upload(ctx, &session, r)
+}
+
+// ResumeProviderUpload continues an upload session started by UploadProviderFile, e.g. after the process was restarted.
+// The reader has to return the same file, the upload continues from the offset the server received.
+func (c *Client) ResumeProviderUpload(ctx context.Context, namespace, name, id string, r io.ReaderAt) (*resumable.Session, error) {
+	session, err := c.uploadSession(ctx, namespace, name, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.upload(ctx, session, r)
+}
+
+// AbortProviderUpload deletes the upload session and the bytes received so far
+func (c *Client) AbortProviderUpload(ctx context.Context, namespace, name, id string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, c.adminEndpoint(escape("uploads", "providers", namespace, name, id)...), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(req, nil, http.StatusNoContent)
+	return err
+}
+
+// upload sends the remaining bytes of the session in chunks and completes it
+func (c *Client) upload(ctx context.Context, session *resumable.Session, r io.ReaderAt) (*resumable.Session, error) {
+	failures := 0
+	for session.Offset < session.Length {
+		updated, err := c.appendChunk(ctx, session, r)
+		if err == nil {
+			session, failures = updated, 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		failures++
+		if failures > maxUploadRetries {
+			return nil, fmt.Errorf("failed to upload %s: %w", session.Filename, err)
+		}
+		slog.Warn("failed to upload chunk, resuming from the received offset", slog.String("filename", session.Filename), slog.Int64("offset", session.Offset), slog.String("error", err.Error()))
+
+		// The chunk may have been received partially, the server knows the offset to continue from
+		if session, err = c.uploadSession(ctx, session.Namespace, session.Name, session.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.adminEndpoint(escape("uploads", "providers", session.Namespace, session.Name, session.ID, "complete")...), nil)
+	if err != nil {
+		return nil, err
+	}
+	var completed resumable.Session
+	if _, err := c.do(req, &completed, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to complete the upload of %s: %w", session.Filename, err)
+	}
+	return &completed, nil
+}
+
+func (c *Client) appendChunk(ctx context.Context, session *resumable.Session, r io.ReaderAt) (*resumable.Session, error) {
+	size := min(c.chunkSize, session.Length-session.Offset)
+	req, err := c.newRequest(ctx, http.MethodPatch, c.adminEndpoint(escape("uploads", "providers", session.Namespace, session.Name, session.ID)...), io.NewSectionReader(r, session.Offset, size))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+
+	var updated resumable.Session
+	if _, err := c.do(req, &updated, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if updated.Offset <= session.Offset {
+		return nil, errors.New("the server didn't receive any bytes of the chunk")
+	}
+	return &updated, nil
+}
+
+func (c *Client) uploadSession(ctx context.Context, namespace, name, id string) (*resumable.Session, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.adminEndpoint(escape("uploads", "providers", namespace, name, id)...), nil)
+	if err != nil {
+		return nil, err
+	}
+	var session resumable.Session
+	if _, err := c.do(req, &session, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to retrieve upload session %s: %w", id, err)
+	}
+	return &session, nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CacheMiddleware adds HTTP caching semantics to handlers.
+type CacheMiddleware interface {
+	// WrapHandler wraps the given HTTP handler with ETag and Cache-Control support
+	WrapHandler(handler http.Handler) http.Handler
+}
+
+type cacheMiddleware struct {
+	cacheControl string
+}
+
+// WrapHandler buffers successful GET responses to derive an ETag from the hash of their content.
+// Requests with a matching If-None-Match header are answered with 304 Not Modified and without a body.
+func (c *cacheMiddleware) WrapHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(bw, r)
+
+		if bw.status != http.StatusOK {
+			w.WriteHeader(bw.status)
+			_, _ = w.Write(bw.buf.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(bw.buf.Bytes())
+		etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", c.cacheControl)
+
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(bw.buf.Bytes())
+	})
+}
+
+// etagMatches implements the weak comparison of If-None-Match as specified in RFC 9110
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponseWriter holds back the response until the ETag is known
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buf         bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+	b.wroteHeader = true
+	b.status = status
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.buf.Write(p)
+}
+
+// NewCacheMiddleware returns a CacheMiddleware.
+// A maxAge of zero requires clients to revalidate the response on every request.
+// Responses are only marked as cacheable by shared caches like CDNs if the registry is public,
+// as responses to authenticated requests must not be served to other clients.
+func NewCacheMiddleware(maxAge time.Duration, public bool) CacheMiddleware {
+	visibility := "private"
+	if public {
+		visibility = "public"
+	}
+
+	cacheControl := fmt.Sprintf("%s, no-cache", visibility)
+	if maxAge > 0 {
+		cacheControl = fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds()))
+	}
+
+	return &cacheMiddleware{
+		cacheControl: cacheControl,
+	}
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestCacheMiddleware_WrapHandler(t *testing.T) {
+	t.Parallel()
+	assert := assertion.New(t)
+
+	body := `{"versions":[{"version":"1.0.0"}]}`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	})
+
+	testCases := []struct {
+		name                 string
+		middleware           CacheMiddleware
+		path                 string
+		ifNoneMatch          func(etag string) string
+		expectedStatus       int
+		expectedBody         string
+		expectedCacheControl string
+	}{
+		{
+			name:                 "initial request of a private registry",
+			middleware:           NewCacheMiddleware(0, false),
+			path:                 "/versions",
+			expectedStatus:       http.StatusOK,
+			expectedBody:         body,
+			expectedCacheControl: "private, no-cache",
+		},
+		{
+			name:                 "conditional request with matching etag",
+			middleware:           NewCacheMiddleware(5*time.Minute, true),
+			path:                 "/versions",
+			ifNoneMatch:          func(etag string) string { return etag },
+			expectedStatus:       http.StatusNotModified,
+			expectedCacheControl: "public, max-age=300",
+		},
+		{
+			name:                 "conditional request with a list of weak etags",
+			middleware:           NewCacheMiddleware(0, true),
+			path:                 "/versions",
+			ifNoneMatch:          func(etag string) string { return `"abc", W/` + etag },
+			expectedStatus:       http.StatusNotModified,
+			expectedCacheControl: "public, no-cache",
+		},
+		{
+			name:                 "conditional request with outdated etag",
+			middleware:           NewCacheMiddleware(0, true),
+			path:                 "/versions",
+			ifNoneMatch:          func(string) string { return `"outdated"` },
+			expectedStatus:       http.StatusOK,
+			expectedBody:         body,
+			expectedCacheControl: "public, no-cache",
+		},
+		{
+			name:           "errors are not cached",
+			middleware:     NewCacheMiddleware(0, true),
+			path:           "/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			h := tc.middleware.WrapHandler(handler)
+
+			// Learn the ETag of the response first
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			etag := rec.Header().Get("ETag")
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.ifNoneMatch != nil {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch(etag))
+			}
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(tc.expectedStatus, rec.Code)
+			assert.Equal(tc.expectedBody, rec.Body.String())
+			assert.Equal(tc.expectedCacheControl, rec.Header().Get("Cache-Control"))
+			if tc.expectedCacheControl != "" {
+				assert.Equal(etag, rec.Header().Get("ETag"))
+				assert.NotEmpty(etag)
+			} else {
+				assert.Empty(rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+package core
+
+// Channels maps the names of channels like stable to the version of a module or provider they point to.
+type Channels map[string]string
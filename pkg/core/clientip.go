@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the reverse proxies and load balancers in front of the registry,
+// whose Forwarded and X-Forwarded-For headers are used to determine the address of the client.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses IP addresses like 10.0.0.1 and CIDR ranges like 10.0.0.0/8
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	res := make(TrustedProxies, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+			}
+			res = append(res, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		res = append(res, prefix.Masked())
+	}
+	return res, nil
+}
+
+// Trusted returns true if the address belongs to a trusted proxy
+func (t TrustedProxies) Trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client forwarded by the trusted proxies,
+// and false if the request wasn't forwarded by a trusted proxy.
+// The forwarded addresses are walked from the right, as only the entries appended by trusted proxies can be trusted,
+// and the first address which doesn't belong to a trusted proxy is the client.
+func (t TrustedProxies) ClientIP(r *http.Request) (netip.Addr, bool) {
+	peer, ok := parseForwardedAddr(r.RemoteAddr)
+	if !ok || !t.Trusted(peer) {
+		return netip.Addr{}, false
+	}
+
+	var client netip.Addr
+	hops := forwardedFor(r.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseForwardedAddr(hops[i])
+		if !ok {
+			// The entries left of an invalid entry can't be attributed to a trusted proxy
+			break
+		}
+		client = addr
+		if !t.Trusted(addr) {
+			break
+		}
+	}
+	return client, client.IsValid()
+}
+
+// RealClientIP sets the remote address of requests received from trusted proxies to the address of the client,
+// so that the logs, the consumers, and the admission policies see the client instead of the load balancer.
+func RealClientIP(handler http.Handler, proxies TrustedProxies) http.Handler {
+	if len(proxies) == 0 {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if client, ok := proxies.ClientIP(r); ok {
+			r = r.Clone(r.Context())
+			r.RemoteAddr = net.JoinHostPort(client.String(), "0")
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// forwardedFor returns the addresses of the Forwarded header, or of the X-Forwarded-For header if there is none
+func forwardedFor(header http.Header) []string {
+	var hops []string
+	if forwarded := header.Values("Forwarded"); len(forwarded) > 0 {
+		for _, element := range strings.Split(strings.Join(forwarded, ","), ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+		return hops
+	}
+
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseForwardedAddr parses addresses like 192.0.2.60, 192.0.2.60:4711, [2001:db8::17]:4711, or 2001:db8::17
+func parseForwardedAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"lb.example.com"})
+	assert.Error(t, err)
+
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"})
+	assert.NoError(t, err)
+	assert.Len(t, proxies, 3)
+}
+
+func TestRealClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::/32"})
+	assert.NoError(t, err)
+
+	var remoteAddr string
+	handler := RealClientIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	}), proxies)
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "untrusted peer",
+			remoteAddr: "198.51.100.7:4711",
+			header:     http.Header{"X-Forwarded-For": {"203.0.113.1"}},
+			want:       "198.51.100.7:4711",
+		},
+		{
+			name:       "trusted peer without header",
+			remoteAddr: "10.0.0.1:4711",
+			want:       "10.0.0.1:4711",
+		},
+		{
+			name:       "x-forwarded-for",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"X-Forwarded-For": {"203.0.113.1"}},
+			want:       "203.0.113.1:0",
+		},
+		{
+			name:       "spoofed entries left of the client",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"X-Forwarded-For": {"192.0.2.1, 203.0.113.1", "10.0.0.2"}},
+			want:       "203.0.113.1:0",
+		},
+		{
+			name:       "invalid entry",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"X-Forwarded-For": {"unknown, 10.0.0.2"}},
+			want:       "10.0.0.2:0",
+		},
+		{
+			name:       "forwarded takes precedence",
+			remoteAddr: "[2001:db8::1]:4711",
+			header: http.Header{
+				"Forwarded":       {`for=192.0.2.1, for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.2`},
+				"X-Forwarded-For": {"203.0.113.1"},
+			},
+			want: "192.0.2.1:0",
+		},
+		{
+			name:       "forwarded ipv6 client",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"Forwarded": {`for="[2001:db9::17]:4711"`}},
+			want:       "[2001:db9::17]:0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for key, values := range tc.header {
+				r.Header[key] = values
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, tc.want, remoteAddr)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"net"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of the context carrying the identity of the authenticated client
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// Identity returns the identity of the authenticated client, or an empty string if the request isn't authenticated
+func Identity(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey{}).(string)
+	return identity
+}
+
+// ClientAddress returns the IP address of the client, which is populated by httptransport.PopulateRequestContext
+func ClientAddress(ctx context.Context) string {
+	addr, _ := ctx.Value(httptransport.ContextKeyRequestRemoteAddr).(string)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// ConsumerStats holds the downloads of a single consumer of a module or provider.
+type ConsumerStats struct {
+	Versions     map[string]int64 `json:"versions"`
+	LastDownload time.Time        `json:"last_download"`
+}
+
+// Consumers holds the consumers of a single module or provider by their identity.
+type Consumers map[string]*ConsumerStats
+
+// Add increments the downloads of a version by the consumer by count.
+func (c Consumers) Add(consumer, version string, count int64, downloadedAt time.Time) {
+	s, ok := c[consumer]
+	if !ok {
+		s = &ConsumerStats{Versions: make(map[string]int64)}
+		c[consumer] = s
+	}
+
+	s.Versions[version] += count
+	if downloadedAt.After(s.LastDownload) {
+		s.LastDownload = downloadedAt
+	}
+}
+
+// Merge adds all downloads of other to c.
+func (c Consumers) Merge(other Consumers) {
+	for consumer, s := range other {
+		for version, count := range s.Versions {
+			c.Add(consumer, version, count, s.LastDownload)
+		}
+	}
+}
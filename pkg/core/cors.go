@@ -0,0 +1,87 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultCORSAllowedMethods are the methods of the registry API
+	DefaultCORSAllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete}
+
+	// DefaultCORSAllowedHeaders are the request headers sent by clients of the registry API
+	DefaultCORSAllowedHeaders = []string{"Authorization", "Content-Type", "If-None-Match", "X-Request-ID"}
+
+	// corsExposedHeaders are the response headers which browsers make available to scripts in addition to the safelisted ones
+	corsExposedHeaders = strings.Join([]string{"ETag", "X-Request-ID"}, ", ")
+)
+
+// CORSPolicy configures which origins may call the registry from browsers.
+type CORSPolicy struct {
+	// AllowedOrigins are origins like https://ui.example.com, which may contain a wildcard like https://*.example.com.
+	// A single * allows all origins.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+
+	// MaxAge is the duration for which browsers may cache the result of a preflight request, not sent if 0
+	MaxAge time.Duration
+}
+
+// allowsOrigin returns true if the origin matches any of the allowed origins
+func (p *CORSPolicy) allowsOrigin(origin string) bool {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		prefix, suffix, ok := strings.Cut(strings.ToLower(allowed), "*")
+		lower := strings.ToLower(origin)
+		if ok && len(lower) > len(prefix)+len(suffix) && strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS wraps the handler to answer preflight requests and add the CORS headers to responses to allowed origins.
+// Requests of other origins are passed on without CORS headers, so that browsers deny access to the responses.
+func CORS(handler http.Handler, policy CORSPolicy) http.Handler {
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		// Responses differ by origin, which has to be considered by caches
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !policy.allowsOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		// Browsers check the requested method and headers against the allowed ones themselves
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+		if policy.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
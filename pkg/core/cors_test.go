@@ -0,0 +1,63 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	policy := CORSPolicy{
+		AllowedOrigins: []string{"https://ui.example.com", "https://*.acme.com"},
+		AllowedMethods: DefaultCORSAllowedMethods,
+		AllowedHeaders: DefaultCORSAllowedHeaders,
+		MaxAge:         time.Hour,
+	}
+	handler := CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}), policy)
+
+	testCases := []struct {
+		name       string
+		method     string
+		origin     string
+		preflight  bool
+		wantStatus int
+		wantOrigin string
+		wantMaxAge string
+	}{
+		{name: "same origin request", method: http.MethodGet, wantStatus: http.StatusTeapot},
+		{name: "allowed origin", method: http.MethodGet, origin: "https://ui.example.com", wantStatus: http.StatusTeapot, wantOrigin: "https://ui.example.com"},
+		{name: "wildcard origin", method: http.MethodGet, origin: "https://portal.acme.com", wantStatus: http.StatusTeapot, wantOrigin: "https://portal.acme.com"},
+		{name: "wildcard doesn't match the bare domain", method: http.MethodGet, origin: "https://.acme.com", wantStatus: http.StatusTeapot},
+		{name: "denied origin", method: http.MethodGet, origin: "https://evil.example.com", wantStatus: http.StatusTeapot},
+		{name: "preflight", method: http.MethodOptions, origin: "https://ui.example.com", preflight: true, wantStatus: http.StatusNoContent, wantOrigin: "https://ui.example.com", wantMaxAge: "3600"},
+		{name: "denied preflight", method: http.MethodOptions, origin: "https://evil.example.com", preflight: true, wantStatus: http.StatusNoContent},
+		{name: "options without preflight", method: http.MethodOptions, origin: "https://ui.example.com", wantStatus: http.StatusTeapot, wantOrigin: "https://ui.example.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, "/v1/modules/acme/vpc/aws/versions", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+			if tc.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			assert.Equal(t, tc.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tc.wantMaxAge, w.Header().Get("Access-Control-Max-Age"))
+			if tc.preflight && tc.wantOrigin != "" {
+				assert.Equal(t, "GET, HEAD, POST, PUT, DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+				assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+			}
+		})
+	}
+}
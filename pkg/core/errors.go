@@ -13,13 +13,43 @@ var (
 	ErrVarMissing = errors.New("variable missing")
 	ErrVarType    = errors.New("invalid variable type")
 
+	// ErrContentLength is returned if a body is shorter or longer than its Content-Length
+	ErrContentLength = errors.New("body doesn't match the content length")
+
 	// Auth errors
 	ErrUnauthorized = errors.New("unauthorized")           // Middleware error
 	ErrInvalidToken = errors.New("failed to verify token") // Provider error
+	ErrForbidden    = errors.New("forbidden")              // Authorization error
 
 	// Storage errors
 	ErrObjectNotFound      = errors.New("failed to locate object")
 	ErrObjectAlreadyExists = errors.New("object already exists")
+
+	// Redirect errors
+	ErrInvalidSignature = errors.New("invalid download signature")
+	ErrSignatureExpired = errors.New("download signature expired")
+
+	// ErrTooManyRequests is returned if the storage backend keeps throttling requests
+	ErrTooManyRequests = errors.New("too many requests")
+
+	// Quota errors
+	ErrArtifactTooLarge = errors.New("artifact too large")
+	ErrQuotaExceeded    = errors.New("quota exceeded")
+
+	// ErrArtifactRejected is returned if the scanner found something in an uploaded artifact
+	ErrArtifactRejected = errors.New("artifact rejected by the scanner")
+
+	// ErrAdmissionDenied is returned if the admission policy denies an upload
+	ErrAdmissionDenied = errors.New("upload denied by the admission policy")
+
+	// ErrInvalidConstraint is returned if a version constraint can't be parsed
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+
+	// ErrLocked is returned if another upload of the same artifact version holds the lock for too long
+	ErrLocked = errors.New("another upload of the artifact is in progress")
+
+	// ErrReadOnly is returned for changes to a registry running in read-only mode
+	ErrReadOnly = errors.New("the registry is in read-only mode, changes are disabled")
 )
 
 type ProviderError struct {
@@ -54,27 +84,48 @@ func (p ProviderError) Error() string {
 	return message
 }
 
-// GenericError returns the HTTP status code for module-agnostic boring-registry errors
+// GenericError returns the HTTP status code for module-agnostic boring-registry errors,
+// which includes the errors of the storage backends and the auth middleware
 func GenericError(err error) int {
-	if errors.Is(err, ErrVarMissing) {
+	var providerError *ProviderError
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &providerError) && providerError.StatusCode != 0 {
+		return providerError.StatusCode
+	} else if errors.As(err, &maxBytesError) || errors.Is(err, ErrArtifactTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	} else if errors.Is(err, ErrVarMissing) || errors.Is(err, ErrVarType) || errors.Is(err, ErrInvalidConstraint) || errors.Is(err, ErrContentLength) {
 		return http.StatusBadRequest
 	} else if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrUnauthorized) {
 		return http.StatusUnauthorized
-	} else if errors.Is(err, ErrObjectAlreadyExists) {
+	} else if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrSignatureExpired) || errors.Is(err, ErrReadOnly) || errors.Is(err, ErrAdmissionDenied) || errors.Is(err, ErrForbidden) {
+		return http.StatusForbidden
+	} else if errors.Is(err, ErrObjectNotFound) {
+		return http.StatusNotFound
+	} else if errors.Is(err, ErrObjectAlreadyExists) || errors.Is(err, ErrLocked) {
 		return http.StatusConflict
+	} else if errors.Is(err, ErrArtifactRejected) {
+		return http.StatusUnprocessableEntity
+	} else if errors.Is(err, ErrTooManyRequests) || errors.Is(err, ErrQuotaExceeded) {
+		return http.StatusTooManyRequests
 	}
 
 	// Default error
 	return http.StatusInternalServerError
 }
 
-// HandleErrorResponse handles the HTTP response for errors
-func HandleErrorResponse(err error, w http.ResponseWriter) {
+// ErrorResponse is the error envelope of the registry protocol, which is displayed by the Terraform CLI.
+// See https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#error-responses
+type ErrorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// HandleErrorResponse writes the error with the status code in the error envelope of the registry protocol
+func HandleErrorResponse(err error, statusCode int, w http.ResponseWriter) {
+	// The headers need to be set before the status code is written
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
 
-	_ = json.NewEncoder(w).Encode(struct {
-		Errors []string `json:"errors"`
-	}{
+	_ = json.NewEncoder(w).Encode(ErrorResponse{
 		Errors: []string{
 			err.Error(),
 		},
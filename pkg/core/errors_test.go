@@ -1,6 +1,11 @@
 package core
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -56,3 +61,45 @@ func TestProviderError_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestGenericError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: fmt.Errorf("%w: namespace", ErrVarMissing), want: http.StatusBadRequest},
+		{err: ErrVarType, want: http.StatusBadRequest},
+		{err: fmt.Errorf("failed to upload module: %w", ErrContentLength), want: http.StatusBadRequest},
+		{err: ErrUnauthorized, want: http.StatusUnauthorized},
+		{err: fmt.Errorf("%w: token expired", ErrInvalidToken), want: http.StatusUnauthorized},
+		{err: ErrSignatureExpired, want: http.StatusForbidden},
+		{err: fmt.Errorf("failed to download signing keys: %w", ErrObjectNotFound), want: http.StatusNotFound},
+		{err: ErrObjectAlreadyExists, want: http.StatusConflict},
+		{err: ErrLocked, want: http.StatusConflict},
+		{err: fmt.Errorf("%w: SlowDown", ErrTooManyRequests), want: http.StatusTooManyRequests},
+		{err: fmt.Errorf("failed to decode request: %w", &http.MaxBytesError{Limit: 1024}), want: http.StatusRequestEntityTooLarge},
+		{err: fmt.Errorf("failed to upload module: %w", ErrArtifactTooLarge), want: http.StatusRequestEntityTooLarge},
+		{err: fmt.Errorf("%w: namespace acme", ErrQuotaExceeded), want: http.StatusTooManyRequests},
+		{err: fmt.Errorf("%w: Eicar-Signature", ErrArtifactRejected), want: http.StatusUnprocessableEntity},
+		{err: fmt.Errorf("%w: module names have to start with platform-", ErrAdmissionDenied), want: http.StatusForbidden},
+		{err: &ProviderError{Reason: "not found", Provider: &Provider{}, StatusCode: http.StatusNotFound}, want: http.StatusNotFound},
+		{err: errors.New("connection reset"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.want, GenericError(tt.err))
+		})
+	}
+}
+
+func TestHandleErrorResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleErrorResponse(ErrObjectNotFound, http.StatusNotFound, rec)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	var res ErrorResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	assert.Equal(t, []string{ErrObjectNotFound.Error()}, res.Errors)
+}
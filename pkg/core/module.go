@@ -9,6 +9,15 @@ type Module struct {
 	Provider    string `json:"provider"`
 	Version     string `json:"version"`
 	DownloadURL string `json:"download_url"`
+
+	// SignatureURL points to the detached OpenPGP signature of the module archive, if the module was signed
+	SignatureURL string `json:"signature_url,omitempty"`
+
+	// AttestationURL points to the Sigstore bundle of the module archive, which contains a cosign signature or an in-toto attestation
+	AttestationURL string `json:"attestation_url,omitempty"`
+
+	// SHA256 is the hex-encoded digest of the module archive recorded when the version was first published
+	SHA256 string `json:"sha256,omitempty"`
 }
 
 // ID returns the module metadata in a compact format.
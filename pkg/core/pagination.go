@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// DefaultPageLimit is the number of items of a page if only the offset is requested
+	DefaultPageLimit = 100
+
+	// MaxPageLimit is the highest number of items which can be requested per page
+	MaxPageLimit = 1000
+)
+
+// Page is requested with the limit and offset query parameters of a listing
+type Page struct {
+	Limit  int
+	Offset int
+
+	// uri is the request URI, which links to the next and previous pages
+	uri *url.URL
+}
+
+// PageMeta describes the returned page of a listing like the meta object of the Terraform registry API
+type PageMeta struct {
+	Limit         int    `json:"limit"`
+	CurrentOffset int    `json:"current_offset"`
+	NextOffset    *int   `json:"next_offset,omitempty"`
+	PrevOffset    *int   `json:"prev_offset,omitempty"`
+	NextURL       string `json:"next_url,omitempty"`
+	PrevURL       string `json:"prev_url,omitempty"`
+	TotalCount    int    `json:"total_count"`
+}
+
+// ParsePage returns the page requested with the limit and offset query parameters,
+// or nil if neither is set, so that listings without them stay unchanged.
+func ParsePage(r *http.Request) (*Page, error) {
+	query := r.URL.Query()
+	if !query.Has("limit") && !query.Has("offset") {
+		return nil, nil
+	}
+
+	page := &Page{Limit: DefaultPageLimit}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrVarType, MaxPageLimit)
+		}
+		page.Limit = limit
+	}
+	if s := query.Get("offset"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("%w: offset must not be negative", ErrVarType)
+		}
+		page.Offset = offset
+	}
+
+	// The request URI still contains the prefixes which are stripped from the path by the router
+	if uri, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		page.uri = uri
+	}
+
+	return page, nil
+}
+
+// Paginate returns the items of the page with its metadata, the items have to be in a stable order
+func Paginate[T any](items []T, page Page) ([]T, *PageMeta) {
+	meta := &PageMeta{
+		Limit:         page.Limit,
+		CurrentOffset: page.Offset,
+		TotalCount:    len(items),
+	}
+
+	start := min(page.Offset, len(items))
+	end := min(start+page.Limit, len(items))
+	if end < len(items) {
+		meta.NextOffset = &end
+		meta.NextURL = page.url(end)
+	}
+	if page.Offset > 0 {
+		prev := max(page.Offset-page.Limit, 0)
+		meta.PrevOffset = &prev
+		meta.PrevURL = page.url(prev)
+	}
+
+	return items[start:end], meta
+}
+
+// url returns the request URI with the offset replaced
+func (p Page) url(offset int) string {
+	if p.uri == nil {
+		return ""
+	}
+
+	query := p.uri.Query()
+	query.Set("limit", strconv.Itoa(p.Limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return (&url.URL{Path: p.uri.Path, RawQuery: query.Encode()}).String()
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    *Page
+		wantErr bool
+	}{
+		{query: "", want: nil},
+		{query: "limit=10", want: &Page{Limit: 10}},
+		{query: "offset=20", want: &Page{Limit: DefaultPageLimit, Offset: 20}},
+		{query: "limit=10&offset=20", want: &Page{Limit: 10, Offset: 20}},
+		{query: "limit=0", wantErr: true},
+		{query: "limit=1001", wantErr: true},
+		{query: "offset=-1", wantErr: true},
+		{query: "limit=ten", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			page, err := ParsePage(httptest.NewRequest(http.MethodGet, "/v1/modules/acme/vpc/aws/versions?"+tt.query, nil))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrVarType)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, page)
+				return
+			}
+			assert.Equal(t, tt.want.Limit, page.Limit)
+			assert.Equal(t, tt.want.Offset, page.Offset)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	page, err := ParsePage(httptest.NewRequest(http.MethodGet, "/v1/providers/hashicorp/random/versions?limit=2&offset=2", nil))
+	assert.NoError(t, err)
+
+	res, meta := Paginate(items, *page)
+	assert.Equal(t, []string{"c", "d"}, res)
+	assert.Equal(t, 5, meta.TotalCount)
+	assert.Equal(t, 4, *meta.NextOffset)
+	assert.Equal(t, "/v1/providers/hashicorp/random/versions?limit=2&offset=4", meta.NextURL)
+	assert.Equal(t, 0, *meta.PrevOffset)
+	assert.Equal(t, "/v1/providers/hashicorp/random/versions?limit=2&offset=0", meta.PrevURL)
+
+	res, meta = Paginate(items, Page{Limit: 2, Offset: 4})
+	assert.Equal(t, []string{"e"}, res)
+	assert.Nil(t, meta.NextOffset)
+	assert.Empty(t, meta.NextURL)
+
+	res, meta = Paginate(items, Page{Limit: 2, Offset: 10})
+	assert.Empty(t, res)
+	assert.Nil(t, meta.NextOffset)
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/go-version"
+)
+
+// prereleasesQueryParam lists the pre-releases of a single request, even if they're hidden by the PrereleasePolicy
+const prereleasesQueryParam = "prereleases"
+
+// PrereleasePolicy decides in which namespaces pre-release versions like 1.2.0-rc.1 are listed.
+// Hidden pre-releases can still be downloaded, they're only omitted from the version listings,
+// so that Terraform doesn't select them when resolving the latest version.
+type PrereleasePolicy struct {
+	hide       bool
+	namespaces map[string]bool
+}
+
+// NewPrereleasePolicy returns a policy hiding pre-releases if hide is set, except in the namespaces
+func NewPrereleasePolicy(hide bool, namespaces []string) PrereleasePolicy {
+	p := PrereleasePolicy{hide: hide, namespaces: make(map[string]bool, len(namespaces))}
+	for _, namespace := range namespaces {
+		p.namespaces[namespace] = true
+	}
+	return p
+}
+
+// Visible returns true if the pre-releases of the namespace are listed for the request of the context
+func (p PrereleasePolicy) Visible(ctx context.Context, namespace string) bool {
+	return !p.hide || p.namespaces[namespace] || PrereleasesFromContext(ctx)
+}
+
+// HidePrereleases returns the items without a pre-release version.
+// Versions which can't be parsed are kept, as they're handled by ValidVersions.
+func HidePrereleases[T any](items []T, versionOf func(T) string) []T {
+	res := make([]T, 0, len(items))
+	for _, item := range items {
+		if v, err := version.NewVersion(versionOf(item)); err == nil && v.Prerelease() != "" {
+			continue
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+type prereleasesKey struct{}
+
+// WithPrereleases returns a context listing the pre-releases, even if they're hidden by the PrereleasePolicy
+func WithPrereleases(ctx context.Context) context.Context {
+	return context.WithValue(ctx, prereleasesKey{}, true)
+}
+
+// PrereleasesFromContext returns true if the pre-releases were requested
+func PrereleasesFromContext(ctx context.Context) bool {
+	prereleases, _ := ctx.Value(prereleasesKey{}).(bool)
+	return prereleases
+}
+
+// PrereleasesFromRequest returns true if the pre-releases are requested with the prereleases query parameter
+func PrereleasesFromRequest(r *http.Request) (bool, error) {
+	s := r.URL.Query().Get(prereleasesQueryParam)
+	if s == "" {
+		return false, nil
+	}
+
+	prereleases, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s must be a boolean", ErrVarType, prereleasesQueryParam)
+	}
+	return prereleases, nil
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrereleasePolicy(t *testing.T) {
+	ctx := context.Background()
+	policy := NewPrereleasePolicy(true, []string{"sandbox"})
+	assert.False(t, policy.Visible(ctx, "acme"))
+	assert.True(t, policy.Visible(ctx, "sandbox"))
+	assert.True(t, policy.Visible(WithPrereleases(ctx), "acme"))
+	assert.True(t, NewPrereleasePolicy(false, nil).Visible(ctx, "acme"))
+}
+
+func TestHidePrereleases(t *testing.T) {
+	versions := []string{"1.0.0", "1.1.0-rc.1", "1.1.0+build.1", "2.0.0-beta+build.2", "invalid"}
+	assert.Equal(t, []string{"1.0.0", "1.1.0+build.1", "invalid"}, HidePrereleases(versions, func(v string) string { return v }))
+}
+
+func TestPrereleasesFromRequest(t *testing.T) {
+	prereleases, err := PrereleasesFromRequest(httptest.NewRequest("GET", "/v1/modules/acme/vpc/aws/versions", nil))
+	assert.NoError(t, err)
+	assert.False(t, prereleases)
+
+	prereleases, err = PrereleasesFromRequest(httptest.NewRequest("GET", "/v1/modules/acme/vpc/aws/versions?prereleases=true", nil))
+	assert.NoError(t, err)
+	assert.True(t, prereleases)
+
+	_, err = PrereleasesFromRequest(httptest.NewRequest("GET", "/v1/modules/acme/vpc/aws/versions?prereleases=maybe", nil))
+	assert.ErrorIs(t, err, ErrVarType)
+}
@@ -1,24 +1,32 @@
 package core
 
 import (
+	"archive/zip"
 	"bufio"
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	openpgpErrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"golang.org/x/mod/sumdb/dirhash"
 )
 
 const (
 	ProviderPrefix    = "terraform-provider-"
 	ProviderExtension = ".zip"
+
+	// ProviderManifestSuffix is the suffix of the manifest file of a provider release, which is created by goreleaser
+	ProviderManifestSuffix = "_manifest.json"
 )
 
 // Provider copied from provider.Provider
@@ -35,8 +43,12 @@ type Provider struct {
 	Shasum              string      `json:"shasum,omitempty"`
 	SHASumsURL          string      `json:"shasums_url,omitempty"`
 	SHASumsSignatureURL string      `json:"shasums_signature_url,omitempty"`
+	AttestationURL      string      `json:"attestation_url,omitempty"`
 	SigningKeys         SigningKeys `json:"signing_keys,omitempty"`
 	Platforms           []Platform  `json:"platforms,omitempty"`
+
+	// Protocols are the plugin protocol versions declared in the manifest of the release
+	Protocols []string `json:"protocols,omitempty"`
 }
 
 func (p *Provider) ArchiveFileName() string {
@@ -74,6 +86,16 @@ func (p *Provider) ShasumSignatureFileName() string {
 	return fmt.Sprintf("%s%s_%s_SHA256SUMS.sig", ProviderPrefix, p.Name, p.Version)
 }
 
+func (p *Provider) ManifestFileName() string {
+	if p.Name == "" {
+		panic("provider Name is empty")
+	} else if p.Version == "" {
+		panic("provider Version is empty")
+	}
+
+	return fmt.Sprintf("%s%s_%s%s", ProviderPrefix, p.Name, p.Version, ProviderManifestSuffix)
+}
+
 // Clone returns a deep copy of the struct
 func (p *Provider) Clone() *Provider {
 	r := &Provider{
@@ -88,11 +110,16 @@ func (p *Provider) Clone() *Provider {
 		Shasum:              p.Shasum,
 		SHASumsURL:          p.SHASumsURL,
 		SHASumsSignatureURL: p.SHASumsSignatureURL,
+		AttestationURL:      p.AttestationURL,
 	}
 	if p.Platforms != nil {
 		r.Platforms = make([]Platform, len(p.Platforms))
 		copy(r.Platforms, p.Platforms)
 	}
+	if p.Protocols != nil {
+		r.Protocols = make([]string, len(p.Protocols))
+		copy(r.Protocols, p.Protocols)
+	}
 	if p.SigningKeys.GPGPublicKeys != nil {
 		r.SigningKeys = SigningKeys{GPGPublicKeys: make([]GPGPublicKey, len(p.SigningKeys.GPGPublicKeys))}
 		copy(p.SigningKeys.GPGPublicKeys, r.SigningKeys.GPGPublicKeys)
@@ -161,6 +188,9 @@ type GPGPublicKey struct {
 
 type ProviderVersions struct {
 	Versions []ProviderVersion `json:"versions,omitempty"`
+
+	// Warnings are displayed by the Terraform CLI when the versions are fetched
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // The ProviderVersion is a copy from provider.ProviderVersion
@@ -178,6 +208,25 @@ type Platform struct {
 	Arch string `json:"arch,omitempty"`
 }
 
+// ProviderPlatforms is the availability of a provider version on all platforms the provider was released for
+type ProviderPlatforms struct {
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name"`
+	Version   string              `json:"version"`
+	Platforms []PlatformAvailable `json:"platforms"`
+}
+
+// PlatformAvailable describes whether a provider version was released for the platform.
+// The filename, checksum and hashes are only set if the platform is available.
+type PlatformAvailable struct {
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	Available bool     `json:"available"`
+	Filename  string   `json:"filename,omitempty"`
+	Shasum    string   `json:"shasum,omitempty"`
+	Hashes    []string `json:"hashes,omitempty"`
+}
+
 type providerOption struct {
 	Hostname  string `json:"hostname,omitempty"`
 	Namespace string `json:"namespace,omitempty"`
@@ -221,6 +270,16 @@ func (s *Sha256Sums) Name() (string, error) {
 	return matches[1], nil
 }
 
+// Version returns the version of the provider of the SHA256SUMS file
+func (s *Sha256Sums) Version() (string, error) {
+	r := regexp.MustCompile("^terraform-provider-(?P<name>.+)_(?P<version>.+)_SHA256SUMS$")
+	matches := r.FindStringSubmatch(s.Filename)
+	if len(matches) != 3 {
+		return "", fmt.Errorf("regex for %s matched %d times instead of 3 times", s.Filename, len(matches))
+	}
+	return matches[2], nil
+}
+
 // Checksum returns the corresponding stringified checksum for the archive file name parameter
 func (s *Sha256Sums) Checksum(fileName string) (string, error) {
 	checksum, exists := s.Entries[fileName]
@@ -230,6 +289,31 @@ func (s *Sha256Sums) Checksum(fileName string) (string, error) {
 	return fmt.Sprintf("%x", checksum), nil
 }
 
+// Equal reports whether both SHA256SUMS files contain the same checksums for the same files
+func (s *Sha256Sums) Equal(other *Sha256Sums) bool {
+	if other == nil || len(s.Entries) != len(other.Entries) {
+		return false
+	}
+
+	for fileName, checksum := range s.Entries {
+		otherChecksum, exists := other.Entries[fileName]
+		if !exists || !bytes.Equal(checksum, otherChecksum) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Bytes returns the SHA256SUMS file with the entries sorted by file name, in the format of sha256sum and goreleaser
+func (s *Sha256Sums) Bytes() []byte {
+	var b bytes.Buffer
+	for _, fileName := range slices.Sorted(maps.Keys(s.Entries)) {
+		fmt.Fprintf(&b, "%x  %s\n", s.Entries[fileName], fileName)
+	}
+	return b.Bytes()
+}
+
 func NewSha256Sums(filename string, r io.Reader) (*Sha256Sums, error) {
 	if !isValidSha256SumsFilename(filename) {
 		return nil, fmt.Errorf("SHA256SUMS file %s doesn't have valid file name", filename)
@@ -266,3 +350,66 @@ func Sha256Checksum(r io.Reader) ([]byte, error) {
 
 	return h.Sum(nil), nil
 }
+
+// ProviderArchiveHashes returns the hashes of a provider archive as recorded in the dependency lock file.
+// The h1: hash covers the extracted files, the zh: hash is the SHA256 checksum of the archive itself.
+// See https://developer.hashicorp.com/terraform/language/files/dependency-lock#hashes
+func ProviderArchiveHashes(r io.ReaderAt, size int64) ([]string, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider archive: %w", err)
+	}
+
+	// The h1: hash is computed like dirhash.HashZip, which requires a file on disk
+	files := make([]string, 0, len(z.File))
+	entries := make(map[string]*zip.File, len(z.File))
+	for _, f := range z.File {
+		files = append(files, f.Name)
+		entries[f.Name] = f
+	}
+	h1, err := dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return entries[name].Open()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute h1 hash of provider archive: %w", err)
+	}
+
+	zh, err := Sha256Checksum(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute zh hash of provider archive: %w", err)
+	}
+
+	return []string{h1, fmt.Sprintf("zh:%x", zh)}, nil
+}
+
+// ProviderManifest is the manifest file of a provider release, which declares the plugin protocol versions of the provider.
+// https://developer.hashicorp.com/terraform/registry/providers/publishing#terraform-registry-manifest-file
+type ProviderManifest struct {
+	Version  int `json:"version"`
+	Metadata struct {
+		ProtocolVersions []string `json:"protocol_versions"`
+	} `json:"metadata"`
+}
+
+var protocolVersionRegex = regexp.MustCompile(`^\d+\.\d+$`)
+
+// NewProviderManifest parses and validates the manifest file of a provider release
+func NewProviderManifest(r io.Reader) (*ProviderManifest, error) {
+	var m ProviderManifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to decode provider manifest: %w", err)
+	}
+
+	if m.Version != 1 {
+		return nil, fmt.Errorf("unsupported provider manifest version %d", m.Version)
+	} else if len(m.Metadata.ProtocolVersions) == 0 {
+		return nil, errors.New("the provider manifest doesn't declare protocol versions")
+	}
+	for _, v := range m.Metadata.ProtocolVersions {
+		if !protocolVersionRegex.MatchString(v) {
+			return nil, fmt.Errorf("invalid protocol version %q in provider manifest, expected a version like 6.0", v)
+		}
+	}
+
+	return &m, nil
+}
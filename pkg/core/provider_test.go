@@ -3,9 +3,12 @@ package core
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -14,6 +17,7 @@ import (
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"golang.org/x/mod/sumdb/dirhash"
 )
 
 func decodeHexString(s string) []byte {
@@ -464,6 +468,7 @@ func TestProvider_Clone(t *testing.T) {
 						Arch: "arm64",
 					},
 				},
+				Protocols: []string{"5.0", "6.0"},
 			},
 		},
 	}
@@ -548,6 +553,47 @@ func TestSha256Sums_Name(t *testing.T) {
 	}
 }
 
+func TestSha256Sums_Version(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		filename string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "valid file name",
+			filename: "terraform-provider-random_2.0.0_SHA256SUMS",
+			want:     "2.0.0",
+		},
+		{
+			name:     "valid file name with underscore in provider name",
+			filename: "terraform-provider-random_provider_2.0.0-rc.1_SHA256SUMS",
+			want:     "2.0.0-rc.1",
+		},
+		{
+			name:     "invalid file name",
+			filename: "random_2.0.0_SHA256SUMS",
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Sha256Sums{
+				Filename: tc.filename,
+			}
+			v, err := s.Version()
+			if tc.wantErr {
+				assertion.Error(t, err)
+				return
+			}
+			assertion.NoError(t, err)
+			assertion.Equal(t, tc.want, v)
+		})
+	}
+}
+
 func TestSha256Sums_Checksum(t *testing.T) {
 	const sha256Sums = `be3f1e818ca58a960fd1c80216a691bbd4827c505ab7916fb68ddd186032286e  terraform-provider-random_2.0.0_linux_386.zip
 5f9c7aa76b7c34d722fc9123208e26b22d60440cb47150dd04733b9b94f4541a  terraform-provider-random_2.0.0_linux_amd64.zip
@@ -591,6 +637,69 @@ func TestSha256Sums_Checksum(t *testing.T) {
 	}
 }
 
+func TestSha256Sums_Equal(t *testing.T) {
+	const sha256Sums = `be3f1e818ca58a960fd1c80216a691bbd4827c505ab7916fb68ddd186032286e  terraform-provider-random_2.0.0_linux_386.zip
+5f9c7aa76b7c34d722fc9123208e26b22d60440cb47150dd04733b9b94f4541a  terraform-provider-random_2.0.0_linux_amd64.zip
+`
+	parse := func(content string) *Sha256Sums {
+		sums, err := NewSha256Sums("terraform-provider-random_2.0.0_SHA256SUMS", strings.NewReader(content))
+		if err != nil {
+			panic(err)
+		}
+		return sums
+	}
+
+	tests := []struct {
+		name  string
+		other *Sha256Sums
+		want  bool
+	}{
+		{
+			name:  "nil",
+			other: nil,
+			want:  false,
+		},
+		{
+			name:  "same entries",
+			other: parse(sha256Sums),
+			want:  true,
+		},
+		{
+			name:  "missing entry",
+			other: parse("be3f1e818ca58a960fd1c80216a691bbd4827c505ab7916fb68ddd186032286e  terraform-provider-random_2.0.0_linux_386.zip\n"),
+			want:  false,
+		},
+		{
+			name: "different checksum",
+			other: parse(`be3f1e818ca58a960fd1c80216a691bbd4827c505ab7916fb68ddd186032286e  terraform-provider-random_2.0.0_linux_386.zip
+29df160b8b618227197cc9984c47412461ad66a300a8fc1db4052398bf5656ac  terraform-provider-random_2.0.0_linux_amd64.zip
+`),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertion.Equal(t, tt.want, parse(sha256Sums).Equal(tt.other))
+		})
+	}
+}
+
+func TestSha256Sums_Bytes(t *testing.T) {
+	const sha256Sums = `5f9c7aa76b7c34d722fc9123208e26b22d60440cb47150dd04733b9b94f4541a  terraform-provider-random_2.0.0_linux_amd64.zip
+be3f1e818ca58a960fd1c80216a691bbd4827c505ab7916fb68ddd186032286e  terraform-provider-random_2.0.0_linux_386.zip
+`
+	sums, err := NewSha256Sums("terraform-provider-random_2.0.0_SHA256SUMS", strings.NewReader(sha256Sums))
+	assertion.NoError(t, err)
+
+	assertion.Equal(t, `be3f1e818ca58a960fd1c80216a691bbd4827c505ab7916fb68ddd186032286e  terraform-provider-random_2.0.0_linux_386.zip
+5f9c7aa76b7c34d722fc9123208e26b22d60440cb47150dd04733b9b94f4541a  terraform-provider-random_2.0.0_linux_amd64.zip
+`, string(sums.Bytes()))
+
+	parsed, err := NewSha256Sums(sums.Filename, bytes.NewReader(sums.Bytes()))
+	assertion.NoError(t, err)
+	assertion.True(t, sums.Equal(parsed))
+}
+
 func TestNewSha256Sums(t *testing.T) {
 	t.Parallel()
 
@@ -659,6 +768,54 @@ func TestNewSha256Sums(t *testing.T) {
 	}
 }
 
+func TestNewProviderManifest(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "goreleaser manifest",
+			content: `{"version": 1, "metadata": {"protocol_versions": ["5.0", "6.0"]}}`,
+			want:    []string{"5.0", "6.0"},
+		},
+		{
+			name:    "invalid JSON",
+			content: `protocol_versions: 6.0`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported manifest version",
+			content: `{"version": 2, "metadata": {"protocol_versions": ["6.0"]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing protocol versions",
+			content: `{"version": 1, "metadata": {}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid protocol version",
+			content: `{"version": 1, "metadata": {"protocol_versions": ["6"]}}`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewProviderManifest(strings.NewReader(tc.content))
+			if tc.wantErr {
+				assertion.Error(t, err)
+				return
+			}
+			assertion.NoError(t, err)
+			assertion.Equal(t, tc.want, got.Metadata.ProtocolVersions)
+		})
+	}
+}
+
 func TestSha256Checksum(t *testing.T) {
 	t.Parallel()
 
@@ -714,3 +871,30 @@ func TestSha256Checksum(t *testing.T) {
 		})
 	}
 }
+
+func TestProviderArchiveHashes(t *testing.T) {
+	assert := assertion.New(t)
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range map[string]string{"terraform-provider-random_v2.0.0": "binary", "LICENSE": "license"} {
+		w, err := zw.Create(name)
+		assert.NoError(err)
+		_, _ = w.Write([]byte(content))
+	}
+	assert.NoError(zw.Close())
+
+	// The h1: hash must match the hash computed by the Terraform CLI
+	archive := filepath.Join(t.TempDir(), "terraform-provider-random_2.0.0_linux_amd64.zip")
+	assert.NoError(os.WriteFile(archive, buf.Bytes(), 0o644))
+	h1, err := dirhash.HashZip(archive, dirhash.Hash1)
+	assert.NoError(err)
+	zh := sha256.Sum256(buf.Bytes())
+
+	hashes, err := ProviderArchiveHashes(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(err)
+	assert.Equal([]string{h1, "zh:" + hex.EncodeToString(zh[:])}, hashes)
+
+	_, err = ProviderArchiveHashes(strings.NewReader("no archive"), 10)
+	assert.Error(err)
+}
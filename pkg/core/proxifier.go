@@ -4,25 +4,60 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	proxyExpiresParam   = "proxy-expires"
+	proxySignatureParam = "proxy-signature"
 )
 
 // ProxyUrlService represents Boring tool to manage proxyfied downloads.
 type ProxyUrlService interface {
 	IsProxyEnabled(ctx context.Context) bool
 	GetProxyUrl(ctx context.Context, downloadUrl string) (string, error)
+
+	// VerifyProxyUrl checks the signature of a proxy URL with the path and raw query below the proxy path,
+	// and returns the raw query without the signature parameters. The query is returned unchanged if proxy URLs aren't signed.
+	VerifyProxyUrl(ctx context.Context, path, rawQuery string) (string, error)
 }
 
 type proxyUrlService struct {
 	IsEnabled bool
 	ProxyPath string
+
+	signer Signer
+	expiry time.Duration
+	now    func() time.Time
+}
+
+// ProxyUrlOption provides additional options for the ProxyUrlService.
+type ProxyUrlOption func(*proxyUrlService)
+
+// WithProxyUrlSigner signs the proxy URLs with the signer, so that the download proxy only serves URLs created by the registry.
+// The signed URLs are valid for the expiry duration.
+func WithProxyUrlSigner(signer Signer, expiry time.Duration) ProxyUrlOption {
+	return func(p *proxyUrlService) {
+		p.signer = signer
+		p.expiry = expiry
+	}
 }
 
 // NewProxyUrlService returns a fully initialized Proxy.
-func NewProxyUrlService(isEnabled bool, proxyPath string) ProxyUrlService {
-	return &proxyUrlService{
+func NewProxyUrlService(isEnabled bool, proxyPath string, options ...ProxyUrlOption) ProxyUrlService {
+	p := &proxyUrlService{
 		IsEnabled: isEnabled,
 		ProxyPath: proxyPath,
+		now:       time.Now,
 	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
 }
 
 func (p *proxyUrlService) IsProxyEnabled(ctx context.Context) bool {
@@ -39,5 +74,72 @@ func (p *proxyUrlService) GetProxyUrl(ctx context.Context, downloadUrl string) (
 	pathUrl := downloadUrl[len(baseUrl):]
 	finalUrl := fmt.Sprintf("%s/%s", p.ProxyPath, pathUrl)
 
-	return finalUrl, nil
+	if p.signer == nil {
+		return finalUrl, nil
+	}
+
+	expires := p.now().Add(p.expiry).Unix()
+	signature, err := p.signer.Sign(ctx, proxySigningPayload(strings.TrimPrefix(parsedUrl.Path, "/"), parsedUrl.RawQuery, expires))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign proxy URL: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set(proxyExpiresParam, strconv.FormatInt(expires, 10))
+	query.Set(proxySignatureParam, signature)
+	separator := "?"
+	if parsedUrl.RawQuery != "" {
+		separator = "&"
+	}
+
+	return finalUrl + separator + query.Encode(), nil
+}
+
+func (p *proxyUrlService) VerifyProxyUrl(ctx context.Context, path, rawQuery string) (string, error) {
+	if p.signer == nil {
+		return rawQuery, nil
+	}
+
+	// The original query is kept byte by byte, as presigned URLs of the storage backends may depend on its encoding
+	var params []string
+	values := url.Values{}
+	for _, param := range strings.Split(rawQuery, "&") {
+		key, value, _ := strings.Cut(param, "=")
+		if key != proxyExpiresParam && key != proxySignatureParam {
+			if param != "" {
+				params = append(params, param)
+			}
+			continue
+		}
+		unescaped, err := url.QueryUnescape(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid encoding", ErrInvalidSignature)
+		}
+		values.Set(key, unescaped)
+	}
+	query := strings.Join(params, "&")
+
+	expires, err := strconv.ParseInt(values.Get(proxyExpiresParam), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid expiry", ErrInvalidSignature)
+	}
+	signature := values.Get(proxySignatureParam)
+	if signature == "" {
+		return "", ErrInvalidSignature
+	}
+
+	// Expired URLs are rejected without verifying their signature, which might call the key management service
+	if p.now().Unix() > expires {
+		return "", ErrSignatureExpired
+	}
+
+	if err := p.signer.Verify(ctx, proxySigningPayload(path, query, expires), signature); err != nil {
+		return "", err
+	}
+
+	return query, nil
+}
+
+func proxySigningPayload(path, rawQuery string, expires int64) []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%d", path, rawQuery, expires))
 }
@@ -2,7 +2,10 @@ package core
 
 import (
 	"context"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	assertion "github.com/stretchr/testify/assert"
 )
@@ -88,3 +91,51 @@ func TestProxifier_IsEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestProxifier_SignedProxyUrl(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	server := newTransitServer(t)
+	defer server.Close()
+	signer := NewVaultTransitSigner(nil, VaultTransitConfig{Address: server.URL, Token: "token", Mount: "transit", Key: "registry"})
+
+	now := time.Unix(1700000000, 0)
+	p := NewProxyUrlService(true, prefixProxy, WithProxyUrlSigner(signer, time.Minute)).(*proxyUrlService)
+	p.now = func() time.Time { return now }
+
+	signed, err := p.GetProxyUrl(ctx, downloadUrl)
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(signed, prefixProxy+"/"+downloadUrlPath+"&proxy-expires=1700000060&proxy-signature=vault%3Av1%3A"))
+
+	u, err := url.Parse(strings.TrimPrefix(signed, prefixProxy+"/"))
+	assert.NoError(err)
+
+	// The query of the storage backend is returned without the signature parameters
+	query, err := p.VerifyProxyUrl(ctx, u.Path, u.RawQuery)
+	assert.NoError(err)
+	assert.Equal("X-Signature=ABC", query)
+
+	// The signature is bound to the path and the query of the storage backend
+	_, err = p.VerifyProxyUrl(ctx, "providers/sfr/siroco/terraform-provider-random_2.0.0_linux_arm64.zip", u.RawQuery)
+	assert.ErrorIs(err, ErrInvalidSignature)
+	_, err = p.VerifyProxyUrl(ctx, u.Path, strings.Replace(u.RawQuery, "X-Signature=ABC", "X-Signature=DEF", 1))
+	assert.ErrorIs(err, ErrInvalidSignature)
+
+	// The expiry can't be extended without invalidating the signature
+	_, err = p.VerifyProxyUrl(ctx, u.Path, strings.Replace(u.RawQuery, "1700000060", "1800000000", 1))
+	assert.ErrorIs(err, ErrInvalidSignature)
+
+	// Unsigned URLs are rejected
+	_, err = p.VerifyProxyUrl(ctx, u.Path, "X-Signature=ABC")
+	assert.ErrorIs(err, ErrInvalidSignature)
+
+	now = now.Add(2 * time.Minute)
+	_, err = p.VerifyProxyUrl(ctx, u.Path, u.RawQuery)
+	assert.ErrorIs(err, ErrSignatureExpired)
+
+	// URLs aren't verified without a signer
+	query, err = NewProxyUrlService(true, prefixProxy).VerifyProxyUrl(ctx, u.Path, "X-Signature=ABC")
+	assert.NoError(err)
+	assert.Equal("X-Signature=ABC", query)
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SizedReader reads a body of a known size, e.g. the Content-Length of a request,
+// so that the size can be checked before the body is streamed to the storage backend.
+type SizedReader struct {
+	r    io.Reader
+	size int64
+	read int64
+}
+
+// NewSizedReader returns a reader which fails with ErrContentLength if the body is shorter or longer than size
+func NewSizedReader(r io.Reader, size int64) *SizedReader {
+	return &SizedReader{r: r, size: size}
+}
+
+// Size returns the declared size of the body
+func (r *SizedReader) Size() int64 {
+	return r.size
+}
+
+func (r *SizedReader) Read(p []byte) (int, error) {
+	// One more byte than declared is allowed to be read, so that longer bodies are detected
+	if remaining := r.size - r.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.read > r.size {
+		return n, fmt.Errorf("%w: the body is longer than %d bytes", ErrContentLength, r.size)
+	}
+	if err == io.EOF && r.read < r.size {
+		return n, fmt.Errorf("%w: the body has %d of %d bytes", ErrContentLength, r.read, r.size)
+	}
+	return n, err
+}
+
+// MaxBodySize limits the size of request bodies like http.MaxBytesHandler,
+// but rejects requests with a larger Content-Length before their body is read.
+func MaxBodySize(handler http.Handler, limit int64) http.Handler {
+	limited := http.MaxBytesHandler(handler, limit)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			err := fmt.Errorf("the body of %d bytes exceeds the limit: %w", r.ContentLength, &http.MaxBytesError{Limit: limit})
+			HandleErrorResponse(err, http.StatusRequestEntityTooLarge, w)
+			return
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// UploadTimeout extends the read and write deadlines of requests with a body to the timeout,
+// so that uploads of large archives aren't cut off by the read and write timeouts of the server
+func UploadTimeout(handler http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody {
+			deadline := time.Now().Add(timeout)
+			rc := http.NewResponseController(w)
+			// Writers which don't support deadlines keep the timeouts of the server
+			_ = rc.SetReadDeadline(deadline)
+			_ = rc.SetWriteDeadline(deadline)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
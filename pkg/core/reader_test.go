@@ -0,0 +1,104 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizedReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		size    int64
+		wantErr bool
+	}{
+		{name: "exact", body: "module", size: 6},
+		{name: "empty", body: "", size: 0},
+		{name: "shorter", body: "mod", size: 6, wantErr: true},
+		{name: "longer", body: "module archive", size: 6, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewSizedReader(strings.NewReader(tt.body), tt.size)
+			assert.Equal(t, tt.size, r.Size())
+
+			data, err := io.ReadAll(r)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrContentLength)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(data))
+		})
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	handler := MaxBodySize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			HandleErrorResponse(err, GenericError(err), w)
+		}
+	}), 8)
+
+	tests := []struct {
+		name          string
+		body          io.Reader
+		contentLength int64
+		want          int
+	}{
+		{name: "small", body: strings.NewReader("module"), contentLength: 6, want: http.StatusOK},
+		{name: "declared too large", body: strings.NewReader("module archive"), contentLength: 14, want: http.StatusRequestEntityTooLarge},
+		{name: "streamed too large", body: io.MultiReader(strings.NewReader("module archive")), contentLength: -1, want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", tt.body)
+			req.ContentLength = tt.contentLength
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestUploadTimeout(t *testing.T) {
+	handler := UploadTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			HandleErrorResponse(err, http.StatusBadRequest, w)
+			return
+		}
+		_, _ = w.Write(data)
+	}), time.Minute)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	// The body is sent slower than the read timeout allows
+	body, w := io.Pipe()
+	go func() {
+		for _, chunk := range []string{"module ", "archive"} {
+			time.Sleep(150 * time.Millisecond)
+			_, _ = io.WriteString(w, chunk)
+		}
+		_ = w.Close()
+	}()
+
+	resp, err := http.Post(server.URL, "application/octet-stream", body)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "module archive", string(data))
+}
@@ -0,0 +1,16 @@
+package core
+
+import "net/http"
+
+// ReadOnly wraps the handler to reject all requests which may change the registry with 403 Forbidden.
+// Only requests with the GET, HEAD, and OPTIONS methods are passed on to the handler.
+func ReadOnly(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			handler.ServeHTTP(w, r)
+		default:
+			HandleErrorResponse(ErrReadOnly, GenericError(ErrReadOnly), w)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnly(t *testing.T) {
+	handler := ReadOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	testCases := []struct {
+		method     string
+		wantStatus int
+	}{
+		{method: http.MethodGet, wantStatus: http.StatusTeapot},
+		{method: http.MethodHead, wantStatus: http.StatusTeapot},
+		{method: http.MethodOptions, wantStatus: http.StatusTeapot},
+		{method: http.MethodPost, wantStatus: http.StatusForbidden},
+		{method: http.MethodPut, wantStatus: http.StatusForbidden},
+		{method: http.MethodDelete, wantStatus: http.StatusForbidden},
+		{method: http.MethodPatch, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, "/admin/modules/hashicorp/consul/aws/channels/stable", nil))
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantStatus == http.StatusForbidden {
+				assert.Contains(t, rec.Body.String(), "read-only mode")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+const (
+	redirectExpiresParam   = "expires"
+	redirectSignatureParam = "signature"
+)
+
+// DownloadRedirector creates signed download URLs which point to the redirect endpoint of the registry instead of the storage backend.
+// The redirect endpoint verifies the signature and redirects to a presigned URL of the storage backend,
+// so that downloads are counted and authorized per request while the content is still served by the storage backend.
+type DownloadRedirector interface {
+	// ModuleURL returns the URL of a module archive, the file name of the archive is kept so that clients can detect the archive format
+	ModuleURL(ctx context.Context, namespace, name, provider, version, archive string) (string, error)
+	ProviderURL(ctx context.Context, namespace, name, version, os, arch string) (string, error)
+
+	// Verify checks that the signature in the query belongs to the path and has not expired yet
+	Verify(ctx context.Context, path string, query url.Values) error
+}
+
+type downloadRedirector struct {
+	prefix string
+	secret []byte
+	expiry time.Duration
+	now    func() time.Time
+}
+
+// NewDownloadRedirector returns a DownloadRedirector creating URLs below the prefix, which are valid for the expiry duration.
+func NewDownloadRedirector(prefix string, secret []byte, expiry time.Duration) DownloadRedirector {
+	return &downloadRedirector{
+		prefix: prefix,
+		secret: secret,
+		expiry: expiry,
+		now:    time.Now,
+	}
+}
+
+func (r *downloadRedirector) ModuleURL(ctx context.Context, namespace, name, provider, version, archive string) (string, error) {
+	return r.sign(path.Join("/modules", namespace, name, provider, version, archive))
+}
+
+func (r *downloadRedirector) ProviderURL(ctx context.Context, namespace, name, version, os, arch string) (string, error) {
+	return r.sign(path.Join("/providers", namespace, name, version, os, arch))
+}
+
+func (r *downloadRedirector) Verify(ctx context.Context, p string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get(redirectExpiresParam), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid expiry", ErrInvalidSignature)
+	}
+
+	signature, err := hex.DecodeString(query.Get(redirectSignatureParam))
+	if err != nil {
+		return fmt.Errorf("%w: invalid encoding", ErrInvalidSignature)
+	}
+
+	if !hmac.Equal(signature, r.signature(p, expires)) {
+		return ErrInvalidSignature
+	}
+
+	if r.now().Unix() > expires {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
+
+func (r *downloadRedirector) sign(p string) (string, error) {
+	expires := r.now().Add(r.expiry).Unix()
+
+	query := url.Values{}
+	query.Set(redirectExpiresParam, strconv.FormatInt(expires, 10))
+	query.Set(redirectSignatureParam, hex.EncodeToString(r.signature(p, expires)))
+
+	return fmt.Sprintf("%s%s?%s", r.prefix, p, query.Encode()), nil
+}
+
+func (r *downloadRedirector) signature(p string, expires int64) []byte {
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write([]byte(fmt.Sprintf("%s\n%d", p, expires)))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestDownloadRedirector(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	now := time.Unix(1700000000, 0)
+	r := NewDownloadRedirector("/v1/redirect", []byte("secret"), time.Minute).(*downloadRedirector)
+	r.now = func() time.Time { return now }
+
+	signed, err := r.ProviderURL(ctx, "hashicorp", "random", "2.0.0", "linux", "amd64")
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(signed, "/v1/redirect/providers/hashicorp/random/2.0.0/linux/amd64?"))
+
+	u, err := url.Parse(strings.TrimPrefix(signed, "/v1/redirect"))
+	assert.NoError(err)
+	assert.NoError(r.Verify(ctx, u.Path, u.Query()))
+
+	// The signature is bound to the path
+	assert.ErrorIs(r.Verify(ctx, "/providers/hashicorp/random/2.0.0/linux/arm64", u.Query()), ErrInvalidSignature)
+
+	// The expiry can't be extended without invalidating the signature
+	query := u.Query()
+	query.Set(redirectExpiresParam, "1800000000")
+	assert.ErrorIs(r.Verify(ctx, u.Path, query), ErrInvalidSignature)
+
+	query = u.Query()
+	query.Del(redirectSignatureParam)
+	assert.ErrorIs(r.Verify(ctx, u.Path, query), ErrInvalidSignature)
+
+	// A different secret is not accepted
+	other := NewDownloadRedirector("/v1/redirect", []byte("other"), time.Minute)
+	assert.ErrorIs(other.Verify(ctx, u.Path, u.Query()), ErrInvalidSignature)
+
+	now = now.Add(2 * time.Minute)
+	assert.ErrorIs(r.Verify(ctx, u.Path, u.Query()), ErrSignatureExpired)
+}
+
+func TestDownloadRedirector_ModuleURL(t *testing.T) {
+	r := NewDownloadRedirector("/v1/redirect", []byte("secret"), time.Minute)
+
+	signed, err := r.ModuleURL(context.Background(), "hashicorp", "consul", "aws", "1.0.0", "hashicorp-consul-aws-1.0.0.tar.gz")
+	assertion.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	assertion.NoError(t, err)
+	assertion.Equal(t, "/v1/redirect/modules/hashicorp/consul/aws/1.0.0/hashicorp-consul-aws-1.0.0.tar.gz", u.Path)
+}
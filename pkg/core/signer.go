@@ -0,0 +1,124 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Signer signs data with a key that is managed outside of the registry, so that all signatures are created and verified in one audited place.
+type Signer interface {
+	Sign(ctx context.Context, data []byte) (string, error)
+
+	// Verify returns ErrInvalidSignature if the signature doesn't belong to the data
+	Verify(ctx context.Context, data []byte, signature string) error
+}
+
+// VaultTransitConfig configures the key of the Vault Transit secrets engine which signs the data
+type VaultTransitConfig struct {
+	Address   string
+	Token     string
+	Namespace string // Vault Enterprise namespace, optional
+
+	// Mount is the path of the Transit secrets engine and Key the name of a key supporting signatures, e.g. of type ed25519
+	Mount string
+	Key   string
+}
+
+type vaultTransitSigner struct {
+	client *http.Client
+	config VaultTransitConfig
+}
+
+// NewVaultTransitSigner returns a Signer using the sign and verify endpoints of the Vault Transit secrets engine.
+// http.DefaultClient is used if the client is nil.
+func NewVaultTransitSigner(client *http.Client, config VaultTransitConfig) Signer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &vaultTransitSigner{
+		client: client,
+		config: config,
+	}
+}
+
+func (s *vaultTransitSigner) Sign(ctx context.Context, data []byte) (string, error) {
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := s.do(ctx, "sign", map[string]string{
+		"input": base64.StdEncoding.EncodeToString(data),
+	}, &resp); err != nil {
+		return "", err
+	}
+	if resp.Data.Signature == "" {
+		return "", fmt.Errorf("vault transit returned an empty signature")
+	}
+	return resp.Data.Signature, nil
+}
+
+func (s *vaultTransitSigner) Verify(ctx context.Context, data []byte, signature string) error {
+	var resp struct {
+		Data struct {
+			Valid bool `json:"valid"`
+		} `json:"data"`
+	}
+	if err := s.do(ctx, "verify", map[string]string{
+		"input":     base64.StdEncoding.EncodeToString(data),
+		"signature": signature,
+	}, &resp); err != nil {
+		return err
+	}
+	if !resp.Data.Valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *vaultTransitSigner) do(ctx context.Context, operation string, input map[string]string, output interface{}) error {
+	u, err := url.JoinPath(s.config.Address, "v1", s.config.Mount, operation, s.config.Key)
+	if err != nil {
+		return fmt.Errorf("invalid vault address: %w", err)
+	}
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", s.config.Token)
+	if s.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.config.Namespace)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s with vault transit: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	// Vault rejects malformed signatures with 400 instead of reporting them as invalid
+	if operation == "verify" && resp.StatusCode == http.StatusBadRequest {
+		return ErrInvalidSignature
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to %s with vault transit: unexpected status %s: %s", operation, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(output); err != nil {
+		return fmt.Errorf("failed to decode vault transit response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// newTransitServer returns a fake Vault Transit secrets engine signing with HMAC-SHA256 instead of an asymmetric key
+func newTransitServer(t *testing.T) *httptest.Server {
+	sign := func(input string) string {
+		mac := hmac.New(sha256.New, []byte("transit-key"))
+		mac.Write([]byte(input))
+		return "vault:v1:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			Input     string `json:"input"`
+			Signature string `json:"signature"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+
+		var resp map[string]interface{}
+		switch r.URL.Path {
+		case "/v1/transit/sign/registry":
+			resp = map[string]interface{}{"signature": sign(req.Input)}
+		case "/v1/transit/verify/registry":
+			if len(req.Signature) < len("vault:v1:") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp = map[string]interface{}{"valid": req.Signature == sign(req.Input)}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": resp})
+	}))
+}
+
+func TestVaultTransitSigner(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	server := newTransitServer(t)
+	defer server.Close()
+	signer := NewVaultTransitSigner(nil, VaultTransitConfig{Address: server.URL, Token: "token", Mount: "transit", Key: "registry"})
+
+	signature, err := signer.Sign(ctx, []byte("data"))
+	assert.NoError(err)
+	assert.NoError(signer.Verify(ctx, []byte("data"), signature))
+	assert.ErrorIs(signer.Verify(ctx, []byte("other"), signature), ErrInvalidSignature)
+	assert.ErrorIs(signer.Verify(ctx, []byte("data"), "garbage"), ErrInvalidSignature)
+
+	denied := NewVaultTransitSigner(nil, VaultTransitConfig{Address: server.URL, Token: "other", Mount: "transit", Key: "registry"})
+	_, err = denied.Sign(ctx, []byte("data"))
+	assert.ErrorContains(err, "403 Forbidden")
+
+	unknown := NewVaultTransitSigner(nil, VaultTransitConfig{Address: server.URL, Token: "token", Mount: "transit", Key: "other"})
+	_, err = unknown.Sign(ctx, []byte("data"))
+	assert.ErrorContains(err, "404 Not Found")
+}
@@ -0,0 +1,28 @@
+package core
+
+// DownloadStats holds the download counters of a single module or provider.
+type DownloadStats struct {
+	Total    int64            `json:"total"`
+	Versions map[string]int64 `json:"versions"`
+}
+
+// Add increments the counter of a version and the total by count.
+func (d *DownloadStats) Add(version string, count int64) {
+	if d.Versions == nil {
+		d.Versions = make(map[string]int64)
+	}
+
+	d.Versions[version] += count
+	d.Total += count
+}
+
+// Merge adds all counters of other to d.
+func (d *DownloadStats) Merge(other *DownloadStats) {
+	if other == nil {
+		return
+	}
+
+	for version, count := range other.Versions {
+		d.Add(version, count)
+	}
+}
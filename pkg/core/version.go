@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// MatchVersion returns the highest of the versions matching the constraint like Terraform selects versions,
+// so pre-releases only match constraints which name the pre-release explicitly, e.g. = 1.0.0-beta.
+// An empty constraint matches the highest stable version. An empty string is returned if no version matches.
+func MatchVersion(versions []string, constraint string) (string, error) {
+	if constraint == "" {
+		constraint = ">= 0.0.0"
+	}
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidConstraint, err)
+	}
+
+	var best *version.Version
+	var match string
+	for _, v := range versions {
+		parsed, err := version.NewVersion(v)
+		if err != nil {
+			// Versions which can't be parsed never match, like in Terraform
+			continue
+		}
+		if constraints.Check(parsed) && (best == nil || parsed.GreaterThan(best)) {
+			best, match = parsed, v
+		}
+	}
+
+	return match, nil
+}
+
+// SortByVersion sorts the items by their version with the highest version first.
+// Versions which can't be parsed are sorted after all others by their string, so that the order is stable.
+func SortByVersion[T any](items []T, versionOf func(T) string) {
+	parsed := make(map[string]*version.Version, len(items))
+	for _, item := range items {
+		v := versionOf(item)
+		if p, err := version.NewVersion(v); err == nil {
+			parsed[v] = p
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := versionOf(items[i]), versionOf(items[j])
+		pa, pb := parsed[a], parsed[b]
+		switch {
+		case pa != nil && pb != nil && !pa.Equal(pb):
+			return pa.GreaterThan(pb)
+		case pa != nil && pb == nil:
+			return true
+		case pa == nil && pb != nil:
+			return false
+		}
+		return a > b
+	})
+}
+
+// ValidVersions returns the items whose version can be parsed and the versions which can't be parsed.
+// Terraform ignores versions which can't be parsed, so they're omitted from the listings of the registry.
+func ValidVersions[T any](items []T, versionOf func(T) string) ([]T, []string) {
+	valid := make([]T, 0, len(items))
+	var invalid []string
+	for _, item := range items {
+		if _, err := version.NewVersion(versionOf(item)); err != nil {
+			invalid = append(invalid, versionOf(item))
+			continue
+		}
+		valid = append(valid, item)
+	}
+	return valid, invalid
+}
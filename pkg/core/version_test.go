@@ -0,0 +1,51 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchVersion(t *testing.T) {
+	versions := []string{"1.0.0", "1.2.0", "1.10.1", "2.0.0-beta", "invalid", "0.9.0"}
+
+	tests := []struct {
+		constraint string
+		expected   string
+		wantErr    error
+	}{
+		{constraint: "", expected: "1.10.1"},
+		{constraint: "~> 1.2", expected: "1.10.1"},
+		{constraint: "~> 1.2.0", expected: "1.2.0"},
+		{constraint: ">= 1.0, < 1.5", expected: "1.2.0"},
+		{constraint: "< 1.0.0", expected: "0.9.0"},
+		{constraint: ">= 2.0.0", expected: ""},
+		{constraint: "2.0.0-beta", expected: "2.0.0-beta"},
+		{constraint: "not a constraint", wantErr: ErrInvalidConstraint},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			res, err := MatchVersion(versions, tc.constraint)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestSortByVersion(t *testing.T) {
+	versions := []string{"1.2.0", "invalid", "1.10.1", "2.0.0-beta", "1.0.0", "another"}
+	SortByVersion(versions, func(v string) string { return v })
+	assert.Equal(t, []string{"2.0.0-beta", "1.10.1", "1.2.0", "1.0.0", "invalid", "another"}, versions)
+}
+
+func TestValidVersions(t *testing.T) {
+	versions := []string{"1.2.0", "invalid", "2.0.0-beta+build.1", "v1.0.0", ""}
+	valid, invalid := ValidVersions(versions, func(v string) string { return v })
+	assert.Equal(t, []string{"1.2.0", "2.0.0-beta+build.1", "v1.0.0"}, valid)
+	assert.Equal(t, []string{"invalid", ""}, invalid)
+}
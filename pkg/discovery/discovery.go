@@ -1,10 +1,16 @@
 package discovery
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"strings"
 )
 
+// ErrInvalidService is returned for additional services which can't be advertised
+var ErrInvalidService = errors.New("invalid discovery service")
+
 // See:
 // https://opentofu.org/docs/internals/remote-service-discovery/
 // https://developer.hashicorp.com/terraform/internals/remote-service-discovery
@@ -12,6 +18,25 @@ type Discovery struct {
 	LoginV1     *LoginV1 `json:"login.v1,omitempty"`
 	ModulesV1   string   `json:"modules.v1,omitempty"`
 	ProvidersV1 string   `json:"providers.v1,omitempty"`
+
+	// Services are advertised in addition to the services of the registry, e.g. tfe.v2 or state.v1 of another host
+	Services map[string]json.RawMessage `json:"-"`
+}
+
+// MarshalJSON adds the additional services to the document
+func (d Discovery) MarshalJSON() ([]byte, error) {
+	type discovery Discovery
+	b, err := json.Marshal(discovery(d))
+	if err != nil || len(d.Services) == 0 {
+		return b, err
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(b, &document); err != nil {
+		return nil, err
+	}
+	maps.Copy(document, d.Services)
+	return json.Marshal(document)
 }
 
 // See: https://opentofu.org/docs/internals/login-protocol/
@@ -86,6 +111,39 @@ func WithLoginV1(login *LoginV1) Option {
 	}
 }
 
+// WithService advertises an additional service, the services of the registry can't be replaced
+func WithService(name string, value json.RawMessage) Option {
+	return func(d *Discovery) {
+		if d.Services == nil {
+			d.Services = make(map[string]json.RawMessage)
+		}
+		d.Services[name] = value
+	}
+}
+
+// ParseService parses a service in the form <name>=<value>, e.g. tfe.v2=https://tfe.example.com/api/v2/.
+// Values which are JSON objects are advertised as objects, like the login.v1 service, all other values as strings.
+func ParseService(s string) (string, json.RawMessage, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" || value == "" {
+		return "", nil, fmt.Errorf("%w: %q must be in the form <name>=<value>", ErrInvalidService, s)
+	}
+	switch name {
+	case "login.v1", "modules.v1", "providers.v1":
+		return "", nil, fmt.Errorf("%w: %s is advertised by the registry itself", ErrInvalidService, name)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		if !json.Valid([]byte(value)) {
+			return "", nil, fmt.Errorf("%w: the value of %s isn't a valid JSON object", ErrInvalidService, name)
+		}
+		return name, json.RawMessage(value), nil
+	}
+
+	b, err := json.Marshal(value)
+	return name, b, err
+}
+
 func NewDiscovery(options ...Option) *Discovery {
 	discovery := &Discovery{}
 
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -122,3 +123,34 @@ func TestLoginV1Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscovery_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(NewDiscovery(WithModulesV1("/v1/modules/")))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"modules.v1": "/v1/modules/"}`, string(b))
+
+	b, err = json.Marshal(NewDiscovery(WithModulesV1("/v1/modules/"), WithService("tfe.v2", json.RawMessage(`"/api/v2/"`))))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"modules.v1": "/v1/modules/", "tfe.v2": "/api/v2/"}`, string(b))
+}
+
+func TestParseService(t *testing.T) {
+	t.Parallel()
+
+	name, value, err := ParseService("tfe.v2=https://tfe.example.com/api/v2/")
+	assert.NoError(t, err)
+	assert.Equal(t, "tfe.v2", name)
+	assert.JSONEq(t, `"https://tfe.example.com/api/v2/"`, string(value))
+
+	name, value, err = ParseService(`state.v1={"url": "https://state.example.com/"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "state.v1", name)
+	assert.JSONEq(t, `{"url": "https://state.example.com/"}`, string(value))
+
+	for _, s := range []string{"tfe.v2", "=value", "tfe.v2=", "state.v1={invalid", "modules.v1=/modules/", "login.v1={}"} {
+		_, _, err = ParseService(s)
+		assert.ErrorIs(t, err, ErrInvalidService, s)
+	}
+}
@@ -0,0 +1,211 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+)
+
+const (
+	// SchemaNative publishes the events as they're served by the event feed
+	SchemaNative = "native"
+
+	// SchemaCloudEvents publishes the events in the structured JSON format of CloudEvents 1.0
+	SchemaCloudEvents = "cloudevents"
+
+	// DefaultTopic is the NATS subject or Kafka topic the events are published to
+	DefaultTopic = "boring-registry.events"
+
+	// topicTypePlaceholder is replaced with the type of the event, e.g. to publish to a NATS subject per event type
+	topicTypePlaceholder = "{type}"
+
+	maxSendAttempts = 3
+)
+
+// Sink sends messages to an event bus like NATS or Kafka
+type Sink interface {
+	// Send publishes the payload to the topic, the key determines the partition of Kafka topics
+	Send(ctx context.Context, topic, key string, payload []byte) error
+}
+
+// Forwarder publishes the events of the Broker to a Sink in the order they were published
+type Forwarder struct {
+	broker *Broker
+	sink   Sink
+	topic  string
+	schema string
+	source string
+
+	// instance makes the IDs of CloudEvents unique, as the event IDs restart when the server is restarted
+	instance string
+	logger   *slog.Logger
+}
+
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            Event     `json:"data"`
+}
+
+func (f *Forwarder) run(ctx context.Context) {
+	var last uint64
+	for ctx.Err() == nil {
+		s := f.broker.Subscribe(last)
+		last = f.forward(ctx, s, last)
+		s.Close()
+	}
+}
+
+// forward sends the events of the subscription until it's closed, and returns the ID of the last forwarded event.
+// Subscriptions are closed if the Sink can't keep up, the next subscription resumes with the missed events.
+func (f *Forwarder) forward(ctx context.Context, s *Subscription, last uint64) uint64 {
+	for _, e := range s.Missed {
+		if last > 0 && e.ID > last+1 {
+			f.logger.Warn("events were dropped, as the event bus couldn't keep up", slog.Uint64("from", last+1), slog.Uint64("to", e.ID-1))
+		}
+		f.send(ctx, e)
+		last = e.ID
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return last
+		case e, ok := <-s.Events():
+			if !ok {
+				return last
+			}
+			f.send(ctx, e)
+			last = e.ID
+		}
+	}
+}
+
+// send retries failed events with a backoff, events which still can't be sent are dropped
+func (f *Forwarder) send(ctx context.Context, e Event) {
+	payload, err := f.encode(e)
+	if err != nil {
+		f.logger.Error("failed to encode event", slog.Uint64("id", e.ID), slog.String("err", err.Error()))
+		return
+	}
+	topic := strings.ReplaceAll(f.topic, topicTypePlaceholder, e.Type)
+
+	for attempt := 1; ; attempt++ {
+		err = f.sink.Send(ctx, topic, Artifact(e), payload)
+		if err == nil {
+			return
+		}
+		if attempt == maxSendAttempts || ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+	f.logger.Error("failed to publish event to the event bus", slog.Uint64("id", e.ID), slog.String("type", e.Type), slog.String("topic", topic), slog.String("err", err.Error()))
+}
+
+func (f *Forwarder) encode(e Event) ([]byte, error) {
+	if f.schema != SchemaCloudEvents {
+		return json.Marshal(e)
+	}
+	return json.Marshal(cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              fmt.Sprintf("%s-%d", f.instance, e.ID),
+		Source:          f.source,
+		Type:            "io.boring-registry." + e.Type,
+		Subject:         path.Join(Artifact(e), e.Version),
+		Time:            e.Time,
+		DataContentType: "application/json",
+		Data:            e,
+	})
+}
+
+// Artifact returns the artifact of the event in the form of modules/<namespace>/<name>/<provider>,
+// providers/<namespace>/<name> or mirror/providers/<hostname>/<namespace>/<name>
+func Artifact(e Event) string {
+	switch {
+	case e.Provider != "":
+		return path.Join("modules", e.Namespace, e.Name, e.Provider)
+	case e.Hostname != "":
+		return path.Join("mirror", "providers", e.Hostname, e.Namespace, e.Name)
+	default:
+		return path.Join("providers", e.Namespace, e.Name)
+	}
+}
+
+// ValidateSchema returns an error for unknown schemas
+func ValidateSchema(schema string) error {
+	if schema != SchemaNative && schema != SchemaCloudEvents {
+		return fmt.Errorf("invalid event schema %q, expected %s or %s", schema, SchemaNative, SchemaCloudEvents)
+	}
+	return nil
+}
+
+// ForwarderOption provides additional options for the Forwarder.
+type ForwarderOption func(*Forwarder)
+
+// WithTopic sets the topic the events are published to, {type} is replaced with the type of the event
+func WithTopic(topic string) ForwarderOption {
+	return func(f *Forwarder) {
+		if topic != "" {
+			f.topic = topic
+		}
+	}
+}
+
+// WithSchema sets the schema of the published messages
+func WithSchema(schema string) ForwarderOption {
+	return func(f *Forwarder) {
+		if schema != "" {
+			f.schema = schema
+		}
+	}
+}
+
+// WithSource sets the source attribute of CloudEvents, which identifies the registry
+func WithSource(source string) ForwarderOption {
+	return func(f *Forwarder) {
+		if source != "" {
+			f.source = source
+		}
+	}
+}
+
+// NewForwarder starts forwarding the events published to the Broker to the Sink, until the context is canceled
+func NewForwarder(ctx context.Context, broker *Broker, sink Sink, opts ...ForwarderOption) *Forwarder {
+	instance := make([]byte, 8)
+	_, _ = rand.Read(instance)
+
+	f := &Forwarder{
+		broker:   broker,
+		sink:     sink,
+		topic:    DefaultTopic,
+		schema:   SchemaNative,
+		source:   "boring-registry",
+		instance: hex.EncodeToString(instance),
+		logger:   slog.Default().With(slog.String(loglevel.ComponentKey, "event-bus")),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	go f.run(ctx)
+
+	return f
+}
@@ -0,0 +1,241 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/stats"
+	assertion "github.com/stretchr/testify/assert"
+)
+
+type sentMessage struct {
+	topic, key string
+	payload    []byte
+}
+
+type fakeSink struct {
+	failures int
+	sent     chan sentMessage
+}
+
+func (s *fakeSink) Send(_ context.Context, topic, key string, payload []byte) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.sent <- sentMessage{topic: topic, key: key, payload: payload}
+	return nil
+}
+
+func receive(t *testing.T, sink *fakeSink) sentMessage {
+	t.Helper()
+	select {
+	case m := <-sink.sent:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+		return sentMessage{}
+	}
+}
+
+func TestForwarder(t *testing.T) {
+	assert := assertion.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBroker(10)
+	sink := &fakeSink{sent: make(chan sentMessage, 10)}
+	NewForwarder(ctx, b, sink, WithTopic("registry.{type}"))
+
+	b.Publish(Event{Type: ModulePublished, Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"})
+	m := receive(t, sink)
+	assert.Equal("registry.module.published", m.topic)
+	assert.Equal("modules/acme/vpc/aws", m.key)
+
+	var e Event
+	assert.NoError(json.Unmarshal(m.payload, &e))
+	assert.Equal(uint64(1), e.ID)
+	assert.Equal("1.0.0", e.Version)
+}
+
+func TestForwarder_CloudEvents(t *testing.T) {
+	assert := assertion.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBroker(10)
+	sink := &fakeSink{sent: make(chan sentMessage, 10)}
+	NewForwarder(ctx, b, sink, WithSchema(SchemaCloudEvents), WithSource("https://registry.example.com"))
+
+	b.Publish(Event{Type: ProviderDeleted, Hostname: "registry.terraform.io", Namespace: "hashicorp", Name: "aws", Version: "5.0.0"})
+	m := receive(t, sink)
+	assert.Equal(DefaultTopic, m.topic)
+
+	var ce cloudEvent
+	assert.NoError(json.Unmarshal(m.payload, &ce))
+	assert.Equal("1.0", ce.SpecVersion)
+	assert.True(strings.HasSuffix(ce.ID, "-1"))
+	assert.Equal("https://registry.example.com", ce.Source)
+	assert.Equal("io.boring-registry.provider.deleted", ce.Type)
+	assert.Equal("mirror/providers/registry.terraform.io/hashicorp/aws/5.0.0", ce.Subject)
+	assert.Equal("5.0.0", ce.Data.Version)
+}
+
+func TestForwarder_Retry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the retry waits for the backoff")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBroker(10)
+	sink := &fakeSink{failures: 1, sent: make(chan sentMessage, 10)}
+	NewForwarder(ctx, b, sink)
+
+	b.Publish(Event{Type: ProviderPublished, Namespace: "acme", Name: "dns", Version: "1.0.0"})
+	assertion.Equal(t, "providers/acme/dns", receive(t, sink).key)
+}
+
+func TestValidateSchema(t *testing.T) {
+	assert := assertion.New(t)
+	assert.NoError(ValidateSchema(SchemaNative))
+	assert.NoError(ValidateSchema(SchemaCloudEvents))
+	assert.Error(ValidateSchema("avro"))
+}
+
+func TestNATSSink(t *testing.T) {
+	assert := assertion.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var mu sync.Mutex
+	var connect, published string
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		_, _ = io.WriteString(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				mu.Lock()
+				connect = strings.TrimPrefix(line, "CONNECT ")
+				mu.Unlock()
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := r.ReadString('\n')
+				mu.Lock()
+				published = line + " " + strings.TrimRight(payload, "\r\n")
+				mu.Unlock()
+			case line == "PING":
+				_, _ = io.WriteString(conn, "PONG\r\n")
+			}
+		}
+	}()
+
+	sink, err := NewNATSSink("nats://s3cr3t@" + l.Addr().String())
+	assert.NoError(err)
+	assert.NoError(sink.Send(context.Background(), "registry.events", "", []byte(`{"id":1}`)))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(connect, `"auth_token":"s3cr3t"`)
+	assert.Equal(`PUB registry.events 8 {"id":1}`, published)
+
+	assert.Error(sink.Send(context.Background(), "invalid subject", "", nil))
+}
+
+func TestNewNATSSink(t *testing.T) {
+	assert := assertion.New(t)
+
+	sink, err := NewNATSSink("nats://nats.example.com")
+	assert.NoError(err)
+	assert.Equal("nats://nats.example.com:4222", sink.(*natsSink).url)
+
+	_, err = NewNATSSink("https://nats.example.com")
+	assert.Error(err)
+}
+
+func TestKafkaRESTSink(t *testing.T) {
+	assert := assertion.New(t)
+
+	var records kafkaRecords
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/topics/registry.events", r.URL.Path)
+		assert.Equal(kafkaContentType, r.Header.Get("Content-Type"))
+		user, pass, ok := r.BasicAuth()
+		assert.True(ok)
+		assert.Equal("user:pass", user+":"+pass)
+		assert.NoError(json.NewDecoder(r.Body).Decode(&records))
+
+		if records.Records[0].Key == "fail" {
+			_, _ = io.WriteString(w, `{"offsets":[{"error_code":50002,"error":"Kafka error"}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"offsets":[{"partition":0,"offset":42}]}`)
+	}))
+	defer server.Close()
+
+	sink, err := NewKafkaRESTSink(strings.Replace(server.URL, "http://", "http://user:pass@", 1))
+	assert.NoError(err)
+
+	assert.NoError(sink.Send(context.Background(), "registry.events", "modules/acme/vpc/aws", []byte(`{"id":1}`)))
+	assert.Len(records.Records, 1)
+	assert.Equal("modules/acme/vpc/aws", records.Records[0].Key)
+	assert.JSONEq(`{"id":1}`, string(records.Records[0].Value))
+
+	assert.ErrorContains(sink.Send(context.Background(), "registry.events", "fail", []byte(`{}`)), "Kafka error")
+
+	_, err = NewKafkaRESTSink("kafka://broker:9092")
+	assert.Error(err)
+}
+
+type countingRecorder struct {
+	stats.Recorder
+	recorded []string
+}
+
+func (r *countingRecorder) Record(artifact, version string) {
+	r.recorded = append(r.recorded, artifact+"@"+version)
+}
+
+func TestDownloadRecorder(t *testing.T) {
+	assert := assertion.New(t)
+
+	b := NewBroker(10)
+	next := &countingRecorder{}
+	r := DownloadRecorder(next, b)
+	r.Record("modules/acme/vpc/aws", "1.0.0")
+	r.Record("providers/acme/dns", "2.0.0")
+
+	assert.Equal([]string{"modules/acme/vpc/aws@1.0.0", "providers/acme/dns@2.0.0"}, next.recorded)
+
+	s := b.Subscribe(0)
+	defer s.Close()
+	assert.Len(s.Missed, 2)
+	assert.Equal(ModuleDownloaded, s.Missed[0].Type)
+	assert.Equal("aws", s.Missed[0].Provider)
+	assert.Equal(ProviderDownloaded, s.Missed[1].Type)
+	assert.Equal("dns", s.Missed[1].Name)
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type feedRequest struct {
+	// after is the ID of the last event the subscriber received
+	after uint64
+
+	// stream sends the events as Server-Sent Events, otherwise the request waits for events like a long poll
+	stream bool
+	wait   time.Duration
+}
+
+type feedResponse struct {
+	feedRequest
+	subscription *Subscription
+}
+
+type eventsResponse struct {
+	Events []Event `json:"events"`
+}
+
+// feedEndpoint subscribes to the broker, the events are awaited and written by encodeFeedResponse
+func feedEndpoint(b *Broker) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(feedRequest)
+		return feedResponse{
+			feedRequest:  req,
+			subscription: b.Subscribe(req.after),
+		}, nil
+	}
+}
@@ -0,0 +1,147 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// The event types of modules and providers
+const (
+	ModulePublished = "module.published"
+	ModuleReplaced  = "module.replaced"
+	ModuleDeleted   = "module.deleted"
+	ModuleRestored  = "module.restored"
+	ModulePurged    = "module.purged"
+
+	ProviderPublished = "provider.published"
+	ProviderUpdated   = "provider.updated"
+	ProviderDeleted   = "provider.deleted"
+	ProviderRestored  = "provider.restored"
+	ProviderPurged    = "provider.purged"
+)
+
+const (
+	// DefaultHistorySize is the number of events kept for subscribers resuming the feed
+	DefaultHistorySize = 1000
+
+	// subscriberBufferSize is the number of events a subscriber can lag behind, before it's disconnected
+	subscriberBufferSize = 100
+)
+
+// Event describes a change of a module or provider version
+type Event struct {
+	// ID increases with every event, it restarts at 1 when the server is restarted
+	ID   uint64    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Hostname is the upstream registry of mirrored providers
+	Hostname  string `json:"hostname,omitempty"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// Provider is the provider of modules
+	Provider string `json:"provider,omitempty"`
+	Version  string `json:"version"`
+}
+
+// Broker fans out the published events to the subscribers of the feed,
+// and keeps the latest events, so that subscribers can resume after reconnecting.
+type Broker struct {
+	mu          sync.Mutex
+	history     []Event
+	historySize int
+	lastID      uint64
+	subscribers map[*Subscription]struct{}
+}
+
+// Publish assigns the next ID to the event and sends it to all subscribers.
+// Subscribers which can't keep up are disconnected instead of blocking the publisher.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e.ID = b.lastID
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for s := range b.subscribers {
+		select {
+		case s.events <- e:
+		default:
+			delete(b.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+// Subscribe returns a Subscription receiving the events published after the event with the given ID.
+// The missed events which are still kept are returned in Subscription.Missed.
+// An ID greater than the ID of the latest event is from before a restart of the server, in that case all kept events are missed.
+func (b *Broker) Subscribe(after uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if after > b.lastID {
+		after = 0
+	}
+	s := &Subscription{
+		events: make(chan Event, subscriberBufferSize),
+		broker: b,
+	}
+	for _, e := range b.history {
+		if e.ID > after {
+			s.Missed = append(s.Missed, e)
+		}
+	}
+	b.subscribers[s] = struct{}{}
+
+	return s
+}
+
+func (b *Broker) unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[s]; ok {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
+
+// Subscription receives the events published after it was created
+type Subscription struct {
+	// Missed are the events which were published before the Subscription was created
+	Missed []Event
+
+	events chan Event
+	broker *Broker
+}
+
+// Events returns the channel of published events, it's closed if the subscriber can't keep up or the Subscription is closed
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the delivery of events
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// NewBroker returns a Broker keeping the given number of events for resuming subscribers
+func NewBroker(historySize int) *Broker {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Broker{
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	assert := assertion.New(t)
+
+	b := NewBroker(2)
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		b.Publish(Event{Type: ModulePublished, Namespace: "acme", Name: "vpc", Provider: "aws", Version: v})
+	}
+
+	// Only the latest events are kept
+	s := b.Subscribe(0)
+	assert.Len(s.Missed, 2)
+	assert.Equal(uint64(2), s.Missed[0].ID)
+	s.Close()
+
+	s = b.Subscribe(2)
+	assert.Len(s.Missed, 1)
+	assert.Equal("1.2.0", s.Missed[0].Version)
+	s.Close()
+
+	// IDs from before a restart of the server resume from the oldest kept event
+	s = b.Subscribe(42)
+	assert.Len(s.Missed, 2)
+
+	b.Publish(Event{Type: ModuleDeleted, Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"})
+	e := <-s.Events()
+	assert.Equal(uint64(4), e.ID)
+	assert.Equal(ModuleDeleted, e.Type)
+	assert.False(e.Time.IsZero())
+
+	// Subscribers which can't keep up are disconnected
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		b.Publish(Event{Type: ModulePublished})
+	}
+	received := 0
+	for range s.Events() {
+		received++
+	}
+	assert.Equal(subscriberBufferSize, received)
+	s.Close()
+}
+
+type nopInstrumentation struct{}
+
+func (nopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+func newTestHandler(b *Broker) http.Handler {
+	nop := func(e endpoint.Endpoint) endpoint.Endpoint { return e }
+	return MakeHandler(b, nop, nopInstrumentation{}, httptransport.ServerErrorEncoder(ErrorEncoder))
+}
+
+func TestMakeHandler_Poll(t *testing.T) {
+	assert := assertion.New(t)
+
+	b := NewBroker(0)
+	b.Publish(Event{Type: ProviderPublished, Namespace: "acme", Name: "dummy", Version: "1.0.0"})
+	handler := newTestHandler(b)
+
+	poll := func(target string) []Event {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(http.StatusOK, rec.Code)
+
+		var resp eventsResponse
+		assert.NoError(json.NewDecoder(rec.Body).Decode(&resp))
+		return resp.Events
+	}
+
+	// Missed events are returned immediately
+	events := poll("/events")
+	assert.Len(events, 1)
+	assert.Equal("dummy", events[0].Name)
+
+	assert.Empty(poll("/events?after=1&wait=0s"))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		b.Publish(Event{Type: ProviderDeleted, Namespace: "acme", Name: "dummy", Version: "1.0.0"})
+	}()
+	events = poll("/events?after=1&wait=5s")
+	assert.Len(events, 1)
+	assert.Equal(ProviderDeleted, events[0].Type)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?after=latest", nil))
+	assert.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func TestMakeHandler_Stream(t *testing.T) {
+	assert := assertion.New(t)
+
+	b := NewBroker(0)
+	b.Publish(Event{Type: ModulePublished, Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"})
+	b.Publish(Event{Type: ModulePublished, Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.1.0"})
+	server := httptest.NewServer(newTestHandler(b))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	assert.NoError(err)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	b.Publish(Event{Type: ModuleDeleted, Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"})
+
+	// The missed event is followed by the published one
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() && len(lines) < 6 {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal("id: 2", lines[0])
+	assert.Equal("event: module.published", lines[1])
+	assert.True(strings.HasPrefix(lines[2], `data: {"id":2,"type":"module.published"`))
+	assert.Equal("id: 3", lines[3])
+	assert.Equal("event: module.deleted", lines[4])
+}
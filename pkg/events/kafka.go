@@ -0,0 +1,98 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const kafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// kafkaRESTSink produces messages through the REST Proxy of Kafka, which is also implemented by Redpanda.
+// See: https://docs.confluent.io/platform/current/kafka-rest/api.html#records-v2
+type kafkaRESTSink struct {
+	url    *url.URL
+	user   *url.Userinfo
+	client *http.Client
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaOffsets struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (k *kafkaRESTSink) Send(ctx context.Context, topic, key string, payload []byte) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Key: key, Value: payload}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url.JoinPath("topics", topic).String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if k.user != nil {
+		password, _ := k.user.Password()
+		req.SetBasicAuth(k.user.Username(), password)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce to Kafka topic %s: %w", topic, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to produce to Kafka topic %s: the REST proxy responded with status code %d", topic, resp.StatusCode)
+	}
+
+	// Records can fail individually even if the request succeeded
+	var offsets kafkaOffsets
+	if err := json.NewDecoder(resp.Body).Decode(&offsets); err != nil {
+		return fmt.Errorf("failed to decode the response of the REST proxy: %w", err)
+	}
+	for _, o := range offsets.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("failed to produce to Kafka topic %s: %s (error code %d)", topic, o.Error, *o.ErrorCode)
+		}
+	}
+	return nil
+}
+
+// NewKafkaRESTSink returns a Sink producing to Kafka through the REST proxy at the URL, e.g. https://kafka-rest.example.com:8082.
+// Basic authentication credentials are taken from the URL.
+func NewKafkaRESTSink(rawURL string) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Kafka REST proxy URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Kafka REST proxy URL %s, the scheme must be http or https", u.Redacted())
+	}
+
+	user := u.User
+	u.User = nil
+	return &kafkaRESTSink{
+		url:  u,
+		user: user,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
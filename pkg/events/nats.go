@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const natsTimeout = 10 * time.Second
+
+// natsSink publishes messages to a NATS server.
+// Every message is flushed, so that Send only returns once the server processed the message.
+type natsSink struct {
+	url string
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+func (n *natsSink) Send(ctx context.Context, topic, _ string, payload []byte) error {
+	if topic == "" || strings.ContainsAny(topic, " \t\r\n") {
+		return fmt.Errorf("invalid NATS subject %q", topic)
+	}
+
+	conn, err := n.connection()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natsTimeout)
+	defer cancel()
+
+	if err := conn.Publish(topic, payload); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	if err := conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// connection connects to the server on the first message.
+// The client reconnects on its own, the connection is only replaced once the client gave up.
+func (n *natsSink) connection() (*nats.Conn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn != nil && !n.conn.IsClosed() {
+		return n.conn, nil
+	}
+
+	conn, err := nats.Connect(n.url, nats.Name("boring-registry"), nats.Timeout(natsTimeout))
+	if err != nil {
+		u, _ := url.Parse(n.url)
+		return nil, fmt.Errorf("failed to connect to NATS server %s: %w", u.Redacted(), err)
+	}
+	n.conn = conn
+	return conn, nil
+}
+
+// NewNATSSink returns a Sink publishing to the NATS server, e.g. nats://nats.example.com:4222.
+// TLS is used if the server requires it, or enforced with the tls:// scheme.
+// Credentials are taken from the URL, either as user and password or as token, e.g. nats://token@nats.example.com:4222.
+func NewNATSSink(rawURL string) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse NATS URL: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("invalid NATS URL %s, the scheme must be nats or tls", u.Redacted())
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsSink{url: u.String()}, nil
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/stats"
+)
+
+// The event types of downloads, which are only published with DownloadRecorder
+const (
+	ModuleDownloaded   = "module.downloaded"
+	ProviderDownloaded = "provider.downloaded"
+)
+
+// downloadRecorder publishes an event for every download counted by the Recorder
+type downloadRecorder struct {
+	stats.Recorder
+	broker *Broker
+}
+
+func (r *downloadRecorder) Record(artifact, version string) {
+	r.Recorder.Record(artifact, version)
+
+	e := Event{Version: version}
+	parts := strings.Split(artifact, "/")
+	switch {
+	case len(parts) == 4 && parts[0] == "modules":
+		e.Type, e.Namespace, e.Name, e.Provider = ModuleDownloaded, parts[1], parts[2], parts[3]
+	case len(parts) == 3 && parts[0] == "providers":
+		e.Type, e.Namespace, e.Name = ProviderDownloaded, parts[1], parts[2]
+	default:
+		return
+	}
+	r.broker.Publish(e)
+}
+
+// DownloadRecorder wraps the Recorder to publish an event for every recorded download to the Broker
+func DownloadRecorder(recorder stats.Recorder, broker *Broker) stats.Recorder {
+	return &downloadRecorder{
+		Recorder: recorder,
+		broker:   broker,
+	}
+}
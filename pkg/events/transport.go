@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultWait is the duration a long poll waits for events
+	defaultWait = 30 * time.Second
+	maxWait     = 5 * time.Minute
+
+	// heartbeatInterval keeps idle streams open through proxies and load balancers
+	heartbeatInterval = 15 * time.Second
+)
+
+// MakeHandler returns a fully initialized http.Handler for the event feed.
+func MakeHandler(b *Broker, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/events`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(feedEndpoint(b)),
+				decodeFeedRequest,
+				encodeFeedResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+// decodeFeedRequest takes the ID of the last received event from the Last-Event-ID header, which browsers send when reconnecting,
+// or from the after query parameter
+func decodeFeedRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := feedRequest{
+		stream: strings.Contains(r.Header.Get("Accept"), "text/event-stream"),
+		wait:   defaultWait,
+	}
+
+	after := r.Header.Get("Last-Event-ID")
+	if v := r.URL.Query().Get("after"); v != "" {
+		after = v
+	}
+	if after != "" {
+		id, err := strconv.ParseUint(after, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: the event ID %q isn't a positive integer", core.ErrVarType, after)
+		}
+		req.after = id
+	}
+
+	if v := r.URL.Query().Get("wait"); v != "" {
+		wait, err := time.ParseDuration(v)
+		if err != nil || wait < 0 || wait > maxWait {
+			return nil, fmt.Errorf("%w: wait must be a duration of at most %s", core.ErrVarType, maxWait)
+		}
+		req.wait = wait
+	}
+
+	return req, nil
+}
+
+// encodeFeedResponse writes the events until the client disconnects for streams,
+// or until the first events are received for long polls
+func encodeFeedResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(feedResponse)
+	defer resp.subscription.Close()
+
+	if resp.stream {
+		return stream(ctx, w, resp.subscription)
+	}
+	return poll(ctx, w, resp.subscription, resp.wait)
+}
+
+// stream writes the events as Server-Sent Events.
+// The write deadline of the server is extended with every write, so that the stream isn't closed by --server-write-timeout.
+// Failed writes end the stream without an error, as the status code has been written and the client is gone already.
+func stream(ctx context.Context, w http.ResponseWriter, s *Subscription) error {
+	rc := http.NewResponseController(w)
+	extendDeadline := func() {
+		_ = rc.SetWriteDeadline(time.Now().Add(heartbeatInterval * 2))
+	}
+	extendDeadline()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Disables the response buffering of nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range s.Missed {
+		if err := writeEvent(w, e); err != nil {
+			return nil
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-s.Events():
+			if !ok {
+				// The subscriber lagged behind, it resumes from the last received event when reconnecting
+				return nil
+			}
+			extendDeadline()
+			if err := writeEvent(w, e); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			extendDeadline()
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// poll returns the missed events immediately, otherwise it waits for the next events or until the wait duration elapsed
+func poll(ctx context.Context, w http.ResponseWriter, s *Subscription, wait time.Duration) error {
+	events := s.Missed
+	if len(events) == 0 && wait > 0 {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + heartbeatInterval))
+
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-s.Events():
+			if ok {
+				events = append(events, e)
+			}
+		case <-timer.C:
+		}
+	}
+	if events == nil {
+		events = []Event{}
+	}
+
+	return httptransport.EncodeJSONResponse(ctx, w, eventsResponse{Events: events})
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	core.HandleErrorResponse(err, core.GenericError(err), w)
+}
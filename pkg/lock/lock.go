@@ -0,0 +1,113 @@
+// Package lock serializes publish operations of the same artifact version, so that concurrent uploads can't race past
+// the existence checks of the storage and leave partial or inconsistent artifacts behind.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+const (
+	// DefaultTimeout is how long an upload waits for the upload of the same artifact version holding the lock
+	DefaultTimeout = time.Minute
+
+	// DefaultTTL is how long a distributed lock is held without being renewed, e.g. after the holder crashed
+	DefaultTTL = 30 * time.Second
+)
+
+// Unlock releases an acquired lock
+type Unlock func()
+
+// Locker grants exclusive access to a key, e.g. the path of a module version
+type Locker interface {
+	// Lock blocks until the lock of the key is acquired.
+	// An error wrapping core.ErrLocked is returned if the lock is still held by someone else once the timeout elapsed.
+	Lock(ctx context.Context, key string) (Unlock, error)
+}
+
+// New returns an in-process Locker if the URL is empty, and a Locker backed by Redis for redis:// and rediss:// URLs,
+// e.g. rediss://:password@redis.example.com:6379/0
+func New(rawURL string, timeout, ttl time.Duration) (Locker, error) {
+	if rawURL == "" {
+		return NewMemory(timeout), nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lock URL: %w", err)
+	}
+	switch u.Scheme {
+	case "redis", "rediss":
+		return NewRedis(u, timeout, ttl)
+	default:
+		return nil, fmt.Errorf("invalid lock URL %s, the scheme must be redis or rediss", u.Redacted())
+	}
+}
+
+// Memory is a Locker for uploads handled by the same process
+type Memory struct {
+	timeout time.Duration
+
+	mu sync.Mutex
+	// held contains a channel per locked key, which is closed once the lock is released
+	held map[string]chan struct{}
+}
+
+func (m *Memory) Lock(ctx context.Context, key string) (Unlock, error) {
+	ctx, cancel := withTimeout(ctx, m.timeout)
+	defer cancel()
+
+	for {
+		m.mu.Lock()
+		released, held := m.held[key]
+		if !held {
+			released = make(chan struct{})
+			m.held[key] = released
+			m.mu.Unlock()
+
+			return func() {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				delete(m.held, key)
+				close(released)
+			}, nil
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, lockError(ctx, key)
+		}
+	}
+}
+
+// NewMemory returns a Locker for uploads handled by the same process, which waits up to the timeout for a lock
+func NewMemory(timeout time.Duration) *Memory {
+	return &Memory{
+		timeout: timeout,
+		held:    make(map[string]chan struct{}),
+	}
+}
+
+// withTimeout limits the time waiting for a lock, it's unlimited if the timeout is 0
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// lockError returns core.ErrLocked if the timeout elapsed, and the error of the context if the caller gave up
+func lockError(ctx context.Context, key string) error {
+	if errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s", core.ErrLocked, key)
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,82 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	m := NewMemory(50 * time.Millisecond)
+
+	unlock, err := m.Lock(ctx, "modules/acme/vpc/aws/1.0.0")
+	assert.NoError(err)
+
+	// Other keys aren't affected
+	other, err := m.Lock(ctx, "modules/acme/vpc/aws/1.1.0")
+	assert.NoError(err)
+	other()
+
+	_, err = m.Lock(ctx, "modules/acme/vpc/aws/1.0.0")
+	assert.ErrorIs(err, core.ErrLocked)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = m.Lock(canceled, "modules/acme/vpc/aws/1.0.0")
+	assert.ErrorIs(err, context.Canceled)
+
+	unlock()
+	unlock, err = m.Lock(ctx, "modules/acme/vpc/aws/1.0.0")
+	assert.NoError(err)
+	unlock()
+}
+
+func TestMemory_Wait(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	m := NewMemory(time.Minute)
+
+	unlock, err := m.Lock(ctx, "providers/acme/dns/terraform-provider-dns_1.0.0_SHA256SUMS")
+	assert.NoError(err)
+
+	// Waiting uploads acquire the lock once it's released
+	acquired := make(chan error)
+	go func() {
+		unlock, err := m.Lock(ctx, "providers/acme/dns/terraform-provider-dns_1.0.0_SHA256SUMS")
+		if err == nil {
+			unlock()
+		}
+		acquired <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	unlock()
+	assert.NoError(<-acquired)
+}
+
+func TestNew(t *testing.T) {
+	assert := assertion.New(t)
+
+	l, err := New("", DefaultTimeout, DefaultTTL)
+	assert.NoError(err)
+	assert.IsType(&Memory{}, l)
+
+	l, err = New("rediss://:secret@redis.example.com/2", DefaultTimeout, DefaultTTL)
+	assert.NoError(err)
+	opts := l.(*Redis).client.Options()
+	assert.Equal("redis.example.com:6379", opts.Addr)
+	assert.Equal(2, opts.DB)
+	assert.NotNil(opts.TLSConfig)
+
+	_, err = New("redis://redis.example.com/db", DefaultTimeout, DefaultTTL)
+	assert.Error(err)
+	_, err = New("redis://redis.example.com", DefaultTimeout, 100*time.Millisecond)
+	assert.Error(err)
+	_, err = New("memcached://memcached.example.com", DefaultTimeout, DefaultTTL)
+	assert.Error(err)
+}
@@ -0,0 +1,172 @@
+package lock
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// fakeRedis implements the commands used by the Redis locker, expiration is ignored
+type fakeRedis struct {
+	mu       sync.Mutex
+	keys     map[string]string
+	commands []string
+}
+
+func (f *fakeRedis) serve(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(conn, f.execute(args))
+	}
+}
+
+func (f *fakeRedis) execute(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	command := strings.ToUpper(args[0])
+	f.commands = append(f.commands, command)
+
+	switch command {
+	case "AUTH":
+		if args[len(args)-1] != "secret" {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		if _, ok := f.keys[args[1]]; ok {
+			return "$-1\r\n"
+		}
+		f.keys[args[1]] = args[2]
+		return "+OK\r\n"
+	case "EVALSHA":
+		return "-NOSCRIPT No matching script\r\n"
+	case "EVAL":
+		if f.keys[args[3]] != args[4] {
+			return ":0\r\n"
+		}
+		if args[1] == redisReleaseScript {
+			delete(f.keys, args[3])
+		}
+		return ":1\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func (f *fakeRedis) locked() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for k := range f.keys {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	f := &fakeRedis{keys: make(map[string]string)}
+	addr := f.serve(t)
+
+	u, _ := url.Parse(fmt.Sprintf("redis://:secret@%s/1", addr))
+	r, err := NewRedis(u, 300*time.Millisecond, time.Second)
+	assert.NoError(err)
+
+	unlock, err := r.Lock(ctx, "modules/acme/vpc/aws/1.0.0")
+	assert.NoError(err)
+	assert.Equal([]string{"boring-registry:lock:modules/acme/vpc/aws/1.0.0"}, f.locked())
+
+	// The lock is held by another process until it's released
+	other, err := NewRedis(u, 300*time.Millisecond, time.Second)
+	assert.NoError(err)
+	_, err = other.Lock(ctx, "modules/acme/vpc/aws/1.0.0")
+	assert.ErrorIs(err, core.ErrLocked)
+
+	unlock()
+	assert.Empty(f.locked())
+	unlock, err = other.Lock(ctx, "modules/acme/vpc/aws/1.0.0")
+	assert.NoError(err)
+	unlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Subset(f.commands, []string{"AUTH", "SELECT", "SET", "EVAL"})
+}
+
+func TestRedis_AuthFailed(t *testing.T) {
+	f := &fakeRedis{keys: make(map[string]string)}
+	addr := f.serve(t)
+
+	u, _ := url.Parse(fmt.Sprintf("redis://:wrong@%s", addr))
+	r, err := NewRedis(u, time.Second, time.Second)
+	assertion.NoError(t, err)
+	_, err = r.Lock(context.Background(), "modules/acme/vpc/aws/1.0.0")
+	assertion.ErrorContains(t, err, "WRONGPASS")
+}
@@ -0,0 +1,53 @@
+package login
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type authorizeResponse struct {
+	id string
+}
+
+func authorizeEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AuthorizationRequest)
+
+		a, err := svc.Authorize(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return authorizeResponse{id: a.ID}, nil
+	}
+}
+
+type statusRequest struct {
+	id string
+}
+
+type statusResponse struct {
+	Authorization
+}
+
+func statusEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(statusRequest)
+
+		a, err := svc.Status(ctx, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return statusResponse{Authorization: a}, nil
+	}
+}
+
+func tokenEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(TokenRequest)
+
+		return svc.Token(ctx, req)
+	}
+}
@@ -0,0 +1,15 @@
+package login
+
+import "errors"
+
+// The errors are named after the error codes of the OAuth 2.0 token endpoint, see https://datatracker.ietf.org/doc/html/rfc6749#section-5.2
+var (
+	ErrInvalidRequest          = errors.New("invalid_request")
+	ErrInvalidClient           = errors.New("invalid_client")
+	ErrInvalidGrant            = errors.New("invalid_grant")
+	ErrUnsupportedGrantType    = errors.New("unsupported_grant_type")
+	ErrUnsupportedResponseType = errors.New("unsupported_response_type")
+
+	ErrAuthorizationNotFound = errors.New("authorization not found or expired")
+	ErrAuthorizationFailed   = errors.New("authorization at the identity provider failed")
+)
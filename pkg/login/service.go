@@ -0,0 +1,329 @@
+package login
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// codeExpiry is the duration in which the Terraform CLI has to exchange an authorization code for a token
+	codeExpiry = time.Minute
+
+	// defaultDeviceCodeExpiry is used if the identity provider doesn't return the expiry of the device code
+	defaultDeviceCodeExpiry = 10 * time.Minute
+)
+
+// AuthorizationRequest is sent by the Terraform CLI to the authorization endpoint.
+// See: https://developer.hashicorp.com/terraform/internals/login-protocol
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// TokenRequest is sent by the Terraform CLI to the token endpoint to exchange the authorization code for a token.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	ClientID     string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+// Token is returned by the token endpoint and stored by the Terraform CLI.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// Authorization is an authorization waiting for the user to approve the device code at the identity provider.
+type Authorization struct {
+	ID              string
+	UserCode        string
+	VerificationURI string
+	Interval        time.Duration
+
+	// RedirectURL is set once the user approved the authorization, it passes the authorization code to the Terraform CLI
+	RedirectURL string
+}
+
+// Service implements the server side of the Terraform login protocol.
+type Service interface {
+	// Authorize starts the authorization of the Terraform CLI
+	Authorize(ctx context.Context, req AuthorizationRequest) (Authorization, error)
+
+	// Status returns the authorization, which fails if the user denied the authorization
+	Status(ctx context.Context, id string) (Authorization, error)
+
+	// Token exchanges the authorization code for a token
+	Token(ctx context.Context, req TokenRequest) (Token, error)
+}
+
+type authorization struct {
+	Authorization
+	expiry time.Time
+	err    error
+}
+
+type grant struct {
+	token         string
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	expiry        time.Time
+}
+
+// deviceFlowService authorizes the Terraform CLI with the OAuth 2.0 device authorization grant of the identity provider.
+// The user approves the device code at the identity provider, while the registry polls for the token in the background.
+// Afterward, the browser is redirected to the Terraform CLI, which receives the ID token in exchange for the authorization code.
+// Authorizations are kept in memory, so all requests of a login need to be handled by the same instance.
+type deviceFlowService struct {
+	ctx    context.Context
+	config *oauth2.Config
+	ports  []int
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu             sync.Mutex
+	authorizations map[string]*authorization
+	grants         map[string]*grant
+}
+
+func (s *deviceFlowService) Authorize(ctx context.Context, req AuthorizationRequest) (Authorization, error) {
+	if req.ResponseType != "code" {
+		return Authorization{}, fmt.Errorf("%w: response_type %q", ErrUnsupportedResponseType, req.ResponseType)
+	}
+	if req.ClientID != s.config.ClientID {
+		return Authorization{}, fmt.Errorf("%w: unknown client_id %q", ErrInvalidClient, req.ClientID)
+	}
+	if err := s.validateRedirectURI(req.RedirectURI); err != nil {
+		return Authorization{}, err
+	}
+	if req.CodeChallengeMethod != "S256" || req.CodeChallenge == "" {
+		return Authorization{}, fmt.Errorf("%w: a S256 code_challenge is required", ErrInvalidRequest)
+	}
+
+	da, err := s.config.DeviceAuth(ctx)
+	if err != nil {
+		return Authorization{}, fmt.Errorf("failed to request device code: %w", err)
+	}
+
+	id, err := randomString()
+	if err != nil {
+		return Authorization{}, err
+	}
+
+	verificationURI := da.VerificationURIComplete
+	if verificationURI == "" {
+		verificationURI = da.VerificationURI
+	}
+	expiry := da.Expiry
+	if expiry.IsZero() {
+		expiry = s.now().Add(defaultDeviceCodeExpiry)
+		da.Expiry = expiry
+	}
+
+	a := &authorization{
+		Authorization: Authorization{
+			ID:              id,
+			UserCode:        da.UserCode,
+			VerificationURI: verificationURI,
+			Interval:        time.Duration(da.Interval) * time.Second,
+		},
+		expiry: expiry,
+	}
+
+	s.mu.Lock()
+	s.removeExpired()
+	s.authorizations[id] = a
+	s.mu.Unlock()
+
+	go s.poll(a, da, req)
+
+	return a.Authorization, nil
+}
+
+// poll waits for the user to approve the device code and issues an authorization code for the Terraform CLI
+func (s *deviceFlowService) poll(a *authorization, da *oauth2.DeviceAuthResponse, req AuthorizationRequest) {
+	token, err := s.config.DeviceAccessToken(s.ctx, da)
+	if err != nil {
+		s.logger.Info("login failed", slog.String("error", err.Error()))
+		s.fail(a, err)
+		return
+	}
+
+	// The ID token is returned to the Terraform CLI, as the OIDC auth provider verifies ID tokens
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		s.fail(a, fmt.Errorf("the identity provider didn't return an ID token, the openid scope may be missing"))
+		return
+	}
+
+	code, err := randomString()
+	if err != nil {
+		s.fail(a, err)
+		return
+	}
+
+	redirectURL, _ := url.Parse(req.RedirectURI) // validated in Authorize
+	query := redirectURL.Query()
+	query.Set("code", code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	redirectURL.RawQuery = query.Encode()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants[code] = &grant{
+		token:         idToken,
+		clientID:      req.ClientID,
+		redirectURI:   req.RedirectURI,
+		codeChallenge: req.CodeChallenge,
+		expiry:        s.now().Add(codeExpiry),
+	}
+	a.RedirectURL = redirectURL.String()
+}
+
+func (s *deviceFlowService) fail(a *authorization, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.err = fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+}
+
+func (s *deviceFlowService) Status(ctx context.Context, id string) (Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.authorizations[id]
+	if !ok || s.now().After(a.expiry) {
+		return Authorization{}, ErrAuthorizationNotFound
+	}
+	if a.err != nil {
+		return Authorization{}, a.err
+	}
+
+	return a.Authorization, nil
+}
+
+func (s *deviceFlowService) Token(ctx context.Context, req TokenRequest) (Token, error) {
+	if req.GrantType != "authorization_code" {
+		return Token{}, fmt.Errorf("%w: grant_type %q", ErrUnsupportedGrantType, req.GrantType)
+	}
+
+	s.mu.Lock()
+	g, ok := s.grants[req.Code]
+	// Authorization codes can only be used once
+	delete(s.grants, req.Code)
+	s.mu.Unlock()
+
+	if !ok || s.now().After(g.expiry) {
+		return Token{}, fmt.Errorf("%w: the authorization code is invalid or expired", ErrInvalidGrant)
+	}
+	if req.ClientID != g.clientID {
+		return Token{}, fmt.Errorf("%w: unknown client_id %q", ErrInvalidClient, req.ClientID)
+	}
+	if req.RedirectURI != g.redirectURI {
+		return Token{}, fmt.Errorf("%w: redirect_uri doesn't match the authorization request", ErrInvalidGrant)
+	}
+
+	challenge := sha256.Sum256([]byte(req.CodeVerifier))
+	if subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(challenge[:])), []byte(g.codeChallenge)) != 1 {
+		return Token{}, fmt.Errorf("%w: code_verifier doesn't match the code_challenge", ErrInvalidGrant)
+	}
+
+	return Token{
+		AccessToken: g.token,
+		TokenType:   "Bearer",
+	}, nil
+}
+
+// validateRedirectURI ensures that the authorization code is only passed to the Terraform CLI on the local machine
+func (s *deviceFlowService) validateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Scheme != "http" {
+		return fmt.Errorf("%w: redirect_uri must be a http URL", ErrInvalidRequest)
+	}
+
+	if host := u.Hostname(); host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return fmt.Errorf("%w: redirect_uri must point to the loopback interface", ErrInvalidRequest)
+		}
+	}
+
+	if len(s.ports) == 2 {
+		port, err := strconv.Atoi(u.Port())
+		if err != nil || port < s.ports[0] || port > s.ports[1] {
+			return fmt.Errorf("%w: the port of the redirect_uri must be in the range of %d to %d", ErrInvalidRequest, s.ports[0], s.ports[1])
+		}
+	}
+
+	return nil
+}
+
+// removeExpired removes authorizations and grants which can't be used anymore, the caller has to hold the lock
+func (s *deviceFlowService) removeExpired() {
+	now := s.now()
+	for id, a := range s.authorizations {
+		if now.After(a.expiry) {
+			delete(s.authorizations, id)
+		}
+	}
+	for code, g := range s.grants {
+		if now.After(g.expiry) {
+			delete(s.grants, code)
+		}
+	}
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Option provides additional options for the Service.
+type Option func(*deviceFlowService)
+
+// WithPorts restricts the ports of the redirect URI to the inclusive range advertised in the service discovery.
+func WithPorts(ports []int) Option {
+	return func(s *deviceFlowService) {
+		s.ports = ports
+	}
+}
+
+// NewDeviceFlowService returns a Service authorizing the Terraform CLI with the device authorization grant of the identity provider.
+// The context bounds the polling for tokens at the identity provider.
+func NewDeviceFlowService(ctx context.Context, config *oauth2.Config, options ...Option) Service {
+	s := &deviceFlowService{
+		ctx:            ctx,
+		config:         config,
+		logger:         slog.Default(),
+		now:            time.Now,
+		authorizations: make(map[string]*authorization),
+		grants:         make(map[string]*grant),
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
@@ -0,0 +1,205 @@
+package login
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+type nopInstrumentation struct{}
+
+func (nopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+// newIdentityProvider returns an identity provider approving every device code on the first poll
+func newIdentityProvider(t *testing.T, idToken string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": "https://idp.example.com/device",
+			"expires_in":       60,
+			"interval":         1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		token := map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+		}
+		if idToken != "" {
+			token["id_token"] = idToken
+		}
+		_ = json.NewEncoder(w).Encode(token)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestService(idp *httptest.Server) Service {
+	return NewDeviceFlowService(context.Background(), &oauth2.Config{
+		ClientID: "boring-registry",
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: idp.URL + "/device",
+			TokenURL:      idp.URL + "/token",
+		},
+	}, WithPorts([]int{10000, 10010}))
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestLogin(t *testing.T) {
+	registry := httptest.NewServer(http.StripPrefix("/v1/login", MakeHandler(newTestService(newIdentityProvider(t, "id-token")), nopInstrumentation{})))
+	defer registry.Close()
+
+	client := registry.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"boring-registry"},
+		"redirect_uri":          {"http://localhost:10000/login"},
+		"state":                 {"state"},
+		"code_challenge":        {codeChallenge("verifier")},
+		"code_challenge_method": {"S256"},
+	}
+	resp, err := client.Get(registry.URL + "/v1/login/authorization?" + query.Encode())
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+
+	location, err := resp.Location()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(location.Path, "/v1/login/authorization/"))
+
+	// The authorization page is shown until the identity provider issued the token
+	var redirect *url.URL
+	assert.Eventually(t, func() bool {
+		resp, err := client.Get(location.String())
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusFound {
+			return false
+		}
+		redirect, err = resp.Location()
+		return err == nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.Equal(t, "localhost:10000", redirect.Host)
+	assert.Equal(t, "state", redirect.Query().Get("state"))
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {redirect.Query().Get("code")},
+		"client_id":     {"boring-registry"},
+		"redirect_uri":  {"http://localhost:10000/login"},
+		"code_verifier": {"verifier"},
+	}
+	resp, err = client.PostForm(registry.URL+"/v1/login/token", form)
+	assert.NoError(t, err)
+	var token Token
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&token))
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, Token{AccessToken: "id-token", TokenType: "Bearer"}, token)
+
+	// Authorization codes can only be used once
+	resp, err = client.PostForm(registry.URL+"/v1/login/token", form)
+	assert.NoError(t, err)
+	var oauthErr struct {
+		Error string `json:"error"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&oauthErr))
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_grant", oauthErr.Error)
+}
+
+func TestDeviceFlowService_Authorize(t *testing.T) {
+	svc := newTestService(newIdentityProvider(t, "id-token"))
+	valid := AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "boring-registry",
+		RedirectURI:         "http://127.0.0.1:10010/login",
+		CodeChallenge:       codeChallenge("verifier"),
+		CodeChallengeMethod: "S256",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *AuthorizationRequest)
+		err    error
+	}{
+		{name: "valid", modify: func(r *AuthorizationRequest) {}},
+		{name: "unsupported response type", modify: func(r *AuthorizationRequest) { r.ResponseType = "token" }, err: ErrUnsupportedResponseType},
+		{name: "unknown client", modify: func(r *AuthorizationRequest) { r.ClientID = "other" }, err: ErrInvalidClient},
+		{name: "remote redirect uri", modify: func(r *AuthorizationRequest) { r.RedirectURI = "http://evil.example.com:10000/login" }, err: ErrInvalidRequest},
+		{name: "port outside of range", modify: func(r *AuthorizationRequest) { r.RedirectURI = "http://localhost:8080/login" }, err: ErrInvalidRequest},
+		{name: "plain code challenge", modify: func(r *AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, err: ErrInvalidRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid
+			tc.modify(&req)
+
+			a, err := svc.Authorize(context.Background(), req)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "ABCD-EFGH", a.UserCode)
+			assert.Equal(t, "https://idp.example.com/device", a.VerificationURI)
+		})
+	}
+}
+
+func TestDeviceFlowService_MissingIDToken(t *testing.T) {
+	svc := newTestService(newIdentityProvider(t, ""))
+
+	a, err := svc.Authorize(context.Background(), AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "boring-registry",
+		RedirectURI:         "http://localhost:10000/login",
+		CodeChallenge:       codeChallenge("verifier"),
+		CodeChallengeMethod: "S256",
+	})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := svc.Status(context.Background(), a.ID)
+		return err != nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	_, err = svc.Status(context.Background(), a.ID)
+	assert.ErrorIs(t, err, ErrAuthorizationFailed)
+	assert.ErrorContains(t, err, "ID token")
+
+	_, err = svc.Status(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrAuthorizationNotFound)
+}
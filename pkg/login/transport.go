@@ -0,0 +1,228 @@
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type muxVar string
+
+const (
+	varID muxVar = "id"
+)
+
+// defaultRefreshInterval is used to refresh the authorization page if the identity provider doesn't specify a polling interval
+const defaultRefreshInterval = 5
+
+var authorizationPage = template.Must(template.New("authorization").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>boring-registry login</title>
+</head>
+<body>
+<h1>Log in to the boring-registry</h1>
+<p>Open <a href="{{.VerificationURI}}" target="_blank" rel="noopener">{{.VerificationURI}}</a> and confirm the code <strong>{{.UserCode}}</strong>.</p>
+<p>This page continues automatically once the login is confirmed.</p>
+</body>
+</html>
+`))
+
+// MakeHandler returns a fully initialized http.Handler for the authorization and token endpoints of the Terraform login protocol.
+// The endpoints are not protected by authentication, as they are used to obtain a token in the first place.
+func MakeHandler(svc Service, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/authorization`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				authorizeEndpoint(svc),
+				decodeAuthorizationRequest,
+				encodeAuthorizeResponse,
+				append(options, httptransport.ServerErrorEncoder(ErrorEncoder))...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/authorization/{id}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				statusEndpoint(svc),
+				decodeStatusRequest,
+				encodeStatusResponse,
+				append(
+					options,
+					httptransport.ServerErrorEncoder(ErrorEncoder),
+					httptransport.ServerBefore(extractMuxVars(varID)),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("POST").Path(`/token`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				tokenEndpoint(svc),
+				decodeTokenRequest,
+				encodeTokenResponse,
+				append(options, httptransport.ServerErrorEncoder(TokenErrorEncoder))...,
+			),
+		),
+	)
+
+	return r
+}
+
+func decodeAuthorizationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	return AuthorizationRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		State:               q.Get("state"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	}, nil
+}
+
+func decodeStatusRequest(ctx context.Context, _ *http.Request) (interface{}, error) {
+	id, ok := ctx.Value(varID).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: id", core.ErrVarMissing)
+	}
+
+	return statusRequest{id: id}, nil
+}
+
+func decodeTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	return TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		ClientID:     r.PostForm.Get("client_id"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+	}, nil
+}
+
+// encodeAuthorizeResponse redirects the browser to the page of the authorization, so that refreshing the page doesn't start a new authorization
+func encodeAuthorizeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(authorizeResponse)
+
+	// The location is relative to the authorization endpoint, as the prefix of the handler isn't known
+	w.Header().Set("Location", "authorization/"+res.id)
+	w.WriteHeader(http.StatusSeeOther)
+	return nil
+}
+
+// encodeStatusResponse shows the user code until the user approved the authorization, and then redirects the browser to the Terraform CLI
+func encodeStatusResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(statusResponse)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if res.RedirectURL != "" {
+		w.Header().Set("Location", res.RedirectURL)
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	refresh := int(res.Interval.Seconds())
+	if refresh <= 0 {
+		refresh = defaultRefreshInterval
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return authorizationPage.Execute(w, struct {
+		Refresh         int
+		VerificationURI string
+		UserCode        string
+	}{
+		Refresh:         refresh,
+		VerificationURI: res.VerificationURI,
+		UserCode:        res.UserCode,
+	})
+}
+
+func encodeTokenResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	return json.NewEncoder(w).Encode(response)
+}
+
+// ErrorEncoder translates domain specific errors of the authorization endpoints to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	statusCode := core.GenericError(err)
+	if errors.Is(err, ErrAuthorizationNotFound) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, ErrAuthorizationFailed) {
+		statusCode = http.StatusForbidden
+	} else if oauthError(err) != "" {
+		statusCode = http.StatusBadRequest
+	}
+
+	core.HandleErrorResponse(err, statusCode, w)
+}
+
+// TokenErrorEncoder writes the error response of the token endpoint as specified by OAuth 2.0.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-5.2
+func TokenErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	code := oauthError(err)
+	if code == "" {
+		code = "server_error"
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if errors.Is(err, ErrInvalidClient) {
+		w.WriteHeader(http.StatusUnauthorized)
+	} else if code == "server_error" {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}{
+		Error:            code,
+		ErrorDescription: strings.TrimPrefix(strings.TrimPrefix(err.Error(), code), ": "),
+	})
+}
+
+// oauthError returns the OAuth 2.0 error code of the error, or an empty string if it doesn't have one
+func oauthError(err error) string {
+	for _, e := range []error{ErrInvalidRequest, ErrInvalidClient, ErrInvalidGrant, ErrUnsupportedGrantType, ErrUnsupportedResponseType} {
+		if errors.Is(err, e) {
+			return e.Error()
+		}
+	}
+	return ""
+}
+
+func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, k := range keys {
+			if v, ok := mux.Vars(r)[string(k)]; ok {
+				ctx = context.WithValue(ctx, k, v)
+			}
+		}
+
+		return ctx
+	}
+}
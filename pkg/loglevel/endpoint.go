@@ -0,0 +1,50 @@
+package loglevel
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// setRequest changes the default level without a component, and resets the level of the component without a level
+type setRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
+
+func statusEndpoint(levels *Levels) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return levels.Status(), nil
+	}
+}
+
+func setEndpoint(levels *Levels) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setRequest)
+
+		if req.Component != "" && req.Level == "" {
+			levels.ResetComponent(req.Component)
+			slog.Info("reset log level", slog.String("scope", req.Component))
+			return levels.Status(), nil
+		}
+
+		level, err := ParseLevel(req.Level)
+		if err != nil {
+			return nil, err
+		}
+		if req.Component == "" {
+			levels.SetLevel(level)
+		} else {
+			levels.SetComponent(req.Component, level)
+		}
+		// The component isn't logged as ComponentKey, which would filter the record by its level
+		scope := req.Component
+		if scope == "" {
+			scope = "default"
+		}
+		slog.Info("changed log level", slog.String("scope", scope), slog.String("level", req.Level))
+
+		return levels.Status(), nil
+	}
+}
@@ -0,0 +1,197 @@
+package loglevel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// ComponentKey is the attribute with which loggers are tagged with their component, e.g. storage or scheduler
+const ComponentKey = "component"
+
+// Status describes the default log level and the levels overriding it per component
+type Status struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// Levels holds the default log level and the levels of single components, which can be changed at runtime
+type Levels struct {
+	mu         sync.RWMutex
+	level      slog.Level
+	components map[string]slog.Level
+}
+
+// NewLevels returns the given default level without component levels
+func NewLevels(level slog.Level) *Levels {
+	return &Levels{
+		level:      level,
+		components: make(map[string]slog.Level),
+	}
+}
+
+// Level returns the level of the component, or the default level if the component has none
+func (l *Levels) Level(component string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if level, ok := l.components[component]; ok {
+		return level
+	}
+	return l.level
+}
+
+// min returns the lowest level of all components, which is the lowest level any record can be logged with
+func (l *Levels) min() slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	level := l.level
+	for _, c := range l.components {
+		level = min(level, c)
+	}
+	return level
+}
+
+// SetLevel changes the default level, the levels of components are kept
+func (l *Levels) SetLevel(level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// SetComponent overrides the default level for the component
+func (l *Levels) SetComponent(component string, level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components[component] = level
+}
+
+// ResetComponent removes the level of the component, so that it uses the default level again
+func (l *Levels) ResetComponent(component string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.components, component)
+}
+
+// Reset replaces the default level and all levels of components
+func (l *Levels) Reset(level slog.Level, components map[string]slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.level = level
+	l.components = make(map[string]slog.Level, len(components))
+	for component, level := range components {
+		l.components[component] = level
+	}
+}
+
+// Status returns the current levels
+func (l *Levels) Status() Status {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	status := Status{
+		Level:      strings.ToLower(l.level.String()),
+		Components: make(map[string]string, len(l.components)),
+	}
+	for component, level := range l.components {
+		status.Components[component] = strings.ToLower(level.String())
+	}
+	return status
+}
+
+// ParseLevel parses a level like debug, info, warn, or error, case-insensitively
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("%w: invalid log level %q", core.ErrVarType, s)
+	}
+	return level, nil
+}
+
+// ParseComponents parses levels of components in the form component=level
+func ParseComponents(values []string) (map[string]slog.Level, error) {
+	components := make(map[string]slog.Level, len(values))
+	for _, value := range values {
+		component, s, ok := strings.Cut(value, "=")
+		if !ok || component == "" {
+			return nil, fmt.Errorf("%w: expected a component level in the form component=level, got %q", core.ErrVarType, value)
+		}
+
+		level, err := ParseLevel(s)
+		if err != nil {
+			return nil, err
+		}
+		components[component] = level
+	}
+	return components, nil
+}
+
+// Handler filters records by the level of their component before passing them to the wrapped handler.
+// The component is taken from the attribute ComponentKey, either added to the logger or to the record itself.
+type Handler struct {
+	inner     slog.Handler
+	levels    *Levels
+	component string
+	grouped   bool
+}
+
+// NewHandler wraps the handler, which should enable all levels, as the filtering is done by the levels
+func NewHandler(inner slog.Handler, levels *Levels) *Handler {
+	return &Handler{
+		inner:  inner,
+		levels: levels,
+	}
+}
+
+// Enabled reports whether the level is enabled for the component of the logger.
+// Without a component, the lowest level of all components is used, as the record might still set one.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.component != "" {
+		return level >= h.levels.Level(h.component) && h.inner.Enabled(ctx, level)
+	}
+	return level >= h.levels.min() && h.inner.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	component := h.component
+	if component == "" {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == ComponentKey {
+				component = a.Value.String()
+				return false
+			}
+			return true
+		})
+	}
+
+	if r.Level < h.levels.Level(component) {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.inner = h.inner.WithAttrs(attrs)
+	if !h.grouped {
+		for _, a := range attrs {
+			if a.Key == ComponentKey {
+				handler.component = a.Value.String()
+			}
+		}
+	}
+	return &handler
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	handler := *h
+	handler.inner = h.inner.WithGroup(name)
+	handler.grouped = true
+	return &handler
+}
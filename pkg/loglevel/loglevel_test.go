@@ -0,0 +1,104 @@
+package loglevel
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	assert := assertion.New(t)
+
+	var buf bytes.Buffer
+	levels := NewLevels(slog.LevelInfo)
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), levels))
+	storage := logger.With(slog.String(ComponentKey, "storage"))
+	logged := func(log func()) bool {
+		buf.Reset()
+		log()
+		return buf.Len() > 0
+	}
+
+	assert.False(logged(func() { logger.Debug("default") }))
+	assert.False(logged(func() { storage.Debug("storage") }))
+	assert.True(logged(func() { storage.Info("storage") }))
+
+	levels.SetComponent("storage", slog.LevelDebug)
+	assert.False(logged(func() { logger.Debug("default") }))
+	assert.True(logged(func() { storage.Debug("storage") }))
+	assert.True(logged(func() { logger.Debug("inline", slog.String(ComponentKey, "storage")) }))
+	assert.False(logged(func() { logger.Debug("inline", slog.String(ComponentKey, "scheduler")) }))
+
+	// A component attribute within a group isn't the component of the logger
+	assert.False(logged(func() { logger.WithGroup("request").With(slog.String(ComponentKey, "storage")).Debug("grouped") }))
+
+	levels.SetComponent("storage", slog.LevelError)
+	assert.False(logged(func() { storage.Warn("storage") }))
+	assert.True(logged(func() { logger.Warn("default") }))
+
+	levels.ResetComponent("storage")
+	assert.True(logged(func() { storage.Warn("storage") }))
+
+	levels.Reset(slog.LevelDebug, nil)
+	assert.True(logged(func() { logger.Debug("default") }))
+}
+
+func TestParseComponents(t *testing.T) {
+	assert := assertion.New(t)
+
+	components, err := ParseComponents([]string{"storage=debug", "scheduler=WARN"})
+	assert.NoError(err)
+	assert.Equal(map[string]slog.Level{"storage": slog.LevelDebug, "scheduler": slog.LevelWarn}, components)
+
+	for _, value := range []string{"storage", "=debug", "storage=verbose"} {
+		_, err = ParseComponents([]string{value})
+		assert.Error(err, value)
+	}
+}
+
+type nopInstrumentation struct{}
+
+func (nopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+func TestMakeHandler(t *testing.T) {
+	assert := assertion.New(t)
+
+	levels := NewLevels(slog.LevelInfo)
+	nop := func(e endpoint.Endpoint) endpoint.Endpoint { return e }
+	handler := MakeHandler(levels, nop, nopInstrumentation{}, httptransport.ServerErrorEncoder(ErrorEncoder))
+
+	request := func(method, body string, code int) Status {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/log-level", strings.NewReader(body)))
+		assert.Equal(code, rec.Code)
+
+		var status Status
+		_ = json.NewDecoder(rec.Body).Decode(&status)
+		return status
+	}
+
+	assert.Equal(Status{Level: "info", Components: map[string]string{}}, request(http.MethodGet, "", http.StatusOK))
+
+	status := request(http.MethodPut, `{"component": "storage", "level": "debug"}`, http.StatusOK)
+	assert.Equal(map[string]string{"storage": "debug"}, status.Components)
+	assert.Equal(slog.LevelDebug, levels.Level("storage"))
+
+	status = request(http.MethodPut, `{"level": "warn"}`, http.StatusOK)
+	assert.Equal("warn", status.Level)
+
+	status = request(http.MethodPut, `{"component": "storage"}`, http.StatusOK)
+	assert.Empty(status.Components)
+
+	request(http.MethodPut, `{"level": "verbose"}`, http.StatusBadRequest)
+	request(http.MethodPut, `{}`, http.StatusBadRequest)
+}
@@ -0,0 +1,65 @@
+package loglevel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a fully initialized http.Handler for the log level API.
+func MakeHandler(levels *Levels, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/log-level`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(statusEndpoint(levels)),
+				httptransport.NopRequestDecoder,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("PUT").Path(`/log-level`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(setEndpoint(levels)),
+				decodeSetRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+func decodeSetRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req setRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %s", core.ErrVarType, err)
+	}
+
+	return req, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	core.HandleErrorResponse(err, core.GenericError(err), w)
+}
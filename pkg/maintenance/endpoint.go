@@ -0,0 +1,40 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type statusResponse struct {
+	Status
+}
+
+type enableRequest struct {
+	Reason string `json:"reason"`
+}
+
+func statusEndpoint(m *Mode) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return statusResponse{m.Status()}, nil
+	}
+}
+
+func enableEndpoint(m *Mode) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enableRequest)
+
+		status := m.Enable(req.Reason)
+		slog.Warn("maintenance mode enabled", slog.String("reason", status.Reason))
+		return statusResponse{status}, nil
+	}
+}
+
+func disableEndpoint(m *Mode) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		status := m.Disable()
+		slog.Info("maintenance mode disabled")
+		return statusResponse{status}, nil
+	}
+}
@@ -0,0 +1,8 @@
+package maintenance
+
+import "errors"
+
+var (
+	// ErrMaintenance is returned to new clients in maintenance mode
+	ErrMaintenance = errors.New("the registry is in maintenance mode, retry later")
+)
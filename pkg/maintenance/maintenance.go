@@ -0,0 +1,89 @@
+package maintenance
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Status describes whether the registry is in maintenance mode
+type Status struct {
+	Enabled bool       `json:"enabled"`
+	Since   *time.Time `json:"since,omitempty"`
+	Reason  string     `json:"reason,omitempty"`
+}
+
+// Mode is the maintenance mode of the process, which is shared by all tenants.
+// In maintenance mode, the process reports not to be ready and rejects new service discovery requests,
+// while clients which discovered the registry before can still finish their downloads.
+type Mode struct {
+	mu     sync.RWMutex
+	status Status
+
+	retryAfter time.Duration
+	now        func() time.Time
+}
+
+// NewMode returns a disabled maintenance mode, rejected clients are asked to retry after the given duration
+func NewMode(retryAfter time.Duration) *Mode {
+	return &Mode{
+		retryAfter: retryAfter,
+		now:        time.Now,
+	}
+}
+
+// Enable switches to maintenance mode, the time and reason of an enabled maintenance mode are kept
+func (m *Mode) Enable(reason string) Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.status.Enabled {
+		since := m.now().UTC()
+		m.status = Status{Enabled: true, Since: &since, Reason: reason}
+	}
+	return m.status
+}
+
+// Disable leaves maintenance mode and clears its time and reason
+func (m *Mode) Disable() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.status = Status{}
+	return m.status
+}
+
+// Status returns whether maintenance mode is enabled, and since when and why it is
+func (m *Mode) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+// WrapDiscovery rejects service discovery requests with 503 Service Unavailable and a Retry-After header in maintenance mode
+func (m *Mode) WrapDiscovery(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Status().Enabled {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(m.retryAfter.Seconds())))
+		core.HandleErrorResponse(ErrMaintenance, http.StatusServiceUnavailable, w)
+	})
+}
+
+// ReadinessHandler reports whether the process is ready to receive new clients, which it isn't in maintenance mode
+func (m *Mode) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.Status().Enabled {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
@@ -0,0 +1,89 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestMode(t *testing.T) {
+	assert := assertion.New(t)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMode(time.Minute)
+	m.now = func() time.Time { return now }
+
+	discovery := m.WrapDiscovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"modules.v1":"/v1/modules/"}`))
+	}))
+	serve := func(handler http.Handler) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec
+	}
+
+	assert.Equal(http.StatusOK, serve(discovery).Code)
+	assert.Equal(http.StatusOK, serve(m.ReadinessHandler()).Code)
+
+	status := m.Enable("cutover to blue")
+	assert.True(status.Enabled)
+	assert.Equal(now, *status.Since)
+
+	rec := serve(discovery)
+	assert.Equal(http.StatusServiceUnavailable, rec.Code)
+	assert.Equal("60", rec.Header().Get("Retry-After"))
+	assert.Contains(rec.Body.String(), "maintenance mode")
+	assert.Equal(http.StatusServiceUnavailable, serve(m.ReadinessHandler()).Code)
+
+	// Enabling the maintenance mode again keeps its start and reason
+	now = now.Add(time.Hour)
+	status = m.Enable("other")
+	assert.Equal("cutover to blue", status.Reason)
+	assert.Equal(now.Add(-time.Hour), *status.Since)
+
+	assert.Equal(Status{}, m.Disable())
+	assert.Equal(http.StatusOK, serve(discovery).Code)
+	assert.Equal(http.StatusOK, serve(m.ReadinessHandler()).Code)
+}
+
+type nopInstrumentation struct{}
+
+func (nopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+func TestMakeHandler(t *testing.T) {
+	assert := assertion.New(t)
+
+	m := NewMode(time.Minute)
+	nop := func(e endpoint.Endpoint) endpoint.Endpoint { return e }
+	handler := MakeHandler(m, nop, nopInstrumentation{})
+
+	request := func(method, body string) Status {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/maintenance", strings.NewReader(body)))
+		assert.Equal(http.StatusOK, rec.Code)
+
+		var status Status
+		assert.NoError(json.NewDecoder(rec.Body).Decode(&status))
+		return status
+	}
+
+	assert.False(request(http.MethodGet, "").Enabled)
+
+	status := request(http.MethodPut, `{"reason": "cutover"}`)
+	assert.True(status.Enabled)
+	assert.Equal("cutover", status.Reason)
+	assert.True(request(http.MethodGet, "").Enabled)
+
+	assert.False(request(http.MethodDelete, "").Enabled)
+
+	// The reason is optional
+	assert.True(request(http.MethodPut, "").Enabled)
+}
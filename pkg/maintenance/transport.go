@@ -0,0 +1,82 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a fully initialized http.Handler for the maintenance mode API.
+func MakeHandler(m *Mode, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/maintenance`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(statusEndpoint(m)),
+				httptransport.NopRequestDecoder,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("PUT").Path(`/maintenance`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(enableEndpoint(m)),
+				decodeEnableRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("DELETE").Path(`/maintenance`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(disableEndpoint(m)),
+				httptransport.NopRequestDecoder,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+// decodeEnableRequest decodes the optional reason of the maintenance, the body may be empty
+func decodeEnableRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req enableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %s", core.ErrVarType, err)
+	}
+
+	return req, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	core.HandleErrorResponse(err, core.GenericError(err), w)
+}
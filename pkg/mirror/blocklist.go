@@ -0,0 +1,238 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v3"
+)
+
+// BlockedRelease denies provider releases which were recalled or are known to be vulnerable.
+// Releases are blocked by a version constraint, by the SHA-256 checksums of their archives, or both.
+type BlockedRelease struct {
+	// Hostname of the upstream registry, the entry applies to all hostnames if it is empty
+	Hostname  string `yaml:"hostname"`
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+
+	// Versions is the version constraint of the blocked versions, e.g. "= 5.31.0" or ">= 5.30.0, < 5.31.1"
+	Versions string `yaml:"versions"`
+
+	// Checksums are the hex-encoded SHA-256 checksums of blocked archives, with or without the zh: prefix
+	Checksums []string `yaml:"checksums"`
+
+	// Reason is included in the error returned to Terraform, e.g. the CVE
+	Reason string `yaml:"reason"`
+
+	// Advisory is a URL with details on why the release is blocked
+	Advisory string `yaml:"advisory"`
+
+	constraints version.Constraints
+}
+
+func (b *BlockedRelease) covers(provider *core.Provider) bool {
+	return (b.Hostname == "" || b.Hostname == provider.Hostname) && b.Namespace == provider.Namespace && b.Name == provider.Name
+}
+
+func (b *BlockedRelease) blocksVersion(provider *core.Provider) bool {
+	if !b.covers(provider) || b.constraints == nil {
+		return false
+	}
+	parsed, err := version.NewVersion(provider.Version)
+	if err != nil {
+		return false
+	}
+	return b.constraints.Check(parsed)
+}
+
+func (b *BlockedRelease) blocksChecksum(provider *core.Provider, checksum string) bool {
+	return b.covers(provider) && checksum != "" && slices.Contains(b.Checksums, normalizeChecksum(checksum))
+}
+
+// err returns a descriptive error pointing to the advisory, which is displayed by Terraform
+func (b *BlockedRelease) err(provider *core.Provider) error {
+	msg := fmt.Sprintf("%s/%s/%s %s", provider.Hostname, provider.Namespace, provider.Name, provider.Version)
+	if b.Reason != "" {
+		msg = fmt.Sprintf("%s: %s", msg, b.Reason)
+	}
+	if b.Advisory != "" {
+		msg = fmt.Sprintf("%s, see %s", msg, b.Advisory)
+	}
+	return fmt.Errorf("%w: %s", ErrReleaseBlocked, msg)
+}
+
+func (b *BlockedRelease) validate() error {
+	if b.Namespace == "" || b.Name == "" {
+		return errors.New("namespace and name are required")
+	}
+	if b.Versions == "" && len(b.Checksums) == 0 {
+		return errors.New("versions or checksums are required")
+	}
+	for i, c := range b.Checksums {
+		b.Checksums[i] = normalizeChecksum(c)
+	}
+	if b.Versions == "" {
+		return nil
+	}
+
+	constraints, err := version.NewConstraint(b.Versions)
+	if err != nil {
+		return fmt.Errorf("invalid versions: %w", err)
+	}
+	b.constraints = constraints
+
+	return nil
+}
+
+func normalizeChecksum(checksum string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(checksum), "zh:"))
+}
+
+// Blocklist is the list of releases which are never served by the network mirror nor mirrored from upstream
+type Blocklist struct {
+	Releases []BlockedRelease `yaml:"releases"`
+}
+
+// LoadBlocklist reads the blocklist from a YAML or JSON file
+func LoadBlocklist(path string) (*Blocklist, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocklist file: %w", err)
+	}
+
+	return ParseBlocklist(b)
+}
+
+// ParseBlocklist parses and validates the blocklist from YAML or JSON
+func ParseBlocklist(b []byte) (*Blocklist, error) {
+	var l Blocklist
+	if err := yaml.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse blocklist: %w", err)
+	}
+
+	for i := range l.Releases {
+		if err := l.Releases[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid blocked release %d: %w", i, err)
+		}
+	}
+
+	return &l, nil
+}
+
+// CheckVersion returns an error wrapping ErrReleaseBlocked if the version of the provider is blocked
+func (l *Blocklist) CheckVersion(provider *core.Provider) error {
+	for i := range l.Releases {
+		if l.Releases[i].blocksVersion(provider) {
+			return l.Releases[i].err(provider)
+		}
+	}
+	return nil
+}
+
+// CheckChecksum returns an error wrapping ErrReleaseBlocked if the archive of the provider with the checksum is blocked
+func (l *Blocklist) CheckChecksum(provider *core.Provider, checksum string) error {
+	for i := range l.Releases {
+		if l.Releases[i].blocksChecksum(provider, checksum) {
+			return l.Releases[i].err(provider)
+		}
+	}
+	return nil
+}
+
+// blocksChecksums returns true if any archive of the provider is blocked by its checksum
+func (l *Blocklist) blocksChecksums(provider *core.Provider) bool {
+	for i := range l.Releases {
+		if l.Releases[i].covers(provider) && len(l.Releases[i].Checksums) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type blocklistMiddleware struct {
+	next      Service
+	blocklist *Blocklist
+}
+
+// ListProviderVersions omits the blocked versions, so that Terraform selects another version matching its constraints
+func (mw blocklistMiddleware) ListProviderVersions(ctx context.Context, provider *core.Provider) (*ListProviderVersionsResponse, error) {
+	res, err := mw.next.ListProviderVersions(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	for v := range res.Versions {
+		clone := provider.Clone()
+		clone.Version = v
+		if mw.blocklist.CheckVersion(clone) != nil {
+			delete(res.Versions, v)
+		}
+	}
+	return res, nil
+}
+
+// ListProviderInstallation fails for blocked versions and omits the archives which are blocked by their checksum
+func (mw blocklistMiddleware) ListProviderInstallation(ctx context.Context, provider *core.Provider) (*ListProviderInstallationResponse, error) {
+	if err := mw.blocklist.CheckVersion(provider); err != nil {
+		return nil, err
+	}
+
+	res, err := mw.next.ListProviderInstallation(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked error
+	for platform, archive := range res.Archives {
+		for _, hash := range archive.Hashes {
+			if err := mw.blocklist.CheckChecksum(provider, hash); err != nil {
+				blocked = err
+				delete(res.Archives, platform)
+				break
+			}
+		}
+	}
+	if len(res.Archives) == 0 && blocked != nil {
+		return nil, blocked
+	}
+	return res, nil
+}
+
+// RetrieveProviderArchive fails for blocked versions and archives blocked by their checksum.
+// The checksum is looked up in the installation packages, which is only necessary if checksums of the provider are blocked.
+func (mw blocklistMiddleware) RetrieveProviderArchive(ctx context.Context, provider *core.Provider) (*retrieveProviderArchiveResponse, error) {
+	if err := mw.blocklist.CheckVersion(provider); err != nil {
+		return nil, err
+	}
+
+	if mw.blocklist.blocksChecksums(provider) {
+		installation, err := mw.next.ListProviderInstallation(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range installation.Archives[fmt.Sprintf("%s_%s", provider.OS, provider.Arch)].Hashes {
+			if err := mw.blocklist.CheckChecksum(provider, hash); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return mw.next.RetrieveProviderArchive(ctx, provider)
+}
+
+// BlocklistMiddleware denies the releases of the blocklist
+func BlocklistMiddleware(blocklist *Blocklist) Middleware {
+	return func(next Service) Service {
+		return &blocklistMiddleware{
+			next:      next,
+			blocklist: blocklist,
+		}
+	}
+}
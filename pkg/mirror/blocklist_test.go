@@ -0,0 +1,138 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+const exampleBlocklist = `
+releases:
+  - namespace: example
+    name: dummy
+    versions: "= 1.0.0"
+    reason: CVE-2024-0001
+    advisory: https://example.com/advisories/1
+  - hostname: terraform.example.com
+    namespace: example
+    name: dummy
+    checksums:
+      - zh:ABCDEF
+`
+
+func TestParseBlocklist(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "valid blocklist",
+			input: exampleBlocklist,
+		},
+		{
+			name:    "missing name",
+			input:   "releases:\n  - namespace: example\n    versions: \"= 1.0.0\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "neither versions nor checksums",
+			input:   "releases:\n  - namespace: example\n    name: dummy\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid versions",
+			input:   "releases:\n  - namespace: example\n    name: dummy\n    versions: one\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBlocklist([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBlocklist() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type mockedService struct {
+	versions     *ListProviderVersionsResponse
+	installation *ListProviderInstallationResponse
+	retrieved    bool
+}
+
+func (m *mockedService) ListProviderVersions(_ context.Context, _ *core.Provider) (*ListProviderVersionsResponse, error) {
+	return m.versions, nil
+}
+
+func (m *mockedService) ListProviderInstallation(_ context.Context, _ *core.Provider) (*ListProviderInstallationResponse, error) {
+	return m.installation, nil
+}
+
+func (m *mockedService) RetrieveProviderArchive(_ context.Context, _ *core.Provider) (*retrieveProviderArchiveResponse, error) {
+	m.retrieved = true
+	return &retrieveProviderArchiveResponse{location: "https://example.com/archive.zip"}, nil
+}
+
+func TestBlocklistMiddleware(t *testing.T) {
+	assert := assertion.New(t)
+
+	blocklist, err := ParseBlocklist([]byte(exampleBlocklist))
+	assert.NoError(err)
+
+	newService := func() *mockedService {
+		return &mockedService{
+			versions: &ListProviderVersionsResponse{
+				Versions: map[string]EmptyObject{"1.0.0": {}, "1.0.1": {}},
+			},
+			installation: &ListProviderInstallationResponse{
+				Archives: map[string]Archive{
+					"linux_amd64":  {Url: "https://example.com/linux.zip", Hashes: []string{"zh:abcdef"}},
+					"darwin_arm64": {Url: "https://example.com/darwin.zip", Hashes: []string{"zh:012345"}},
+				},
+			},
+		}
+	}
+	provider := func(version, os, arch string) *core.Provider {
+		return &core.Provider{Hostname: "terraform.example.com", Namespace: "example", Name: "dummy", Version: version, OS: os, Arch: arch}
+	}
+
+	t.Run("blocked versions are omitted", func(t *testing.T) {
+		svc := BlocklistMiddleware(blocklist)(newService())
+		res, err := svc.ListProviderVersions(context.Background(), provider("", "", ""))
+		assert.NoError(err)
+		assert.Equal(map[string]EmptyObject{"1.0.1": {}}, res.Versions)
+	})
+
+	t.Run("blocked version points to the advisory", func(t *testing.T) {
+		svc := BlocklistMiddleware(blocklist)(newService())
+		_, err := svc.ListProviderInstallation(context.Background(), provider("1.0.0", "", ""))
+		assert.True(errors.Is(err, ErrReleaseBlocked))
+		assert.ErrorContains(err, "CVE-2024-0001, see https://example.com/advisories/1")
+	})
+
+	t.Run("archives blocked by checksum are omitted", func(t *testing.T) {
+		svc := BlocklistMiddleware(blocklist)(newService())
+		res, err := svc.ListProviderInstallation(context.Background(), provider("1.0.1", "", ""))
+		assert.NoError(err)
+		assert.Len(res.Archives, 1)
+		assert.Contains(res.Archives, "darwin_arm64")
+	})
+
+	t.Run("archive blocked by checksum isn't retrieved", func(t *testing.T) {
+		next := newService()
+		svc := BlocklistMiddleware(blocklist)(next)
+		_, err := svc.RetrieveProviderArchive(context.Background(), provider("1.0.1", "linux", "amd64"))
+		assert.True(errors.Is(err, ErrReleaseBlocked))
+		assert.False(next.retrieved)
+
+		_, err = svc.RetrieveProviderArchive(context.Background(), provider("1.0.1", "darwin", "arm64"))
+		assert.NoError(err)
+		assert.True(next.retrieved)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
 )
 
 type Copier interface {
@@ -43,40 +44,96 @@ func (c *copier) copy(provider *core.Provider) {
 		}
 	}()
 
-	// We download the files from upstream and mirror them to our storage
-	if err := c.signingKeys(ctx, provider); err != nil {
-		c.logger.Error("failed to copy signing keys", logKeyValues(provider), slog.String("err", err.Error()))
+	skipped, err := c.sync(ctx, provider)
+	if err != nil {
+		c.logger.Error("failed to copy provider", logKeyValues(provider), slog.String("err", err.Error()))
 		return
 	}
-
-	if err := c.sha256Sums(ctx, provider); err != nil {
-		c.logger.Error("failed to copy SHA256SUMS", logKeyValues(provider), slog.String("err", err.Error()))
+	if skipped {
+		c.logger.Info("provider is already up-to-date", logKeyValues(provider), slog.String("took", time.Since(begin).String()))
 		return
 	}
+	c.logger.Info("successfully copied provider", logKeyValues(provider), slog.String("took", time.Since(begin).String()))
+}
 
-	if err := c.sha256SumsSignature(ctx, provider); err != nil {
-		c.logger.Error("failed to copy SHA256SUMS.sig", logKeyValues(provider), slog.String("err", err.Error()))
-		return
+// sync downloads the files of a provider release from upstream and mirrors them to our storage.
+// The stored SHA256SUMS are compared against upstream before anything else is transferred,
+// so that releases which are mirrored already are skipped without downloading the archive again.
+// It reports whether the copy of the archive was skipped.
+func (c *copier) sync(ctx context.Context, provider *core.Provider) (bool, error) {
+	if err := c.signingKeys(ctx, provider); err != nil {
+		return false, fmt.Errorf("failed to copy signing keys: %w", err)
+	}
+
+	upToDate, err := c.sha256SumsUpToDate(ctx, provider)
+	if err != nil {
+		return false, err
+	}
+
+	if upToDate {
+		// SHA256SUMS contains the checksums of all platforms, the archive could be missing nevertheless
+		if _, err := c.storage.GetMirroredProvider(ctx, provider.Clone()); err == nil {
+			return true, nil
+		}
+	} else {
+		if err := c.sha256Sums(ctx, provider); err != nil {
+			return false, fmt.Errorf("failed to copy SHA256SUMS: %w", err)
+		}
+
+		if err := c.sha256SumsSignature(ctx, provider); err != nil {
+			return false, fmt.Errorf("failed to copy SHA256SUMS.sig: %w", err)
+		}
 	}
 
 	// Request the provider archive
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.DownloadURL, nil)
 	if err != nil {
-		c.logger.Error("failed to create provider download request", logKeyValues(provider), slog.String("err", err.Error()))
-		return
+		return false, fmt.Errorf("failed to create provider download request: %w", err)
 	}
 	resp, err := c.client.Do(req)
 	if err != nil {
-		c.logger.Error("failed to download provider", logKeyValues(provider), slog.String("err", err.Error()))
-		return
+		return false, fmt.Errorf("failed to download provider: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to download provider, statuscode is %v", resp.StatusCode)
+	}
 
 	fileName := provider.ArchiveFileName()
 	if err = c.storage.UploadMirroredFile(ctx, provider, fileName, resp.Body); err != nil {
-		c.logger.Error("failed to upload provider to mirror", logKeyValues(provider), slog.String("err", err.Error()))
+		return false, fmt.Errorf("failed to upload provider to mirror: %w", err)
 	}
-	c.logger.Info("successfully copied provider", logKeyValues(provider), slog.String("took", time.Since(begin).String()))
+
+	return false, nil
+}
+
+// sha256SumsUpToDate compares the mirrored SHA256SUMS with the upstream SHA256SUMS
+func (c *copier) sha256SumsUpToDate(ctx context.Context, provider *core.Provider) (bool, error) {
+	stored, err := c.storage.MirroredSha256Sum(ctx, provider)
+	if err != nil {
+		// The release hasn't been mirrored yet
+		return false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.SHASumsURL, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to download SHA256SUMS, statuscode is %v", resp.StatusCode)
+	}
+
+	upstream, err := core.NewSha256Sums(provider.ShasumFileName(), resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse upstream SHA256SUMS: %w", err)
+	}
+
+	return stored.Equal(upstream), nil
 }
 
 // check if the signing keys exist, if not add it
@@ -143,18 +200,21 @@ func (c *copier) shutdown(ctx context.Context) {
 }
 
 func NewCopier(ctx context.Context, storage Storage) Copier {
-	logger := slog.Default().With(slog.String("component", "copier"))
-	m := &copier{
+	m := newCopier(storage)
+	go m.shutdown(ctx)
+	return m
+}
+
+func newCopier(storage Storage) *copier {
+	return &copier{
 		done:   make(chan struct{}),
-		logger: logger,
+		logger: slog.Default().With(slog.String(loglevel.ComponentKey, "copier")),
 		client: &http.Client{
 			// This is also the timeout for reading the response body
 			Timeout: 2 * time.Minute,
 		},
 		storage: storage,
 	}
-	go m.shutdown(ctx)
-	return m
 }
 
 func logKeyValues(provider *core.Provider) slog.Attr {
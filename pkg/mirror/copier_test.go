@@ -6,9 +6,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
 )
 
 var exampleSigningKeys = core.SigningKeys{
@@ -271,3 +274,108 @@ func Test_copier_sha256Sums(t *testing.T) {
 		})
 	}
 }
+
+func Test_copier_sync(t *testing.T) {
+	const sha256Sums = "5f9c7aa76b7c34d722fc9123208e26b22d60440cb47150dd04733b9b94f4541a  terraform-provider-dummy_1.2.3_linux_amd64.zip\n"
+	storedSums, err := core.NewSha256Sums("terraform-provider-dummy_1.2.3_SHA256SUMS", strings.NewReader(sha256Sums))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdatedSums, err := core.NewSha256Sums("terraform-provider-dummy_1.2.3_SHA256SUMS", strings.NewReader("29df160b8b618227197cc9984c47412461ad66a300a8fc1db4052398bf5656ac  terraform-provider-dummy_1.2.3_linux_amd64.zip\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		storedSums      *core.Sha256Sums
+		archiveMirrored bool
+		wantSkipped     bool
+		wantUploads     []string
+		wantDownloads   []string
+	}{
+		{
+			name:          "not mirrored yet",
+			wantUploads:   []string{"terraform-provider-dummy_1.2.3_SHA256SUMS", "terraform-provider-dummy_1.2.3_SHA256SUMS.sig", "terraform-provider-dummy_1.2.3_linux_amd64.zip"},
+			wantDownloads: []string{"/sums", "/sig", "/archive"},
+		},
+		{
+			name:            "mirrored and up-to-date",
+			storedSums:      storedSums,
+			archiveMirrored: true,
+			wantSkipped:     true,
+			wantDownloads:   []string{"/sums"},
+		},
+		{
+			name:          "SHA256SUMS up-to-date but archive of platform missing",
+			storedSums:    storedSums,
+			wantUploads:   []string{"terraform-provider-dummy_1.2.3_linux_amd64.zip"},
+			wantDownloads: []string{"/sums", "/archive"},
+		},
+		{
+			name:            "SHA256SUMS changed upstream",
+			storedSums:      outdatedSums,
+			archiveMirrored: true,
+			wantUploads:     []string{"terraform-provider-dummy_1.2.3_SHA256SUMS", "terraform-provider-dummy_1.2.3_SHA256SUMS.sig", "terraform-provider-dummy_1.2.3_linux_amd64.zip"},
+			wantDownloads:   []string{"/sums", "/sums", "/sig", "/archive"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var downloads []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				downloads = append(downloads, r.URL.Path)
+				if r.URL.Path == "/sums" {
+					_, _ = w.Write([]byte(sha256Sums))
+					return
+				}
+				_, _ = w.Write([]byte("content"))
+			}))
+			defer server.Close()
+
+			var uploads []string
+			c := &copier{
+				client: server.Client(),
+				storage: &mockedStorage{
+					mirroredSigningKeys: func(ctx context.Context, hostname, namespace string) (*core.SigningKeys, error) {
+						return &exampleSigningKeys, nil
+					},
+					mirroredSha256Sum: func(ctx context.Context, provider *core.Provider) (*core.Sha256Sums, error) {
+						if tt.storedSums == nil {
+							return nil, errors.New("failed to download SHA256SUMS")
+						}
+						return tt.storedSums, nil
+					},
+					getMirroredProvider: func(ctx context.Context, provider *core.Provider) (*core.Provider, error) {
+						if !tt.archiveMirrored {
+							return nil, &core.ProviderError{Reason: "not found", Provider: provider}
+						}
+						return provider, nil
+					},
+					uploadMirroredFile: func(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
+						uploads = append(uploads, fileName)
+						_, err := io.Copy(io.Discard, reader)
+						return err
+					},
+				},
+			}
+
+			skipped, err := c.sync(context.Background(), &core.Provider{
+				Hostname:            "terraform.example.com",
+				Namespace:           "example",
+				Name:                "dummy",
+				Version:             "1.2.3",
+				OS:                  "linux",
+				Arch:                "amd64",
+				SigningKeys:         exampleSigningKeys,
+				DownloadURL:         server.URL + "/archive",
+				SHASumsURL:          server.URL + "/sums",
+				SHASumsSignatureURL: server.URL + "/sig",
+			})
+			assertion.NoError(t, err)
+			assertion.Equal(t, tt.wantSkipped, skipped)
+			assertion.Equal(t, tt.wantUploads, uploads)
+			assertion.Equal(t, tt.wantDownloads, downloads)
+		})
+	}
+}
@@ -4,4 +4,5 @@ import "errors"
 
 var (
 	ErrUpstreamNotFound = errors.New("not found upstream")
+	ErrReleaseBlocked   = errors.New("provider release is blocked")
 )
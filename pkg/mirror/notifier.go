@@ -0,0 +1,54 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Notifier is notified of the releases which were mirrored for the first time by the Syncer
+type Notifier interface {
+	Notify(ctx context.Context, release Release) error
+}
+
+// webhookNotifier posts the release as JSON to a URL, e.g. of a Slack workflow or a CI pipeline
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, release Release) error {
+	body, err := json.Marshal(release)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with statuscode %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// NewWebhookNotifier returns a Notifier posting every release as JSON to the URL
+func NewWebhookNotifier(url string) Notifier {
+	return &webhookNotifier{
+		url: url,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
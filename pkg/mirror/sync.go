@@ -0,0 +1,318 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/discovery"
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/hashicorp/go-version"
+)
+
+// defaultSyncHostname is the hostname of the targets whose source address omits it, like in Terraform
+const defaultSyncHostname = "registry.terraform.io"
+
+// SyncTarget is an upstream provider whose releases matching the version constraint are mirrored by the Syncer
+type SyncTarget struct {
+	Hostname   string
+	Namespace  string
+	Name       string
+	Constraint string
+
+	constraints version.Constraints
+}
+
+// ParseSyncTarget parses a target in the form of [<hostname>/]<namespace>/<name>[ <constraint>], e.g. hashicorp/aws >= 5.0.
+// The hostname defaults to registry.terraform.io and an empty constraint matches all versions except pre-releases.
+func ParseSyncTarget(s string) (SyncTarget, error) {
+	source, constraint := strings.TrimSpace(s), ""
+	if i := strings.IndexAny(source, " <>=!~"); i >= 0 {
+		source, constraint = source[:i], strings.TrimSpace(source[i:])
+	}
+
+	parts := strings.Split(source, "/")
+	if len(parts) == 2 {
+		parts = append([]string{defaultSyncHostname}, parts...)
+	}
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return SyncTarget{}, fmt.Errorf("invalid provider source address %q, expected [<hostname>/]<namespace>/<name>", source)
+	}
+
+	t := SyncTarget{
+		Hostname:   parts[0],
+		Namespace:  parts[1],
+		Name:       parts[2],
+		Constraint: constraint,
+	}
+	if constraint == "" {
+		constraint = ">= 0.0.0"
+	}
+	var err error
+	if t.constraints, err = version.NewConstraint(constraint); err != nil {
+		return SyncTarget{}, fmt.Errorf("%w: %w", core.ErrInvalidConstraint, err)
+	}
+
+	return t, nil
+}
+
+func (t SyncTarget) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%s/%s/%s %s", t.Hostname, t.Namespace, t.Name, t.Constraint))
+}
+
+// Release is a provider version which was mirrored for the first time by the Syncer
+type Release struct {
+	Hostname  string          `json:"hostname"`
+	Namespace string          `json:"namespace"`
+	Name      string          `json:"name"`
+	Version   string          `json:"version"`
+	Platforms []core.Platform `json:"platforms"`
+}
+
+// releaseSyncer copies a single platform of a provider release, it's implemented by the copier
+type releaseSyncer interface {
+	sync(ctx context.Context, provider *core.Provider) (bool, error)
+}
+
+// Syncer periodically mirrors the releases of upstream providers matching the version constraints of the targets.
+// Platforms of a release which are mirrored already are skipped, so that only new releases and platforms are copied.
+type Syncer struct {
+	targets   []SyncTarget
+	platforms map[core.Platform]struct{}
+	upstream  upstreamProvider
+	storage   Storage
+	copier    releaseSyncer
+	notifier  Notifier
+	blocklist *Blocklist
+	logger    *slog.Logger
+}
+
+// SyncOption configures the Syncer
+type SyncOption func(*Syncer)
+
+// WithSyncPlatforms restricts the mirrored platforms, all platforms of a release are mirrored by default
+func WithSyncPlatforms(platforms []core.Platform) SyncOption {
+	return func(s *Syncer) {
+		for _, p := range platforms {
+			s.platforms[p] = struct{}{}
+		}
+	}
+}
+
+// WithSyncNotifier sets the Notifier which is notified of every release that was mirrored for the first time
+func WithSyncNotifier(notifier Notifier) SyncOption {
+	return func(s *Syncer) {
+		s.notifier = notifier
+	}
+}
+
+// WithSyncBlocklist skips the releases of the blocklist, so that they're never mirrored
+func WithSyncBlocklist(blocklist *Blocklist) SyncOption {
+	return func(s *Syncer) {
+		s.blocklist = blocklist
+	}
+}
+
+// NewSyncer syncs the targets right away and then in the given interval until the context is cancelled,
+// a non-positive interval disables the periodic sync, e.g. when Sync is called by the scheduler instead.
+func NewSyncer(ctx context.Context, storage Storage, targets []SyncTarget, interval time.Duration, opts ...SyncOption) *Syncer {
+	s := &Syncer{
+		targets:   targets,
+		platforms: make(map[core.Platform]struct{}),
+		upstream:  newUpstreamProviderRegistry(discovery.NewRemoteServiceDiscovery(http.DefaultClient)),
+		storage:   storage,
+		copier:    newCopier(storage),
+		logger:    slog.Default().With(slog.String(loglevel.ComponentKey, "mirror-sync")),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	go s.run(ctx, interval)
+
+	return s
+}
+
+func (s *Syncer) run(ctx context.Context, interval time.Duration) {
+	sync := func() {
+		if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("failed to sync mirrored providers", slog.String("err", err.Error()))
+		}
+	}
+	sync()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sync()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sync mirrors the missing releases of all targets. A failing target doesn't prevent the other targets from being synced.
+func (s *Syncer) Sync(ctx context.Context) error {
+	var errs []error
+	for _, t := range s.targets {
+		if err := s.syncTarget(ctx, t); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync %s: %w", t, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Syncer) syncTarget(ctx context.Context, t SyncTarget) error {
+	provider := &core.Provider{
+		Hostname:  t.Hostname,
+		Namespace: t.Namespace,
+		Name:      t.Name,
+	}
+
+	versions, err := s.upstream.listProviderVersions(ctx, provider)
+	if err != nil {
+		return err
+	}
+
+	mirrored, err := s.mirroredPlatforms(ctx, provider)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, v := range versions.Versions {
+		parsed, err := version.NewVersion(v.Version)
+		if err != nil || !t.constraints.Check(parsed) {
+			continue
+		}
+		if s.blocklist != nil {
+			clone := provider.Clone()
+			clone.Version = v.Version
+			if err := s.blocklist.CheckVersion(clone); err != nil {
+				s.logger.Debug("skipping blocked provider version", slog.String("err", err.Error()))
+				continue
+			}
+		}
+
+		_, known := mirrored[v.Version]
+		var copied []core.Platform
+		for _, p := range v.Platforms {
+			if _, ok := mirrored[v.Version][p]; ok || !s.wanted(p) {
+				continue
+			}
+
+			clone := provider.Clone()
+			clone.Version = v.Version
+			clone.OS = p.OS
+			clone.Arch = p.Arch
+			synced, err := s.syncPlatform(ctx, clone)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if synced {
+				copied = append(copied, p)
+			}
+		}
+
+		if !known && len(copied) > 0 {
+			s.released(ctx, Release{
+				Hostname:  t.Hostname,
+				Namespace: t.Namespace,
+				Name:      t.Name,
+				Version:   v.Version,
+				Platforms: copied,
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// syncPlatform reports whether the platform was mirrored, it's skipped if its archive is blocked by the checksum
+func (s *Syncer) syncPlatform(ctx context.Context, provider *core.Provider) (bool, error) {
+	upstream, err := s.upstream.getProvider(ctx, provider)
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve %s %s_%s from upstream: %w", provider.Version, provider.OS, provider.Arch, err)
+	}
+	if s.blocklist != nil {
+		if err := s.blocklist.CheckChecksum(upstream, upstream.Shasum); err != nil {
+			s.logger.Debug("skipping blocked provider archive", logKeyValues(upstream), slog.String("err", err.Error()))
+			return false, nil
+		}
+	}
+
+	if _, err := s.copier.sync(ctx, upstream); err != nil {
+		return false, fmt.Errorf("failed to copy %s %s_%s: %w", provider.Version, provider.OS, provider.Arch, err)
+	}
+	s.logger.Debug("mirrored provider", logKeyValues(upstream))
+	return true, nil
+}
+
+// mirroredPlatforms returns the platforms of the mirrored releases of the provider by version
+func (s *Syncer) mirroredPlatforms(ctx context.Context, provider *core.Provider) (map[string]map[core.Platform]struct{}, error) {
+	platforms := make(map[string]map[core.Platform]struct{})
+
+	providers, err := s.storage.ListMirroredProviders(ctx, provider)
+	if err != nil {
+		var providerErr *core.ProviderError
+		if errors.As(err, &providerErr) {
+			// Nothing of the provider has been mirrored yet
+			return platforms, nil
+		}
+		return nil, err
+	}
+
+	for _, p := range providers {
+		if _, ok := platforms[p.Version]; !ok {
+			platforms[p.Version] = make(map[core.Platform]struct{})
+		}
+		platforms[p.Version][core.Platform{OS: p.OS, Arch: p.Arch}] = struct{}{}
+	}
+	return platforms, nil
+}
+
+func (s *Syncer) wanted(p core.Platform) bool {
+	if len(s.platforms) == 0 {
+		return true
+	}
+	_, ok := s.platforms[p]
+	return ok
+}
+
+// released announces a release which was mirrored for the first time. Failed notifications are logged, but don't fail the sync.
+func (s *Syncer) released(ctx context.Context, r Release) {
+	s.logger.Info("mirrored new provider version",
+		slog.String("hostname", r.Hostname),
+		slog.String("namespace", r.Namespace),
+		slog.String("name", r.Name),
+		slog.String("version", r.Version),
+		slog.Int("platforms", len(r.Platforms)),
+	)
+	o11y.Audit(ctx, "mirror.sync",
+		slog.String("provider", fmt.Sprintf("%s/%s/%s", r.Hostname, r.Namespace, r.Name)),
+		slog.String("version", r.Version),
+	)
+
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.Notify(ctx, r); err != nil {
+		s.logger.Warn("failed to send notification of new provider version",
+			slog.String("version", r.Version),
+			slog.String("err", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,220 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestParseSyncTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    SyncTarget
+		wantErr bool
+	}{
+		{
+			name:  "hostname and constraint",
+			input: "terraform.example.com/example/dummy >= 5.0, < 6.0",
+			want:  SyncTarget{Hostname: "terraform.example.com", Namespace: "example", Name: "dummy", Constraint: ">= 5.0, < 6.0"},
+		},
+		{
+			name:  "default hostname",
+			input: "hashicorp/aws>=5.0",
+			want:  SyncTarget{Hostname: "registry.terraform.io", Namespace: "hashicorp", Name: "aws", Constraint: ">=5.0"},
+		},
+		{
+			name:  "without constraint",
+			input: "hashicorp/aws",
+			want:  SyncTarget{Hostname: "registry.terraform.io", Namespace: "hashicorp", Name: "aws"},
+		},
+		{
+			name:    "missing name",
+			input:   "hashicorp >= 5.0",
+			wantErr: true,
+		},
+		{
+			name:    "invalid constraint",
+			input:   "hashicorp/aws >= five",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assertion.New(t)
+			got, err := ParseSyncTarget(tt.input)
+			if tt.wantErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tt.want.Hostname, got.Hostname)
+			assert.Equal(tt.want.Namespace, got.Namespace)
+			assert.Equal(tt.want.Name, got.Name)
+			assert.Equal(tt.want.Constraint, got.Constraint)
+		})
+	}
+}
+
+type mockedReleaseSyncer struct {
+	synced []string
+	err    error
+}
+
+func (m *mockedReleaseSyncer) sync(_ context.Context, provider *core.Provider) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.synced = append(m.synced, provider.ArchiveFileName())
+	return false, nil
+}
+
+type mockedNotifier struct {
+	releases []Release
+}
+
+func (m *mockedNotifier) Notify(_ context.Context, release Release) error {
+	m.releases = append(m.releases, release)
+	return nil
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	upstream := &mockedUpstreamProvider{
+		customListProviderVersions: func(ctx context.Context, provider *core.Provider) (*core.ProviderVersions, error) {
+			return &core.ProviderVersions{
+				Versions: []core.ProviderVersion{
+					{Version: "4.9.0", Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}}},
+					{Version: "5.0.0", Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"}}},
+					{Version: "5.1.0", Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}, {OS: "windows", Arch: "amd64"}}},
+					{Version: "5.2.0-beta1", Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}}},
+				},
+			}, nil
+		},
+		customGetProvider: func(ctx context.Context, provider *core.Provider) (*core.Provider, error) {
+			return provider, nil
+		},
+	}
+
+	tests := []struct {
+		name         string
+		platforms    []core.Platform
+		mirrored     []*core.Provider
+		copierErr    error
+		wantSynced   []string
+		wantReleases []string
+		wantErr      bool
+	}{
+		{
+			name: "nothing mirrored yet",
+			wantSynced: []string{
+				"terraform-provider-aws_5.0.0_linux_amd64.zip",
+				"terraform-provider-aws_5.0.0_darwin_arm64.zip",
+				"terraform-provider-aws_5.1.0_linux_amd64.zip",
+				"terraform-provider-aws_5.1.0_windows_amd64.zip",
+			},
+			wantReleases: []string{"5.0.0", "5.1.0"},
+		},
+		{
+			name: "missing platform of a mirrored release",
+			mirrored: []*core.Provider{
+				{Version: "5.0.0", OS: "linux", Arch: "amd64"},
+				{Version: "5.0.0", OS: "darwin", Arch: "arm64"},
+				{Version: "5.1.0", OS: "linux", Arch: "amd64"},
+			},
+			wantSynced: []string{"terraform-provider-aws_5.1.0_windows_amd64.zip"},
+		},
+		{
+			name:      "restricted platforms",
+			platforms: []core.Platform{{OS: "linux", Arch: "amd64"}},
+			wantSynced: []string{
+				"terraform-provider-aws_5.0.0_linux_amd64.zip",
+				"terraform-provider-aws_5.1.0_linux_amd64.zip",
+			},
+			wantReleases: []string{"5.0.0", "5.1.0"},
+		},
+		{
+			name:      "failed copy",
+			copierErr: errors.New("mocked copier error"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assertion.New(t)
+
+			copier := &mockedReleaseSyncer{err: tt.copierErr}
+			notifier := &mockedNotifier{}
+			s := &Syncer{
+				targets:   []SyncTarget{mustParseSyncTarget(t, "hashicorp/aws >= 5.0")},
+				platforms: make(map[core.Platform]struct{}),
+				upstream:  upstream,
+				storage: &mockedStorage{
+					listMirrorProviders: func(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
+						if len(tt.mirrored) == 0 {
+							return nil, &core.ProviderError{Reason: "mocked provider error"}
+						}
+						return tt.mirrored, nil
+					},
+				},
+				copier:   copier,
+				notifier: notifier,
+				logger:   slog.Default(),
+			}
+			WithSyncPlatforms(tt.platforms)(s)
+
+			err := s.Sync(context.Background())
+			if tt.wantErr {
+				assert.Error(err)
+				assert.Empty(notifier.releases)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tt.wantSynced, copier.synced)
+
+			var releases []string
+			for _, r := range notifier.releases {
+				releases = append(releases, r.Version)
+			}
+			assert.Equal(tt.wantReleases, releases)
+		})
+	}
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	assert := assertion.New(t)
+
+	var received Release
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(http.MethodPost, r.Method)
+		assert.NoError(json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	release := Release{Hostname: "registry.terraform.io", Namespace: "hashicorp", Name: "aws", Version: "5.0.0"}
+	assert.NoError(NewWebhookNotifier(server.URL).Notify(context.Background(), release))
+	assert.Equal(release.Version, received.Version)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	assert.Error(NewWebhookNotifier(failing.URL).Notify(context.Background(), release))
+}
+
+func mustParseSyncTarget(t *testing.T, s string) SyncTarget {
+	t.Helper()
+	target, err := ParseSyncTarget(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
@@ -215,16 +215,14 @@ func encodeMirroredResponse(ctx context.Context, w http.ResponseWriter, response
 
 // ErrorEncoder translates domain specific errors to HTTP status codes
 func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
-	var providerErr *core.ProviderError
-	if errors.As(err, &providerErr) {
-		w.WriteHeader(providerErr.StatusCode)
-	} else if errors.Is(err, ErrUpstreamNotFound) {
-		w.WriteHeader(http.StatusNotFound)
-	} else {
-		w.WriteHeader(core.GenericError(err))
+	statusCode := core.GenericError(err)
+	if errors.Is(err, ErrUpstreamNotFound) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, ErrReleaseBlocked) {
+		statusCode = http.StatusGone
 	}
 
-	core.HandleErrorResponse(err, w)
+	core.HandleErrorResponse(err, statusCode, w)
 }
 
 func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
@@ -147,6 +147,7 @@ func Test_upstreamProviderRegistry_getProvider(t *testing.T) {
 				DownloadURL:         "https://releases.hashicorp.com/terraform-provider-random/2.0.0/terraform-provider-random_2.0.0_linux_amd64.zip",
 				SHASumsURL:          "https://releases.hashicorp.com/terraform-provider-random/2.0.0/terraform-provider-random_2.0.0_SHA256SUMS",
 				SHASumsSignatureURL: "https://releases.hashicorp.com/terraform-provider-random/2.0.0/terraform-provider-random_2.0.0_SHA256SUMS.sig",
+				Protocols:           []string{"4.0", "5.1"},
 				Shasum:              "5f9c7aa76b7c34d722fc9123208e26b22d60440cb47150dd04733b9b94f4541a",
 				SigningKeys: core.SigningKeys{
 					GPGPublicKeys: []core.GPGPublicKey{
@@ -0,0 +1,61 @@
+package module
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	checksumPrefix = "sha256:"
+
+	checksumQueryParam = "checksum"
+	checksumHeader     = "X-Expected-Checksum"
+
+	// checksumResponseHeader contains the checksum of the module archive in the sha256:<hex> format
+	checksumResponseHeader = "X-Module-Checksum"
+
+	// signatureResponseHeader contains the URL of the detached OpenPGP signature of the module archive, if the module was signed
+	signatureResponseHeader = "X-Module-Signature-URL"
+
+	// attestationResponseHeader contains the URL of the Sigstore bundle of the module archive, if the module was attested
+	attestationResponseHeader = "X-Module-Attestation-URL"
+)
+
+// ExpectedChecksum returns the checksum the client expects the module archive to have from the query or the header
+func ExpectedChecksum(r *http.Request) string {
+	if checksum := r.URL.Query().Get(checksumQueryParam); checksum != "" {
+		return checksum
+	}
+	return r.Header.Get(checksumHeader)
+}
+
+// FormatChecksum returns the hex-encoded SHA-256 checksum in the sha256:<hex> format, which is also understood by go-getter
+func FormatChecksum(checksum string) string {
+	return checksumPrefix + checksum
+}
+
+// VerifyChecksum compares the expected checksum in the sha256:<hex> or <hex> format with the hex-encoded checksum of the archive
+func VerifyChecksum(expected, actual string) error {
+	e := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(expected)), checksumPrefix)
+	if b, err := hex.DecodeString(e); err != nil || len(b) != 32 {
+		return fmt.Errorf("%w: %s", ErrInvalidChecksum, expected)
+	}
+
+	if e != actual {
+		return fmt.Errorf("%w: expected %s but was %s", ErrChecksumMismatch, FormatChecksum(e), FormatChecksum(actual))
+	}
+
+	return nil
+}
+
+// withChecksum appends the checksum to the download URL, so that go-getter verifies the archive after downloading it.
+// The query parameter is removed by go-getter before the URL is requested, which keeps presigned URLs valid.
+func withChecksum(downloadURL, checksum string) string {
+	separator := "?"
+	if strings.Contains(downloadURL, "?") {
+		separator = "&"
+	}
+	return downloadURL + separator + checksumQueryParam + "=" + FormatChecksum(checksum)
+}
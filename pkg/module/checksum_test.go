@@ -0,0 +1,61 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	checksum := "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3"
+
+	testCases := []struct {
+		name     string
+		expected string
+		err      error
+	}{
+		{
+			name:     "hex",
+			expected: checksum,
+		},
+		{
+			name:     "prefixed",
+			expected: "sha256:" + checksum,
+		},
+		{
+			name:     "uppercase",
+			expected: "SHA256:0EB3E36BFB24DCD9BB1D1BECE1531216B59539A8FDE17EE80224AF0653C92AA3",
+		},
+		{
+			name:     "mismatch",
+			expected: "sha256:" + "1eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3",
+			err:      ErrChecksumMismatch,
+		},
+		{
+			name:     "too short",
+			expected: "sha256:0eb3e36b",
+			err:      ErrInvalidChecksum,
+		},
+		{
+			name:     "invalid encoding",
+			expected: "md5:abc",
+			err:      ErrInvalidChecksum,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyChecksum(tc.expected, checksum)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithChecksum(t *testing.T) {
+	assert.Equal(t, "https://example.com/a.tar.gz?checksum=sha256:abc", withChecksum("https://example.com/a.tar.gz", "abc"))
+	assert.Equal(t, "https://example.com/a.tar.gz?X-Amz-Signature=def&checksum=sha256:abc", withChecksum("https://example.com/a.tar.gz?X-Amz-Signature=def", "abc"))
+}
@@ -0,0 +1,171 @@
+package module
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	zipMagic      = []byte("PK\x03\x04")
+	emptyZipMagic = []byte("PK\x05\x06")
+)
+
+// SniffArchiveFormat returns the format of the archive from its first bytes, or an empty string if the format is unknown
+func SniffArchiveFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return ArchiveFormatTarGz
+	case bytes.HasPrefix(header, zipMagic), bytes.HasPrefix(header, emptyZipMagic):
+		return ArchiveFormatZip
+	}
+	return ""
+}
+
+// archiveFormatKind returns the format of the archive content for a file extension, as tgz is stored like tar.gz
+func archiveFormatKind(format string) string {
+	switch format {
+	case ArchiveFormatTarGz, "tgz":
+		return ArchiveFormatTarGz
+	case ArchiveFormatZip:
+		return ArchiveFormatZip
+	}
+	return ""
+}
+
+// ConvertArchive returns the archive repackaged in the format, which is converted while the returned reader is read.
+// Archives which are already in the format, or whose format is unknown, are returned unchanged.
+// The reader has to be closed to release the conversion, even if it isn't read until the end.
+func ConvertArchive(r io.Reader, format string) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(zipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	from, to := SniffArchiveFormat(header), archiveFormatKind(format)
+	if from == "" || to == "" || from == to {
+		return io.NopCloser(br), nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		if to == ArchiveFormatZip {
+			pw.CloseWithError(convertTarGzToZip(pw, br))
+		} else {
+			pw.CloseWithError(convertZipToTarGz(pw, br))
+		}
+	}()
+	return pr, nil
+}
+
+// convertTarGzToZip streams the files of the tar.gz archive into a zip archive one by one
+func convertTarGzToZip(w io.Writer, r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read module archive: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	zw := zip.NewWriter(w)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read module archive: %w", err)
+		}
+
+		// Directories are implied by the files, links are not supported by the upload command either
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, err := cleanArchivePath(header.Name)
+		if err != nil {
+			return err
+		}
+
+		fh := &zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: header.ModTime,
+		}
+		fh.SetMode(fs.FileMode(header.Mode).Perm())
+		fw, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, tr); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// convertZipToTarGz repackages the zip archive as tar.gz.
+// The central directory of a zip archive is located at its end, so the archive is spooled to a temporary file instead of memory.
+func convertZipToTarGz(w io.Writer, r io.Reader) error {
+	f, err := os.CreateTemp("", "module-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return fmt.Errorf("failed to read module archive: %w", err)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, zf := range zr.File {
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		name, err := cleanArchivePath(zf.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     int64(zf.Mode().Perm()),
+			Size:     int64(zf.UncompressedSize64),
+			ModTime:  zf.Modified,
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
@@ -0,0 +1,97 @@
+package module
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tarGzArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func convertArchive(t *testing.T, data []byte, format string) []byte {
+	rc, err := ConvertArchive(bytes.NewReader(data), format)
+	assert.NoError(t, err)
+	defer rc.Close()
+	converted, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	return converted
+}
+
+func TestConvertArchive(t *testing.T) {
+	files := map[string]string{
+		"main.tf":              `variable "name" {}`,
+		"./modules/vpc/vpc.tf": `resource "aws_vpc" "this" {}`,
+	}
+	tarGz := tarGzArchive(t, files)
+
+	zipped := convertArchive(t, tarGz, ArchiveFormatZip)
+	assert.Equal(t, ArchiveFormatZip, SniffArchiveFormat(zipped))
+
+	zr, err := zip.NewReader(bytes.NewReader(zipped), int64(len(zipped)))
+	assert.NoError(t, err)
+	names := make([]string, 0, len(zr.File))
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"main.tf", "modules/vpc/vpc.tf"}, names)
+
+	unzipped := convertArchive(t, zipped, "tgz")
+	assert.Equal(t, ArchiveFormatTarGz, SniffArchiveFormat(unzipped))
+
+	gr, err := gzip.NewReader(bytes.NewReader(unzipped))
+	assert.NoError(t, err)
+	tr := tar.NewReader(gr)
+	contents := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		contents[header.Name] = string(data)
+		assert.Equal(t, int64(0o644), header.Mode)
+	}
+	assert.Equal(t, map[string]string{
+		"main.tf":            files["main.tf"],
+		"modules/vpc/vpc.tf": files["./modules/vpc/vpc.tf"],
+	}, contents)
+}
+
+func TestConvertArchive_Unchanged(t *testing.T) {
+	tarGz := tarGzArchive(t, map[string]string{"main.tf": ""})
+
+	// Archives in the target format, archives of unknown format, and unknown target formats aren't converted
+	assert.Equal(t, tarGz, convertArchive(t, tarGz, "tgz"))
+	assert.Equal(t, []byte("archive"), convertArchive(t, []byte("archive"), ArchiveFormatZip))
+	assert.Equal(t, tarGz, convertArchive(t, tarGz, "tar.xz"))
+	assert.Empty(t, convertArchive(t, nil, ArchiveFormatZip))
+}
+
+func TestConvertArchive_UnsafePath(t *testing.T) {
+	tarGz := tarGzArchive(t, map[string]string{"../main.tf": ""})
+
+	rc, err := ConvertArchive(bytes.NewReader(tarGz), ArchiveFormatZip)
+	assert.NoError(t, err)
+	defer rc.Close()
+	_, err = io.ReadAll(rc)
+	assert.ErrorContains(t, err, "unsafe path ../main.tf")
+}
@@ -0,0 +1,268 @@
+package module
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Diff is the difference between two versions of a module, e.g. to write release notes or to review a change
+type Diff struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Files     []FileDiff  `json:"files"`
+	Variables []BlockDiff `json:"variables"`
+	Outputs   []BlockDiff `json:"outputs"`
+
+	// Breaking is true if any of the variables or outputs changed in a way which may break callers of the module
+	Breaking bool `json:"breaking"`
+}
+
+// FileDiff is a regular file of the module archives which was added, removed or modified
+type FileDiff struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+// BlockDiff is a variable or output of the root module which was added, removed or modified
+type BlockDiff struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+
+	// Attributes are the attributes and nested blocks of a modified variable or output which changed, e.g. default or validation
+	Attributes []string `json:"attributes,omitempty"`
+
+	// Breaking is true for removed variables and outputs, new variables without default,
+	// and variables whose type changed or whose default was removed
+	Breaking bool `json:"breaking"`
+}
+
+// block holds the source of the attributes and nested blocks of a variable or output
+type block map[string]string
+
+// NewDiff returns the difference between the module archives of two versions, which are either tar.gz or zip archives.
+// Variables and outputs are compared in the .tf files of the root module, files which can't be parsed are ignored for that.
+func NewDiff(from, to string, fromArchive, toArchive []byte) (*Diff, error) {
+	fromFiles, err := archiveFiles(fromArchive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive of version %s: %w", from, err)
+	}
+	toFiles, err := archiveFiles(toArchive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive of version %s: %w", to, err)
+	}
+
+	d := &Diff{
+		From:      from,
+		To:        to,
+		Files:     []FileDiff{},
+		Variables: []BlockDiff{},
+		Outputs:   []BlockDiff{},
+	}
+	for _, p := range unionKeys(fromFiles, toFiles) {
+		before, inFrom := fromFiles[p]
+		after, inTo := toFiles[p]
+		switch {
+		case !inFrom:
+			d.Files = append(d.Files, FileDiff{Path: p, Change: ChangeAdded})
+		case !inTo:
+			d.Files = append(d.Files, FileDiff{Path: p, Change: ChangeRemoved})
+		case sha256.Sum256(before) != sha256.Sum256(after):
+			d.Files = append(d.Files, FileDiff{Path: p, Change: ChangeModified})
+		}
+	}
+
+	fromVariables, fromOutputs := rootModuleBlocks(fromFiles)
+	toVariables, toOutputs := rootModuleBlocks(toFiles)
+	d.Variables = diffBlocks(fromVariables, toVariables, variableBreaking)
+	d.Outputs = diffBlocks(fromOutputs, toOutputs, outputBreaking)
+	for _, b := range slices.Concat(d.Variables, d.Outputs) {
+		d.Breaking = d.Breaking || b.Breaking
+	}
+	return d, nil
+}
+
+// diffBlocks compares the variables or outputs by name, the breaking function decides which changes are breaking
+func diffBlocks(from, to map[string]block, breaking func(change string, from, to block) bool) []BlockDiff {
+	diffs := []BlockDiff{}
+	for _, name := range unionKeys(from, to) {
+		before, inFrom := from[name]
+		after, inTo := to[name]
+
+		d := BlockDiff{Name: name}
+		switch {
+		case !inFrom:
+			d.Change = ChangeAdded
+		case !inTo:
+			d.Change = ChangeRemoved
+		default:
+			for _, attribute := range unionKeys(before, after) {
+				if before[attribute] != after[attribute] {
+					d.Attributes = append(d.Attributes, attribute)
+				}
+			}
+			if len(d.Attributes) == 0 {
+				continue
+			}
+			d.Change = ChangeModified
+		}
+		d.Breaking = breaking(d.Change, before, after)
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+func variableBreaking(change string, from, to block) bool {
+	_, hadDefault := from["default"]
+	_, hasDefault := to["default"]
+	switch change {
+	case ChangeAdded:
+		return !hasDefault
+	case ChangeRemoved:
+		return true
+	}
+	return (hadDefault && !hasDefault) || from["type"] != to["type"]
+}
+
+func outputBreaking(change string, _, _ block) bool {
+	return change == ChangeRemoved
+}
+
+// rootModuleBlocks returns the variables and outputs declared in the .tf files of the root module
+func rootModuleBlocks(files map[string][]byte) (map[string]block, map[string]block) {
+	variables, outputs := map[string]block{}, map[string]block{}
+	for p, src := range files {
+		if path.Dir(p) != "." || path.Ext(p) != ".tf" {
+			continue
+		}
+		file, diags := hclsyntax.ParseConfig(src, p, hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+
+		for _, b := range body.Blocks {
+			if len(b.Labels) != 1 {
+				continue
+			}
+			switch b.Type {
+			case "variable":
+				variables[b.Labels[0]] = blockSource(b, src)
+			case "output":
+				outputs[b.Labels[0]] = blockSource(b, src)
+			}
+		}
+	}
+	return variables, outputs
+}
+
+// blockSource returns the source of the attributes and nested blocks, so that changes in formatting don't count as changes
+func blockSource(b *hclsyntax.Block, src []byte) block {
+	res := block{}
+	for name, attribute := range b.Body.Attributes {
+		res[name] = normalizeSource(attribute.Expr.Range().SliceBytes(src))
+	}
+	for _, nested := range b.Body.Blocks {
+		res[nested.Type] += normalizeSource(nested.Range().SliceBytes(src))
+	}
+	return res
+}
+
+func normalizeSource(src []byte) string {
+	return strings.Join(strings.Fields(string(src)), " ")
+}
+
+const (
+	// maxDiffFileSize limits the files which are compared, larger files aren't Terraform sources
+	maxDiffFileSize = 16 << 20
+
+	// maxDiffArchiveSize limits the files extracted from each of the compared archives
+	maxDiffArchiveSize = 256 << 20
+)
+
+// archiveFiles returns the content of the regular files of a tar.gz or zip archive by their path
+func archiveFiles(archive []byte) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	limit := newExtractionLimit(maxDiffArchiveSize, maxDiffFileSize)
+	switch SniffArchiveFormat(archive) {
+	case ArchiveFormatTarGz:
+		gr, err := gzip.NewReader(bytes.NewReader(archive))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				return files, nil
+			} else if err != nil {
+				return nil, err
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			if files[path.Clean(header.Name)], err = limit.read(tr); err != nil {
+				return nil, err
+			}
+		}
+	case ArchiveFormatZip:
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			if !zf.Mode().IsRegular() {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, err
+			}
+			files[path.Clean(zf.Name)], err = limit.read(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return files, nil
+	}
+	return nil, fmt.Errorf("%w: the archive is neither a tar.gz nor a zip archive", ErrInvalidArchiveFormat)
+}
+
+// unionKeys returns the sorted keys of both maps
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
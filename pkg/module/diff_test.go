@@ -0,0 +1,118 @@
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDiff(t *testing.T) {
+	from := tarGzArchive(t, map[string]string{
+		"./main.tf": `resource "aws_vpc" "this" {}`,
+		"./variables.tf": `
+variable "name" {
+  type = string
+}
+
+variable "cidr" {
+  type    = string
+  default = "10.0.0.0/16"
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+`,
+		"./outputs.tf": `
+output "id" {
+  value = aws_vpc.this.id
+}
+
+output "arn" {
+  value = aws_vpc.this.arn
+}
+`,
+		"./README.md":           "# VPC",
+		"./modules/nat/main.tf": `variable "subnet" {}`,
+	})
+	to := convertArchive(t, tarGzArchive(t, map[string]string{
+		"./main.tf": `resource "aws_vpc" "this" {}`,
+		"./variables.tf": `
+variable "name" {
+  type        = string
+  description = "Name of the VPC"
+}
+
+variable "cidr" {
+  type = string
+}
+
+variable "tags" {
+  type = map(string)
+  default = {}
+}
+
+variable "ipv6" {
+  type    = bool
+  default = false
+}
+`,
+		"./outputs.tf": `
+output "id" {
+  value = aws_vpc.this.id
+}
+`,
+		"./CHANGELOG.md":        "# 2.0.0",
+		"./modules/nat/main.tf": `variable "subnets" {}`,
+	}), ArchiveFormatZip)
+
+	d, err := NewDiff("1.0.0", "2.0.0", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0", d.From)
+	assert.Equal(t, "2.0.0", d.To)
+	assert.True(t, d.Breaking)
+	assert.Equal(t, []FileDiff{
+		{Path: "CHANGELOG.md", Change: ChangeAdded},
+		{Path: "README.md", Change: ChangeRemoved},
+		{Path: "modules/nat/main.tf", Change: ChangeModified},
+		{Path: "outputs.tf", Change: ChangeModified},
+		{Path: "variables.tf", Change: ChangeModified},
+	}, d.Files)
+	// Variables of nested modules and changes in formatting are ignored
+	assert.Equal(t, []BlockDiff{
+		{Name: "cidr", Change: ChangeModified, Attributes: []string{"default"}, Breaking: true},
+		{Name: "ipv6", Change: ChangeAdded},
+		{Name: "name", Change: ChangeModified, Attributes: []string{"description"}},
+	}, d.Variables)
+	assert.Equal(t, []BlockDiff{
+		{Name: "arn", Change: ChangeRemoved, Breaking: true},
+	}, d.Outputs)
+}
+
+func TestNewDiff_Unchanged(t *testing.T) {
+	archive := tarGzArchive(t, map[string]string{"main.tf": `variable "name" {}`})
+
+	d, err := NewDiff("1.0.0", "1.0.1", archive, archive)
+	assert.NoError(t, err)
+	assert.False(t, d.Breaking)
+	assert.Empty(t, d.Files)
+	assert.Empty(t, d.Variables)
+	assert.Empty(t, d.Outputs)
+}
+
+func TestNewDiff_InvalidArchive(t *testing.T) {
+	archive := tarGzArchive(t, map[string]string{"main.tf": `variable "name" {}`})
+
+	_, err := NewDiff("1.0.0", "1.0.1", archive, []byte("not an archive"))
+	assert.ErrorIs(t, err, ErrInvalidArchiveFormat)
+}
+
+func TestNewDiff_LargeFile(t *testing.T) {
+	archive := tarGzArchive(t, map[string]string{"main.tf": `variable "name" {}`})
+	large := tarGzArchive(t, map[string]string{"main.tf": strings.Repeat("#", maxDiffFileSize+1)})
+
+	_, err := NewDiff("1.0.0", "1.0.1", archive, large)
+	assert.ErrorContains(t, err, "exceeds the maximum size")
+}
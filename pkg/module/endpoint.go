@@ -2,11 +2,18 @@ package module
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
 
+	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
 )
 
 type listRequest struct {
@@ -15,8 +22,20 @@ type listRequest struct {
 	provider  string
 }
 
+// listVersionsRequest lists all versions, unless a page is requested
+type listVersionsRequest struct {
+	listRequest
+	page *core.Page
+
+	// prereleases lists the pre-release versions, even if they're hidden by the policy of the namespace
+	prereleases bool
+}
+
 type listResponseVersion struct {
 	Version string `json:"version,omitempty"`
+
+	// SHA256 is the digest recorded when the version was published, it's ignored by Terraform
+	SHA256 string `json:"sha256,omitempty"`
 }
 
 type listResponseModule struct {
@@ -25,11 +44,12 @@ type listResponseModule struct {
 
 type listResponse struct {
 	Modules []listResponseModule `json:"modules,omitempty"`
+	Meta    *core.PageMeta       `json:"meta,omitempty"`
 }
 
 func listEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(listRequest)
+		req := request.(listVersionsRequest)
 
 		metrics.ListVersions.With(prometheus.Labels{
 			o11y.NamespaceLabel: req.namespace,
@@ -37,16 +57,30 @@ func listEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoint {
 			o11y.ProviderLabel:  req.provider,
 		}).Inc()
 
+		if req.prereleases {
+			ctx = core.WithPrereleases(ctx)
+		}
 		res, err := svc.ListModuleVersions(ctx, req.namespace, req.name, req.provider)
 		if err != nil {
 			return nil, err
 		}
+		res = sortedVersions(res, metrics, req.namespace, req.name, req.provider)
+		if len(res) == 0 {
+			// Terraform reports unknown modules as not found instead of listing no versions
+			return nil, fmt.Errorf("%w: %s/%s/%s", ErrModuleNotFound, req.namespace, req.name, req.provider)
+		}
+
+		var meta *core.PageMeta
+		if req.page != nil {
+			res, meta = core.Paginate(res, *req.page)
+		}
 
 		var versions []listResponseVersion
 
 		for _, module := range res {
 			versions = append(versions, listResponseVersion{
 				Version: module.Version,
+				SHA256:  module.SHA256,
 			})
 		}
 
@@ -56,18 +90,133 @@ func listEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoint {
 					Versions: versions,
 				},
 			},
+			Meta: meta,
 		}, nil
 	}
 }
 
+// maxBatchListModules is the maximum number of modules whose versions can be listed with a single request
+const maxBatchListModules = 500
+
+// batchListParallelism is the number of modules whose versions are listed concurrently
+const batchListParallelism = 16
+
+type batchListRequest struct {
+	// Modules are addresses in the form <namespace>/<name>/<provider>
+	Modules []string `json:"modules"`
+}
+
+type batchListResponseModule struct {
+	Source   string                `json:"source"`
+	Versions []listResponseVersion `json:"versions,omitempty"`
+
+	// Error is set instead of the versions if the versions of the module couldn't be listed
+	Error string `json:"error,omitempty"`
+}
+
+type batchListResponse struct {
+	Modules []batchListResponseModule `json:"modules"`
+}
+
+// batchListEndpoint lists the versions of many modules with a single request.
+// A module which can't be listed doesn't fail the whole request, its error is returned in place of its versions.
+func batchListEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(batchListRequest)
+
+		res := batchListResponse{Modules: make([]batchListResponseModule, len(req.Modules))}
+		group, groupCtx := errgroup.WithContext(ctx)
+		group.SetLimit(batchListParallelism)
+
+		for i, source := range req.Modules {
+			res.Modules[i].Source = source
+			group.Go(func() error {
+				versions, err := listVersions(groupCtx, svc, metrics, source)
+				if err != nil {
+					res.Modules[i].Error = err.Error()
+				} else {
+					res.Modules[i].Versions = versions
+				}
+				return nil
+			})
+		}
+
+		_ = group.Wait()
+		return res, ctx.Err()
+	}
+}
+
+func listVersions(ctx context.Context, svc Service, metrics *o11y.ModuleMetrics, source string) ([]listResponseVersion, error) {
+	parts := strings.Split(source, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("%w: module address %q must be in the form <namespace>/<name>/<provider>", core.ErrVarType, source)
+	}
+	namespace, name, provider := parts[0], parts[1], parts[2]
+
+	metrics.ListVersions.With(prometheus.Labels{
+		o11y.NamespaceLabel: namespace,
+		o11y.NameLabel:      name,
+		o11y.ProviderLabel:  provider,
+	}).Inc()
+
+	modules, err := svc.ListModuleVersions(ctx, namespace, name, provider)
+	if err != nil {
+		return nil, err
+	}
+	modules = sortedVersions(modules, metrics, namespace, name, provider)
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrModuleNotFound, source)
+	}
+
+	versions := make([]listResponseVersion, 0, len(modules))
+	for _, module := range modules {
+		versions = append(versions, listResponseVersion{Version: module.Version, SHA256: module.SHA256})
+	}
+	return versions, nil
+}
+
+// sortedVersions returns the modules with a valid semantic version, sorted with the highest version first,
+// as the storage backends don't guarantee an order. Invalid versions are omitted with a warning.
+func sortedVersions(modules []core.Module, metrics *o11y.ModuleMetrics, namespace, name, provider string) []core.Module {
+	modules, invalid := core.ValidVersions(modules, func(m core.Module) string { return m.Version })
+	if len(invalid) > 0 {
+		slog.Warn("omitted invalid module versions from the listing",
+			slog.String("namespace", namespace),
+			slog.String("name", name),
+			slog.String("provider", provider),
+			slog.Any("versions", invalid),
+		)
+		metrics.InvalidVersions.With(prometheus.Labels{
+			o11y.NamespaceLabel: namespace,
+			o11y.NameLabel:      name,
+			o11y.ProviderLabel:  provider,
+		}).Add(float64(len(invalid)))
+	}
+
+	core.SortByVersion(modules, func(m core.Module) string { return m.Version })
+	return modules
+}
+
 type downloadRequest struct {
 	namespace string
 	name      string
 	provider  string
 	version   string
+	checksum  string // optional, the download is rejected if the archive doesn't match the expected checksum
+
+	// archiveFormat is optional, the archive in the format is served if the module version exists in multiple formats
+	archiveFormat string
+
+	// subdir is optional, only the sub-directory of the module is served as tar.gz archive if it's set
+	subdir string
 }
 
-type downloadResponse struct{ url string }
+type downloadResponse struct {
+	url            string
+	checksum       string
+	signatureURL   string
+	attestationURL string
+}
 
 func downloadEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -80,13 +229,453 @@ func downloadEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoin
 			o11y.VersionLabel:   req.version,
 		}).Inc()
 
+		if req.archiveFormat != "" {
+			ctx = WithArchiveFormat(ctx, req.archiveFormat)
+		}
+		if req.subdir != "" {
+			ctx = WithSubdir(ctx, req.subdir)
+		}
+
+		// The checksum is verified first, so that rejected downloads aren't counted
+		checksum, err := svc.GetModuleChecksum(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.checksum != "" {
+			if err := VerifyChecksum(req.checksum, checksum); err != nil {
+				return nil, err
+			}
+		}
+
 		res, err := svc.GetModule(ctx, req.namespace, req.name, req.provider, req.version)
 		if err != nil {
 			return nil, err
 		}
 
+		// The checksum is passed on to go-getter, which verifies the archive end-to-end after downloading it
 		return downloadResponse{
-			url: res.DownloadURL,
+			url:            withChecksum(res.DownloadURL, checksum),
+			checksum:       FormatChecksum(checksum),
+			signatureURL:   res.SignatureURL,
+			attestationURL: res.AttestationURL,
+		}, nil
+	}
+}
+
+type resolveRequest struct {
+	namespace  string
+	name       string
+	provider   string
+	constraint string // optional, the latest stable version is resolved if empty
+
+	archiveFormat string // optional, see downloadRequest
+	subdir        string // optional, see downloadRequest
+}
+
+type resolveResponse struct {
+	Namespace      string `json:"namespace"`
+	Name           string `json:"name"`
+	Provider       string `json:"provider"`
+	Version        string `json:"version"`
+	DownloadURL    string `json:"download_url"`
+	Checksum       string `json:"checksum"`
+	SignatureURL   string `json:"signature_url,omitempty"`
+	AttestationURL string `json:"attestation_url,omitempty"`
+}
+
+// resolveEndpoint returns the highest version matching the constraint together with its download,
+// so that clients don't have to resolve version constraints themselves
+func resolveEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resolveRequest)
+
+		modules, err := svc.ListModuleVersions(ctx, req.namespace, req.name, req.provider)
+		if err != nil {
+			return nil, err
+		}
+
+		versions := make([]string, 0, len(modules))
+		for _, m := range modules {
+			versions = append(versions, m.Version)
+		}
+		version, err := core.MatchVersion(versions, req.constraint)
+		if err != nil {
+			return nil, err
+		} else if version == "" {
+			return nil, fmt.Errorf("%w: no version of %s/%s/%s matches %q", ErrModuleNotFound, req.namespace, req.name, req.provider, req.constraint)
+		}
+
+		metrics.Download.With(prometheus.Labels{
+			o11y.NamespaceLabel: req.namespace,
+			o11y.NameLabel:      req.name,
+			o11y.ProviderLabel:  req.provider,
+			o11y.VersionLabel:   version,
+		}).Inc()
+
+		if req.archiveFormat != "" {
+			ctx = WithArchiveFormat(ctx, req.archiveFormat)
+		}
+		if req.subdir != "" {
+			ctx = WithSubdir(ctx, req.subdir)
+		}
+		checksum, err := svc.GetModuleChecksum(ctx, req.namespace, req.name, req.provider, version)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := svc.GetModule(ctx, req.namespace, req.name, req.provider, version)
+		if err != nil {
+			return nil, err
+		}
+
+		return resolveResponse{
+			Namespace:      req.namespace,
+			Name:           req.name,
+			Provider:       req.provider,
+			Version:        version,
+			DownloadURL:    res.DownloadURL,
+			Checksum:       FormatChecksum(checksum),
+			SignatureURL:   res.SignatureURL,
+			AttestationURL: res.AttestationURL,
+		}, nil
+	}
+}
+
+type checksumResponse struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Version   string `json:"version"`
+	Checksum  string `json:"checksum"`
+}
+
+func checksumEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(downloadRequest)
+
+		if req.archiveFormat != "" {
+			ctx = WithArchiveFormat(ctx, req.archiveFormat)
+		}
+		if req.subdir != "" {
+			ctx = WithSubdir(ctx, req.subdir)
+		}
+		checksum, err := svc.GetModuleChecksum(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		return checksumResponse{
+			Namespace: req.namespace,
+			Name:      req.name,
+			Provider:  req.provider,
+			Version:   req.version,
+			Checksum:  FormatChecksum(checksum),
+		}, nil
+	}
+}
+
+type sbomResponse struct {
+	document []byte
+}
+
+func sbomEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(downloadRequest)
+
+		document, err := svc.GetModuleSBOM(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+		return sbomResponse{document: document}, nil
+	}
+}
+
+type diffRequest struct {
+	namespace string
+	name      string
+	provider  string
+	from      string
+	to        string
+}
+
+func diffEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(diffRequest)
+		return svc.DiffModuleVersions(ctx, req.namespace, req.name, req.provider, req.from, req.to)
+	}
+}
+
+type downloadStatsResponse struct {
+	*core.DownloadStats
+}
+
+func downloadStatsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+
+		res, err := svc.GetDownloadStats(ctx, req.namespace, req.name, req.provider)
+		if err != nil {
+			return nil, err
+		}
+
+		return downloadStatsResponse{res}, nil
+	}
+}
+
+type consumersResponse struct {
+	Consumers core.Consumers `json:"consumers"`
+}
+
+func consumersEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+
+		res, err := svc.ListConsumers(ctx, req.namespace, req.name, req.provider)
+		if err != nil {
+			return nil, err
+		}
+
+		return consumersResponse{Consumers: res}, nil
+	}
+}
+
+type snippetsRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string // optional, the latest version is used if empty
+	hostname  string
+}
+
+type snippetsResponse struct {
+	Hostname      string   `json:"hostname"`
+	Namespace     string   `json:"namespace"`
+	Name          string   `json:"name"`
+	Provider      string   `json:"provider"`
+	Version       string   `json:"version"`
+	LatestVersion string   `json:"latest_version"`
+	Snippets      Snippets `json:"snippets"`
+}
+
+func snippetsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(snippetsRequest)
+
+		res, err := svc.ListModuleVersions(ctx, req.namespace, req.name, req.provider)
+		if err != nil {
+			return nil, err
+		}
+
+		latest, err := LatestVersion(res)
+		if err != nil {
+			return nil, err
+		}
+
+		module := latest
+		if req.version != "" {
+			found := false
+			for _, m := range res {
+				if m.Version == req.version {
+					module, found = m, true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%w: version %s", ErrModuleNotFound, req.version)
+			}
+		}
+
+		return snippetsResponse{
+			Hostname:      req.hostname,
+			Namespace:     req.namespace,
+			Name:          req.name,
+			Provider:      req.provider,
+			Version:       module.Version,
+			LatestVersion: latest.Version,
+			Snippets:      NewSnippets(req.hostname, module),
 		}, nil
 	}
 }
+
+type republishRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+	force     bool
+	reason    string // optional, recorded in the audit event
+	body      io.Reader
+}
+
+type republishResponse struct {
+	Namespace        string `json:"namespace"`
+	Name             string `json:"name"`
+	Provider         string `json:"provider"`
+	Version          string `json:"version"`
+	Checksum         string `json:"checksum"`
+	PreviousChecksum string `json:"previous_checksum"`
+}
+
+func republishEndpoint(storage Storage, replacer ReplaceStorage, policy OverwritePolicy) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(republishRequest)
+
+		if !req.force {
+			return nil, ErrForceRequired
+		}
+		if !policy.Allowed(req.namespace) {
+			return nil, fmt.Errorf("%w: overwrites aren't allowed in namespace %s", ErrModuleImmutable, req.namespace)
+		}
+
+		previous, err := storage.GetModuleChecksum(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := replacer.ReplaceModule(ctx, req.namespace, req.name, req.provider, req.version, req.body); err != nil {
+			return nil, err
+		}
+
+		checksum, err := storage.GetModuleChecksum(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		o11y.Audit(ctx, "module.republish",
+			slog.String("module", path.Join(req.namespace, req.name, req.provider, req.version)),
+			slog.String("previous-checksum", FormatChecksum(previous)),
+			slog.String("checksum", FormatChecksum(checksum)),
+			slog.String("reason", req.reason),
+		)
+
+		return republishResponse{
+			Namespace:        req.namespace,
+			Name:             req.name,
+			Provider:         req.provider,
+			Version:          req.version,
+			Checksum:         FormatChecksum(checksum),
+			PreviousChecksum: FormatChecksum(previous),
+		}, nil
+	}
+}
+
+type channelsResponse struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Provider  string        `json:"provider"`
+	Channels  core.Channels `json:"channels"`
+}
+
+func channelsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+
+		channels, err := svc.ListChannels(ctx, req.namespace, req.name, req.provider)
+		if err != nil {
+			return nil, err
+		}
+
+		return channelsResponse{
+			Namespace: req.namespace,
+			Name:      req.name,
+			Provider:  req.provider,
+			Channels:  channels,
+		}, nil
+	}
+}
+
+type channelRequest struct {
+	namespace string
+	name      string
+	provider  string
+	channel   string
+	Version   string `json:"version"` // only used when setting the channel
+}
+
+func setChannelEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(channelRequest)
+
+		if err := svc.SetChannel(ctx, req.namespace, req.name, req.provider, req.channel, req.Version); err != nil {
+			return nil, err
+		}
+
+		o11y.Audit(ctx, "module.channel.set",
+			slog.String("module", path.Join(req.namespace, req.name, req.provider)),
+			slog.String("channel", req.channel),
+			slog.String("version", req.Version),
+		)
+
+		return listEndpointChannels(ctx, svc, req)
+	}
+}
+
+func deleteChannelEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(channelRequest)
+
+		if err := svc.DeleteChannel(ctx, req.namespace, req.name, req.provider, req.channel); err != nil {
+			return nil, err
+		}
+
+		o11y.Audit(ctx, "module.channel.delete",
+			slog.String("module", path.Join(req.namespace, req.name, req.provider)),
+			slog.String("channel", req.channel),
+		)
+
+		return listEndpointChannels(ctx, svc, req)
+	}
+}
+
+// listEndpointChannels returns the channels of the module after they have been changed
+func listEndpointChannels(ctx context.Context, svc Service, req channelRequest) (interface{}, error) {
+	return channelsEndpoint(svc)(ctx, listRequest{namespace: req.namespace, name: req.name, provider: req.provider})
+}
+
+type stagingResponse struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Provider  string   `json:"provider"`
+	Version   string   `json:"version"`
+	Publisher string   `json:"publisher,omitempty"`
+	Reviewers []string `json:"reviewers"`
+	Promoted  bool     `json:"promoted"`
+}
+
+func newStagingResponse(req downloadRequest, staging *Staging, promoted bool) stagingResponse {
+	return stagingResponse{
+		Namespace: req.namespace,
+		Name:      req.name,
+		Provider:  req.provider,
+		Version:   req.version,
+		Publisher: staging.Publisher,
+		Reviewers: staging.Reviewers(),
+		Promoted:  promoted,
+	}
+}
+
+// approveEndpoint records the approval of the authenticated client, which is the reviewer
+func approveEndpoint(promoter *Promoter) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(downloadRequest)
+
+		staging, err := promoter.Approve(ctx, req.namespace, req.name, req.provider, req.version, core.Identity(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return newStagingResponse(req, staging, false), nil
+	}
+}
+
+func promoteEndpoint(promoter *Promoter) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(downloadRequest)
+
+		staging, err := promoter.Promote(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+		return newStagingResponse(req, staging, true), nil
+	}
+}
@@ -0,0 +1,200 @@
+package module
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepublishEndpoint(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	_, err := storage.UploadModule(ctx, "sandbox", "s3", "aws", "1.0.0", strings.NewReader("old"))
+	assert.NoError(t, err)
+	_, err = storage.UploadModule(ctx, "acme", "s3", "aws", "1.0.0", strings.NewReader("old"))
+	assert.NoError(t, err)
+
+	e := republishEndpoint(storage, storage.(ReplaceStorage), NewOverwritePolicy([]string{"sandbox"}))
+	req := republishRequest{namespace: "sandbox", name: "s3", provider: "aws", version: "1.0.0", body: strings.NewReader("new")}
+
+	_, err = e(ctx, req)
+	assert.ErrorIs(t, err, ErrForceRequired)
+
+	req.force = true
+	res, err := e(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:11507a0e2f5e69d5dfa40a62a1bd7b6ee57e6bcd85c67c9b8431b36fff21c437", res.(republishResponse).Checksum)
+	assert.Equal(t, "sha256:cba06b5736faf67e54b07b561eae94395e774c517a7d910a54369e1263ccfbd4", res.(republishResponse).PreviousChecksum)
+
+	req.namespace = "acme"
+	_, err = e(ctx, req)
+	assert.ErrorIs(t, err, ErrModuleImmutable)
+
+	req.namespace = "sandbox"
+	req.version = "2.0.0"
+	_, err = e(ctx, req)
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+}
+
+func TestBatchListEndpoint(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		_, err := storage.UploadModule(ctx, "acme", "vpc", "aws", v, strings.NewReader(v))
+		assert.NoError(t, err)
+	}
+
+	metrics := &o11y.ModuleMetrics{
+		ListVersions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "list_versions_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.ProviderLabel}),
+	}
+	e := batchListEndpoint(NewService(storage, nil), metrics)
+
+	res, err := e(ctx, batchListRequest{Modules: []string{"acme/vpc/aws", "acme/unknown/aws", "acme/vpc"}})
+	assert.NoError(t, err)
+
+	modules := res.(batchListResponse).Modules
+	if assert.Len(t, modules, 3) {
+		assert.Equal(t, "acme/vpc/aws", modules[0].Source)
+		assert.ElementsMatch(t, []listResponseVersion{{Version: "1.0.0"}, {Version: "1.1.0"}}, modules[0].Versions)
+		assert.Empty(t, modules[0].Error)
+
+		assert.Equal(t, "acme/unknown/aws", modules[1].Source)
+		assert.Contains(t, modules[1].Error, "no modules found")
+
+		assert.Equal(t, "acme/vpc", modules[2].Source)
+		assert.Contains(t, modules[2].Error, "must be in the form <namespace>/<name>/<provider>")
+	}
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, v := range []string{"1.0.0", "1.2.0", "1.10.0", "2.0.0-rc1"} {
+		_, err := storage.UploadModule(ctx, "acme", "vpc", "aws", v, strings.NewReader(v))
+		assert.NoError(t, err)
+	}
+
+	metrics := &o11y.ModuleMetrics{
+		Download: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "download_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.ProviderLabel, o11y.VersionLabel}),
+	}
+	e := resolveEndpoint(NewService(storage, core.NewProxyUrlService(false, "/proxy")), metrics)
+
+	res, err := e(ctx, resolveRequest{namespace: "acme", name: "vpc", provider: "aws"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.10.0", res.(resolveResponse).Version)
+	assert.Equal(t, "sha256:e91dee3b412922e56d788d757cd30eaaae92b0846323abdb99eeb58b9cfe30c1", res.(resolveResponse).Checksum)
+
+	res, err = e(ctx, resolveRequest{namespace: "acme", name: "vpc", provider: "aws", constraint: "~> 1.2.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0", res.(resolveResponse).Version)
+
+	res, err = e(ctx, resolveRequest{namespace: "acme", name: "vpc", provider: "aws", constraint: "2.0.0-rc1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0-rc1", res.(resolveResponse).Version)
+
+	_, err = e(ctx, resolveRequest{namespace: "acme", name: "vpc", provider: "aws", constraint: ">= 2.0.0"})
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+
+	_, err = e(ctx, resolveRequest{namespace: "acme", name: "vpc", provider: "aws", constraint: "latest"})
+	assert.ErrorIs(t, err, core.ErrInvalidConstraint)
+}
+
+func TestListEndpoint_Page(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, v := range []string{"1.0.0", "1.10.0", "1.2.0", "2.0.0", "2.0.0-rc.1+build.5", "nightly"} {
+		_, err := storage.UploadModule(ctx, "acme", "vpc", "aws", v, strings.NewReader(v))
+		assert.NoError(t, err)
+	}
+
+	metrics := &o11y.ModuleMetrics{
+		ListVersions:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "list_versions_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.ProviderLabel}),
+		InvalidVersions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "invalid_versions_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.ProviderLabel}),
+	}
+	e := listEndpoint(NewService(storage, nil), metrics)
+	list := listRequest{namespace: "acme", name: "vpc", provider: "aws"}
+
+	res, err := e(ctx, listVersionsRequest{listRequest: list})
+	assert.NoError(t, err)
+	assert.Equal(t, []listResponseVersion{{Version: "2.0.0"}, {Version: "2.0.0-rc.1+build.5"}, {Version: "1.10.0"}, {Version: "1.2.0"}, {Version: "1.0.0"}}, res.(listResponse).Modules[0].Versions)
+	assert.Nil(t, res.(listResponse).Meta)
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, registry.Register(metrics.InvalidVersions))
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, families[0].GetMetric()[0].GetCounter().GetValue())
+
+	res, err = e(ctx, listVersionsRequest{listRequest: list, page: &core.Page{Limit: 3, Offset: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, []listResponseVersion{{Version: "2.0.0-rc.1+build.5"}, {Version: "1.10.0"}, {Version: "1.2.0"}}, res.(listResponse).Modules[0].Versions)
+	assert.Equal(t, 5, res.(listResponse).Meta.TotalCount)
+	assert.Equal(t, 4, *res.(listResponse).Meta.NextOffset)
+	assert.Equal(t, 0, *res.(listResponse).Meta.PrevOffset)
+}
+
+func TestListEndpoint_Prereleases(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, v := range []string{"1.0.0", "1.1.0-rc.1"} {
+		_, err := storage.UploadModule(ctx, "acme", "vpc", "aws", v, strings.NewReader(v))
+		assert.NoError(t, err)
+	}
+
+	metrics := &o11y.ModuleMetrics{
+		ListVersions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "list_versions_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.ProviderLabel}),
+	}
+	svc := PrereleaseMiddleware(core.NewPrereleasePolicy(true, nil))(NewService(storage, nil))
+	e := listEndpoint(svc, metrics)
+	list := listRequest{namespace: "acme", name: "vpc", provider: "aws"}
+
+	res, err := e(ctx, listVersionsRequest{listRequest: list})
+	assert.NoError(t, err)
+	assert.Equal(t, []listResponseVersion{{Version: "1.0.0"}}, res.(listResponse).Modules[0].Versions)
+
+	res, err = e(ctx, listVersionsRequest{listRequest: list, prereleases: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []listResponseVersion{{Version: "1.1.0-rc.1"}, {Version: "1.0.0"}}, res.(listResponse).Modules[0].Versions)
+}
+
+// digestStorage returns the versions of the storage with the digest recorded when they were published
+type digestStorage struct {
+	Storage
+}
+
+func (s digestStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	modules, err := s.Storage.ListModuleVersions(ctx, namespace, name, provider)
+	for i := range modules {
+		modules[i].SHA256 = "sha256-" + modules[i].Version
+	}
+	return modules, err
+}
+
+func TestListEndpoint_Digests(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		_, err := storage.UploadModule(ctx, "acme", "vpc", "aws", v, strings.NewReader(v))
+		assert.NoError(t, err)
+	}
+
+	metrics := &o11y.ModuleMetrics{
+		ListVersions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "list_versions_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.ProviderLabel}),
+	}
+	svc := NewService(digestStorage{storage}, nil)
+	expected := []listResponseVersion{{Version: "1.1.0", SHA256: "sha256-1.1.0"}, {Version: "1.0.0", SHA256: "sha256-1.0.0"}}
+
+	res, err := listEndpoint(svc, metrics)(ctx, listVersionsRequest{listRequest: listRequest{namespace: "acme", name: "vpc", provider: "aws"}})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, res.(listResponse).Modules[0].Versions)
+
+	res, err = batchListEndpoint(svc, metrics)(ctx, batchListRequest{Modules: []string{"acme/vpc/aws"}})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, res.(batchListResponse).Modules[0].Versions)
+}
@@ -8,4 +8,20 @@ var (
 	ErrModuleUploadFailed  = errors.New("failed to upload module")
 	ErrModuleAlreadyExists = errors.New("module already exists")
 	ErrModuleListFailed    = errors.New("failed to list module versions")
+	ErrModuleImmutable     = errors.New("module version is immutable")
+	ErrForceRequired       = errors.New("replacing a module version requires force=true")
+
+	// ErrInvalidArchiveFormat is returned for archive formats which aren't supported
+	ErrInvalidArchiveFormat = errors.New("unsupported module archive format")
+
+	// ErrInvalidSubdir is returned for sub-directories which are outside of the module archive
+	ErrInvalidSubdir = errors.New("invalid module sub-directory")
+
+	// ErrSubdirNotFound is returned if the module archive doesn't contain the requested sub-directory
+	ErrSubdirNotFound = errors.New("module sub-directory not found")
+
+	// Checksum errors
+	ErrInvalidChecksum  = errors.New("invalid module checksum")
+	ErrChecksumMismatch = errors.New("module checksum does not match")
+	ErrModuleCorrupted  = errors.New("module archive doesn't match the checksum manifest")
 )
@@ -0,0 +1,67 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// ArchiveFormatTarGz is the format of the archives created by the upload command and of the cached upstream modules
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+
+	archiveFormatQueryParam = "archive_format"
+)
+
+// ArchiveFormats are the module archive formats which are detected in the storage in addition to the configured format
+var ArchiveFormats = []string{ArchiveFormatTarGz, "tgz", ArchiveFormatZip}
+
+// archiveFormatMediaTypes maps the media types of the Accept header to the archive formats
+var archiveFormatMediaTypes = map[string]string{
+	"application/gzip":             ArchiveFormatTarGz,
+	"application/x-gzip":           ArchiveFormatTarGz,
+	"application/x-gtar":           ArchiveFormatTarGz,
+	"application/zip":              ArchiveFormatZip,
+	"application/x-zip":            ArchiveFormatZip,
+	"application/x-zip-compressed": ArchiveFormatZip,
+}
+
+type archiveFormatKey struct{}
+
+// WithArchiveFormat returns a context preferring the archive format, e.g. when a module version exists in multiple formats.
+// The format of uploaded archives is taken from the context as well.
+func WithArchiveFormat(ctx context.Context, format string) context.Context {
+	return context.WithValue(ctx, archiveFormatKey{}, format)
+}
+
+// ArchiveFormatFromContext returns the preferred archive format, or an empty string if there's no preference
+func ArchiveFormatFromContext(ctx context.Context) string {
+	format, _ := ctx.Value(archiveFormatKey{}).(string)
+	return format
+}
+
+// ArchiveFormatFromRequest returns the archive format requested with the archive_format query parameter or the Accept header.
+// An empty string is returned if the client has no preference.
+func ArchiveFormatFromRequest(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get(archiveFormatQueryParam); format != "" {
+		if !slices.Contains(ArchiveFormats, format) {
+			return "", fmt.Errorf("%w: %s", ErrInvalidArchiveFormat, format)
+		}
+		return format, nil
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if format, ok := archiveFormatMediaTypes[mediaType]; ok {
+			return format, nil
+		}
+	}
+	return "", nil
+}
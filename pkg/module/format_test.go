@@ -0,0 +1,68 @@
+package module
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveFormatFromRequest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+		err    error
+	}{
+		{
+			name:   "no preference",
+			target: "/download",
+			accept: "*/*",
+		},
+		{
+			name:   "query parameter",
+			target: "/download?archive_format=zip",
+			accept: "application/gzip",
+			want:   ArchiveFormatZip,
+		},
+		{
+			name:   "unsupported query parameter",
+			target: "/download?archive_format=rar",
+			err:    ErrInvalidArchiveFormat,
+		},
+		{
+			name:   "accept zip",
+			target: "/download",
+			accept: "text/html, application/zip;q=0.9",
+			want:   ArchiveFormatZip,
+		},
+		{
+			name:   "accept gzip",
+			target: "/download",
+			accept: "application/x-gzip",
+			want:   ArchiveFormatTarGz,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tc.target, nil)
+			r.Header.Set("Accept", tc.accept)
+
+			format, err := ArchiveFormatFromRequest(r)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, format)
+		})
+	}
+}
+
+func TestArchiveFormatFromContext(t *testing.T) {
+	assert.Empty(t, ArchiveFormatFromContext(context.Background()))
+	assert.Equal(t, ArchiveFormatZip, ArchiveFormatFromContext(WithArchiveFormat(context.Background(), ArchiveFormatZip)))
+}
@@ -35,11 +35,11 @@ func (mw loggingMiddleware) ListModuleVersions(ctx context.Context, namespace, n
 			),
 		)
 		if err != nil {
-			logger.Error("failed to list module", slog.String("err", err.Error()))
+			logger.ErrorContext(ctx, "failed to list module", slog.String("err", err.Error()))
 			return
 		}
 
-		logger.Info("list module version", slog.String("took", time.Since(begin).String()))
+		logger.InfoContext(ctx, "list module version", slog.String("took", time.Since(begin).String()))
 	}(time.Now())
 
 	return mw.next.ListModuleVersions(ctx, namespace, name, provider)
@@ -61,12 +61,207 @@ func (mw loggingMiddleware) GetModule(ctx context.Context, namespace, name, prov
 		)
 
 		if err != nil {
-			logger.Error("failed to get module", slog.String("err", err.Error()))
+			logger.ErrorContext(ctx, "failed to get module", slog.String("err", err.Error()))
 			return
 		}
 
-		logger.Info("get module", slog.String("took", time.Since(begin).String()), slog.String("module", module.ID(true)))
+		logger.InfoContext(ctx, "get module", slog.String("took", time.Since(begin).String()), slog.String("module", module.ID(true)))
 	}(time.Now())
 
 	return mw.next.GetModule(ctx, namespace, name, provider, version)
 }
+
+func (mw loggingMiddleware) GetModuleChecksum(ctx context.Context, namespace, name, provider, version string) (checksum string, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetModuleChecksum"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("version", version),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get module checksum", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get module checksum", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetModuleChecksum(ctx, namespace, name, provider, version)
+}
+
+func (mw loggingMiddleware) GetModuleSBOM(ctx context.Context, namespace, name, provider, version string) (document []byte, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetModuleSBOM"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("version", version),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get module SBOM", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get module SBOM", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetModuleSBOM(ctx, namespace, name, provider, version)
+}
+
+func (mw loggingMiddleware) DiffModuleVersions(ctx context.Context, namespace, name, provider, from, to string) (diff *Diff, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "DiffModuleVersions"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("from", from),
+				slog.String("to", to),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to diff module versions", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "diff module versions", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.DiffModuleVersions(ctx, namespace, name, provider, from, to)
+}
+
+func (mw loggingMiddleware) GetDownloadStats(ctx context.Context, namespace, name, provider string) (stats *core.DownloadStats, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetDownloadStats"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get module download stats", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get module download stats", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetDownloadStats(ctx, namespace, name, provider)
+}
+
+func (mw loggingMiddleware) ListConsumers(ctx context.Context, namespace, name, provider string) (consumers core.Consumers, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "ListConsumers"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to list module consumers", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "list module consumers", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.ListConsumers(ctx, namespace, name, provider)
+}
+
+func (mw loggingMiddleware) ListChannels(ctx context.Context, namespace, name, provider string) (channels core.Channels, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "ListChannels"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to list module channels", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "list module channels", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.ListChannels(ctx, namespace, name, provider)
+}
+
+func (mw loggingMiddleware) SetChannel(ctx context.Context, namespace, name, provider, channel, version string) (err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "SetChannel"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("channel", channel),
+				slog.String("version", version),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to set module channel", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "set module channel", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.SetChannel(ctx, namespace, name, provider, channel, version)
+}
+
+func (mw loggingMiddleware) DeleteChannel(ctx context.Context, namespace, name, provider, channel string) (err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "DeleteChannel"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("channel", channel),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to delete module channel", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "delete module channel", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.DeleteChannel(ctx, namespace, name, provider, channel)
+}
+
+type prereleaseMiddleware struct {
+	Service
+	policy core.PrereleasePolicy
+}
+
+// PrereleaseMiddleware omits the pre-release versions from the listings of the namespaces in which the policy hides them
+func PrereleaseMiddleware(policy core.PrereleasePolicy) Middleware {
+	return func(next Service) Service {
+		return &prereleaseMiddleware{Service: next, policy: policy}
+	}
+}
+
+func (mw prereleaseMiddleware) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	modules, err := mw.Service.ListModuleVersions(ctx, namespace, name, provider)
+	if err != nil || mw.policy.Visible(ctx, namespace) {
+		return modules, err
+	}
+	return core.HidePrereleases(modules, func(m core.Module) string { return m.Version }), nil
+}
@@ -0,0 +1,30 @@
+package module
+
+// OverwritePolicy decides in which namespaces existing module versions can be replaced.
+// Module versions are immutable unless their namespace is explicitly allowed.
+type OverwritePolicy struct {
+	all        bool
+	namespaces map[string]bool
+}
+
+// NewOverwritePolicy returns a policy allowing overwrites in the namespaces, * allows overwrites in all namespaces
+func NewOverwritePolicy(namespaces []string) OverwritePolicy {
+	p := OverwritePolicy{namespaces: make(map[string]bool, len(namespaces))}
+	for _, namespace := range namespaces {
+		if namespace == "*" {
+			p.all = true
+		}
+		p.namespaces[namespace] = true
+	}
+	return p
+}
+
+// Allowed returns true if module versions of the namespace can be replaced
+func (p OverwritePolicy) Allowed(namespace string) bool {
+	return p.all || p.namespaces[namespace]
+}
+
+// Enabled returns true if overwrites are allowed in at least one namespace
+func (p OverwritePolicy) Enabled() bool {
+	return len(p.namespaces) > 0
+}
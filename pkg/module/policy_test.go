@@ -0,0 +1,21 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverwritePolicy(t *testing.T) {
+	p := NewOverwritePolicy(nil)
+	assert.False(t, p.Enabled())
+	assert.False(t, p.Allowed("acme"))
+
+	p = NewOverwritePolicy([]string{"sandbox"})
+	assert.True(t, p.Enabled())
+	assert.True(t, p.Allowed("sandbox"))
+	assert.False(t, p.Allowed("acme"))
+
+	p = NewOverwritePolicy([]string{"*"})
+	assert.True(t, p.Allowed("acme"))
+}
@@ -0,0 +1,234 @@
+package module
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxExtractedArchiveSize limits the total size of the files extracted from a module archive, as they are held in memory.
+// Archives with a high compression ratio would exhaust the memory otherwise.
+const maxExtractedArchiveSize = 512 << 20
+
+type archiveFile struct {
+	name string
+	mode int64
+	data []byte
+}
+
+// repackArchive extracts the module from the archive of the source and packages it as tar.gz,
+// so that it can be served like the modules published with the upload command.
+func repackArchive(data []byte, src *moduleSource) ([]byte, error) {
+	var files []archiveFile
+	var err error
+	if src.format == "zip" {
+		files, err = readZip(data)
+	} else {
+		files, err = readTarGz(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module archive: %w", err)
+	}
+
+	if src.stripRoot {
+		if files, err = stripPrefix(files, rootDir(files)); err != nil {
+			return nil, err
+		}
+	}
+	if src.subdir != "" {
+		if files, err = stripPrefix(files, src.subdir); err != nil {
+			return nil, err
+		}
+	}
+
+	return writeTarGz(files)
+}
+
+// writeTarGz packages the files as tar.gz, like the upload command
+func writeTarGz(files []archiveFile) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		header := &tar.Header{
+			Name:     f.name,
+			Mode:     f.mode,
+			Size:     int64(len(f.data)),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func readTarGz(data []byte) ([]archiveFile, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	var files []archiveFile
+	limit := newExtractionLimit(maxExtractedArchiveSize, 0)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Directories are implied by the files, links are not supported by the upload command either
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := limit.read(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := newArchiveFile(header.Name, header.Mode, content)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+func readZip(data []byte) ([]archiveFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []archiveFile
+	limit := newExtractionLimit(maxExtractedArchiveSize, 0)
+	for _, zf := range zr.File {
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := limit.read(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := newArchiveFile(zf.Name, int64(zf.Mode().Perm()), content)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// extractionLimit tracks the size of the files extracted from an archive, independent of the sizes declared by the archive
+type extractionLimit struct {
+	max       int64
+	perFile   int64
+	remaining int64
+}
+
+// newExtractionLimit limits the total size of the extracted files to max, and the size of every file to perFile unless it's 0
+func newExtractionLimit(max, perFile int64) *extractionLimit {
+	return &extractionLimit{max: max, perFile: perFile, remaining: max}
+}
+
+// read returns the content of the file, or an error once the file or the extracted files exceed their limit
+func (l *extractionLimit) read(r io.Reader) ([]byte, error) {
+	limit := l.remaining
+	if l.perFile > 0 && l.perFile < limit {
+		limit = l.perFile
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.remaining {
+		return nil, fmt.Errorf("the files extracted from the module archive exceed the maximum size of %d bytes", l.max)
+	} else if l.perFile > 0 && int64(len(data)) > l.perFile {
+		return nil, fmt.Errorf("a file of the module archive exceeds the maximum size of %d bytes", l.perFile)
+	}
+	l.remaining -= int64(len(data))
+	return data, nil
+}
+
+func newArchiveFile(name string, mode int64, data []byte) (archiveFile, error) {
+	cleaned, err := cleanArchivePath(name)
+	if err != nil {
+		return archiveFile{}, err
+	}
+
+	return archiveFile{name: cleaned, mode: mode, data: data}, nil
+}
+
+// cleanArchivePath returns the cleaned path of a file in the archive, paths outside of the archive are rejected
+func cleanArchivePath(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(name, "./"))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("the module archive contains the unsafe path %s", name)
+	}
+	return cleaned, nil
+}
+
+// rootDir returns the top-level directory, if all files are located in it
+func rootDir(files []archiveFile) string {
+	var root string
+	for _, f := range files {
+		dir, _, ok := strings.Cut(f.name, "/")
+		if !ok || (root != "" && dir != root) {
+			return ""
+		}
+		root = dir
+	}
+	return root
+}
+
+// stripPrefix returns the files within the directory with paths relative to it
+func stripPrefix(files []archiveFile, dir string) ([]archiveFile, error) {
+	if dir == "" {
+		return files, nil
+	}
+
+	var stripped []archiveFile
+	for _, f := range files {
+		if name, ok := strings.CutPrefix(f.name, dir+"/"); ok {
+			f.name = name
+			stripped = append(stripped, f)
+		}
+	}
+	if len(stripped) == 0 {
+		return nil, fmt.Errorf("the module archive doesn't contain the directory %s", dir)
+	}
+
+	return stripped, nil
+}
@@ -1,9 +1,20 @@
 package module
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/channel"
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/stats"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // Service implements the Module Registry Protocol.
@@ -11,34 +22,160 @@ import (
 type Service interface {
 	GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error)
 	ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error)
+	GetModuleChecksum(ctx context.Context, namespace, name, provider, version string) (string, error)
+	GetDownloadStats(ctx context.Context, namespace, name, provider string) (*core.DownloadStats, error)
+
+	// GetModuleSBOM returns the SPDX or CycloneDX SBOM attached to the module version
+	GetModuleSBOM(ctx context.Context, namespace, name, provider, version string) ([]byte, error)
+
+	// DiffModuleVersions returns the difference between the archives of two versions of the module
+	DiffModuleVersions(ctx context.Context, namespace, name, provider, from, to string) (*Diff, error)
+
+	// ListConsumers returns which consumers downloaded which versions of the module
+	ListConsumers(ctx context.Context, namespace, name, provider string) (core.Consumers, error)
+
+	// ListChannels returns the channels of the module, the version endpoints accept channels in place of versions
+	ListChannels(ctx context.Context, namespace, name, provider string) (core.Channels, error)
+	SetChannel(ctx context.Context, namespace, name, provider, channel, version string) error
+	DeleteChannel(ctx context.Context, namespace, name, provider, channel string) error
 }
 
 type service struct {
-	storage Storage
-	proxy   core.ProxyUrlService
+	storage  Storage
+	proxy    core.ProxyUrlService
+	stats    stats.Recorder
+	tracker  stats.ConsumerTracker
+	redirect core.DownloadRedirector
+	upstream Upstream
+	channels *channel.Manager
+	readOnly bool
+
+	// fetches deduplicates concurrent fetches of the same module version from the upstream
+	fetches singleflight.Group
+}
+
+// ServiceOption provides additional options for the Service.
+type ServiceOption func(*service)
+
+// WithDownloadStats enables recording the downloads of modules
+func WithDownloadStats(recorder stats.Recorder) ServiceOption {
+	return func(s *service) {
+		s.stats = recorder
+	}
+}
+
+// WithConsumerTracking records which consumers download which versions of modules
+func WithConsumerTracking(tracker stats.ConsumerTracker) ServiceOption {
+	return func(s *service) {
+		s.tracker = tracker
+	}
+}
+
+// WithDownloadRedirect replaces the download URLs of modules with signed URLs of the redirect endpoint
+func WithDownloadRedirect(redirector core.DownloadRedirector) ServiceOption {
+	return func(s *service) {
+		s.redirect = redirector
+	}
+}
+
+// WithUpstream fetches modules missing in the storage from the upstream registry and caches them in the storage
+func WithUpstream(upstream Upstream) ServiceOption {
+	return func(s *service) {
+		s.upstream = upstream
+	}
+}
+
+// WithChannels resolves channels like stable to the version they point to
+func WithChannels(manager *channel.Manager) ServiceOption {
+	return func(s *service) {
+		s.channels = manager
+	}
+}
+
+// WithReadOnly serves the module sub-directories cached in the storage, but doesn't extract and cache further ones
+func WithReadOnly() ServiceOption {
+	return func(s *service) {
+		s.readOnly = true
+	}
 }
 
 // NewService returns a fully initialized Service.
-func NewService(storage Storage, proxy core.ProxyUrlService) Service {
-	return &service{
+func NewService(storage Storage, proxy core.ProxyUrlService, options ...ServiceOption) Service {
+	s := &service{
 		storage: storage,
 		proxy:   proxy,
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
 func (s *service) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	version, err := s.resolve(ctx, namespace, name, provider, version)
+	if err != nil {
+		return core.Module{}, err
+	}
+
 	res, err := s.storage.GetModule(ctx, namespace, name, provider, version)
+	if errors.Is(err, ErrModuleNotFound) && s.upstream != nil {
+		res, err = s.fetchUpstream(ctx, namespace, name, provider, version)
+	}
 	if err != nil {
 		return core.Module{}, err
 	}
 
-	if s.proxy.IsProxyEnabled(ctx) {
+	// The archives of sub-directories are served directly, as the redirect endpoint only serves whole modules
+	subdir := SubdirFromContext(ctx)
+	if subdir != "" {
+		if res, _, err = s.subdirModule(ctx, namespace, name, provider, version, subdir); err != nil {
+			return core.Module{}, err
+		}
+	}
+
+	if s.redirect != nil && subdir == "" {
+		u, err := url.Parse(res.DownloadURL)
+		if err != nil {
+			return core.Module{}, fmt.Errorf("failed to parse download URL: %w", err)
+		}
+
+		downloadUrl, err := s.redirect.ModuleURL(ctx, namespace, name, provider, version, path.Base(u.Path))
+		if err != nil {
+			return core.Module{}, err
+		}
+
+		res.DownloadURL = downloadUrl
+	} else if s.proxy.IsProxyEnabled(ctx) {
 		downloadUrl, err := s.proxy.GetProxyUrl(ctx, res.DownloadURL)
 		if err != nil {
 			return core.Module{}, err
 		}
 
 		res.DownloadURL = downloadUrl
+
+		if res.SignatureURL != "" {
+			res.SignatureURL, err = s.proxy.GetProxyUrl(ctx, res.SignatureURL)
+			if err != nil {
+				return core.Module{}, err
+			}
+		}
+		if res.AttestationURL != "" {
+			res.AttestationURL, err = s.proxy.GetProxyUrl(ctx, res.AttestationURL)
+			if err != nil {
+				return core.Module{}, err
+			}
+		}
+	}
+
+	// The redirect endpoint records the download once the archive is actually requested
+	if s.stats != nil && (s.redirect == nil || subdir != "") {
+		s.stats.Record(stats.ModuleArtifact(namespace, name, provider), version)
+	}
+	// Consumers are tracked even if downloads are redirected, as the redirect endpoint doesn't know the consumer
+	if s.tracker != nil {
+		s.tracker.Track(ctx, stats.ModuleArtifact(namespace, name, provider), version)
 	}
 
 	return res, err
@@ -50,5 +187,237 @@ func (s *service) ListModuleVersions(ctx context.Context, namespace, name, provi
 		return nil, err
 	}
 
+	if s.upstream == nil {
+		return res, nil
+	}
+
+	// Versions of the upstream are listed as well, they are fetched once they are downloaded
+	versions, err := s.upstream.ListModuleVersions(ctx, namespace, name, provider)
+	if err != nil {
+		if !errors.Is(err, ErrModuleNotFound) {
+			slog.Warn("failed to list module versions of the upstream registry, only cached versions are listed", slog.String("err", err.Error()))
+		}
+		return res, nil
+	}
+
+	known := make(map[string]bool, len(res))
+	for _, m := range res {
+		known[m.Version] = true
+	}
+	for _, version := range versions {
+		if !known[version] {
+			known[version] = true
+			res = append(res, core.Module{
+				Namespace: namespace,
+				Name:      name,
+				Provider:  provider,
+				Version:   version,
+			})
+		}
+	}
+
 	return res, nil
 }
+
+func (s *service) GetModuleChecksum(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	version, err := s.resolve(ctx, namespace, name, provider, version)
+	if err != nil {
+		return "", err
+	}
+
+	checksum, err := s.storage.GetModuleChecksum(ctx, namespace, name, provider, version)
+	if errors.Is(err, ErrModuleNotFound) && s.upstream != nil {
+		if _, err := s.fetchUpstream(ctx, namespace, name, provider, version); err != nil {
+			return "", err
+		}
+		checksum, err = s.storage.GetModuleChecksum(ctx, namespace, name, provider, version)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if subdir := SubdirFromContext(ctx); subdir != "" {
+		_, checksum, err = s.subdirModule(ctx, namespace, name, provider, version, subdir)
+	}
+	return checksum, err
+}
+
+func (s *service) GetModuleSBOM(ctx context.Context, namespace, name, provider, version string) ([]byte, error) {
+	sbomStorage, ok := s.storage.(SBOMStorage)
+	if !ok {
+		return nil, fmt.Errorf("%w: the storage backend doesn't support SBOMs", core.ErrObjectNotFound)
+	}
+
+	version, err := s.resolve(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+	return sbomStorage.ModuleSBOM(ctx, namespace, name, provider, version)
+}
+
+func (s *service) DiffModuleVersions(ctx context.Context, namespace, name, provider, from, to string) (*Diff, error) {
+	archiveStorage, ok := s.storage.(ArchiveStorage)
+	if !ok {
+		return nil, fmt.Errorf("%w: the storage backend doesn't support reading module archives", core.ErrObjectNotFound)
+	}
+
+	versions := []string{from, to}
+	archives := make([][]byte, len(versions))
+	for i, version := range versions {
+		version, err := s.resolve(ctx, namespace, name, provider, version)
+		if err != nil {
+			return nil, err
+		}
+		versions[i] = version
+
+		if archives[i], err = archiveStorage.ModuleArchive(ctx, namespace, name, provider, version); err != nil {
+			return nil, err
+		}
+	}
+	return NewDiff(versions[0], versions[1], archives[0], archives[1])
+}
+
+// upstreamFetchTimeout limits fetching a module version from the upstream, which isn't canceled with the request it was started by
+const upstreamFetchTimeout = 5 * time.Minute
+
+// fetchUpstream downloads the module version from the upstream and uploads it to the storage
+func (s *service) fetchUpstream(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	key := path.Join(namespace, name, provider, version)
+	res, err, _ := s.fetches.Do(key, func() (interface{}, error) {
+		// The fetch is shared by all concurrent requests, a canceled first request mustn't fail the others
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), upstreamFetchTimeout)
+		defer cancel()
+
+		data, err := s.upstream.DownloadModule(ctx, namespace, name, provider, version)
+		if err != nil {
+			return core.Module{}, err
+		}
+
+		// The archive is always repackaged as tar.gz, independent of the format preferred by the client
+		m, err := s.storage.UploadModule(WithArchiveFormat(ctx, ArchiveFormatTarGz), namespace, name, provider, version, bytes.NewReader(data))
+		if errors.Is(err, ErrModuleAlreadyExists) {
+			// The module was uploaded in the meantime, e.g. by another instance
+			return s.storage.GetModule(ctx, namespace, name, provider, version)
+		} else if err != nil {
+			return core.Module{}, err
+		}
+
+		slog.Info("cached module of the upstream registry", slog.String("module", key))
+		return m, nil
+	})
+	if err != nil {
+		return core.Module{}, err
+	}
+
+	return res.(core.Module), nil
+}
+
+type subdirArchive struct {
+	module   core.Module
+	checksum string
+}
+
+// subdirModule returns the archive of the sub-directory of the module version and its checksum.
+// The sub-directory is extracted from the module archive on the first request and cached in the storage.
+func (s *service) subdirModule(ctx context.Context, namespace, name, provider, version, subdir string) (core.Module, string, error) {
+	subdirStorage, ok := s.storage.(SubdirStorage)
+	if !ok {
+		return core.Module{}, "", fmt.Errorf("%w: the storage backend doesn't support module sub-directories", core.ErrObjectNotFound)
+	}
+	archiveStorage, ok := s.storage.(ArchiveStorage)
+	if !ok {
+		return core.Module{}, "", fmt.Errorf("%w: the storage backend doesn't support reading module archives", core.ErrObjectNotFound)
+	}
+
+	checksum, err := s.storage.GetModuleChecksum(ctx, namespace, name, provider, version)
+	if err != nil {
+		return core.Module{}, "", err
+	}
+
+	m, subdirChecksum, err := subdirStorage.ModuleSubdir(ctx, namespace, name, provider, version, checksum, subdir)
+	if !errors.Is(err, core.ErrObjectNotFound) {
+		return m, subdirChecksum, err
+	} else if s.readOnly {
+		return core.Module{}, "", fmt.Errorf("%w: the module sub-directory %s isn't cached yet", core.ErrReadOnly, subdir)
+	}
+
+	key := path.Join("subdir", namespace, name, provider, version, checksum, subdir)
+	res, err, _ := s.fetches.Do(key, func() (interface{}, error) {
+		archive, err := archiveStorage.ModuleArchive(ctx, namespace, name, provider, version)
+		if err != nil {
+			return nil, err
+		}
+		extracted, err := ExtractSubdir(archive, subdir)
+		if err != nil {
+			return nil, err
+		}
+
+		m, subdirChecksum, err := subdirStorage.UploadModuleSubdir(ctx, namespace, name, provider, version, checksum, subdir, extracted)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("cached module sub-directory", slog.String("module", path.Join(namespace, name, provider, version)), slog.String("subdir", subdir))
+		return subdirArchive{module: m, checksum: subdirChecksum}, nil
+	})
+	if err != nil {
+		return core.Module{}, "", err
+	}
+
+	archive := res.(subdirArchive)
+	return archive.module, archive.checksum, nil
+}
+
+func (s *service) GetDownloadStats(ctx context.Context, namespace, name, provider string) (*core.DownloadStats, error) {
+	if s.stats == nil {
+		return nil, stats.ErrStatsDisabled
+	}
+
+	return s.stats.Stats(ctx, stats.ModuleArtifact(namespace, name, provider))
+}
+
+func (s *service) ListConsumers(ctx context.Context, namespace, name, provider string) (core.Consumers, error) {
+	if s.tracker == nil {
+		return nil, stats.ErrConsumersDisabled
+	}
+
+	return s.tracker.Consumers(ctx, stats.ModuleArtifact(namespace, name, provider))
+}
+
+func (s *service) ListChannels(ctx context.Context, namespace, name, provider string) (core.Channels, error) {
+	if s.channels == nil {
+		return nil, channel.ErrChannelsDisabled
+	}
+
+	return s.channels.List(ctx, stats.ModuleArtifact(namespace, name, provider))
+}
+
+func (s *service) SetChannel(ctx context.Context, namespace, name, provider, ch, version string) error {
+	if s.channels == nil {
+		return channel.ErrChannelsDisabled
+	}
+
+	// Channels can only point to versions in the storage, so that they never resolve to a missing version
+	if _, err := s.storage.GetModule(ctx, namespace, name, provider, version); err != nil {
+		return err
+	}
+
+	return s.channels.Set(ctx, stats.ModuleArtifact(namespace, name, provider), ch, version)
+}
+
+func (s *service) DeleteChannel(ctx context.Context, namespace, name, provider, ch string) error {
+	if s.channels == nil {
+		return channel.ErrChannelsDisabled
+	}
+
+	return s.channels.Delete(ctx, stats.ModuleArtifact(namespace, name, provider), ch)
+}
+
+// resolve returns the version the channel points to, versions are returned unchanged
+func (s *service) resolve(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	if s.channels == nil {
+		return version, nil
+	}
+
+	return s.channels.Resolve(ctx, stats.ModuleArtifact(namespace, name, provider), version)
+}
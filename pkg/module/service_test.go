@@ -8,8 +8,11 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/channel"
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/stats"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -196,3 +199,152 @@ func TestService_ListModuleVersions(t *testing.T) {
 		})
 	}
 }
+
+type mockedRecorder struct {
+	stats map[string]*core.DownloadStats
+}
+
+func (m *mockedRecorder) Record(artifact, version string) {
+	s, ok := m.stats[artifact]
+	if !ok {
+		s = &core.DownloadStats{}
+		m.stats[artifact] = s
+	}
+	s.Add(version, 1)
+}
+
+func (m *mockedRecorder) Stats(_ context.Context, artifact string) (*core.DownloadStats, error) {
+	return m.stats[artifact], nil
+}
+
+func (m *mockedRecorder) Flush(_ context.Context) error {
+	return nil
+}
+
+func TestService_GetDownloadStats(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		ctx      = context.Background()
+		storage  = NewInmemStorage()
+		proxy    = core.NewProxyUrlService(false, "/proxy")
+		recorder = &mockedRecorder{stats: make(map[string]*core.DownloadStats)}
+		svc      = NewService(storage, proxy, WithDownloadStats(recorder))
+	)
+
+	_, err := storage.UploadModule(ctx, "example", "s3", "aws", "1.0.0", testModuleData(map[string]string{
+		"main.tf": `name = "foo"`,
+	}))
+	assert.NoError(err)
+
+	for i := 0; i < 2; i++ {
+		_, err = svc.GetModule(ctx, "example", "s3", "aws", "1.0.0")
+		assert.NoError(err)
+	}
+
+	// Failed downloads must not be counted
+	_, err = svc.GetModule(ctx, "example", "s3", "aws", "9.9.9")
+	assert.Error(err)
+
+	stats, err := svc.GetDownloadStats(ctx, "example", "s3", "aws")
+	assert.NoError(err)
+	assert.Equal(&core.DownloadStats{Total: 2, Versions: map[string]int64{"1.0.0": 2}}, stats)
+
+	_, err = NewService(storage, proxy).GetDownloadStats(ctx, "example", "s3", "aws")
+	assert.Error(err)
+}
+
+type mockedTracker struct {
+	consumers map[string]core.Consumers
+}
+
+func (m *mockedTracker) Track(ctx context.Context, artifact, version string) {
+	if _, ok := m.consumers[artifact]; !ok {
+		m.consumers[artifact] = make(core.Consumers)
+	}
+	m.consumers[artifact].Add(core.Identity(ctx), version, 1, time.Now())
+}
+
+func (m *mockedTracker) Consumers(_ context.Context, artifact string) (core.Consumers, error) {
+	return m.consumers[artifact], nil
+}
+
+func (m *mockedTracker) Flush(_ context.Context) error {
+	return nil
+}
+
+func TestService_ListConsumers(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		ctx     = core.WithIdentity(context.Background(), "ci")
+		storage = NewInmemStorage()
+		proxy   = core.NewProxyUrlService(false, "/proxy")
+		tracker = &mockedTracker{consumers: make(map[string]core.Consumers)}
+		svc     = NewService(storage, proxy, WithConsumerTracking(tracker))
+	)
+
+	_, err := storage.UploadModule(ctx, "example", "s3", "aws", "1.0.0", testModuleData(map[string]string{
+		"main.tf": `name = "foo"`,
+	}))
+	assert.NoError(err)
+
+	_, err = svc.GetModule(ctx, "example", "s3", "aws", "1.0.0")
+	assert.NoError(err)
+	_, err = svc.GetModule(ctx, "example", "s3", "aws", "9.9.9")
+	assert.Error(err)
+
+	consumers, err := svc.ListConsumers(ctx, "example", "s3", "aws")
+	assert.NoError(err)
+	assert.Equal(map[string]int64{"1.0.0": 1}, consumers["ci"].Versions)
+
+	_, err = NewService(storage, proxy).ListConsumers(ctx, "example", "s3", "aws")
+	assert.ErrorIs(err, stats.ErrConsumersDisabled)
+}
+
+func TestService_Channels(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		ctx     = context.Background()
+		storage = NewInmemStorage()
+		proxy   = core.NewProxyUrlService(false, "/proxy")
+		svc     = NewService(storage, proxy, WithChannels(channel.NewManager(channel.NewInmemStorage())))
+	)
+
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		_, err := storage.UploadModule(ctx, "example", "s3", "aws", version, testModuleData(map[string]string{
+			"main.tf": `name = "` + version + `"`,
+		}))
+		assert.NoError(err)
+	}
+
+	assert.NoError(svc.SetChannel(ctx, "example", "s3", "aws", "stable", "1.0.0"))
+	assert.ErrorIs(svc.SetChannel(ctx, "example", "s3", "aws", "beta", "3.0.0"), ErrModuleNotFound)
+
+	m, err := svc.GetModule(ctx, "example", "s3", "aws", "stable")
+	assert.NoError(err)
+	assert.Equal("1.0.0", m.Version)
+
+	// Versions can still be pinned
+	m, err = svc.GetModule(ctx, "example", "s3", "aws", "2.0.0")
+	assert.NoError(err)
+	assert.Equal("2.0.0", m.Version)
+
+	checksum, err := svc.GetModuleChecksum(ctx, "example", "s3", "aws", "stable")
+	assert.NoError(err)
+	expected, err := storage.GetModuleChecksum(ctx, "example", "s3", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal(expected, checksum)
+
+	channels, err := svc.ListChannels(ctx, "example", "s3", "aws")
+	assert.NoError(err)
+	assert.Equal(core.Channels{"stable": "1.0.0"}, channels)
+
+	assert.NoError(svc.DeleteChannel(ctx, "example", "s3", "aws", "stable"))
+	_, err = svc.GetModule(ctx, "example", "s3", "aws", "stable")
+	assert.ErrorIs(err, ErrModuleNotFound)
+
+	_, err = NewService(storage, proxy).ListChannels(ctx, "example", "s3", "aws")
+	assert.ErrorIs(err, channel.ErrChannelsDisabled)
+}
@@ -0,0 +1,85 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Snippets contains ready-to-paste examples for consuming a module
+type Snippets struct {
+	Terraform  string `json:"terraform"`
+	OpenTofu   string `json:"opentofu"`
+	Terragrunt string `json:"terragrunt"`
+}
+
+// Get returns the snippet of the given workspace type
+func (s Snippets) Get(workspace string) (string, error) {
+	switch strings.ToLower(workspace) {
+	case "terraform":
+		return s.Terraform, nil
+	case "opentofu", "tofu":
+		return s.OpenTofu, nil
+	case "terragrunt":
+		return s.Terragrunt, nil
+	default:
+		return "", fmt.Errorf("unknown workspace type %q, expected one of terraform, opentofu, terragrunt", workspace)
+	}
+}
+
+// NewSnippets generates the usage snippets of a module version served by the registry at hostname
+func NewSnippets(hostname string, m core.Module) Snippets {
+	source := fmt.Sprintf("%s/%s/%s/%s", hostname, m.Namespace, m.Name, m.Provider)
+
+	// The module name is used as the block label, which must be a valid identifier
+	label := strings.NewReplacer("-", "_", ".", "_").Replace(m.Name)
+
+	block := fmt.Sprintf(`module "%s" {
+  source  = "%s"
+  version = "%s"
+}
+`, label, source, m.Version)
+
+	return Snippets{
+		Terraform: block,
+		OpenTofu:  block,
+		Terragrunt: fmt.Sprintf(`terraform {
+  source = "tfr://%s?version=%s"
+}
+`, source, m.Version),
+	}
+}
+
+// LatestVersion returns the module with the highest version.
+// Pre-releases are only considered if no stable version exists.
+func LatestVersion(modules []core.Module) (core.Module, error) {
+	var (
+		latest           core.Module
+		latestVersion    *version.Version
+		latestPrerelease bool
+	)
+
+	for _, m := range modules {
+		v, err := version.NewVersion(m.Version)
+		if err != nil {
+			continue
+		}
+
+		prerelease := v.Prerelease() != ""
+		switch {
+		case latestVersion == nil,
+			latestPrerelease && !prerelease,
+			latestPrerelease == prerelease && v.GreaterThan(latestVersion):
+			latest, latestVersion, latestPrerelease = m, v, prerelease
+		}
+	}
+
+	if latestVersion == nil {
+		return core.Module{}, ErrModuleNotFound
+	}
+
+	return latest, nil
+}
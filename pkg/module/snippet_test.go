@@ -0,0 +1,89 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSnippets(t *testing.T) {
+	assert := assert.New(t)
+
+	snippets := NewSnippets("registry.example.com", core.Module{
+		Namespace: "acme",
+		Name:      "tls-private-key",
+		Provider:  "aws",
+		Version:   "0.2.0",
+	})
+
+	expected := `module "tls_private_key" {
+  source  = "registry.example.com/acme/tls-private-key/aws"
+  version = "0.2.0"
+}
+`
+	assert.Equal(expected, snippets.Terraform)
+	assert.Equal(expected, snippets.OpenTofu)
+	assert.Equal(`terraform {
+  source = "tfr://registry.example.com/acme/tls-private-key/aws?version=0.2.0"
+}
+`, snippets.Terragrunt)
+
+	s, err := snippets.Get("tofu")
+	assert.NoError(err)
+	assert.Equal(expected, s)
+
+	_, err = snippets.Get("pulumi")
+	assert.Error(err)
+}
+
+func TestLatestVersion(t *testing.T) {
+	testCases := []struct {
+		name        string
+		versions    []string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "semantic ordering",
+			versions: []string{"1.2.0", "1.10.0", "1.9.1"},
+			expected: "1.10.0",
+		},
+		{
+			name:     "stable versions take precedence over pre-releases",
+			versions: []string{"1.0.0", "2.0.0-rc1"},
+			expected: "1.0.0",
+		},
+		{
+			name:     "only pre-releases",
+			versions: []string{"2.0.0-rc1", "2.0.0-rc2"},
+			expected: "2.0.0-rc2",
+		},
+		{
+			name:        "no valid versions",
+			versions:    []string{"latest"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			var modules []core.Module
+			for _, v := range tc.versions {
+				modules = append(modules, core.Module{Namespace: "acme", Name: "vpc", Provider: "aws", Version: v})
+			}
+
+			latest, err := LatestVersion(modules)
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrModuleNotFound)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, latest.Version)
+		})
+	}
+}
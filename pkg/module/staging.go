@@ -0,0 +1,141 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+)
+
+var (
+	// ErrModuleNotStaged is returned when approving or promoting a module version which isn't staged
+	ErrModuleNotStaged = errors.New("module version isn't staged")
+
+	// ErrApprovalRequired is returned when promoting a module version without the required approvals
+	ErrApprovalRequired = errors.New("module version requires more approvals")
+
+	// ErrSelfApproval is returned when the publisher approves their own module version
+	ErrSelfApproval = errors.New("module version can't be approved by its publisher")
+)
+
+// Staging records a module version which has been uploaded but isn't published yet.
+// Staged versions are neither listed nor downloadable until they are promoted.
+type Staging struct {
+	StagedAt  time.Time  `json:"staged_at"`
+	Publisher string     `json:"publisher,omitempty"`
+	Approvals []Approval `json:"approvals,omitempty"`
+}
+
+// Approval records a reviewer approving a staged module version
+type Approval struct {
+	Reviewer   string    `json:"reviewer"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// Reviewers returns the reviewers who approved the module version
+func (s *Staging) Reviewers() []string {
+	reviewers := make([]string, 0, len(s.Approvals))
+	for _, a := range s.Approvals {
+		reviewers = append(reviewers, a.Reviewer)
+	}
+	return reviewers
+}
+
+type stagingKey struct{}
+
+// WithStaging returns a context which stages the uploaded module versions instead of publishing them
+func WithStaging(ctx context.Context, staging *Staging) context.Context {
+	return context.WithValue(ctx, stagingKey{}, staging)
+}
+
+// StagingFromContext returns the Staging of the uploads of the context, or nil if they're published immediately
+func StagingFromContext(ctx context.Context) *Staging {
+	staging, _ := ctx.Value(stagingKey{}).(*Staging)
+	return staging
+}
+
+// StagingStorage is implemented by storages which can stage uploaded module versions until they are promoted
+type StagingStorage interface {
+	// ModuleStaging should return an ErrModuleNotStaged error if the module version isn't staged
+	ModuleStaging(ctx context.Context, namespace, name, provider, version string) (*Staging, error)
+
+	// UpdateModuleStaging should return an ErrModuleNotStaged error if the module version isn't staged
+	UpdateModuleStaging(ctx context.Context, namespace, name, provider, version string, staging *Staging) error
+
+	// PromoteModule publishes the staged module version and should return an ErrModuleNotStaged error if it isn't staged
+	PromoteModule(ctx context.Context, namespace, name, provider, version string) error
+}
+
+// Promoter approves staged module versions and promotes them once they have the required number of approvals
+type Promoter struct {
+	storage           StagingStorage
+	requiredApprovals int
+}
+
+// NewPromoter returns a Promoter requiring approvals of the given number of distinct reviewers
+func NewPromoter(storage StagingStorage, requiredApprovals int) *Promoter {
+	return &Promoter{
+		storage:           storage,
+		requiredApprovals: requiredApprovals,
+	}
+}
+
+// Approve records the approval of the reviewer. Approving a version twice doesn't count twice.
+func (p *Promoter) Approve(ctx context.Context, namespace, name, provider, version, reviewer string) (*Staging, error) {
+	if reviewer == "" {
+		return nil, fmt.Errorf("%w: reviewer", core.ErrVarMissing)
+	}
+
+	staging, err := p.storage.ModuleStaging(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+	if reviewer == staging.Publisher {
+		return nil, fmt.Errorf("%w: %s", ErrSelfApproval, reviewer)
+	}
+	if slices.Contains(staging.Reviewers(), reviewer) {
+		return staging, nil
+	}
+
+	staging.Approvals = append(staging.Approvals, Approval{Reviewer: reviewer, ApprovedAt: time.Now().UTC()})
+	if err := p.storage.UpdateModuleStaging(ctx, namespace, name, provider, version, staging); err != nil {
+		return nil, err
+	}
+
+	o11y.Audit(ctx, "module.approve",
+		slog.String("module", path.Join(namespace, name, provider, version)),
+		slog.String("reviewer", reviewer),
+		slog.Int("approvals", len(staging.Approvals)),
+		slog.Int("required-approvals", p.requiredApprovals),
+	)
+	return staging, nil
+}
+
+// Promote publishes the staged module version, if it has been approved by the required number of reviewers
+func (p *Promoter) Promote(ctx context.Context, namespace, name, provider, version string) (*Staging, error) {
+	staging, err := p.storage.ModuleStaging(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(staging.Approvals) < p.requiredApprovals {
+		return nil, fmt.Errorf("%w: %d of %d approvals", ErrApprovalRequired, len(staging.Approvals), p.requiredApprovals)
+	}
+
+	if err := p.storage.PromoteModule(ctx, namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+
+	o11y.Audit(ctx, "module.promote",
+		slog.String("module", path.Join(namespace, name, provider, version)),
+		slog.String("publisher", staging.Publisher),
+		slog.Any("reviewers", staging.Reviewers()),
+		slog.String("promoted-by", core.Identity(ctx)),
+	)
+	return staging, nil
+}
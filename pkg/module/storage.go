@@ -13,4 +13,69 @@ type Storage interface {
 	GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error)
 	ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error)
 	UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error)
+
+	// GetModuleChecksum returns the hex-encoded SHA-256 checksum of the module archive
+	GetModuleChecksum(ctx context.Context, namespace, name, provider, version string) (string, error)
+}
+
+// SignatureStorage is implemented by storages which store detached OpenPGP signatures of module archives
+type SignatureStorage interface {
+	// UploadModuleSignature should return an ErrModuleNotFound error if the module version doesn't exist
+	UploadModuleSignature(ctx context.Context, namespace, name, provider, version string, signature io.Reader) error
+}
+
+// AttestationStorage is implemented by storages which store Sigstore bundles of module archives
+type AttestationStorage interface {
+	// UploadModuleAttestation should return an ErrModuleNotFound error if the module version doesn't exist
+	UploadModuleAttestation(ctx context.Context, namespace, name, provider, version string, bundle io.Reader) error
+}
+
+// SBOMStorage is implemented by storages which store SPDX or CycloneDX SBOMs of module versions
+type SBOMStorage interface {
+	// UploadModuleSBOM should return an ErrModuleNotFound error if the module version doesn't exist,
+	// and an sbom.ErrInvalidSBOM error if the document isn't an SBOM
+	UploadModuleSBOM(ctx context.Context, namespace, name, provider, version string, document io.Reader) error
+
+	// ModuleSBOM should return a core.ErrObjectNotFound error if no SBOM is attached to the module version
+	ModuleSBOM(ctx context.Context, namespace, name, provider, version string) ([]byte, error)
+}
+
+// ArchiveStorage is implemented by storages which can return the content of module archives
+type ArchiveStorage interface {
+	// ModuleArchive should return an ErrModuleNotFound error if the module version doesn't exist
+	ModuleArchive(ctx context.Context, namespace, name, provider, version string) ([]byte, error)
+}
+
+// ChecksumManifestStorage is implemented by storages which record the checksums of all archives of a module in a manifest
+type ChecksumManifestStorage interface {
+	// UploadModuleChecksum should return an ErrChecksumMismatch error if the stored archive has a different checksum
+	UploadModuleChecksum(ctx context.Context, namespace, name, provider, version, checksum string) error
+}
+
+// ReplaceStorage is implemented by storages which can replace the archive of an existing module version
+type ReplaceStorage interface {
+	// ReplaceModule should return an ErrModuleNotFound error if the module version doesn't exist,
+	// and an ErrModuleImmutable error if the module version can't be replaced
+	ReplaceModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error)
+}
+
+// SubdirStorage is implemented by storages which cache the archives of sub-directories extracted from module archives.
+// The archives are cached by the checksum of the module archive, so that replaced archives are extracted again.
+type SubdirStorage interface {
+	// ModuleSubdir returns the cached archive of the sub-directory and its hex-encoded SHA-256 checksum.
+	// It should return a core.ErrObjectNotFound error if the sub-directory hasn't been cached yet.
+	ModuleSubdir(ctx context.Context, namespace, name, provider, version, checksum, subdir string) (core.Module, string, error)
+
+	// UploadModuleSubdir caches the archive of the sub-directory and returns it like ModuleSubdir
+	UploadModuleSubdir(ctx context.Context, namespace, name, provider, version, checksum, subdir string, archive []byte) (core.Module, string, error)
+}
+
+// DigestStorage is implemented by storages which record the digests of the module directories the archives were created from.
+// Unlike the checksum of the archive, the digest only depends on the files of the module, which allows detecting unchanged modules.
+type DigestStorage interface {
+	// ModuleDigest should return a core.ErrObjectNotFound error if no digest was recorded for the module version
+	ModuleDigest(ctx context.Context, namespace, name, provider, version string) (string, error)
+
+	// UploadModuleDigest should return an ErrModuleNotFound error if the module version doesn't exist
+	UploadModuleDigest(ctx context.Context, namespace, name, provider, version, digest string) error
 }
@@ -1,7 +1,10 @@
 package module
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -17,6 +20,7 @@ type InmemStorage struct {
 	mu            sync.RWMutex
 	modules       map[string]core.Module
 	moduleData    map[string]io.Reader
+	checksums     map[string]string
 	archiveFormat string
 }
 
@@ -34,7 +38,7 @@ func (s *InmemStorage) GetModule(_ context.Context, namespace, name, provider, v
 	id := m.ID(true)
 	module, ok := s.modules[id]
 	if !ok {
-		return core.Module{}, fmt.Errorf("module not found: %s", id)
+		return core.Module{}, fmt.Errorf("%w: %s", ErrModuleNotFound, id)
 	}
 
 	return module, nil
@@ -78,6 +82,12 @@ func (s *InmemStorage) UploadModule(ctx context.Context, namespace, name, provid
 		return core.Module{}, errors.New("version not defined")
 	}
 
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return core.Module{}, fmt.Errorf("%v: %w", ErrModuleUploadFailed, err)
+	}
+	checksum := sha256.Sum256(data)
+
 	s.mu.Lock()
 
 	m := core.Module{
@@ -89,17 +99,68 @@ func (s *InmemStorage) UploadModule(ctx context.Context, namespace, name, provid
 
 	id := m.ID(true)
 	if _, ok := s.modules[id]; ok {
-		return core.Module{}, fmt.Errorf("module exists already: %s", id)
+		s.mu.Unlock()
+		return core.Module{}, fmt.Errorf("%w: %s", ErrModuleAlreadyExists, id)
 	}
 
 	s.modules[id] = m
 
-	s.moduleData[id] = body
+	s.moduleData[id] = bytes.NewReader(data)
+	s.checksums[id] = hex.EncodeToString(checksum[:])
 	s.mu.Unlock()
 
 	return s.GetModule(ctx, namespace, name, provider, version)
 }
 
+// ReplaceModule replaces the archive of an existing module version
+func (s *InmemStorage) ReplaceModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return core.Module{}, fmt.Errorf("%v: %w", ErrModuleUploadFailed, err)
+	}
+	checksum := sha256.Sum256(data)
+
+	s.mu.Lock()
+
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+
+	id := m.ID(true)
+	if _, ok := s.modules[id]; !ok {
+		s.mu.Unlock()
+		return core.Module{}, fmt.Errorf("%w: %s", ErrModuleNotFound, id)
+	}
+
+	s.moduleData[id] = bytes.NewReader(data)
+	s.checksums[id] = hex.EncodeToString(checksum[:])
+	s.mu.Unlock()
+
+	return s.GetModule(ctx, namespace, name, provider, version)
+}
+
+// GetModuleChecksum returns the checksum of the module archive, which is computed on upload
+func (s *InmemStorage) GetModuleChecksum(_ context.Context, namespace, name, provider, version string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+	checksum, ok := s.checksums[m.ID(true)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrModuleNotFound, m.ID(true))
+	}
+
+	return checksum, nil
+}
+
 func (s *InmemStorage) MigrateModules(ctx context.Context, dryRun bool) error {
 	panic("MigrateModules should not be called for InmemStorage")
 }
@@ -119,6 +180,7 @@ func NewInmemStorage(options ...InmemStorageOption) Storage {
 	s := &InmemStorage{
 		modules:       make(map[string]core.Module),
 		moduleData:    make(map[string]io.Reader),
+		checksums:     make(map[string]string),
 		archiveFormat: "tar.gz",
 	}
 
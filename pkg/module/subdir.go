@@ -0,0 +1,62 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const subdirQueryParam = "subdir"
+
+type subdirKey struct{}
+
+// WithSubdir returns a context requesting the archive of a sub-directory of the module instead of the whole module,
+// like the // convention of module source addresses, e.g. namespace/name/provider//modules/vpc
+func WithSubdir(ctx context.Context, subdir string) context.Context {
+	return context.WithValue(ctx, subdirKey{}, subdir)
+}
+
+// SubdirFromContext returns the requested sub-directory, or an empty string if the whole module is requested
+func SubdirFromContext(ctx context.Context) string {
+	subdir, _ := ctx.Value(subdirKey{}).(string)
+	return subdir
+}
+
+// SubdirFromRequest returns the cleaned sub-directory requested with the subdir query parameter.
+// An empty string is returned if the whole module is requested.
+func SubdirFromRequest(r *http.Request) (string, error) {
+	subdir := strings.Trim(r.URL.Query().Get(subdirQueryParam), "/")
+	if subdir == "" {
+		return "", nil
+	}
+
+	cleaned, err := cleanArchivePath(subdir)
+	if err != nil || cleaned == "." {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSubdir, subdir)
+	}
+	return cleaned, nil
+}
+
+// ExtractSubdir returns a tar.gz archive containing the files of the sub-directory of the module archive,
+// with paths relative to the sub-directory. Both tar.gz and zip archives are supported.
+func ExtractSubdir(archive []byte, subdir string) ([]byte, error) {
+	var files []archiveFile
+	var err error
+	switch SniffArchiveFormat(archive) {
+	case ArchiveFormatZip:
+		files, err = readZip(archive)
+	case ArchiveFormatTarGz:
+		files, err = readTarGz(archive)
+	default:
+		return nil, fmt.Errorf("%w: the module archive isn't a tar.gz or zip archive", ErrInvalidArchiveFormat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module archive: %w", err)
+	}
+
+	if files, err = stripPrefix(files, subdir); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSubdirNotFound, err)
+	}
+	return writeTarGz(files)
+}
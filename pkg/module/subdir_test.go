@@ -0,0 +1,82 @@
+package module
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractSubdir(t *testing.T) {
+	files := map[string]string{
+		"main.tf":                  `module "vpc" {}`,
+		"modules/vpc/main.tf":      `resource "aws_vpc" "this" {}`,
+		"modules/vpc/outputs.tf":   `output "id" {}`,
+		"modules/vpc-peering/a.tf": `resource "aws_vpc_peering_connection" "this" {}`,
+	}
+
+	zipBuf := new(bytes.Buffer)
+	zw := zip.NewWriter(zipBuf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	archives := map[string][]byte{
+		ArchiveFormatTarGz: testModuleData(files).Bytes(),
+		ArchiveFormatZip:   zipBuf.Bytes(),
+	}
+	for format, archive := range archives {
+		t.Run(format, func(t *testing.T) {
+			extracted, err := ExtractSubdir(archive, "modules/vpc")
+			assert.NoError(t, err)
+			assert.Equal(t, ArchiveFormatTarGz, SniffArchiveFormat(extracted))
+
+			got, err := readTarGz(extracted)
+			assert.NoError(t, err)
+			names := make([]string, 0, len(got))
+			for _, f := range got {
+				names = append(names, f.name)
+			}
+			assert.ElementsMatch(t, []string{"main.tf", "outputs.tf"}, names)
+
+			_, err = ExtractSubdir(archive, "modules/missing")
+			assert.ErrorIs(t, err, ErrSubdirNotFound)
+		})
+	}
+
+	_, err := ExtractSubdir([]byte("not an archive"), "modules/vpc")
+	assert.ErrorIs(t, err, ErrInvalidArchiveFormat)
+}
+
+func TestSubdirFromRequest(t *testing.T) {
+	testCases := []struct {
+		query       string
+		expected    string
+		expectError bool
+	}{
+		{query: "", expected: ""},
+		{query: "?subdir=modules/vpc", expected: "modules/vpc"},
+		{query: "?subdir=/modules/vpc/", expected: "modules/vpc"},
+		{query: "?subdir=./modules//vpc", expected: "modules/vpc"},
+		{query: "?subdir=../vpc", expectError: true},
+		{query: "?subdir=modules/../..", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			subdir, err := SubdirFromRequest(httptest.NewRequest("GET", "/download"+tc.query, nil))
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidSubdir)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, subdir)
+		})
+	}
+}
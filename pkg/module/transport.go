@@ -2,12 +2,19 @@ package module
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
+	registryauth "github.com/boring-registry/boring-registry/pkg/auth"
+	"github.com/boring-registry/boring-registry/pkg/channel"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
+	"github.com/boring-registry/boring-registry/pkg/stats"
 
 	"github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
@@ -22,21 +29,39 @@ const (
 	varName      muxVar = "name"
 	varProvider  muxVar = "provider"
 	varVersion   muxVar = "version"
+	varChannel   muxVar = "channel"
 )
 
 // MakeHandler returns a fully initialized http.Handler.
-func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ModuleMetrics, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ModuleMetrics, instrumentation o11y.Middleware, cache core.CacheMiddleware, options ...httptransport.ServerOption) http.Handler {
 	r := mux.NewRouter().StrictSlash(true)
 
 	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/versions`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(listEndpoint(svc, metrics)),
+					decodeListVersionsRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("POST").Path(`/versions`).Handler(
 		instrumentation.WrapHandler(
 			httptransport.NewServer(
-				auth(listEndpoint(svc, metrics)),
-				decodeListRequest,
+				auth(batchListEndpoint(svc, metrics)),
+				decodeBatchListRequest,
 				httptransport.EncodeJSONResponse,
 				append(
 					options,
-					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(registryauth.ReadRequest),
 					httptransport.ServerBefore(jwt.HTTPToContext()),
 				)...,
 			),
@@ -58,6 +83,228 @@ func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ModuleMetr
 		),
 	)
 
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/{version}/checksum`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(checksumEndpoint(svc)),
+					decodeDownloadRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/{version}/sbom`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(sbomEndpoint(svc)),
+				decodeDownloadRequest,
+				encodeSBOMResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/resolve`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(resolveEndpoint(svc, metrics)),
+				decodeResolveRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/snippets`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(snippetsEndpoint(svc)),
+				decodeSnippetsRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/{version}/snippets`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(snippetsEndpoint(svc)),
+				decodeSnippetsRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/diff`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(diffEndpoint(svc)),
+				decodeDiffRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/channels`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(channelsEndpoint(svc)),
+				decodeListRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/downloads`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(downloadStatsEndpoint(svc)),
+				decodeListRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/consumers`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(consumersEndpoint(svc)),
+				decodeListRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+// MakeAdminHandler returns a fully initialized http.Handler for the module administration API.
+// Staged module versions can only be approved and promoted if the promoter isn't nil.
+func MakeAdminHandler(svc Service, storage Storage, replacer ReplaceStorage, policy OverwritePolicy, promoter *Promoter, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("PUT").Path(`/modules/{namespace}/{name}/{provider}/channels/{channel}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(setChannelEndpoint(svc)),
+				decodeChannelRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varChannel)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("DELETE").Path(`/modules/{namespace}/{name}/{provider}/channels/{channel}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(deleteChannelEndpoint(svc)),
+				decodeChannelRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varChannel)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("PUT").Path(`/modules/{namespace}/{name}/{provider}/{version}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(republishEndpoint(storage, replacer, policy)),
+				decodeRepublishRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	if promoter != nil {
+		r.Methods("POST").Path(`/modules/{namespace}/{name}/{provider}/{version}/approve`).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(approveEndpoint(promoter)),
+					decodeDownloadRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		)
+
+		r.Methods("POST").Path(`/modules/{namespace}/{name}/{provider}/{version}/promote`).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(promoteEndpoint(promoter)),
+					decodeDownloadRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		)
+	}
+
 	return r
 }
 
@@ -84,6 +331,44 @@ func decodeListRequest(ctx context.Context, r *http.Request) (interface{}, error
 	}, nil
 }
 
+func decodeListVersionsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := core.ParsePage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	prereleases, err := core.PrereleasesFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return listVersionsRequest{
+		listRequest: req.(listRequest),
+		page:        page,
+		prereleases: prereleases,
+	}, nil
+}
+
+func decodeBatchListRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req batchListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: modules: %w", core.ErrVarType, err)
+	}
+
+	if len(req.Modules) == 0 {
+		return nil, fmt.Errorf("%w: modules", core.ErrVarMissing)
+	} else if len(req.Modules) > maxBatchListModules {
+		return nil, fmt.Errorf("%w: at most %d modules can be listed with a single request", core.ErrVarType, maxBatchListModules)
+	}
+
+	return req, nil
+}
+
 func decodeDownloadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	namespace, ok := ctx.Value(varNamespace).(string)
 	if !ok {
@@ -105,24 +390,176 @@ func decodeDownloadRequest(ctx context.Context, r *http.Request) (interface{}, e
 		return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
 	}
 
+	archiveFormat, err := ArchiveFormatFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	subdir, err := SubdirFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
 	return downloadRequest{
-		namespace: namespace,
-		name:      name,
-		provider:  provider,
+		namespace:     namespace,
+		name:          name,
+		provider:      provider,
+		version:       version,
+		checksum:      ExpectedChecksum(r),
+		archiveFormat: archiveFormat,
+		subdir:        subdir,
+	}, nil
+}
+
+func decodeResolveRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	archiveFormat, err := ArchiveFormatFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	subdir, err := SubdirFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveRequest{
+		namespace:     list.namespace,
+		name:          list.name,
+		provider:      list.provider,
+		constraint:    r.URL.Query().Get("version"),
+		archiveFormat: archiveFormat,
+		subdir:        subdir,
+	}, nil
+}
+
+func decodeDiffRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	// Both versions are required, they may also be channels
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" {
+		return nil, fmt.Errorf("%w: from", core.ErrVarMissing)
+	}
+	if to == "" {
+		return nil, fmt.Errorf("%w: to", core.ErrVarMissing)
+	}
+
+	return diffRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		provider:  list.provider,
+		from:      from,
+		to:        to,
+	}, nil
+}
+
+func decodeRepublishRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeDownloadRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	download := req.(downloadRequest)
+
+	// The force flag is required explicitly, so that a module version isn't replaced by accident
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	// The body is streamed to the storage backend, its Content-Length allows to check the quota upfront
+	var body io.Reader = r.Body
+	if r.ContentLength >= 0 {
+		body = core.NewSizedReader(r.Body, r.ContentLength)
+	}
+
+	return republishRequest{
+		namespace: download.namespace,
+		name:      download.name,
+		provider:  download.provider,
+		version:   download.version,
+		force:     force,
+		reason:    r.URL.Query().Get("reason"),
+		body:      body,
+	}, nil
+}
+
+func decodeChannelRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	ch, ok := ctx.Value(varChannel).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: channel", core.ErrVarMissing)
+	}
+
+	res := channelRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		provider:  list.provider,
+		channel:   ch,
+	}
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+			return nil, fmt.Errorf("%w: version: %w", core.ErrVarType, err)
+		}
+		if res.Version == "" {
+			return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
+		}
+	}
+	return res, nil
+}
+
+func decodeSnippetsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	// The version is optional, the latest version is used if it's omitted
+	version, _ := ctx.Value(varVersion).(string)
+
+	// The snippets have to reference the hostname under which the client reached the registry
+	hostname := r.Header.Get("X-Forwarded-Host")
+	if hostname == "" {
+		hostname = r.Host
+	}
+
+	return snippetsRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		provider:  list.provider,
 		version:   version,
+		hostname:  hostname,
 	}, nil
 }
 
 // ErrorEncoder translates domain specific errors to HTTP status codes
 func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
-
-	if errors.Is(err, ErrModuleNotFound) {
-		w.WriteHeader(http.StatusNotFound)
-	} else {
-		w.WriteHeader(core.GenericError(err))
+	statusCode := core.GenericError(err)
+	if errors.Is(err, ErrModuleNotFound) || errors.Is(err, ErrSubdirNotFound) || errors.Is(err, ErrModuleNotStaged) || errors.Is(err, stats.ErrStatsDisabled) || errors.Is(err, stats.ErrConsumersDisabled) || errors.Is(err, channel.ErrChannelNotFound) || errors.Is(err, channel.ErrChannelsDisabled) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, ErrModuleAlreadyExists) || errors.Is(err, ErrModuleImmutable) || errors.Is(err, ErrApprovalRequired) {
+		statusCode = http.StatusConflict
+	} else if errors.Is(err, ErrInvalidChecksum) || errors.Is(err, ErrInvalidArchiveFormat) || errors.Is(err, ErrInvalidSubdir) || errors.Is(err, ErrForceRequired) || errors.Is(err, channel.ErrInvalidChannel) {
+		statusCode = http.StatusBadRequest
+	} else if errors.Is(err, ErrChecksumMismatch) {
+		statusCode = http.StatusPreconditionFailed
+	} else if errors.Is(err, ErrSelfApproval) {
+		statusCode = http.StatusForbidden
 	}
 
-	core.HandleErrorResponse(err, w)
+	core.HandleErrorResponse(err, statusCode, w)
 }
 
 func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
@@ -140,6 +577,22 @@ func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
 func encodeDownloadResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	res := response.(downloadResponse)
 	w.Header().Set("X-Terraform-Get", res.url)
+	w.Header().Set(checksumResponseHeader, res.checksum)
+	if res.signatureURL != "" {
+		w.Header().Set(signatureResponseHeader, res.signatureURL)
+	}
+	if res.attestationURL != "" {
+		w.Header().Set(attestationResponseHeader, res.attestationURL)
+	}
 	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
+
+// encodeSBOMResponse writes the SBOM with the media type of its format
+func encodeSBOMResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(sbomResponse)
+	format, _ := sbom.Detect(res.document)
+	w.Header().Set("Content-Type", sbom.MediaType(format))
+	_, err := w.Write(res.document)
+	return err
+}
@@ -0,0 +1,327 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/discovery"
+)
+
+// maxUpstreamArchiveSize limits the size of module archives fetched from the upstream registry, as they are processed in memory
+const maxUpstreamArchiveSize = 256 << 20
+
+// Upstream is a registry implementing the module registry protocol, from which modules missing in the Storage are fetched.
+type Upstream interface {
+	// ListModuleVersions returns the versions of the module, or an ErrModuleNotFound error if the upstream doesn't know the module
+	ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]string, error)
+
+	// DownloadModule returns the module archive in the tar.gz format, as created by the upload command
+	DownloadModule(ctx context.Context, namespace, name, provider, version string) ([]byte, error)
+}
+
+type upstreamRegistry struct {
+	host     string
+	token    string
+	client   *http.Client
+	resolver discovery.ServiceDiscoveryResolver
+
+	// allowedHosts are the hosts from which module archives are downloaded in addition to the upstream registry itself
+	allowedHosts []string
+}
+
+func (u *upstreamRegistry) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]string, error) {
+	resp, err := u.get(ctx, path.Join(namespace, name, provider, "versions"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := upstreamStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var list listResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode module versions of the upstream registry: %w", err)
+	}
+
+	var versions []string
+	for _, m := range list.Modules {
+		for _, v := range m.Versions {
+			versions = append(versions, v.Version)
+		}
+	}
+
+	return versions, nil
+}
+
+func (u *upstreamRegistry) DownloadModule(ctx context.Context, namespace, name, provider, version string) ([]byte, error) {
+	resp, err := u.get(ctx, path.Join(namespace, name, provider, version, "download"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The location is either returned in the X-Terraform-Get header or in the body
+	location := resp.Header.Get("X-Terraform-Get")
+	if location == "" && resp.StatusCode == http.StatusOK {
+		var body struct {
+			Location string `json:"location"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("failed to decode module location of the upstream registry: %w", err)
+		}
+		location = body.Location
+	}
+	if err := upstreamStatus(resp, http.StatusNoContent, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if location == "" {
+		return nil, fmt.Errorf("the upstream registry didn't return the location of module %s/%s/%s/%s", namespace, name, provider, version)
+	}
+
+	src, err := parseModuleSource(resp.Request.URL, location)
+	if err != nil {
+		return nil, err
+	}
+	// The location is controlled by the upstream, it mustn't make the boring-registry request arbitrary hosts
+	if !u.allowed(src.url) {
+		return nil, fmt.Errorf("the module source %s isn't allowed, its host has to be added to the allowed hosts of the upstream", src.url.Redacted())
+	}
+
+	return u.fetchArchive(ctx, src)
+}
+
+// fetchArchive downloads the archive of the module source and repackages it as tar.gz
+func (u *upstreamRegistry) fetchArchive(ctx context.Context, src *moduleSource) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// The token is only sent to the upstream registry itself, but not to third parties hosting the archives
+	if u.token != "" && src.url.Host == u.host {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", u.token))
+	}
+
+	// Redirects are only followed to allowed hosts as well
+	client := *u.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !u.allowed(req.URL) {
+			return fmt.Errorf("the redirect to %s isn't allowed", req.URL.Redacted())
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download module archive: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download module archive from %s: status code is %d", src.url.Redacted(), resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamArchiveSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download module archive: %w", err)
+	}
+	if len(data) > maxUpstreamArchiveSize {
+		return nil, fmt.Errorf("the module archive exceeds the maximum size of %d bytes", maxUpstreamArchiveSize)
+	}
+
+	return repackArchive(data, src)
+}
+
+// allowed reports whether module archives can be downloaded from the host of the URL
+func (u *upstreamRegistry) allowed(target *url.URL) bool {
+	if target.Host == u.host || target.Hostname() == u.host {
+		return true
+	}
+	return slices.Contains(u.allowedHosts, target.Host) || slices.Contains(u.allowedHosts, target.Hostname())
+}
+
+func (u *upstreamRegistry) get(ctx context.Context, p string) (*http.Response, error) {
+	discovered, err := u.resolver.Resolve(ctx, u.host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover upstream registry: %w", err)
+	}
+	if discovered.ModulesV1 == "" {
+		return nil, fmt.Errorf("the upstream registry %s doesn't serve modules", u.host)
+	}
+
+	// The modules path is either absolute or relative to the host serving the discovery document
+	base, err := url.Parse(strings.TrimSuffix(discovered.ModulesV1, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse modules path of the upstream registry: %w", err)
+	}
+	endpoint := discovered.URL.ResolveReference(base).JoinPath(p)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if u.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", u.token))
+	}
+
+	return u.client.Do(req)
+}
+
+func upstreamStatus(resp *http.Response, expected ...int) error {
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrModuleNotFound
+	}
+	return fmt.Errorf("unexpected status code %d of the upstream registry for %s", resp.StatusCode, resp.Request.URL.Redacted())
+}
+
+// moduleSource is the location of a module archive, as returned by the upstream registry
+type moduleSource struct {
+	url    *url.URL
+	format string
+
+	// subdir is the directory of the module within the archive
+	subdir string
+
+	// stripRoot removes the single top-level directory of the archive, which is added by GitHub
+	stripRoot bool
+}
+
+// parseModuleSource supports HTTP URLs of tar.gz or zip archives, as well as GitHub repositories,
+// which are downloaded as archives instead of being cloned.
+// See https://developer.hashicorp.com/terraform/language/modules/sources for the source address syntax.
+func parseModuleSource(base *url.URL, location string) (*moduleSource, error) {
+	src := &moduleSource{}
+	location = strings.TrimPrefix(location, "git::")
+	if strings.HasPrefix(location, "github.com/") {
+		location = "https://" + location
+	}
+
+	location, src.subdir = splitSubdir(location)
+
+	u, err := base.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse module source %s: %w", location, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("the module source %s isn't supported, only HTTP archives and GitHub repositories are", u.Redacted())
+	}
+
+	query := u.Query()
+	if u.Host == "github.com" {
+		// github.com/<owner>/<repo>?ref=<ref> is downloaded from the archive endpoint of GitHub
+		parts := strings.Split(strings.Trim(strings.TrimSuffix(u.Path, ".git"), "/"), "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("the GitHub module source %s isn't supported", u.Redacted())
+		}
+		ref := query.Get("ref")
+		if ref == "" {
+			ref = "HEAD"
+		}
+
+		src.url = &url.URL{Scheme: "https", Host: "codeload.github.com", Path: "/" + path.Join(parts[0], parts[1], "tar.gz", ref)}
+		src.format = "tar.gz"
+		src.stripRoot = true
+		return src, nil
+	}
+
+	// The archive format is either given explicitly or derived from the file extension
+	src.format = query.Get("archive")
+	query.Del("archive")
+	u.RawQuery = query.Encode()
+	if src.format == "" {
+		for _, format := range []string{"tar.gz", "tgz", "zip"} {
+			if strings.HasSuffix(u.Path, "."+format) {
+				src.format = format
+			}
+		}
+	}
+
+	switch src.format {
+	case "tar.gz", "tgz", "zip":
+	default:
+		return nil, fmt.Errorf("the archive format of the module source %s isn't supported, only tar.gz and zip are", u.Redacted())
+	}
+
+	src.url = u
+	return src, nil
+}
+
+// splitSubdir splits the subdirectory separated by a double slash from the source address
+func splitSubdir(location string) (string, string) {
+	offset := 0
+	if i := strings.Index(location, "://"); i >= 0 {
+		offset = i + 3
+	}
+
+	i := strings.Index(location[offset:], "//")
+	if i < 0 {
+		return location, ""
+	}
+	i += offset
+
+	subdir := location[i+2:]
+	var query string
+	if q := strings.Index(subdir, "?"); q >= 0 {
+		subdir, query = subdir[:q], subdir[q:]
+	}
+
+	return location[:i] + query, strings.Trim(subdir, "/")
+}
+
+// UpstreamOption provides additional options for the Upstream.
+type UpstreamOption func(*upstreamRegistry)
+
+// WithUpstreamToken authenticates the requests to the upstream registry with the token
+func WithUpstreamToken(token string) UpstreamOption {
+	return func(u *upstreamRegistry) {
+		u.token = token
+	}
+}
+
+// WithUpstreamHTTPClient replaces the HTTP client used for the upstream registry and the module archives
+func WithUpstreamHTTPClient(client *http.Client) UpstreamOption {
+	return func(u *upstreamRegistry) {
+		u.client = client
+	}
+}
+
+// WithUpstreamAllowedHosts allows downloading module archives from the hosts, e.g. codeload.github.com for GitHub repositories.
+// Archives are only downloaded from the upstream registry itself by default.
+func WithUpstreamAllowedHosts(hosts ...string) UpstreamOption {
+	return func(u *upstreamRegistry) {
+		u.allowedHosts = append(u.allowedHosts, hosts...)
+	}
+}
+
+// NewUpstreamRegistry returns an Upstream for the registry with the hostname, e.g. registry.terraform.io.
+func NewUpstreamRegistry(host string, options ...UpstreamOption) Upstream {
+	u := &upstreamRegistry{
+		host:   host,
+		client: &http.Client{},
+	}
+
+	for _, option := range options {
+		option(u)
+	}
+	u.resolver = discovery.NewRemoteServiceDiscovery(u.client)
+
+	return u
+}
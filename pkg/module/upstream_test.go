@@ -0,0 +1,246 @@
+package module
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testArchiveFiles(t *testing.T, data []byte) map[string]string {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+
+	return files
+}
+
+func TestSplitSubdir(t *testing.T) {
+	testCases := []struct {
+		location string
+		expected string
+		subdir   string
+	}{
+		{location: "https://example.com/vpc.tar.gz", expected: "https://example.com/vpc.tar.gz"},
+		{location: "https://example.com/vpc.tar.gz//modules/vpc", expected: "https://example.com/vpc.tar.gz", subdir: "modules/vpc"},
+		{location: "https://example.com/vpc.zip//modules/vpc?archive=zip", expected: "https://example.com/vpc.zip?archive=zip", subdir: "modules/vpc"},
+		{location: "github.com/acme/vpc//modules/vpc?ref=v1.0.0", expected: "github.com/acme/vpc?ref=v1.0.0", subdir: "modules/vpc"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.location, func(t *testing.T) {
+			location, subdir := splitSubdir(tc.location)
+			assert.Equal(t, tc.expected, location)
+			assert.Equal(t, tc.subdir, subdir)
+		})
+	}
+}
+
+func TestParseModuleSource(t *testing.T) {
+	base, _ := url.Parse("https://registry.example.com/v1/modules/acme/vpc/aws/1.0.0/download")
+
+	testCases := []struct {
+		location    string
+		url         string
+		format      string
+		subdir      string
+		stripRoot   bool
+		expectError bool
+	}{
+		{
+			location: "https://archives.example.com/vpc.tar.gz",
+			url:      "https://archives.example.com/vpc.tar.gz",
+			format:   "tar.gz",
+		},
+		{
+			location: "/archives/vpc?archive=zip&token=secret",
+			url:      "https://registry.example.com/archives/vpc?token=secret",
+			format:   "zip",
+		},
+		{
+			location:  "git::https://github.com/acme/terraform-aws-vpc.git//modules/vpc?ref=v1.0.0",
+			url:       "https://codeload.github.com/acme/terraform-aws-vpc/tar.gz/v1.0.0",
+			format:    "tar.gz",
+			subdir:    "modules/vpc",
+			stripRoot: true,
+		},
+		{
+			location:  "github.com/acme/terraform-aws-vpc",
+			url:       "https://codeload.github.com/acme/terraform-aws-vpc/tar.gz/HEAD",
+			format:    "tar.gz",
+			stripRoot: true,
+		},
+		{
+			location:    "git::ssh://git@example.com/acme/vpc.git",
+			expectError: true,
+		},
+		{
+			location:    "https://archives.example.com/vpc.tar.bz2",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.location, func(t *testing.T) {
+			src, err := parseModuleSource(base, tc.location)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.url, src.url.String())
+			assert.Equal(t, tc.format, src.format)
+			assert.Equal(t, tc.subdir, src.subdir)
+			assert.Equal(t, tc.stripRoot, src.stripRoot)
+		})
+	}
+}
+
+func TestRepackArchive(t *testing.T) {
+	assert := assert.New(t)
+
+	zipped := new(bytes.Buffer)
+	zw := zip.NewWriter(zipped)
+	for name, content := range map[string]string{"main.tf": "zip", "modules/vpc/main.tf": "vpc"} {
+		w, _ := zw.Create(name)
+		_, _ = w.Write([]byte(content))
+	}
+	assert.NoError(zw.Close())
+
+	data, err := repackArchive(zipped.Bytes(), &moduleSource{format: "zip"})
+	assert.NoError(err)
+	assert.Equal(map[string]string{"main.tf": "zip", "modules/vpc/main.tf": "vpc"}, testArchiveFiles(t, data))
+
+	// GitHub archives contain a top-level directory named after the repository and ref
+	github := testModuleData(map[string]string{
+		"terraform-aws-vpc-1.0.0/main.tf":             "root",
+		"terraform-aws-vpc-1.0.0/modules/vpc/main.tf": "vpc",
+	})
+
+	data, err = repackArchive(github.Bytes(), &moduleSource{format: "tar.gz", stripRoot: true})
+	assert.NoError(err)
+	assert.Equal(map[string]string{"main.tf": "root", "modules/vpc/main.tf": "vpc"}, testArchiveFiles(t, data))
+
+	data, err = repackArchive(github.Bytes(), &moduleSource{format: "tar.gz", stripRoot: true, subdir: "modules/vpc"})
+	assert.NoError(err)
+	assert.Equal(map[string]string{"main.tf": "vpc"}, testArchiveFiles(t, data))
+
+	_, err = repackArchive(github.Bytes(), &moduleSource{format: "tar.gz", stripRoot: true, subdir: "modules/subnet"})
+	assert.ErrorContains(err, "doesn't contain the directory")
+
+	_, err = repackArchive(testModuleData(map[string]string{"../main.tf": "escape"}).Bytes(), &moduleSource{format: "tar.gz"})
+	assert.ErrorContains(err, "unsafe path")
+
+	limit := newExtractionLimit(8, 0)
+	_, err = limit.read(strings.NewReader("main.tf"))
+	assert.NoError(err)
+	_, err = limit.read(strings.NewReader("vpc"))
+	assert.ErrorContains(err, "exceed the maximum size of 8 bytes")
+
+	limit = newExtractionLimit(8, 4)
+	_, err = limit.read(strings.NewReader("main.tf"))
+	assert.ErrorContains(err, "exceeds the maximum size of 4 bytes")
+}
+
+func TestService_Upstream(t *testing.T) {
+	assert := assert.New(t)
+
+	var downloads atomic.Int32
+	archive := testModuleData(map[string]string{"main.tf": "upstream"}).Bytes()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"modules.v1": "/v1/modules/"}`))
+	})
+	mux.HandleFunc("/v1/modules/acme/vpc/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "1.0.0"}, {"version": "2.0.0"}]}]}`))
+	})
+	mux.HandleFunc("/v1/modules/acme/vpc/aws/2.0.0/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Terraform-Get", "/archives/vpc.tar.gz")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/v1/modules/acme/vpc/aws/1.0.0/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Terraform-Get", "https://169.254.169.254/latest/meta-data.tar.gz")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/archives/vpc.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		_, _ = w.Write(archive)
+	})
+
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	var (
+		ctx      = context.Background()
+		storage  = NewInmemStorage()
+		upstream = NewUpstreamRegistry(server.Listener.Addr().String(), WithUpstreamToken("secret"), WithUpstreamHTTPClient(server.Client()))
+		svc      = NewService(storage, core.NewProxyUrlService(false, "/proxy"), WithUpstream(upstream))
+	)
+
+	_, err := storage.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", testModuleData(map[string]string{"main.tf": "local"}))
+	assert.NoError(err)
+
+	modules, err := svc.ListModuleVersions(ctx, "acme", "vpc", "aws")
+	assert.NoError(err)
+	var versions []string
+	for _, m := range modules {
+		versions = append(versions, m.Version)
+	}
+	sort.Strings(versions)
+	assert.Equal([]string{"1.0.0", "2.0.0"}, versions)
+
+	// The module is fetched once and served from the storage afterwards
+	for i := 0; i < 2; i++ {
+		m, err := svc.GetModule(ctx, "acme", "vpc", "aws", "2.0.0")
+		assert.NoError(err)
+		assert.Equal("2.0.0", m.Version)
+	}
+	assert.Equal(int32(1), downloads.Load())
+
+	checksum, err := svc.GetModuleChecksum(ctx, "acme", "vpc", "aws", "2.0.0")
+	assert.NoError(err)
+	assert.NotEmpty(checksum)
+
+	_, err = svc.GetModule(ctx, "acme", "vpc", "aws", "3.0.0")
+	assert.ErrorIs(err, ErrModuleNotFound)
+
+	// Archives are only downloaded from the upstream and allowed hosts
+	_, err = upstream.DownloadModule(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.ErrorContains(err, "isn't allowed")
+}
@@ -0,0 +1,159 @@
+package observability
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// RequestIDHeader is the header the request ID is propagated in, it's generated if the client doesn't send it
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of request IDs sent by clients, as they are written to every log line of the request
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of the context carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of the context, or an empty string if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Audit logs an audit event of an administrative change together with the address of the client.
+// The request ID is added by the ContextHandler.
+func Audit(ctx context.Context, event string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("event", event)}, attrs...)
+	if remoteAddr, ok := ctx.Value(httptransport.ContextKeyRequestRemoteAddr).(string); ok {
+		attrs = append(attrs, slog.String("remote-addr", remoteAddr))
+	}
+	if forwardedFor, ok := ctx.Value(httptransport.ContextKeyRequestXForwardedFor).(string); ok && forwardedFor != "" {
+		attrs = append(attrs, slog.String("forwarded-for", forwardedFor))
+	}
+	slog.LogAttrs(ctx, slog.LevelWarn, "audit event", attrs...)
+}
+
+// AccessLog wraps the handler to log every request with its status code, latency, and size.
+// The request ID is taken from the X-Request-ID header or generated, returned in the response,
+// and added to the context of the request, so that it's included in the logs written while handling the request.
+func AccessLog(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		begin := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithRequestID(r.Context(), id)
+
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rw.status),
+				slog.Duration("took", time.Since(begin)),
+				slog.Int64("bytes", rw.bytes),
+				slog.String("client-ip", clientIP(r)),
+			}
+			if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+				attrs = append(attrs, slog.String("forwarded-for", forwardedFor))
+			}
+			slog.Default().LogAttrs(ctx, slog.LevelInfo, "request", attrs...)
+		}()
+
+		handler.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// responseWriter records the status code and the number of bytes written
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap allows the http.ResponseController to access the underlying http.ResponseWriter, e.g. to flush streamed downloads
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isValidRequestID only accepts printable ASCII characters, so that clients can't inject content into the logs
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ContextHandler adds the request ID of the context to every record logged with a context
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps the slog.Handler to add the request ID of the context to the records
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request-id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,73 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))))
+	defer slog.SetDefault(defaultLogger)
+
+	var handlerRequestID string
+	handler := AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerRequestID = RequestID(r.Context())
+		slog.InfoContext(r.Context(), "storage operation")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	}))
+
+	tests := []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{name: "propagated request ID", requestID: "abc-123"},
+		{name: "generated request ID", generated: true},
+		{name: "invalid request ID is replaced", requestID: "abc\n123", generated: true},
+		{name: "too long request ID is replaced", requestID: strings.Repeat("a", maxRequestIDLength+1), generated: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/v1/modules/acme/vpc/aws/versions", nil)
+			if tc.requestID != "" {
+				req.Header.Set(RequestIDHeader, tc.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if tc.generated {
+				assert.Len(t, id, 32)
+			} else {
+				assert.Equal(t, tc.requestID, id)
+			}
+			assert.Equal(t, id, handlerRequestID)
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			assert.Len(t, lines, 2)
+
+			var operation, access map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(lines[0]), &operation))
+			assert.NoError(t, json.Unmarshal([]byte(lines[1]), &access))
+			assert.Equal(t, id, operation["request-id"])
+			assert.Equal(t, id, access["request-id"])
+			assert.Equal(t, "GET", access["method"])
+			assert.Equal(t, "/v1/modules/acme/vpc/aws/versions", access["path"])
+			assert.Equal(t, float64(http.StatusNotFound), access["status"])
+			assert.Equal(t, float64(len("not found")), access["bytes"])
+			assert.Equal(t, "192.0.2.1", access["client-ip"])
+		})
+	}
+}
@@ -14,36 +14,78 @@ const (
 	OsLabel           = "os"
 	ArchLabel         = "arch"
 	ProxyFailureLabel = "failure"
+	ArtifactLabel     = "artifact"
+	OperationLabel    = "operation"
+	ResultLabel       = "result"
+	TypeLabel         = "type"
+	CheckLabel        = "check"
 
-	ProxyFailureUrl      = "bad-url"
-	ProxyFailureRequest  = "invalid-request"
-	ProxyFailureDownload = "download"
+	ProxyFailureUrl       = "bad-url"
+	ProxyFailureRequest   = "invalid-request"
+	ProxyFailureDownload  = "download"
+	ProxyFailureChecksum  = "checksum"
+	ProxyFailureSignature = "signature"
+
+	ResultSuccess = "success"
+	ResultError   = "error"
+
+	TypeModule   = "module"
+	TypeProvider = "provider"
 )
 
 type ServerMetrics struct {
+	Audit    *AuditMetrics
 	Mirror   *MirrorMetrics
 	Module   *ModuleMetrics
 	Provider *ProviderMetrics
 	Proxy    *ProxyMetrics
+	Redirect *RedirectMetrics
+	Stats    *StatsMetrics
+	Storage  *StorageMetrics
+	Usage    *UsageMetrics
 	Http     *HttpMetrics
 }
+type AuditMetrics struct {
+	Discrepancies *prometheus.GaugeVec
+	Archives      prometheus.Gauge
+	LastRun       prometheus.Gauge
+}
 type MirrorMetrics struct {
 	ListProviderVersions     *prometheus.CounterVec
 	ListProviderInstallation *prometheus.CounterVec
 	RetrieveProviderArchive  *prometheus.CounterVec
 }
 type ModuleMetrics struct {
-	ListVersions *prometheus.CounterVec
-	Download     *prometheus.CounterVec
+	ListVersions    *prometheus.CounterVec
+	InvalidVersions *prometheus.CounterVec
+	Download        *prometheus.CounterVec
 }
 type ProviderMetrics struct {
-	ListVersions *prometheus.CounterVec
-	Download     *prometheus.CounterVec
+	ListVersions    *prometheus.CounterVec
+	InvalidVersions *prometheus.CounterVec
+	Download        *prometheus.CounterVec
 }
 type ProxyMetrics struct {
 	Download *prometheus.CounterVec
 	Failure  *prometheus.CounterVec
 }
+type RedirectMetrics struct {
+	Downloads *prometheus.CounterVec
+	Rejected  *prometheus.CounterVec
+}
+type StatsMetrics struct {
+	Downloads     *prometheus.CounterVec
+	FlushFailures prometheus.Counter
+}
+type StorageMetrics struct {
+	Operations        *prometheus.CounterVec
+	OperationDuration *prometheus.HistogramVec
+}
+type UsageMetrics struct {
+	Objects *prometheus.GaugeVec
+	Bytes   *prometheus.GaugeVec
+	Quota   *prometheus.GaugeVec
+}
 type HttpMetrics struct {
 	RequestsTotal   *prometheus.CounterVec
 	RequestDuration *prometheus.HistogramVec
@@ -55,10 +97,15 @@ func NewMetrics(buckets []float64) *ServerMetrics {
 	boringNamespace := "boring_registry"
 	httpNamespace := "http"
 
+	auditSubsystem := "audit"
 	mirrorsSubsystem := "mirrors"
 	providersSubsystem := "providers"
 	proxySubsystem := "proxy"
+	redirectSubsystem := "redirect"
 	modulesSubsystem := "modules"
+	statsSubsystem := "stats"
+	storageSubsystem := "storage"
+	usageSubsystem := "usage"
 	requestSubsystem := "request"
 	responseSubsystem := "response"
 
@@ -67,6 +114,33 @@ func NewMetrics(buckets []float64) *ServerMetrics {
 	}
 
 	metrics := &ServerMetrics{
+		Audit: &AuditMetrics{
+			Discrepancies: promauto.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: boringNamespace,
+					Subsystem: auditSubsystem,
+					Name:      "discrepancies",
+					Help:      "The number of stored artifacts which failed a check of the last integrity audit",
+				},
+				[]string{CheckLabel},
+			),
+			Archives: promauto.NewGauge(
+				prometheus.GaugeOpts{
+					Namespace: boringNamespace,
+					Subsystem: auditSubsystem,
+					Name:      "archives_verified",
+					Help:      "The number of archives whose checksum was verified by the last integrity audit",
+				},
+			),
+			LastRun: promauto.NewGauge(
+				prometheus.GaugeOpts{
+					Namespace: boringNamespace,
+					Subsystem: auditSubsystem,
+					Name:      "last_run_timestamp_seconds",
+					Help:      "The time of the last integrity audit as a Unix timestamp",
+				},
+			),
+		},
 		Mirror: &MirrorMetrics{
 			ListProviderVersions: promauto.NewCounterVec(
 				prometheus.CounterOpts{
@@ -106,6 +180,15 @@ func NewMetrics(buckets []float64) *ServerMetrics {
 				},
 				[]string{NamespaceLabel, NameLabel},
 			),
+			InvalidVersions: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: providersSubsystem,
+					Name:      "invalid_versions_total",
+					Help:      "The total number of stored provider versions omitted from listings as they aren't valid semantic versions",
+				},
+				[]string{NamespaceLabel, NameLabel},
+			),
 			Download: promauto.NewCounterVec(
 				prometheus.CounterOpts{
 					Namespace: boringNamespace,
@@ -126,6 +209,15 @@ func NewMetrics(buckets []float64) *ServerMetrics {
 				},
 				[]string{NamespaceLabel, NameLabel, ProviderLabel},
 			),
+			InvalidVersions: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: modulesSubsystem,
+					Name:      "invalid_versions_total",
+					Help:      "The total number of stored module versions omitted from listings as they aren't valid semantic versions",
+				},
+				[]string{NamespaceLabel, NameLabel, ProviderLabel},
+			),
 			Download: promauto.NewCounterVec(
 				prometheus.CounterOpts{
 					Namespace: boringNamespace,
@@ -156,6 +248,95 @@ func NewMetrics(buckets []float64) *ServerMetrics {
 				[]string{ProxyFailureLabel},
 			),
 		},
+		Redirect: &RedirectMetrics{
+			Downloads: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: redirectSubsystem,
+					Name:      "downloads_total",
+					Help:      "The total number of downloads redirected to the storage backend",
+				},
+				[]string{TypeLabel},
+			),
+			Rejected: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: redirectSubsystem,
+					Name:      "rejected_total",
+					Help:      "The total number of download redirects rejected due to an invalid or expired signature",
+				},
+				[]string{TypeLabel},
+			),
+		},
+		Stats: &StatsMetrics{
+			Downloads: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: statsSubsystem,
+					Name:      "downloads_total",
+					Help:      "The total number of successful downloads recorded in the download statistics",
+				},
+				[]string{ArtifactLabel},
+			),
+			FlushFailures: promauto.NewCounter(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: statsSubsystem,
+					Name:      "flush_failures_total",
+					Help:      "The total number of failures to persist download statistics",
+				},
+			),
+		},
+		Storage: &StorageMetrics{
+			Operations: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: storageSubsystem,
+					Name:      "operations_total",
+					Help:      "The total number of operations against the storage backend",
+				},
+				[]string{OperationLabel, ResultLabel},
+			),
+			OperationDuration: promauto.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Namespace: boringNamespace,
+					Subsystem: storageSubsystem,
+					Name:      "operation_duration_seconds",
+					Help:      "The latencies of operations against the storage backend in seconds",
+					Buckets:   buckets,
+				},
+				[]string{OperationLabel},
+			),
+		},
+		Usage: &UsageMetrics{
+			Objects: promauto.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: boringNamespace,
+					Subsystem: usageSubsystem,
+					Name:      "objects",
+					Help:      "The number of objects stored for the modules and providers of a namespace",
+				},
+				[]string{NamespaceLabel},
+			),
+			Bytes: promauto.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: boringNamespace,
+					Subsystem: usageSubsystem,
+					Name:      "bytes",
+					Help:      "The number of bytes stored for the modules and providers of a namespace",
+				},
+				[]string{NamespaceLabel},
+			),
+			Quota: promauto.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Namespace: boringNamespace,
+					Subsystem: usageSubsystem,
+					Name:      "quota_bytes",
+					Help:      "The total size limits of the quota of a namespace in bytes, by the limit type max or soft",
+				},
+				[]string{NamespaceLabel, TypeLabel},
+			),
+		},
 		Http: &HttpMetrics{
 			RequestsTotal: promauto.NewCounterVec(
 				prometheus.CounterOpts{
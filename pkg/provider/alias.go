@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v3"
+)
+
+// Alias serves the versions of a forked provider, which match the version constraints, under the namespace and name
+// of the original provider. This allows hotfixing a provider without changing the configuration of its consumers.
+// Without version constraints and name, an Alias redirects all providers of a namespace to another namespace,
+// e.g. to serve hashicorp/aws from the mirrored copy internal/aws or to keep serving a renamed namespace.
+type Alias struct {
+	// Namespace of the original provider
+	Namespace string `yaml:"namespace"`
+
+	// Name of the original provider, all providers of the namespace are aliased if it is empty
+	Name string `yaml:"name"`
+
+	// Versions is the version constraint of the versions served from the fork, e.g. ">= 5.31.1, < 5.32.0".
+	// All versions are served from the fork if it is empty.
+	Versions string `yaml:"versions"`
+
+	// Source is the fork, which is stored in the registry under a different namespace or name
+	Source AliasSource `yaml:"source"`
+
+	// Warning is displayed by the Terraform CLI. A default warning is used for aliases with version constraints
+	// if it is empty, while aliases of all versions don't display a warning by default.
+	Warning string `yaml:"warning"`
+
+	constraints version.Constraints
+}
+
+// AliasSource references the forked provider, the name is empty if all providers of the namespace are aliased
+type AliasSource struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+}
+
+// Matches returns true if the version of the original provider is served from the fork
+func (a *Alias) Matches(namespace, name, v string) bool {
+	if !a.covers(namespace, name) {
+		return false
+	}
+	if a.constraints == nil {
+		return true
+	}
+
+	parsed, err := version.NewVersion(v)
+	if err != nil {
+		return false
+	}
+
+	return a.constraints.Check(parsed)
+}
+
+// covers returns true if the alias applies to the provider, regardless of the version
+func (a *Alias) covers(namespace, name string) bool {
+	return a.Namespace == namespace && (a.Name == "" || a.Name == name)
+}
+
+// source returns the namespace and name of the fork serving the provider
+func (a *Alias) source(name string) (string, string) {
+	if a.Source.Name == "" {
+		return a.Source.Namespace, name
+	}
+
+	return a.Source.Namespace, a.Source.Name
+}
+
+func (a *Alias) warning(name string) string {
+	if a.Warning != "" || a.Versions == "" {
+		return a.Warning
+	}
+
+	sourceNamespace, sourceName := a.source(name)
+	return fmt.Sprintf("versions %s of %s/%s are served from the fork %s/%s", a.Versions, a.Namespace, name, sourceNamespace, sourceName)
+}
+
+func (a *Alias) validate() error {
+	if a.Namespace == "" {
+		return errors.New("namespace is required")
+	}
+	if a.Source.Namespace == "" {
+		return errors.New("source namespace is required")
+	}
+	if (a.Name == "") != (a.Source.Name == "") {
+		return errors.New("name and source name must either both be set or both be empty")
+	}
+	if a.Source.Namespace == a.Namespace && a.Source.Name == a.Name {
+		return errors.New("source must differ from the provider")
+	}
+	if a.Versions == "" {
+		return nil
+	}
+
+	constraints, err := version.NewConstraint(a.Versions)
+	if err != nil {
+		return fmt.Errorf("invalid versions: %w", err)
+	}
+	a.constraints = constraints
+
+	return nil
+}
+
+type aliasFile struct {
+	Aliases []Alias `yaml:"aliases"`
+}
+
+// LoadAliases reads the provider aliases from a YAML or JSON file
+func LoadAliases(path string) ([]Alias, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider aliases file: %w", err)
+	}
+
+	return ParseAliases(b)
+}
+
+// ParseAliases parses and validates provider aliases from YAML or JSON
+func ParseAliases(b []byte) ([]Alias, error) {
+	var f aliasFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse provider aliases: %w", err)
+	}
+
+	for i := range f.Aliases {
+		if err := f.Aliases[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid provider alias %d: %w", i, err)
+		}
+	}
+
+	return f.Aliases, nil
+}
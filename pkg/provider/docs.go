@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Categories of the provider documentation, following the layout of tfplugindocs
+const (
+	DocCategoryOverview           = "overview"
+	DocCategoryResources          = "resources"
+	DocCategoryDataSources        = "data-sources"
+	DocCategoryEphemeralResources = "ephemeral-resources"
+	DocCategoryFunctions          = "functions"
+	DocCategoryGuides             = "guides"
+)
+
+// docLanguage is the language of all documents, the CDKTF documentation isn't supported
+const docLanguage = "hcl"
+
+// DocCategories are the directories below docs/ which are read by LoadDocs, in the order of the registry UI
+var DocCategories = []string{
+	DocCategoryResources,
+	DocCategoryDataSources,
+	DocCategoryEphemeralResources,
+	DocCategoryFunctions,
+	DocCategoryGuides,
+}
+
+// Doc is a single page of the documentation of a provider version, as served by the v2 API of the Terraform Registry
+type Doc struct {
+	Category    string `json:"category"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Subcategory string `json:"subcategory,omitempty"`
+	Language    string `json:"language"`
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+}
+
+// docFrontMatter is the YAML front matter tfplugindocs generates at the start of every page
+type docFrontMatter struct {
+	PageTitle   string `yaml:"page_title"`
+	Subcategory string `yaml:"subcategory"`
+}
+
+// LoadDocs reads the documentation generated by tfplugindocs from the docs/ directory of a provider,
+// i.e. docs/index.md and docs/<category>/<slug>.md. Other files are ignored.
+func LoadDocs(dir string) ([]Doc, error) {
+	fsys := os.DirFS(dir)
+
+	var docs []Doc
+	if doc, err := loadDoc(fsys, DocCategoryOverview, "index.md"); err == nil {
+		docs = append(docs, doc)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, category := range DocCategories {
+		entries, err := fs.ReadDir(fsys, category)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != ".md" {
+				continue
+			}
+			doc, err := loadDoc(fsys, category, path.Join(category, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no provider documentation found in %s", dir)
+	}
+	return docs, nil
+}
+
+func loadDoc(fsys fs.FS, category, name string) (Doc, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return Doc{}, err
+	}
+
+	slug := strings.TrimSuffix(path.Base(name), ".md")
+	if category == DocCategoryOverview {
+		slug = "index"
+	}
+	doc := Doc{
+		Category: category,
+		Slug:     slug,
+		Title:    slug,
+		Language: docLanguage,
+		Path:     path.Join("docs", name),
+		Content:  string(content),
+	}
+
+	var front docFrontMatter
+	if rest, ok := bytes.CutPrefix(content, []byte("---\n")); ok {
+		if end := bytes.Index(rest, []byte("\n---")); end >= 0 {
+			if err := yaml.Unmarshal(rest[:end], &front); err != nil {
+				return Doc{}, fmt.Errorf("failed to parse the front matter of %s: %w", doc.Path, err)
+			}
+		}
+	}
+	doc.Subcategory = front.Subcategory
+	// Resources and data sources are listed by their name, while guides have a title of their own
+	if front.PageTitle != "" && slices.Contains([]string{DocCategoryOverview, DocCategoryGuides}, category) {
+		doc.Title = front.PageTitle
+	}
+
+	return doc, nil
+}
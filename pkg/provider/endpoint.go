@@ -2,6 +2,10 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
@@ -15,16 +19,61 @@ type listRequest struct {
 	name      string
 }
 
+// listVersionsRequest lists all versions, unless a page is requested
+type listVersionsRequest struct {
+	listRequest
+	page *core.Page
+
+	// prereleases lists the pre-release versions, even if they're hidden by the policy of the namespace
+	prereleases bool
+}
+
+type listResponse struct {
+	*core.ProviderVersions
+	Meta *core.PageMeta `json:"meta,omitempty"`
+}
+
 func listEndpoint(svc Service, metrics *o11y.ProviderMetrics) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(listRequest)
+		req := request.(listVersionsRequest)
 
 		metrics.ListVersions.With(prometheus.Labels{
 			o11y.NamespaceLabel: req.namespace,
 			o11y.NameLabel:      req.name,
 		}).Inc()
 
-		return svc.ListProviderVersions(ctx, req.namespace, req.name)
+		if req.prereleases {
+			ctx = core.WithPrereleases(ctx)
+		}
+		res, err := svc.ListProviderVersions(ctx, req.namespace, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		// The storage backends don't guarantee an order, and Terraform ignores versions which can't be parsed
+		page := *res
+		var invalid []string
+		page.Versions, invalid = core.ValidVersions(slices.Clone(res.Versions), func(v core.ProviderVersion) string { return v.Version })
+		if len(invalid) > 0 {
+			slog.Warn("omitted invalid provider versions from the listing",
+				slog.String("namespace", req.namespace),
+				slog.String("name", req.name),
+				slog.Any("versions", invalid),
+			)
+			metrics.InvalidVersions.With(prometheus.Labels{
+				o11y.NamespaceLabel: req.namespace,
+				o11y.NameLabel:      req.name,
+			}).Add(float64(len(invalid)))
+		}
+		core.SortByVersion(page.Versions, func(v core.ProviderVersion) string { return v.Version })
+		if req.page == nil {
+			return &page, nil
+		}
+
+		var meta *core.PageMeta
+		page.Versions, meta = core.Paginate(page.Versions, *req.page)
+
+		return listResponse{ProviderVersions: &page, Meta: meta}, nil
 	}
 }
 
@@ -37,6 +86,7 @@ type downloadRequest struct {
 }
 
 type downloadResponse struct {
+	Protocols           []string         `json:"protocols,omitempty"`
 	OS                  string           `json:"os"`
 	Arch                string           `json:"arch"`
 	Filename            string           `json:"filename"`
@@ -44,6 +94,7 @@ type downloadResponse struct {
 	Shasum              string           `json:"shasum"`
 	ShasumsURL          string           `json:"shasums_url"`
 	ShasumsSignatureURL string           `json:"shasums_signature_url"`
+	AttestationURL      string           `json:"attestation_url,omitempty"`
 	SigningKeys         core.SigningKeys `json:"signing_keys"`
 }
 
@@ -64,15 +115,213 @@ func downloadEndpoint(svc Service, metrics *o11y.ProviderMetrics) endpoint.Endpo
 			return nil, err
 		}
 
-		return downloadResponse{
-			OS:                  res.OS,
-			Arch:                res.Arch,
-			DownloadURL:         res.DownloadURL,
-			Filename:            res.Filename,
-			Shasum:              res.Shasum,
-			SigningKeys:         res.SigningKeys,
-			ShasumsURL:          res.SHASumsURL,
-			ShasumsSignatureURL: res.SHASumsSignatureURL,
+		return newDownloadResponse(res), nil
+	}
+}
+
+func newDownloadResponse(p *core.Provider) downloadResponse {
+	return downloadResponse{
+		Protocols:           p.Protocols,
+		OS:                  p.OS,
+		Arch:                p.Arch,
+		DownloadURL:         p.DownloadURL,
+		Filename:            p.Filename,
+		Shasum:              p.Shasum,
+		SigningKeys:         p.SigningKeys,
+		ShasumsURL:          p.SHASumsURL,
+		ShasumsSignatureURL: p.SHASumsSignatureURL,
+		AttestationURL:      p.AttestationURL,
+	}
+}
+
+type resolveRequest struct {
+	namespace  string
+	name       string
+	constraint string // optional, the latest stable version is resolved if empty
+	os         string
+	arch       string
+}
+
+type resolveResponse struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	downloadResponse
+}
+
+// resolveEndpoint returns the highest version matching the constraint, which was released for the platform, together with its download,
+// so that clients don't have to resolve version constraints themselves
+func resolveEndpoint(svc Service, metrics *o11y.ProviderMetrics) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resolveRequest)
+
+		res, err := svc.ListProviderVersions(ctx, req.namespace, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		platform := core.Platform{OS: req.os, Arch: req.arch}
+		versions := make([]string, 0, len(res.Versions))
+		for _, v := range res.Versions {
+			if len(v.Platforms) > 0 && !slices.Contains(v.Platforms, platform) {
+				continue
+			}
+			versions = append(versions, v.Version)
+		}
+		version, err := core.MatchVersion(versions, req.constraint)
+		if err != nil {
+			return nil, err
+		} else if version == "" {
+			return nil, fmt.Errorf("%w: no version of %s/%s for %s_%s matches %q", ErrProviderNotFound, req.namespace, req.name, req.os, req.arch, req.constraint)
+		}
+
+		metrics.Download.With(prometheus.Labels{
+			o11y.NamespaceLabel: req.namespace,
+			o11y.NameLabel:      req.name,
+			o11y.VersionLabel:   version,
+			o11y.OsLabel:        req.os,
+			o11y.ArchLabel:      req.arch,
+		}).Inc()
+
+		p, err := svc.GetProvider(ctx, req.namespace, req.name, version, req.os, req.arch)
+		if err != nil {
+			return nil, err
+		}
+
+		return resolveResponse{
+			Namespace:        req.namespace,
+			Name:             req.name,
+			Version:          version,
+			downloadResponse: newDownloadResponse(p),
+		}, nil
+	}
+}
+
+type platformsRequest struct {
+	namespace string
+	name      string
+	version   string
+}
+
+func platformsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(platformsRequest)
+
+		return svc.ListPlatforms(ctx, req.namespace, req.name, req.version)
+	}
+}
+
+type sbomResponse struct {
+	document []byte
+}
+
+func sbomEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(platformsRequest)
+
+		document, err := svc.GetProviderSBOM(ctx, req.namespace, req.name, req.version)
+		if err != nil {
+			return nil, err
+		}
+		return sbomResponse{document: document}, nil
+	}
+}
+
+type downloadStatsResponse struct {
+	*core.DownloadStats
+}
+
+func downloadStatsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+
+		res, err := svc.GetDownloadStats(ctx, req.namespace, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return downloadStatsResponse{res}, nil
+	}
+}
+
+type consumersResponse struct {
+	Consumers core.Consumers `json:"consumers"`
+}
+
+func consumersEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+
+		res, err := svc.ListConsumers(ctx, req.namespace, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return consumersResponse{Consumers: res}, nil
+	}
+}
+
+type channelsResponse struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Channels  core.Channels `json:"channels"`
+}
+
+func channelsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+
+		channels, err := svc.ListChannels(ctx, req.namespace, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return channelsResponse{
+			Namespace: req.namespace,
+			Name:      req.name,
+			Channels:  channels,
 		}, nil
 	}
 }
+
+type channelRequest struct {
+	namespace string
+	name      string
+	channel   string
+	Version   string `json:"version"` // only used when setting the channel
+}
+
+func setChannelEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(channelRequest)
+
+		if err := svc.SetChannel(ctx, req.namespace, req.name, req.channel, req.Version); err != nil {
+			return nil, err
+		}
+
+		o11y.Audit(ctx, "provider.channel.set",
+			slog.String("provider", path.Join(req.namespace, req.name)),
+			slog.String("channel", req.channel),
+			slog.String("version", req.Version),
+		)
+
+		return channelsEndpoint(svc)(ctx, listRequest{namespace: req.namespace, name: req.name})
+	}
+}
+
+func deleteChannelEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(channelRequest)
+
+		if err := svc.DeleteChannel(ctx, req.namespace, req.name, req.channel); err != nil {
+			return nil, err
+		}
+
+		o11y.Audit(ctx, "provider.channel.delete",
+			slog.String("provider", path.Join(req.namespace, req.name)),
+			slog.String("channel", req.channel),
+		)
+
+		return channelsEndpoint(svc)(ctx, listRequest{namespace: req.namespace, name: req.name})
+	}
+}
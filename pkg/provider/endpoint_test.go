@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEndpoint(t *testing.T) {
+	ctx := context.Background()
+	storage := &mockStorage{
+		versions: map[string][]string{
+			"hashicorp/random": {"3.0.0", "3.1.0", "3.2.0", "4.0.0-beta"},
+		},
+		platforms: map[string][]core.Platform{
+			"hashicorp/random/3.2.0": {{OS: "linux", Arch: "amd64"}},
+		},
+	}
+	metrics := &o11y.ProviderMetrics{
+		Download: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "download_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.VersionLabel, o11y.OsLabel, o11y.ArchLabel}),
+	}
+	e := resolveEndpoint(NewService(storage, core.NewProxyUrlService(false, "/proxy")), metrics)
+
+	res, err := e(ctx, resolveRequest{namespace: "hashicorp", name: "random", os: "linux", arch: "amd64"})
+	assert.NoError(t, err)
+	assert.Equal(t, "3.2.0", res.(resolveResponse).Version)
+	assert.Equal(t, "terraform-provider-random_3.2.0_linux_amd64.zip", res.(resolveResponse).Filename)
+
+	// 3.2.0 wasn't released for darwin
+	res, err = e(ctx, resolveRequest{namespace: "hashicorp", name: "random", constraint: "~> 3.0", os: "darwin", arch: "arm64"})
+	assert.NoError(t, err)
+	assert.Equal(t, "3.1.0", res.(resolveResponse).Version)
+
+	_, err = e(ctx, resolveRequest{namespace: "hashicorp", name: "random", constraint: ">= 5.0", os: "linux", arch: "amd64"})
+	assert.ErrorIs(t, err, ErrProviderNotFound)
+
+	_, err = e(ctx, resolveRequest{namespace: "hashicorp", name: "random", constraint: "~>", os: "linux", arch: "amd64"})
+	assert.ErrorIs(t, err, core.ErrInvalidConstraint)
+}
+
+func TestListEndpoint_Page(t *testing.T) {
+	ctx := context.Background()
+	storage := &mockStorage{
+		versions: map[string][]string{
+			"hashicorp/random": {"3.0.0", "3.10.0", "latest", "3.2.0", "4.0.0-beta", "4.0.0"},
+		},
+	}
+	metrics := &o11y.ProviderMetrics{
+		ListVersions:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "list_versions_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel}),
+		InvalidVersions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "invalid_versions_total"}, []string{o11y.NamespaceLabel, o11y.NameLabel}),
+	}
+	e := listEndpoint(NewService(storage, core.NewProxyUrlService(false, "/proxy")), metrics)
+	list := listRequest{namespace: "hashicorp", name: "random"}
+
+	res, err := e(ctx, listVersionsRequest{listRequest: list})
+	assert.NoError(t, err)
+	var versions []string
+	for _, v := range res.(*core.ProviderVersions).Versions {
+		versions = append(versions, v.Version)
+	}
+	assert.Equal(t, []string{"4.0.0", "4.0.0-beta", "3.10.0", "3.2.0", "3.0.0"}, versions)
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, registry.Register(metrics.InvalidVersions))
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, families[0].GetMetric()[0].GetCounter().GetValue())
+
+	res, err = e(ctx, listVersionsRequest{listRequest: list, page: &core.Page{Limit: 2}})
+	assert.NoError(t, err)
+	page := res.(listResponse)
+	if assert.Len(t, page.Versions, 2) {
+		assert.Equal(t, "4.0.0", page.Versions[0].Version)
+		assert.Equal(t, "4.0.0-beta", page.Versions[1].Version)
+	}
+	assert.Equal(t, 2, *page.Meta.NextOffset)
+	assert.Nil(t, page.Meta.PrevOffset)
+}
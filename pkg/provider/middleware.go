@@ -35,11 +35,11 @@ func (mw loggingMiddleware) ListProviderVersions(ctx context.Context, namespace,
 		)
 
 		if err != nil {
-			logger.Error("failed to list provider versions", slog.String("err", err.Error()))
+			logger.ErrorContext(ctx, "failed to list provider versions", slog.String("err", err.Error()))
 			return
 		}
 
-		logger.Info("list provider version", slog.String("took", time.Since(begin).String()))
+		logger.InfoContext(ctx, "list provider version", slog.String("took", time.Since(begin).String()))
 	}(time.Now())
 
 	return mw.next.ListProviderVersions(ctx, namespace, name)
@@ -59,12 +59,208 @@ func (mw loggingMiddleware) GetProvider(ctx context.Context, namespace, name, ve
 		)
 
 		if err != nil {
-			logger.Error("failed to get provider", slog.String("err", err.Error()))
+			logger.ErrorContext(ctx, "failed to get provider", slog.String("err", err.Error()))
 			return
 		}
 
-		logger.Info("get provider", slog.String("took", time.Since(begin).String()))
+		logger.InfoContext(ctx, "get provider", slog.String("took", time.Since(begin).String()))
 	}(time.Now())
 
 	return mw.next.GetProvider(ctx, namespace, name, version, os, arch)
 }
+
+func (mw loggingMiddleware) GetDownloadStats(ctx context.Context, namespace, name string) (stats *core.DownloadStats, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetDownloadStats"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+			),
+		)
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get provider download stats", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get provider download stats", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetDownloadStats(ctx, namespace, name)
+}
+
+func (mw loggingMiddleware) ListConsumers(ctx context.Context, namespace, name string) (consumers core.Consumers, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "ListConsumers"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+			),
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to list provider consumers", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "list provider consumers", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.ListConsumers(ctx, namespace, name)
+}
+
+func (mw loggingMiddleware) ListPlatforms(ctx context.Context, namespace, name, version string) (platforms *core.ProviderPlatforms, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "ListPlatforms"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to list provider platforms", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "list provider platforms", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.ListPlatforms(ctx, namespace, name, version)
+}
+
+func (mw loggingMiddleware) GetProviderSBOM(ctx context.Context, namespace, name, version string) (document []byte, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetProviderSBOM"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get provider SBOM", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get provider SBOM", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetProviderSBOM(ctx, namespace, name, version)
+}
+
+func (mw loggingMiddleware) GetProviderDocs(ctx context.Context, namespace, name, version string) (docs []Doc, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetProviderDocs"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to get provider documentation", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "get provider documentation", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetProviderDocs(ctx, namespace, name, version)
+}
+
+func (mw loggingMiddleware) ListChannels(ctx context.Context, namespace, name string) (channels core.Channels, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "ListChannels"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+			),
+		)
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to list provider channels", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "list provider channels", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.ListChannels(ctx, namespace, name)
+}
+
+func (mw loggingMiddleware) SetChannel(ctx context.Context, namespace, name, channel, version string) (err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "SetChannel"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("channel", channel),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to set provider channel", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "set provider channel", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.SetChannel(ctx, namespace, name, channel, version)
+}
+
+func (mw loggingMiddleware) DeleteChannel(ctx context.Context, namespace, name, channel string) (err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "DeleteChannel"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("channel", channel),
+			),
+		)
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to delete provider channel", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.InfoContext(ctx, "delete provider channel", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.DeleteChannel(ctx, namespace, name, channel)
+}
+
+type prereleaseMiddleware struct {
+	Service
+	policy core.PrereleasePolicy
+}
+
+// PrereleaseMiddleware omits the pre-release versions from the listings of the namespaces in which the policy hides them
+func PrereleaseMiddleware(policy core.PrereleasePolicy) Middleware {
+	return func(next Service) Service {
+		return &prereleaseMiddleware{Service: next, policy: policy}
+	}
+}
+
+func (mw prereleaseMiddleware) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	versions, err := mw.Service.ListProviderVersions(ctx, namespace, name)
+	if err != nil || mw.policy.Visible(ctx, namespace) {
+		return versions, err
+	}
+
+	res := *versions
+	res.Versions = core.HidePrereleases(versions.Versions, func(v core.ProviderVersion) string { return v.Version })
+	return &res, nil
+}
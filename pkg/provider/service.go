@@ -2,8 +2,15 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
 
+	"github.com/boring-registry/boring-registry/pkg/channel"
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/stats"
 )
 
 // Service implements the Provider Registry Protocol.
@@ -11,28 +18,114 @@ import (
 type Service interface {
 	GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error)
 	ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error)
+	GetDownloadStats(ctx context.Context, namespace, name string) (*core.DownloadStats, error)
+
+	// ListConsumers returns which consumers downloaded which versions of the provider
+	ListConsumers(ctx context.Context, namespace, name string) (core.Consumers, error)
+
+	// ListPlatforms returns the availability, checksums and lock file hashes of a provider version on all platforms of the provider
+	ListPlatforms(ctx context.Context, namespace, name, version string) (*core.ProviderPlatforms, error)
+
+	// GetProviderSBOM returns the SPDX or CycloneDX SBOM attached to the provider version
+	GetProviderSBOM(ctx context.Context, namespace, name, version string) ([]byte, error)
+
+	// GetProviderDocs returns the documentation of the provider version
+	GetProviderDocs(ctx context.Context, namespace, name, version string) ([]Doc, error)
+
+	// ListChannels returns the channels of the provider, the version endpoints accept channels in place of versions
+	ListChannels(ctx context.Context, namespace, name string) (core.Channels, error)
+	SetChannel(ctx context.Context, namespace, name, channel, version string) error
+	DeleteChannel(ctx context.Context, namespace, name, channel string) error
 }
 
 type service struct {
-	storage Storage
-	proxy   core.ProxyUrlService
+	storage  Storage
+	proxy    core.ProxyUrlService
+	stats    stats.Recorder
+	tracker  stats.ConsumerTracker
+	redirect core.DownloadRedirector
+	aliases  []Alias
+	channels *channel.Manager
+}
+
+// ServiceOption provides additional options for the Service.
+type ServiceOption func(*service)
+
+// WithDownloadStats enables recording the downloads of providers
+func WithDownloadStats(recorder stats.Recorder) ServiceOption {
+	return func(s *service) {
+		s.stats = recorder
+	}
+}
+
+// WithConsumerTracking records which consumers download which versions of providers
+func WithConsumerTracking(tracker stats.ConsumerTracker) ServiceOption {
+	return func(s *service) {
+		s.tracker = tracker
+	}
+}
+
+// WithDownloadRedirect replaces the download URLs of provider archives with signed URLs of the redirect endpoint
+func WithDownloadRedirect(redirector core.DownloadRedirector) ServiceOption {
+	return func(s *service) {
+		s.redirect = redirector
+	}
+}
+
+// WithAliases serves versions of forked providers under the namespace and name of the original providers
+func WithAliases(aliases ...Alias) ServiceOption {
+	return func(s *service) {
+		s.aliases = append(s.aliases, aliases...)
+	}
+}
+
+// WithChannels resolves channels like stable to the version they point to
+func WithChannels(manager *channel.Manager) ServiceOption {
+	return func(s *service) {
+		s.channels = manager
+	}
 }
 
 // NewService returns a fully initialized Service.
-func NewService(storage Storage, proxy core.ProxyUrlService) Service {
-	return &service{
+func NewService(storage Storage, proxy core.ProxyUrlService, options ...ServiceOption) Service {
+	s := &service{
 		storage: storage,
 		proxy:   proxy,
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
 func (s *service) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
-	p, err := s.storage.GetProvider(ctx, namespace, name, version, os, arch)
+	version, err := s.resolve(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceNamespace, sourceName := namespace, name
+	if a := s.alias(namespace, name, version); a != nil {
+		sourceNamespace, sourceName = a.source(name)
+	}
+
+	p, err := s.storage.GetProvider(ctx, sourceNamespace, sourceName, version, os, arch)
 	if err != nil {
 		return p, err
 	}
+	p.Namespace = namespace
+	p.Name = name
 
-	if s.proxy.IsProxyEnabled(ctx) {
+	if s.redirect != nil {
+		// The redirect endpoint looks up the fork directly
+		downloadUrl, err := s.redirect.ProviderURL(ctx, sourceNamespace, sourceName, version, os, arch)
+		if err != nil {
+			return p, err
+		}
+		p.DownloadURL = downloadUrl
+	} else if s.proxy.IsProxyEnabled(ctx) {
 		downloadUrl, err := s.proxy.GetProxyUrl(ctx, p.DownloadURL)
 		if err != nil {
 			return p, err
@@ -50,11 +143,273 @@ func (s *service) GetProvider(ctx context.Context, namespace, name, version, os,
 			return p, err
 		}
 		p.SHASumsSignatureURL = shaSumsSignatureURL
+
+		if p.AttestationURL != "" {
+			attestationURL, err := s.proxy.GetProxyUrl(ctx, p.AttestationURL)
+			if err != nil {
+				return p, err
+			}
+			p.AttestationURL = attestationURL
+		}
+	}
+
+	// The redirect endpoint records the download once the archive is actually requested
+	if s.stats != nil && s.redirect == nil {
+		s.stats.Record(stats.ProviderArtifact(namespace, name), version)
+	}
+	// Consumers are tracked even if downloads are redirected, as the redirect endpoint doesn't know the consumer
+	if s.tracker != nil {
+		s.tracker.Track(ctx, stats.ProviderArtifact(namespace, name), version)
 	}
 
 	return p, err
 }
 
 func (s *service) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
-	return s.storage.ListProviderVersions(ctx, namespace, name)
+	var aliases []*Alias
+	for i := range s.aliases {
+		if s.aliases[i].covers(namespace, name) {
+			aliases = append(aliases, &s.aliases[i])
+		}
+	}
+
+	res, err := s.storage.ListProviderVersions(ctx, namespace, name)
+	if len(aliases) == 0 {
+		return res, err
+	}
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	// The versions served from forks replace the versions of the original provider
+	versions := &core.ProviderVersions{}
+	if res != nil {
+		for _, v := range res.Versions {
+			if s.alias(namespace, name, v.Version) == nil {
+				versions.Versions = append(versions.Versions, v)
+			}
+		}
+	}
+
+	for _, a := range aliases {
+		sourceNamespace, sourceName := a.source(name)
+		fork, forkErr := s.storage.ListProviderVersions(ctx, sourceNamespace, sourceName)
+		if forkErr != nil {
+			if isNotFound(forkErr) {
+				continue
+			}
+			return nil, forkErr
+		}
+
+		served := false
+		for _, v := range fork.Versions {
+			// Only the first matching alias serves a version, as GetProvider resolves the same one
+			if s.alias(namespace, name, v.Version) != a {
+				continue
+			}
+			v.Namespace = namespace
+			v.Name = name
+			versions.Versions = append(versions.Versions, v)
+			served = true
+		}
+		if warning := a.warning(name); served && warning != "" {
+			versions.Warnings = append(versions.Warnings, warning)
+		}
+	}
+
+	if len(versions.Versions) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		// All versions of the original provider are served from forks, which don't have any
+		return nil, fmt.Errorf("%w: %s/%s", ErrProviderNotFound, namespace, name)
+	}
+
+	return versions, nil
+}
+
+func (s *service) ListPlatforms(ctx context.Context, namespace, name, version string) (*core.ProviderPlatforms, error) {
+	version, err := s.resolve(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.ListProviderVersions(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The matrix contains the platforms of all versions, so that missing platforms are visible
+	var platforms []core.Platform
+	known := make(map[core.Platform]bool)
+	available := make(map[core.Platform]bool)
+	found := false
+	for _, v := range versions.Versions {
+		for _, p := range v.Platforms {
+			if !known[p] {
+				known[p] = true
+				platforms = append(platforms, p)
+			}
+			if v.Version == version {
+				available[p] = true
+			}
+		}
+		found = found || v.Version == version
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s/%s %s", ErrProviderNotFound, namespace, name, version)
+	}
+
+	sort.Slice(platforms, func(i, j int) bool {
+		if platforms[i].OS != platforms[j].OS {
+			return platforms[i].OS < platforms[j].OS
+		}
+		return platforms[i].Arch < platforms[j].Arch
+	})
+
+	sourceNamespace, sourceName := namespace, name
+	if a := s.alias(namespace, name, version); a != nil {
+		sourceNamespace, sourceName = a.source(name)
+	}
+
+	res := &core.ProviderPlatforms{
+		Namespace: namespace,
+		Name:      name,
+		Version:   version,
+		Platforms: make([]core.PlatformAvailable, 0, len(platforms)),
+	}
+	for _, p := range platforms {
+		entry := core.PlatformAvailable{OS: p.OS, Arch: p.Arch, Available: available[p]}
+		if entry.Available {
+			// The storage is queried directly, as no download is recorded and no download URL is needed
+			provider, err := s.storage.GetProvider(ctx, sourceNamespace, sourceName, version, p.OS, p.Arch)
+			if err != nil {
+				return nil, err
+			}
+			entry.Filename = provider.Filename
+			entry.Shasum = provider.Shasum
+
+			entry.Hashes, err = s.storage.ProviderHashes(ctx, sourceNamespace, sourceName, version, p.OS, p.Arch)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res.Platforms = append(res.Platforms, entry)
+	}
+
+	return res, nil
+}
+
+// alias returns the first Alias serving the version of the provider, or nil if the version is not aliased
+func (s *service) GetProviderSBOM(ctx context.Context, namespace, name, version string) ([]byte, error) {
+	sbomStorage, ok := s.storage.(SBOMStorage)
+	if !ok {
+		return nil, fmt.Errorf("%w: the storage backend doesn't support SBOMs", core.ErrObjectNotFound)
+	}
+
+	version, err := s.resolve(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// The SBOM of an aliased version is the one of the fork
+	if a := s.alias(namespace, name, version); a != nil {
+		namespace, name = a.source(name)
+	}
+	return sbomStorage.ProviderSBOM(ctx, namespace, name, version)
+}
+
+func (s *service) GetProviderDocs(ctx context.Context, namespace, name, version string) ([]Doc, error) {
+	docsStorage, ok := s.storage.(DocsStorage)
+	if !ok {
+		return nil, fmt.Errorf("%w: the storage backend doesn't support provider documentation", core.ErrObjectNotFound)
+	}
+
+	version, err := s.resolve(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// The documentation of an aliased version is the one of the fork
+	if a := s.alias(namespace, name, version); a != nil {
+		namespace, name = a.source(name)
+	}
+	return docsStorage.ProviderDocs(ctx, namespace, name, version)
+}
+
+func (s *service) alias(namespace, name, version string) *Alias {
+	for i := range s.aliases {
+		if s.aliases[i].Matches(namespace, name, version) {
+			return &s.aliases[i]
+		}
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var providerError *core.ProviderError
+	if errors.As(err, &providerError) {
+		return providerError.StatusCode == http.StatusNotFound
+	}
+
+	return errors.Is(err, ErrProviderNotFound)
+}
+
+func (s *service) GetDownloadStats(ctx context.Context, namespace, name string) (*core.DownloadStats, error) {
+	if s.stats == nil {
+		return nil, stats.ErrStatsDisabled
+	}
+
+	return s.stats.Stats(ctx, stats.ProviderArtifact(namespace, name))
+}
+
+func (s *service) ListConsumers(ctx context.Context, namespace, name string) (core.Consumers, error) {
+	if s.tracker == nil {
+		return nil, stats.ErrConsumersDisabled
+	}
+
+	return s.tracker.Consumers(ctx, stats.ProviderArtifact(namespace, name))
+}
+
+func (s *service) ListChannels(ctx context.Context, namespace, name string) (core.Channels, error) {
+	if s.channels == nil {
+		return nil, channel.ErrChannelsDisabled
+	}
+
+	return s.channels.List(ctx, stats.ProviderArtifact(namespace, name))
+}
+
+func (s *service) SetChannel(ctx context.Context, namespace, name, ch, version string) error {
+	if s.channels == nil {
+		return channel.ErrChannelsDisabled
+	}
+
+	// Channels can only point to published versions, so that they never resolve to a missing version
+	versions, err := s.ListProviderVersions(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(versions.Versions, func(v core.ProviderVersion) bool { return v.Version == version }) {
+		return fmt.Errorf("%w: %s/%s %s", ErrProviderNotFound, namespace, name, version)
+	}
+
+	return s.channels.Set(ctx, stats.ProviderArtifact(namespace, name), ch, version)
+}
+
+func (s *service) DeleteChannel(ctx context.Context, namespace, name, ch string) error {
+	if s.channels == nil {
+		return channel.ErrChannelsDisabled
+	}
+
+	return s.channels.Delete(ctx, stats.ProviderArtifact(namespace, name), ch)
+}
+
+// resolve returns the version the channel points to, versions are returned unchanged
+func (s *service) resolve(ctx context.Context, namespace, name, version string) (string, error) {
+	if s.channels == nil {
+		return version, nil
+	}
+
+	return s.channels.Resolve(ctx, stats.ProviderArtifact(namespace, name), version)
 }
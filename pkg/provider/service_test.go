@@ -0,0 +1,262 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/channel"
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockStorage serves the versions of providers keyed by "namespace/name".
+// The platforms of versions are keyed by "namespace/name/version", all platforms are available if not set.
+type mockStorage struct {
+	versions  map[string][]string
+	platforms map[string][]core.Platform
+}
+
+func (m *mockStorage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	for _, v := range m.versions[namespace+"/"+name] {
+		if v != version {
+			continue
+		}
+
+		if platforms, ok := m.platforms[namespace+"/"+name+"/"+version]; ok && !slices.Contains(platforms, core.Platform{OS: os, Arch: arch}) {
+			break
+		}
+
+		filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", name, version, os, arch)
+		return &core.Provider{
+			Namespace:   namespace,
+			Name:        name,
+			Version:     version,
+			OS:          os,
+			Arch:        arch,
+			Filename:    filename,
+			Shasum:      "sha-" + filename,
+			DownloadURL: "https://example.com/" + namespace + "/" + name + "/" + version,
+		}, nil
+	}
+
+	return nil, ErrProviderNotFound
+}
+
+func (m *mockStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	versions, ok := m.versions[namespace+"/"+name]
+	if !ok {
+		return nil, &core.ProviderError{Reason: "not found", Provider: &core.Provider{}, StatusCode: http.StatusNotFound}
+	}
+
+	res := &core.ProviderVersions{}
+	for _, v := range versions {
+		res.Versions = append(res.Versions, core.ProviderVersion{
+			Namespace: namespace,
+			Name:      name,
+			Version:   v,
+			Platforms: m.platforms[namespace+"/"+name+"/"+v],
+		})
+	}
+	return res, nil
+}
+
+func (m *mockStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	return nil
+}
+
+func (m *mockStorage) ProviderHashes(ctx context.Context, namespace, name, version, os, arch string) ([]string, error) {
+	return []string{fmt.Sprintf("h1:%s_%s_%s", version, os, arch)}, nil
+}
+
+func (m *mockStorage) SigningKeys(ctx context.Context, namespace, name string) (*core.SigningKeys, error) {
+	return &core.SigningKeys{}, nil
+}
+
+func TestService_Aliases(t *testing.T) {
+	ctx := context.Background()
+
+	aliases, err := ParseAliases([]byte(`
+aliases:
+  - namespace: hashicorp
+    name: aws
+    versions: ">= 5.31.1, < 5.32.0"
+    source:
+      namespace: acme
+      name: aws
+    warning: "5.31.x is a patched build of acme"
+`))
+	assert.NoError(t, err)
+
+	storage := &mockStorage{versions: map[string][]string{
+		"hashicorp/aws": {"5.30.0", "5.31.0", "5.31.1"},
+		"acme/aws":      {"5.31.1", "5.31.2", "5.32.0"},
+	}}
+	svc := NewService(storage, core.NewProxyUrlService(false, "/proxy"), WithAliases(aliases...))
+
+	versions, err := svc.ListProviderVersions(ctx, "hashicorp", "aws")
+	assert.NoError(t, err)
+	var got []string
+	for _, v := range versions.Versions {
+		assert.Equal(t, "hashicorp", v.Namespace)
+		got = append(got, v.Version)
+	}
+	assert.Equal(t, []string{"5.30.0", "5.31.0", "5.31.1", "5.31.2"}, got)
+	assert.Equal(t, []string{"5.31.x is a patched build of acme"}, versions.Warnings)
+
+	// Aliased versions are served from the fork
+	p, err := svc.GetProvider(ctx, "hashicorp", "aws", "5.31.1", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/acme/aws/5.31.1", p.DownloadURL)
+	assert.Equal(t, "hashicorp", p.Namespace)
+
+	p, err = svc.GetProvider(ctx, "hashicorp", "aws", "5.31.0", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/hashicorp/aws/5.31.0", p.DownloadURL)
+
+	// Versions of the fork outside the constraints are not served
+	_, err = svc.GetProvider(ctx, "hashicorp", "aws", "5.32.0", "linux", "amd64")
+	assert.ErrorIs(t, err, ErrProviderNotFound)
+
+	// Other providers are not affected
+	_, err = svc.ListProviderVersions(ctx, "hashicorp", "random")
+	assert.Error(t, err)
+}
+
+func TestService_NamespaceAliases(t *testing.T) {
+	ctx := context.Background()
+
+	aliases, err := ParseAliases([]byte(`
+aliases:
+  - namespace: hashicorp
+    source:
+      namespace: internal
+`))
+	assert.NoError(t, err)
+
+	storage := &mockStorage{versions: map[string][]string{
+		"hashicorp/aws":    {"5.30.0"},
+		"internal/aws":     {"5.31.0", "5.32.0"},
+		"internal/random":  {"3.6.0"},
+		"hashicorp/google": {"6.0.0"},
+	}}
+	svc := NewService(storage, core.NewProxyUrlService(false, "/proxy"), WithAliases(aliases...))
+
+	versions, err := svc.ListProviderVersions(ctx, "hashicorp", "aws")
+	assert.NoError(t, err)
+	var got []string
+	for _, v := range versions.Versions {
+		assert.Equal(t, "hashicorp", v.Namespace)
+		assert.Equal(t, "aws", v.Name)
+		got = append(got, v.Version)
+	}
+	assert.Equal(t, []string{"5.31.0", "5.32.0"}, got)
+	assert.Empty(t, versions.Warnings)
+
+	// The metadata matches the requested provider, while the archive and its checksum are served from the mirror
+	p, err := svc.GetProvider(ctx, "hashicorp", "random", "3.6.0", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "hashicorp", p.Namespace)
+	assert.Equal(t, "random", p.Name)
+	assert.Equal(t, "terraform-provider-random_3.6.0_linux_amd64.zip", p.Filename)
+	assert.Equal(t, "https://example.com/internal/random/3.6.0", p.DownloadURL)
+
+	// Providers missing in the mirror are not found, even if they exist under the original namespace
+	_, err = svc.ListProviderVersions(ctx, "hashicorp", "google")
+	assert.Error(t, err)
+}
+
+func TestService_ListPlatforms(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		linuxAmd64  = core.Platform{OS: "linux", Arch: "amd64"}
+		linuxArm64  = core.Platform{OS: "linux", Arch: "arm64"}
+		darwinArm64 = core.Platform{OS: "darwin", Arch: "arm64"}
+	)
+	storage := &mockStorage{
+		versions: map[string][]string{"acme/dns": {"1.0.0", "1.1.0"}},
+		platforms: map[string][]core.Platform{
+			"acme/dns/1.0.0": {linuxAmd64, darwinArm64},
+			"acme/dns/1.1.0": {linuxAmd64, linuxArm64},
+		},
+	}
+	svc := NewService(storage, core.NewProxyUrlService(false, "/proxy"))
+
+	platforms, err := svc.ListPlatforms(ctx, "acme", "dns", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, &core.ProviderPlatforms{
+		Namespace: "acme",
+		Name:      "dns",
+		Version:   "1.1.0",
+		Platforms: []core.PlatformAvailable{
+			{OS: "darwin", Arch: "arm64"},
+			{OS: "linux", Arch: "amd64", Available: true, Filename: "terraform-provider-dns_1.1.0_linux_amd64.zip", Shasum: "sha-terraform-provider-dns_1.1.0_linux_amd64.zip", Hashes: []string{"h1:1.1.0_linux_amd64"}},
+			{OS: "linux", Arch: "arm64", Available: true, Filename: "terraform-provider-dns_1.1.0_linux_arm64.zip", Shasum: "sha-terraform-provider-dns_1.1.0_linux_arm64.zip", Hashes: []string{"h1:1.1.0_linux_arm64"}},
+		},
+	}, platforms)
+
+	_, err = svc.ListPlatforms(ctx, "acme", "dns", "2.0.0")
+	assert.ErrorIs(t, err, ErrProviderNotFound)
+}
+
+func TestParseAliases(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "invalid constraint",
+			data: `{"aliases": [{"namespace": "hashicorp", "name": "aws", "versions": "not a version", "source": {"namespace": "acme", "name": "aws"}}]}`,
+		},
+		{
+			name: "missing source",
+			data: `{"aliases": [{"namespace": "hashicorp", "name": "aws", "versions": ">= 1.0.0"}]}`,
+		},
+		{
+			name: "name without source name",
+			data: `{"aliases": [{"namespace": "hashicorp", "name": "aws", "source": {"namespace": "acme"}}]}`,
+		},
+		{
+			name: "namespace equals source namespace",
+			data: `{"aliases": [{"namespace": "hashicorp", "source": {"namespace": "hashicorp"}}]}`,
+		},
+		{
+			name: "source equals provider",
+			data: `{"aliases": [{"namespace": "hashicorp", "name": "aws", "versions": ">= 1.0.0", "source": {"namespace": "hashicorp", "name": "aws"}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAliases([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestService_Channels(t *testing.T) {
+	ctx := context.Background()
+	storage := &mockStorage{versions: map[string][]string{
+		"hashicorp/aws": {"5.30.0", "5.31.0"},
+	}}
+	svc := NewService(storage, core.NewProxyUrlService(false, "/proxy"), WithChannels(channel.NewManager(channel.NewInmemStorage())))
+
+	assert.NoError(t, svc.SetChannel(ctx, "hashicorp", "aws", "stable", "5.30.0"))
+	assert.ErrorIs(t, svc.SetChannel(ctx, "hashicorp", "aws", "stable", "6.0.0"), ErrProviderNotFound)
+
+	p, err := svc.GetProvider(ctx, "hashicorp", "aws", "stable", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.30.0", p.Version)
+
+	platforms, err := svc.ListPlatforms(ctx, "hashicorp", "aws", "stable")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.30.0", platforms.Version)
+
+	channels, err := svc.ListChannels(ctx, "hashicorp", "aws")
+	assert.NoError(t, err)
+	assert.Equal(t, core.Channels{"stable": "5.30.0"}, channels)
+}
@@ -16,6 +16,28 @@ type Storage interface {
 	// https://developer.hashicorp.com/terraform/registry/providers/publishing#manually-preparing-a-release
 	UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error
 
-	// SigningKeys downloads and returns the keys for a given namespace from the configured storage backend
-	SigningKeys(ctx context.Context, namespace string) (*core.SigningKeys, error)
+	// ProviderHashes returns the h1: and zh: hashes of the provider archive, as recorded in the dependency lock file
+	ProviderHashes(ctx context.Context, namespace, name, version, os, arch string) ([]string, error)
+
+	// SigningKeys downloads and returns the keys of the provider from the configured storage backend.
+	// Providers without keys of their own fall back to the keys of the namespace, which are returned as well if the name is empty.
+	SigningKeys(ctx context.Context, namespace, name string) (*core.SigningKeys, error)
+}
+
+// SBOMStorage is implemented by storages which store SPDX or CycloneDX SBOMs of provider versions
+type SBOMStorage interface {
+	// UploadProviderSBOM should return an sbom.ErrInvalidSBOM error if the document isn't an SBOM
+	UploadProviderSBOM(ctx context.Context, namespace, name, version string, document io.Reader) error
+
+	// ProviderSBOM should return a core.ErrObjectNotFound error if no SBOM is attached to the provider version
+	ProviderSBOM(ctx context.Context, namespace, name, version string) ([]byte, error)
+}
+
+// DocsStorage is implemented by storages which store the documentation of provider versions
+type DocsStorage interface {
+	// UploadProviderDocs replaces the documentation of the provider version
+	UploadProviderDocs(ctx context.Context, namespace, name, version string, docs []Doc) error
+
+	// ProviderDocs should return a core.ErrObjectNotFound error if no documentation is stored for the provider version
+	ProviderDocs(ctx context.Context, namespace, name, version string) ([]Doc, error)
 }
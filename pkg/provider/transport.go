@@ -2,12 +2,17 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/boring-registry/boring-registry/pkg/channel"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
+	"github.com/boring-registry/boring-registry/pkg/stats"
 
 	"github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
@@ -23,17 +28,39 @@ const (
 	varOS        muxVar = "os"
 	varArch      muxVar = "arch"
 	varVersion   muxVar = "version"
+	varChannel   muxVar = "channel"
+	varID        muxVar = "id"
 )
 
+// v2MediaType is the media type of JSON:API documents
+const v2MediaType = "application/vnd.api+json"
+
 // MakeHandler returns a fully initialized http.Handler.
-func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ProviderMetrics, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ProviderMetrics, instrumentation o11y.Middleware, cache core.CacheMiddleware, options ...httptransport.ServerOption) http.Handler {
 	r := mux.NewRouter().StrictSlash(true)
 
 	r.Methods("GET").Path(`/{namespace}/{name}/versions`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(listEndpoint(svc, metrics)),
+					decodeListVersionsRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/resolve`).Handler(
 		instrumentation.WrapHandler(
 			httptransport.NewServer(
-				auth(listEndpoint(svc, metrics)),
-				decodeListRequest,
+				auth(resolveEndpoint(svc, metrics)),
+				decodeResolveRequest,
 				httptransport.EncodeJSONResponse,
 				append(
 					options,
@@ -59,6 +86,211 @@ func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ProviderMe
 		),
 	)
 
+	r.Methods("GET").Path(`/{namespace}/{name}/{version}/platforms`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(platformsEndpoint(svc)),
+					decodePlatformsRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{version}/sbom`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(sbomEndpoint(svc)),
+				decodePlatformsRequest,
+				encodeSBOMResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/channels`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(channelsEndpoint(svc)),
+				decodeListRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/downloads`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(downloadStatsEndpoint(svc)),
+				decodeListRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/consumers`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(consumersEndpoint(svc)),
+				decodeListRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+// MakeAdminHandler returns a fully initialized http.Handler for the provider administration API.
+func MakeAdminHandler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("PUT").Path(`/providers/{namespace}/{name}/channels/{channel}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(setChannelEndpoint(svc)),
+				decodeChannelRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varChannel)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("DELETE").Path(`/providers/{namespace}/{name}/channels/{channel}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(deleteChannelEndpoint(svc)),
+				decodeChannelRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varChannel)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+// MakeV2Handler returns a fully initialized http.Handler for the v2 provider API of the Terraform Registry.
+func MakeV2Handler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, cache core.CacheMiddleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/providers/{namespace}/{name}`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(v2ProviderEndpoint(svc)),
+					decodeV2ProviderRequest,
+					encodeV2Response,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/providers/{namespace}/{name}/provider-versions/latest`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(v2VersionEndpoint(svc)),
+					decodeV2VersionRequest,
+					encodeV2Response,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/provider-versions/{id}`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(v2VersionEndpoint(svc)),
+					decodeV2VersionRequest,
+					encodeV2Response,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varID)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/provider-docs`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(v2DocsEndpoint(svc)),
+					decodeV2DocsRequest,
+					encodeV2Response,
+					append(
+						options,
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/provider-docs/{id}`).Handler(
+		instrumentation.WrapHandler(
+			cache.WrapHandler(
+				httptransport.NewServer(
+					auth(v2DocEndpoint(svc)),
+					decodeV2DocRequest,
+					encodeV2Response,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varID)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
 	return r
 }
 
@@ -79,6 +311,29 @@ func decodeListRequest(ctx context.Context, r *http.Request) (interface{}, error
 	}, nil
 }
 
+func decodeListVersionsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := core.ParsePage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	prereleases, err := core.PrereleasesFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return listVersionsRequest{
+		listRequest: req.(listRequest),
+		page:        page,
+		prereleases: prereleases,
+	}, nil
+}
+
 func decodeDownloadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	namespace, ok := ctx.Value(varNamespace).(string)
 	if !ok {
@@ -114,18 +369,164 @@ func decodeDownloadRequest(ctx context.Context, r *http.Request) (interface{}, e
 	}, nil
 }
 
+func decodeResolveRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	query := r.URL.Query()
+	os := query.Get("os")
+	if os == "" {
+		return nil, fmt.Errorf("%w: os", core.ErrVarMissing)
+	}
+	arch := query.Get("arch")
+	if arch == "" {
+		return nil, fmt.Errorf("%w: arch", core.ErrVarMissing)
+	}
+
+	return resolveRequest{
+		namespace:  list.namespace,
+		name:       list.name,
+		constraint: query.Get("version"),
+		os:         os,
+		arch:       arch,
+	}, nil
+}
+
+func decodePlatformsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	namespace, ok := ctx.Value(varNamespace).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: namespace", core.ErrVarMissing)
+	}
+
+	name, ok := ctx.Value(varName).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: name", core.ErrVarMissing)
+	}
+
+	version, ok := ctx.Value(varVersion).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
+	}
+
+	return platformsRequest{
+		namespace: namespace,
+		name:      name,
+		version:   version,
+	}, nil
+}
+
+func decodeChannelRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	ch, ok := ctx.Value(varChannel).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: channel", core.ErrVarMissing)
+	}
+
+	res := channelRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		channel:   ch,
+	}
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+			return nil, fmt.Errorf("%w: version: %w", core.ErrVarType, err)
+		}
+		if res.Version == "" {
+			return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
+		}
+	}
+	return res, nil
+}
+
+// include returns the comma-separated relationships of the include query parameter
+func include(r *http.Request) []string {
+	var res []string
+	for _, v := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func decodeV2ProviderRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	return v2ProviderRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		include:   include(r),
+	}, nil
+}
+
+// decodeV2VersionRequest decodes the request of a version by id, or the latest version of a provider
+func decodeV2VersionRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	if id, ok := ctx.Value(varID).(string); ok {
+		return v2VersionRequest{
+			id:      id,
+			include: include(r),
+		}, nil
+	}
+
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	return v2VersionRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		include:   include(r),
+	}, nil
+}
+
+func decodeV2DocsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	versionID := query.Get("filter[provider-version]")
+	if versionID == "" {
+		return nil, fmt.Errorf("%w: filter[provider-version]", core.ErrVarMissing)
+	}
+
+	return v2DocsRequest{
+		versionID: versionID,
+		category:  query.Get("filter[category]"),
+		slug:      query.Get("filter[slug]"),
+		language:  query.Get("filter[language]"),
+	}, nil
+}
+
+func decodeV2DocRequest(ctx context.Context, _ *http.Request) (interface{}, error) {
+	id, ok := ctx.Value(varID).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: id", core.ErrVarMissing)
+	}
+
+	return v2DocRequest{id: id}, nil
+}
+
 // ErrorEncoder translates domain specific errors to HTTP status codes
 func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
-	var providerError *core.ProviderError
-	if errors.Is(err, ErrProviderNotFound) {
-		w.WriteHeader(http.StatusNotFound)
-	} else if errors.As(err, &providerError) {
-		w.WriteHeader(providerError.StatusCode)
-	} else {
-		w.WriteHeader(core.GenericError(err))
+	statusCode := core.GenericError(err)
+	if errors.Is(err, ErrProviderNotFound) || errors.Is(err, stats.ErrStatsDisabled) || errors.Is(err, stats.ErrConsumersDisabled) || errors.Is(err, channel.ErrChannelNotFound) || errors.Is(err, channel.ErrChannelsDisabled) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, channel.ErrInvalidChannel) {
+		statusCode = http.StatusBadRequest
 	}
 
-	core.HandleErrorResponse(err, w)
+	core.HandleErrorResponse(err, statusCode, w)
 }
 
 func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
@@ -139,3 +540,18 @@ func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
 		return ctx
 	}
 }
+
+// encodeSBOMResponse writes the SBOM with the media type of its format
+func encodeSBOMResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(sbomResponse)
+	format, _ := sbom.Detect(res.document)
+	w.Header().Set("Content-Type", sbom.MediaType(format))
+	_, err := w.Write(res.document)
+	return err
+}
+
+// encodeV2Response writes the JSON:API document with its media type
+func encodeV2Response(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", v2MediaType)
+	return json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,350 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/stats"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// The v2 API of the Terraform Registry follows the JSON:API specification, see https://jsonapi.org.
+// It's consumed by the registry UI and tooling like tfplugindocs, while Terraform itself only uses the v1 protocol.
+const (
+	v2TypeProviders        = "providers"
+	v2TypeProviderVersions = "provider-versions"
+	v2TypeProviderDocs     = "provider-docs"
+	v2TypeCategories       = "categories"
+
+	// v2IDSeparator joins the parts of the ids, as the registry has no database assigning ids.
+	// It can't occur in namespaces, names, versions, categories, or slugs.
+	v2IDSeparator = ":"
+)
+
+type v2Document struct {
+	Data     any          `json:"data"`
+	Included []v2Resource `json:"included,omitempty"`
+}
+
+type v2Resource struct {
+	Type          string                    `json:"type"`
+	ID            string                    `json:"id"`
+	Attributes    any                       `json:"attributes"`
+	Relationships map[string]v2Relationship `json:"relationships,omitempty"`
+}
+
+type v2Relationship struct {
+	Data []v2Identifier `json:"data"`
+}
+
+type v2Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type v2ProviderAttributes struct {
+	Alias         string `json:"alias"`
+	Downloads     int64  `json:"downloads"`
+	Featured      bool   `json:"featured"`
+	FullName      string `json:"full-name"`
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	OwnerName     string `json:"owner-name"`
+	RobotsNoindex bool   `json:"robots-noindex"`
+	Unlisted      bool   `json:"unlisted"`
+	Warning       string `json:"warning,omitempty"`
+}
+
+type v2VersionAttributes struct {
+	Downloads int64    `json:"downloads"`
+	Tag       string   `json:"tag"`
+	Version   string   `json:"version"`
+	Protocols []string `json:"protocols,omitempty"`
+}
+
+type v2DocAttributes struct {
+	Category    string  `json:"category"`
+	Language    string  `json:"language"`
+	Path        string  `json:"path"`
+	Slug        string  `json:"slug"`
+	Subcategory *string `json:"subcategory"`
+	Title       string  `json:"title"`
+	Truncated   bool    `json:"truncated"`
+
+	// Content is only returned for single documents
+	Content *string `json:"content,omitempty"`
+}
+
+type v2ProviderRequest struct {
+	namespace string
+	name      string
+	include   []string
+}
+
+// v2VersionRequest references a version by its id, or the latest version of the provider if the id is empty
+type v2VersionRequest struct {
+	id        string
+	namespace string
+	name      string
+	include   []string
+}
+
+type v2DocsRequest struct {
+	versionID string
+	category  string
+	slug      string
+	language  string
+}
+
+type v2DocRequest struct {
+	id string
+}
+
+func v2VersionID(namespace, name, version string) string {
+	return strings.Join([]string{namespace, name, version}, v2IDSeparator)
+}
+
+func parseV2VersionID(id string) (namespace, name, version string, err error) {
+	parts := strings.Split(id, v2IDSeparator)
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return "", "", "", fmt.Errorf("%w: invalid provider version id %q", core.ErrVarType, id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func v2DocID(versionID string, doc Doc) string {
+	return strings.Join([]string{versionID, doc.Category, doc.Slug}, v2IDSeparator)
+}
+
+func parseV2DocID(id string) (versionID, category, slug string, err error) {
+	i := strings.LastIndex(id, v2IDSeparator)
+	j := strings.LastIndex(id[:max(i, 0)], v2IDSeparator)
+	if i < 0 || j < 0 {
+		return "", "", "", fmt.Errorf("%w: invalid provider doc id %q", core.ErrVarType, id)
+	}
+	versionID, category, slug = id[:j], id[j+1:i], id[i+1:]
+	if _, _, _, err := parseV2VersionID(versionID); err != nil || category == "" || slug == "" {
+		return "", "", "", fmt.Errorf("%w: invalid provider doc id %q", core.ErrVarType, id)
+	}
+	return versionID, category, slug, nil
+}
+
+// downloadStats returns empty statistics if they are disabled
+func downloadStats(ctx context.Context, svc Service, namespace, name string) (*core.DownloadStats, error) {
+	res, err := svc.GetDownloadStats(ctx, namespace, name)
+	if errors.Is(err, stats.ErrStatsDisabled) {
+		return &core.DownloadStats{}, nil
+	}
+	return res, err
+}
+
+func v2ProviderEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(v2ProviderRequest)
+
+		res, err := svc.ListProviderVersions(ctx, req.namespace, req.name)
+		if err != nil {
+			return nil, err
+		}
+		downloads, err := downloadStats(ctx, svc, req.namespace, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		versions := slices.Clone(res.Versions)
+		core.SortByVersion(versions, func(v core.ProviderVersion) string { return v.Version })
+
+		doc := v2Document{}
+		ids := []v2Identifier{}
+		for _, v := range versions {
+			version := newV2Version(req.namespace, req.name, v, downloads)
+			ids = append(ids, v2Identifier{Type: version.Type, ID: version.ID})
+			if slices.Contains(req.include, v2TypeProviderVersions) {
+				doc.Included = append(doc.Included, version)
+			}
+		}
+
+		doc.Data = v2Resource{
+			Type: v2TypeProviders,
+			ID:   strings.Join([]string{req.namespace, req.name}, v2IDSeparator),
+			Attributes: v2ProviderAttributes{
+				Alias:     req.name,
+				Downloads: downloads.Total,
+				FullName:  fmt.Sprintf("%s/%s", req.namespace, req.name),
+				Name:      req.name,
+				Namespace: req.namespace,
+				OwnerName: req.namespace,
+				Warning:   strings.Join(res.Warnings, "\n"),
+			},
+			Relationships: map[string]v2Relationship{
+				// Providers aren't categorized, the categories are only listed for compatibility
+				v2TypeCategories:       {Data: []v2Identifier{}},
+				v2TypeProviderVersions: {Data: ids},
+			},
+		}
+
+		return doc, nil
+	}
+}
+
+func newV2Version(namespace, name string, v core.ProviderVersion, downloads *core.DownloadStats) v2Resource {
+	return v2Resource{
+		Type: v2TypeProviderVersions,
+		ID:   v2VersionID(namespace, name, v.Version),
+		Attributes: v2VersionAttributes{
+			Downloads: downloads.Versions[v.Version],
+			Tag:       "v" + v.Version,
+			Version:   v.Version,
+			Protocols: v.Protocols,
+		},
+	}
+}
+
+// v2VersionEndpoint returns a provider version with the ids of its documents, the latest stable version is returned without id
+func v2VersionEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(v2VersionRequest)
+
+		namespace, name, version := req.namespace, req.name, ""
+		if req.id != "" {
+			var err error
+			if namespace, name, version, err = parseV2VersionID(req.id); err != nil {
+				return nil, err
+			}
+		}
+
+		res, err := svc.ListProviderVersions(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if version == "" {
+			version, err = latestVersion(res.Versions)
+			if err != nil {
+				return nil, err
+			}
+		}
+		i := slices.IndexFunc(res.Versions, func(v core.ProviderVersion) bool { return v.Version == version })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s/%s %s", ErrProviderNotFound, namespace, name, version)
+		}
+
+		downloads, err := downloadStats(ctx, svc, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		docs, err := svc.GetProviderDocs(ctx, namespace, name, version)
+		if err != nil && !errors.Is(err, core.ErrObjectNotFound) {
+			return nil, err
+		}
+
+		resource := newV2Version(namespace, name, res.Versions[i], downloads)
+		doc := v2Document{}
+		ids := []v2Identifier{}
+		for _, d := range docs {
+			r := newV2Doc(resource.ID, d, false)
+			ids = append(ids, v2Identifier{Type: r.Type, ID: r.ID})
+			if slices.Contains(req.include, v2TypeProviderDocs) {
+				doc.Included = append(doc.Included, r)
+			}
+		}
+		resource.Relationships = map[string]v2Relationship{v2TypeProviderDocs: {Data: ids}}
+		doc.Data = resource
+
+		return doc, nil
+	}
+}
+
+// latestVersion returns the highest stable version, or the highest pre-release if there's no stable version
+func latestVersion(versions []core.ProviderVersion) (string, error) {
+	candidates := make([]string, 0, len(versions))
+	for _, v := range versions {
+		candidates = append(candidates, v.Version)
+	}
+	if len(candidates) == 0 {
+		return "", ErrProviderNotFound
+	}
+	latest, err := core.MatchVersion(candidates, "")
+	if err != nil || latest != "" {
+		return latest, err
+	}
+
+	core.SortByVersion(candidates, func(v string) string { return v })
+	return candidates[0], nil
+}
+
+func newV2Doc(versionID string, d Doc, content bool) v2Resource {
+	attributes := v2DocAttributes{
+		Category: d.Category,
+		Language: d.Language,
+		Path:     d.Path,
+		Slug:     d.Slug,
+		Title:    d.Title,
+	}
+	if d.Subcategory != "" {
+		attributes.Subcategory = &d.Subcategory
+	}
+	if content {
+		attributes.Content = &d.Content
+	}
+
+	return v2Resource{
+		Type:       v2TypeProviderDocs,
+		ID:         v2DocID(versionID, d),
+		Attributes: attributes,
+	}
+}
+
+// v2DocsEndpoint lists the documents of a provider version matching the filters, without their content
+func v2DocsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(v2DocsRequest)
+
+		namespace, name, version, err := parseV2VersionID(req.versionID)
+		if err != nil {
+			return nil, err
+		}
+		docs, err := svc.GetProviderDocs(ctx, namespace, name, version)
+		if err != nil && !errors.Is(err, core.ErrObjectNotFound) {
+			return nil, err
+		}
+
+		resources := []v2Resource{}
+		for _, d := range docs {
+			if (req.category != "" && d.Category != req.category) || (req.slug != "" && d.Slug != req.slug) || (req.language != "" && d.Language != req.language) {
+				continue
+			}
+			resources = append(resources, newV2Doc(req.versionID, d, false))
+		}
+
+		return v2Document{Data: resources}, nil
+	}
+}
+
+// v2DocEndpoint returns a single document with its content
+func v2DocEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(v2DocRequest)
+
+		versionID, category, slug, err := parseV2DocID(req.id)
+		if err != nil {
+			return nil, err
+		}
+		namespace, name, version, _ := parseV2VersionID(versionID)
+		docs, err := svc.GetProviderDocs(ctx, namespace, name, version)
+		if err != nil {
+			return nil, err
+		}
+
+		i := slices.IndexFunc(docs, func(d Doc) bool { return d.Category == category && d.Slug == slug })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: provider doc %s", core.ErrObjectNotFound, req.id)
+		}
+
+		return v2Document{Data: newV2Doc(versionID, docs[i], true)}, nil
+	}
+}
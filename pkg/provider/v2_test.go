@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDocsStorage serves the docs of provider versions keyed by "namespace/name/version"
+type mockDocsStorage struct {
+	*mockStorage
+	docs map[string][]Doc
+}
+
+func (m *mockDocsStorage) UploadProviderDocs(ctx context.Context, namespace, name, version string, docs []Doc) error {
+	m.docs[namespace+"/"+name+"/"+version] = docs
+	return nil
+}
+
+func (m *mockDocsStorage) ProviderDocs(ctx context.Context, namespace, name, version string) ([]Doc, error) {
+	docs, ok := m.docs[namespace+"/"+name+"/"+version]
+	if !ok {
+		return nil, core.ErrObjectNotFound
+	}
+	return docs, nil
+}
+
+type nopInstrumentation struct{}
+
+func (nopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+func TestParseV2DocID(t *testing.T) {
+	versionID, category, slug, err := parseV2DocID("hashicorp:random:3.1.0:resources:password")
+	assert.NoError(t, err)
+	assert.Equal(t, "hashicorp:random:3.1.0", versionID)
+	assert.Equal(t, "resources", category)
+	assert.Equal(t, "password", slug)
+
+	for _, id := range []string{"", "password", "random:3.1.0:resources:password", "hashicorp:random:3.1.0::password"} {
+		_, _, _, err = parseV2DocID(id)
+		assert.ErrorIs(t, err, core.ErrVarType, id)
+	}
+}
+
+func TestMakeV2Handler(t *testing.T) {
+	storage := &mockDocsStorage{
+		mockStorage: &mockStorage{versions: map[string][]string{
+			"hashicorp/random": {"3.0.0", "3.1.0", "4.0.0-beta"},
+		}},
+		docs: map[string][]Doc{
+			"hashicorp/random/3.1.0": {
+				{Category: DocCategoryOverview, Slug: "index", Title: "Provider: Random", Language: docLanguage, Path: "docs/index.md", Content: "# Random"},
+				{Category: DocCategoryResources, Slug: "password", Title: "password", Language: docLanguage, Path: "docs/resources/password.md", Content: "# random_password"},
+			},
+		},
+	}
+	nop := func(e endpoint.Endpoint) endpoint.Endpoint { return e }
+	handler := MakeV2Handler(
+		NewService(storage, core.NewProxyUrlService(false, "/proxy")),
+		nop,
+		nopInstrumentation{},
+		core.NewCacheMiddleware(0, false),
+		httptransport.ServerErrorEncoder(ErrorEncoder),
+	)
+
+	request := func(target string, code int) map[string]any {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, code, rec.Code, target)
+
+		var doc map[string]any
+		if code == http.StatusOK {
+			assert.Equal(t, v2MediaType, rec.Header().Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+		}
+		return doc
+	}
+
+	doc := request("/providers/hashicorp/random?include=provider-versions", http.StatusOK)
+	data := doc["data"].(map[string]any)
+	assert.Equal(t, "hashicorp/random", data["attributes"].(map[string]any)["full-name"])
+	versions := data["relationships"].(map[string]any)["provider-versions"].(map[string]any)["data"].([]any)
+	if assert.Len(t, versions, 3) {
+		assert.Equal(t, "hashicorp:random:4.0.0-beta", versions[0].(map[string]any)["id"])
+	}
+	assert.Len(t, doc["included"], 3)
+	request("/providers/hashicorp/unknown", http.StatusNotFound)
+
+	// The latest version is the highest stable version
+	doc = request("/providers/hashicorp/random/provider-versions/latest?include=provider-docs", http.StatusOK)
+	data = doc["data"].(map[string]any)
+	assert.Equal(t, "hashicorp:random:3.1.0", data["id"])
+	assert.Equal(t, "v3.1.0", data["attributes"].(map[string]any)["tag"])
+	assert.Len(t, doc["included"], 2)
+
+	doc = request("/provider-versions/hashicorp:random:3.0.0", http.StatusOK)
+	assert.Empty(t, doc["data"].(map[string]any)["relationships"].(map[string]any)["provider-docs"].(map[string]any)["data"])
+	request("/provider-versions/hashicorp:random:5.0.0", http.StatusNotFound)
+	request("/provider-versions/random", http.StatusBadRequest)
+
+	doc = request("/provider-docs?filter[provider-version]=hashicorp:random:3.1.0&filter[category]=resources", http.StatusOK)
+	docs := doc["data"].([]any)
+	if assert.Len(t, docs, 1) {
+		attributes := docs[0].(map[string]any)["attributes"].(map[string]any)
+		assert.Equal(t, "password", attributes["slug"])
+		assert.NotContains(t, attributes, "content")
+	}
+	assert.Empty(t, request("/provider-docs?filter[provider-version]=hashicorp:random:3.0.0", http.StatusOK)["data"])
+	request("/provider-docs", http.StatusBadRequest)
+
+	doc = request("/provider-docs/hashicorp:random:3.1.0:resources:password", http.StatusOK)
+	assert.Equal(t, "# random_password", doc["data"].(map[string]any)["attributes"].(map[string]any)["content"])
+	request("/provider-docs/hashicorp:random:3.1.0:resources:unknown", http.StatusNotFound)
+}
+
+func TestLoadDocs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.md":              "---\npage_title: \"Provider: Random\"\n---\n# Random",
+		"resources/password.md": "---\npage_title: \"random_password Resource - terraform-provider-random\"\nsubcategory: \"Secrets\"\n---\n# random_password",
+		"guides/upgrading.md":   "---\npage_title: \"Upgrading\"\n---\n# Upgrading",
+		"resources/README.txt":  "ignored",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	docs, err := LoadDocs(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []Doc{
+		{Category: DocCategoryOverview, Slug: "index", Title: "Provider: Random", Language: docLanguage, Path: "docs/index.md", Content: files["index.md"]},
+		{Category: DocCategoryResources, Slug: "password", Title: "password", Subcategory: "Secrets", Language: docLanguage, Path: "docs/resources/password.md", Content: files["resources/password.md"]},
+		{Category: DocCategoryGuides, Slug: "upgrading", Title: "Upgrading", Language: docLanguage, Path: "docs/guides/upgrading.md", Content: files["guides/upgrading.md"]},
+	}, docs)
+
+	_, err = LoadDocs(t.TempDir())
+	assert.Error(t, err)
+}
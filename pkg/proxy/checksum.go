@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// moduleChecksumSuffix is the suffix of the file containing the hex-encoded SHA-256 checksum of a module archive
+	moduleChecksumSuffix = ".sha256"
+
+	checksumHeader = "X-Module-Checksum"
+)
+
+// expectedChecksum returns the checksum stored next to a module archive.
+// An empty checksum is returned if the path isn't a module archive or the checksum can't be fetched, e.g. for modules uploaded before checksums were stored.
+func expectedChecksum(ctx context.Context, storage Storage, client *http.Client, p string) string {
+	if !strings.HasPrefix(p, "modules/") && !strings.Contains(p, "/modules/") || strings.HasSuffix(p, moduleChecksumSuffix) {
+		return ""
+	}
+
+	checksumUrl, err := storage.GetDownloadUrl(ctx, p+moduleChecksumSuffix)
+	if err != nil {
+		return ""
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checksumUrl, nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	checksum, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(string(checksum)))
+}
+
+// verifyingReader computes the SHA-256 checksum of the proxied body and fails at the end of the body if it doesn't match.
+// The response is already partially written at that point, so the failed copy aborts the response and the client sees an incomplete body.
+type verifyingReader struct {
+	io.ReadCloser
+	hash       hash.Hash
+	expected   string
+	onMismatch func()
+}
+
+func newVerifyingReader(body io.ReadCloser, expected string, onMismatch func()) *verifyingReader {
+	return &verifyingReader{
+		ReadCloser: body,
+		hash:       sha256.New(),
+		expected:   expected,
+		onMismatch: onMismatch,
+	}
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if actual := hex.EncodeToString(r.hash.Sum(nil)); actual != r.expected {
+			r.onMismatch()
+			return n, fmt.Errorf("%w: expected %s but was %s", ErrChecksumMismatch, r.expected, actual)
+		}
+	}
+	return n, err
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
 
 	"github.com/go-kit/kit/endpoint"
@@ -15,7 +16,8 @@ import (
 )
 
 type proxyRequest struct {
-	url string
+	path     string
+	rawQuery string
 }
 
 type proxyResponse struct {
@@ -24,13 +26,22 @@ type proxyResponse struct {
 	Header     http.Header
 }
 
-func proxyEndpoint(storage Storage, metrics *o11y.ProxyMetrics) endpoint.Endpoint {
+// proxyEndpoint downloads the objects with the client, which is shared by all requests to reuse connections
+func proxyEndpoint(storage Storage, urls core.ProxyUrlService, client *http.Client, metrics *o11y.ProxyMetrics) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		input := request.(proxyRequest)
 
 		metrics.Download.With(prometheus.Labels{}).Inc()
 
-		downloadUrl, err := storage.GetDownloadUrl(ctx, input.url)
+		query, err := urls.VerifyProxyUrl(ctx, input.path, input.rawQuery)
+		if err != nil {
+			metrics.Failure.With(prometheus.Labels{
+				o11y.ProxyFailureLabel: o11y.ProxyFailureSignature,
+			}).Inc()
+			return nil, err
+		}
+
+		downloadUrl, err := storage.GetDownloadUrl(ctx, input.path+"?"+query)
 		if err != nil {
 			metrics.Failure.With(prometheus.Labels{
 				o11y.ProxyFailureLabel: o11y.ProxyFailureUrl,
@@ -48,7 +59,6 @@ func proxyEndpoint(storage Storage, metrics *o11y.ProxyMetrics) endpoint.Endpoin
 		}
 
 		// Send the HTTP request
-		client := &http.Client{}
 		resp, err := client.Do(req)
 		if err != nil {
 			metrics.Failure.With(prometheus.Labels{
@@ -58,8 +68,21 @@ func proxyEndpoint(storage Storage, metrics *o11y.ProxyMetrics) endpoint.Endpoin
 		}
 
 		headers := resp.Header.Clone()
+		body := resp.Body
 
 		if resp.StatusCode == 200 {
+			// Module archives are verified against the checksum recorded on upload while they are streamed to the client
+			if checksum := expectedChecksum(ctx, storage, client, input.path); checksum != "" {
+				headers.Set(checksumHeader, "sha256:"+checksum)
+				// The body is sent chunked, so that an aborted response can't be mistaken for a complete one
+				headers.Del("Content-Length")
+				body = newVerifyingReader(resp.Body, checksum, func() {
+					metrics.Failure.With(prometheus.Labels{
+						o11y.ProxyFailureLabel: o11y.ProxyFailureChecksum,
+					}).Inc()
+				})
+			}
+
 			// Add Content-Disposition header if not there
 			_, ok := headers["Content-Disposition"]
 			if !ok {
@@ -73,7 +96,7 @@ func proxyEndpoint(storage Storage, metrics *o11y.ProxyMetrics) endpoint.Endpoin
 		pResp := proxyResponse{
 			StatusCode: resp.StatusCode,
 			Header:     headers,
-			Body:       resp.Body,
+			Body:       body,
 		}
 
 		return pResp, nil
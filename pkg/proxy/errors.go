@@ -6,4 +6,5 @@ var (
 	// Proxy errors
 	ErrInvalidRequestUrl  = errors.New("failed to initiate remote URL request")
 	ErrCannotDownloadFile = errors.New("remote file failed to download")
+	ErrChecksumMismatch   = errors.New("checksum of the downloaded file does not match")
 )
@@ -22,13 +22,19 @@ const (
 )
 
 // MakeHandler returns a fully initialized http.Handler.
-func MakeHandler(storage Storage, metrics *o11y.ProxyMetrics, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+// The proxy URLs are verified with the ProxyUrlService before they are downloaded from the storage.
+// The client downloads the objects from the storage, http.DefaultClient is used if it's nil.
+func MakeHandler(storage Storage, urls core.ProxyUrlService, client *http.Client, metrics *o11y.ProxyMetrics, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
 	r := mux.NewRouter().StrictSlash(true)
 
+	if client == nil {
+		client = http.DefaultClient
+	}
+
 	r.Methods("GET").Path(`/{url:.*}`).Handler(
 		instrumentation.WrapHandler(
 			httptransport.NewServer(
-				proxyEndpoint(storage, metrics),
+				proxyEndpoint(storage, urls, client, metrics),
 				decodeProxyRequest,
 				copyHeadersAndBody,
 				append(
@@ -50,10 +56,9 @@ func decodeProxyRequest(ctx context.Context, r *http.Request) (interface{}, erro
 		return nil, fmt.Errorf("%w: url", core.ErrVarMissing)
 	}
 
-	completeUrl := downloadUrl + "?" + r.URL.RawQuery
-
 	return proxyRequest{
-		url: completeUrl,
+		path:     downloadUrl,
+		rawQuery: r.URL.RawQuery,
 	}, nil
 }
 
@@ -75,20 +80,24 @@ func copyHeadersAndBody(_ context.Context, w http.ResponseWriter, response inter
 	// Close the body reader
 	resp.Body.Close()
 
+	// The status code is already sent, the connection is closed to signal the client that the download failed
+	if errors.Is(err, ErrChecksumMismatch) {
+		panic(http.ErrAbortHandler)
+	}
+
 	return err
 }
 
 // ErrorEncoder translates domain specific errors to HTTP status codes
 func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	statusCode := core.GenericError(err)
 	if errors.Is(err, ErrInvalidRequestUrl) {
-		w.WriteHeader(http.StatusUnprocessableEntity)
+		statusCode = http.StatusUnprocessableEntity
 	} else if errors.Is(err, ErrCannotDownloadFile) {
-		w.WriteHeader(http.StatusBadGateway)
-	} else {
-		w.WriteHeader(core.GenericError(err))
+		statusCode = http.StatusBadGateway
 	}
 
-	core.HandleErrorResponse(err, w)
+	core.HandleErrorResponse(err, statusCode, w)
 }
 
 func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
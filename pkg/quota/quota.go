@@ -0,0 +1,196 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Size is a number of bytes, which can be written with a unit like 800MB or 1GiB in the policy file
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	// Longer suffixes come first, so that e.g. MiB doesn't match B
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// ParseSize parses a number of bytes with an optional unit, e.g. 1024, 800MB, or 1GiB
+func ParseSize(s string) (Size, error) {
+	s = strings.TrimSpace(s)
+	factor := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(strings.ToUpper(s), strings.ToUpper(unit.suffix)) {
+			s = strings.TrimSpace(s[:len(s)-len(unit.suffix)])
+			factor = unit.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return Size(n * factor), nil
+}
+
+// UnmarshalYAML accepts plain numbers of bytes and sizes with a unit
+func (s *Size) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// Quota limits the artifacts of a namespace, a limit of 0 is unlimited
+type Quota struct {
+	// MaxVersions is the maximum number of versions of a module or provider
+	MaxVersions int `yaml:"max_versions"`
+
+	// MaxTotalSize is the maximum number of bytes stored for all modules and providers of the namespace
+	MaxTotalSize Size `yaml:"max_total_size"`
+
+	// MaxArtifactSize is the maximum size of a single module or provider archive
+	MaxArtifactSize Size `yaml:"max_artifact_size"`
+
+	// SoftTotalSize is the number of bytes stored for the namespace above which a warning is reported, uploads aren't rejected
+	SoftTotalSize Size `yaml:"soft_total_size"`
+}
+
+// Policy holds the default quota and the quotas of individual namespaces
+type Policy struct {
+	Default    Quota            `yaml:"default"`
+	Namespaces map[string]Quota `yaml:"namespaces"`
+}
+
+// Quota returns the quota of the namespace, limits which aren't set for the namespace are inherited from the default
+func (p *Policy) Quota(namespace string) Quota {
+	if p == nil {
+		return Quota{}
+	}
+
+	q := p.Default
+	if ns, ok := p.Namespaces[namespace]; ok {
+		if ns.MaxVersions != 0 {
+			q.MaxVersions = ns.MaxVersions
+		}
+		if ns.MaxTotalSize != 0 {
+			q.MaxTotalSize = ns.MaxTotalSize
+		}
+		if ns.MaxArtifactSize != 0 {
+			q.MaxArtifactSize = ns.MaxArtifactSize
+		}
+		if ns.SoftTotalSize != 0 {
+			q.SoftTotalSize = ns.SoftTotalSize
+		}
+	}
+	return q
+}
+
+// CheckArtifactSize returns an ErrArtifactTooLarge error if the archive exceeds the maximum artifact size
+func (q Quota) CheckArtifactSize(namespace string, size int64) error {
+	if q.MaxArtifactSize > 0 && size > int64(q.MaxArtifactSize) {
+		return fmt.Errorf("%w: the archive has %d bytes, namespace %s allows at most %d bytes", core.ErrArtifactTooLarge, size, namespace, q.MaxArtifactSize)
+	}
+	return nil
+}
+
+// CheckVersions returns an ErrQuotaExceeded error if another version would exceed the maximum number of versions
+func (q Quota) CheckVersions(namespace string, versions int) error {
+	if q.MaxVersions > 0 && versions >= q.MaxVersions {
+		return fmt.Errorf("%w: namespace %s allows at most %d versions of a module or provider", core.ErrQuotaExceeded, namespace, q.MaxVersions)
+	}
+	return nil
+}
+
+// CheckTotalSize returns an ErrQuotaExceeded error if adding size bytes to the used bytes exceeds the maximum total size
+func (q Quota) CheckTotalSize(namespace string, used, size int64) error {
+	if q.MaxTotalSize > 0 && used+size > int64(q.MaxTotalSize) {
+		return fmt.Errorf("%w: namespace %s uses %d of %d bytes, the upload has %d bytes", core.ErrQuotaExceeded, namespace, used, q.MaxTotalSize, size)
+	}
+	return nil
+}
+
+// LimitReader returns a reader failing with an ErrArtifactTooLarge error once more than the maximum artifact size is read.
+// It's used for bodies whose size isn't known upfront.
+func (q Quota) LimitReader(namespace string, r io.Reader) io.Reader {
+	if q.MaxArtifactSize <= 0 {
+		return r
+	}
+	return &limitReader{r: r, remaining: int64(q.MaxArtifactSize), err: q.CheckArtifactSize(namespace, int64(q.MaxArtifactSize)+1)}
+}
+
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	// One more byte than allowed is read to tell an archive of exactly the maximum size from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return 0, l.err
+	}
+	return n, err
+}
+
+type policyFile struct {
+	Quotas *Policy `yaml:"quotas"`
+}
+
+// LoadPolicy reads the quotas from a YAML or JSON policy file
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	return ParsePolicy(b)
+}
+
+// ParsePolicy parses the quotas from YAML or JSON, nil is returned if no quotas are configured
+func ParsePolicy(b []byte) (*Policy, error) {
+	var f policyFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	if f.Quotas == nil {
+		return nil, nil
+	}
+
+	if f.Quotas.Default.MaxVersions < 0 {
+		return nil, errors.New("invalid default quota: max_versions must not be negative")
+	}
+	for namespace, q := range f.Quotas.Namespaces {
+		if q.MaxVersions < 0 {
+			return nil, fmt.Errorf("invalid quota of namespace %s: max_versions must not be negative", namespace)
+		}
+	}
+
+	return f.Quotas, nil
+}
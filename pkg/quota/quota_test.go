@@ -0,0 +1,108 @@
+package quota
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Size
+		wantErr bool
+	}{
+		{in: "1024", want: 1024},
+		{in: "800MB", want: 800 * 1000 * 1000},
+		{in: "1GiB", want: 1 << 30},
+		{in: "10 kib", want: 10 << 10},
+		{in: "5B", want: 5},
+		{in: "-1", wantErr: true},
+		{in: "MB", wantErr: true},
+		{in: "1.5GB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSize(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := ParsePolicy([]byte(`
+quotas:
+  default:
+    max_versions: 50
+    max_total_size: 10GiB
+    max_artifact_size: 100MB
+    soft_total_size: 8GiB
+  namespaces:
+    acme:
+      max_artifact_size: 1GiB
+      soft_total_size: 5GiB
+    sandbox:
+      max_versions: 5
+`))
+	assert.NoError(err)
+
+	assert.Equal(Quota{MaxVersions: 50, MaxTotalSize: 10 << 30, MaxArtifactSize: 1 << 30, SoftTotalSize: 5 << 30}, policy.Quota("acme"))
+	assert.Equal(Quota{MaxVersions: 5, MaxTotalSize: 10 << 30, MaxArtifactSize: 100 * 1000 * 1000, SoftTotalSize: 8 << 30}, policy.Quota("sandbox"))
+	assert.Equal(policy.Default, policy.Quota("other"))
+
+	policy, err = ParsePolicy([]byte(`{}`))
+	assert.NoError(err)
+	assert.Nil(policy)
+	assert.Equal(Quota{}, policy.Quota("acme"))
+
+	_, err = ParsePolicy([]byte(`{"quotas": {"namespaces": {"acme": {"max_versions": -1}}}}`))
+	assert.ErrorContains(err, "namespace acme")
+
+	_, err = ParsePolicy([]byte(`{"quotas": {"default": {"max_artifact_size": "lots"}}}`))
+	assert.Error(err)
+}
+
+func TestQuota_Checks(t *testing.T) {
+	assert := assert.New(t)
+	q := Quota{MaxVersions: 2, MaxTotalSize: 100, MaxArtifactSize: 10}
+
+	assert.NoError(q.CheckArtifactSize("acme", 10))
+	assert.ErrorIs(q.CheckArtifactSize("acme", 11), core.ErrArtifactTooLarge)
+
+	assert.NoError(q.CheckVersions("acme", 1))
+	assert.ErrorIs(q.CheckVersions("acme", 2), core.ErrQuotaExceeded)
+
+	assert.NoError(q.CheckTotalSize("acme", 90, 10))
+	assert.ErrorIs(q.CheckTotalSize("acme", 91, 10), core.ErrQuotaExceeded)
+
+	unlimited := Quota{}
+	assert.NoError(unlimited.CheckArtifactSize("acme", 1<<40))
+	assert.NoError(unlimited.CheckVersions("acme", 1000))
+	assert.NoError(unlimited.CheckTotalSize("acme", 1<<40, 1<<40))
+}
+
+func TestQuota_LimitReader(t *testing.T) {
+	assert := assert.New(t)
+	q := Quota{MaxArtifactSize: 10}
+
+	data, err := io.ReadAll(q.LimitReader("acme", strings.NewReader("0123456789")))
+	assert.NoError(err)
+	assert.Equal("0123456789", string(data))
+
+	_, err = io.ReadAll(q.LimitReader("acme", strings.NewReader("0123456789a")))
+	assert.ErrorIs(err, core.ErrArtifactTooLarge)
+
+	r := strings.NewReader("data")
+	assert.Same(r, Quota{}.LimitReader("acme", r))
+}
@@ -0,0 +1,105 @@
+package redirect
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/stats"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type moduleRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+	checksum  string // optional, the redirect is rejected if the archive doesn't match the expected checksum
+	path      string
+	query     url.Values
+}
+
+type providerRequest struct {
+	namespace string
+	name      string
+	version   string
+	os        string
+	arch      string
+	path      string
+	query     url.Values
+}
+
+type redirectResponse struct {
+	location string
+}
+
+func moduleEndpoint(redirector core.DownloadRedirector, storage Storage, recorder stats.Recorder, metrics *o11y.RedirectMetrics) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(moduleRequest)
+
+		if err := redirector.Verify(ctx, req.path, req.query); err != nil {
+			metrics.Rejected.With(prometheus.Labels{o11y.TypeLabel: o11y.TypeModule}).Inc()
+			return nil, err
+		}
+
+		if req.checksum != "" {
+			checksum, err := storage.GetModuleChecksum(ctx, req.namespace, req.name, req.provider, req.version)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := module.VerifyChecksum(req.checksum, checksum); err != nil {
+				metrics.Rejected.With(prometheus.Labels{o11y.TypeLabel: o11y.TypeModule}).Inc()
+				return nil, err
+			}
+		}
+
+		res, err := storage.GetModule(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics.Downloads.With(prometheus.Labels{o11y.TypeLabel: o11y.TypeModule}).Inc()
+		if recorder != nil {
+			recorder.Record(stats.ModuleArtifact(req.namespace, req.name, req.provider), req.version)
+		}
+
+		return redirectResponse{location: res.DownloadURL}, nil
+	}
+}
+
+func providerEndpoint(redirector core.DownloadRedirector, storage Storage, recorder stats.Recorder, metrics *o11y.RedirectMetrics) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(providerRequest)
+
+		if err := redirector.Verify(ctx, req.path, req.query); err != nil {
+			metrics.Rejected.With(prometheus.Labels{o11y.TypeLabel: o11y.TypeProvider}).Inc()
+			return nil, err
+		}
+
+		res, err := storage.GetProvider(ctx, req.namespace, req.name, req.version, req.os, req.arch)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics.Downloads.With(prometheus.Labels{o11y.TypeLabel: o11y.TypeProvider}).Inc()
+		if recorder != nil {
+			recorder.Record(stats.ProviderArtifact(req.namespace, req.name), req.version)
+		}
+
+		return redirectResponse{location: res.DownloadURL}, nil
+	}
+}
+
+// encodeRedirectResponse redirects the client to the presigned URL of the storage backend
+func encodeRedirectResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(redirectResponse)
+	w.Header().Set("Location", res.location)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
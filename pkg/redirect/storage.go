@@ -0,0 +1,19 @@
+package redirect
+
+import (
+	"context"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Storage represents the Storage of Terraform providers and modules.
+type Storage interface {
+	// GetModule retrieves information about a module from the storage, including a presigned download URL
+	GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error)
+
+	// GetModuleChecksum returns the hex-encoded SHA-256 checksum of the module archive
+	GetModuleChecksum(ctx context.Context, namespace, name, provider, version string) (string, error)
+
+	// GetProvider retrieves information about a provider from the storage, including a presigned download URL
+	GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error)
+}
@@ -0,0 +1,159 @@
+package redirect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/stats"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type muxVar string
+
+const (
+	varNamespace muxVar = "namespace"
+	varName      muxVar = "name"
+	varProvider  muxVar = "provider"
+	varVersion   muxVar = "version"
+	varOS        muxVar = "os"
+	varArch      muxVar = "arch"
+	varArchive   muxVar = "archive"
+)
+
+// MakeHandler returns a fully initialized http.Handler.
+// The endpoints are not protected by authentication, as the signature of the URL authorizes the download.
+func MakeHandler(redirector core.DownloadRedirector, storage Storage, recorder stats.Recorder, metrics *o11y.RedirectMetrics, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/modules/{namespace}/{name}/{provider}/{version}/{archive}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				moduleEndpoint(redirector, storage, recorder, metrics),
+				decodeModuleRequest,
+				encodeRedirectResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/providers/{namespace}/{name}/{version}/{os}/{arch}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				providerEndpoint(redirector, storage, recorder, metrics),
+				decodeProviderRequest,
+				encodeRedirectResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion, varOS, varArch)),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+func decodeModuleRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	namespace, ok := ctx.Value(varNamespace).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: namespace", core.ErrVarMissing)
+	}
+
+	name, ok := ctx.Value(varName).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: name", core.ErrVarMissing)
+	}
+
+	provider, ok := ctx.Value(varProvider).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: provider", core.ErrVarMissing)
+	}
+
+	version, ok := ctx.Value(varVersion).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
+	}
+
+	return moduleRequest{
+		namespace: namespace,
+		name:      name,
+		provider:  provider,
+		version:   version,
+		checksum:  module.ExpectedChecksum(r),
+		path:      r.URL.Path,
+		query:     r.URL.Query(),
+	}, nil
+}
+
+func decodeProviderRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	namespace, ok := ctx.Value(varNamespace).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: namespace", core.ErrVarMissing)
+	}
+
+	name, ok := ctx.Value(varName).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: name", core.ErrVarMissing)
+	}
+
+	version, ok := ctx.Value(varVersion).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
+	}
+
+	os, ok := ctx.Value(varOS).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: os", core.ErrVarMissing)
+	}
+
+	arch, ok := ctx.Value(varArch).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: arch", core.ErrVarMissing)
+	}
+
+	return providerRequest{
+		namespace: namespace,
+		name:      name,
+		version:   version,
+		os:        os,
+		arch:      arch,
+		path:      r.URL.Path,
+		query:     r.URL.Query(),
+	}, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	statusCode := core.GenericError(err)
+	if errors.Is(err, module.ErrModuleNotFound) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, module.ErrInvalidChecksum) {
+		statusCode = http.StatusBadRequest
+	} else if errors.Is(err, module.ErrChecksumMismatch) {
+		statusCode = http.StatusPreconditionFailed
+	}
+
+	core.HandleErrorResponse(err, statusCode, w)
+}
+
+func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, k := range keys {
+			if v, ok := mux.Vars(r)[string(k)]; ok {
+				ctx = context.WithValue(ctx, k, v)
+			}
+		}
+
+		return ctx
+	}
+}
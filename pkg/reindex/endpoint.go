@@ -0,0 +1,24 @@
+package reindex
+
+import (
+	"context"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type reindexResponse struct {
+	storage.ReindexResult
+}
+
+func reindexEndpoint(r storage.Reindexer) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		res, err := r.Reindex(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return reindexResponse{res}, nil
+	}
+}
@@ -0,0 +1,110 @@
+package resumable
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type createRequest struct {
+	namespace string
+	name      string
+	Filename  string `json:"filename"`
+	Length    int64  `json:"length"`
+}
+
+type sessionRequest struct {
+	namespace string
+	name      string
+	id        string
+}
+
+type appendRequest struct {
+	sessionRequest
+	offset int64
+	body   io.Reader
+}
+
+type sessionResponse struct {
+	Session
+	created bool
+}
+
+// StatusCode is 201 for created sessions
+func (r sessionResponse) StatusCode() int {
+	if r.created {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+// Headers returns the offset in the Upload-Offset header, so that clients can resume with a HEAD request
+func (r sessionResponse) Headers() http.Header {
+	return http.Header{
+		headerUploadOffset: {strconv.FormatInt(r.Offset, 10)},
+		headerUploadLength: {strconv.FormatInt(r.Length, 10)},
+	}
+}
+
+type abortResponse struct{}
+
+func (abortResponse) StatusCode() int {
+	return http.StatusNoContent
+}
+
+func createEndpoint(m *Manager) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createRequest)
+		s, err := m.Create(ctx, req.namespace, req.name, req.Filename, req.Length)
+		if err != nil {
+			return nil, err
+		}
+		return sessionResponse{Session: s, created: true}, nil
+	}
+}
+
+func getEndpoint(m *Manager) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(sessionRequest)
+		s, err := m.Get(req.namespace, req.name, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return sessionResponse{Session: s}, nil
+	}
+}
+
+func appendEndpoint(m *Manager) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(appendRequest)
+		s, err := m.Append(req.namespace, req.name, req.id, req.offset, req.body)
+		if err != nil {
+			return nil, err
+		}
+		return sessionResponse{Session: s}, nil
+	}
+}
+
+func completeEndpoint(m *Manager) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sessionRequest)
+		s, err := m.Complete(ctx, req.namespace, req.name, req.id)
+		if err != nil {
+			return nil, err
+		}
+		return sessionResponse{Session: s}, nil
+	}
+}
+
+func abortEndpoint(m *Manager) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(sessionRequest)
+		if err := m.Abort(req.namespace, req.name, req.id); err != nil {
+			return nil, err
+		}
+		return abortResponse{}, nil
+	}
+}
@@ -0,0 +1,10 @@
+package resumable
+
+import "errors"
+
+var (
+	ErrSessionNotFound  = errors.New("failed to locate upload session")
+	ErrOffsetMismatch   = errors.New("upload offset doesn't match the received bytes")
+	ErrUploadIncomplete = errors.New("upload is incomplete")
+	ErrInvalidUpload    = errors.New("invalid upload")
+)
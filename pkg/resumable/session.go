@@ -0,0 +1,325 @@
+package resumable
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+)
+
+// DefaultSessionTTL is the default duration after which an upload session without progress is discarded
+const DefaultSessionTTL = 24 * time.Hour
+
+// Storage stores the completed files of provider releases
+type Storage interface {
+	UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error
+}
+
+// SizeChecker is implemented by storages enforcing quotas, so that files exceeding them are rejected before they're uploaded
+type SizeChecker interface {
+	CheckUploadSize(ctx context.Context, namespace string, size int64) error
+}
+
+// Session is a resumable upload of a single file of a provider release
+type Session struct {
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Filename  string    `json:"filename"`
+	Length    int64     `json:"length"`
+	Offset    int64     `json:"offset"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type session struct {
+	Session
+	file string
+
+	// busy is set while bytes are appended or the session is completed, so that concurrent requests don't interleave
+	busy bool
+}
+
+// Manager keeps the received bytes of the upload sessions in files of a local directory.
+// The sessions are local to the server and don't survive restarts,
+// so all requests of an upload have to reach the same replica, e.g. with sticky sessions.
+type Manager struct {
+	storage   Storage
+	dir       string
+	ttl       time.Duration
+	maxLength int64
+	now       func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// ManagerOption provides additional options for the Manager
+type ManagerOption func(*Manager)
+
+// WithMaxLength rejects uploads of files larger than max bytes, unlimited if 0
+func WithMaxLength(max int64) ManagerOption {
+	return func(m *Manager) {
+		m.maxLength = max
+	}
+}
+
+// NewManager returns a Manager spooling the uploads to the directory, which is created if it doesn't exist.
+// Sessions without progress for the ttl are discarded until the context is cancelled.
+func NewManager(ctx context.Context, storage Storage, dir string, ttl time.Duration, options ...ManagerOption) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the upload directory: %w", err)
+	}
+
+	m := &Manager{
+		storage:  storage,
+		dir:      dir,
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: make(map[string]*session),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	go m.run(ctx)
+
+	return m, nil
+}
+
+// run discards the expired sessions every minute
+func (m *Manager) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.expire()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) expire() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for id, s := range m.sessions {
+		if !s.busy && now.After(s.ExpiresAt) {
+			slog.Info("discarding expired upload session", slog.String("id", id), slog.String("filename", s.Filename))
+			m.removeLocked(id)
+		}
+	}
+}
+
+// Create starts the upload of a file of length bytes, which has to belong to the provider.
+// Files exceeding the maximum length or the quotas of the storage are rejected before any bytes are received.
+func (m *Manager) Create(ctx context.Context, namespace, name, filename string, length int64) (Session, error) {
+	if namespace == "" || name == "" {
+		return Session{}, fmt.Errorf("%w: namespace and name are required", ErrInvalidUpload)
+	}
+	if path.Base(filename) != filename || !strings.HasPrefix(filename, fmt.Sprintf("%s%s_", core.ProviderPrefix, name)) {
+		return Session{}, fmt.Errorf("%w: %q isn't a file of provider %s", ErrInvalidUpload, filename, name)
+	}
+	if length < 0 {
+		return Session{}, fmt.Errorf("%w: the length must not be negative", ErrInvalidUpload)
+	}
+	if m.maxLength > 0 && length > m.maxLength {
+		return Session{}, fmt.Errorf("%w: the file of %d bytes exceeds the limit of %d bytes", core.ErrArtifactTooLarge, length, m.maxLength)
+	}
+	if checker, ok := m.storage.(SizeChecker); ok {
+		if err := checker.CheckUploadSize(ctx, namespace, length); err != nil {
+			return Session{}, err
+		}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Session{}, err
+	}
+	id := hex.EncodeToString(b)
+
+	file := filepath.Join(m.dir, id)
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to create the upload file: %w", err)
+	}
+	_ = f.Close()
+
+	s := &session{
+		Session: Session{
+			ID:        id,
+			Namespace: namespace,
+			Name:      name,
+			Filename:  filename,
+			Length:    length,
+			ExpiresAt: m.now().Add(m.ttl),
+		},
+		file: file,
+	}
+
+	m.mu.Lock()
+	m.sessions[id] = s
+	m.mu.Unlock()
+
+	return s.Session, nil
+}
+
+// Get returns the session of the provider with the id, whose offset is the number of bytes received so far
+func (m *Manager) Get(namespace, name, id string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.lookup(namespace, name, id)
+	if err != nil {
+		return Session{}, err
+	}
+	return s.Session, nil
+}
+
+// lookup returns the session with the id, which has to belong to the provider. The mutex has to be held.
+func (m *Manager) lookup(namespace, name, id string) (*session, error) {
+	s, ok := m.sessions[id]
+	if !ok || s.Namespace != namespace || s.Name != name {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return s, nil
+}
+
+// acquire marks the session as busy, the returned function releases it
+func (m *Manager) acquire(namespace, name, id string) (*session, func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.lookup(namespace, name, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.busy {
+		return nil, nil, fmt.Errorf("%w: another request is in progress", core.ErrTooManyRequests)
+	}
+	s.busy = true
+
+	return s, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		s.busy = false
+	}, nil
+}
+
+// Append writes the body at the offset, which has to match the number of bytes received so far.
+// The bytes which were received before the body failed are kept, so that the client can resume from the offset of the session.
+func (m *Manager) Append(namespace, name, id string, offset int64, body io.Reader) (Session, error) {
+	s, release, err := m.acquire(namespace, name, id)
+	if err != nil {
+		return Session{}, err
+	}
+	defer release()
+
+	if offset != s.Offset {
+		return s.Session, fmt.Errorf("%w: expected offset %d but got %d", ErrOffsetMismatch, s.Offset, offset)
+	}
+
+	f, err := os.OpenFile(s.file, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return s.Session, err
+	}
+	defer f.Close()
+
+	// One more byte than remaining is read to tell a body of exactly the remaining length from a longer one
+	remaining := s.Length - s.Offset
+	n, copyErr := io.Copy(f, io.LimitReader(body, remaining+1))
+	if n > remaining {
+		// The surplus byte is discarded, the remaining bytes are kept as they may be the valid end of the file
+		if err := f.Truncate(s.Length); err != nil {
+			return s.Session, err
+		}
+		n = remaining
+		copyErr = fmt.Errorf("%w: the file has %d bytes", ErrInvalidUpload, s.Length)
+	}
+
+	m.mu.Lock()
+	s.Offset += n
+	s.ExpiresAt = m.now().Add(m.ttl)
+	res := s.Session
+	m.mu.Unlock()
+
+	return res, copyErr
+}
+
+// Complete stores the file in the storage once all bytes have been received, and discards the session
+func (m *Manager) Complete(ctx context.Context, namespace, name, id string) (Session, error) {
+	s, release, err := m.acquire(namespace, name, id)
+	if err != nil {
+		return Session{}, err
+	}
+	defer release()
+
+	if s.Offset != s.Length {
+		return s.Session, fmt.Errorf("%w: received %d of %d bytes", ErrUploadIncomplete, s.Offset, s.Length)
+	}
+
+	f, err := os.Open(s.file)
+	if err != nil {
+		return s.Session, err
+	}
+	defer f.Close()
+
+	if err := m.storage.UploadProviderReleaseFiles(ctx, namespace, name, s.Filename, f); err != nil {
+		return s.Session, err
+	}
+
+	o11y.Audit(ctx, "provider.upload",
+		slog.String("provider", path.Join(namespace, name)),
+		slog.String("filename", s.Filename),
+		slog.Int64("bytes", s.Length),
+	)
+
+	m.remove(id)
+	return s.Session, nil
+}
+
+// Abort discards the session and the bytes received so far
+func (m *Manager) Abort(namespace, name, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.lookup(namespace, name, id)
+	if err != nil {
+		return err
+	}
+	if s.busy {
+		return fmt.Errorf("%w: another request is in progress", core.ErrTooManyRequests)
+	}
+
+	m.removeLocked(id)
+	return nil
+}
+
+func (m *Manager) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(id)
+}
+
+// removeLocked discards the session and its file. The mutex has to be held.
+func (m *Manager) removeLocked(id string) {
+	if s, ok := m.sessions[id]; ok {
+		if err := os.Remove(s.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to remove upload file", slog.String("file", s.file), slog.String("err", err.Error()))
+		}
+		delete(m.sessions, id)
+	}
+}
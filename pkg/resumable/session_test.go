@@ -0,0 +1,128 @@
+package resumable
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStorage struct {
+	files map[string]string
+}
+
+func (m *mockStorage) UploadProviderReleaseFiles(_ context.Context, namespace, name, filename string, file io.Reader) error {
+	b, err := io.ReadAll(file)
+	m.files[namespace+"/"+name+"/"+filename] = string(b)
+	return err
+}
+
+// failingReader returns the content and then fails like a dropped connection
+type failingReader struct {
+	r io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func TestManager(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := &mockStorage{files: map[string]string{}}
+	m, err := NewManager(ctx, storage, t.TempDir(), time.Hour)
+	assert.NoError(t, err)
+
+	for _, filename := range []string{"../terraform-provider-random_2.0.0_linux_amd64.zip", "terraform-provider-other_2.0.0_linux_amd64.zip"} {
+		_, err = m.Create(ctx, "acme", "random", filename, 10)
+		assert.ErrorIs(t, err, ErrInvalidUpload)
+	}
+
+	s, err := m.Create(ctx, "acme", "random", "terraform-provider-random_2.0.0_linux_amd64.zip", 10)
+	assert.NoError(t, err)
+
+	_, err = m.Get("example", "random", s.ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	// The bytes received before the connection dropped are kept
+	s, err = m.Append("acme", "random", s.ID, 0, &failingReader{strings.NewReader("0123")})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, int64(4), s.Offset)
+
+	_, err = m.Complete(ctx, "acme", "random", s.ID)
+	assert.ErrorIs(t, err, ErrUploadIncomplete)
+
+	_, err = m.Append("acme", "random", s.ID, 0, strings.NewReader("0123456789"))
+	assert.ErrorIs(t, err, ErrOffsetMismatch)
+
+	s, err = m.Append("acme", "random", s.ID, 4, strings.NewReader("456789!"))
+	assert.ErrorIs(t, err, ErrInvalidUpload)
+	assert.Equal(t, int64(10), s.Offset)
+
+	_, err = m.Complete(ctx, "acme", "random", s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", storage.files["acme/random/terraform-provider-random_2.0.0_linux_amd64.zip"])
+
+	_, err = m.Get("acme", "random", s.ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestManager_Expire(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m, err := NewManager(ctx, &mockStorage{}, t.TempDir(), time.Hour)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	s, err := m.Create(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS", 10)
+	assert.NoError(t, err)
+
+	now = now.Add(30 * time.Minute)
+	m.expire()
+	_, err = m.Get("acme", "random", s.ID)
+	assert.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	m.expire()
+	_, err = m.Get("acme", "random", s.ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.ErrorIs(t, m.Abort("acme", "random", s.ID), ErrSessionNotFound)
+}
+
+type quotaStorage struct {
+	mockStorage
+	maxSize int64
+}
+
+func (q *quotaStorage) CheckUploadSize(_ context.Context, namespace string, size int64) error {
+	if size > q.maxSize {
+		return fmt.Errorf("%w: namespace %s", core.ErrArtifactTooLarge, namespace)
+	}
+	return nil
+}
+
+func TestManager_Create_TooLarge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m, err := NewManager(ctx, &quotaStorage{maxSize: 100}, t.TempDir(), time.Hour, WithMaxLength(1000))
+	assert.NoError(t, err)
+
+	// Files exceeding the maximum length or the quotas are rejected upfront
+	for _, length := range []int64{1001, 101} {
+		_, err = m.Create(ctx, "acme", "random", "terraform-provider-random_2.0.0_linux_amd64.zip", length)
+		assert.ErrorIs(t, err, core.ErrArtifactTooLarge)
+	}
+	_, err = m.Create(ctx, "acme", "random", "terraform-provider-random_2.0.0_linux_amd64.zip", 100)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,141 @@
+package resumable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type muxVar string
+
+const (
+	varNamespace muxVar = "namespace"
+	varName      muxVar = "name"
+	varID        muxVar = "id"
+
+	headerUploadOffset = "Upload-Offset"
+	headerUploadLength = "Upload-Length"
+)
+
+// MakeHandler returns a fully initialized http.Handler for the resumable upload API.
+func MakeHandler(m *Manager, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	handle := func(e endpoint.Endpoint, dec httptransport.DecodeRequestFunc, keys ...muxVar) http.Handler {
+		return instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(e),
+				dec,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(keys...)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		)
+	}
+
+	r.Methods("POST").Path(`/uploads/providers/{namespace}/{name}`).Handler(
+		handle(createEndpoint(m), decodeCreateRequest, varNamespace, varName),
+	)
+	r.Methods("GET", "HEAD").Path(`/uploads/providers/{namespace}/{name}/{id}`).Handler(
+		handle(getEndpoint(m), decodeSessionRequest, varNamespace, varName, varID),
+	)
+	r.Methods("PATCH").Path(`/uploads/providers/{namespace}/{name}/{id}`).Handler(
+		handle(appendEndpoint(m), decodeAppendRequest, varNamespace, varName, varID),
+	)
+	r.Methods("POST").Path(`/uploads/providers/{namespace}/{name}/{id}/complete`).Handler(
+		handle(completeEndpoint(m), decodeSessionRequest, varNamespace, varName, varID),
+	)
+	r.Methods("DELETE").Path(`/uploads/providers/{namespace}/{name}/{id}`).Handler(
+		handle(abortEndpoint(m), decodeSessionRequest, varNamespace, varName, varID),
+	)
+
+	return r
+}
+
+func decodeCreateRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidUpload, err)
+	}
+
+	var ok bool
+	if req.namespace, ok = ctx.Value(varNamespace).(string); !ok {
+		return nil, fmt.Errorf("%w: namespace", core.ErrVarMissing)
+	}
+	if req.name, ok = ctx.Value(varName).(string); !ok {
+		return nil, fmt.Errorf("%w: name", core.ErrVarMissing)
+	}
+	return req, nil
+}
+
+func decodeSessionRequest(ctx context.Context, _ *http.Request) (interface{}, error) {
+	namespace, ok := ctx.Value(varNamespace).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: namespace", core.ErrVarMissing)
+	}
+	name, ok := ctx.Value(varName).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: name", core.ErrVarMissing)
+	}
+	id, ok := ctx.Value(varID).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: id", core.ErrVarMissing)
+	}
+
+	return sessionRequest{namespace: namespace, name: name, id: id}, nil
+}
+
+// decodeAppendRequest passes the body on without reading it, so that the bytes are written to the session as they are received
+func decodeAppendRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeSessionRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get(headerUploadOffset), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the %s header is required", ErrInvalidUpload, headerUploadOffset)
+	}
+
+	return appendRequest{sessionRequest: req.(sessionRequest), offset: offset, body: r.Body}, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	statusCode := core.GenericError(err)
+	if errors.Is(err, ErrSessionNotFound) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, ErrOffsetMismatch) || errors.Is(err, ErrUploadIncomplete) {
+		statusCode = http.StatusConflict
+	} else if errors.Is(err, ErrInvalidUpload) {
+		statusCode = http.StatusBadRequest
+	}
+
+	core.HandleErrorResponse(err, statusCode, w)
+}
+
+func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, k := range keys {
+			if v, ok := mux.Vars(r)[string(k)]; ok {
+				ctx = context.WithValue(ctx, k, v)
+			}
+		}
+
+		return ctx
+	}
+}
@@ -0,0 +1,82 @@
+// Package sbom validates software bills of materials in the SPDX and CycloneDX JSON formats,
+// which are attached to module and provider versions.
+package sbom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Suffix is the suffix of the SBOM stored next to a module archive or the SHA256SUMS file of a provider version
+	Suffix = ".sbom.json"
+
+	FormatSPDX      = "spdx"
+	FormatCycloneDX = "cyclonedx"
+)
+
+var (
+	// ErrInvalidSBOM is returned if a document is neither an SPDX nor a CycloneDX SBOM in JSON
+	ErrInvalidSBOM = errors.New("invalid SBOM")
+
+	// ErrSBOMMissing is returned if a version is published without SBOM in a namespace requiring one
+	ErrSBOMMissing = errors.New("SBOM is missing")
+)
+
+// mediaTypes are the media types the SBOMs are served with
+var mediaTypes = map[string]string{
+	FormatSPDX:      "application/spdx+json",
+	FormatCycloneDX: "application/vnd.cyclonedx+json",
+}
+
+// Detect returns the format of the SBOM, an ErrInvalidSBOM error is returned for other documents
+func Detect(data []byte) (string, error) {
+	var document struct {
+		SPDXVersion string `json:"spdxVersion"`
+		BOMFormat   string `json:"bomFormat"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSBOM, err)
+	}
+
+	switch {
+	case strings.HasPrefix(document.SPDXVersion, "SPDX-"):
+		return FormatSPDX, nil
+	case document.BOMFormat == "CycloneDX":
+		return FormatCycloneDX, nil
+	}
+	return "", fmt.Errorf("%w: the document has neither an spdxVersion nor a CycloneDX bomFormat", ErrInvalidSBOM)
+}
+
+// MediaType returns the media type of the SBOM format
+func MediaType(format string) string {
+	if mediaType, ok := mediaTypes[format]; ok {
+		return mediaType
+	}
+	return "application/json"
+}
+
+// Policy decides in which namespaces modules and providers can only be published with an SBOM
+type Policy struct {
+	all        bool
+	namespaces map[string]bool
+}
+
+// NewPolicy returns a policy requiring SBOMs in the namespaces, * requires SBOMs in all namespaces
+func NewPolicy(namespaces []string) Policy {
+	p := Policy{namespaces: make(map[string]bool, len(namespaces))}
+	for _, namespace := range namespaces {
+		if namespace == "*" {
+			p.all = true
+		}
+		p.namespaces[namespace] = true
+	}
+	return p
+}
+
+// Required returns true if versions of the namespace have to be published with an SBOM
+func (p Policy) Required(namespace string) bool {
+	return p.all || p.namespaces[namespace]
+}
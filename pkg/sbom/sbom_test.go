@@ -0,0 +1,66 @@
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "SPDX",
+			document: `{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT"}`,
+			want:     FormatSPDX,
+		},
+		{
+			name:     "CycloneDX",
+			document: `{"bomFormat": "CycloneDX", "specVersion": "1.5"}`,
+			want:     FormatCycloneDX,
+		},
+		{
+			name:     "other JSON document",
+			document: `{"name": "vpc"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "SPDX tag-value document",
+			document: "SPDXVersion: SPDX-2.3",
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := Detect([]byte(tc.document))
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSBOM)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, format)
+		})
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	assert.Equal(t, "application/spdx+json", MediaType(FormatSPDX))
+	assert.Equal(t, "application/vnd.cyclonedx+json", MediaType(FormatCycloneDX))
+	assert.Equal(t, "application/json", MediaType(""))
+}
+
+func TestPolicy_Required(t *testing.T) {
+	p := NewPolicy([]string{"acme"})
+	assert.True(t, p.Required("acme"))
+	assert.False(t, p.Required("hashicorp"))
+
+	p = NewPolicy([]string{"*"})
+	assert.True(t, p.Required("hashicorp"))
+
+	p = NewPolicy(nil)
+	assert.False(t, p.Required("acme"))
+}
@@ -0,0 +1,143 @@
+// Package scan runs uploaded artifacts through an external scanner, e.g. ClamAV or an internal malware scanner,
+// which is either invoked as a command or called over HTTP.
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ResultSuffix is the suffix of the scan result stored next to a scanned artifact
+const ResultSuffix = ".scan.json"
+
+// ErrScanFailed is returned if the scanner couldn't be run or its response couldn't be understood
+var ErrScanFailed = errors.New("scan failed")
+
+// Result is the outcome of a scan, which is stored as metadata of the artifact
+type Result struct {
+	// Clean is true if the scanner didn't find anything
+	Clean bool `json:"clean"`
+
+	// Scanner identifies the command or URL the artifact was scanned with
+	Scanner string `json:"scanner"`
+
+	// Details is the output of the scanner, e.g. the names of the detected signatures
+	Details string `json:"details,omitempty"`
+
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// Scanner scans an artifact, an error is only returned if the scan itself failed
+type Scanner interface {
+	Scan(ctx context.Context, filename string, r io.Reader) (*Result, error)
+}
+
+// New returns the scanner of the hook, which is either an http:// or https:// URL of a scanning endpoint,
+// or a command prefixed with exec:, e.g. "exec:clamdscan --no-summary -"
+func New(hook string, timeout time.Duration) (Scanner, error) {
+	if command, ok := strings.CutPrefix(hook, "exec:"); ok {
+		// Arguments are separated by whitespace, there's no support for quoting
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("invalid scan command %q", command)
+		}
+		return &ExecScanner{Args: args, Timeout: timeout}, nil
+	}
+
+	u, err := url.Parse(hook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("scan hook %q must be an http(s) URL or a command prefixed with exec:", hook)
+	}
+	return &HTTPScanner{URL: u.String(), Client: &http.Client{Timeout: timeout}}, nil
+}
+
+// ExecScanner pipes the artifact into a command following the exit codes of clamscan:
+// 0 if the artifact is clean, 1 if something was found, and any other code if the scan failed
+type ExecScanner struct {
+	Args    []string
+	Timeout time.Duration
+}
+
+func (s *ExecScanner) Scan(ctx context.Context, filename string, r io.Reader) (*Result, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Args[0], s.Args[1:]...)
+	cmd.Stdin = r
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// The file name lets scanners reading from stdin report which artifact they scanned
+	cmd.Env = append(os.Environ(), "BORING_REGISTRY_SCAN_FILENAME="+filename)
+
+	result := &Result{
+		Scanner:   s.Args[0],
+		ScannedAt: time.Now().UTC(),
+	}
+	err := cmd.Run()
+	result.Details = strings.TrimSpace(output.String())
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.Clean = true
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		result.Clean = false
+	default:
+		return nil, fmt.Errorf("%w: %s: %w: %s", ErrScanFailed, s.Args[0], err, result.Details)
+	}
+	return result, nil
+}
+
+// HTTPScanner posts the artifact to an endpoint, which responds with a JSON object like {"clean": false, "details": "Eicar-Signature"}
+type HTTPScanner struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *HTTPScanner) Scan(ctx context.Context, filename string, r io.Reader) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Filename", filename)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s responded with %s", ErrScanFailed, s.URL, resp.Status)
+	}
+
+	var response struct {
+		Clean   *bool  `json:"clean"`
+		Details string `json:"details"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil || response.Clean == nil {
+		return nil, fmt.Errorf("%w: invalid response of %s", ErrScanFailed, s.URL)
+	}
+
+	return &Result{
+		Clean:     *response.Clean,
+		Scanner:   s.URL,
+		Details:   response.Details,
+		ScannedAt: time.Now().UTC(),
+	}, nil
+}
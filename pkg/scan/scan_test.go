@@ -0,0 +1,84 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New("exec:clamdscan --no-summary -", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, &ExecScanner{Args: []string{"clamdscan", "--no-summary", "-"}, Timeout: time.Minute}, s)
+
+	s, err = New("https://scanner.example.com/scan", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://scanner.example.com/scan", s.(*HTTPScanner).URL)
+
+	for _, hook := range []string{"exec:", "clamdscan", "ftp://scanner.example.com"} {
+		_, err = New(hook, time.Minute)
+		assert.Error(t, err, hook)
+	}
+}
+
+func TestExecScanner(t *testing.T) {
+	// The script reports the file name and fails with the exit code read from the artifact
+	script := filepath.Join(t.TempDir(), "scan.sh")
+	assert.NoError(t, os.WriteFile(script, []byte("read code\necho \"$BORING_REGISTRY_SCAN_FILENAME: $code\"\nexit $code\n"), 0o600))
+	s := &ExecScanner{Args: []string{"sh", script}}
+
+	result, err := s.Scan(context.Background(), "provider.zip", strings.NewReader("0\n"))
+	assert.NoError(t, err)
+	assert.True(t, result.Clean)
+	assert.Equal(t, "provider.zip: 0", result.Details)
+	assert.Equal(t, "sh", result.Scanner)
+
+	result, err = s.Scan(context.Background(), "provider.zip", strings.NewReader("1\n"))
+	assert.NoError(t, err)
+	assert.False(t, result.Clean)
+
+	_, err = s.Scan(context.Background(), "provider.zip", strings.NewReader("2\n"))
+	assert.ErrorIs(t, err, ErrScanFailed)
+	assert.ErrorContains(t, err, "provider.zip: 2")
+}
+
+func TestHTTPScanner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch string(body) {
+		case "clean":
+			_ = json.NewEncoder(w).Encode(map[string]any{"clean": true})
+		case "infected":
+			_ = json.NewEncoder(w).Encode(map[string]any{"clean": false, "details": r.Header.Get("X-Filename") + ": Eicar-Signature"})
+		case "invalid":
+			_, _ = w.Write([]byte(`{"details": "clean is missing"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	s := &HTTPScanner{URL: server.URL, Client: server.Client()}
+
+	result, err := s.Scan(context.Background(), "provider.zip", strings.NewReader("clean"))
+	assert.NoError(t, err)
+	assert.True(t, result.Clean)
+
+	result, err = s.Scan(context.Background(), "provider.zip", strings.NewReader("infected"))
+	assert.NoError(t, err)
+	assert.False(t, result.Clean)
+	assert.Equal(t, "provider.zip: Eicar-Signature", result.Details)
+
+	for _, body := range []string{"invalid", "unavailable"} {
+		_, err = s.Scan(context.Background(), "provider.zip", strings.NewReader(body))
+		assert.ErrorIs(t, err, ErrScanFailed, body)
+	}
+}
@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule describes when a task is supposed to run.
+type Schedule interface {
+	// Next returns the next activation time after t
+	Next(t time.Time) time.Time
+}
+
+// cronSchedule is a Schedule based on a standard five field cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar are true if the day of month or day of week field is a wildcard
+	domStar, dowStar bool
+}
+
+// everySchedule is a Schedule with a fixed interval
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(e.interval)
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var (
+	fieldMinute = field{name: "minute", min: 0, max: 59}
+	fieldHour   = field{name: "hour", min: 0, max: 23}
+	fieldDom    = field{name: "day of month", min: 1, max: 31}
+	fieldMonth  = field{name: "month", min: 1, max: 12}
+	fieldDow    = field{name: "day of week", min: 0, max: 7}
+
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// ParseCron parses a five field cron expression (minute, hour, day of month, month, day of week).
+// Additionally, the descriptors @yearly, @monthly, @weekly, @daily, @hourly and @every <duration> are supported.
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("invalid cron expression %q: interval must be at least one second", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, but found %d", spec, len(fields))
+	}
+
+	var (
+		c   cronSchedule
+		err error
+	)
+	if c.minute, err = parseField(fields[0], fieldMinute); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if c.hour, err = parseField(fields[1], fieldHour); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if c.dom, err = parseField(fields[2], fieldDom); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if c.month, err = parseField(fields[3], fieldMonth); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if c.dow, err = parseField(fields[4], fieldDow); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+
+	// Sunday can be specified as either 0 or 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return &c, nil
+}
+
+// parseField parses a comma-separated list of values, ranges and steps into a bit set
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepExpr, f.name)
+			}
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			lo, hi, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if start, err = parseValue(lo, f); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(hi, f); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangeExpr, f); err != nil {
+				return 0, err
+			}
+			// A single value without a step is not a range, e.g. "5" instead of "5/10"
+			if !hasStep {
+				end = start
+			}
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d] in %s field", v, f.min, f.max, f.name)
+	}
+	return v, nil
+}
+
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	// Start at the next full minute
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// An expression like "0 0 30 2 *" never matches, therefore we give up after a couple of years
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows the cron semantics: if both day fields are restricted, either of them has to match
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 30, 20, 0, time.UTC) // Friday
+
+	testCases := []struct {
+		name     string
+		spec     string
+		expected time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "every minute",
+			spec:     "* * * * *",
+			expected: time.Date(2024, time.March, 15, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name:     "step",
+			spec:     "*/15 * * * *",
+			expected: time.Date(2024, time.March, 15, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			name:     "list and range",
+			spec:     "0 8-9,18 * * *",
+			expected: time.Date(2024, time.March, 15, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of week with sunday as 7",
+			spec:     "0 0 * * 7",
+			expected: time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of month or day of week",
+			spec:     "0 0 20 * 1",
+			expected: time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "month rollover",
+			spec:     "0 0 1 1 *",
+			expected: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "descriptor",
+			spec:     "@daily",
+			expected: time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "every",
+			spec:     "@every 90s",
+			expected: from.Add(90 * time.Second),
+		},
+		{
+			name:     "never matches",
+			spec:     "0 0 30 2 *",
+			expected: time.Time{},
+		},
+		{
+			name:    "too few fields",
+			spec:    "* * * *",
+			wantErr: true,
+		},
+		{
+			name:    "out of range",
+			spec:    "60 * * * *",
+			wantErr: true,
+		},
+		{
+			name:    "invalid step",
+			spec:    "*/0 * * * *",
+			wantErr: true,
+		},
+		{
+			name:    "invalid range",
+			spec:    "* 10-5 * * *",
+			wantErr: true,
+		},
+		{
+			name:    "invalid every",
+			spec:    "@every never",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseCron(tc.spec)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, schedule.Next(from))
+		})
+	}
+}
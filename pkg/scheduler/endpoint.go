@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type statusResponse struct {
+	Tasks []TaskStatus `json:"tasks"`
+}
+
+func statusEndpoint(s *Scheduler) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return statusResponse{Tasks: s.Status()}, nil
+	}
+}
+
+type runRequest struct {
+	name string
+}
+
+type runResponse struct {
+	Task string `json:"task"`
+}
+
+// StatusCode is used by httptransport.EncodeJSONResponse, the task is run asynchronously
+func (r runResponse) StatusCode() int {
+	return http.StatusAccepted
+}
+
+func runEndpoint(s *Scheduler) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(runRequest)
+
+		if err := s.Run(ctx, req.name); err != nil {
+			return nil, err
+		}
+
+		return runResponse{Task: req.name}, nil
+	}
+}
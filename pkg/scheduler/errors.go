@@ -0,0 +1,9 @@
+package scheduler
+
+import "errors"
+
+var (
+	// Scheduler errors
+	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskRunning  = errors.New("task is already running")
+)
@@ -0,0 +1,247 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+)
+
+// TaskFunc is a maintenance task which can be run by the Scheduler
+type TaskFunc func(ctx context.Context) error
+
+// TaskStatus describes the state of a registered task
+type TaskStatus struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule,omitempty"`
+	Running      bool       `json:"running"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Runs         int64      `json:"runs"`
+	Failures     int64      `json:"failures"`
+	Skipped      int64      `json:"skipped"`
+}
+
+type task struct {
+	run      TaskFunc
+	schedule Schedule
+
+	// status is protected by the mutex of the Scheduler
+	status TaskStatus
+}
+
+// Scheduler runs registered maintenance tasks based on their cron schedule.
+// A task is never executed concurrently, a scheduled run is skipped if the previous run is still in progress.
+type Scheduler struct {
+	mu    sync.Mutex
+	tasks map[string]*task
+
+	// ctx is the context of the running Scheduler and is used for manually triggered runs
+	ctx    context.Context
+	wg     sync.WaitGroup
+	logger *slog.Logger
+}
+
+// Register adds a task which can be scheduled or run manually
+func (s *Scheduler) Register(name string, run TaskFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks[name] = &task{
+		run:    run,
+		status: TaskStatus{Name: name},
+	}
+}
+
+// Schedule configures the cron schedule of a registered task
+func (s *Scheduler) Schedule(name, spec string) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[name]
+	if !ok {
+		return fmt.Errorf("%w: %s, available tasks are: %s", ErrTaskNotFound, name, strings.Join(s.names(), ", "))
+	}
+	t.schedule = schedule
+	t.status.Schedule = spec
+
+	return nil
+}
+
+// Scheduled returns true if at least one task has a schedule
+func (s *Scheduler) Scheduled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tasks {
+		if t.schedule != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Start runs the scheduled tasks until the context is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx = ctx
+	for name, t := range s.tasks {
+		if t.schedule == nil {
+			continue
+		}
+
+		s.logger.Info("scheduling task", slog.String("task", name), slog.String("schedule", t.status.Schedule))
+		go s.loop(ctx, name, t)
+	}
+}
+
+// Wait blocks until all running tasks returned
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, name string, t *task) {
+	for {
+		next := t.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Warn("task schedule never matches", slog.String("task", name))
+			return
+		}
+
+		s.mu.Lock()
+		t.status.NextRun = &next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := s.trigger(ctx, name, t); err != nil {
+				s.logger.Warn("skipping scheduled run", slog.String("task", name), slog.String("err", err.Error()))
+			}
+		}
+	}
+}
+
+// trigger starts the task in a separate goroutine, unless it is already running
+func (s *Scheduler) trigger(ctx context.Context, name string, t *task) error {
+	s.mu.Lock()
+	if t.status.Running {
+		t.status.Skipped++
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrTaskRunning, name)
+	}
+	begin := time.Now()
+	t.status.Running = true
+	t.status.LastRun = &begin
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		logger := s.logger.With(slog.String("task", name))
+		logger.Info("running task")
+		err := t.run(ctx)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		t.status.Running = false
+		t.status.Runs++
+		t.status.LastDuration = time.Since(begin).String()
+		t.status.LastError = ""
+		if err != nil {
+			t.status.Failures++
+			t.status.LastError = err.Error()
+			logger.Error("task failed", slog.String("err", err.Error()), slog.String("took", t.status.LastDuration))
+			return
+		}
+		logger.Info("task finished", slog.String("took", t.status.LastDuration))
+	}()
+
+	return nil
+}
+
+// Run triggers a registered task outside of its schedule
+func (s *Scheduler) Run(_ context.Context, name string) error {
+	s.mu.Lock()
+	t, ok := s.tasks[name]
+	ctx := s.ctx
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, name)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// The run is decoupled from the request context, as it would be cancelled once the response is sent
+	return s.trigger(ctx, name, t)
+}
+
+// Status returns the status of all registered tasks ordered by name
+func (s *Scheduler) Status() []TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]TaskStatus, 0, len(s.tasks))
+	for _, name := range s.names() {
+		statuses = append(statuses, s.tasks[name].status)
+	}
+	return statuses
+}
+
+// names must be called with the mutex held
+func (s *Scheduler) names() []string {
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New returns a Scheduler without any registered tasks
+func New() *Scheduler {
+	return &Scheduler{
+		tasks:  make(map[string]*task),
+		logger: slog.Default().With(slog.String(loglevel.ComponentKey, "scheduler")),
+	}
+}
+
+// ParseSchedules parses schedules in the form of <task>=<cron expression>.
+// Multiple schedules can be passed in a single value, when separated by a semicolon.
+func ParseSchedules(values []string) (map[string]string, error) {
+	schedules := make(map[string]string)
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ";") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			name, spec, ok := strings.Cut(entry, "=")
+			if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(spec) == "" {
+				return nil, fmt.Errorf("invalid schedule %q, expected <task>=<cron expression>", entry)
+			}
+			schedules[strings.TrimSpace(name)] = strings.TrimSpace(spec)
+		}
+	}
+	return schedules, nil
+}
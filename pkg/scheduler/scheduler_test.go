@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_Schedule(t *testing.T) {
+	s := New()
+	s.Register("stats-flush", func(ctx context.Context) error { return nil })
+
+	assert.False(t, s.Scheduled())
+	assert.ErrorIs(t, s.Schedule("unknown", "* * * * *"), ErrTaskNotFound)
+	assert.Error(t, s.Schedule("stats-flush", "invalid"))
+	assert.NoError(t, s.Schedule("stats-flush", "*/5 * * * *"))
+	assert.True(t, s.Scheduled())
+
+	status := s.Status()
+	assert.Len(t, status, 1)
+	assert.Equal(t, "*/5 * * * *", status[0].Schedule)
+}
+
+func TestScheduler_RunOverlap(t *testing.T) {
+	release := make(chan struct{})
+	s := New()
+	s.Register("slow", func(ctx context.Context) error {
+		<-release
+		return errors.New("mocked error")
+	})
+
+	assert.ErrorIs(t, s.Run(context.Background(), "unknown"), ErrTaskNotFound)
+	assert.NoError(t, s.Run(context.Background(), "slow"))
+	assert.ErrorIs(t, s.Run(context.Background(), "slow"), ErrTaskRunning)
+
+	status := s.Status()[0]
+	assert.True(t, status.Running)
+	assert.Equal(t, int64(1), status.Skipped)
+
+	close(release)
+	s.Wait()
+
+	status = s.Status()[0]
+	assert.False(t, status.Running)
+	assert.Equal(t, int64(1), status.Runs)
+	assert.Equal(t, int64(1), status.Failures)
+	assert.Equal(t, "mocked error", status.LastError)
+	assert.NotNil(t, status.LastRun)
+}
+
+func TestScheduler_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ran := make(chan struct{}, 1)
+	s := New()
+	s.Register("fast", func(ctx context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	assert.NoError(t, s.Schedule("fast", "@every 1s"))
+	s.Start(ctx)
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled task did not run")
+	}
+}
+
+func TestParseSchedules(t *testing.T) {
+	schedules, err := ParseSchedules([]string{"stats-flush=*/5 * * * *; audit = @daily", "sync=0,30 * * * *"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"stats-flush": "*/5 * * * *",
+		"audit":       "@daily",
+		"sync":        "0,30 * * * *",
+	}, schedules)
+
+	_, err = ParseSchedules([]string{"stats-flush"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type muxVar string
+
+const (
+	varTask muxVar = "task"
+)
+
+// MakeHandler returns a fully initialized http.Handler for the task administration API.
+func MakeHandler(s *Scheduler, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/tasks`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(statusEndpoint(s)),
+				httptransport.NopRequestDecoder,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("POST").Path(`/tasks/{task}/run`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(runEndpoint(s)),
+				decodeRunRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varTask)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+func decodeRunRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	name, ok := ctx.Value(varTask).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: task", core.ErrVarMissing)
+	}
+
+	return runRequest{name: name}, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	statusCode := core.GenericError(err)
+	if errors.Is(err, ErrTaskNotFound) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, ErrTaskRunning) {
+		statusCode = http.StatusConflict
+	}
+
+	core.HandleErrorResponse(err, statusCode, w)
+}
+
+func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, k := range keys {
+			if v, ok := mux.Vars(r)[string(k)]; ok {
+				ctx = context.WithValue(ctx, k, v)
+			}
+		}
+
+		return ctx
+	}
+}
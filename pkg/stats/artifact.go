@@ -0,0 +1,15 @@
+package stats
+
+import (
+	"path"
+)
+
+// ModuleArtifact returns the artifact identifier of a module in the form of modules/<namespace>/<name>/<provider>
+func ModuleArtifact(namespace, name, provider string) string {
+	return path.Join("modules", namespace, name, provider)
+}
+
+// ProviderArtifact returns the artifact identifier of a provider in the form of providers/<namespace>/<name>
+func ProviderArtifact(namespace, name string) string {
+	return path.Join("providers", namespace, name)
+}
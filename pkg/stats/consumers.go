@@ -0,0 +1,145 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+)
+
+// ConsumerStorage represents the Storage of the consumers of artifacts.
+type ConsumerStorage interface {
+	// Consumers returns the persisted consumers of an artifact.
+	// core.ErrObjectNotFound is returned if no consumers have been persisted yet.
+	Consumers(ctx context.Context, artifact string) (core.Consumers, error)
+
+	// UploadConsumers persists the consumers of an artifact and overwrites existing ones.
+	UploadConsumers(ctx context.Context, artifact string, consumers core.Consumers) error
+}
+
+// ConsumerTracker records which consumers download which versions of artifacts.
+type ConsumerTracker interface {
+	// Track records a download of an artifact version by the consumer of the context.
+	// Downloads of anonymous consumers are ignored.
+	Track(ctx context.Context, artifact, version string)
+
+	// Consumers returns the consumers of an artifact, including downloads which have not been persisted yet
+	Consumers(ctx context.Context, artifact string) (core.Consumers, error)
+
+	// Flush persists all pending downloads to the ConsumerStorage
+	Flush(ctx context.Context) error
+}
+
+// consumerTracker implements ConsumerTracker and buffers the downloads in memory like the recorder.
+type consumerTracker struct {
+	mu      sync.Mutex
+	pending map[string]core.Consumers
+
+	storage ConsumerStorage
+
+	// trackAddresses identifies anonymous consumers by their address, e.g. if downloads are proxied without authentication
+	trackAddresses bool
+	logger         *slog.Logger
+}
+
+// consumer returns the identity of the authenticated client, or its address if addresses are tracked
+func (t *consumerTracker) consumer(ctx context.Context) string {
+	if identity := core.Identity(ctx); identity != "" {
+		return identity
+	}
+	if t.trackAddresses {
+		return core.ClientAddress(ctx)
+	}
+	return ""
+}
+
+func (t *consumerTracker) Track(ctx context.Context, artifact, version string) {
+	consumer := t.consumer(ctx)
+	if consumer == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.pending[artifact]
+	if !ok {
+		c = make(core.Consumers)
+		t.pending[artifact] = c
+	}
+	c.Add(consumer, version, 1, time.Now().UTC())
+}
+
+func (t *consumerTracker) Consumers(ctx context.Context, artifact string) (core.Consumers, error) {
+	result := make(core.Consumers)
+
+	persisted, err := t.storage.Consumers(ctx, artifact)
+	if err != nil && !errors.Is(err, core.ErrObjectNotFound) {
+		return nil, err
+	}
+	result.Merge(persisted)
+
+	t.mu.Lock()
+	result.Merge(t.pending[artifact])
+	t.mu.Unlock()
+
+	return result, nil
+}
+
+func (t *consumerTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[string]core.Consumers)
+	t.mu.Unlock()
+
+	var errs []error
+	for artifact, delta := range pending {
+		if err := t.flushArtifact(ctx, artifact, delta); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush consumers of %s: %w", artifact, err))
+
+			// Keep the downloads so that they can be persisted on the next flush
+			t.mu.Lock()
+			c, ok := t.pending[artifact]
+			if !ok {
+				c = make(core.Consumers)
+				t.pending[artifact] = c
+			}
+			c.Merge(delta)
+			t.mu.Unlock()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t *consumerTracker) flushArtifact(ctx context.Context, artifact string, delta core.Consumers) error {
+	persisted, err := t.storage.Consumers(ctx, artifact)
+	if err != nil && !errors.Is(err, core.ErrObjectNotFound) {
+		return err
+	}
+
+	c := make(core.Consumers)
+	c.Merge(persisted)
+	c.Merge(delta)
+	return t.storage.UploadConsumers(ctx, artifact, c)
+}
+
+// NewConsumerTracker returns a ConsumerTracker which flushes the pending downloads in the given interval and on shutdown.
+// Anonymous consumers are identified by their address if trackAddresses is set, otherwise only authenticated consumers are tracked.
+func NewConsumerTracker(ctx context.Context, storage ConsumerStorage, flushInterval time.Duration, trackAddresses bool) ConsumerTracker {
+	t := &consumerTracker{
+		pending:        make(map[string]core.Consumers),
+		storage:        storage,
+		trackAddresses: trackAddresses,
+		logger:         slog.Default().With(slog.String(loglevel.ComponentKey, "consumers")),
+	}
+
+	go runFlush(ctx, flushInterval, t.Flush, t.logger)
+
+	return t
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockedConsumerStorage struct {
+	mu        sync.Mutex
+	consumers map[string]core.Consumers
+}
+
+func (m *mockedConsumerStorage) Consumers(_ context.Context, artifact string) (core.Consumers, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.consumers[artifact]
+	if !ok {
+		return nil, core.ErrObjectNotFound
+	}
+	return c, nil
+}
+
+func (m *mockedConsumerStorage) UploadConsumers(_ context.Context, artifact string, consumers core.Consumers) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.consumers[artifact] = consumers
+	return nil
+}
+
+func TestConsumerTracker(t *testing.T) {
+	ctx := context.Background()
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).UTC()
+	s := &mockedConsumerStorage{consumers: map[string]core.Consumers{
+		"modules/example/s3/aws": {"ci": {Versions: map[string]int64{"1.0.0": 2}, LastDownload: lastWeek}},
+	}}
+	tracker := NewConsumerTracker(ctx, s, 0, false)
+
+	anonymous := context.WithValue(ctx, httptransport.ContextKeyRequestRemoteAddr, "10.0.0.1:51234")
+	tracker.Track(anonymous, "modules/example/s3/aws", "1.0.0")
+	tracker.Track(core.WithIdentity(ctx, "ci"), "modules/example/s3/aws", "2.0.0")
+	tracker.Track(core.WithIdentity(ctx, "jane"), "modules/example/s3/aws", "2.0.0")
+
+	assert.NoError(t, tracker.Flush(ctx))
+	consumers, err := tracker.Consumers(ctx, "modules/example/s3/aws")
+	assert.NoError(t, err)
+	assert.Len(t, consumers, 2)
+	assert.Equal(t, map[string]int64{"1.0.0": 2, "2.0.0": 1}, consumers["ci"].Versions)
+	assert.True(t, consumers["ci"].LastDownload.After(lastWeek))
+	assert.Equal(t, map[string]int64{"2.0.0": 1}, consumers["jane"].Versions)
+
+	// Anonymous consumers are identified by their address if addresses are tracked
+	tracker = NewConsumerTracker(ctx, s, 0, true)
+	tracker.Track(anonymous, "providers/hashicorp/random", "1.0.0")
+	consumers, err = tracker.Consumers(ctx, "providers/hashicorp/random")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"1.0.0": 1}, consumers["10.0.0.1"].Versions)
+}
@@ -0,0 +1,11 @@
+package stats
+
+import "errors"
+
+var (
+	// ErrStatsDisabled is returned if download statistics are requested while they are not being recorded
+	ErrStatsDisabled = errors.New("download statistics are disabled")
+
+	// ErrConsumersDisabled is returned if consumers are requested while they are not being tracked
+	ErrConsumersDisabled = errors.New("consumer tracking is disabled")
+)
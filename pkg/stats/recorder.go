@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultFlushInterval is the default interval in which pending download counts are persisted
+	DefaultFlushInterval = time.Minute
+
+	shutdownFlushTimeout = 30 * time.Second
+)
+
+// Recorder counts downloads of artifacts.
+type Recorder interface {
+	// Record counts a single download of an artifact version
+	Record(artifact, version string)
+
+	// Stats returns the download statistics of an artifact, including downloads which have not been persisted yet
+	Stats(ctx context.Context, artifact string) (*core.DownloadStats, error)
+
+	// Flush persists all pending download counts to the Storage
+	Flush(ctx context.Context) error
+}
+
+// recorder implements Recorder and buffers the download counts in memory.
+// The counts are merged into the persisted statistics on every flush, which keeps the number of storage
+// requests independent of the number of downloads.
+type recorder struct {
+	mu      sync.Mutex
+	pending map[string]*core.DownloadStats
+
+	storage Storage
+	metrics *o11y.StatsMetrics
+	logger  *slog.Logger
+}
+
+func (r *recorder) Record(artifact, version string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.pending[artifact]
+	if !ok {
+		s = &core.DownloadStats{}
+		r.pending[artifact] = s
+	}
+	s.Add(version, 1)
+
+	if r.metrics != nil {
+		r.metrics.Downloads.With(prometheus.Labels{
+			o11y.ArtifactLabel: artifact,
+		}).Inc()
+	}
+}
+
+func (r *recorder) Stats(ctx context.Context, artifact string) (*core.DownloadStats, error) {
+	result := &core.DownloadStats{Versions: make(map[string]int64)}
+
+	persisted, err := r.storage.DownloadStats(ctx, artifact)
+	if err != nil && !errors.Is(err, core.ErrObjectNotFound) {
+		return nil, err
+	}
+	result.Merge(persisted)
+
+	r.mu.Lock()
+	result.Merge(r.pending[artifact])
+	r.mu.Unlock()
+
+	return result, nil
+}
+
+func (r *recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[string]*core.DownloadStats)
+	r.mu.Unlock()
+
+	var errs []error
+	for artifact, delta := range pending {
+		if err := r.flushArtifact(ctx, artifact, delta); err != nil {
+			if r.metrics != nil {
+				r.metrics.FlushFailures.Inc()
+			}
+			errs = append(errs, fmt.Errorf("failed to flush download stats of %s: %w", artifact, err))
+
+			// Keep the counts so that they can be persisted on the next flush
+			r.mu.Lock()
+			s, ok := r.pending[artifact]
+			if !ok {
+				s = &core.DownloadStats{}
+				r.pending[artifact] = s
+			}
+			s.Merge(delta)
+			r.mu.Unlock()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *recorder) flushArtifact(ctx context.Context, artifact string, delta *core.DownloadStats) error {
+	s, err := r.storage.DownloadStats(ctx, artifact)
+	if err != nil {
+		if !errors.Is(err, core.ErrObjectNotFound) {
+			return err
+		}
+		s = &core.DownloadStats{}
+	}
+
+	s.Merge(delta)
+	return r.storage.UploadDownloadStats(ctx, artifact, s)
+}
+
+// runFlush calls flush in the given interval until the context is cancelled, and a final time on shutdown.
+// A non-positive interval only flushes once the context is cancelled.
+func runFlush(ctx context.Context, interval time.Duration, flush func(ctx context.Context) error, logger *slog.Logger) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			if err := flush(ctx); err != nil {
+				logger.Error("failed to flush", slog.String("err", err.Error()))
+			}
+		case <-ctx.Done():
+			// The parent context is already cancelled, therefore we need a new one for the final flush
+			flushCtx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+			if err := flush(flushCtx); err != nil {
+				logger.Error("failed to flush on shutdown", slog.String("err", err.Error()))
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// NewRecorder returns a Recorder which flushes the pending download counts in the given interval and on shutdown.
+// A non-positive flushInterval disables the periodic flush, e.g. when Flush is called by the scheduler instead.
+func NewRecorder(ctx context.Context, storage Storage, metrics *o11y.StatsMetrics, flushInterval time.Duration) Recorder {
+	r := &recorder{
+		pending: make(map[string]*core.DownloadStats),
+		storage: storage,
+		metrics: metrics,
+		logger:  slog.Default().With(slog.String(loglevel.ComponentKey, "stats")),
+	}
+
+	go runFlush(ctx, flushInterval, r.Flush, r.logger)
+
+	return r
+}
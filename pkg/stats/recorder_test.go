@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockedStorage struct {
+	mu        sync.Mutex
+	stats     map[string]*core.DownloadStats
+	uploadErr error
+}
+
+func (m *mockedStorage) DownloadStats(_ context.Context, artifact string) (*core.DownloadStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[artifact]
+	if !ok {
+		return nil, core.ErrObjectNotFound
+	}
+
+	c := &core.DownloadStats{}
+	c.Merge(s)
+	return c, nil
+}
+
+func (m *mockedStorage) UploadDownloadStats(_ context.Context, artifact string, stats *core.DownloadStats) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.uploadErr != nil {
+		return m.uploadErr
+	}
+
+	m.stats[artifact] = stats
+	return nil
+}
+
+func TestRecorder_Flush(t *testing.T) {
+	testCases := []struct {
+		name      string
+		persisted map[string]*core.DownloadStats
+		records   [][2]string
+		uploadErr error
+		expected  map[string]*core.DownloadStats
+		wantErr   bool
+	}{
+		{
+			name: "new artifact",
+			records: [][2]string{
+				{"modules/example/s3/aws", "1.0.0"},
+				{"modules/example/s3/aws", "1.0.0"},
+				{"modules/example/s3/aws", "2.0.0"},
+			},
+			expected: map[string]*core.DownloadStats{
+				"modules/example/s3/aws": {Total: 3, Versions: map[string]int64{"1.0.0": 2, "2.0.0": 1}},
+			},
+		},
+		{
+			name: "merge with persisted stats",
+			persisted: map[string]*core.DownloadStats{
+				"providers/hashicorp/random": {Total: 5, Versions: map[string]int64{"1.0.0": 5}},
+			},
+			records: [][2]string{
+				{"providers/hashicorp/random", "1.0.0"},
+				{"providers/hashicorp/random", "1.1.0"},
+			},
+			expected: map[string]*core.DownloadStats{
+				"providers/hashicorp/random": {Total: 7, Versions: map[string]int64{"1.0.0": 6, "1.1.0": 1}},
+			},
+		},
+		{
+			name: "failed upload keeps pending counts",
+			records: [][2]string{
+				{"modules/example/s3/aws", "1.0.0"},
+			},
+			uploadErr: errors.New("mocked error"),
+			expected:  map[string]*core.DownloadStats{},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			persisted := tc.persisted
+			if persisted == nil {
+				persisted = make(map[string]*core.DownloadStats)
+			}
+			s := &mockedStorage{stats: persisted, uploadErr: tc.uploadErr}
+			r := NewRecorder(context.Background(), s, nil, 0)
+
+			for _, record := range tc.records {
+				r.Record(record[0], record[1])
+			}
+
+			err := r.Flush(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+
+				// The downloads must still be visible and persisted once the storage recovers
+				s.uploadErr = nil
+				stats, err := r.Stats(context.Background(), tc.records[0][0])
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(tc.records)), stats.Total)
+
+				assert.NoError(t, r.Flush(context.Background()))
+				assert.Contains(t, s.stats, tc.records[0][0])
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, s.stats)
+
+			// A second flush without new downloads must not change anything
+			assert.NoError(t, r.Flush(context.Background()))
+			assert.Equal(t, tc.expected, s.stats)
+		})
+	}
+}
+
+func TestRecorder_Stats(t *testing.T) {
+	s := &mockedStorage{stats: map[string]*core.DownloadStats{
+		"modules/example/s3/aws": {Total: 2, Versions: map[string]int64{"1.0.0": 2}},
+	}}
+	r := NewRecorder(context.Background(), s, nil, 0)
+	r.Record("modules/example/s3/aws", "1.0.0")
+	r.Record("modules/example/s3/aws", "2.0.0")
+
+	stats, err := r.Stats(context.Background(), "modules/example/s3/aws")
+	assert.NoError(t, err)
+	assert.Equal(t, &core.DownloadStats{Total: 4, Versions: map[string]int64{"1.0.0": 3, "2.0.0": 1}}, stats)
+
+	stats, err = r.Stats(context.Background(), "modules/example/unknown/aws")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Total)
+	assert.Empty(t, stats.Versions)
+}
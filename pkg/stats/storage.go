@@ -0,0 +1,17 @@
+package stats
+
+import (
+	"context"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Storage represents the Storage of download statistics.
+type Storage interface {
+	// DownloadStats returns the persisted download statistics of an artifact.
+	// core.ErrObjectNotFound is returned if no statistics have been persisted yet.
+	DownloadStats(ctx context.Context, artifact string) (*core.DownloadStats, error)
+
+	// UploadDownloadStats persists the download statistics of an artifact and overwrites existing ones.
+	UploadDownloadStats(ctx context.Context, artifact string, stats *core.DownloadStats) error
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/admission"
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// admitModule evaluates the upload of the module archive with the admission controller, and returns the archive to upload.
+// The archive is spooled to a temporary file to extract its inventory, unless it allows random access.
+// The returned function removes the temporary file once the archive has been uploaded.
+func (s *ObjectStorage) admitModule(ctx context.Context, operation, namespace, name, provider, version, key string, body io.Reader) (io.Reader, func(), error) {
+	if s.admission == nil {
+		return body, func() {}, nil
+	}
+
+	archive, size, cleanup, err := spool(s.quotas.Quota(namespace).LimitReader(namespace, body))
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := admission.Inventory(archive, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	modules, err := s.ListModuleVersions(ctx, namespace, name, provider)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	versions := make([]string, 0, len(modules))
+	for _, m := range modules {
+		versions = append(versions, m.Version)
+	}
+
+	input := &admission.Input{
+		Operation: operation,
+		Artifact: admission.Artifact{
+			Type:      admission.ArtifactModule,
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+			Filename:  path.Base(key),
+			Size:      size,
+		},
+		Uploader: uploader(ctx),
+		Versions: versions,
+		Files:    files,
+	}
+	if err := s.admission.Admit(ctx, input); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return io.NewSectionReader(archive, 0, size), cleanup, nil
+}
+
+// admitProvider evaluates the upload of the provider archive with the admission controller
+func (s *ObjectStorage) admitProvider(ctx context.Context, namespace, name, filename string, archive io.ReaderAt, size int64) error {
+	if s.admission == nil {
+		return nil
+	}
+
+	p, err := core.NewProviderFromArchive(filename)
+	if err != nil {
+		return err
+	}
+	files, err := admission.Inventory(archive, size)
+	if err != nil {
+		return err
+	}
+
+	objects, err := s.backend.List(ctx, providerStoragePrefix(s.prefix, internalProviderType, "", namespace, name)+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list providers: %w", err)
+	}
+	versions := []string{}
+	for _, obj := range objects {
+		existing, err := core.NewProviderFromArchive(path.Base(obj.Key))
+		if err != nil || !strings.HasSuffix(obj.Key, core.ProviderExtension) || slices.Contains(versions, existing.Version) {
+			continue
+		}
+		versions = append(versions, existing.Version)
+	}
+
+	return s.admission.Admit(ctx, &admission.Input{
+		Operation: admission.OperationUpload,
+		Artifact: admission.Artifact{
+			Type:      admission.ArtifactProvider,
+			Namespace: namespace,
+			Name:      name,
+			Version:   p.Version,
+			OS:        p.OS,
+			Arch:      p.Arch,
+			Filename:  filename,
+			Size:      size,
+		},
+		Uploader: uploader(ctx),
+		Versions: versions,
+		Files:    files,
+	})
+}
+
+// uploader returns the identity of the context, which is the authenticated client of the server or the publisher of the upload command
+func uploader(ctx context.Context) admission.Uploader {
+	return admission.Uploader{
+		Identity:      core.Identity(ctx),
+		ClientAddress: core.ClientAddress(ctx),
+	}
+}
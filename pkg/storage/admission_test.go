@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/admission"
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// mockController records the inputs and denies uploads of version 9.9.9
+type mockController struct {
+	inputs []*admission.Input
+}
+
+func (c *mockController) Admit(_ context.Context, input *admission.Input) error {
+	c.inputs = append(c.inputs, input)
+	if input.Artifact.Version == "9.9.9" {
+		return fmt.Errorf("%w: version 9.9.9 is reserved", core.ErrAdmissionDenied)
+	}
+	return nil
+}
+
+func TestObjectStorage_Admission(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := core.WithIdentity(context.Background(), "ci")
+	backend := newMockBackend()
+	controller := &mockController{}
+	s := NewObjectStorage(backend, WithObjectStorageAdmission(controller))
+
+	_, err := s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.1.0", strings.NewReader("module"))
+	assert.NoError(err)
+	_, err = s.ReplaceModule(ctx, "acme", "vpc", "aws", "1.1.0", strings.NewReader("replaced"))
+	assert.NoError(err)
+	assert.Equal([]byte("replaced"), backend.objects["modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz"])
+
+	// Denied uploads aren't stored
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "9.9.9", strings.NewReader("module"))
+	assert.ErrorIs(err, core.ErrAdmissionDenied)
+	assert.NotContains(backend.objects, "modules/acme/vpc/aws/acme-vpc-aws-9.9.9.tar.gz")
+
+	if assert.Len(controller.inputs, 4) {
+		assert.Equal(&admission.Input{
+			Operation: admission.OperationReplace,
+			Artifact: admission.Artifact{
+				Type:      admission.ArtifactModule,
+				Namespace: "acme",
+				Name:      "vpc",
+				Provider:  "aws",
+				Version:   "1.1.0",
+				Filename:  "acme-vpc-aws-1.1.0.tar.gz",
+				Size:      8,
+			},
+			Uploader: admission.Uploader{Identity: "ci"},
+			Versions: []string{"1.0.0", "1.1.0"},
+			Files:    []admission.File{},
+		}, controller.inputs[2])
+	}
+
+	archive := "terraform-provider-random_9.9.9_linux_amd64.zip"
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", archive, strings.NewReader("provider"))
+	assert.ErrorIs(err, core.ErrAdmissionDenied)
+	assert.NotContains(backend.objects, "providers/hashicorp/random/"+archive)
+	input := controller.inputs[len(controller.inputs)-1]
+	assert.Equal(admission.Artifact{
+		Type:      admission.ArtifactProvider,
+		Namespace: "hashicorp",
+		Name:      "random",
+		Version:   "9.9.9",
+		OS:        "linux",
+		Arch:      "amd64",
+		Filename:  archive,
+		Size:      8,
+	}, input.Artifact)
+	assert.Equal([]string{}, input.Versions)
+
+	// Manifests and SHA256SUMS files aren't evaluated
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_9.9.9_SHA256SUMS", strings.NewReader("sums")))
+	assert.Len(controller.inputs, 5)
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// The checks of an audit, which are reported with every Discrepancy
+const (
+	AuditChecksum  = "checksum"
+	AuditSignature = "signature"
+	AuditPlatform  = "platform"
+)
+
+// Discrepancy is a stored artifact which failed a check of an audit
+type Discrepancy struct {
+	Key    string `json:"key"`
+	Check  string `json:"check"`
+	Reason string `json:"reason"`
+}
+
+// AuditResult summarizes an audit of the stored artifacts
+type AuditResult struct {
+	// Versions is the number of audited provider versions, Archives the number of archives whose checksum was verified
+	Versions      int           `json:"versions"`
+	Archives      int           `json:"archives"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// Auditor checks the integrity of the stored artifacts
+type Auditor interface {
+	Audit(ctx context.Context, sampleRate float64) (AuditResult, error)
+}
+
+// Audit checks that the SHA256SUMS files of all provider versions are signed by the signing keys of the provider,
+// and that every file advertised by them is stored. The checksums of a sample of the module and provider archives are verified,
+// as this requires downloading them. A sampleRate of 1 or above verifies all archives.
+// The discrepancies are logged and returned, an error is only returned if the storage can't be listed.
+func (s *ObjectStorage) Audit(ctx context.Context, sampleRate float64) (AuditResult, error) {
+	result := AuditResult{Discrepancies: []Discrepancy{}}
+
+	objects, err := s.backend.List(ctx, s.prefix)
+	if err != nil {
+		return result, fmt.Errorf("failed to list objects to audit: %w", err)
+	}
+
+	a := &audit{
+		s:          s,
+		keys:       make(map[string]bool, len(objects)),
+		result:     &result,
+		sampleRate: sampleRate,
+		signing:    make(map[string]*core.SigningKeys),
+	}
+	for _, obj := range objects {
+		a.keys[obj.Key] = true
+	}
+
+	for _, obj := range objects {
+		rel := obj.Key
+		if s.prefix != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(obj.Key, strings.TrimSuffix(s.prefix, "/")+"/"); !ok {
+				continue
+			}
+		}
+		parts := strings.Split(rel, "/")
+
+		switch {
+		case parts[0] == string(internalModuleType) && strings.HasSuffix(obj.Key, moduleChecksumSuffix):
+			archive := strings.TrimSuffix(obj.Key, moduleChecksumSuffix)
+			if a.keys[archive] && a.sampled() {
+				a.moduleArchive(ctx, archive, obj.Key)
+			}
+		case parts[0] == string(internalProviderType) && len(parts) == 4 && strings.HasSuffix(obj.Key, "_SHA256SUMS"):
+			if a.keys[providerTombstonePath(s.prefix, parts[1], parts[2], providerFileVersion(parts[3]))] {
+				continue
+			}
+			a.provider(ctx, obj.Key, internalProviderType, "", parts[1], parts[2])
+		case parts[0] == "mirror" && len(parts) == 6 && parts[1] == "providers" && strings.HasSuffix(obj.Key, "_SHA256SUMS"):
+			a.provider(ctx, obj.Key, mirrorProviderType, parts[2], parts[3], parts[4])
+		}
+	}
+
+	slices.SortFunc(result.Discrepancies, func(a, b Discrepancy) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	for _, d := range result.Discrepancies {
+		slog.Warn("audit discrepancy", slog.String("key", d.Key), slog.String("check", d.Check), slog.String("reason", d.Reason))
+	}
+	slog.Info("audited storage",
+		slog.Int("versions", result.Versions),
+		slog.Int("archives", result.Archives),
+		slog.Int("discrepancies", len(result.Discrepancies)),
+	)
+
+	return result, nil
+}
+
+// audit holds the state of a single Audit
+type audit struct {
+	s          *ObjectStorage
+	keys       map[string]bool
+	result     *AuditResult
+	sampleRate float64
+
+	// signing caches the signing keys by the directory they apply to. Keys which can't be read are cached as nil and only reported once.
+	signing map[string]*core.SigningKeys
+}
+
+func (a *audit) discrepancy(key, check, reason string) {
+	a.result.Discrepancies = append(a.result.Discrepancies, Discrepancy{Key: key, Check: check, Reason: reason})
+}
+
+func (a *audit) sampled() bool {
+	return a.sampleRate >= 1 || rand.Float64() < a.sampleRate
+}
+
+// moduleArchive verifies the module archive against the checksum stored next to it
+func (a *audit) moduleArchive(ctx context.Context, archive, checksumKey string) {
+	checksum, err := a.s.backend.Download(ctx, checksumKey)
+	if err != nil {
+		a.discrepancy(archive, AuditChecksum, fmt.Sprintf("the checksum can't be read: %s", err))
+		return
+	}
+	a.archive(ctx, archive, strings.TrimSpace(string(checksum)))
+}
+
+func (a *audit) archive(ctx context.Context, key, expected string) {
+	a.result.Archives++
+	if err := a.s.verifyObject(ctx, key, expected); err != nil {
+		a.discrepancy(key, AuditChecksum, err.Error())
+	}
+}
+
+// provider audits the provider version of the SHA256SUMS file
+func (a *audit) provider(ctx context.Context, key string, pt providerType, hostname, namespace, name string) {
+	a.result.Versions++
+
+	data, err := a.s.backend.Download(ctx, key)
+	var sums *core.Sha256Sums
+	if err == nil {
+		sums, err = core.NewSha256Sums(path.Base(key), bytes.NewReader(data))
+	}
+	if err != nil {
+		a.discrepancy(key, AuditChecksum, fmt.Sprintf("the SHA256SUMS file can't be read: %s", err))
+		return
+	}
+
+	a.signature(ctx, key, data, pt, hostname, namespace, name)
+
+	files := make([]string, 0, len(sums.Entries))
+	for file := range sums.Entries {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	dir := path.Dir(key)
+	for _, file := range files {
+		archive := path.Join(dir, file)
+		if !a.keys[archive] {
+			a.discrepancy(archive, AuditPlatform, "the file advertised by the SHA256SUMS file is missing")
+			continue
+		}
+		if a.sampled() {
+			a.archive(ctx, archive, hex.EncodeToString(sums.Entries[file]))
+		}
+	}
+}
+
+// signature verifies the signature of the SHA256SUMS file with the signing keys of the provider
+func (a *audit) signature(ctx context.Context, key string, data []byte, pt providerType, hostname, namespace, name string) {
+	if !a.keys[key+".sig"] {
+		a.discrepancy(key, AuditSignature, "the signature of the SHA256SUMS file is missing")
+		return
+	}
+	sig, err := a.s.backend.Download(ctx, key+".sig")
+	if err != nil {
+		a.discrepancy(key, AuditSignature, fmt.Sprintf("the signature can't be read: %s", err))
+		return
+	}
+
+	keys, ok := a.signing[path.Dir(key)]
+	if !ok {
+		if pt == internalProviderType {
+			keys, err = a.s.SigningKeys(ctx, namespace, name)
+		} else {
+			keys, err = a.s.MirroredSigningKeys(ctx, hostname, namespace)
+		}
+		if err != nil {
+			a.discrepancy(key, AuditSignature, fmt.Sprintf("the signing keys can't be read: %s", err))
+		}
+		a.signing[path.Dir(key)] = keys
+	}
+	if keys == nil {
+		return
+	}
+
+	if err := keys.IsValidSha256Sums(data, sig); err != nil {
+		a.discrepancy(key, AuditSignature, fmt.Sprintf("invalid signature: %s", err))
+	}
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_Audit(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	entity, err := openpgp.NewEntity("boring-registry", "test", "boring-registry@example.com", &packet.Config{RSABits: 2048})
+	assert.NoError(err)
+	armored := new(bytes.Buffer)
+	w, err := armor.Encode(armored, openpgp.PublicKeyType, nil)
+	assert.NoError(err)
+	assert.NoError(entity.Serialize(w))
+	assert.NoError(w.Close())
+	assert.NoError(s.UploadSigningKeys(ctx, "acme", "", &core.SigningKeys{GPGPublicKeys: []core.GPGPublicKey{{ASCIIArmor: armored.String()}}}))
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+
+	// Version 2.0.0 is signed but lacks the darwin_arm64 archive, version 2.1.0 is complete but unsigned
+	for _, version := range []string{"2.0.0", "2.1.0"} {
+		archive := fmt.Sprintf("terraform-provider-random_%s_linux_amd64.zip", version)
+		shasum := sha256.Sum256([]byte("provider archive"))
+		sums := fmt.Sprintf("%s  %s\n%s  terraform-provider-random_%s_darwin_arm64.zip\n", hex.EncodeToString(shasum[:]), archive, hex.EncodeToString(shasum[:]), version)
+		if version == "2.1.0" {
+			sums = fmt.Sprintf("%s  %s\n", hex.EncodeToString(shasum[:]), archive)
+		}
+		assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", archive, strings.NewReader("provider archive")))
+		assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", fmt.Sprintf("terraform-provider-random_%s_SHA256SUMS", version), strings.NewReader(sums)))
+
+		if version == "2.0.0" {
+			sig := new(bytes.Buffer)
+			assert.NoError(openpgp.DetachSign(sig, entity, strings.NewReader(sums), nil))
+			assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS.sig", sig))
+		}
+	}
+
+	result, err := s.Audit(ctx, 1)
+	assert.NoError(err)
+	assert.Equal(2, result.Versions)
+	assert.Equal(3, result.Archives)
+	assert.Equal([]Discrepancy{
+		{Key: "providers/acme/random/terraform-provider-random_2.0.0_darwin_arm64.zip", Check: AuditPlatform, Reason: "the file advertised by the SHA256SUMS file is missing"},
+		{Key: "providers/acme/random/terraform-provider-random_2.1.0_SHA256SUMS", Check: AuditSignature, Reason: "the signature of the SHA256SUMS file is missing"},
+	}, result.Discrepancies)
+
+	// Archives which were modified after the upload are only detected if they are sampled
+	assert.NoError(s.backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", strings.NewReader("tampered")))
+
+	result, err = s.Audit(ctx, 0)
+	assert.NoError(err)
+	assert.Equal(0, result.Archives)
+	assert.Len(result.Discrepancies, 2)
+
+	result, err = s.Audit(ctx, 1)
+	assert.NoError(err)
+	assert.Len(result.Discrepancies, 3)
+	assert.Equal(Discrepancy{Key: "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", Check: AuditChecksum, Reason: fmt.Sprintf("expected checksum %x but was %x", sha256.Sum256([]byte("module")), sha256.Sum256([]byte("tampered")))}, result.Discrepancies[0])
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Object describes a single object stored in a Backend
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Backend is the minimal set of operations an object store has to provide.
+// The registry logic is implemented once in ObjectStorage on top of these primitives,
+// so adding a new storage backend only requires implementing this interface.
+type Backend interface {
+	// Exists reports whether an object is stored under the given key
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// Download returns the content of the object stored under the given key
+	Download(ctx context.Context, key string) ([]byte, error)
+
+	// Open returns a reader of the object stored under the given key, which must be closed by the caller
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Upload stores the content of the reader under the given key and replaces existing objects
+	Upload(ctx context.Context, key string, reader io.Reader) error
+
+	// Delete removes the object stored under the given key, deleting a missing object doesn't fail
+	Delete(ctx context.Context, key string) error
+
+	// List returns all objects with a key starting with the given prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// PresignedURL returns a URL which allows downloading the object without further authentication
+	PresignedURL(ctx context.Context, key string) (string, error)
+
+	// GetDownloadUrl returns the URL the download proxy fetches the given path from
+	GetDownloadUrl(ctx context.Context, url string) (string, error)
+}
+
+// Decorator wraps a Backend to add behavior like caching, metrics, or tracing
+type Decorator func(Backend) Backend
+
+// Decorate wraps the Backend with the decorators.
+// The first decorator is the outermost one and is invoked first.
+func Decorate(backend Backend, decorators ...Decorator) Backend {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		backend = decorators[i](backend)
+	}
+	return backend
+}
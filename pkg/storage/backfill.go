@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// ProviderBackfiller adds platforms to existing provider versions, e.g. darwin_arm64 builds which were released after the fact.
+// The archives of the new platforms are uploaded with UploadProviderReleaseFiles, before the SHA256SUMS file listing them is replaced.
+type ProviderBackfiller interface {
+	// ProviderSha256Sums returns the SHA256SUMS file of the provider version
+	ProviderSha256Sums(ctx context.Context, namespace, name, version string) ([]byte, error)
+
+	// ReplaceProviderSha256Sums replaces the SHA256SUMS file of the provider version and its signature
+	ReplaceProviderSha256Sums(ctx context.Context, namespace, name, version string, sums, signature []byte) error
+}
+
+// ProviderSha256Sums returns the SHA256SUMS file of the provider version, if the version exists and isn't deleted
+func (s *ObjectStorage) ProviderSha256Sums(ctx context.Context, namespace, name, version string) ([]byte, error) {
+	shasumPath, err := s.providerShasumPath(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.backend.Download(ctx, shasumPath)
+}
+
+// ReplaceProviderSha256Sums overwrites the SHA256SUMS file and its signature.
+// The Sigstore bundle of the previous SHA256SUMS file is deleted, as it can't be valid for the new one.
+func (s *ObjectStorage) ReplaceProviderSha256Sums(ctx context.Context, namespace, name, version string, sums, signature []byte) error {
+	shasumPath, err := s.providerShasumPath(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	ctx = s.tagged(ctx, namespace, name, version)
+
+	if err := s.upload(ctx, shasumPath, bytes.NewReader(sums), true); err != nil {
+		return fmt.Errorf("failed to upload SHA256SUMS: %w", err)
+	}
+	if err := s.upload(ctx, shasumPath+".sig", bytes.NewReader(signature), true); err != nil {
+		return fmt.Errorf("failed to upload SHA256SUMS signature: %w", err)
+	}
+
+	bundle := attestationPath(shasumPath)
+	if exists, err := s.backend.Exists(ctx, bundle); err != nil {
+		return err
+	} else if exists {
+		if err := s.backend.Delete(ctx, bundle); err != nil {
+			return fmt.Errorf("failed to delete Sigstore bundle: %w", err)
+		}
+		slog.Warn("deleted the Sigstore bundle of the replaced SHA256SUMS file", slog.String("key", bundle))
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_ReplaceProviderSha256Sums(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	var providerErr *core.ProviderError
+	_, err := s.ProviderSha256Sums(ctx, "acme", "random", "2.0.0")
+	assert.ErrorAs(err, &providerErr)
+
+	const sumsKey = "providers/acme/random/terraform-provider-random_2.0.0_SHA256SUMS"
+	assert.NoError(s.backend.Upload(ctx, "providers/acme/random/terraform-provider-random_2.0.0_linux_amd64.zip", strings.NewReader("linux")))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS", strings.NewReader("linux")))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS.sig", strings.NewReader("sig")))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS.sigstore.json", strings.NewReader("{}")))
+
+	sums, err := s.ProviderSha256Sums(ctx, "acme", "random", "2.0.0")
+	assert.NoError(err)
+	assert.Equal("linux", string(sums))
+
+	assert.NoError(s.ReplaceProviderSha256Sums(ctx, "acme", "random", "2.0.0", []byte("linux darwin"), []byte("new sig")))
+	sums, err = s.ProviderSha256Sums(ctx, "acme", "random", "2.0.0")
+	assert.NoError(err)
+	assert.Equal("linux darwin", string(sums))
+	signature, err := s.backend.Download(ctx, sumsKey+".sig")
+	assert.NoError(err)
+	assert.Equal("new sig", string(signature))
+
+	// The Sigstore bundle of the replaced SHA256SUMS file is invalid
+	exists, err := s.backend.Exists(ctx, sumsKey+".sigstore.json")
+	assert.NoError(err)
+	assert.False(exists)
+
+	// Deleted versions can't be backfilled
+	assert.NoError(s.DeleteProvider(ctx, "acme", "random", "2.0.0"))
+	assert.ErrorAs(s.ReplaceProviderSha256Sums(ctx, "acme", "random", "2.0.0", []byte("linux"), []byte("sig")), &providerErr)
+}
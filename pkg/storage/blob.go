@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	// Register the drivers, which are selected with the scheme of the storage URL
+	_ "gocloud.dev/blob/azureblob"
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/memblob"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+// BlobStorage is a Backend implementation backed by any gocloud.dev/blob driver, e.g. s3://, gs://, azblob://, file://, or mem://.
+// NewBlobStorage wraps it into an ObjectStorage, which implements module.Storage, provider.Storage, and mirror.Storage
+type BlobStorage struct {
+	bucket                *blob.Bucket
+	prefix                string
+	moduleArchiveFormat   string
+	convertModuleArchives bool
+	signedURLExpiry       time.Duration
+	baseURL               string
+	decorators            []Decorator
+	objectOptions         []ObjectStorageOption
+}
+
+// Exists checks if an object with the key exists in the bucket
+func (s *BlobStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return s.bucket.Exists(ctx, key)
+}
+
+// Download reads the object from the bucket
+func (s *BlobStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.bucket.ReadAll(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	return data, nil
+}
+
+func (s *BlobStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := s.bucket.NewReader(ctx, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	return r, nil
+}
+
+// Upload writes the object into the bucket
+func (s *BlobStorage) Upload(ctx context.Context, key string, reader io.Reader) error {
+	// The writer detects the content type, which some drivers require
+	w, err := s.bucket.NewWriter(ctx, key, nil)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the object from the bucket
+func (s *BlobStorage) Delete(ctx context.Context, key string) error {
+	if err := s.bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// List returns all objects in the bucket with the given prefix
+func (s *BlobStorage) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	it := s.bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		obj, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, Object{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.ModTime,
+		})
+	}
+
+	return objects, nil
+}
+
+// PresignedURL returns a signed URL to download the object.
+// The unsigned URL below the base URL is returned if the driver doesn't support signing URLs, e.g. mem:// or file://
+func (s *BlobStorage) PresignedURL(ctx context.Context, key string) (string, error) {
+	url, err := s.bucket.SignedURL(ctx, key, &blob.SignedURLOptions{Expiry: s.signedURLExpiry})
+	if gcerrors.Code(err) == gcerrors.Unimplemented {
+		if s.baseURL == "" {
+			return "", fmt.Errorf("the storage driver doesn't support signed URLs and no base URL is configured: %w", err)
+		}
+		return s.GetDownloadUrl(ctx, key)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to sign URL: %w", err)
+	}
+
+	return url, nil
+}
+
+// GetDownloadUrl returns the URL below the base URL, as the endpoint of the bucket isn't known for arbitrary drivers
+func (s *BlobStorage) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	if s.baseURL == "" {
+		return "", errors.New("the download proxy requires a base URL with the blob storage")
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(s.baseURL, "/"), url), nil
+}
+
+// BlobStorageOption provides additional options for the BlobStorage.
+type BlobStorageOption func(*BlobStorage)
+
+// WithBlobStoragePrefix configures the blob storage to work under a given prefix.
+func WithBlobStoragePrefix(prefix string) BlobStorageOption {
+	return func(s *BlobStorage) {
+		s.prefix = prefix
+	}
+}
+
+// WithBlobStorageArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
+func WithBlobStorageArchiveFormat(archiveFormat string) BlobStorageOption {
+	return func(s *BlobStorage) {
+		s.moduleArchiveFormat = archiveFormat
+	}
+}
+
+// WithBlobStorageArchiveConversion stores all uploaded module archives in the configured format, archives in another format are repackaged
+func WithBlobStorageArchiveConversion(enabled bool) BlobStorageOption {
+	return func(s *BlobStorage) {
+		s.convertModuleArchives = enabled
+	}
+}
+
+// WithBlobStorageSignedUrlExpiry configures the duration until the signed url expires
+func WithBlobStorageSignedUrlExpiry(t time.Duration) BlobStorageOption {
+	return func(s *BlobStorage) {
+		s.signedURLExpiry = t
+	}
+}
+
+// WithBlobStorageBaseURL configures the URL the objects are served from, e.g. by a web server in front of a file:// bucket.
+// It is used for drivers without support for signed URLs and by the download proxy.
+func WithBlobStorageBaseURL(baseURL string) BlobStorageOption {
+	return func(s *BlobStorage) {
+		s.baseURL = baseURL
+	}
+}
+
+// WithBlobStorageDecorators wraps the blob backend with the given decorators.
+func WithBlobStorageDecorators(decorators ...Decorator) BlobStorageOption {
+	return func(s *BlobStorage) {
+		s.decorators = append(s.decorators, decorators...)
+	}
+}
+
+// WithBlobStorageObjectOptions applies the options to the ObjectStorage of the blob storage, e.g. quotas or the admission controller
+func WithBlobStorageObjectOptions(options ...ObjectStorageOption) BlobStorageOption {
+	return func(s *BlobStorage) {
+		s.objectOptions = append(s.objectOptions, options...)
+	}
+}
+
+// NewBlobBackend opens the bucket of the URL as a plain Backend, e.g. to use it as a replication target.
+func NewBlobBackend(ctx context.Context, url string) (Backend, error) {
+	bucket, err := blob.OpenBucket(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bucket: %w", err)
+	}
+
+	return &BlobStorage{bucket: bucket}, nil
+}
+
+// NewBlobStorage returns a fully initialized storage for the bucket URL, see https://gocloud.dev/howto/blob/ for the supported URLs.
+func NewBlobStorage(ctx context.Context, url string, options ...BlobStorageOption) (Storage, error) {
+	bucket, err := blob.OpenBucket(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bucket: %w", err)
+	}
+
+	s := &BlobStorage{
+		bucket: bucket,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	objectOptions := []ObjectStorageOption{
+		WithObjectStoragePrefix(s.prefix),
+		WithObjectStorageArchiveFormat(s.moduleArchiveFormat),
+		WithObjectStorageArchiveConversion(s.convertModuleArchives),
+		WithObjectStorageDecorators(s.decorators...),
+	}
+	return NewObjectStorage(s, append(objectOptions, s.objectOptions...)...), nil
+}
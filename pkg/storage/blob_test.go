@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestBlobStorage_Modules(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	s, err := NewBlobStorage(ctx, "mem://",
+		WithBlobStoragePrefix("registry"),
+		WithBlobStorageBaseURL("https://downloads.example.com/"),
+	)
+	assert.NoError(err)
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.ErrorIs(err, module.ErrModuleAlreadyExists)
+
+	modules, err := s.ListModuleVersions(ctx, "acme", "vpc", "aws")
+	assert.NoError(err)
+	assert.Len(modules, 1)
+
+	m, err := s.GetModule(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal("https://downloads.example.com/registry/modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", m.DownloadURL)
+}
+
+func TestBlobStorage_PresignedURLWithoutBaseURL(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewBlobStorage(ctx, "mem://")
+	assertion.NoError(t, err)
+
+	// memblob doesn't support signed URLs
+	_, err = s.(*ObjectStorage).backend.PresignedURL(ctx, "key")
+	assertion.ErrorContains(t, err, "doesn't support signed URLs")
+}
+
+func TestBlobStorage_Backend(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	s, err := NewBlobStorage(ctx, "mem://")
+	assert.NoError(err)
+	b := s.(*ObjectStorage).backend
+
+	exists, err := b.Exists(ctx, "a/b")
+	assert.NoError(err)
+	assert.False(exists)
+
+	_, err = b.Download(ctx, "a/b")
+	assert.Error(err)
+
+	assert.NoError(b.Upload(ctx, "a/b", strings.NewReader("content")))
+	assert.NoError(b.Upload(ctx, "c/d", strings.NewReader("other")))
+
+	data, err := b.Download(ctx, "a/b")
+	assert.NoError(err)
+	assert.Equal("content", string(data))
+
+	objects, err := b.List(ctx, "a/")
+	assert.NoError(err)
+	assert.Len(objects, 1)
+	assert.Equal("a/b", objects[0].Key)
+	assert.Equal(int64(7), objects[0].Size)
+}
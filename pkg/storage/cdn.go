@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLSigner signs the URLs of a CDN, so that they can only be used until they expire
+type URLSigner interface {
+	SignURL(url string, expires time.Time) (string, error)
+}
+
+// CloudFrontSigner signs URLs of Amazon CloudFront with a canned policy
+type CloudFrontSigner struct {
+	keyPairID string
+	key       *rsa.PrivateKey
+}
+
+// NewCloudFrontSigner returns a URLSigner of the public key with the ID, whose PEM-encoded RSA private key is given
+func NewCloudFrontSigner(keyPairID string, privateKey []byte) (*CloudFrontSigner, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("the CloudFront private key isn't PEM-encoded")
+	}
+
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = parsed
+	} else if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		var ok bool
+		if key, ok = parsed.(*rsa.PrivateKey); !ok {
+			return nil, errors.New("the CloudFront private key isn't an RSA key")
+		}
+	} else {
+		return nil, fmt.Errorf("failed to parse the CloudFront private key: %w", err)
+	}
+
+	return &CloudFrontSigner{keyPairID: keyPairID, key: key}, nil
+}
+
+func (s *CloudFrontSigner) SignURL(url string, expires time.Time) (string, error) {
+	epoch := strconv.FormatInt(expires.Unix(), 10)
+	policy := fmt.Sprintf(`{"Statement":[{"Resource":"%s","Condition":{"DateLessThan":{"AWS:EpochTime":%s}}}]}`, url, epoch)
+
+	digest := sha1.Sum([]byte(policy))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA1, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the CloudFront URL: %w", err)
+	}
+
+	// CloudFront uses a variant of base64 without the characters which are invalid in query parameters
+	encoded := strings.NewReplacer("+", "-", "=", "_", "/", "~").Replace(base64.StdEncoding.EncodeToString(signature))
+	return fmt.Sprintf("%s%sExpires=%s&Signature=%s&Key-Pair-Id=%s", url, querySeparator(url), epoch, encoded, s.keyPairID), nil
+}
+
+// CloudCDNSigner signs URLs of Google Cloud CDN
+type CloudCDNSigner struct {
+	keyName string
+	key     []byte
+}
+
+// NewCloudCDNSigner returns a URLSigner of the signed URL key with the name, whose value is base64url-encoded like generated by gcloud
+func NewCloudCDNSigner(keyName string, encodedKey []byte) (*CloudCDNSigner, error) {
+	key, err := base64.URLEncoding.DecodeString(strings.TrimSpace(string(encodedKey)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the Cloud CDN key: %w", err)
+	}
+	return &CloudCDNSigner{keyName: keyName, key: key}, nil
+}
+
+func (s *CloudCDNSigner) SignURL(url string, expires time.Time) (string, error) {
+	url = fmt.Sprintf("%s%sExpires=%d&KeyName=%s", url, querySeparator(url), expires.Unix(), s.keyName)
+
+	mac := hmac.New(sha1.New, s.key)
+	mac.Write([]byte(url))
+	return url + "&Signature=" + base64.URLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func querySeparator(url string) string {
+	if strings.Contains(url, "?") {
+		return "&"
+	}
+	return "?"
+}
+
+// cdnBackend serves the provider downloads from a CDN in front of the bucket
+type cdnBackend struct {
+	next    Backend
+	baseURL string
+	signer  URLSigner
+	expiry  time.Duration
+	modules bool
+}
+
+// CDNDecorator returns a Decorator serving the downloads of providers from the CDN at the base URL instead of presigned URLs of the bucket,
+// so that large provider archives are served from edge caches. The origin of the CDN has to be the bucket, as the keys are appended to the base URL.
+// The URLs are signed by the signer if it isn't nil, and are valid for the expiry.
+// The downloads of modules are served from the CDN as well if modules is true, e.g. so that all downloads pass through the web ACL of the CDN.
+func CDNDecorator(baseURL string, signer URLSigner, expiry time.Duration, modules bool) Decorator {
+	return func(next Backend) Backend {
+		return &cdnBackend{
+			next:    next,
+			baseURL: strings.TrimSuffix(baseURL, "/"),
+			signer:  signer,
+			expiry:  expiry,
+			modules: modules,
+		}
+	}
+}
+
+// isProviderKey returns true for the objects of internal and mirrored providers, which is decided by the first top-level directory of the layout
+func isProviderKey(key string) bool {
+	for _, part := range strings.Split(key, "/") {
+		switch part {
+		case string(internalProviderType), "mirror":
+			return true
+		case string(internalModuleType):
+			return false
+		}
+	}
+	return false
+}
+
+func (b *cdnBackend) Exists(ctx context.Context, key string) (bool, error) {
+	return b.next.Exists(ctx, key)
+}
+
+func (b *cdnBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	return b.next.Download(ctx, key)
+}
+
+func (b *cdnBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.next.Open(ctx, key)
+}
+
+func (b *cdnBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	return b.next.Upload(ctx, key, reader)
+}
+
+func (b *cdnBackend) Delete(ctx context.Context, key string) error {
+	return b.next.Delete(ctx, key)
+}
+
+func (b *cdnBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	return b.next.List(ctx, prefix)
+}
+
+func (b *cdnBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	if !b.modules && !isProviderKey(key) {
+		return b.next.PresignedURL(ctx, key)
+	}
+
+	url := fmt.Sprintf("%s/%s", b.baseURL, key)
+	if b.signer == nil {
+		return url, nil
+	}
+	return b.signer.SignURL(url, time.Now().Add(b.expiry))
+}
+
+// GetDownloadUrl isn't rewritten, as the download proxy fetches the objects from the bucket itself
+func (b *cdnBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.next.GetDownloadUrl(ctx, url)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestCDNDecorator(t *testing.T) {
+	ctx := context.Background()
+	b := CDNDecorator("https://cdn.example.com/", nil, time.Minute, false)(newMockBackend())
+
+	u, err := b.PresignedURL(ctx, "prefix/providers/hashicorp/random/terraform-provider-random_2.0.0_linux_amd64.zip")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "https://cdn.example.com/prefix/providers/hashicorp/random/terraform-provider-random_2.0.0_linux_amd64.zip", u)
+
+	u, err = b.PresignedURL(ctx, "mirror/providers/registry.terraform.io/hashicorp/random/terraform-provider-random_2.0.0_SHA256SUMS")
+	assertion.NoError(t, err)
+	assertion.True(t, strings.HasPrefix(u, "https://cdn.example.com/mirror/providers/"))
+
+	// Modules are still served from the bucket
+	u, err = b.PresignedURL(ctx, "modules/providers/vpc/aws/providers-vpc-aws-1.0.0.tar.gz")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "modules/providers/vpc/aws/providers-vpc-aws-1.0.0.tar.gz?presigned=true", u)
+
+	// Unless all downloads are served from the CDN
+	b = CDNDecorator("https://cdn.example.com", nil, time.Minute, true)(newMockBackend())
+	u, err = b.PresignedURL(ctx, "modules/providers/vpc/aws/providers-vpc-aws-1.0.0.tar.gz")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "https://cdn.example.com/modules/providers/vpc/aws/providers-vpc-aws-1.0.0.tar.gz", u)
+}
+
+func TestCloudFrontSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assertion.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	_, err = NewCloudFrontSigner("K2JCJMDEHXQW5F", []byte("invalid"))
+	assertion.Error(t, err)
+	signer, err := NewCloudFrontSigner("K2JCJMDEHXQW5F", pemKey)
+	assertion.NoError(t, err)
+
+	expires := time.Unix(1700000000, 0)
+	signed, err := signer.SignURL("https://d111111abcdef8.cloudfront.net/providers/archive.zip", expires)
+	assertion.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	assertion.NoError(t, err)
+	assertion.Equal(t, "1700000000", u.Query().Get("Expires"))
+	assertion.Equal(t, "K2JCJMDEHXQW5F", u.Query().Get("Key-Pair-Id"))
+
+	signature, err := base64.StdEncoding.DecodeString(strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(u.Query().Get("Signature")))
+	assertion.NoError(t, err)
+	digest := sha1.Sum([]byte(`{"Statement":[{"Resource":"https://d111111abcdef8.cloudfront.net/providers/archive.zip","Condition":{"DateLessThan":{"AWS:EpochTime":1700000000}}}]}`))
+	assertion.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, digest[:], signature))
+}
+
+func TestCloudCDNSigner(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	_, err := NewCloudCDNSigner("registry-key", []byte("!invalid"))
+	assertion.Error(t, err)
+	signer, err := NewCloudCDNSigner("registry-key", []byte(base64.URLEncoding.EncodeToString(key)+"\n"))
+	assertion.NoError(t, err)
+
+	signed, err := signer.SignURL("https://cdn.example.com/providers/archive.zip", time.Unix(1700000000, 0))
+	assertion.NoError(t, err)
+
+	unsigned := "https://cdn.example.com/providers/archive.zip?Expires=1700000000&KeyName=registry-key"
+	mac := hmac.New(sha1.New, key)
+	mac.Write([]byte(unsigned))
+	assertion.Equal(t, unsigned+"&Signature="+base64.URLEncoding.EncodeToString(mac.Sum(nil)), signed)
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"time"
+)
+
+// checkPrefix is the prefix of the probe objects written by Check, which are deleted right away
+const checkPrefix = ".boring-registry-check"
+
+// ErrPermissionMissing is returned by Check for every operation the storage backend doesn't allow
+var ErrPermissionMissing = errors.New("missing storage permission")
+
+// Checker validates the access to the storage backend
+type Checker interface {
+	// Check returns an error wrapping ErrPermissionMissing for every operation which fails.
+	// The put and delete permissions are only checked with write, as they're not required in read-only mode.
+	Check(ctx context.Context, write bool) error
+}
+
+// Check validates that the bucket exists and objects can be listed, downloaded, presigned, uploaded, and deleted,
+// so that a misconfigured storage fails on startup instead of on the first request.
+// The write permissions are checked with a probe object, which is deleted afterwards.
+func (s *ObjectStorage) Check(ctx context.Context, write bool) error {
+	var errs []error
+	missing := func(permission string, err error) {
+		errs = append(errs, fmt.Errorf("%w %s: %w", ErrPermissionMissing, permission, err))
+	}
+
+	objects, err := s.backend.List(ctx, s.prefix)
+	if err != nil {
+		missing("to list objects, check that the bucket exists", err)
+	}
+
+	key := path.Join(s.prefix, checkPrefix, strconv.FormatInt(time.Now().UnixNano(), 10))
+	readable := ""
+	if write {
+		if err := s.backend.Upload(ctx, key, bytes.NewReader([]byte("ok"))); err != nil {
+			missing("to upload objects", err)
+		} else {
+			readable = key
+		}
+	} else if len(objects) > 0 {
+		readable = objects[0].Key
+	}
+
+	// The download can only be checked with an existing object
+	if readable != "" {
+		if _, err := s.backend.Download(ctx, readable); err != nil {
+			missing("to download objects", err)
+		}
+	}
+	if _, err := s.backend.PresignedURL(ctx, key); err != nil {
+		missing("to presign download URLs", err)
+	}
+
+	if readable == key {
+		if err := s.backend.Delete(ctx, key); err != nil {
+			missing("to delete objects", err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	slog.Debug("checked storage", slog.String("prefix", s.prefix), slog.Bool("write", write))
+	return nil
+}
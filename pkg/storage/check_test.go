@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_Check(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	backend := newMemoryBackend()
+	s := NewObjectStorage(backend)
+	assert.NoError(s.Check(ctx, true))
+	objects, err := backend.List(ctx, "")
+	assert.NoError(err)
+	assert.Empty(objects, "the probe object is deleted")
+
+	assert.NoError(backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", strings.NewReader("module")))
+	s = NewObjectStorage(&failingBackend{backend})
+	err = s.Check(ctx, true)
+	assert.ErrorIs(err, ErrPermissionMissing)
+	assert.ErrorContains(err, "to upload objects: region unavailable")
+
+	// The upload isn't required in read-only mode
+	assert.NoError(s.Check(ctx, false))
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+// UploadModuleChecksum records the checksum of the module archive in the SHA256SUMS manifest of the module.
+// The checksum is computed by the publisher before the upload, so that the archive is only recorded if the stored checksum matches.
+func (s *ObjectStorage) UploadModuleChecksum(ctx context.Context, namespace, name, provider, version, checksum string) error {
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+
+	stored, err := s.GetModuleChecksum(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	if err := module.VerifyChecksum(checksum, stored); err != nil {
+		return err
+	}
+
+	return s.setModuleChecksum(ctx, namespace, name, provider, path.Base(key), stored)
+}
+
+// moduleChecksums returns the checksums of the SHA256SUMS manifest of the module by archive file name.
+// A module without manifest has no checksums.
+func (s *ObjectStorage) moduleChecksums(ctx context.Context, namespace, name, provider string) (map[string]string, error) {
+	key := moduleChecksumsPath(s.prefix, namespace, name, provider)
+
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return map[string]string{}, nil
+	}
+
+	data, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := parseModuleChecksums(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum manifest %s: %w", key, err)
+	}
+	return checksums, nil
+}
+
+// setModuleChecksum adds or replaces the checksum of an archive in the SHA256SUMS manifest of the module
+func (s *ObjectStorage) setModuleChecksum(ctx context.Context, namespace, name, provider, file, checksum string) error {
+	checksums, err := s.moduleChecksums(ctx, namespace, name, provider)
+	if err != nil {
+		return err
+	}
+	if checksums[file] == checksum {
+		return nil
+	}
+	checksums[file] = checksum
+
+	ctx = s.tagged(ctx, namespace, name, "")
+	if err := s.backend.Upload(ctx, moduleChecksumsPath(s.prefix, namespace, name, provider), bytes.NewReader(formatModuleChecksums(checksums))); err != nil {
+		return fmt.Errorf("failed to upload checksum manifest: %w", err)
+	}
+	return nil
+}
+
+// updateModuleChecksum replaces the checksum of an archive listed in the SHA256SUMS manifest after the archive has been uploaded again,
+// e.g. when it's republished or uploaded after the deleted version has been purged.
+func (s *ObjectStorage) updateModuleChecksum(ctx context.Context, namespace, name, provider, version string) error {
+	checksums, err := s.moduleChecksums(ctx, namespace, name, provider)
+	if err != nil {
+		return err
+	}
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	file := path.Base(key)
+	if _, ok := checksums[file]; !ok {
+		return nil
+	}
+
+	checksum, err := s.GetModuleChecksum(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	return s.setModuleChecksum(ctx, namespace, name, provider, file, checksum)
+}
+
+// verifyModuleChecksum compares the stored checksum of the module archive with the SHA256SUMS manifest of the module.
+// Archives which aren't listed in the manifest, e.g. uploaded by an older version of the CLI, aren't verified.
+func (s *ObjectStorage) verifyModuleChecksum(ctx context.Context, namespace, name, provider, key string) error {
+	checksums, err := s.moduleChecksums(ctx, namespace, name, provider)
+	if err != nil {
+		return err
+	}
+	expected, ok := checksums[path.Base(key)]
+	if !ok {
+		return nil
+	}
+
+	checksum, err := s.archiveChecksum(ctx, key)
+	if err != nil {
+		return err
+	}
+	if checksum != expected {
+		return fmt.Errorf("%w: %s has checksum %s instead of %s", module.ErrModuleCorrupted, key, module.FormatChecksum(checksum), module.FormatChecksum(expected))
+	}
+	return nil
+}
+
+// parseModuleChecksums parses a manifest in the format of sha256sum with one "<checksum>  <file>" line per archive
+func parseModuleChecksums(data []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line contains %d parts instead of 2", len(fields))
+		}
+		checksum := strings.ToLower(fields[0])
+		if b, err := hex.DecodeString(checksum); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%w: %s", module.ErrInvalidChecksum, fields[0])
+		}
+		// sha256sum marks files read in binary mode with a leading asterisk
+		checksums[strings.TrimPrefix(fields[1], "*")] = checksum
+	}
+	return checksums, scanner.Err()
+}
+
+// formatModuleChecksums returns the manifest sorted by file name, so that it can be checked with sha256sum -c
+func formatModuleChecksums(checksums map[string]string) []byte {
+	files := make([]string, 0, len(checksums))
+	for file := range checksums {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	var buf bytes.Buffer
+	for _, file := range files {
+		fmt.Fprintf(&buf, "%s  %s\n", checksums[file], file)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_UploadModuleChecksum(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	backend := newMockBackend()
+	s := NewObjectStorage(backend)
+
+	// sha256sum of "archive" and "replaced"
+	archiveChecksum := "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3"
+	replacedChecksum := "6c1aa50442a93e42c0eb2907cf4e017cd19547891fa190f3ea473582b0479290"
+	manifest := "modules/hashicorp/consul/aws/SHA256SUMS"
+
+	err := s.UploadModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.0.0", archiveChecksum)
+	assert.ErrorIs(err, module.ErrModuleNotFound)
+
+	for _, version := range []string{"1.1.0", "1.0.0"} {
+		_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", version, strings.NewReader("archive"))
+		assert.NoError(err)
+		assert.NoError(s.UploadModuleChecksum(ctx, "hashicorp", "consul", "aws", version, archiveChecksum))
+	}
+	assert.Equal(archiveChecksum+"  hashicorp-consul-aws-1.0.0.tar.gz\n"+archiveChecksum+"  hashicorp-consul-aws-1.1.0.tar.gz\n", string(backend.objects[manifest]))
+
+	// The checksum computed by the publisher has to match the stored archive
+	err = s.UploadModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.0.0", replacedChecksum)
+	assert.ErrorIs(err, module.ErrChecksumMismatch)
+	err = s.UploadModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.0.0", "invalid")
+	assert.ErrorIs(err, module.ErrInvalidChecksum)
+
+	// Replacing the archive updates the manifest
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("replaced"))
+	assert.NoError(err)
+	assert.Contains(string(backend.objects[manifest]), replacedChecksum+"  hashicorp-consul-aws-1.0.0.tar.gz\n")
+
+	// Archives which don't match the manifest aren't served
+	backend.objects["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.1.0.tar.gz.sha256"] = []byte(replacedChecksum)
+	_, err = s.GetModule(ctx, "hashicorp", "consul", "aws", "1.1.0")
+	assert.ErrorIs(err, module.ErrModuleCorrupted)
+	_, err = s.GetModule(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assert.NoError(err)
+
+	// Archives which aren't listed in the manifest aren't verified
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.2.0", strings.NewReader("archive"))
+	assert.NoError(err)
+	assert.NotContains(string(backend.objects[manifest]), "1.2.0")
+}
+
+func TestParseModuleChecksums(t *testing.T) {
+	assert := assertion.New(t)
+
+	checksums, err := parseModuleChecksums([]byte("0EB3E36BFB24DCD9BB1D1BECE1531216B59539A8FDE17EE80224AF0653C92AA3 *a.tar.gz\n\n"))
+	assert.NoError(err)
+	assert.Equal(map[string]string{"a.tar.gz": "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3"}, checksums)
+
+	_, err = parseModuleChecksums([]byte("0eb3e36b  a.tar.gz\n"))
+	assert.ErrorIs(err, module.ErrInvalidChecksum)
+
+	_, err = parseModuleChecksums([]byte("0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3\n"))
+	assert.ErrorContains(err, "1 parts instead of 2")
+}
@@ -34,6 +34,7 @@ func (s *Collection) Add(provider *core.Provider) {
 			Namespace: provider.Namespace,
 			Name:      provider.Name,
 			Version:   provider.Version,
+			Protocols: provider.Protocols,
 		}
 	}
 
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+type ifNotExistsKey struct{}
+
+// withIfNotExists makes the upload fail with core.ErrObjectAlreadyExists if the object exists already.
+// Like the object tags, the condition is passed through the decorators to the Backend, which evaluates it atomically.
+func withIfNotExists(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ifNotExistsKey{}, true)
+}
+
+// ifNotExists reports whether the upload must not replace an existing object
+func ifNotExists(ctx context.Context) bool {
+	v, _ := ctx.Value(ifNotExistsKey{}).(bool)
+	return v
+}
+
+// errAlreadyExists is returned by Backends rejecting a conditional upload
+func errAlreadyExists(key string) error {
+	return fmt.Errorf("failed to upload key %s: %w", key, core.ErrObjectAlreadyExists)
+}
+
+// createOnly prepares the upload of an object which must not exist yet.
+// Backends supporting conditional writes reject the upload atomically, all others are checked upfront,
+// which concurrent uploads can race past.
+func (s *ObjectStorage) createOnly(ctx context.Context, key string) (context.Context, error) {
+	if s.conditionalWrites {
+		return withIfNotExists(ctx), nil
+	}
+
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return ctx, err
+	} else if exists {
+		return ctx, errAlreadyExists(key)
+	}
+	return ctx, nil
+}
+
+// conditional requests a conditional write if the Backend supports it.
+// The other checks of uploads are performed nevertheless, they reject most uploads of existing versions early.
+func (s *ObjectStorage) conditional(ctx context.Context) context.Context {
+	if !s.conditionalWrites {
+		return ctx
+	}
+	return withIfNotExists(ctx)
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// racingBackend hides the objects from existence checks while racing, like a concurrent upload which isn't finished yet
+type racingBackend struct {
+	*memoryBackend
+	racing bool
+}
+
+func (b *racingBackend) Exists(ctx context.Context, key string) (bool, error) {
+	if b.racing {
+		return false, nil
+	}
+	return b.memoryBackend.Exists(ctx, key)
+}
+
+func TestObjectStorage_ConditionalWrites(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	backend := &racingBackend{memoryBackend: newMemoryBackend()}
+	s := NewObjectStorage(backend, WithObjectStorageConditionalWrites(true))
+
+	_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("first"))
+	assert.NoError(err)
+	backend.racing = true
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("second"))
+	assert.ErrorIs(err, module.ErrModuleAlreadyExists)
+
+	data, err := backend.Download(ctx, "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz")
+	assert.NoError(err)
+	assert.Equal("first", string(data))
+
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "hashicorp", "dns", "terraform-provider-dns_1.0.0_SHA256SUMS", strings.NewReader("first")))
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "dns", "terraform-provider-dns_1.0.0_SHA256SUMS", strings.NewReader("second"))
+	assert.ErrorIs(err, core.ErrObjectAlreadyExists)
+}
+
+func TestObjectStorage_WithoutConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	backend := &racingBackend{memoryBackend: newMemoryBackend(), racing: true}
+	s := NewObjectStorage(backend)
+
+	// Without conditional writes, uploads racing past the existence check replace the object
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "dns", "terraform-provider-dns_1.0.0_SHA256SUMS", strings.NewReader("first")))
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "dns", "terraform-provider-dns_1.0.0_SHA256SUMS", strings.NewReader("second")))
+}
@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	operationExists         = "exists"
+	operationDownload       = "download"
+	operationOpen           = "open"
+	operationUpload         = "upload"
+	operationDelete         = "delete"
+	operationList           = "list"
+	operationPresignedURL   = "presigned_url"
+	operationGetDownloadUrl = "download_url"
+)
+
+// MetricsDecorator counts and times all operations against the Backend
+func MetricsDecorator(metrics *o11y.StorageMetrics) Decorator {
+	return func(next Backend) Backend {
+		return &metricsBackend{
+			next:    next,
+			metrics: metrics,
+		}
+	}
+}
+
+type metricsBackend struct {
+	next    Backend
+	metrics *o11y.StorageMetrics
+}
+
+func (b *metricsBackend) observe(operation string, begin time.Time, err error) {
+	result := o11y.ResultSuccess
+	if err != nil {
+		result = o11y.ResultError
+	}
+
+	b.metrics.Operations.With(prometheus.Labels{
+		o11y.OperationLabel: operation,
+		o11y.ResultLabel:    result,
+	}).Inc()
+	b.metrics.OperationDuration.With(prometheus.Labels{
+		o11y.OperationLabel: operation,
+	}).Observe(time.Since(begin).Seconds())
+}
+
+func (b *metricsBackend) Exists(ctx context.Context, key string) (exists bool, err error) {
+	defer func(begin time.Time) { b.observe(operationExists, begin, err) }(time.Now())
+	return b.next.Exists(ctx, key)
+}
+
+func (b *metricsBackend) Download(ctx context.Context, key string) (data []byte, err error) {
+	defer func(begin time.Time) { b.observe(operationDownload, begin, err) }(time.Now())
+	return b.next.Download(ctx, key)
+}
+
+func (b *metricsBackend) Open(ctx context.Context, key string) (r io.ReadCloser, err error) {
+	defer func(begin time.Time) { b.observe(operationOpen, begin, err) }(time.Now())
+	return b.next.Open(ctx, key)
+}
+
+func (b *metricsBackend) Upload(ctx context.Context, key string, reader io.Reader) (err error) {
+	defer func(begin time.Time) { b.observe(operationUpload, begin, err) }(time.Now())
+	return b.next.Upload(ctx, key, reader)
+}
+
+func (b *metricsBackend) Delete(ctx context.Context, key string) (err error) {
+	defer func(begin time.Time) { b.observe(operationDelete, begin, err) }(time.Now())
+	return b.next.Delete(ctx, key)
+}
+
+func (b *metricsBackend) List(ctx context.Context, prefix string) (objects []Object, err error) {
+	defer func(begin time.Time) { b.observe(operationList, begin, err) }(time.Now())
+	return b.next.List(ctx, prefix)
+}
+
+func (b *metricsBackend) PresignedURL(ctx context.Context, key string) (url string, err error) {
+	defer func(begin time.Time) { b.observe(operationPresignedURL, begin, err) }(time.Now())
+	return b.next.PresignedURL(ctx, key)
+}
+
+func (b *metricsBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.next.GetDownloadUrl(ctx, url)
+}
+
+// TracingDecorator logs every operation against the Backend with its duration on the debug level
+func TracingDecorator(logger *slog.Logger) Decorator {
+	return func(next Backend) Backend {
+		return &tracingBackend{
+			next:   next,
+			logger: logger,
+		}
+	}
+}
+
+type tracingBackend struct {
+	next   Backend
+	logger *slog.Logger
+}
+
+func (b *tracingBackend) trace(ctx context.Context, operation, key string, begin time.Time, err error) {
+	attrs := []slog.Attr{
+		slog.String("operation", operation),
+		slog.String("key", key),
+		slog.Duration("took", time.Since(begin)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("err", err.Error()))
+	}
+	b.logger.LogAttrs(ctx, slog.LevelDebug, "storage operation", attrs...)
+}
+
+func (b *tracingBackend) Exists(ctx context.Context, key string) (exists bool, err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationExists, key, begin, err) }(time.Now())
+	return b.next.Exists(ctx, key)
+}
+
+func (b *tracingBackend) Download(ctx context.Context, key string) (data []byte, err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationDownload, key, begin, err) }(time.Now())
+	return b.next.Download(ctx, key)
+}
+
+func (b *tracingBackend) Open(ctx context.Context, key string) (r io.ReadCloser, err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationOpen, key, begin, err) }(time.Now())
+	return b.next.Open(ctx, key)
+}
+
+func (b *tracingBackend) Upload(ctx context.Context, key string, reader io.Reader) (err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationUpload, key, begin, err) }(time.Now())
+	return b.next.Upload(ctx, key, reader)
+}
+
+func (b *tracingBackend) Delete(ctx context.Context, key string) (err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationDelete, key, begin, err) }(time.Now())
+	return b.next.Delete(ctx, key)
+}
+
+func (b *tracingBackend) List(ctx context.Context, prefix string) (objects []Object, err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationList, prefix, begin, err) }(time.Now())
+	return b.next.List(ctx, prefix)
+}
+
+func (b *tracingBackend) PresignedURL(ctx context.Context, key string) (url string, err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationPresignedURL, key, begin, err) }(time.Now())
+	return b.next.PresignedURL(ctx, key)
+}
+
+func (b *tracingBackend) GetDownloadUrl(ctx context.Context, url string) (downloadUrl string, err error) {
+	defer func(begin time.Time) { b.trace(ctx, operationGetDownloadUrl, url, begin, err) }(time.Now())
+	return b.next.GetDownloadUrl(ctx, url)
+}
+
+// maxCachedObjectSize is the size up to which downloaded objects are cached.
+// Larger objects like module and provider archives aren't cached, as they would grow the memory with the archives served.
+const maxCachedObjectSize = 1 << 20
+
+// CacheDecorator caches the results of Exists, Download, and List for the given TTL.
+// Only objects up to maxCachedObjectSize are cached.
+// Uploads and deletes through the decorated Backend invalidate the affected entries immediately,
+// changes made by other processes become visible once the entries expire.
+func CacheDecorator(ttl time.Duration) Decorator {
+	return func(next Backend) Backend {
+		return &cacheBackend{
+			next:      next,
+			ttl:       ttl,
+			now:       time.Now,
+			exists:    make(map[string]cacheEntry[bool]),
+			downloads: make(map[string]cacheEntry[[]byte]),
+			lists:     make(map[string]cacheEntry[[]Object]),
+		}
+	}
+}
+
+type cacheEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+type cacheBackend struct {
+	next Backend
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	exists    map[string]cacheEntry[bool]
+	downloads map[string]cacheEntry[[]byte]
+	lists     map[string]cacheEntry[[]Object]
+}
+
+func cacheGet[T any](b *cacheBackend, entries map[string]cacheEntry[T], key string) (T, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := entries[key]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	if !b.now().Before(entry.expires) {
+		delete(entries, key)
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func cachePut[T any](b *cacheBackend, entries map[string]cacheEntry[T], key string, value T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries[key] = cacheEntry[T]{
+		value:   value,
+		expires: b.now().Add(b.ttl),
+	}
+}
+
+func (b *cacheBackend) invalidate(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.exists, key)
+	delete(b.downloads, key)
+	for prefix := range b.lists {
+		if strings.HasPrefix(key, prefix) {
+			delete(b.lists, prefix)
+		}
+	}
+}
+
+func (b *cacheBackend) Exists(ctx context.Context, key string) (bool, error) {
+	if exists, ok := cacheGet(b, b.exists, key); ok {
+		return exists, nil
+	}
+
+	exists, err := b.next.Exists(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	cachePut(b, b.exists, key, exists)
+	return exists, nil
+}
+
+func (b *cacheBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	if data, ok := cacheGet(b, b.downloads, key); ok {
+		return append([]byte(nil), data...), nil
+	}
+
+	data, err := b.next.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) <= maxCachedObjectSize {
+		cachePut(b, b.downloads, key, append([]byte(nil), data...))
+	}
+	return data, nil
+}
+
+// Open serves cached objects, but doesn't cache the objects it reads, as they might be too large to be kept in memory
+func (b *cacheBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if data, ok := cacheGet(b, b.downloads, key); ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return b.next.Open(ctx, key)
+}
+
+func (b *cacheBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	// Invalidate even if the upload fails, as the object might have been partially written
+	defer b.invalidate(key)
+	return b.next.Upload(ctx, key, reader)
+}
+
+func (b *cacheBackend) Delete(ctx context.Context, key string) error {
+	defer b.invalidate(key)
+	return b.next.Delete(ctx, key)
+}
+
+func (b *cacheBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	if objects, ok := cacheGet(b, b.lists, prefix); ok {
+		return append([]Object(nil), objects...), nil
+	}
+
+	objects, err := b.next.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	cachePut(b, b.lists, prefix, append([]Object(nil), objects...))
+	return objects, nil
+}
+
+func (b *cacheBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	// Presigned URLs are not cached, as they expire independently of the cache
+	return b.next.PresignedURL(ctx, key)
+}
+
+func (b *cacheBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.next.GetDownloadUrl(ctx, url)
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// orderBackend records the order in which decorators are invoked
+type orderBackend struct {
+	Backend
+	name  string
+	order *[]string
+}
+
+func (b *orderBackend) Exists(ctx context.Context, key string) (bool, error) {
+	*b.order = append(*b.order, b.name)
+	return b.Backend.Exists(ctx, key)
+}
+
+func TestDecorate(t *testing.T) {
+	var order []string
+	decorator := func(name string) Decorator {
+		return func(next Backend) Backend {
+			return &orderBackend{Backend: next, name: name, order: &order}
+		}
+	}
+
+	b := Decorate(newMockBackend(), decorator("outer"), decorator("inner"))
+	_, err := b.Exists(context.Background(), "key")
+	assertion.NoError(t, err)
+	assertion.Equal(t, []string{"outer", "inner"}, order)
+}
+
+func TestCacheDecorator(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	cached := CacheDecorator(time.Minute)(backend).(*cacheBackend)
+
+	now := time.Now()
+	cached.now = func() time.Time { return now }
+
+	exists, err := cached.Exists(ctx, "providers/hashicorp/random/file")
+	assertion.NoError(t, err)
+	assertion.False(t, exists)
+
+	_, err = cached.List(ctx, "providers/hashicorp/")
+	assertion.NoError(t, err)
+
+	// Both results are served from the cache
+	_, _ = cached.Exists(ctx, "providers/hashicorp/random/file")
+	_, _ = cached.List(ctx, "providers/hashicorp/")
+	assertion.Equal(t, 1, backend.calls[operationExists])
+	assertion.Equal(t, 1, backend.calls[operationList])
+
+	// The upload invalidates the entry of the key and the listings containing it
+	assertion.NoError(t, cached.Upload(ctx, "providers/hashicorp/random/file", strings.NewReader("content")))
+	exists, err = cached.Exists(ctx, "providers/hashicorp/random/file")
+	assertion.NoError(t, err)
+	assertion.True(t, exists)
+	objects, err := cached.List(ctx, "providers/hashicorp/")
+	assertion.NoError(t, err)
+	assertion.Len(t, objects, 1)
+	assertion.Equal(t, 2, backend.calls[operationExists])
+	assertion.Equal(t, 2, backend.calls[operationList])
+
+	data, err := cached.Download(ctx, "providers/hashicorp/random/file")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "content", string(data))
+	data[0] = 'X' // Modifying the returned data must not modify the cache
+	data, err = cached.Download(ctx, "providers/hashicorp/random/file")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "content", string(data))
+	assertion.Equal(t, 1, backend.calls[operationDownload])
+
+	// Changes by other processes become visible after the entries expire
+	assertion.NoError(t, backend.Upload(ctx, "providers/hashicorp/random/file", strings.NewReader("changed")))
+	data, _ = cached.Download(ctx, "providers/hashicorp/random/file")
+	assertion.Equal(t, "content", string(data))
+
+	now = now.Add(time.Minute)
+	data, err = cached.Download(ctx, "providers/hashicorp/random/file")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "changed", string(data))
+	assertion.Equal(t, 2, backend.calls[operationDownload])
+}
+
+func TestCacheDecorator_LargeObjects(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	cached := CacheDecorator(time.Minute)(backend).(*cacheBackend)
+
+	// Large objects like archives are not kept in memory
+	assertion.NoError(t, backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", bytes.NewReader(make([]byte, maxCachedObjectSize+1))))
+	for range 2 {
+		data, err := cached.Download(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz")
+		assertion.NoError(t, err)
+		assertion.Len(t, data, maxCachedObjectSize+1)
+	}
+	assertion.Equal(t, 2, backend.calls[operationDownload])
+	assertion.Empty(t, cached.downloads)
+}
+
+func TestCacheDecorator_Errors(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	cached := CacheDecorator(time.Minute)(backend)
+
+	// Errors are not cached
+	_, err := cached.Download(ctx, "missing")
+	assertion.Error(t, err)
+	_, err = cached.Download(ctx, "missing")
+	assertion.Error(t, err)
+	assertion.Equal(t, 2, backend.calls[operationDownload])
+
+	// A failed upload still invalidates the entry
+	_, _ = cached.Exists(ctx, "key")
+	assertion.Error(t, cached.Upload(ctx, "key", &failingReader{}))
+	_, _ = cached.Exists(ctx, "key")
+	assertion.Equal(t, 2, backend.calls[operationExists])
+}
+
+type failingReader struct{}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
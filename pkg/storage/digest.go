@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+// UploadModuleDigest stores the digest of the module directory next to the module archive, an existing digest is replaced
+func (s *ObjectStorage) UploadModuleDigest(ctx context.Context, namespace, name, provider, version, digest string) error {
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+
+	ctx = s.tagged(ctx, namespace, name, version)
+	if err := s.upload(ctx, moduleDigestPath(key), strings.NewReader(digest), true); err != nil {
+		return fmt.Errorf("%v: failed to upload digest: %w", module.ErrModuleUploadFailed, err)
+	}
+	return nil
+}
+
+// ModuleDigest returns the digest of the module directory the archive of the module version was created from
+func (s *ObjectStorage) ModuleDigest(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return "", err
+	}
+
+	if exists, err := s.backend.Exists(ctx, moduleDigestPath(key)); err != nil {
+		return "", err
+	} else if !exists {
+		return "", fmt.Errorf("%w: no digest is recorded at %s", core.ErrObjectNotFound, moduleDigestPath(key))
+	}
+	b, err := s.backend.Download(ctx, moduleDigestPath(key))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
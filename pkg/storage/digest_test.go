@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_ModuleDigest(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	// Digests can only be recorded for existing versions
+	assert.ErrorIs(s.UploadModuleDigest(ctx, "acme", "vpc", "aws", "1.0.0", "abc"), module.ErrModuleNotFound)
+
+	_, err := s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+	_, err = s.ModuleDigest(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+
+	assert.NoError(s.UploadModuleDigest(ctx, "acme", "vpc", "aws", "1.0.0", "abc"))
+	digest, err := s.ModuleDigest(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal("abc", digest)
+
+	// The digest of a replaced module version doesn't describe the new archive
+	_, err = s.ReplaceModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("replaced"))
+	assert.NoError(err)
+	_, err = s.ModuleDigest(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+)
+
+// UploadProviderDocs stores the documentation next to the SHA256SUMS file of the provider version, existing documentation is replaced
+func (s *ObjectStorage) UploadProviderDocs(ctx context.Context, namespace, name, version string, docs []provider.Doc) error {
+	shasumPath, err := s.providerShasumPath(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return err
+	}
+
+	ctx = s.tagged(ctx, namespace, name, version)
+	if err := s.upload(ctx, providerDocsPath(shasumPath), bytes.NewReader(data), true); err != nil {
+		return fmt.Errorf("failed to upload documentation of provider %s/%s %s: %w", namespace, name, version, err)
+	}
+	return nil
+}
+
+// ProviderDocs returns the documentation of the provider version
+func (s *ObjectStorage) ProviderDocs(ctx context.Context, namespace, name, version string) ([]provider.Doc, error) {
+	shasumPath, err := s.providerShasumPath(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	key := providerDocsPath(shasumPath)
+	if exists, err := s.backend.Exists(ctx, key); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, fmt.Errorf("%w: no documentation is stored at %s", core.ErrObjectNotFound, key)
+	}
+	data, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []provider.Doc
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode documentation at %s: %w", key, err)
+	}
+	return docs, nil
+}
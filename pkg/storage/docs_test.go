@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_ProviderDocs(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	docs := []provider.Doc{{Category: provider.DocCategoryResources, Slug: "password", Title: "password", Language: "hcl", Path: "docs/resources/password.md", Content: "# random_password"}}
+
+	// Docs can only be attached to existing versions
+	assert.Error(s.UploadProviderDocs(ctx, "acme", "random", "2.0.0", docs))
+
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS", strings.NewReader("")))
+	_, err := s.ProviderDocs(ctx, "acme", "random", "2.0.0")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+
+	assert.NoError(s.UploadProviderDocs(ctx, "acme", "random", "2.0.0", docs))
+	res, err := s.ProviderDocs(ctx, "acme", "random", "2.0.0")
+	assert.NoError(err)
+	assert.Equal(docs, res)
+	exists, err := s.backend.Exists(ctx, "providers/acme/random/terraform-provider-random_2.0.0_SHA256SUMS.docs.json")
+	assert.NoError(err)
+	assert.True(exists)
+
+	// Uploaded docs are replaced
+	assert.NoError(s.UploadProviderDocs(ctx, "acme", "random", "2.0.0", docs[:0]))
+	res, err = s.ProviderDocs(ctx, "acme", "random", "2.0.0")
+	assert.NoError(err)
+	assert.Empty(res)
+}
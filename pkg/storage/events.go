@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/events"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+type eventObjectKind int
+
+const (
+	moduleArchiveObject eventObjectKind = iota
+	moduleChecksumObject
+	moduleStagingObject
+	moduleTombstoneObject
+	providerShasumsObject
+	providerTombstoneObject
+)
+
+// eventObject is an object whose upload or deletion changes the visibility of a module or provider version
+type eventObject struct {
+	kind eventObjectKind
+
+	// subject is the module archive, or the SHA256SUMS file of the provider version
+	subject string
+	prefix  string
+	event   events.Event
+}
+
+// EventsDecorator publishes the events of the module and provider versions changed through the decorated Backend.
+// The events are derived from the written objects, so that uploads, deletions and restores of all APIs are covered.
+func EventsDecorator(broker *events.Broker) Decorator {
+	return func(next Backend) Backend {
+		return &eventsBackend{
+			next:   next,
+			broker: broker,
+		}
+	}
+}
+
+type eventsBackend struct {
+	next   Backend
+	broker *events.Broker
+}
+
+func (b *eventsBackend) Exists(ctx context.Context, key string) (bool, error) {
+	return b.next.Exists(ctx, key)
+}
+
+func (b *eventsBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	return b.next.Download(ctx, key)
+}
+
+func (b *eventsBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.next.Open(ctx, key)
+}
+
+func (b *eventsBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	obj, ok := parseEventObject(key)
+	if !ok {
+		return b.next.Upload(ctx, key, reader)
+	}
+
+	// Overwritten checksums belong to replaced module archives, overwritten SHA256SUMS files to added platforms
+	existed := false
+	if obj.kind == moduleChecksumObject || obj.kind == providerShasumsObject {
+		existed = b.exists(ctx, key)
+	}
+	if err := b.next.Upload(ctx, key, reader); err != nil {
+		return err
+	}
+
+	switch obj.kind {
+	case moduleChecksumObject:
+		// Staged versions aren't visible until they are promoted
+		if b.exists(ctx, moduleStagingPath(obj.subject)) {
+			return nil
+		}
+		b.publish(obj.event, events.ModulePublished, events.ModuleReplaced, existed)
+	case moduleTombstoneObject:
+		b.publish(obj.event, events.ModuleDeleted, "", false)
+	case providerShasumsObject:
+		b.publish(obj.event, events.ProviderPublished, events.ProviderUpdated, existed)
+	case providerTombstoneObject:
+		b.publish(obj.event, events.ProviderDeleted, "", false)
+	}
+	return nil
+}
+
+func (b *eventsBackend) Delete(ctx context.Context, key string) error {
+	if err := b.next.Delete(ctx, key); err != nil {
+		return err
+	}
+	obj, ok := parseEventObject(key)
+	if !ok {
+		return nil
+	}
+
+	switch obj.kind {
+	case moduleArchiveObject:
+		// Replaced versions keep the archive in the new format
+		for _, format := range module.ArchiveFormats {
+			e := obj.event
+			if b.exists(ctx, modulePath(obj.prefix, e.Namespace, e.Name, e.Provider, e.Version, format)) {
+				return nil
+			}
+		}
+		b.publish(obj.event, events.ModulePurged, "", false)
+	case moduleStagingObject:
+		// Staging markers are also removed if the upload of a staged version failed
+		if b.exists(ctx, obj.subject) && !b.exists(ctx, moduleTombstonePath(obj.subject)) {
+			b.publish(obj.event, events.ModulePublished, "", false)
+		}
+	case moduleTombstoneObject:
+		// Tombstones are removed after the archive when the version is purged
+		if b.exists(ctx, obj.subject) {
+			b.publish(obj.event, events.ModuleRestored, "", false)
+		}
+	case providerShasumsObject:
+		b.publish(obj.event, events.ProviderPurged, "", false)
+	case providerTombstoneObject:
+		if b.exists(ctx, obj.subject) {
+			b.publish(obj.event, events.ProviderRestored, "", false)
+		}
+	}
+	return nil
+}
+
+func (b *eventsBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	return b.next.List(ctx, prefix)
+}
+
+func (b *eventsBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	return b.next.PresignedURL(ctx, key)
+}
+
+func (b *eventsBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.next.GetDownloadUrl(ctx, url)
+}
+
+// exists doesn't fail the operation, the event is skipped if the object can't be checked
+func (b *eventsBackend) exists(ctx context.Context, key string) bool {
+	exists, err := b.next.Exists(ctx, key)
+	if err != nil {
+		slog.Warn("failed to check object for the event feed", slog.String("key", key), slog.String("error", err.Error()))
+	}
+	return exists
+}
+
+func (b *eventsBackend) publish(e events.Event, eventType, existedType string, existed bool) {
+	e.Type = eventType
+	if existed {
+		e.Type = existedType
+	}
+	b.broker.Publish(e)
+}
+
+// parseEventObject recognizes the objects in the form of <prefix>/modules/<namespace>/<name>/<provider>/<file>,
+// <prefix>/providers/<namespace>/<name>/<file> and <prefix>/mirror/providers/<hostname>/<namespace>/<name>/<file>
+func parseEventObject(key string) (eventObject, bool) {
+	parts := strings.Split(key, "/")
+	n := len(parts)
+	switch {
+	case n >= 5 && parts[n-5] == string(internalModuleType):
+		return parseModuleEventObject(key, strings.Join(parts[:n-5], "/"), parts[n-4:])
+	case n >= 6 && parts[n-6] == "mirror" && parts[n-5] == "providers":
+		return parseProviderEventObject(key, parts[n-4], parts[n-3:])
+	case n >= 4 && parts[n-4] == string(internalProviderType) && (n == 4 || parts[n-5] != quarantineDir):
+		return parseProviderEventObject(key, "", parts[n-3:])
+	}
+	return eventObject{}, false
+}
+
+func parseModuleEventObject(key, prefix string, parts []string) (eventObject, bool) {
+	obj := eventObject{kind: moduleArchiveObject, subject: key, prefix: prefix}
+	for _, sidecar := range []struct {
+		suffix string
+		kind   eventObjectKind
+	}{
+		{moduleChecksumSuffix, moduleChecksumObject},
+		{stagingSuffix, moduleStagingObject},
+		{tombstoneSuffix, moduleTombstoneObject},
+	} {
+		if strings.HasSuffix(key, sidecar.suffix) {
+			obj.kind, obj.subject = sidecar.kind, strings.TrimSuffix(key, sidecar.suffix)
+			break
+		}
+	}
+
+	// The key is made relative, so that prefixes containing a modules directory aren't mistaken for the module layout
+	m, _, err := moduleFromArchive(path.Join(string(internalModuleType), path.Join(parts[:3]...), path.Base(obj.subject)), module.ArchiveFormats)
+	if err != nil {
+		// Other sidecars like signatures don't change the visibility of the version
+		return eventObject{}, false
+	}
+	obj.event = events.Event{
+		Namespace: m.Namespace,
+		Name:      m.Name,
+		Provider:  m.Provider,
+		Version:   m.Version,
+	}
+	return obj, true
+}
+
+func parseProviderEventObject(key, hostname string, parts []string) (eventObject, bool) {
+	namespace, name, file := parts[0], parts[1], parts[2]
+	filePrefix := fmt.Sprintf("%s%s_", core.ProviderPrefix, name)
+	if !strings.HasPrefix(file, filePrefix) {
+		return eventObject{}, false
+	}
+
+	obj := eventObject{
+		event: events.Event{
+			Hostname:  hostname,
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+	switch {
+	case strings.HasSuffix(file, "_SHA256SUMS"):
+		obj.kind, obj.subject = providerShasumsObject, key
+		obj.event.Version = providerFileVersion(file)
+	case strings.HasSuffix(file, tombstoneSuffix) && hostname == "":
+		obj.kind = providerTombstoneObject
+		obj.event.Version = strings.TrimSuffix(strings.TrimPrefix(file, filePrefix), tombstoneSuffix)
+		if obj.event.Version != "" {
+			obj.subject = path.Join(path.Dir(key), (&core.Provider{Name: name, Version: obj.event.Version}).ShasumFileName())
+		}
+	}
+	if obj.subject == "" || obj.event.Version == "" {
+		return eventObject{}, false
+	}
+	return obj, true
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/events"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func receivedEvents(sub *events.Subscription) []string {
+	var received []string
+	for {
+		select {
+		case e := <-sub.Events():
+			var parts []string
+			for _, p := range []string{e.Hostname, e.Namespace, e.Name, e.Provider, e.Version} {
+				if p != "" {
+					parts = append(parts, p)
+				}
+			}
+			received = append(received, e.Type+" "+strings.Join(parts, "/"))
+		default:
+			return received
+		}
+	}
+}
+
+func TestEventsDecorator_Modules(t *testing.T) {
+	ctx := context.Background()
+	broker := events.NewBroker(0)
+	sub := broker.Subscribe(0)
+	defer sub.Close()
+	s := NewObjectStorage(newMockBackend(), WithObjectStoragePrefix("registry"), WithObjectStorageDecorators(EventsDecorator(broker)))
+
+	_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	assertion.NoError(t, s.UploadModuleSignature(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("signature")))
+	assertion.Equal(t, []string{"module.published hashicorp/consul/aws/1.0.0"}, receivedEvents(sub))
+
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.1.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.1.0", strings.NewReader("replaced archive"))
+	assertion.NoError(t, err)
+	assertion.NoError(t, s.DeleteModule(ctx, "hashicorp", "consul", "aws", "1.1.0"))
+	assertion.NoError(t, s.RestoreModule(ctx, "hashicorp", "consul", "aws", "1.1.0"))
+	assertion.Equal(t, []string{
+		"module.published hashicorp/consul/aws/1.1.0",
+		"module.replaced hashicorp/consul/aws/1.1.0",
+		"module.deleted hashicorp/consul/aws/1.1.0",
+		"module.restored hashicorp/consul/aws/1.1.0",
+	}, receivedEvents(sub))
+
+	// Staged versions are published once they're promoted
+	stagingCtx := module.WithStaging(ctx, &module.Staging{StagedAt: time.Now().UTC(), Publisher: "alice"})
+	_, err = s.UploadModule(stagingCtx, "hashicorp", "consul", "aws", "1.2.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	assertion.Empty(t, receivedEvents(sub))
+	assertion.NoError(t, s.PromoteModule(ctx, "hashicorp", "consul", "aws", "1.2.0"))
+	assertion.Equal(t, []string{"module.published hashicorp/consul/aws/1.2.0"}, receivedEvents(sub))
+
+	assertion.NoError(t, s.DeleteModule(ctx, "hashicorp", "consul", "aws", "1.0.0"))
+	_, err = s.Purge(ctx, time.Now().Add(time.Hour))
+	assertion.NoError(t, err)
+	assertion.Equal(t, []string{
+		"module.deleted hashicorp/consul/aws/1.0.0",
+		"module.purged hashicorp/consul/aws/1.0.0",
+	}, receivedEvents(sub))
+}
+
+func TestEventsDecorator_Providers(t *testing.T) {
+	ctx := context.Background()
+	broker := events.NewBroker(0)
+	sub := broker.Subscribe(0)
+	defer sub.Close()
+	s := NewObjectStorage(newMockBackend(), WithObjectStorageDecorators(EventsDecorator(broker)))
+
+	for _, f := range []string{
+		"terraform-provider-random_2.0.0_linux_amd64.zip",
+		"terraform-provider-random_2.0.0_SHA256SUMS",
+		"terraform-provider-random_2.0.0_SHA256SUMS.sig",
+	} {
+		assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", f, strings.NewReader("archive")))
+	}
+	// Backfilled platforms overwrite the SHA256SUMS file
+	assertion.NoError(t, s.upload(ctx, "providers/hashicorp/random/terraform-provider-random_2.0.0_SHA256SUMS", strings.NewReader("sums"), true))
+	assertion.NoError(t, s.DeleteProvider(ctx, "hashicorp", "random", "2.0.0"))
+	assertion.NoError(t, s.RestoreProvider(ctx, "hashicorp", "random", "2.0.0"))
+	assertion.Equal(t, []string{
+		"provider.published hashicorp/random/2.0.0",
+		"provider.updated hashicorp/random/2.0.0",
+		"provider.deleted hashicorp/random/2.0.0",
+		"provider.restored hashicorp/random/2.0.0",
+	}, receivedEvents(sub))
+}
+
+func TestParseEventObject(t *testing.T) {
+	tests := []struct {
+		key   string
+		ok    bool
+		kind  eventObjectKind
+		event events.Event
+	}{
+		{
+			key:   "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz.sha256",
+			ok:    true,
+			kind:  moduleChecksumObject,
+			event: events.Event{Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"},
+		},
+		{
+			// Prefixes containing a modules directory aren't part of the module layout
+			key:   "modules/registry/modules/acme/vpc/aws/acme-vpc-aws-1.0.0.zip.tombstone",
+			ok:    true,
+			kind:  moduleTombstoneObject,
+			event: events.Event{Namespace: "acme", Name: "vpc", Provider: "aws", Version: "1.0.0"},
+		},
+		{key: "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz.sig"},
+		{key: "modules/acme/vpc/aws/SHA256SUMS"},
+		{
+			key:   "mirror/providers/registry.terraform.io/hashicorp/random/terraform-provider-random_2.0.0_SHA256SUMS",
+			ok:    true,
+			kind:  providerShasumsObject,
+			event: events.Event{Hostname: "registry.terraform.io", Namespace: "hashicorp", Name: "random", Version: "2.0.0"},
+		},
+		{
+			key:   "providers/hashicorp/random/terraform-provider-random_2.0.0.tombstone",
+			ok:    true,
+			kind:  providerTombstoneObject,
+			event: events.Event{Namespace: "hashicorp", Name: "random", Version: "2.0.0"},
+		},
+		{key: "providers/hashicorp/random/terraform-provider-random_2.0.0_linux_amd64.zip"},
+		{key: "quarantine/providers/hashicorp/random/terraform-provider-random_2.0.0_SHA256SUMS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			obj, ok := parseEventObject(tt.key)
+			assertion.Equal(t, tt.ok, ok)
+			if ok {
+				assertion.Equal(t, tt.kind, obj.kind)
+				assertion.Equal(t, tt.event, obj.event)
+			}
+		})
+	}
+}
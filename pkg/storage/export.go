@@ -0,0 +1,348 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+const (
+	// exportVersion is the version of the export format, exports of newer versions can't be imported
+	exportVersion = 1
+
+	exportManifestName = "manifest.json"
+	exportObjectsDir   = "objects"
+)
+
+// ErrInvalidExport is returned if an export can't be imported
+var ErrInvalidExport = errors.New("invalid export")
+
+// ExportManifest describes an export of the storage, it's the first file of every export
+type ExportManifest struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Archives bool      `json:"archives"`
+	Objects  int       `json:"objects"`
+}
+
+// ImportResult summarizes an import of an export
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// Exporter serializes all objects of the storage into a portable export and restores them from it,
+// e.g. to back up the registry, to migrate it to another storage backend, or to seed an air-gapped environment.
+type Exporter interface {
+	// Export writes all objects, the module and provider archives are skipped without archives
+	Export(ctx context.Context, w ExportWriter, archives bool) (ExportManifest, error)
+
+	// Import uploads the objects of the export, existing objects are skipped unless overwrite is set
+	Import(ctx context.Context, r ExportReader, overwrite bool) (ImportResult, error)
+}
+
+// ExportWriter stores the files of an export
+type ExportWriter interface {
+	Write(name string, data []byte) error
+	Close() error
+}
+
+// ExportReader reads the files of an export
+type ExportReader interface {
+	// Walk calls fn for every file in the order they were written
+	Walk(fn func(name string, r io.Reader) error) error
+	Close() error
+}
+
+// Export writes the manifest and all objects below the prefix of the storage, the keys are relative to the prefix,
+// so that the export can be imported into a storage with a different prefix.
+func (s *ObjectStorage) Export(ctx context.Context, w ExportWriter, archives bool) (ExportManifest, error) {
+	manifest := ExportManifest{
+		Version:  exportVersion,
+		Created:  time.Now().UTC(),
+		Archives: archives,
+	}
+
+	objects, err := s.backend.List(ctx, s.prefix)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to list objects to export: %w", err)
+	}
+
+	var keys []string
+	for _, obj := range objects {
+		rel, ok := s.relativeKey(obj.Key)
+		if !ok || (!archives && isArchive(rel)) {
+			continue
+		}
+		keys = append(keys, obj.Key)
+	}
+	manifest.Objects = len(keys)
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return manifest, err
+	}
+	if err := w.Write(exportManifestName, data); err != nil {
+		return manifest, fmt.Errorf("failed to write export manifest: %w", err)
+	}
+
+	for _, key := range keys {
+		data, err := s.backend.Download(ctx, key)
+		if err != nil {
+			return manifest, fmt.Errorf("failed to download %s: %w", key, err)
+		}
+		rel, _ := s.relativeKey(key)
+		if err := w.Write(path.Join(exportObjectsDir, rel), data); err != nil {
+			return manifest, fmt.Errorf("failed to export %s: %w", key, err)
+		}
+	}
+
+	slog.Info("exported storage", slog.Int("objects", manifest.Objects), slog.Bool("archives", archives))
+	return manifest, nil
+}
+
+// Import uploads the objects of the export below the prefix of the storage.
+// The objects are restored as they were exported, uploads aren't checked against quotas, the scanner, or the admission policy.
+func (s *ObjectStorage) Import(ctx context.Context, r ExportReader, overwrite bool) (ImportResult, error) {
+	var result ImportResult
+	var manifest *ExportManifest
+
+	err := r.Walk(func(name string, content io.Reader) error {
+		if manifest == nil {
+			if name != exportManifestName {
+				return fmt.Errorf("%w: the export doesn't start with %s", ErrInvalidExport, exportManifestName)
+			}
+			manifest = &ExportManifest{}
+			if err := json.NewDecoder(content).Decode(manifest); err != nil {
+				return fmt.Errorf("%w: failed to decode %s: %w", ErrInvalidExport, exportManifestName, err)
+			}
+			if manifest.Version < 1 || manifest.Version > exportVersion {
+				return fmt.Errorf("%w: unsupported version %d", ErrInvalidExport, manifest.Version)
+			}
+			return nil
+		}
+
+		rel, ok := strings.CutPrefix(name, exportObjectsDir+"/")
+		if !ok || !fs.ValidPath(rel) {
+			return fmt.Errorf("%w: unexpected file %s", ErrInvalidExport, name)
+		}
+		key := path.Join(s.prefix, rel)
+
+		err := s.upload(ctx, key, content, overwrite)
+		if errors.Is(err, core.ErrObjectAlreadyExists) {
+			result.Skipped++
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to import %s: %w", key, err)
+		}
+		result.Imported++
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if manifest == nil {
+		return result, fmt.Errorf("%w: the export is empty", ErrInvalidExport)
+	}
+
+	slog.Info("imported storage", slog.Int("imported", result.Imported), slog.Int("skipped", result.Skipped), slog.Bool("archives", manifest.Archives))
+	return result, nil
+}
+
+// relativeKey returns the key relative to the prefix of the storage, or false for keys of sibling prefixes
+func (s *ObjectStorage) relativeKey(key string) (string, bool) {
+	if s.prefix == "" {
+		return key, true
+	}
+	return strings.CutPrefix(key, strings.TrimSuffix(s.prefix, "/")+"/")
+}
+
+// isArchive reports whether the object is a module or provider archive
+func isArchive(key string) bool {
+	if strings.HasSuffix(key, core.ProviderExtension) {
+		return true
+	}
+	for _, format := range module.ArchiveFormats {
+		if strings.HasSuffix(key, "."+format) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewExportWriter writes an export to a directory if the path ends with a slash or is an existing directory,
+// and to a tarball otherwise, which is compressed if the path ends with .gz or .tgz.
+func NewExportWriter(p string) (ExportWriter, error) {
+	if info, err := os.Stat(p); strings.HasSuffix(p, "/") || (err == nil && info.IsDir()) {
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			return nil, err
+		}
+		return &dirExport{dir: p}, nil
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return nil, err
+	}
+	w := &tarExport{file: f}
+	if strings.HasSuffix(p, ".gz") || strings.HasSuffix(p, ".tgz") {
+		w.gzip = gzip.NewWriter(f)
+		w.tar = tar.NewWriter(w.gzip)
+	} else {
+		w.tar = tar.NewWriter(f)
+	}
+	return w, nil
+}
+
+// OpenExport reads an export written by NewExportWriter
+func OpenExport(p string) (ExportReader, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dirExport{dir: p}, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	return &tarExport{file: f}, nil
+}
+
+// dirExport stores the files of an export in a directory
+type dirExport struct {
+	dir string
+}
+
+func (d *dirExport) Write(name string, data []byte) error {
+	p := filepath.Join(d.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// Walk returns the manifest first and the objects in lexical order
+func (d *dirExport) Walk(fn func(name string, r io.Reader) error) error {
+	manifest, err := os.ReadFile(filepath.Join(d.dir, exportManifestName))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidExport, err)
+	}
+	if err := fn(exportManifestName, bytes.NewReader(manifest)); err != nil {
+		return err
+	}
+
+	objects := filepath.Join(d.dir, exportObjectsDir)
+	return filepath.WalkDir(objects, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == objects {
+				// An export of an empty storage has no objects
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.dir, p)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return fn(filepath.ToSlash(rel), f)
+	})
+}
+
+func (d *dirExport) Close() error {
+	return nil
+}
+
+// tarExport stores the files of an export in a tarball, which is optionally compressed
+type tarExport struct {
+	file *os.File
+	gzip *gzip.Writer
+	tar  *tar.Writer
+}
+
+func (t *tarExport) Write(name string, data []byte) error {
+	if err := t.tar.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := t.tar.Write(data)
+	return err
+}
+
+// Walk detects compressed tarballs by their content
+func (t *tarExport) Walk(fn func(name string, r io.Reader) error) error {
+	var r io.Reader = t.file
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(t.file, header); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidExport, err)
+	}
+	if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if header[0] == 0x1f && header[1] == 0x8b {
+		gr, err := gzip.NewReader(t.file)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidExport, err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidExport, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *tarExport) Close() error {
+	var errs []error
+	if t.tar != nil {
+		errs = append(errs, t.tar.Close())
+	}
+	if t.gzip != nil {
+		errs = append(errs, t.gzip.Close())
+	}
+	errs = append(errs, t.file.Close())
+	return errors.Join(errs...)
+}
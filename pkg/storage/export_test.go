@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func exportedKeys(t *testing.T, s *MemoryStorage) []string {
+	t.Helper()
+	objects, err := s.backend.List(context.Background(), "")
+	assertion.NoError(t, err)
+
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestObjectStorage_ExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemoryStorage()
+	_, err := source.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assertion.NoError(t, err)
+	checksum := sha256.Sum256([]byte("module"))
+	assertion.NoError(t, source.UploadModuleChecksum(ctx, "acme", "vpc", "aws", "1.0.0", hex.EncodeToString(checksum[:])))
+	assertion.NoError(t, source.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_linux_amd64.zip", strings.NewReader("provider")))
+	assertion.NoError(t, source.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS", strings.NewReader("sums")))
+
+	tests := []struct {
+		name     string
+		path     string
+		archives bool
+		want     []string
+	}{
+		{
+			name:     "tarball",
+			path:     "export.tar",
+			archives: true,
+			want:     exportedKeys(t, source),
+		},
+		{
+			name:     "compressed tarball",
+			path:     "export.tar.gz",
+			archives: true,
+			want:     exportedKeys(t, source),
+		},
+		{
+			name:     "directory without archives",
+			path:     "export/",
+			archives: false,
+			want: []string{
+				"modules/acme/vpc/aws/SHA256SUMS",
+				"modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz.sha256",
+				"providers/acme/random/terraform-provider-random_2.0.0_SHA256SUMS",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assertion.New(t)
+			p := filepath.Join(t.TempDir(), tc.path)
+
+			w, err := NewExportWriter(p)
+			assert.NoError(err)
+			manifest, err := source.Export(ctx, w, tc.archives)
+			assert.NoError(err)
+			assert.NoError(w.Close())
+			assert.Equal(len(tc.want), manifest.Objects)
+
+			target := NewMemoryStorage()
+			r, err := OpenExport(p)
+			assert.NoError(err)
+			result, err := target.Import(ctx, r, false)
+			assert.NoError(err)
+			assert.NoError(r.Close())
+			assert.Equal(ImportResult{Imported: len(tc.want)}, result)
+			assert.Equal(tc.want, exportedKeys(t, target))
+
+			// Existing objects are skipped, unless they're overwritten
+			r, err = OpenExport(p)
+			assert.NoError(err)
+			result, err = target.Import(ctx, r, false)
+			assert.NoError(err)
+			assert.Equal(ImportResult{Skipped: len(tc.want)}, result)
+			r.Close()
+
+			r, err = OpenExport(p)
+			assert.NoError(err)
+			result, err = target.Import(ctx, r, true)
+			assert.NoError(err)
+			assert.Equal(ImportResult{Imported: len(tc.want)}, result)
+			r.Close()
+		})
+	}
+}
+
+func TestObjectStorage_ImportInvalid(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	assertion.NoError(t, os.WriteFile(filepath.Join(dir, exportManifestName), []byte(`{"version": 2}`), 0o644))
+
+	r, err := OpenExport(dir)
+	assertion.NoError(t, err)
+	_, err = NewMemoryStorage().Import(ctx, r, false)
+	assertion.ErrorIs(t, err, ErrInvalidExport)
+
+	_, err = NewMemoryStorage().Import(ctx, &dirExport{dir: t.TempDir()}, false)
+	assertion.ErrorIs(t, err, ErrInvalidExport)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+// archiveFormats returns the module archive formats in the order of preference:
+// the format preferred by the context, the configured format, and all other known formats
+func (s *ObjectStorage) archiveFormats(ctx context.Context) []string {
+	formats := make([]string, 0, len(module.ArchiveFormats)+2)
+	for _, format := range append([]string{module.ArchiveFormatFromContext(ctx), s.moduleArchiveFormat}, module.ArchiveFormats...) {
+		if format != "" && !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// uploadModulePath returns the path of an uploaded module archive in the format of the context, or the configured format.
+// Uploaded archives are always stored in the configured format if they're converted.
+func (s *ObjectStorage) uploadModulePath(ctx context.Context, namespace, name, provider, version string) string {
+	format := module.ArchiveFormatFromContext(ctx)
+	if format == "" || s.convertArchives {
+		format = s.moduleArchiveFormat
+	}
+	return modulePath(s.prefix, namespace, name, provider, version, format)
+}
+
+// moduleArchive returns the path of the archive of the module version in the most preferred format it exists in
+func (s *ObjectStorage) moduleArchive(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	for _, format := range s.archiveFormats(ctx) {
+		key := modulePath(s.prefix, namespace, name, provider, version, format)
+		if exists, err := s.backend.Exists(ctx, key); err != nil {
+			return "", err
+		} else if exists {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s/%s/%s/%s", module.ErrModuleNotFound, namespace, name, provider, version)
+}
+
+// moduleArchives returns the paths of the archives of the module version in all formats it exists in
+func (s *ObjectStorage) moduleArchives(ctx context.Context, namespace, name, provider, version string) ([]string, error) {
+	var keys []string
+	for _, format := range s.archiveFormats(ctx) {
+		key := modulePath(s.prefix, namespace, name, provider, version, format)
+		if exists, err := s.backend.Exists(ctx, key); err != nil {
+			return nil, err
+		} else if exists {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// moduleFromArchive parses the module archive in any of the formats, and returns the index of the matching format.
+// The known formats are tried before the configured format, so that a configured format like gz doesn't shadow tar.gz.
+func moduleFromArchive(key string, formats []string) (*core.Module, int, error) {
+	var err error
+	for _, i := range archiveFormatOrder(formats) {
+		var m *core.Module
+		if m, err = moduleFromObject(key, formats[i]); err == nil {
+			return m, i, nil
+		}
+	}
+	return nil, -1, err
+}
+
+// archiveFormatOrder returns the indexes of the formats sorted by descending length, so that the longest matching extension wins
+func archiveFormatOrder(formats []string) []int {
+	order := make([]int, len(formats))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return len(formats[b]) - len(formats[a])
+	})
+	return order
+}
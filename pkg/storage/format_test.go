@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_ArchiveFormats(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	zipCtx := module.WithArchiveFormat(ctx, module.ArchiveFormatZip)
+	backend := newMockBackend()
+	s := NewObjectStorage(backend)
+
+	// Modules uploaded in another format than the configured one are found
+	_, err := s.UploadModule(zipCtx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("zip"))
+	assert.NoError(err)
+	m, err := s.GetModule(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.0.0.zip?presigned=true", m.DownloadURL)
+
+	// A version can't be uploaded again in another format
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("tar.gz"))
+	assert.ErrorIs(err, module.ErrModuleAlreadyExists)
+
+	// Versions existing in multiple formats are served in the preferred format
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.1.0", strings.NewReader("tar.gz"))
+	assert.NoError(err)
+	backend.objects["modules/acme/vpc/aws/acme-vpc-aws-1.1.0.zip"] = []byte("zip")
+
+	m, err = s.GetModule(ctx, "acme", "vpc", "aws", "1.1.0")
+	assert.NoError(err)
+	assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz?presigned=true", m.DownloadURL)
+	m, err = s.GetModule(zipCtx, "acme", "vpc", "aws", "1.1.0")
+	assert.NoError(err)
+	assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.1.0.zip?presigned=true", m.DownloadURL)
+
+	modules, err := s.ListModuleVersions(zipCtx, "acme", "vpc", "aws")
+	assert.NoError(err)
+	if assert.Len(modules, 2) {
+		assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.0.0.zip?presigned=true", modules[0].DownloadURL)
+		assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.1.0.zip?presigned=true", modules[1].DownloadURL)
+	}
+
+	// Replacing a version removes the archives in the other formats
+	_, err = s.ReplaceModule(zipCtx, "acme", "vpc", "aws", "1.1.0", strings.NewReader("replaced"))
+	assert.NoError(err)
+	assert.NotContains(backend.objects, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz")
+	assert.NotContains(backend.objects, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz.sha256")
+	m, err = s.GetModule(ctx, "acme", "vpc", "aws", "1.1.0")
+	assert.NoError(err)
+	assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.1.0.zip?presigned=true", m.DownloadURL)
+
+	// Deleting a version hides all of its formats
+	backend.objects["modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tgz"] = []byte("tgz")
+	assert.NoError(s.DeleteModule(ctx, "acme", "vpc", "aws", "1.1.0"))
+	_, err = s.GetModule(ctx, "acme", "vpc", "aws", "1.1.0")
+	assert.ErrorIs(err, module.ErrModuleNotFound)
+	assert.NoError(s.RestoreModule(ctx, "acme", "vpc", "aws", "1.1.0"))
+	_, err = s.GetModule(ctx, "acme", "vpc", "aws", "1.1.0")
+	assert.NoError(err)
+}
+
+func TestModuleFromArchive(t *testing.T) {
+	assert := assertion.New(t)
+	formats := []string{"gz", "tar.gz", "zip"}
+
+	m, i, err := moduleFromArchive("modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", formats)
+	assert.NoError(err)
+	assert.Equal("1.0.0", m.Version)
+	assert.Equal(1, i)
+
+	_, _, err = moduleFromArchive("modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz.sha256", formats)
+	assert.Error(err)
+}
+
+func TestObjectStorage_ArchiveConversion(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := module.WithArchiveFormat(context.Background(), module.ArchiveFormatTarGz)
+	backend := newMockBackend()
+	s := NewObjectStorage(backend, WithObjectStorageArchiveFormat(module.ArchiveFormatZip), WithObjectStorageArchiveConversion(true))
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	assert.NoError(tw.WriteHeader(&tar.Header{Name: "main.tf", Mode: 0o644, Typeflag: tar.TypeReg}))
+	assert.NoError(tw.Close())
+	assert.NoError(gw.Close())
+
+	// Uploaded archives are stored in the configured format regardless of their format
+	m, err := s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", &buf)
+	assert.NoError(err)
+	assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.0.0.zip?presigned=true", m.DownloadURL)
+	assert.Equal(module.ArchiveFormatZip, module.SniffArchiveFormat(backend.objects["modules/acme/vpc/aws/acme-vpc-aws-1.0.0.zip"]))
+
+	// Archives of unknown format are stored as is
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.1.0", strings.NewReader("archive"))
+	assert.NoError(err)
+	assert.Equal([]byte("archive"), backend.objects["modules/acme/vpc/aws/acme-vpc-aws-1.1.0.zip"])
+}
@@ -1,197 +1,109 @@
 package storage
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"path"
-	"path/filepath"
+	"net/http"
+	"os"
 	"time"
 
-	"github.com/boring-registry/boring-registry/pkg/core"
-	"github.com/boring-registry/boring-registry/pkg/module"
-
 	credentials "cloud.google.com/go/iam/credentials/apiv1"
 	"cloud.google.com/go/iam/credentials/apiv1/credentialspb"
 	"cloud.google.com/go/storage"
-	"golang.org/x/oauth2/google"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
-// GCSStorage is a Storage implementation backed by GCS.
-// GCSStorage implements module.Storage, provider.Storage, and mirror.Storage
-type GCSStorage struct {
-	sc                  *storage.Client
-	bucket              string
-	bucketPrefix        string
-	signedURLExpiry     time.Duration
-	serviceAccount      string
-	moduleArchiveFormat string
+// gcsSignerAPI is used to mock the IAM Credentials API
+type gcsSignerAPI interface {
+	SignBlob(ctx context.Context, req *credentialspb.SignBlobRequest, opts ...gax.CallOption) (*credentialspb.SignBlobResponse, error)
 }
 
-func (s *GCSStorage) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
-	o := s.sc.Bucket(s.bucket).Object(modulePath(s.bucketPrefix, namespace, name, provider, version, s.moduleArchiveFormat))
-	attrs, err := o.Attrs(ctx)
-	if err != nil {
-		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleNotFound, err)
-	}
-	url, err := s.presignedURL(ctx, attrs.Name)
-	if err != nil {
-		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleNotFound, err)
-	}
-	return core.Module{
-		Namespace: namespace,
-		Name:      attrs.Name,
-		Provider:  provider,
-		Version:   version,
-		/* https://www.terraform.io/docs/internals/module-registry-protocol.html#sample-response-1
-		e.g. "gcs::https://www.googleapis.com/storage/v1/modules/foomodule.zip
-		*/
-		DownloadURL: url,
-	}, nil
-}
-
-func (s *GCSStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
-	prefix := modulePathPrefix(s.bucketPrefix, namespace, name, provider)
-
-	query := &storage.Query{
-		Prefix: prefix,
-	}
-
-	var modules []core.Module
-	it := s.sc.Bucket(s.bucket).Objects(ctx, query)
-	for {
-		attrs, err := it.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return modules, err
-		}
-		m, err := moduleFromObject(attrs.Name, s.moduleArchiveFormat)
-		if err != nil {
-			// TODO: we're skipping possible failures silently
-			continue
-		}
-		modules = append(modules, *m)
-	}
-	return modules, nil
+// GCSStorage is a Backend implementation backed by GCS.
+// NewGCSStorage wraps it into an ObjectStorage, which implements module.Storage, provider.Storage, and mirror.Storage
+type GCSStorage struct {
+	sc                    *storage.Client
+	signer                gcsSignerAPI
+	bucket                string
+	bucketPrefix          string
+	signedURLExpiry       time.Duration
+	serviceAccount        string
+	moduleArchiveFormat   string
+	convertModuleArchives bool
+	decorators            []Decorator
+	objectOptions         []ObjectStorageOption
+	tags                  map[string]string
+	publisher             string
+	httpClient            *HTTPClientConfig
 }
 
-func (s *GCSStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
-	if namespace == "" {
-		return core.Module{}, errors.New("namespace not defined")
+// Upload writes the object into the GCS bucket
+func (s *GCSStorage) Upload(ctx context.Context, key string, reader io.Reader) error {
+	obj := s.sc.Bucket(s.bucket).Object(key)
+	if ifNotExists(ctx) {
+		obj = obj.If(storage.Conditions{DoesNotExist: true})
 	}
-
-	if name == "" {
-		return core.Module{}, errors.New("name not defined")
-	}
-
-	if provider == "" {
-		return core.Module{}, errors.New("provider not defined")
-	}
-
-	if version == "" {
-		return core.Module{}, errors.New("version not defined")
-	}
-
-	key := modulePath(s.bucketPrefix, namespace, name, provider, version, s.moduleArchiveFormat)
-	if _, err := s.GetModule(ctx, namespace, name, provider, version); err == nil {
-		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
-	}
-
-	wc := s.sc.Bucket(s.bucket).Object(key).NewWriter(ctx)
-	if _, err := io.Copy(wc, body); err != nil {
-		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+	wc := obj.NewWriter(ctx)
+	// GCS doesn't support tags on objects, so they are stored as custom metadata
+	wc.Metadata = objectTags(ctx)
+	if _, err := io.Copy(wc, reader); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
 	}
 	if err := wc.Close(); err != nil {
-		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+			return errAlreadyExists(key)
+		}
+		return fmt.Errorf("failed to upload object: %w", err)
 	}
 
-	return s.GetModule(ctx, namespace, name, provider, version)
+	return nil
 }
 
-// GetProvider implements provider.Storage
-func (s *GCSStorage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
-	var archivePath, shasumPath, shasumSigPath string
-	if pt == internalProviderType {
-		archivePath, shasumPath, shasumSigPath = internalProviderPath(s.bucketPrefix, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
-	} else if pt == mirrorProviderType {
-		archivePath, shasumPath, shasumSigPath = mirrorProviderPath(s.bucketPrefix, provider.Hostname, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
+// Delete removes the object from the GCS bucket
+func (s *GCSStorage) Delete(ctx context.Context, key string) error {
+	if err := s.sc.Bucket(s.bucket).Object(key).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
 	}
 
-	if exists, err := s.objectExists(ctx, archivePath); err != nil {
-		return nil, err
-	} else if !exists {
-		return nil, noMatchingProviderFound(provider)
-	}
-
-	var err error
-	provider.DownloadURL, err = s.presignedURL(ctx, archivePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pre-signed url for %s: %w", archivePath, err)
-	}
-	provider.SHASumsURL, err = s.presignedURL(ctx, shasumPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pre-signed url for %s: %w", archivePath, err)
-	}
-	provider.SHASumsSignatureURL, err = s.presignedURL(ctx, shasumSigPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pre-signed url for %s: %w", archivePath, err)
-	}
-
-	shasumBytes, err := s.download(ctx, shasumPath)
-	if err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	provider.Shasum, err = readSHASums(bytes.NewReader(shasumBytes), path.Base(archivePath))
+// Download reads the object from the GCS bucket
+func (s *GCSStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.sc.Bucket(s.bucket).Object(key).NewReader(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer func(r *storage.Reader) {
+		_ = r.Close()
+	}(r)
 
-	var signingKeys *core.SigningKeys
-	if pt == internalProviderType {
-		signingKeys, err = s.SigningKeys(ctx, provider.Namespace)
-	} else if pt == mirrorProviderType {
-		signingKeys, err = s.MirroredSigningKeys(ctx, provider.Hostname, provider.Namespace)
-	}
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
 
-	provider.Filename = path.Base(archivePath)
-	provider.SigningKeys = *signingKeys
-	return provider, nil
-}
-
-func (s *GCSStorage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
-	p, err := s.getProvider(ctx, internalProviderType, &core.Provider{
-		Namespace: namespace,
-		Name:      name,
-		Version:   version,
-		OS:        os,
-		Arch:      arch,
-	})
-	return p, err
+	return data, nil
 }
 
-func (s *GCSStorage) GetMirroredProvider(ctx context.Context, provider *core.Provider) (*core.Provider, error) {
-	return s.getProvider(ctx, mirrorProviderType, provider)
+func (s *GCSStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.sc.Bucket(s.bucket).Object(key).NewReader(ctx)
 }
 
-func (s *GCSStorage) listProviderVersions(ctx context.Context, pt providerType, provider *core.Provider) ([]*core.Provider, error) {
-	prefix := providerStoragePrefix(s.bucketPrefix, pt, provider.Hostname, provider.Namespace, provider.Name)
+// List returns all objects in the GCS bucket with the given prefix
+func (s *GCSStorage) List(ctx context.Context, prefix string) ([]Object, error) {
 	query := &storage.Query{
-		Prefix: fmt.Sprintf("%s/", prefix),
+		Prefix: prefix,
 	}
 
+	var objects []Object
 	it := s.sc.Bucket(s.bucket).Objects(ctx, query)
-
-	var providers []*core.Provider
 	for {
 		select { // Check if the context has been canceled in every loop iteration
 		case <-ctx.Done():
@@ -207,224 +119,58 @@ func (s *GCSStorage) listProviderVersions(ctx context.Context, pt providerType,
 			return nil, err
 		}
 
-		p, err := core.NewProviderFromArchive(attrs.Name)
-		if err != nil {
-			continue
-		}
-
-		p.Hostname = provider.Hostname
-		p.Namespace = provider.Namespace
-		archiveUrl, err := s.presignedURL(ctx, attrs.Name)
-		if err != nil {
-			return nil, err
-		}
-		p.DownloadURL = archiveUrl
-
-		if provider.Version != "" && provider.Version != p.Version {
-			// The provider version doesn't match the requested version
-			continue
-		}
-
-		providers = append(providers, &p)
-	}
-
-	if len(providers) == 0 {
-		return nil, noMatchingProviderFound(provider)
-	}
-
-	return providers, nil
-}
-
-func (s *GCSStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
-	providers, err := s.listProviderVersions(ctx, internalProviderType, &core.Provider{Namespace: namespace, Name: name})
-	if err != nil {
-		return nil, err
-	}
-
-	collection := NewCollection()
-	for _, p := range providers {
-		collection.Add(p)
-	}
-	return collection.List(), nil
-}
-
-func (s *GCSStorage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
-	return s.listProviderVersions(ctx, mirrorProviderType, provider)
-}
-
-func (s *GCSStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
-	if namespace == "" {
-		return fmt.Errorf("namespace argument is empty")
-	}
-
-	if name == "" {
-		return fmt.Errorf("name argument is empty")
-	}
-
-	if filename == "" {
-		return fmt.Errorf("filename argument is empty")
-	}
-
-	prefix := providerStoragePrefix(s.bucketPrefix, internalProviderType, "", namespace, name)
-	key := filepath.Join(prefix, filename)
-	return s.upload(ctx, key, file, false)
-}
-
-func (s *GCSStorage) UploadMirroredFile(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
-	prefix := providerStoragePrefix(s.bucketPrefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
-
-	key := filepath.Join(prefix, fileName)
-	return s.upload(ctx, key, reader, true)
-}
-
-func (s *GCSStorage) signingKeys(ctx context.Context, pt providerType, hostname, namespace string) (*core.SigningKeys, error) {
-	if namespace == "" {
-		return nil, fmt.Errorf("namespace argument is empty")
-	}
-	key := signingKeysPath(s.bucketPrefix, pt, hostname, namespace)
-	exists, err := s.objectExists(ctx, key)
-	if err != nil {
-		return nil, err
-	} else if !exists {
-		return nil, core.ErrObjectNotFound
-	}
-	signingKeysRaw, err := s.download(ctx, key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download signing_keys for namespace %s: %w", namespace, err)
-	}
-
-	return unmarshalSigningKeys(signingKeysRaw)
-}
-
-// SigningKeys downloads the JSON placed in the namespace in GCS and unmarshals it into a core.SigningKeys
-func (s *GCSStorage) SigningKeys(ctx context.Context, namespace string) (*core.SigningKeys, error) {
-	return s.signingKeys(ctx, internalProviderType, "", namespace)
-}
-
-func (s *GCSStorage) MirroredSigningKeys(ctx context.Context, hostname, namespace string) (*core.SigningKeys, error) {
-	return s.signingKeys(ctx, mirrorProviderType, hostname, namespace)
-}
-
-func (s *GCSStorage) uploadSigningKeys(ctx context.Context, pt providerType, hostname, namespace string, signingKeys *core.SigningKeys) error {
-	b, err := json.Marshal(signingKeys)
-	if err != nil {
-		return err
+		objects = append(objects, Object{
+			Key:          attrs.Name,
+			Size:         attrs.Size,
+			LastModified: attrs.Updated,
+		})
 	}
-	key := signingKeysPath(s.bucketPrefix, pt, hostname, namespace)
-	return s.upload(ctx, key, bytes.NewReader(b), true)
-}
 
-func (s *GCSStorage) UploadMirroredSigningKeys(ctx context.Context, hostname, namespace string, signingKeys *core.SigningKeys) error {
-	return s.uploadSigningKeys(ctx, mirrorProviderType, hostname, namespace, signingKeys)
+	return objects, nil
 }
 
-func (s *GCSStorage) MirroredSha256Sum(ctx context.Context, provider *core.Provider) (*core.Sha256Sums, error) {
-	prefix := providerStoragePrefix(s.bucketPrefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
-	key := filepath.Join(prefix, provider.ShasumFileName())
-	shaSumBytes, err := s.download(ctx, key)
-	if err != nil {
-		return nil, errors.New("failed to download SHA256SUMS")
+// PresignedURL generates a V4 signed URL of the object with the GET method.
+// URLs are signed through the IAM Credentials SignBlob API if a service account is configured.
+// Otherwise, the private key of the Application Default Credentials is used if available, and the SignBlob API with the
+// service account detected from ADC or the metadata server, e.g. with Workload Identity on GKE.
+func (s *GCSStorage) PresignedURL(ctx context.Context, object string) (string, error) {
+	opts := &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(s.signedURLExpiry),
 	}
-	return core.NewSha256Sums(provider.ShasumFileName(), bytes.NewReader(shaSumBytes))
-}
 
-func (s *GCSStorage) upload(ctx context.Context, key string, reader io.Reader, overwrite bool) error {
-	if !overwrite {
-		exists, err := s.objectExists(ctx, key)
-		if err != nil {
-			return err
-		} else if exists {
-			return fmt.Errorf("failed to upload key %s: %w", key, core.ErrObjectAlreadyExists)
+	if s.serviceAccount != "" {
+		opts.GoogleAccessID = s.serviceAccount
+		opts.SignBytes = func(b []byte) ([]byte, error) {
+			return s.signBlob(ctx, b)
 		}
 	}
 
-	wc := s.sc.Bucket(s.bucket).Object(key).NewWriter(ctx)
-	if _, err := io.Copy(wc, reader); err != nil {
-		return fmt.Errorf("failed to upload object: %w", err)
-	}
-	if err := wc.Close(); err != nil {
-		return fmt.Errorf("failed to upload object: %w", err)
-	}
-
-	return nil
-}
-
-func (s *GCSStorage) download(ctx context.Context, key string) ([]byte, error) {
-	r, err := s.sc.Bucket(s.bucket).Object(key).NewReader(ctx)
+	url, err := s.sc.Bucket(s.bucket).SignedURL(object, opts)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to sign URL: %w", err)
 	}
-	defer func(r *storage.Reader) {
-		_ = r.Close()
-	}(r)
 
-	data, err := io.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
+	return url, nil
 }
 
-// https://github.com/GoogleCloudPlatform/golang-samples/blob/73d60a5de091dcdda5e4f753b594ef18eee67906/storage/objects/generate_v4_get_object_signed_url.go#L28
-// presignedURL generates object signed URL with GET method.
-func (s *GCSStorage) presignedURL(ctx context.Context, object string) (string, error) {
-	//https://godoc.org/golang.org/x/oauth2/google#DefaultClient
-	cred, err := google.FindDefaultCredentials(ctx, "cloud-platform")
+// signBlob signs the payload with the Google-managed key of the service account, which needs the
+// Service Account Token Creator role
+func (s *GCSStorage) signBlob(ctx context.Context, payload []byte) ([]byte, error) {
+	resp, err := s.signer.SignBlob(ctx, &credentialspb.SignBlobRequest{
+		Name:    fmt.Sprintf("projects/-/serviceAccounts/%s", s.serviceAccount),
+		Payload: payload,
+	})
 	if err != nil {
-		return "", fmt.Errorf("google.FindDefaultCredentials: %v", err)
-	}
-
-	var url string
-	if s.serviceAccount != "" {
-		// needs Service Account Token Creator role
-		c, err := credentials.NewIamCredentialsClient(ctx)
-		if err != nil {
-			return "", fmt.Errorf("credentials.NewIamCredentialsClient: %v", err)
-		}
-
-		url, err = storage.SignedURL(s.bucket, object, &storage.SignedURLOptions{
-			Scheme:         storage.SigningSchemeV4,
-			Method:         "GET",
-			GoogleAccessID: s.serviceAccount,
-			Expires:        time.Now().Add(s.signedURLExpiry),
-			SignBytes: func(b []byte) ([]byte, error) {
-				req := &credentialspb.SignBlobRequest{
-					Payload: b,
-					Name:    s.serviceAccount,
-				}
-				resp, err := c.SignBlob(ctx, req)
-				if err != nil {
-					return nil, fmt.Errorf("storage.signedURL.SignBytes: %v", err)
-				}
-				return resp.SignedBlob, nil
-			},
-		})
-		if err != nil {
-			return "", fmt.Errorf("storage.signedURL: %v", err)
-		}
-	} else {
-		conf, err := google.JWTConfigFromJSON(cred.JSON)
-		if err != nil {
-			return "", fmt.Errorf("could not get jwt config: %w", err)
-		}
-		opts := &storage.SignedURLOptions{
-			Scheme:         storage.SigningSchemeV4,
-			Method:         "GET",
-			GoogleAccessID: conf.Email,
-			PrivateKey:     conf.PrivateKey,
-			Expires:        time.Now().Add(s.signedURLExpiry),
-		}
-		url, err = storage.SignedURL(s.bucket, object, opts)
-		if err != nil {
-			return "", fmt.Errorf("storage.signedURL: %v", err)
-		}
+		return nil, fmt.Errorf("failed to sign blob: %w", err)
 	}
 
-	return url, nil
+	return resp.SignedBlob, nil
 }
 
-func (s *GCSStorage) objectExists(ctx context.Context, key string) (bool, error) {
+// Exists checks if an object with the key exists in the GCS bucket
+func (s *GCSStorage) Exists(ctx context.Context, key string) (bool, error) {
 	o := s.sc.Bucket(s.bucket).Object(key)
 	_, err := o.Attrs(ctx)
 	if errors.Is(err, storage.ErrObjectNotExist) {
@@ -470,14 +216,61 @@ func WithGCSArchiveFormat(archiveFormat string) GCSStorageOption {
 	}
 }
 
-func NewGCSStorage(bucket string, options ...GCSStorageOption) (*GCSStorage, error) {
-	ctx := context.Background()
-	client, err := storage.NewClient(ctx)
+// WithGCSArchiveConversion stores all uploaded module archives in the configured format, archives in another format are repackaged
+func WithGCSArchiveConversion(enabled bool) GCSStorageOption {
+	return func(s *GCSStorage) {
+		s.convertModuleArchives = enabled
+	}
+}
+
+// WithGCSStorageHTTPClient configures the connection pool of the HTTP client shared by all requests to GCS
+func WithGCSStorageHTTPClient(config HTTPClientConfig) GCSStorageOption {
+	return func(s *GCSStorage) {
+		s.httpClient = &config
+	}
+}
+
+// WithGCSStorageTags stores the static tags, the publisher, and the artifact of uploaded objects as custom metadata.
+// Tagging is disabled if tags is nil.
+func WithGCSStorageTags(tags map[string]string, publisher string) GCSStorageOption {
+	return func(s *GCSStorage) {
+		s.tags = tags
+		s.publisher = publisher
+	}
+}
+
+// WithGCSStorageDecorators wraps the GCS backend with the given decorators.
+func WithGCSStorageDecorators(decorators ...Decorator) GCSStorageOption {
+	return func(s *GCSStorage) {
+		s.decorators = append(s.decorators, decorators...)
+	}
+}
+
+// WithGCSStorageObjectOptions applies the options to the ObjectStorage of the GCS storage, e.g. quotas or the admission controller
+func WithGCSStorageObjectOptions(options ...ObjectStorageOption) GCSStorageOption {
+	return func(s *GCSStorage) {
+		s.objectOptions = append(s.objectOptions, options...)
+	}
+}
+
+// newHTTPClient returns an authenticated HTTP client with the configured connection pool
+func (s *GCSStorage) newHTTPClient(ctx context.Context) (*http.Client, error) {
+	authOptions := []option.ClientOption{option.WithScopes(storage.ScopeFullControl, "https://www.googleapis.com/auth/cloud-platform")}
+	if os.Getenv("STORAGE_EMULATOR_HOST") != "" {
+		authOptions = []option.ClientOption{option.WithoutAuthentication()}
+	}
+
+	transport, err := htransport.NewTransport(ctx, s.httpClient.Transport(), authOptions...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create GCS transport: %w", err)
 	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// NewGCSStorage returns a fully initialized GCS storage.
+func NewGCSStorage(bucket string, options ...GCSStorageOption) (Storage, error) {
+	ctx := context.Background()
 	s := &GCSStorage{
-		sc:     client,
 		bucket: bucket,
 	}
 
@@ -485,5 +278,37 @@ func NewGCSStorage(bucket string, options ...GCSStorageOption) (*GCSStorage, err
 		option(s)
 	}
 
-	return s, nil
+	var clientOptions []option.ClientOption
+	if s.httpClient != nil {
+		httpClient, err := s.newHTTPClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions = append(clientOptions, option.WithHTTPClient(httpClient))
+	}
+
+	client, err := storage.NewClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	s.sc = client
+
+	if s.serviceAccount != "" {
+		// The client is reused for all signed URLs, as it holds a connection pool
+		signer, err := credentials.NewIamCredentialsClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create IAM credentials client: %w", err)
+		}
+		s.signer = signer
+	}
+
+	objectOptions := []ObjectStorageOption{
+		WithObjectStoragePrefix(s.bucketPrefix),
+		WithObjectStorageArchiveFormat(s.moduleArchiveFormat),
+		WithObjectStorageArchiveConversion(s.convertModuleArchives),
+		WithObjectStorageDecorators(s.decorators...),
+		WithObjectStorageConditionalWrites(true),
+		WithObjectStorageTags(s.tags, s.publisher),
+	}
+	return NewObjectStorage(s, append(objectOptions, s.objectOptions...)...), nil
 }
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/iam/credentials/apiv1/credentialspb"
+	"cloud.google.com/go/storage"
+	"github.com/googleapis/gax-go/v2"
+	assertion "github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+)
+
+type mockGCSSigner struct {
+	requests []*credentialspb.SignBlobRequest
+}
+
+func (m *mockGCSSigner) SignBlob(ctx context.Context, req *credentialspb.SignBlobRequest, opts ...gax.CallOption) (*credentialspb.SignBlobResponse, error) {
+	m.requests = append(m.requests, req)
+	return &credentialspb.SignBlobResponse{SignedBlob: []byte("signature")}, nil
+}
+
+func TestGCSStorage_PresignedURL(t *testing.T) {
+	client, err := storage.NewClient(context.Background(), option.WithoutAuthentication())
+	assertion.NoError(t, err)
+	defer client.Close()
+
+	signer := &mockGCSSigner{}
+	s := &GCSStorage{
+		sc:              client,
+		signer:          signer,
+		bucket:          "boring-registry",
+		serviceAccount:  "boring-registry@project.iam.gserviceaccount.com",
+		signedURLExpiry: time.Minute,
+	}
+
+	signed, err := s.PresignedURL(context.Background(), "providers/hashicorp/random/terraform-provider-random_2.0.0_linux_amd64.zip")
+	assertion.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	assertion.NoError(t, err)
+	assertion.Equal(t, "/boring-registry/providers/hashicorp/random/terraform-provider-random_2.0.0_linux_amd64.zip", u.Path)
+	assertion.Contains(t, u.Query().Get("X-Goog-Credential"), "boring-registry@project.iam.gserviceaccount.com")
+	assertion.Equal(t, "7369676e6174757265", u.Query().Get("X-Goog-Signature"))
+
+	if assertion.Len(t, signer.requests, 1) {
+		assertion.Equal(t, "projects/-/serviceAccounts/boring-registry@project.iam.gserviceaccount.com", signer.requests[0].Name)
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultHTTPMaxIdleConnsPerHost is much higher than the default of net/http, which keeps only 2 idle connections
+	// per host. Bursts of uploads and downloads would otherwise open and close a connection with a TLS handshake each.
+	DefaultHTTPMaxIdleConnsPerHost = 100
+	DefaultHTTPIdleConnTimeout     = 90 * time.Second
+)
+
+// HTTPClientConfig configures the connection pool of the HTTP clients used to access the storage
+type HTTPClientConfig struct {
+	// MaxIdleConnsPerHost is the number of connections per host which are kept open for reuse
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is the time after which idle connections are closed, they are kept open forever if it's 0
+	IdleConnTimeout time.Duration
+
+	// HTTP2 enables HTTP/2 for hosts which support it, which multiplexes requests over a single connection
+	HTTP2 bool
+}
+
+// DefaultHTTPClientConfig returns the HTTPClientConfig used if nothing else is configured
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		MaxIdleConnsPerHost: DefaultHTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultHTTPIdleConnTimeout,
+		HTTP2:               true,
+	}
+}
+
+// configureTransport applies the configuration to the transport
+func (c HTTPClientConfig) configureTransport(t *http.Transport) {
+	t.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	if t.MaxIdleConns != 0 && t.MaxIdleConns < c.MaxIdleConnsPerHost {
+		t.MaxIdleConns = c.MaxIdleConnsPerHost
+	}
+	t.IdleConnTimeout = c.IdleConnTimeout
+
+	t.ForceAttemptHTTP2 = c.HTTP2
+	if !c.HTTP2 {
+		// A non-nil empty map disables HTTP/2
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+}
+
+// Transport returns a new transport with the connection pool configured, it should be shared by all requests
+func (c HTTPClientConfig) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	c.configureTransport(t)
+	return t
+}
+
+// Client returns a new client with the connection pool configured, it should be shared by all requests
+func (c HTTPClientConfig) Client() *http.Client {
+	return &http.Client{Transport: c.Transport()}
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClientConfig_Transport(t *testing.T) {
+	transport := DefaultHTTPClientConfig().Transport()
+	assertion.Equal(t, DefaultHTTPMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assertion.Equal(t, DefaultHTTPIdleConnTimeout, transport.IdleConnTimeout)
+	assertion.True(t, transport.ForceAttemptHTTP2)
+	assertion.Nil(t, transport.TLSNextProto)
+
+	transport = HTTPClientConfig{MaxIdleConnsPerHost: 500, IdleConnTimeout: time.Minute}.Transport()
+	assertion.Equal(t, 500, transport.MaxIdleConnsPerHost)
+	assertion.Equal(t, 500, transport.MaxIdleConns)
+	assertion.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assertion.False(t, transport.ForceAttemptHTTP2)
+	assertion.NotNil(t, transport.TLSNextProto)
+	assertion.Empty(t, transport.TLSNextProto)
+}
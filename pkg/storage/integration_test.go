@@ -0,0 +1,226 @@
+//go:build integration
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// The integration tests run against the storage backend emulators from test/integration/docker-compose.yaml.
+// Backends are skipped if their endpoint is not configured, see `make integration`.
+const (
+	envLocalStackEndpoint = "BORING_REGISTRY_TEST_LOCALSTACK_ENDPOINT"
+	envMinIOEndpoint      = "BORING_REGISTRY_TEST_MINIO_ENDPOINT"
+	envGCSEmulatorHost    = "STORAGE_EMULATOR_HOST"
+
+	integrationBucket = "boring-registry"
+)
+
+func TestIntegration_S3(t *testing.T) {
+	for _, env := range []string{envLocalStackEndpoint, envMinIOEndpoint} {
+		t.Run(env, func(t *testing.T) {
+			endpoint := os.Getenv(env)
+			if endpoint == "" {
+				t.Skipf("%s is not set", env)
+			}
+			waitForEndpoint(t, endpoint)
+
+			ctx := context.Background()
+			createS3Bucket(t, ctx, endpoint)
+
+			s, err := NewS3Storage(ctx, integrationBucket,
+				WithS3StorageBucketEndpoint(endpoint),
+				WithS3StorageBucketRegion("us-east-1"),
+				WithS3StoragePathStyle(true),
+				WithS3StorageBucketPrefix(uniquePrefix()),
+				WithS3StorageSignedUrlExpiry(time.Minute),
+			)
+			mustNoError(t, err)
+
+			testIntegrationStorage(t, s.(*ObjectStorage), true)
+		})
+	}
+}
+
+func TestIntegration_GCS(t *testing.T) {
+	host := os.Getenv(envGCSEmulatorHost)
+	if host == "" {
+		t.Skipf("%s is not set", envGCSEmulatorHost)
+	}
+	waitForEndpoint(t, "http://"+host)
+
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	mustNoError(t, err)
+	defer client.Close()
+
+	if err := client.Bucket(integrationBucket).Create(ctx, "boring-registry", nil); err != nil && !strings.Contains(err.Error(), "409") {
+		mustNoError(t, err)
+	}
+
+	// The emulator doesn't provide credentials, so URLs are signed with a mocked IAM Credentials API
+	backend := &GCSStorage{
+		sc:              client,
+		signer:          &mockGCSSigner{},
+		bucket:          integrationBucket,
+		serviceAccount:  "boring-registry@project.iam.gserviceaccount.com",
+		signedURLExpiry: time.Minute,
+	}
+
+	testIntegrationStorage(t, NewObjectStorage(backend, WithObjectStoragePrefix(uniquePrefix())), false)
+}
+
+// testIntegrationStorage exercises the upload, list, and get paths of modules, providers, and download statistics.
+// The presigned URLs are only downloaded if the backend validates them.
+func testIntegrationStorage(t *testing.T, s *ObjectStorage, download bool) {
+	ctx := context.Background()
+
+	t.Run("modules", func(t *testing.T) {
+		assert := assertion.New(t)
+
+		_, err := s.GetModule(ctx, "acme", "vpc", "aws", "1.0.0")
+		assert.ErrorIs(err, module.ErrModuleNotFound)
+
+		for _, version := range []string{"1.0.0", "1.1.0"} {
+			_, err := s.UploadModule(ctx, "acme", "vpc", "aws", version, strings.NewReader("module "+version))
+			mustNoError(t, err)
+		}
+
+		_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module 1.0.0"))
+		assert.ErrorIs(err, module.ErrModuleAlreadyExists)
+
+		m, err := s.GetModule(ctx, "acme", "vpc", "aws", "1.1.0")
+		mustNoError(t, err)
+		if download {
+			assert.Equal("module 1.1.0", downloadURL(t, m.DownloadURL))
+		}
+
+		modules, err := s.ListModuleVersions(ctx, "acme", "vpc", "aws")
+		assert.NoError(err)
+		assert.Len(modules, 2)
+	})
+
+	t.Run("providers", func(t *testing.T) {
+		assert := assertion.New(t)
+
+		archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+		shasum := sha256.Sum256([]byte("provider archive"))
+		files := map[string]string{
+			archive: "provider archive",
+			"terraform-provider-random_2.0.0_SHA256SUMS":     fmt.Sprintf("%s  %s\n", hex.EncodeToString(shasum[:]), archive),
+			"terraform-provider-random_2.0.0_SHA256SUMS.sig": "signature",
+		}
+		for f, content := range files {
+			mustNoError(t, s.UploadProviderReleaseFiles(ctx, "acme", "random", f, strings.NewReader(content)))
+		}
+		mustNoError(t, s.uploadSigningKeys(ctx, internalProviderType, "", "acme", "", &core.SigningKeys{
+			GPGPublicKeys: []core.GPGPublicKey{{KeyID: "ABCDEF", ASCIIArmor: "armor"}},
+		}))
+
+		err := s.UploadProviderReleaseFiles(ctx, "acme", "random", archive, strings.NewReader("provider archive"))
+		assert.ErrorIs(err, core.ErrObjectAlreadyExists)
+
+		p, err := s.GetProvider(ctx, "acme", "random", "2.0.0", "linux", "amd64")
+		mustNoError(t, err)
+		assert.Equal(hex.EncodeToString(shasum[:]), p.Shasum)
+		assert.Equal("ABCDEF", p.SigningKeys.GPGPublicKeys[0].KeyID)
+		if download {
+			assert.Equal("provider archive", downloadURL(t, p.DownloadURL))
+		}
+
+		versions, err := s.ListProviderVersions(ctx, "acme", "random")
+		assert.NoError(err)
+		assert.Len(versions.Versions, 1)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats := &core.DownloadStats{}
+		stats.Add("1.0.0", 2)
+		mustNoError(t, s.UploadDownloadStats(ctx, "modules/acme/vpc/aws", stats))
+
+		got, err := s.DownloadStats(ctx, "modules/acme/vpc/aws")
+		assertion.NoError(t, err)
+		assertion.Equal(t, stats, got)
+	})
+}
+
+func createS3Bucket(t *testing.T, ctx context.Context, endpoint string) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"))
+	mustNoError(t, err)
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+		o.UsePathStyle = true
+	})
+
+	_, err = client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(integrationBucket)})
+	if err != nil && !strings.Contains(err.Error(), "BucketAlreadyOwnedByYou") {
+		mustNoError(t, err)
+	}
+}
+
+// uniquePrefix isolates the objects of every test run, so that the emulators don't need to be reset
+func uniquePrefix() string {
+	return fmt.Sprintf("integration-%d", time.Now().UnixNano())
+}
+
+func downloadURL(t *testing.T, u string) string {
+	resp, err := http.Get(u)
+	mustNoError(t, err)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d downloading %s", resp.StatusCode, u)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, resp.Body)
+	mustNoError(t, err)
+	return buf.String()
+}
+
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForEndpoint waits until the emulator accepts connections, as the containers take a while to start
+func waitForEndpoint(t *testing.T, endpoint string) {
+	u, err := url.Parse(endpoint)
+	mustNoError(t, err)
+
+	deadline := time.Now().Add(time.Minute)
+	for {
+		conn, err := net.DialTimeout("tcp", u.Host, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is not reachable: %v", endpoint, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,467 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+)
+
+// ErrInventoryInvalid is returned if an inventory report is missing or can't be read
+var ErrInventoryInvalid = errors.New("invalid inventory report")
+
+// InventoryFormat is the format of the bucket inventory reports
+type InventoryFormat string
+
+const (
+	// InventoryFormatS3 reads the CSV reports of S3 Inventory, which are located by their manifest.json
+	InventoryFormatS3 InventoryFormat = "s3"
+
+	// InventoryFormatGCS reads the CSV reports of GCS Storage Insights, which are located by their manifest file
+	InventoryFormatGCS InventoryFormat = "gcs"
+
+	// DefaultInventoryMaxAge is the age of a report after which the objects are listed live again, reports are created daily
+	DefaultInventoryMaxAge = 48 * time.Hour
+)
+
+// Inventory serves the listings of a Backend from the latest bucket inventory report, so that big registries
+// don't have to send LIST requests for every listing. Objects uploaded or deleted through the Decorator after the
+// report was created are applied to the listings, objects changed by other processes appear with the next report.
+// The objects are listed live as long as no report was read or if the latest report is older than the max age.
+type Inventory struct {
+	source Backend
+	prefix string
+	format InventoryFormat
+	maxAge time.Duration
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	objects []Object
+	created time.Time
+	// changes holds the objects changed through the Decorator, nil for deleted objects
+	changes map[string]inventoryChange
+}
+
+type inventoryChange struct {
+	object *Object
+	at     time.Time
+}
+
+// InventoryOption configures the Inventory
+type InventoryOption func(*Inventory)
+
+// WithInventoryMaxAge sets the age of a report after which the objects are listed live again
+func WithInventoryMaxAge(maxAge time.Duration) InventoryOption {
+	return func(i *Inventory) {
+		i.maxAge = maxAge
+	}
+}
+
+// NewInventory reads the reports of the given format from the prefix of the source, which is usually the destination bucket of the reports.
+// The latest report is read in the given interval until the context is cancelled,
+// a non-positive interval disables the periodic refresh, e.g. when Refresh is called by the scheduler instead.
+func NewInventory(ctx context.Context, source Backend, prefix string, format InventoryFormat, interval time.Duration, options ...InventoryOption) (*Inventory, error) {
+	if format != InventoryFormatS3 && format != InventoryFormatGCS {
+		return nil, fmt.Errorf("unsupported inventory format %q, expected %s or %s", format, InventoryFormatS3, InventoryFormatGCS)
+	}
+
+	i := &Inventory{
+		source:  source,
+		prefix:  prefix,
+		format:  format,
+		maxAge:  DefaultInventoryMaxAge,
+		logger:  slog.Default().With(slog.String(loglevel.ComponentKey, "inventory")),
+		now:     time.Now,
+		changes: make(map[string]inventoryChange),
+	}
+	for _, option := range options {
+		option(i)
+	}
+
+	go i.run(ctx, interval)
+
+	return i, nil
+}
+
+// run reads the latest report right away and then in the given interval
+func (i *Inventory) run(ctx context.Context, interval time.Duration) {
+	refresh := func() {
+		if err := i.Refresh(ctx); err != nil && ctx.Err() == nil {
+			i.logger.Error("failed to refresh inventory", slog.String("err", err.Error()))
+		}
+	}
+	refresh()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			refresh()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh reads the latest report, if it's newer than the current one
+func (i *Inventory) Refresh(ctx context.Context) error {
+	var (
+		objects []Object
+		created time.Time
+		err     error
+	)
+	switch i.format {
+	case InventoryFormatS3:
+		objects, created, err = i.readS3(ctx, i.current())
+	case InventoryFormatGCS:
+		objects, created, err = i.readGCS(ctx, i.current())
+	}
+	if err != nil {
+		return err
+	} else if objects == nil {
+		// The current report is still the latest one
+		return nil
+	}
+
+	sort.Slice(objects, func(a, b int) bool { return objects[a].Key < objects[b].Key })
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.objects = objects
+	i.created = created
+	for key, change := range i.changes {
+		// The changes before the report was created are contained in it
+		if change.at.Before(created) {
+			delete(i.changes, key)
+		}
+	}
+
+	i.logger.Info("refreshed inventory", slog.Int("objects", len(objects)), slog.Time("created", created))
+	return nil
+}
+
+// current returns the creation time of the current report
+func (i *Inventory) current() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.created
+}
+
+// list returns the objects with the prefix of the current report, or false if it's missing or outdated
+func (i *Inventory) list(prefix string) ([]Object, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.created.IsZero() || i.now().Sub(i.created) > i.maxAge {
+		return nil, false
+	}
+
+	objects := []Object{}
+	start := sort.Search(len(i.objects), func(n int) bool { return i.objects[n].Key >= prefix })
+	for _, obj := range i.objects[start:] {
+		if !strings.HasPrefix(obj.Key, prefix) {
+			break
+		}
+		if _, changed := i.changes[obj.Key]; !changed {
+			objects = append(objects, obj)
+		}
+	}
+	for key, change := range i.changes {
+		if strings.HasPrefix(key, prefix) && change.object != nil {
+			objects = append(objects, *change.object)
+		}
+	}
+
+	sort.Slice(objects, func(a, b int) bool { return objects[a].Key < objects[b].Key })
+	return objects, true
+}
+
+func (i *Inventory) record(key string, object *Object) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.changes[key] = inventoryChange{object: object, at: i.now()}
+}
+
+// Decorator returns the Decorator serving the listings from the inventory
+func (i *Inventory) Decorator() Decorator {
+	return func(next Backend) Backend {
+		return &inventoryBackend{
+			next:      next,
+			inventory: i,
+		}
+	}
+}
+
+type inventoryBackend struct {
+	next      Backend
+	inventory *Inventory
+}
+
+func (b *inventoryBackend) Exists(ctx context.Context, key string) (bool, error) {
+	return b.next.Exists(ctx, key)
+}
+
+func (b *inventoryBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	return b.next.Download(ctx, key)
+}
+
+func (b *inventoryBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.next.Open(ctx, key)
+}
+
+func (b *inventoryBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	counter := &countingReader{r: reader}
+	if err := b.next.Upload(ctx, key, counter); err != nil {
+		return err
+	}
+
+	b.inventory.record(key, &Object{Key: key, Size: counter.n, LastModified: b.inventory.now()})
+	return nil
+}
+
+func (b *inventoryBackend) Delete(ctx context.Context, key string) error {
+	if err := b.next.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	b.inventory.record(key, nil)
+	return nil
+}
+
+func (b *inventoryBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	if objects, ok := b.inventory.list(prefix); ok {
+		return objects, nil
+	}
+	return b.next.List(ctx, prefix)
+}
+
+func (b *inventoryBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	return b.next.PresignedURL(ctx, key)
+}
+
+func (b *inventoryBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.next.GetDownloadUrl(ctx, url)
+}
+
+// countingReader counts the bytes read, which is the size of an uploaded object
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// s3InventoryManifest is the manifest.json of an S3 Inventory report
+type s3InventoryManifest struct {
+	FileFormat        string `json:"fileFormat"`
+	FileSchema        string `json:"fileSchema"`
+	CreationTimestamp string `json:"creationTimestamp"`
+	Files             []struct {
+		Key string `json:"key"`
+	} `json:"files"`
+}
+
+// readS3 reads the latest S3 Inventory report below the prefix, which is <destination prefix>/<source bucket>/<configuration ID>.
+// The reports are stored in directories named by their date, the keys of the CSV files are relative to the destination bucket.
+// nil objects are returned if the latest report isn't newer than current.
+func (i *Inventory) readS3(ctx context.Context, current time.Time) ([]Object, time.Time, error) {
+	prefix := i.prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	listing, err := i.source.List(ctx, prefix)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to list inventory reports: %w", err)
+	}
+	var latest string
+	for _, obj := range listing {
+		// The hive directory contains symlinks to the same CSV files
+		if path.Base(obj.Key) == "manifest.json" && !strings.Contains(obj.Key, "/hive/") && obj.Key > latest {
+			latest = obj.Key
+		}
+	}
+	if latest == "" {
+		return nil, time.Time{}, fmt.Errorf("%w: no inventory report found below %s", ErrInventoryInvalid, i.prefix)
+	}
+
+	data, err := i.source.Download(ctx, latest)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to download inventory manifest %s: %w", latest, err)
+	}
+	var manifest s3InventoryManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: failed to decode %s: %w", ErrInventoryInvalid, latest, err)
+	}
+	if manifest.FileFormat != "CSV" {
+		return nil, time.Time{}, fmt.Errorf("%w: unsupported file format %s, only CSV is supported", ErrInventoryInvalid, manifest.FileFormat)
+	}
+	millis, err := strconv.ParseInt(manifest.CreationTimestamp, 10, 64)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: invalid creation timestamp %q", ErrInventoryInvalid, manifest.CreationTimestamp)
+	}
+	created := time.UnixMilli(millis)
+	if !created.After(current) {
+		return nil, current, nil
+	}
+
+	columns := make(map[string]int)
+	for n, field := range strings.Split(manifest.FileSchema, ",") {
+		columns[strings.TrimSpace(field)] = n
+	}
+
+	var objects []Object
+	for _, file := range manifest.Files {
+		rows, err := i.readCSV(ctx, file.Key)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		for _, row := range rows {
+			// Reports of versioned buckets contain all versions and the delete markers
+			if csvField(row, columns, "IsLatest") == "false" || csvField(row, columns, "IsDeleteMarker") == "true" {
+				continue
+			}
+
+			key, err := url.QueryUnescape(csvField(row, columns, "Key"))
+			if err != nil {
+				return nil, time.Time{}, fmt.Errorf("%w: invalid key in %s: %w", ErrInventoryInvalid, file.Key, err)
+			}
+			obj := Object{Key: key}
+			obj.Size, _ = strconv.ParseInt(csvField(row, columns, "Size"), 10, 64)
+			obj.LastModified, _ = time.Parse(time.RFC3339, csvField(row, columns, "LastModifiedDate"))
+			objects = append(objects, obj)
+		}
+	}
+
+	return objects, created, nil
+}
+
+// gcsInventoryManifest is the manifest of a GCS Storage Insights inventory report
+type gcsInventoryManifest struct {
+	SnapshotTime          time.Time `json:"snapshot_time"`
+	ReportShardsFileNames []string  `json:"report_shards_file_names"`
+}
+
+// readGCS reads the latest GCS Storage Insights report below the prefix, which is the destination path of the report configuration.
+// The reports need a header row and the name, size, and updated columns, the shards are located next to their manifest.
+// nil objects are returned if the latest report isn't newer than current.
+func (i *Inventory) readGCS(ctx context.Context, current time.Time) ([]Object, time.Time, error) {
+	listing, err := i.source.List(ctx, i.prefix)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to list inventory reports: %w", err)
+	}
+
+	var latest *gcsInventoryManifest
+	var latestKey string
+	for _, obj := range listing {
+		if !strings.HasSuffix(obj.Key, "_manifest.json") {
+			continue
+		}
+		data, err := i.source.Download(ctx, obj.Key)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to download inventory manifest %s: %w", obj.Key, err)
+		}
+		var manifest gcsInventoryManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, time.Time{}, fmt.Errorf("%w: failed to decode %s: %w", ErrInventoryInvalid, obj.Key, err)
+		}
+		if latest == nil || manifest.SnapshotTime.After(latest.SnapshotTime) {
+			latest, latestKey = &manifest, obj.Key
+		}
+	}
+	if latest == nil {
+		return nil, time.Time{}, fmt.Errorf("%w: no inventory report found below %s", ErrInventoryInvalid, i.prefix)
+	}
+	if !latest.SnapshotTime.After(current) {
+		return nil, current, nil
+	}
+
+	var objects []Object
+	for _, name := range latest.ReportShardsFileNames {
+		rows, err := i.readCSV(ctx, path.Join(path.Dir(latestKey), name))
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		columns := make(map[string]int)
+		for n, name := range rows[0] {
+			columns[name] = n
+		}
+		if _, ok := columns["name"]; !ok {
+			return nil, time.Time{}, fmt.Errorf("%w: the report %s lacks the name column", ErrInventoryInvalid, name)
+		}
+		for _, row := range rows[1:] {
+			obj := Object{Key: csvField(row, columns, "name")}
+			obj.Size, _ = strconv.ParseInt(csvField(row, columns, "size"), 10, 64)
+			obj.LastModified, _ = time.Parse(time.RFC3339, csvField(row, columns, "updated"))
+			objects = append(objects, obj)
+		}
+	}
+
+	// An empty bucket still has an inventory
+	if objects == nil {
+		objects = []Object{}
+	}
+	return objects, latest.SnapshotTime, nil
+}
+
+// readCSV downloads and parses the CSV file, which is decompressed if its key ends with .gz
+func (i *Inventory) readCSV(ctx context.Context, key string) ([][]string, error) {
+	data, err := i.source.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download inventory file %s: %w", key, err)
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if strings.HasSuffix(key, ".gz") {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decompress %s: %w", ErrInventoryInvalid, key, err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	cr := csv.NewReader(r)
+	// The columns of S3 reports depend on the configuration and are described by the manifest
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse %s: %w", ErrInventoryInvalid, key, err)
+	}
+	return rows, nil
+}
+
+// csvField returns the value of the named column of the row, or an empty string if it's missing
+func csvField(row []string, columns map[string]int, name string) string {
+	n, ok := columns[name]
+	if !ok || n >= len(row) {
+		return ""
+	}
+	return row[n]
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func TestInventory_S3(t *testing.T) {
+	assert := assertion.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	created := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	source := newMemoryBackend()
+	assert.NoError(source.Upload(ctx, "inventory/registry/daily/2024-01-01T00-00Z/manifest.json", strings.NewReader(`{"fileFormat":"CSV","fileSchema":"Bucket, Key, Size, LastModifiedDate","creationTimestamp":"1704067200000","files":[]}`)))
+	assert.NoError(source.Upload(ctx, "inventory/registry/daily/2024-01-02T00-00Z/files/report.csv.gz", bytes.NewReader(gzipped(
+		`"registry","modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz","10","2024-01-01T12:00:00.000Z"`+"\n"+
+			`"registry","modules/acme/vpc/aws/acme-vpc-aws-1.1.0%2Brc1.tar.gz","20","2024-01-01T12:00:00.000Z"`+"\n"+
+			`"registry","providers/acme/dummy/terraform-provider-dummy_1.0.0_linux_amd64.zip","30","2024-01-01T12:00:00.000Z"`+"\n",
+	))))
+	assert.NoError(source.Upload(ctx, "inventory/registry/daily/2024-01-02T00-00Z/manifest.json", strings.NewReader(fmt.Sprintf(
+		`{"fileFormat":"CSV","fileSchema":"Bucket, Key, Size, LastModifiedDate","creationTimestamp":"%d","files":[{"key":"inventory/registry/daily/2024-01-02T00-00Z/files/report.csv.gz"}]}`,
+		created.UnixMilli(),
+	))))
+
+	next := newMemoryBackend()
+	assert.NoError(next.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-2.0.0.tar.gz", strings.NewReader("live")))
+
+	// The objects are listed live until a report was read
+	missing, err := NewInventory(ctx, newMemoryBackend(), "inventory/registry/daily", InventoryFormatS3, 0)
+	assert.NoError(err)
+	assert.Error(missing.Refresh(ctx))
+	objects, err := Decorate(next, missing.Decorator()).List(ctx, "modules/")
+	assert.NoError(err)
+	assert.Len(objects, 1)
+
+	inventory, err := NewInventory(ctx, source, "inventory/registry/daily", InventoryFormatS3, 0)
+	assert.NoError(err)
+	backend := Decorate(next, inventory.Decorator())
+	assert.NoError(inventory.Refresh(ctx))
+	objects, err = backend.List(ctx, "modules/acme/vpc/aws/")
+	assert.NoError(err)
+	if assert.Len(objects, 2) {
+		assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", objects[0].Key)
+		assert.Equal(int64(10), objects[0].Size)
+		assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.1.0+rc1.tar.gz", objects[1].Key)
+	}
+
+	// Changes through the decorator are applied to the listings
+	assert.NoError(backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.2.0.tar.gz", strings.NewReader("module")))
+	assert.NoError(backend.Delete(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz"))
+	objects, err = backend.List(ctx, "modules/acme/vpc/aws/")
+	assert.NoError(err)
+	if assert.Len(objects, 2) {
+		assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.1.0+rc1.tar.gz", objects[0].Key)
+		assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.2.0.tar.gz", objects[1].Key)
+		assert.Equal(int64(6), objects[1].Size)
+	}
+
+	objects, err = backend.List(ctx, "")
+	assert.NoError(err)
+	assert.Len(objects, 3)
+
+	// Outdated reports aren't used
+	inventory.now = func() time.Time { return created.Add(DefaultInventoryMaxAge + time.Minute) }
+	objects, err = backend.List(ctx, "modules/")
+	assert.NoError(err)
+	assert.Len(objects, 2)
+}
+
+func TestInventory_GCS(t *testing.T) {
+	assert := assertion.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := newMemoryBackend()
+	assert.NoError(source.Upload(ctx, "reports/daily_2024-01-01_manifest.json", strings.NewReader(`{"snapshot_time":"2024-01-01T00:00:00Z","report_shards_file_names":["daily_2024-01-01_0.csv"]}`)))
+	assert.NoError(source.Upload(ctx, "reports/daily_2024-01-01_0.csv", strings.NewReader("name,size,updated\nmodules/acme/old.tar.gz,1,2024-01-01T00:00:00Z\n")))
+	assert.NoError(source.Upload(ctx, "reports/daily_2024-01-02_manifest.json", strings.NewReader(`{"snapshot_time":"2024-01-02T00:00:00Z","report_shards_file_names":["daily_2024-01-02_0.csv","daily_2024-01-02_1.csv"]}`)))
+	assert.NoError(source.Upload(ctx, "reports/daily_2024-01-02_0.csv", strings.NewReader("bucket,name,size,updated\nregistry,modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz,10,2024-01-01T12:00:00Z\n")))
+	assert.NoError(source.Upload(ctx, "reports/daily_2024-01-02_1.csv", strings.NewReader("bucket,name,size,updated\nregistry,modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz,20,2024-01-01T12:00:00Z\n")))
+
+	inventory, err := NewInventory(ctx, source, "reports/", InventoryFormatGCS, 0)
+	assert.NoError(err)
+	inventory.now = func() time.Time { return time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC) }
+	assert.NoError(inventory.Refresh(ctx))
+
+	objects, ok := inventory.list("modules/")
+	assert.True(ok)
+	if assert.Len(objects, 2) {
+		assert.Equal("modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", objects[0].Key)
+		assert.Equal(int64(20), objects[1].Size)
+		assert.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), objects[1].LastModified)
+	}
+
+	_, err = NewInventory(ctx, source, "reports/", "azure", 0)
+	assert.Error(err)
+}
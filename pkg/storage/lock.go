@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"path"
+
+	"github.com/boring-registry/boring-registry/pkg/lock"
+)
+
+// lock acquires the lock of the key, which is a no-op if no Locker is configured
+func (s *ObjectStorage) lock(ctx context.Context, key string) (lock.Unlock, error) {
+	if s.locker == nil {
+		return func() {}, nil
+	}
+	return s.locker.Lock(ctx, key)
+}
+
+// moduleLockKey returns the key locked while a module version is published, which is shared by all of its archive formats
+func moduleLockKey(prefix, namespace, name, provider, version string) string {
+	return path.Join(prefix, string(internalModuleType), namespace, name, provider, version)
+}
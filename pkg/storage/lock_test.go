@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/lock"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// gatedBackend blocks uploads of module archives until the gate is opened
+type gatedBackend struct {
+	*mockBackend
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (b *gatedBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	if strings.HasSuffix(key, ".tar.gz") {
+		b.entered <- struct{}{}
+		<-b.gate
+	}
+	return b.mockBackend.Upload(ctx, key, reader)
+}
+
+func TestObjectStorage_UploadModuleLocked(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	backend := &gatedBackend{mockBackend: newMockBackend(), entered: make(chan struct{}, 2), gate: make(chan struct{})}
+	s := NewObjectStorage(backend, WithObjectStorageLocker(lock.NewMemory(time.Minute)))
+
+	errs := make(chan error, 2)
+	upload := func(content string) {
+		_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader(content))
+		errs <- err
+	}
+	go upload("first")
+	<-backend.entered
+
+	// The second upload waits for the lock instead of passing the existence check
+	go upload("second")
+	select {
+	case <-backend.entered:
+		t.Fatal("the second upload wasn't serialized")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(backend.gate)
+	assert.NoError(<-errs)
+	assert.ErrorIs(<-errs, module.ErrModuleAlreadyExists)
+	assert.Equal("first", string(backend.objects["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz"]))
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+type memoryObject struct {
+	data         []byte
+	lastModified time.Time
+}
+
+// memoryBackend is a Backend keeping all objects in memory, which are lost on restart
+type memoryBackend struct {
+	mu        sync.RWMutex
+	objects   map[string]memoryObject
+	urlPrefix string
+	now       func() time.Time
+}
+
+// Exists checks if an object with the key is kept in memory
+func (b *memoryBackend) Exists(ctx context.Context, key string) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	_, ok := b.objects[key]
+	return ok, nil
+}
+
+// Download returns a copy of the object
+func (b *memoryBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	obj, ok := b.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", core.ErrObjectNotFound, key)
+	}
+
+	return bytes.Clone(obj.data), nil
+}
+
+func (b *memoryBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, err := b.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Upload reads the content into memory and replaces existing objects, unless the upload is conditional
+func (b *memoryBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.objects[key]; ok && ifNotExists(ctx) {
+		return errAlreadyExists(key)
+	}
+	b.objects[key] = memoryObject{
+		data:         data,
+		lastModified: b.now(),
+	}
+	return nil
+}
+
+// Delete removes the object from memory
+func (b *memoryBackend) Delete(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.objects, key)
+	return nil
+}
+
+// List returns all objects with the given prefix sorted by their key
+func (b *memoryBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var objects []Object
+	for key, obj := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, Object{
+				Key:          key,
+				Size:         int64(len(obj.data)),
+				LastModified: obj.lastModified,
+			})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	return objects, nil
+}
+
+// PresignedURL returns the URL of the object below the URL prefix, under which the MemoryStorage serves the objects.
+// The URL is relative to the registry, as the hostname clients use to reach the registry isn't known.
+func (b *memoryBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	return path.Join(b.urlPrefix, key), nil
+}
+
+// GetDownloadUrl isn't supported, as the objects aren't served by a remote storage
+func (b *memoryBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return "", errors.New("the download proxy isn't supported with the in-memory storage")
+}
+
+// MemoryStorage keeps modules and providers in memory, which is useful for tests and demos without a storage backend.
+// It serves the objects itself, so the http.Handler needs to be registered below the URL prefix.
+type MemoryStorage struct {
+	*ObjectStorage
+	backend *memoryBackend
+}
+
+// ServeHTTP serves the content of the object with the key in the path of the request
+func (s *MemoryStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	s.backend.mu.RLock()
+	obj, ok := s.backend.objects[key]
+	s.backend.mu.RUnlock()
+	if !ok {
+		core.HandleErrorResponse(fmt.Errorf("%w: %s", core.ErrObjectNotFound, key), http.StatusNotFound, w)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment;filename="%s"`, path.Base(key)))
+	http.ServeContent(w, r, path.Base(key), obj.lastModified, bytes.NewReader(obj.data))
+}
+
+// MemoryStorageOption provides additional options for the MemoryStorage.
+type MemoryStorageOption func(*memoryStorageOptions)
+
+type memoryStorageOptions struct {
+	urlPrefix             string
+	moduleArchiveFormat   string
+	convertModuleArchives bool
+	decorators            []Decorator
+	objectOptions         []ObjectStorageOption
+}
+
+// WithMemoryStorageURLPrefix configures the path under which the http.Handler of the MemoryStorage is registered.
+func WithMemoryStorageURLPrefix(prefix string) MemoryStorageOption {
+	return func(o *memoryStorageOptions) {
+		o.urlPrefix = prefix
+	}
+}
+
+// WithMemoryStorageArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
+func WithMemoryStorageArchiveFormat(archiveFormat string) MemoryStorageOption {
+	return func(o *memoryStorageOptions) {
+		o.moduleArchiveFormat = archiveFormat
+	}
+}
+
+// WithMemoryStorageArchiveConversion stores all uploaded module archives in the configured format, archives in another format are repackaged
+func WithMemoryStorageArchiveConversion(enabled bool) MemoryStorageOption {
+	return func(o *memoryStorageOptions) {
+		o.convertModuleArchives = enabled
+	}
+}
+
+// WithMemoryStorageDecorators wraps the in-memory backend with the given decorators.
+func WithMemoryStorageDecorators(decorators ...Decorator) MemoryStorageOption {
+	return func(o *memoryStorageOptions) {
+		o.decorators = append(o.decorators, decorators...)
+	}
+}
+
+// WithMemoryStorageObjectOptions applies the options to the ObjectStorage of the in-memory storage, e.g. quotas or the admission controller
+func WithMemoryStorageObjectOptions(options ...ObjectStorageOption) MemoryStorageOption {
+	return func(o *memoryStorageOptions) {
+		o.objectOptions = append(o.objectOptions, options...)
+	}
+}
+
+// NewMemoryStorage returns an empty in-memory storage.
+func NewMemoryStorage(options ...MemoryStorageOption) *MemoryStorage {
+	o := &memoryStorageOptions{
+		urlPrefix: "/",
+	}
+	for _, option := range options {
+		option(o)
+	}
+
+	backend := &memoryBackend{
+		objects:   make(map[string]memoryObject),
+		urlPrefix: o.urlPrefix,
+		now:       time.Now,
+	}
+
+	objectOptions := []ObjectStorageOption{
+		WithObjectStorageArchiveFormat(o.moduleArchiveFormat),
+		WithObjectStorageArchiveConversion(o.convertModuleArchives),
+		WithObjectStorageDecorators(o.decorators...),
+		WithObjectStorageConditionalWrites(true),
+	}
+
+	return &MemoryStorage{
+		ObjectStorage: NewObjectStorage(backend, append(objectOptions, o.objectOptions...)...),
+		backend:       backend,
+	}
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStorage_Modules(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage(WithMemoryStorageURLPrefix("/v1/inmem"))
+
+	_, err := s.GetModule(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.ErrorIs(err, module.ErrModuleNotFound)
+
+	m, err := s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+	assert.Equal("/v1/inmem/modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", m.DownloadURL)
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.ErrorIs(err, module.ErrModuleAlreadyExists)
+
+	modules, err := s.ListModuleVersions(ctx, "acme", "vpc", "aws")
+	assert.NoError(err)
+	assert.Len(modules, 1)
+}
+
+func TestMemoryStorage_Providers(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+	shasum := sha256.Sum256([]byte("provider archive"))
+	files := map[string]string{
+		archive: "provider archive",
+		"terraform-provider-random_2.0.0_SHA256SUMS":     fmt.Sprintf("%s  %s\n", hex.EncodeToString(shasum[:]), archive),
+		"terraform-provider-random_2.0.0_SHA256SUMS.sig": "signature",
+	}
+	for f, content := range files {
+		assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", f, strings.NewReader(content)))
+	}
+	assert.NoError(s.uploadSigningKeys(ctx, internalProviderType, "", "acme", "", &core.SigningKeys{
+		GPGPublicKeys: []core.GPGPublicKey{{KeyID: "ABCDEF", ASCIIArmor: "armor"}},
+	}))
+
+	p, err := s.GetProvider(ctx, "acme", "random", "2.0.0", "linux", "amd64")
+	assert.NoError(err)
+	assert.Equal(hex.EncodeToString(shasum[:]), p.Shasum)
+	assert.Equal("/providers/acme/random/"+archive, p.DownloadURL)
+
+	versions, err := s.ListProviderVersions(ctx, "acme", "random")
+	assert.NoError(err)
+	assert.Len(versions.Versions, 1)
+}
+
+func TestMemoryStorage_ProviderHashes(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("terraform-provider-random_v2.0.0")
+	assert.NoError(err)
+	_, _ = w.Write([]byte("binary"))
+	assert.NoError(zw.Close())
+
+	hashes, err := core.ProviderArchiveHashes(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(err)
+
+	archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", archive, bytes.NewReader(buf.Bytes())))
+	exists, err := s.backend.Exists(ctx, "providers/acme/random/"+archive+".hashes")
+	assert.NoError(err)
+	assert.True(exists)
+
+	stored, err := s.ProviderHashes(ctx, "acme", "random", "2.0.0", "linux", "amd64")
+	assert.NoError(err)
+	assert.Equal(hashes, stored)
+
+	// The hashes of archives uploaded without hashes are computed from the archive
+	legacy := "terraform-provider-random_2.0.0_darwin_arm64.zip"
+	assert.NoError(s.backend.Upload(ctx, "providers/acme/random/"+legacy, bytes.NewReader(buf.Bytes())))
+	computed, err := s.ProviderHashes(ctx, "acme", "random", "2.0.0", "darwin", "arm64")
+	assert.NoError(err)
+	assert.Equal(hashes, computed)
+
+	_, err = s.ProviderHashes(ctx, "acme", "random", "2.0.0", "windows", "amd64")
+	assert.Error(err)
+
+	// The stored hashes are not listed as archives
+	versions, err := s.ListProviderVersions(ctx, "acme", "random")
+	assert.NoError(err)
+	assert.Len(versions.Versions, 1)
+	assert.Len(versions.Versions[0].Platforms, 2)
+}
+
+func TestMemoryStorage_ServeHTTP(t *testing.T) {
+	assert := assertion.New(t)
+	s := NewMemoryStorage(WithMemoryStorageURLPrefix("/v1/inmem"))
+
+	m, err := s.UploadModule(context.Background(), "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/inmem/", http.StripPrefix("/v1/inmem", s))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + m.DownloadURL)
+	assert.NoError(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("module", string(body))
+	assert.Equal(`attachment;filename="acme-vpc-aws-1.0.0.tar.gz"`, resp.Header.Get("Content-Disposition"))
+
+	resp, err = http.Get(server.URL + "/v1/inmem/modules/acme/vpc/aws/acme-vpc-aws-2.0.0.tar.gz")
+	assert.NoError(err)
+	_ = resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+// moduleMetadata is stored in the metadata.json file of a module.
+// It records the digest of every version, which survives deleting and purging the version.
+type moduleMetadata struct {
+	Versions map[string]moduleVersionMetadata `json:"versions"`
+}
+
+type moduleVersionMetadata struct {
+	SHA256      string    `json:"sha256"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// moduleMetadata returns the metadata of the module, a module without metadata file has no recorded versions
+func (s *ObjectStorage) moduleMetadata(ctx context.Context, namespace, name, provider string) (*moduleMetadata, error) {
+	key := moduleMetadataPath(s.prefix, namespace, name, provider)
+
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return &moduleMetadata{Versions: map[string]moduleVersionMetadata{}}, nil
+	}
+
+	data, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var metadata moduleMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal module metadata %s: %w", key, err)
+	}
+	if metadata.Versions == nil {
+		metadata.Versions = map[string]moduleVersionMetadata{}
+	}
+	return &metadata, nil
+}
+
+// recordedArchiveDigest returns the digest of the archive recorded for the module version if digests are immutable.
+// Versions published before digests were recorded have no digest, they're recorded once they're uploaded again.
+func (s *ObjectStorage) recordedArchiveDigest(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	if !s.immutableDigests {
+		return "", nil
+	}
+	metadata, err := s.moduleMetadata(ctx, namespace, name, provider)
+	if err != nil {
+		return "", err
+	}
+	return metadata.Versions[version].SHA256, nil
+}
+
+// recordArchiveDigest adds the digest of the archive of the module version to the metadata file of the module.
+// Digests are only recorded if they're immutable.
+func (s *ObjectStorage) recordArchiveDigest(ctx context.Context, namespace, name, provider, version, checksum string) error {
+	if !s.immutableDigests {
+		return nil
+	}
+
+	// The metadata file is shared by all versions, which are published concurrently
+	unlock, err := s.lock(ctx, modulePathPrefix(s.prefix, namespace, name, provider))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	metadata, err := s.moduleMetadata(ctx, namespace, name, provider)
+	if err != nil {
+		return err
+	}
+	if metadata.Versions[version].SHA256 == checksum {
+		return nil
+	}
+	metadata.Versions[version] = moduleVersionMetadata{SHA256: checksum, PublishedAt: time.Now().UTC()}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	ctx = s.tagged(ctx, namespace, name, "")
+	if err := s.backend.Upload(ctx, moduleMetadataPath(s.prefix, namespace, name, provider), bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%v: failed to upload module metadata: %w", module.ErrModuleUploadFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_ImmutableDigests(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	backend := newMockBackend()
+	s := NewObjectStorage(backend, WithObjectStorageImmutableDigests(true))
+	archiveChecksum := "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3"
+
+	_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(err)
+
+	var metadata moduleMetadata
+	assert.NoError(json.Unmarshal(backend.objects["modules/hashicorp/consul/aws/metadata.json"], &metadata))
+	assert.Equal(archiveChecksum, metadata.Versions["1.0.0"].SHA256)
+	assert.False(metadata.Versions["1.0.0"].PublishedAt.IsZero())
+
+	modules, err := s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assert.NoError(err)
+	if assert.Len(modules, 1) {
+		assert.Equal(archiveChecksum, modules[0].SHA256)
+	}
+
+	// Only archives with the recorded digest can replace the version, whether the body can be rewound or not
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("replaced"))
+	assert.ErrorIs(err, module.ErrModuleImmutable)
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", io.MultiReader(strings.NewReader("replaced")))
+	assert.ErrorIs(err, module.ErrModuleImmutable)
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", io.MultiReader(strings.NewReader("archive")))
+	assert.NoError(err)
+
+	checksum, err := s.GetModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal(archiveChecksum, checksum)
+
+	// The digest outlives the purged version
+	assert.NoError(s.DeleteModule(ctx, "hashicorp", "consul", "aws", "1.0.0"))
+	_, err = s.Purge(ctx, time.Now().Add(time.Hour))
+	assert.NoError(err)
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("replaced"))
+	assert.ErrorIs(err, module.ErrModuleImmutable)
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(err)
+}
+
+func TestObjectStorage_ImmutableDigests_Unrecorded(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	backend := newMockBackend()
+
+	// Versions published without recording digests keep the content of their archive
+	_, err := NewObjectStorage(backend).UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(err)
+	assert.NotContains(backend.objects, "modules/hashicorp/consul/aws/metadata.json")
+
+	s := NewObjectStorage(backend, WithObjectStorageImmutableDigests(true))
+	modules, err := s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assert.NoError(err)
+	if assert.Len(modules, 1) {
+		assert.Empty(modules[0].SHA256)
+	}
+
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("replaced"))
+	assert.ErrorIs(err, module.ErrModuleImmutable)
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(err)
+
+	modules, err = s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assert.NoError(err)
+	if assert.Len(modules, 1) {
+		assert.Equal("0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3", modules[0].SHA256)
+	}
+}
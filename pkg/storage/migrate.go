@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultMigrationConcurrency is the number of objects copied in parallel by a Migrator
+const DefaultMigrationConcurrency = 8
+
+// ErrMigrationChecksum is returned if an object was changed while it was copied to the migration target
+var ErrMigrationChecksum = errors.New("checksum mismatch")
+
+// MigrationResult summarizes a migration between two Backends
+type MigrationResult struct {
+	Copied   int `json:"copied"`
+	UpToDate int `json:"up_to_date"`
+	Failed   int `json:"failed"`
+}
+
+// Migrator copies all objects of a Backend to another Backend, e.g. to switch to a different cloud provider.
+// Every copied object is read back from the target and compared with the checksum of the source.
+// Objects which exist in the target with the same size are skipped, so that an interrupted migration can be resumed.
+type Migrator struct {
+	source      Backend
+	target      Backend
+	concurrency int
+	logger      *slog.Logger
+}
+
+// Migrate copies the objects which are missing in the target or differ in size.
+// Objects which fail to be copied don't stop the migration, they are returned as a joined error.
+func (m *Migrator) Migrate(ctx context.Context) (MigrationResult, error) {
+	var result MigrationResult
+
+	objects, err := m.source.List(ctx, "")
+	if err != nil {
+		return result, fmt.Errorf("failed to list objects to migrate: %w", err)
+	}
+	existing, err := m.target.List(ctx, "")
+	if err != nil {
+		return result, fmt.Errorf("failed to list objects of the migration target: %w", err)
+	}
+
+	sizes := make(map[string]int64, len(existing))
+	for _, obj := range existing {
+		sizes[obj.Key] = obj.Size
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	var group errgroup.Group
+	group.SetLimit(max(m.concurrency, 1))
+
+	for _, obj := range objects {
+		if size, ok := sizes[obj.Key]; ok && size == obj.Size {
+			result.UpToDate++
+			continue
+		}
+
+		group.Go(func() error {
+			err := m.copy(ctx, obj.Key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				m.logger.Warn("failed to migrate object", slog.String("key", obj.Key), slog.String("err", err.Error()))
+				errs = append(errs, err)
+				result.Failed++
+				return nil
+			}
+			result.Copied++
+			m.logger.Debug("migrated object", slog.String("key", obj.Key))
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	m.logger.Info("migrated storage",
+		slog.Int("copied", result.Copied),
+		slog.Int("up-to-date", result.UpToDate),
+		slog.Int("failed", result.Failed),
+	)
+
+	return result, errors.Join(errs...)
+}
+
+// copy uploads the object to the target and verifies it by reading it back.
+// Objects failing the verification are deleted from the target, so that they're copied again when the migration is resumed.
+func (m *Migrator) copy(ctx context.Context, key string) error {
+	data, err := m.source.Download(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", key, err)
+	}
+	expected := sha256.Sum256(data)
+
+	if err := m.target.Upload(ctx, key, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", key, err)
+	}
+
+	copied, err := m.target.Download(ctx, key)
+	if err == nil && sha256.Sum256(copied) != expected {
+		err = fmt.Errorf("%w: expected sha256:%x", ErrMigrationChecksum, expected)
+	}
+	if err != nil {
+		if deleteErr := m.target.Delete(ctx, key); deleteErr != nil {
+			err = errors.Join(err, deleteErr)
+		}
+		return fmt.Errorf("failed to verify %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// MigratorOption provides additional options for the Migrator.
+type MigratorOption func(*Migrator)
+
+// WithMigrationConcurrency configures the number of objects copied in parallel.
+func WithMigrationConcurrency(concurrency int) MigratorOption {
+	return func(m *Migrator) {
+		m.concurrency = concurrency
+	}
+}
+
+// NewMigrator returns a Migrator copying the objects of the source to the target.
+func NewMigrator(source, target Backend, options ...MigratorOption) *Migrator {
+	m := &Migrator{
+		source:      source,
+		target:      target,
+		concurrency: DefaultMigrationConcurrency,
+		logger:      slog.Default(),
+	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	return m
+}
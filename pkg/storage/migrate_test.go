@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// corruptingBackend flips the content of the uploads of a single key
+type corruptingBackend struct {
+	*memoryBackend
+	key string
+}
+
+func (b *corruptingBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	if key != b.key {
+		return b.memoryBackend.Upload(ctx, key, reader)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return b.memoryBackend.Upload(ctx, key, bytes.NewReader(bytes.ToUpper(data)))
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	source := newMemoryBackend()
+	for i := range 20 {
+		assert.NoError(source.Upload(ctx, fmt.Sprintf("modules/acme/vpc/aws/acme-vpc-aws-1.0.%d.tar.gz", i), strings.NewReader("module")))
+	}
+	assert.NoError(source.Upload(ctx, "providers/acme/signing-keys.json", strings.NewReader("keys")))
+
+	target := &corruptingBackend{memoryBackend: newMemoryBackend(), key: "providers/acme/signing-keys.json"}
+	assert.NoError(target.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", strings.NewReader("module")))
+
+	result, err := NewMigrator(source, target, WithMigrationConcurrency(4)).Migrate(ctx)
+	assert.ErrorIs(err, ErrMigrationChecksum)
+	assert.Equal(MigrationResult{Copied: 19, UpToDate: 1, Failed: 1}, result)
+
+	// Objects failing the verification aren't left behind in the target
+	exists, err := target.Exists(ctx, "providers/acme/signing-keys.json")
+	assert.NoError(err)
+	assert.False(exists)
+
+	// The migration is resumed with the objects missing in the target
+	target.key = ""
+	result, err = NewMigrator(source, target).Migrate(ctx)
+	assert.NoError(err)
+	assert.Equal(MigrationResult{Copied: 1, UpToDate: 20}, result)
+	data, err := target.Download(ctx, "providers/acme/signing-keys.json")
+	assert.NoError(err)
+	assert.Equal("keys", string(data))
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+)
+
+// namespaceOffsets are the number of path segments between the top-level directory of the layout and the namespace,
+// e.g. stats/modules/<namespace>. The offset of the mirrored providers is negative, as they don't belong to a namespace of the registry.
+var namespaceOffsets = map[string]int{
+	string(internalModuleType):   1,
+	string(internalProviderType): 1,
+	quarantineDir:                2,
+	subdirDir:                    2,
+	"stats":                      2,
+	"consumers":                  2,
+	"channels":                   2,
+	"mirror":                     -1,
+}
+
+// keyNamespace returns the namespace the key belongs to and whether it's known.
+// The key may be a list prefix, in which case the namespace is only known if it's followed by a slash.
+// Like moduleFromObject, the first known top-level directory is looked up, so the storage prefix can be skipped.
+func keyNamespace(key string) (string, bool) {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		offset, ok := namespaceOffsets[part]
+		if !ok {
+			continue
+		}
+		if offset < 0 || i+offset+1 >= len(parts) {
+			return "", false
+		}
+		return parts[i+offset], true
+	}
+	return "", false
+}
+
+// namespaceBackend stores the objects of the routed namespaces in their own Backends
+type namespaceBackend struct {
+	next    Backend
+	targets map[string]Backend
+}
+
+// NamespaceDecorator returns a Decorator storing the objects of the namespaces in the target Backends,
+// e.g. in buckets owned by the business units publishing to these namespaces.
+// The objects of all other namespaces and those which don't belong to a namespace, like the mirrored providers,
+// are stored in the decorated Backend. The keys of the objects are the same in all Backends.
+func NamespaceDecorator(targets map[string]Backend) Decorator {
+	return func(next Backend) Backend {
+		if len(targets) == 0 {
+			return next
+		}
+		return &namespaceBackend{
+			next:    next,
+			targets: targets,
+		}
+	}
+}
+
+// backend returns the Backend storing the key
+func (b *namespaceBackend) backend(key string) Backend {
+	if namespace, ok := keyNamespace(key); ok {
+		if target, ok := b.targets[namespace]; ok {
+			return target
+		}
+	}
+	return b.next
+}
+
+func (b *namespaceBackend) Exists(ctx context.Context, key string) (bool, error) {
+	return b.backend(key).Exists(ctx, key)
+}
+
+func (b *namespaceBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	return b.backend(key).Download(ctx, key)
+}
+
+func (b *namespaceBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.backend(key).Open(ctx, key)
+}
+
+func (b *namespaceBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	target := b.backend(key)
+	if target != b.next && ifNotExists(ctx) {
+		// The targets don't support conditional writes, their objects are checked upfront instead
+		exists, err := target.Exists(ctx, key)
+		if err != nil {
+			return err
+		} else if exists {
+			return errAlreadyExists(key)
+		}
+	}
+	return target.Upload(ctx, key, reader)
+}
+
+func (b *namespaceBackend) Delete(ctx context.Context, key string) error {
+	return b.backend(key).Delete(ctx, key)
+}
+
+// List only lists the Backend of the namespace if the prefix contains one, and all Backends otherwise.
+// Objects stored in a Backend which doesn't own their namespace, e.g. left behind in the default one, are omitted.
+func (b *namespaceBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	if _, ok := keyNamespace(prefix); ok {
+		return b.backend(prefix).List(ctx, prefix)
+	}
+
+	// Several namespaces may share a target Backend, which is only listed once
+	backends := []Backend{b.next}
+	for _, target := range b.targets {
+		if !slices.Contains(backends, target) {
+			backends = append(backends, target)
+		}
+	}
+
+	var objects []Object
+	var errs []error
+	for _, backend := range backends {
+		listed, err := backend.List(ctx, prefix)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, obj := range listed {
+			if b.backend(obj.Key) == backend {
+				objects = append(objects, obj)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return objects, nil
+}
+
+func (b *namespaceBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	return b.backend(key).PresignedURL(ctx, key)
+}
+
+func (b *namespaceBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.backend(url).GetDownloadUrl(ctx, url)
+}
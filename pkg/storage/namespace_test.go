@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestKeyNamespace(t *testing.T) {
+	assert := assertion.New(t)
+
+	for key, namespace := range map[string]string{
+		"modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz":         "acme",
+		"registry/providers/acme/dummy/signing-keys.json":        "acme",
+		"stats/modules/acme/vpc/aws/downloads.json":              "acme",
+		"quarantine/providers/acme/dummy/terraform-provider.zip": "acme",
+		"modules/acme/": "acme",
+	} {
+		ns, ok := keyNamespace(key)
+		assert.True(ok, key)
+		assert.Equal(namespace, ns, key)
+	}
+
+	for _, key := range []string{
+		"mirror/providers/registry.terraform.io/hashicorp/aws/terraform-provider-aws_5.0.0_linux_amd64.zip",
+		"modules/",
+		"modules/ac",
+		".boring-registry-check/1",
+	} {
+		_, ok := keyNamespace(key)
+		assert.False(ok, key)
+	}
+}
+
+func TestNamespaceDecorator(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	shared, team := newMemoryBackend(), newMemoryBackend()
+	backend := Decorate(shared, NamespaceDecorator(map[string]Backend{"team": team}))
+
+	assert.NoError(backend.Upload(ctx, "modules/team/vpc/aws/team-vpc-aws-1.0.0.tar.gz", strings.NewReader("team")))
+	assert.NoError(backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", strings.NewReader("acme")))
+
+	exists, err := team.Exists(ctx, "modules/team/vpc/aws/team-vpc-aws-1.0.0.tar.gz")
+	assert.NoError(err)
+	assert.True(exists)
+	exists, err = shared.Exists(ctx, "modules/team/vpc/aws/team-vpc-aws-1.0.0.tar.gz")
+	assert.NoError(err)
+	assert.False(exists)
+
+	data, err := backend.Download(ctx, "modules/team/vpc/aws/team-vpc-aws-1.0.0.tar.gz")
+	assert.NoError(err)
+	assert.Equal("team", string(data))
+
+	// Conditional uploads are checked upfront for the targets
+	err = backend.Upload(withIfNotExists(ctx), "modules/team/vpc/aws/team-vpc-aws-1.0.0.tar.gz", strings.NewReader("other"))
+	assert.ErrorIs(err, core.ErrObjectAlreadyExists)
+
+	// Objects left behind in the shared backend are omitted from the listings
+	assert.NoError(shared.Upload(ctx, "modules/team/vpc/aws/team-vpc-aws-0.1.0.tar.gz", strings.NewReader("stale")))
+
+	objects, err := backend.List(ctx, "modules/")
+	assert.NoError(err)
+	assert.Len(objects, 2)
+
+	objects, err = backend.List(ctx, "modules/team/")
+	assert.NoError(err)
+	assert.Len(objects, 1)
+	assert.Equal("modules/team/vpc/aws/team-vpc-aws-1.0.0.tar.gz", objects[0].Key)
+}
@@ -0,0 +1,1183 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/admission"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/lock"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/quota"
+	"github.com/boring-registry/boring-registry/pkg/scan"
+)
+
+// maxProviderManifestSize is the maximum size of provider manifests, which are read into memory to be validated
+const maxProviderManifestSize = 1 << 20
+
+// ObjectStorage implements Storage on top of a Backend.
+// ObjectStorage implements module.Storage, provider.Storage, mirror.Storage, and proxy.Storage
+type ObjectStorage struct {
+	backend             Backend
+	prefix              string
+	moduleArchiveFormat string
+	convertArchives     bool
+	quotas              *quota.Policy
+	scanner             scan.Scanner
+	quarantine          bool
+	admission           admission.Controller
+	locker              lock.Locker
+	conditionalWrites   bool
+	immutableDigests    bool
+	tags                map[string]string
+	publisher           string
+}
+
+// GetModule retrieves information about a module from the storage.
+func (s *ObjectStorage) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	// The module version may have been uploaded in another format than the configured one
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return core.Module{}, err
+	}
+
+	// Deleted versions are hidden until they are purged
+	if deleted, err := s.backend.Exists(ctx, moduleTombstonePath(key)); err != nil {
+		return core.Module{}, err
+	} else if deleted {
+		return core.Module{}, fmt.Errorf("%w: %s is deleted", module.ErrModuleNotFound, key)
+	}
+
+	// Staged versions are hidden until they are promoted
+	if staged, err := s.backend.Exists(ctx, moduleStagingPath(key)); err != nil {
+		return core.Module{}, err
+	} else if staged {
+		return core.Module{}, fmt.Errorf("%w: %s is staged", module.ErrModuleNotFound, key)
+	}
+
+	return s.getModule(ctx, namespace, name, provider, version, key)
+}
+
+// getModule returns the module version of the archive, independent of whether it's staged
+func (s *ObjectStorage) getModule(ctx context.Context, namespace, name, provider, version, key string) (core.Module, error) {
+	if err := s.verifyModuleChecksum(ctx, namespace, name, provider, key); err != nil {
+		return core.Module{}, err
+	}
+
+	presigned, err := s.backend.PresignedURL(ctx, key)
+	if err != nil {
+		return core.Module{}, err
+	}
+
+	m := core.Module{
+		Namespace:   namespace,
+		Name:        name,
+		Provider:    provider,
+		Version:     version,
+		DownloadURL: presigned,
+	}
+
+	m.SignatureURL, err = s.presignedURLIfExists(ctx, moduleSignaturePath(key))
+	if err != nil {
+		return core.Module{}, err
+	}
+	m.AttestationURL, err = s.presignedURLIfExists(ctx, attestationPath(key))
+	if err != nil {
+		return core.Module{}, err
+	}
+
+	return m, nil
+}
+
+// presignedURLIfExists returns a presigned URL of the object, or an empty string if the object doesn't exist
+func (s *ObjectStorage) presignedURLIfExists(ctx context.Context, key string) (string, error) {
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil || !exists {
+		return "", err
+	}
+	return s.backend.PresignedURL(ctx, key)
+}
+
+func (s *ObjectStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	objects, err := s.backend.List(ctx, modulePathPrefix(s.prefix, namespace, name, provider))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", module.ErrModuleListFailed, err)
+	}
+
+	keys := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		keys[obj.Key] = true
+	}
+
+	// Versions existing in multiple formats are listed once with the archive in the most preferred format
+	type archive struct {
+		key    string
+		module *core.Module
+		rank   int
+	}
+	formats := s.archiveFormats(ctx)
+	var versions []string
+	archives := make(map[string]archive)
+	for _, obj := range objects {
+		m, rank, err := moduleFromArchive(obj.Key, formats)
+		if err != nil {
+			// TODO: we're skipping possible failures silently
+			continue
+		}
+		if keys[moduleTombstonePath(obj.Key)] || keys[moduleStagingPath(obj.Key)] {
+			continue
+		}
+
+		current, ok := archives[m.Version]
+		if !ok {
+			versions = append(versions, m.Version)
+		}
+		if !ok || rank < current.rank {
+			archives[m.Version] = archive{key: obj.Key, module: m, rank: rank}
+		}
+	}
+
+	metadata := &moduleMetadata{}
+	if keys[moduleMetadataPath(s.prefix, namespace, name, provider)] {
+		if metadata, err = s.moduleMetadata(ctx, namespace, name, provider); err != nil {
+			return nil, fmt.Errorf("%v: %w", module.ErrModuleListFailed, err)
+		}
+	}
+
+	var modules []core.Module
+	for _, version := range versions {
+		a := archives[version]
+		m := a.module
+
+		// The download URL is probably not necessary for ListModules
+		m.DownloadURL, err = s.backend.PresignedURL(ctx, a.key)
+		if err != nil {
+			return []core.Module{}, err
+		}
+
+		if keys[moduleSignaturePath(a.key)] {
+			m.SignatureURL, err = s.backend.PresignedURL(ctx, moduleSignaturePath(a.key))
+			if err != nil {
+				return []core.Module{}, err
+			}
+		}
+		if keys[attestationPath(a.key)] {
+			m.AttestationURL, err = s.backend.PresignedURL(ctx, attestationPath(a.key))
+			if err != nil {
+				return []core.Module{}, err
+			}
+		}
+		m.SHA256 = metadata.Versions[version].SHA256
+
+		modules = append(modules, *m)
+	}
+
+	return modules, nil
+}
+
+// UploadModule uploads a module to the storage.
+func (s *ObjectStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	if namespace == "" {
+		return core.Module{}, errors.New("namespace not defined")
+	}
+
+	if name == "" {
+		return core.Module{}, errors.New("name not defined")
+	}
+
+	if provider == "" {
+		return core.Module{}, errors.New("provider not defined")
+	}
+
+	if version == "" {
+		return core.Module{}, errors.New("version not defined")
+	}
+
+	key := s.uploadModulePath(ctx, namespace, name, provider, version)
+	ctx = s.tagged(ctx, namespace, name, version)
+
+	// The lock is held until the version is published, so that concurrent uploads fail the checks below
+	unlock, err := s.lock(ctx, moduleLockKey(s.prefix, namespace, name, provider, version))
+	if err != nil {
+		return core.Module{}, err
+	}
+	defer unlock()
+
+	// A version can only be published once, independent of the format of its archive
+	if _, err := s.GetModule(ctx, namespace, name, provider, version); err == nil {
+		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
+	}
+	archives, err := s.moduleArchives(ctx, namespace, name, provider, version)
+	if err != nil {
+		return core.Module{}, err
+	}
+	for _, archive := range archives {
+		if deleted, err := s.backend.Exists(ctx, moduleTombstonePath(archive)); err != nil {
+			return core.Module{}, err
+		} else if deleted {
+			// The version can only be published again once the deleted version has been purged or restored
+			return core.Module{}, fmt.Errorf("%w: %s is deleted and not purged yet", module.ErrModuleAlreadyExists, archive)
+		}
+		if staged, err := s.backend.Exists(ctx, moduleStagingPath(archive)); err != nil {
+			return core.Module{}, err
+		} else if staged {
+			return core.Module{}, fmt.Errorf("%w: %s is staged and not promoted yet", module.ErrModuleAlreadyExists, archive)
+		}
+	}
+
+	if q := s.quotas.Quota(namespace); q.MaxVersions > 0 {
+		versions, err := s.ListModuleVersions(ctx, namespace, name, provider)
+		if err != nil {
+			return core.Module{}, err
+		}
+		if err := q.CheckVersions(namespace, len(versions)); err != nil {
+			return core.Module{}, err
+		}
+	}
+
+	// The digest is still recorded if the version has been deleted and purged, so that it can't be published with other content
+	recorded, err := s.recordedArchiveDigest(ctx, namespace, name, provider, version)
+	if err != nil {
+		return core.Module{}, err
+	}
+
+	body, cleanup, err := s.admitModule(ctx, admission.OperationUpload, namespace, name, provider, version, key, body)
+	if err != nil {
+		return core.Module{}, err
+	}
+	defer cleanup()
+
+	// The staging marker is written before the archive, so that a staged version is never visible
+	staging := module.StagingFromContext(ctx)
+	if staging != nil {
+		if err := s.uploadStaging(s.conditional(ctx), moduleStagingPath(key), staging); errors.Is(err, core.ErrObjectAlreadyExists) {
+			return core.Module{}, fmt.Errorf("%w: %s is staged already", module.ErrModuleAlreadyExists, key)
+		} else if err != nil {
+			return core.Module{}, err
+		}
+	}
+	checksum, err := s.uploadModule(s.conditional(ctx), namespace, key, body, recorded)
+	if err != nil {
+		if staging != nil {
+			// A leftover marker would hide the version once it's published without staging
+			_ = s.backend.Delete(ctx, moduleStagingPath(key))
+		}
+		return core.Module{}, err
+	}
+	if err := s.recordArchiveDigest(ctx, namespace, name, provider, version, checksum); err != nil {
+		return core.Module{}, err
+	}
+	if err := s.updateModuleChecksum(ctx, namespace, name, provider, version); err != nil {
+		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+	}
+
+	if staging != nil {
+		return s.getModule(ctx, namespace, name, provider, version, key)
+	}
+	return s.GetModule(ctx, namespace, name, provider, version)
+}
+
+// ReplaceModule replaces the archive and checksum of an existing module version.
+// Signed or attested module versions can't be replaced, as their signatures and bundles can't be revoked.
+// If digests are immutable, the archive can only be replaced with an archive of the recorded digest.
+func (s *ObjectStorage) ReplaceModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	key := s.uploadModulePath(ctx, namespace, name, provider, version)
+	ctx = s.tagged(ctx, namespace, name, version)
+
+	unlock, err := s.lock(ctx, moduleLockKey(s.prefix, namespace, name, provider, version))
+	if err != nil {
+		return core.Module{}, err
+	}
+	defer unlock()
+
+	archives, err := s.moduleArchives(ctx, namespace, name, provider, version)
+	if err != nil {
+		return core.Module{}, err
+	} else if len(archives) == 0 {
+		return core.Module{}, module.ErrModuleNotFound
+	}
+
+	for _, archive := range archives {
+		for _, sidecar := range []string{moduleSignaturePath(archive), attestationPath(archive)} {
+			exists, err := s.backend.Exists(ctx, sidecar)
+			if err != nil {
+				return core.Module{}, err
+			} else if exists {
+				return core.Module{}, fmt.Errorf("%w: %s has a signature or attestation", module.ErrModuleImmutable, archive)
+			}
+		}
+	}
+	recorded, err := s.recordedArchiveDigest(ctx, namespace, name, provider, version)
+	if err != nil {
+		return core.Module{}, err
+	} else if recorded == "" && s.immutableDigests {
+		// Versions published before digests were recorded keep the content of their current archive
+		current, err := s.moduleArchive(ctx, namespace, name, provider, version)
+		if err != nil {
+			return core.Module{}, err
+		}
+		if recorded, err = s.archiveChecksum(ctx, current); err != nil {
+			return core.Module{}, err
+		}
+	}
+	body, cleanup, err := s.admitModule(ctx, admission.OperationReplace, namespace, name, provider, version, key, body)
+	if err != nil {
+		return core.Module{}, err
+	}
+	defer cleanup()
+
+	checksum, err := s.uploadModule(ctx, namespace, key, body, recorded)
+	if err != nil {
+		return core.Module{}, err
+	}
+	if err := s.recordArchiveDigest(ctx, namespace, name, provider, version, checksum); err != nil {
+		return core.Module{}, err
+	}
+
+	// The archives in other formats are removed, so that all formats of a version have the same content
+	for _, archive := range archives {
+		// SBOMs and digests describe the replaced archive, the ones of the new archive have to be attached again
+		for _, sidecar := range []string{sbomPath(archive), moduleDigestPath(archive)} {
+			if exists, err := s.backend.Exists(ctx, sidecar); err != nil {
+				return core.Module{}, err
+			} else if exists {
+				if err := s.backend.Delete(ctx, sidecar); err != nil {
+					return core.Module{}, fmt.Errorf("%v: failed to delete %s: %w", module.ErrModuleUploadFailed, sidecar, err)
+				}
+			}
+		}
+		if archive == key {
+			continue
+		}
+		for _, obj := range []string{archive, moduleChecksumPath(archive)} {
+			if err := s.backend.Delete(ctx, obj); err != nil {
+				return core.Module{}, fmt.Errorf("%v: failed to delete %s: %w", module.ErrModuleUploadFailed, obj, err)
+			}
+		}
+	}
+	if err := s.updateModuleChecksum(ctx, namespace, name, provider, version); err != nil {
+		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+	}
+
+	return s.GetModule(ctx, namespace, name, provider, version)
+}
+
+// uploadModule uploads the module archive and its checksum, and returns the checksum.
+// Existing archives are replaced, unless the context requests a conditional write.
+// The archive is rejected before it's uploaded if its checksum differs from the recorded digest, unless no digest is recorded.
+func (s *ObjectStorage) uploadModule(ctx context.Context, namespace, key string, body io.Reader, recorded string) (string, error) {
+	q := s.quotas.Quota(namespace)
+
+	if s.convertArchives {
+		converted, err := module.ConvertArchive(body, s.moduleArchiveFormat)
+		if err != nil {
+			return "", fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+		}
+		defer converted.Close()
+		body = converted
+	}
+
+	// The checksum has to be known before the upload to compare it with the recorded digest
+	if _, ok := body.(io.ReadSeeker); !ok && recorded != "" {
+		archive, size, cleanup, err := spool(q.LimitReader(namespace, body))
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		body = io.NewSectionReader(archive, 0, size)
+	}
+
+	// The checksum is computed upfront if the body can be rewound, so that the upload can still be retried
+	hash := sha256.New()
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+		}
+		size, err := io.Copy(hash, seeker)
+		if err != nil {
+			return "", fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return "", fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+		}
+		if err := s.checkSize(ctx, namespace, q, size); err != nil {
+			return "", err
+		}
+		if checksum := hex.EncodeToString(hash.Sum(nil)); recorded != "" && checksum != recorded {
+			return "", fmt.Errorf("%w: %s has the digest %s instead of the recorded digest %s", module.ErrModuleImmutable, key, module.FormatChecksum(checksum), module.FormatChecksum(recorded))
+		}
+	} else {
+		// The declared size of a request body is checked before it's streamed to the storage backend
+		var size int64
+		if sized, ok := body.(interface{ Size() int64 }); ok {
+			size = sized.Size()
+		}
+		if err := s.checkSize(ctx, namespace, q, size); err != nil {
+			return "", err
+		}
+		body = io.TeeReader(q.LimitReader(namespace, body), hash)
+	}
+
+	if err := s.backend.Upload(ctx, key, body); errors.Is(err, core.ErrObjectAlreadyExists) {
+		return "", fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
+	} else if err != nil {
+		return "", fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+	}
+
+	// Only the archive is written conditionally, the checksum belongs to the archive written before
+	ctx = context.WithValue(ctx, ifNotExistsKey{}, false)
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err := s.backend.Upload(ctx, moduleChecksumPath(key), strings.NewReader(checksum)); err != nil {
+		return "", fmt.Errorf("%v: failed to upload checksum: %w", module.ErrModuleUploadFailed, err)
+	}
+
+	return checksum, nil
+}
+
+// UploadModuleSignature stores the detached OpenPGP signature next to the module archive.
+// The signature of a module can't be replaced once it has been uploaded.
+func (s *ObjectStorage) UploadModuleSignature(ctx context.Context, namespace, name, provider, version string, signature io.Reader) error {
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	ctx = s.tagged(ctx, namespace, name, version)
+
+	if err := s.upload(ctx, moduleSignaturePath(key), signature, false); err != nil {
+		return fmt.Errorf("%v: failed to upload signature: %w", module.ErrModuleUploadFailed, err)
+	}
+	return nil
+}
+
+// UploadModuleAttestation stores the Sigstore bundle next to the module archive.
+// The bundle of a module can't be replaced once it has been uploaded.
+func (s *ObjectStorage) UploadModuleAttestation(ctx context.Context, namespace, name, provider, version string, bundle io.Reader) error {
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	ctx = s.tagged(ctx, namespace, name, version)
+
+	if err := s.upload(ctx, attestationPath(key), bundle, false); err != nil {
+		return fmt.Errorf("%v: failed to upload attestation: %w", module.ErrModuleUploadFailed, err)
+	}
+	return nil
+}
+
+// GetModuleChecksum returns the checksum stored next to the module archive.
+// The checksum is computed from the archive for modules uploaded before checksums were stored.
+func (s *ObjectStorage) GetModuleChecksum(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return "", err
+	}
+	return s.archiveChecksum(ctx, key)
+}
+
+// ModuleArchive returns the content of the module archive, which is verified against the checksum manifest
+func (s *ObjectStorage) ModuleArchive(ctx context.Context, namespace, name, provider, version string) ([]byte, error) {
+	// Deleted and corrupted versions are rejected by GetModule
+	if _, err := s.GetModule(ctx, namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.backend.Download(ctx, key)
+}
+
+// archiveChecksum returns the checksum stored next to the module archive with the key.
+// Archives without checksum, e.g. uploaded by older versions, are hashed once and their checksum is stored.
+func (s *ObjectStorage) archiveChecksum(ctx context.Context, key string) (string, error) {
+	exists, err := s.backend.Exists(ctx, moduleChecksumPath(key))
+	if err != nil {
+		return "", err
+	} else if exists {
+		checksum, err := s.backend.Download(ctx, moduleChecksumPath(key))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(checksum)), nil
+	}
+
+	// The archive is streamed, as it might be too large to be kept in memory
+	archive, err := s.backend.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, archive); err != nil {
+		return "", fmt.Errorf("failed to read module archive %s: %w", key, err)
+	}
+	checksum := hex.EncodeToString(h.Sum(nil))
+
+	// Failing to store the checksum only means the archive is hashed again on the next request
+	if err := s.backend.Upload(ctx, moduleChecksumPath(key), strings.NewReader(checksum)); err != nil {
+		slog.Warn("failed to store checksum of module archive", slog.String("key", key), slog.String("err", err.Error()))
+	}
+	return checksum, nil
+}
+
+func (s *ObjectStorage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
+	var archivePath, shasumPath, shasumSigPath string
+	if pt == internalProviderType {
+		archivePath, shasumPath, shasumSigPath = internalProviderPath(s.prefix, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
+	} else if pt == mirrorProviderType {
+		archivePath, shasumPath, shasumSigPath = mirrorProviderPath(s.prefix, provider.Hostname, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
+	}
+
+	if exists, err := s.backend.Exists(ctx, archivePath); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, noMatchingProviderFound(provider)
+	}
+	if pt == internalProviderType {
+		// Deleted versions are hidden until they are purged
+		if deleted, err := s.backend.Exists(ctx, providerTombstonePath(s.prefix, provider.Namespace, provider.Name, provider.Version)); err != nil {
+			return nil, err
+		} else if deleted {
+			return nil, noMatchingProviderFound(provider)
+		}
+	}
+
+	var err error
+	provider.DownloadURL, err = s.backend.PresignedURL(ctx, archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned url for %s: %w", archivePath, err)
+	}
+	provider.SHASumsURL, err = s.backend.PresignedURL(ctx, shasumPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned url for %s: %w", shasumPath, err)
+	}
+	provider.SHASumsSignatureURL, err = s.backend.PresignedURL(ctx, shasumSigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned url for %s: %w", shasumSigPath, err)
+	}
+	provider.AttestationURL, err = s.presignedURLIfExists(ctx, attestationPath(shasumPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned url for %s: %w", attestationPath(shasumPath), err)
+	}
+
+	shasumBytes, err := s.backend.Download(ctx, shasumPath)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.Shasum, err = readSHASums(bytes.NewReader(shasumBytes), path.Base(archivePath))
+	if err != nil {
+		return nil, err
+	}
+
+	if pt == internalProviderType {
+		manifestPath := path.Join(path.Dir(archivePath), provider.ManifestFileName())
+		if exists, err := s.backend.Exists(ctx, manifestPath); err != nil {
+			return nil, err
+		} else if exists {
+			provider.Protocols = s.providerProtocols(ctx, manifestPath)
+		}
+	}
+
+	var signingKeys *core.SigningKeys
+	if pt == internalProviderType {
+		signingKeys, err = s.SigningKeys(ctx, provider.Namespace, provider.Name)
+	} else if pt == mirrorProviderType {
+		signingKeys, err = s.MirroredSigningKeys(ctx, provider.Hostname, provider.Namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider.Filename = path.Base(archivePath)
+	provider.SigningKeys = *signingKeys
+	return provider, nil
+}
+
+// GetProvider retrieves information about a provider from the storage.
+func (s *ObjectStorage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	return s.getProvider(ctx, internalProviderType, &core.Provider{
+		Namespace: namespace,
+		Name:      name,
+		Version:   version,
+		OS:        os,
+		Arch:      arch,
+	})
+}
+
+func (s *ObjectStorage) GetMirroredProvider(ctx context.Context, provider *core.Provider) (*core.Provider, error) {
+	return s.getProvider(ctx, mirrorProviderType, provider)
+}
+
+func (s *ObjectStorage) listProviderVersions(ctx context.Context, pt providerType, provider *core.Provider) ([]*core.Provider, error) {
+	prefix := providerStoragePrefix(s.prefix, pt, provider.Hostname, provider.Namespace, provider.Name)
+	objects, err := s.backend.List(ctx, fmt.Sprintf("%s/", prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+
+	deleted := make(map[string]bool)
+	manifests := make(map[string]bool)
+	for _, obj := range objects {
+		deleted[obj.Key] = strings.HasSuffix(obj.Key, tombstoneSuffix)
+		manifests[obj.Key] = strings.HasSuffix(obj.Key, core.ProviderManifestSuffix)
+	}
+
+	// The protocols are declared once per version, but the providers are listed per platform
+	protocols := make(map[string][]string)
+	var providers []*core.Provider
+	for _, obj := range objects {
+		// The hashes stored next to the archives would otherwise be parsed as archives
+		if !strings.HasSuffix(obj.Key, core.ProviderExtension) {
+			continue
+		}
+
+		p, err := core.NewProviderFromArchive(path.Base(obj.Key))
+		if err != nil {
+			continue
+		}
+		if pt == internalProviderType && deleted[providerTombstonePath(s.prefix, provider.Namespace, provider.Name, p.Version)] {
+			continue
+		}
+
+		if provider.Version != "" && provider.Version != p.Version {
+			// The provider version doesn't match the requested version
+			continue
+		}
+
+		p.Hostname = provider.Hostname
+		p.Namespace = provider.Namespace
+		archiveUrl, err := s.backend.PresignedURL(ctx, obj.Key)
+		if err != nil {
+			return nil, err
+		}
+		p.DownloadURL = archiveUrl
+
+		manifestPath := path.Join(prefix, p.ManifestFileName())
+		if _, ok := protocols[manifestPath]; !ok && manifests[manifestPath] {
+			protocols[manifestPath] = s.providerProtocols(ctx, manifestPath)
+		}
+		p.Protocols = protocols[manifestPath]
+
+		providers = append(providers, &p)
+	}
+
+	if len(providers) == 0 {
+		return nil, noMatchingProviderFound(provider)
+	}
+
+	return providers, nil
+}
+
+// providerProtocols returns the protocol versions declared in the manifest of the release.
+// Manifests which can't be read are ignored, so that the version is listed without protocols like releases without manifest.
+func (s *ObjectStorage) providerProtocols(ctx context.Context, key string) []string {
+	data, err := s.backend.Download(ctx, key)
+	if err != nil {
+		slog.Warn("failed to download provider manifest", slog.String("key", key), slog.String("err", err.Error()))
+		return nil
+	}
+
+	manifest, err := core.NewProviderManifest(bytes.NewReader(data))
+	if err != nil {
+		slog.Warn("failed to parse provider manifest", slog.String("key", key), slog.String("err", err.Error()))
+		return nil
+	}
+	return manifest.Metadata.ProtocolVersions
+}
+
+func (s *ObjectStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	providers, err := s.listProviderVersions(ctx, internalProviderType, &core.Provider{Namespace: namespace, Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	collection := NewCollection()
+	for _, p := range providers {
+		collection.Add(p)
+	}
+	return collection.List(), nil
+}
+
+func (s *ObjectStorage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
+	return s.listProviderVersions(ctx, mirrorProviderType, provider)
+}
+
+func (s *ObjectStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	if namespace == "" {
+		return fmt.Errorf("namespace argument is empty")
+	}
+
+	if name == "" {
+		return fmt.Errorf("name argument is empty")
+	}
+
+	if filename == "" {
+		return fmt.Errorf("filename argument is empty")
+	}
+
+	prefix := providerStoragePrefix(s.prefix, internalProviderType, "", namespace, name)
+	key := path.Join(prefix, filename)
+	ctx = s.tagged(ctx, namespace, name, providerFileVersion(filename))
+
+	// The platforms of a version are uploaded in parallel, only uploads of the same file are serialized
+	unlock, err := s.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if strings.HasSuffix(filename, core.ProviderManifestSuffix) {
+		// Invalid manifests are rejected, as they would hide the protocols of the version
+		data, err := io.ReadAll(io.LimitReader(file, maxProviderManifestSize+1))
+		if err != nil {
+			return fmt.Errorf("failed to read provider manifest %s: %w", filename, err)
+		} else if len(data) > maxProviderManifestSize {
+			return fmt.Errorf("%w: the provider manifest %s exceeds the limit of %d bytes", core.ErrArtifactTooLarge, filename, maxProviderManifestSize)
+		}
+		if _, err := core.NewProviderManifest(bytes.NewReader(data)); err != nil {
+			return err
+		}
+		return s.upload(ctx, key, bytes.NewReader(data), false)
+	}
+	if _, err := core.NewProviderFromArchive(filename); err != nil || !strings.HasSuffix(filename, core.ProviderExtension) {
+		return s.upload(ctx, key, file, false)
+	}
+
+	// The hashes of archives are stored next to them, so that lock files can be populated without downloading the archives
+	q := s.quotas.Quota(namespace)
+	archive, size, cleanup, err := spool(q.LimitReader(namespace, file))
+	if err != nil {
+		return fmt.Errorf("failed to read provider archive %s: %w", filename, err)
+	}
+	defer cleanup()
+	if err := s.checkSize(ctx, namespace, q, size); err != nil {
+		return err
+	}
+	if err := s.checkProviderVersions(ctx, namespace, name, filename, q); err != nil {
+		return err
+	}
+	if err := s.admitProvider(ctx, namespace, name, filename, archive, size); err != nil {
+		return err
+	}
+	result, err := s.scanProviderArchive(ctx, namespace, name, filename, archive, size)
+	if err != nil {
+		return err
+	}
+	hashes, hashErr := core.ProviderArchiveHashes(archive, size)
+
+	if err := s.upload(ctx, key, io.NewSectionReader(archive, 0, size), false); err != nil {
+		return err
+	}
+	if err := s.uploadScanResult(ctx, key, result); err != nil {
+		return err
+	}
+	if hashErr != nil {
+		// The archive is stored nevertheless, the Terraform CLI verifies it on installation
+		slog.Warn("failed to compute hashes of provider archive", slog.String("key", key), slog.String("err", hashErr.Error()))
+		return nil
+	}
+
+	return s.upload(ctx, providerHashesPath(key), strings.NewReader(strings.Join(hashes, "\n")+"\n"), true)
+}
+
+// ProviderHashes returns the dependency lock file hashes stored next to the provider archive.
+// The hashes are computed from the archive for providers uploaded before hashes were stored.
+func (s *ObjectStorage) ProviderHashes(ctx context.Context, namespace, name, version, os, arch string) ([]string, error) {
+	key, _, _ := internalProviderPath(s.prefix, namespace, name, version, os, arch)
+
+	exists, err := s.backend.Exists(ctx, providerHashesPath(key))
+	if err != nil {
+		return nil, err
+	} else if exists {
+		data, err := s.backend.Download(ctx, providerHashesPath(key))
+		if err != nil {
+			return nil, err
+		}
+		return strings.Fields(string(data)), nil
+	}
+
+	exists, err = s.backend.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, noMatchingProviderFound(&core.Provider{Namespace: namespace, Name: name, Version: version, OS: os, Arch: arch})
+	}
+
+	archive, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.ProviderArchiveHashes(bytes.NewReader(archive), int64(len(archive)))
+}
+
+func (s *ObjectStorage) signingKeys(ctx context.Context, pt providerType, hostname, namespace, name string) (*core.SigningKeys, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace argument is empty")
+	}
+	key := signingKeysPath(s.prefix, pt, hostname, namespace, name)
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, core.ErrObjectNotFound
+	}
+
+	signingKeysRaw, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signing_keys.json for namespace %s: %w", namespace, err)
+	}
+
+	return unmarshalSigningKeys(signingKeysRaw)
+}
+
+// SigningKeys downloads the JSON placed next to the provider and unmarshals it into a core.SigningKeys.
+// Providers without keys of their own fall back to the JSON placed in the namespace, which is returned as well if the name is empty.
+func (s *ObjectStorage) SigningKeys(ctx context.Context, namespace, name string) (*core.SigningKeys, error) {
+	if name != "" {
+		signingKeys, err := s.signingKeys(ctx, internalProviderType, "", namespace, name)
+		if !errors.Is(err, core.ErrObjectNotFound) {
+			return signingKeys, err
+		}
+	}
+	return s.signingKeys(ctx, internalProviderType, "", namespace, "")
+}
+
+func (s *ObjectStorage) MirroredSigningKeys(ctx context.Context, hostname, namespace string) (*core.SigningKeys, error) {
+	return s.signingKeys(ctx, mirrorProviderType, hostname, namespace, "")
+}
+
+func (s *ObjectStorage) uploadSigningKeys(ctx context.Context, pt providerType, hostname, namespace, name string, signingKeys *core.SigningKeys) error {
+	b, err := json.Marshal(signingKeys)
+	if err != nil {
+		return err
+	}
+	key := signingKeysPath(s.prefix, pt, hostname, namespace, name)
+	return s.upload(ctx, key, bytes.NewReader(b), true)
+}
+
+// UploadSigningKeys replaces the signing keys of the provider, or of all providers of the namespace if the name is empty
+func (s *ObjectStorage) UploadSigningKeys(ctx context.Context, namespace, name string, signingKeys *core.SigningKeys) error {
+	return s.uploadSigningKeys(ctx, internalProviderType, "", namespace, name, signingKeys)
+}
+
+func (s *ObjectStorage) UploadMirroredSigningKeys(ctx context.Context, hostname, namespace string, signingKeys *core.SigningKeys) error {
+	return s.uploadSigningKeys(ctx, mirrorProviderType, hostname, namespace, "", signingKeys)
+}
+
+func (s *ObjectStorage) MirroredSha256Sum(ctx context.Context, provider *core.Provider) (*core.Sha256Sums, error) {
+	prefix := providerStoragePrefix(s.prefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
+	key := path.Join(prefix, provider.ShasumFileName())
+	shaSumBytes, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, errors.New("failed to download SHA256SUMS")
+	}
+
+	return core.NewSha256Sums(provider.ShasumFileName(), bytes.NewReader(shaSumBytes))
+}
+
+func (s *ObjectStorage) UploadMirroredFile(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
+	prefix := providerStoragePrefix(s.prefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
+	key := path.Join(prefix, fileName)
+	ctx = s.tagged(ctx, provider.Namespace, provider.Name, provider.Version)
+	return s.upload(ctx, key, reader, true)
+}
+
+// DownloadStats downloads the persisted download statistics of an artifact
+func (s *ObjectStorage) DownloadStats(ctx context.Context, artifact string) (*core.DownloadStats, error) {
+	key := downloadStatsPath(s.prefix, artifact)
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, core.ErrObjectNotFound
+	}
+
+	b, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download stats for %s: %w", artifact, err)
+	}
+
+	var stats core.DownloadStats
+	if err := json.Unmarshal(b, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+func (s *ObjectStorage) UploadDownloadStats(ctx context.Context, artifact string, stats *core.DownloadStats) error {
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	key := downloadStatsPath(s.prefix, artifact)
+	return s.upload(ctx, key, bytes.NewReader(b), true)
+}
+
+// Consumers downloads the persisted consumers of an artifact
+func (s *ObjectStorage) Consumers(ctx context.Context, artifact string) (core.Consumers, error) {
+	key := consumersPath(s.prefix, artifact)
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, core.ErrObjectNotFound
+	}
+
+	b, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download consumers for %s: %w", artifact, err)
+	}
+
+	var consumers core.Consumers
+	if err := json.Unmarshal(b, &consumers); err != nil {
+		return nil, err
+	}
+
+	return consumers, nil
+}
+
+func (s *ObjectStorage) UploadConsumers(ctx context.Context, artifact string, consumers core.Consumers) error {
+	b, err := json.Marshal(consumers)
+	if err != nil {
+		return err
+	}
+	key := consumersPath(s.prefix, artifact)
+	return s.upload(ctx, key, bytes.NewReader(b), true)
+}
+
+// Channels downloads the channels of an artifact
+func (s *ObjectStorage) Channels(ctx context.Context, artifact string) (core.Channels, error) {
+	key := channelsPath(s.prefix, artifact)
+	exists, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return core.Channels{}, nil
+	}
+
+	b, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download channels for %s: %w", artifact, err)
+	}
+
+	var channels core.Channels
+	if err := json.Unmarshal(b, &channels); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+func (s *ObjectStorage) UploadChannels(ctx context.Context, artifact string, channels core.Channels) error {
+	b, err := json.Marshal(channels)
+	if err != nil {
+		return err
+	}
+	key := channelsPath(s.prefix, artifact)
+	return s.upload(ctx, key, bytes.NewReader(b), true)
+}
+
+// CheckUploadSize checks an artifact of the given size against the quotas of the namespace before it's uploaded
+func (s *ObjectStorage) CheckUploadSize(ctx context.Context, namespace string, size int64) error {
+	return s.checkSize(ctx, namespace, s.quotas.Quota(namespace), size)
+}
+
+// checkSize checks an archive of the given size against the artifact size and total size limits of the namespace
+func (s *ObjectStorage) checkSize(ctx context.Context, namespace string, q quota.Quota, size int64) error {
+	if err := q.CheckArtifactSize(namespace, size); err != nil {
+		return err
+	}
+	if q.MaxTotalSize == 0 {
+		return nil
+	}
+
+	used, err := s.usage(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return q.CheckTotalSize(namespace, used, size)
+}
+
+// usage returns the number of bytes stored for the modules and providers of the namespace
+func (s *ObjectStorage) usage(ctx context.Context, namespace string) (int64, error) {
+	var used int64
+	for _, prefix := range []string{
+		path.Join(s.prefix, string(internalModuleType), namespace) + "/",
+		path.Join(s.prefix, string(internalProviderType), namespace) + "/",
+	} {
+		objects, err := s.backend.List(ctx, prefix)
+		if err != nil {
+			return 0, fmt.Errorf("failed to compute usage of namespace %s: %w", namespace, err)
+		}
+		for _, obj := range objects {
+			used += obj.Size
+		}
+	}
+	return used, nil
+}
+
+// checkProviderVersions checks the number of versions of the provider if the archive belongs to a new version
+func (s *ObjectStorage) checkProviderVersions(ctx context.Context, namespace, name, filename string, q quota.Quota) error {
+	if q.MaxVersions == 0 {
+		return nil
+	}
+
+	p, err := core.NewProviderFromArchive(filename)
+	if err != nil {
+		return err
+	}
+
+	prefix := providerStoragePrefix(s.prefix, internalProviderType, "", namespace, name)
+	objects, err := s.backend.List(ctx, prefix+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list providers: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, obj := range objects {
+		existing, err := core.NewProviderFromArchive(path.Base(obj.Key))
+		if err != nil || !strings.HasSuffix(obj.Key, core.ProviderExtension) {
+			continue
+		}
+		if existing.Version == p.Version {
+			return nil
+		}
+		seen[existing.Version] = true
+	}
+	return q.CheckVersions(namespace, len(seen))
+}
+
+func (s *ObjectStorage) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return s.backend.GetDownloadUrl(ctx, url)
+}
+
+func (s *ObjectStorage) upload(ctx context.Context, key string, reader io.Reader, overwrite bool) error {
+	if !overwrite {
+		var err error
+		if ctx, err = s.createOnly(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return s.backend.Upload(ctx, key, reader)
+}
+
+// ObjectStorageOption provides additional options for the ObjectStorage.
+type ObjectStorageOption func(*ObjectStorage)
+
+// WithObjectStoragePrefix configures the storage to work under a given prefix.
+func WithObjectStoragePrefix(prefix string) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.prefix = prefix
+	}
+}
+
+// WithObjectStorageArchiveFormat configures the format of uploaded module archives (zip, tar, tgz, etc.).
+// Archives in the other formats of module.ArchiveFormats are detected as well.
+func WithObjectStorageArchiveFormat(archiveFormat string) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		if archiveFormat != "" {
+			s.moduleArchiveFormat = archiveFormat
+		}
+	}
+}
+
+// WithObjectStorageArchiveConversion stores all uploaded module archives in the configured format.
+// Archives in another format are repackaged while they're uploaded.
+func WithObjectStorageArchiveConversion(enabled bool) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.convertArchives = enabled
+	}
+}
+
+// WithObjectStorageQuotas enforces the quotas of the policy on uploads, nil disables the quotas
+func WithObjectStorageQuotas(policy *quota.Policy) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.quotas = policy
+	}
+}
+
+// WithObjectStorageScanner scans uploaded provider archives with the scanner, nil disables scanning.
+// Rejected archives are moved to the quarantine if it's enabled, and are discarded otherwise.
+func WithObjectStorageScanner(scanner scan.Scanner, quarantine bool) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.scanner = scanner
+		s.quarantine = quarantine
+	}
+}
+
+// WithObjectStorageAdmission evaluates uploaded module and provider archives with the admission controller, nil disables admission control.
+// Module archives are read into memory to extract their inventory if admission control is enabled.
+func WithObjectStorageAdmission(controller admission.Controller) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.admission = controller
+	}
+}
+
+// WithObjectStorageLocker serializes uploads of the same module or provider version with the locker, nil disables locking.
+// A distributed Locker is required to serialize uploads of multiple server processes or upload commands.
+func WithObjectStorageLocker(locker lock.Locker) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.locker = locker
+	}
+}
+
+// WithObjectStorageConditionalWrites relies on the Backend to reject uploads of existing objects atomically,
+// instead of checking whether they exist before they're uploaded. The Backend must support conditional writes.
+func WithObjectStorageConditionalWrites(enabled bool) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.conditionalWrites = enabled
+	}
+}
+
+// WithObjectStorageImmutableDigests rejects uploads of module versions whose archive differs from the digest recorded
+// when the version was first published, even if the version is republished or published again after it has been purged.
+func WithObjectStorageImmutableDigests(enabled bool) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.immutableDigests = enabled
+	}
+}
+
+// WithObjectStorageTags tags uploaded objects with the static tags, the publisher, and the namespace, name, and version
+// of the artifact they belong to. Tagging is disabled if tags is nil, the publisher is optional.
+func WithObjectStorageTags(tags map[string]string, publisher string) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.tags = tags
+		s.publisher = publisher
+	}
+}
+
+// WithObjectStorageDecorators wraps the Backend with the given decorators.
+func WithObjectStorageDecorators(decorators ...Decorator) ObjectStorageOption {
+	return func(s *ObjectStorage) {
+		s.backend = Decorate(s.backend, decorators...)
+	}
+}
+
+// NewObjectStorage returns a Storage implementation backed by the given Backend.
+func NewObjectStorage(backend Backend, options ...ObjectStorageOption) *ObjectStorage {
+	s := &ObjectStorage{
+		backend:             backend,
+		moduleArchiveFormat: DefaultModuleArchiveFormat,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
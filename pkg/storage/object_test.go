@@ -0,0 +1,429 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/quota"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// mockBackend is a Backend keeping all objects in memory
+type mockBackend struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	calls   map[string]int
+}
+
+func newMockBackend() *mockBackend {
+	return &mockBackend{
+		objects: make(map[string][]byte),
+		calls:   make(map[string]int),
+	}
+}
+
+func (m *mockBackend) call(operation string) {
+	m.calls[operation]++
+}
+
+func (m *mockBackend) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.call(operationExists)
+	_, ok := m.objects[key]
+	return ok, nil
+}
+
+func (m *mockBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.call(operationDownload)
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, core.ErrObjectNotFound
+	}
+	return data, nil
+}
+
+func (m *mockBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.call(operationOpen)
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, core.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *mockBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.call(operationUpload)
+	m.objects[key] = data
+	return nil
+}
+
+func (m *mockBackend) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.call(operationDelete)
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *mockBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.call(operationList)
+
+	var objects []Object
+	for key, data := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, Object{Key: key, Size: int64(len(data))})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (m *mockBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	return key + "?presigned=true", nil
+}
+
+func (m *mockBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return "https://example.com/" + url, nil
+}
+
+func TestObjectStorage_Modules(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend(), WithObjectStoragePrefix("registry"))
+
+	_, err := s.GetModule(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	m, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	assertion.Equal(t, "registry/modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz?presigned=true", m.DownloadURL)
+
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.ErrorIs(t, err, module.ErrModuleAlreadyExists)
+
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.1.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+
+	modules, err := s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assertion.NoError(t, err)
+	if assertion.Len(t, modules, 2) {
+		assertion.Equal(t, "1.0.0", modules[0].Version)
+		assertion.Equal(t, "1.1.0", modules[1].Version)
+		assertion.NotEmpty(t, modules[1].DownloadURL)
+	}
+}
+
+func TestObjectStorage_GetModuleChecksum(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	s := NewObjectStorage(backend)
+
+	_, err := s.GetModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	// sha256sum of "archive"
+	checksum := "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3"
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	assertion.Equal(t, checksum, string(backend.objects["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz.sha256"]))
+
+	got, err := s.GetModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.NoError(t, err)
+	assertion.Equal(t, checksum, got)
+
+	// Modules uploaded without a checksum file are hashed once, the checksum is stored afterwards
+	backend.objects["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.1.0.tar.gz"] = []byte("archive")
+	got, err = s.GetModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.1.0")
+	assertion.NoError(t, err)
+	assertion.Equal(t, checksum, got)
+	assertion.Equal(t, checksum, string(backend.objects["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.1.0.tar.gz.sha256"]))
+}
+
+func TestObjectStorage_ModuleArchive(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+
+	_, err := s.ModuleArchive(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	archive, err := s.ModuleArchive(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.NoError(t, err)
+	assertion.Equal(t, []byte("archive"), archive)
+
+	// Deleted versions are hidden
+	assertion.NoError(t, s.DeleteModule(ctx, "hashicorp", "consul", "aws", "1.0.0"))
+	_, err = s.ModuleArchive(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+}
+
+func TestObjectStorage_UploadModuleSignature(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+
+	err := s.UploadModuleSignature(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("signature"))
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", version, strings.NewReader("archive"))
+		assertion.NoError(t, err)
+	}
+
+	// Modules without a signature have no signature URL
+	m, err := s.GetModule(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.NoError(t, err)
+	assertion.Empty(t, m.SignatureURL)
+
+	err = s.UploadModuleSignature(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("signature"))
+	assertion.NoError(t, err)
+	err = s.UploadModuleSignature(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("signature"))
+	assertion.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+
+	m, err = s.GetModule(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz.sig?presigned=true", m.SignatureURL)
+
+	modules, err := s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assertion.NoError(t, err)
+	if assertion.Len(t, modules, 2) {
+		assertion.Equal(t, m.SignatureURL, modules[0].SignatureURL)
+		assertion.Empty(t, modules[1].SignatureURL)
+	}
+}
+
+func TestObjectStorage_ReplaceModule(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+
+	_, err := s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("replaced"))
+	assertion.NoError(t, err)
+
+	checksum, err := s.GetModuleChecksum(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "6c1aa50442a93e42c0eb2907cf4e017cd19547891fa190f3ea473582b0479290", checksum)
+
+	// Signed module versions can't be replaced
+	err = s.UploadModuleSignature(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("signature"))
+	assertion.NoError(t, err)
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.ErrorIs(t, err, module.ErrModuleImmutable)
+}
+
+func TestObjectStorage_Channels(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend(), WithObjectStoragePrefix("prefix"))
+
+	channels, err := s.Channels(ctx, "modules/hashicorp/consul/aws")
+	assertion.NoError(t, err)
+	assertion.Empty(t, channels)
+
+	err = s.UploadChannels(ctx, "modules/hashicorp/consul/aws", core.Channels{"stable": "1.0.0"})
+	assertion.NoError(t, err)
+
+	channels, err = s.Channels(ctx, "modules/hashicorp/consul/aws")
+	assertion.NoError(t, err)
+	assertion.Equal(t, core.Channels{"stable": "1.0.0"}, channels)
+}
+
+func TestObjectStorage_UploadModuleAttestation(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+
+	err := s.UploadModuleAttestation(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("{}"))
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	err = s.UploadModuleAttestation(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("{}"))
+	assertion.NoError(t, err)
+	err = s.UploadModuleAttestation(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("{}"))
+	assertion.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+
+	m, err := s.GetModule(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz.sigstore.json?presigned=true", m.AttestationURL)
+
+	modules, err := s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assertion.NoError(t, err)
+	if assertion.Len(t, modules, 1) {
+		assertion.Equal(t, m.AttestationURL, modules[0].AttestationURL)
+	}
+}
+
+func TestObjectStorage_ListProviderVersions(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	s := NewObjectStorage(backend)
+
+	_, err := s.ListProviderVersions(ctx, "hashicorp", "random")
+	var providerErr *core.ProviderError
+	assertion.True(t, errors.As(err, &providerErr))
+
+	for _, f := range []string{
+		"terraform-provider-random_2.0.0_linux_amd64.zip",
+		"terraform-provider-random_2.0.0_darwin_arm64.zip",
+		"terraform-provider-random_2.0.0_SHA256SUMS",
+		"terraform-provider-random_2.1.0_linux_amd64.zip",
+	} {
+		assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", f, strings.NewReader(f)))
+	}
+
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.1.0_linux_amd64.zip", strings.NewReader(""))
+	assertion.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+
+	// The protocols are read from the manifest of the release
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.0.0_manifest.json", strings.NewReader(`{"version": 1}`))
+	assertion.Error(t, err)
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.0.0_manifest.json", strings.NewReader(strings.Repeat(" ", maxProviderManifestSize+1)))
+	assertion.ErrorIs(t, err, core.ErrArtifactTooLarge)
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.0.0_manifest.json",
+		strings.NewReader(`{"version": 1, "metadata": {"protocol_versions": ["6.0"]}}`)))
+
+	versions, err := s.ListProviderVersions(ctx, "hashicorp", "random")
+	assertion.NoError(t, err)
+	assertion.Len(t, versions.Versions, 2)
+	for _, v := range versions.Versions {
+		if v.Version == "2.0.0" {
+			assertion.Equal(t, []string{"6.0"}, v.Protocols)
+			assertion.Len(t, v.Platforms, 2)
+		} else {
+			assertion.Empty(t, v.Protocols)
+		}
+	}
+	// The download of the version contains the protocols as well
+	archive := "terraform-provider-dns_1.0.0_linux_amd64.zip"
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "dns", archive, strings.NewReader(archive)))
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "dns", "terraform-provider-dns_1.0.0_SHA256SUMS",
+		strings.NewReader(fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(archive)), archive))))
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "dns", "terraform-provider-dns_1.0.0_manifest.json",
+		strings.NewReader(`{"version": 1, "metadata": {"protocol_versions": ["5.0"]}}`)))
+	assertion.NoError(t, s.UploadSigningKeys(ctx, "hashicorp", "", &core.SigningKeys{GPGPublicKeys: []core.GPGPublicKey{{KeyID: "51852D87348FFC4C", ASCIIArmor: "key"}}}))
+	p, err := s.GetProvider(ctx, "hashicorp", "dns", "1.0.0", "linux", "amd64")
+	if assertion.NoError(t, err) {
+		assertion.Equal(t, []string{"5.0"}, p.Protocols)
+	}
+
+	url, err := s.GetDownloadUrl(ctx, "providers/hashicorp/random")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "https://example.com/providers/hashicorp/random", url)
+}
+
+func TestObjectStorage_SigningKeys(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	namespaceKeys := &core.SigningKeys{GPGPublicKeys: []core.GPGPublicKey{{KeyID: "51852D87348FFC4C", ASCIIArmor: "namespace"}}}
+	providerKeys := &core.SigningKeys{GPGPublicKeys: []core.GPGPublicKey{{KeyID: "34365D9472D7468F", ASCIIArmor: "provider"}}}
+
+	_, err := s.SigningKeys(ctx, "hashicorp", "random")
+	assertion.ErrorIs(t, err, core.ErrObjectNotFound)
+
+	assertion.NoError(t, s.UploadSigningKeys(ctx, "hashicorp", "", namespaceKeys))
+	assertion.NoError(t, s.UploadSigningKeys(ctx, "hashicorp", "random", providerKeys))
+
+	// Providers without keys of their own fall back to the keys of the namespace
+	for name, want := range map[string]*core.SigningKeys{"": namespaceKeys, "dns": namespaceKeys, "random": providerKeys} {
+		keys, err := s.SigningKeys(ctx, "hashicorp", name)
+		assertion.NoError(t, err)
+		assertion.Equal(t, want, keys, name)
+	}
+
+	archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", archive, strings.NewReader(archive)))
+	assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.0.0_SHA256SUMS",
+		strings.NewReader(fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(archive)), archive))))
+	p, err := s.GetProvider(ctx, "hashicorp", "random", "2.0.0", "linux", "amd64")
+	if assertion.NoError(t, err) {
+		assertion.Equal(t, *providerKeys, p.SigningKeys)
+	}
+}
+
+func TestObjectStorage_Quotas(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend(), WithObjectStorageQuotas(&quota.Policy{
+		Default: quota.Quota{MaxVersions: 2, MaxTotalSize: 200, MaxArtifactSize: 10},
+		Namespaces: map[string]quota.Quota{
+			"acme": {MaxArtifactSize: 100},
+		},
+	}))
+
+	// Bodies which can't be rewound are limited while they are uploaded
+	_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", io.MultiReader(strings.NewReader("larger than ten bytes")))
+	assertion.ErrorIs(t, err, core.ErrArtifactTooLarge)
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("larger than ten bytes"))
+	assertion.ErrorIs(t, err, core.ErrArtifactTooLarge)
+
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", v, strings.NewReader("archive"))
+		assertion.NoError(t, err)
+	}
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.2.0", strings.NewReader("archive"))
+	assertion.ErrorIs(t, err, core.ErrQuotaExceeded)
+
+	// Replacing an existing version doesn't count as a new version
+	_, err = s.ReplaceModule(ctx, "hashicorp", "consul", "aws", "1.1.0", strings.NewReader("replaced"))
+	assertion.NoError(t, err)
+
+	for _, f := range []string{
+		"terraform-provider-random_2.0.0_linux_amd64.zip",
+		"terraform-provider-random_2.0.0_darwin_arm64.zip",
+		"terraform-provider-random_2.1.0_linux_amd64.zip",
+	} {
+		assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", f, strings.NewReader("archive")))
+	}
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.2.0_linux_amd64.zip", strings.NewReader("archive"))
+	assertion.ErrorIs(t, err, core.ErrQuotaExceeded)
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.1.0_darwin_arm64.zip", strings.NewReader("larger than ten bytes"))
+	assertion.ErrorIs(t, err, core.ErrArtifactTooLarge)
+
+	// The namespace acme allows larger archives, but the total size of the default quota is inherited
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader(strings.Repeat("a", 100)))
+	assertion.NoError(t, err)
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.1.0", strings.NewReader(strings.Repeat("a", 100)))
+	assertion.ErrorIs(t, err, core.ErrQuotaExceeded)
+}
+
+func TestObjectStorage_DownloadStats(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+
+	_, err := s.DownloadStats(ctx, "modules/hashicorp/consul/aws")
+	assertion.ErrorIs(t, err, core.ErrObjectNotFound)
+
+	stats := &core.DownloadStats{}
+	stats.Add("1.0.0", 3)
+	assertion.NoError(t, s.UploadDownloadStats(ctx, "modules/hashicorp/consul/aws", stats))
+
+	got, err := s.DownloadStats(ctx, "modules/hashicorp/consul/aws")
+	assertion.NoError(t, err)
+	assertion.Equal(t, stats, got)
+}
@@ -0,0 +1,585 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+const (
+	// OCIArtifactType is the artifact type of the manifests created for the objects
+	OCIArtifactType = "application/vnd.boring-registry.object.v1"
+
+	// ociAnnotationKey records the key of the object in the manifest, as repository names are lowercased
+	ociAnnotationKey = "io.boring-registry.key"
+
+	ociExpiresParam   = "expires"
+	ociSignatureParam = "signature"
+)
+
+// ociTagRegexp matches valid tags of the OCI distribution specification
+var ociTagRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+
+// ociError is returned for unexpected responses of the registry, its status code is evaluated by the RetryDecorator
+type ociError struct {
+	err        error
+	statusCode int
+}
+
+func (e *ociError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ociError) Unwrap() error {
+	return e.err
+}
+
+func (e *ociError) HTTPStatusCode() int {
+	return e.statusCode
+}
+
+// wrapOCIError exposes the status code of error responses of the registry
+func wrapOCIError(err error) error {
+	var resp *errcode.ErrorResponse
+	if errors.As(err, &resp) {
+		return &ociError{err: err, statusCode: resp.StatusCode}
+	}
+	return err
+}
+
+// ociBackend stores every object as an OCI artifact with a single layer.
+// The directory of the key selects the repository below the base repository and the file name is the tag,
+// e.g. modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz is stored as <base>/modules/acme/vpc/aws:acme-vpc-aws-1.0.0.tar.gz
+type ociBackend struct {
+	registry  *remote.Registry
+	base      string
+	urlPrefix string
+	secret    []byte
+	expiry    time.Duration
+	now       func() time.Time
+}
+
+// Exists checks if the tag of the key exists in the repository
+func (b *ociBackend) Exists(ctx context.Context, key string) (bool, error) {
+	repo, tag, err := b.reference(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := repo.Resolve(ctx, tag); err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return false, nil
+		}
+		return false, wrapOCIError(err)
+	}
+
+	return true, nil
+}
+
+// Download fetches the layer of the artifact tagged with the file name of the key
+func (b *ociBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	repo, tag, err := b.reference(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := b.manifest(ctx, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Layers) != 1 {
+		return nil, fmt.Errorf("the artifact of %s has %d layers instead of 1", key, len(manifest.Layers))
+	}
+
+	data, err := content.FetchAll(ctx, repo, manifest.Layers[0])
+	if err != nil {
+		return nil, wrapOCIError(err)
+	}
+
+	return data, nil
+}
+
+func (b *ociBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	repo, tag, err := b.reference(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := b.manifest(ctx, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Layers) != 1 {
+		return nil, fmt.Errorf("the artifact of %s has %d layers instead of 1", key, len(manifest.Layers))
+	}
+
+	r, err := repo.Fetch(ctx, manifest.Layers[0])
+	if err != nil {
+		return nil, wrapOCIError(err)
+	}
+
+	return r, nil
+}
+
+// Upload pushes the content as a layer of a new artifact and tags it with the file name of the key
+func (b *ociBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	repo, tag, err := b.reference(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !ociTagRegexp.MatchString(tag) {
+		return fmt.Errorf("the file name of %s isn't a valid OCI tag", key)
+	}
+
+	// The content is spooled, as the digest of the layer has to be known before it's pushed
+	data, size, cleanup, err := spool(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer cleanup()
+	dgst, err := digest.Canonical.FromReader(io.NewSectionReader(data, 0, size))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	layer := ocispec.Descriptor{
+		MediaType:   "application/octet-stream",
+		Digest:      dgst,
+		Size:        size,
+		Annotations: map[string]string{ocispec.AnnotationTitle: tag},
+	}
+	if err := repo.Push(ctx, layer, io.NewSectionReader(data, 0, size)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return wrapOCIError(err)
+	}
+
+	manifest, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, OCIArtifactType, oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{layer},
+		ManifestAnnotations: map[string]string{
+			ocispec.AnnotationCreated: b.now().UTC().Format(time.RFC3339),
+			ociAnnotationKey:          key,
+		},
+	})
+	if err != nil {
+		return wrapOCIError(err)
+	}
+
+	return wrapOCIError(repo.Tag(ctx, manifest, tag))
+}
+
+// Delete removes the manifest of the artifact tagged with the file name of the key, the registry has to allow deletes.
+// The layer is removed by the garbage collection of the registry.
+func (b *ociBackend) Delete(ctx context.Context, key string) error {
+	repo, tag, err := b.reference(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	desc, err := repo.Resolve(ctx, tag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return wrapOCIError(err)
+	}
+
+	if err := repo.Delete(ctx, desc); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+		return wrapOCIError(err)
+	}
+	return nil
+}
+
+// List returns the artifacts of the repository of the prefix and of its parent directory with a key starting with the prefix.
+// Nested repositories are only listed for the empty prefix, which requires the catalog API of the registry.
+func (b *ociBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	var dirs []string
+	if prefix == "" {
+		repositories, err := b.repositories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dirs = repositories
+	} else {
+		dirs = []string{strings.TrimSuffix(prefix, "/")}
+		if dir := path.Dir(prefix); dir != dirs[0] {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var objects []Object
+	for _, dir := range dirs {
+		objs, err := b.listRepository(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			if strings.HasPrefix(obj.Key, prefix) {
+				objects = append(objects, obj)
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// listRepository returns the artifacts of all tags in the repository of the directory, a missing repository is empty
+func (b *ociBackend) listRepository(ctx context.Context, dir string) ([]Object, error) {
+	repo, err := b.repository(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = repo.Tags(ctx, "", func(t []string) error {
+		tags = append(tags, t...)
+		return nil
+	})
+	if err != nil {
+		var resp *errcode.ErrorResponse
+		if errors.As(err, &resp) && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, wrapOCIError(err)
+	}
+
+	objects := make([]Object, 0, len(tags))
+	for _, tag := range tags {
+		manifest, err := b.manifest(ctx, repo, tag)
+		if err != nil {
+			return nil, err
+		}
+		if manifest.ArtifactType != OCIArtifactType || len(manifest.Layers) != 1 {
+			continue
+		}
+
+		obj := Object{
+			Key:  manifest.Annotations[ociAnnotationKey],
+			Size: manifest.Layers[0].Size,
+		}
+		if obj.Key == "" {
+			obj.Key = path.Join(dir, tag)
+		}
+		if created, err := time.Parse(time.RFC3339, manifest.Annotations[ocispec.AnnotationCreated]); err == nil {
+			obj.LastModified = created
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// repositories returns the directories of all repositories below the base repository
+func (b *ociBackend) repositories(ctx context.Context) ([]string, error) {
+	var dirs []string
+	err := b.registry.Repositories(ctx, "", func(repos []string) error {
+		for _, repo := range repos {
+			if repo == b.base {
+				dirs = append(dirs, "")
+			} else if dir, ok := strings.CutPrefix(repo, b.base+"/"); ok {
+				dirs = append(dirs, dir)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the repositories of the registry: %w", wrapOCIError(err))
+	}
+
+	return dirs, nil
+}
+
+// PresignedURL returns a URL below the URL prefix, under which the OCIStorage serves the objects.
+// The URL is relative to the registry and signed, so that it can be downloaded without authentication until it expires.
+func (b *ociBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	p := path.Join("/", key)
+	expires := b.now().Add(b.expiry).Unix()
+
+	query := url.Values{}
+	query.Set(ociExpiresParam, strconv.FormatInt(expires, 10))
+	query.Set(ociSignatureParam, hex.EncodeToString(b.signature(p, expires)))
+
+	return fmt.Sprintf("%s?%s", path.Join(b.urlPrefix, p), query.Encode()), nil
+}
+
+// GetDownloadUrl isn't supported, as the objects can't be fetched from the registry without authentication
+func (b *ociBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return "", errors.New("the download proxy isn't supported with the OCI storage")
+}
+
+// verify checks that the signature in the query belongs to the path and has not expired yet
+func (b *ociBackend) verify(p string, query url.Values) bool {
+	expires, err := strconv.ParseInt(query.Get(ociExpiresParam), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	signature, err := hex.DecodeString(query.Get(ociSignatureParam))
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(signature, b.signature(p, expires)) && b.now().Unix() <= expires
+}
+
+func (b *ociBackend) signature(p string, expires int64) []byte {
+	mac := hmac.New(sha256.New, b.secret)
+	_, _ = fmt.Fprintf(mac, "%s\n%d", p, expires)
+	return mac.Sum(nil)
+}
+
+// reference returns the repository and the tag of the key
+func (b *ociBackend) reference(ctx context.Context, key string) (registry.Repository, string, error) {
+	key = strings.TrimPrefix(key, "/")
+	dir, tag := path.Split(key)
+	repo, err := b.repository(ctx, strings.TrimSuffix(dir, "/"))
+	return repo, tag, err
+}
+
+// repository returns the repository of the directory below the base repository, repository names need to be lowercase
+func (b *ociBackend) repository(ctx context.Context, dir string) (registry.Repository, error) {
+	name := b.base
+	if dir != "" && dir != "." {
+		name = path.Join(b.base, strings.ToLower(dir))
+	}
+
+	repo, err := b.registry.Repository(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCI repository %s: %w", name, err)
+	}
+	return repo, nil
+}
+
+func (b *ociBackend) manifest(ctx context.Context, repo registry.Repository, tag string) (*ocispec.Manifest, error) {
+	desc, rc, err := repo.FetchReference(ctx, tag)
+	if err != nil {
+		return nil, wrapOCIError(err)
+	}
+	defer rc.Close()
+
+	data, err := content.ReadAll(rc, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest of tag %s: %w", tag, err)
+	}
+	return &manifest, nil
+}
+
+// OCIStorage stores modules and providers as OCI artifacts in repositories of a container registry, e.g. ghcr.io, ECR, or Harbor.
+// Registries don't offer presigned URLs, therefore the OCIStorage serves the objects itself
+// and the http.Handler needs to be registered below the URL prefix.
+type OCIStorage struct {
+	*ObjectStorage
+	backend *ociBackend
+}
+
+// ServeHTTP serves the content of the object with the key in the path of the request if the signature is valid
+func (s *OCIStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := path.Join("/", r.URL.Path)
+	if !s.backend.verify(p, r.URL.Query()) {
+		core.HandleErrorResponse(core.ErrInvalidSignature, http.StatusForbidden, w)
+		return
+	}
+
+	key := strings.TrimPrefix(p, "/")
+	data, err := s.backend.Download(r.Context(), key)
+	if errors.Is(err, errdef.ErrNotFound) {
+		core.HandleErrorResponse(fmt.Errorf("%w: %s", core.ErrObjectNotFound, key), http.StatusNotFound, w)
+		return
+	} else if err != nil {
+		core.HandleErrorResponse(err, http.StatusBadGateway, w)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment;filename="%s"`, path.Base(key)))
+	http.ServeContent(w, r, path.Base(key), time.Time{}, bytes.NewReader(data))
+}
+
+// OCIStorageOption provides additional options for the OCIStorage.
+type OCIStorageOption func(*ociStorageOptions)
+
+type ociStorageOptions struct {
+	urlPrefix             string
+	username              string
+	password              string
+	plainHTTP             bool
+	client                *http.Client
+	signedURLExpiry       time.Duration
+	secret                []byte
+	moduleArchiveFormat   string
+	convertModuleArchives bool
+	decorators            []Decorator
+	objectOptions         []ObjectStorageOption
+}
+
+// WithOCIStorageURLPrefix configures the path under which the http.Handler of the OCIStorage is registered.
+func WithOCIStorageURLPrefix(prefix string) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.urlPrefix = prefix
+	}
+}
+
+// WithOCIStorageBasicAuth authenticates with a username and password or token.
+// The credentials of the Docker config file are used if no username is configured.
+func WithOCIStorageBasicAuth(username, password string) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithOCIStoragePlainHTTP accesses the registry via HTTP instead of HTTPS.
+func WithOCIStoragePlainHTTP(plainHTTP bool) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.plainHTTP = plainHTTP
+	}
+}
+
+// WithOCIStorageHTTPClient replaces the HTTP client used for the requests to the registry.
+func WithOCIStorageHTTPClient(client *http.Client) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.client = client
+	}
+}
+
+// WithOCIStorageSignedUrlExpiry configures the duration until the signed url expires
+func WithOCIStorageSignedUrlExpiry(t time.Duration) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.signedURLExpiry = t
+	}
+}
+
+// WithOCIStorageSignedUrlSecret configures the secret the URLs are signed with, all replicas need to share the same secret.
+func WithOCIStorageSignedUrlSecret(secret []byte) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.secret = secret
+	}
+}
+
+// WithOCIStorageArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
+func WithOCIStorageArchiveFormat(archiveFormat string) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.moduleArchiveFormat = archiveFormat
+	}
+}
+
+// WithOCIStorageArchiveConversion stores all uploaded module archives in the configured format, archives in another format are repackaged
+func WithOCIStorageArchiveConversion(enabled bool) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.convertModuleArchives = enabled
+	}
+}
+
+// WithOCIStorageDecorators wraps the OCI backend with the given decorators.
+func WithOCIStorageDecorators(decorators ...Decorator) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.decorators = append(o.decorators, decorators...)
+	}
+}
+
+// WithOCIStorageObjectOptions applies the options to the ObjectStorage of the OCI storage, e.g. quotas or the admission controller
+func WithOCIStorageObjectOptions(options ...ObjectStorageOption) OCIStorageOption {
+	return func(o *ociStorageOptions) {
+		o.objectOptions = append(o.objectOptions, options...)
+	}
+}
+
+// NewOCIStorage returns a fully initialized storage for the base repository, e.g. ghcr.io/acme/terraform.
+// A random secret is generated if no secret to sign the URLs is configured.
+func NewOCIStorage(repository string, options ...OCIStorageOption) (*OCIStorage, error) {
+	o := &ociStorageOptions{
+		urlPrefix:       "/",
+		client:          &http.Client{},
+		signedURLExpiry: 5 * time.Minute,
+	}
+	for _, option := range options {
+		option(o)
+	}
+
+	ref, err := registry.ParseReference(strings.TrimSuffix(repository, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCI repository %s: %w", repository, err)
+	}
+	if ref.Reference != "" {
+		return nil, fmt.Errorf("the OCI repository %s must not contain a tag or digest", repository)
+	}
+
+	reg, err := remote.NewRegistry(ref.Registry)
+	if err != nil {
+		return nil, err
+	}
+	reg.PlainHTTP = o.plainHTTP
+
+	client := &auth.Client{
+		Client: o.client,
+		Cache:  auth.NewCache(),
+	}
+	if o.username != "" {
+		client.Credential = auth.StaticCredential(ref.Registry, auth.Credential{
+			Username: o.username,
+			Password: o.password,
+		})
+	} else {
+		store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the Docker credentials: %w", err)
+		}
+		client.Credential = credentials.Credential(store)
+	}
+	reg.Client = client
+
+	if len(o.secret) == 0 {
+		o.secret = make([]byte, 32)
+		if _, err := rand.Read(o.secret); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+	}
+
+	backend := &ociBackend{
+		registry:  reg,
+		base:      ref.Repository,
+		urlPrefix: o.urlPrefix,
+		secret:    o.secret,
+		expiry:    o.signedURLExpiry,
+		now:       time.Now,
+	}
+
+	objectOptions := []ObjectStorageOption{
+		WithObjectStorageArchiveFormat(o.moduleArchiveFormat),
+		WithObjectStorageArchiveConversion(o.convertModuleArchives),
+		WithObjectStorageDecorators(o.decorators...),
+	}
+
+	return &OCIStorage{
+		ObjectStorage: NewObjectStorage(backend, append(objectOptions, o.objectOptions...)...),
+		backend:       backend,
+	}, nil
+}
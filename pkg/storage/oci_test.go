@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// fakeOCIRegistry implements the parts of the OCI distribution specification used by the OCIStorage
+type fakeOCIRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string]fakeOCIManifest
+	tags      map[string]map[string]string
+}
+
+type fakeOCIManifest struct {
+	mediaType string
+	data      []byte
+}
+
+func (f *fakeOCIRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case p == "_catalog":
+		repositories := []string{}
+		for name := range f.tags {
+			repositories = append(repositories, name)
+		}
+		sort.Strings(repositories)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"repositories": repositories})
+	case strings.HasSuffix(p, "/tags/list"):
+		name := strings.TrimSuffix(p, "/tags/list")
+		tags, ok := f.tags[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"NAME_UNKNOWN"}]}`))
+			return
+		}
+		list := []string{}
+		for tag := range tags {
+			list = append(list, tag)
+		}
+		sort.Strings(list)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "tags": list})
+	case strings.Contains(p, "/blobs/uploads/"):
+		name, _, _ := strings.Cut(p, "/blobs/uploads/")
+		if r.Method == http.MethodPost {
+			w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", name, len(f.blobs)))
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		f.blobs[r.URL.Query().Get("digest")], _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(p, "/blobs/"):
+		_, digest, _ := strings.Cut(p, "/blobs/")
+		data, ok := f.blobs[digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case strings.Contains(p, "/manifests/"):
+		name, ref, _ := strings.Cut(p, "/manifests/")
+		if r.Method == http.MethodPut {
+			data, _ := io.ReadAll(r.Body)
+			digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+			f.manifests[digest] = fakeOCIManifest{mediaType: r.Header.Get("Content-Type"), data: data}
+			if f.tags[name] == nil {
+				f.tags[name] = map[string]string{}
+			}
+			if !strings.HasPrefix(ref, "sha256:") {
+				f.tags[name][ref] = digest
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		digest := ref
+		if !strings.HasPrefix(ref, "sha256:") {
+			digest = f.tags[name][ref]
+		}
+		manifest, ok := f.manifests[digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", manifest.mediaType)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Length", strconv.Itoa(len(manifest.data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(manifest.data)
+		}
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestOCIStorage(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	fake := &fakeOCIRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]fakeOCIManifest),
+		tags:      make(map[string]map[string]string),
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewOCIStorage(strings.TrimPrefix(server.URL, "http://")+"/terraform",
+		WithOCIStoragePlainHTTP(true),
+		WithOCIStorageBasicAuth("registry", "secret"),
+		WithOCIStorageURLPrefix("/v1/oci"),
+		WithOCIStorageSignedUrlSecret([]byte("secret")),
+	)
+	assert.NoError(err)
+
+	modules, err := s.ListModuleVersions(ctx, "Acme", "vpc", "aws")
+	assert.NoError(err)
+	assert.Empty(modules)
+
+	_, err = s.UploadModule(ctx, "Acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+	_, err = s.UploadModule(ctx, "Acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.ErrorIs(err, module.ErrModuleAlreadyExists)
+	_, err = s.UploadModule(ctx, "Acme", "vpc", "aws", "1.1.0", strings.NewReader("module"))
+	assert.NoError(err)
+
+	// Repository names are lowercase, the keys are kept in the manifests
+	assert.Contains(fake.tags, "terraform/modules/acme/vpc/aws")
+	modules, err = s.ListModuleVersions(ctx, "Acme", "vpc", "aws")
+	assert.NoError(err)
+	assert.Len(modules, 2)
+
+	objects, err := s.backend.List(ctx, "")
+	assert.NoError(err)
+	assert.Len(objects, 4)
+	for _, obj := range objects {
+		assert.False(obj.LastModified.IsZero())
+	}
+
+	m, err := s.GetModule(ctx, "Acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(m.DownloadURL, "/v1/oci/modules/Acme/vpc/aws/Acme-vpc-aws-1.0.0.tar.gz?"))
+
+	// The signed URL is served by the storage
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(m.DownloadURL, "/v1/oci"), nil))
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("module", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/modules/Acme/vpc/aws/Acme-vpc-aws-1.1.0.tar.gz"+m.DownloadURL[strings.Index(m.DownloadURL, "?"):], nil))
+	assert.Equal(http.StatusForbidden, rec.Code)
+
+	err = s.backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0+build.tar.gz", strings.NewReader("module"))
+	assert.ErrorContains(err, "isn't a valid OCI tag")
+}
+
+func TestNewOCIStorage_Errors(t *testing.T) {
+	_, err := NewOCIStorage("ghcr.io/acme/terraform:latest", WithOCIStorageBasicAuth("registry", "secret"))
+	assertion.ErrorContains(t, err, "must not contain a tag or digest")
+
+	_, err = NewOCIStorage("ghcr.io/Acme/terraform", WithOCIStorageBasicAuth("registry", "secret"))
+	assertion.ErrorContains(t, err, "invalid OCI repository")
+}
@@ -8,12 +8,41 @@ import (
 	"strings"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
 )
 
 const (
 	internalProviderType = providerType("providers")
 	mirrorProviderType   = providerType("mirror/providers")
 	internalModuleType   = moduleType("modules")
+
+	moduleChecksumSuffix  = ".sha256"
+	moduleSignatureSuffix = ".sig"
+
+	// moduleDigestSuffix is the suffix of the digest of the module directory, which is stored next to the module archive
+	moduleDigestSuffix = ".digest"
+
+	// moduleChecksumsFile is the manifest with the checksums of all archives of a module, in the format of sha256sum
+	moduleChecksumsFile = "SHA256SUMS"
+
+	// moduleMetadataFile records the digests of all versions of a module at the time they were published
+	moduleMetadataFile = "metadata.json"
+
+	// attestationSuffix is the suffix of the Sigstore bundle stored next to an artifact, following the naming of cosign
+	attestationSuffix = ".sigstore.json"
+
+	// providerDocsSuffix is the suffix of the documentation stored next to the SHA256SUMS file of a provider version
+	providerDocsSuffix = ".docs.json"
+
+	// signingKeysFile holds the signing keys of a namespace, or of a single provider overriding the keys of its namespace
+	signingKeysFile = "signing-keys.json"
+
+	// quarantineDir holds the artifacts rejected by the scanner, outside of the layout served by the registry
+	quarantineDir = "quarantine"
+
+	// subdirDir holds the archives of sub-directories extracted from module archives, which are created on demand
+	subdirDir = "subdirs"
 )
 
 type providerType string
@@ -63,6 +92,16 @@ func mirrorProviderPath(prefix, hostname, namespace, name, version, os, arch str
 	return providerPath(prefix, mirrorProviderType, hostname, namespace, name, version, os, arch)
 }
 
+// providerQuarantinePath returns a <prefix>/quarantine/providers/<namespace>/<name>/<filename> path
+func providerQuarantinePath(prefix, namespace, name, filename string) string {
+	return path.Join(prefix, quarantineDir, string(internalProviderType), namespace, name, filename)
+}
+
+// providerHashesPath returns the path of the file containing the dependency lock file hashes of the provider archive
+func providerHashesPath(archivePath string) string {
+	return archivePath + ".hashes"
+}
+
 // modulePathPrefix returns a <prefix>/modules/<namespace>/<name>/<provider> prefix
 func modulePathPrefix(prefix, namespace, name, provider string) string {
 	return path.Join(prefix, string(internalModuleType), namespace, name, provider)
@@ -73,16 +112,80 @@ func modulePath(prefix, namespace, name, provider, version, archiveFormat string
 	return path.Join(modulePathPrefix(prefix, namespace, name, provider), f)
 }
 
-func signingKeysPath(prefix string, pt providerType, hostname, namespace string) string {
+// moduleSubdirPath returns a <prefix>/subdirs/modules/<namespace>/<name>/<provider>/<version>/<checksum>/<subdir>.tar.gz path,
+// the checksum of the module archive is part of the path, so that replaced archives are extracted again
+func moduleSubdirPath(prefix, namespace, name, provider, version, checksum, subdir string) string {
+	return path.Join(prefix, subdirDir, string(internalModuleType), namespace, name, provider, version, checksum, subdir) + "." + module.ArchiveFormatTarGz
+}
+
+// moduleChecksumPath returns the path of the file containing the hex-encoded SHA-256 checksum of the module archive
+func moduleChecksumPath(archivePath string) string {
+	return archivePath + moduleChecksumSuffix
+}
+
+// moduleChecksumsPath returns a <prefix>/modules/<namespace>/<name>/<provider>/SHA256SUMS path
+func moduleChecksumsPath(prefix, namespace, name, provider string) string {
+	return path.Join(modulePathPrefix(prefix, namespace, name, provider), moduleChecksumsFile)
+}
+
+// moduleMetadataPath returns a <prefix>/modules/<namespace>/<name>/<provider>/metadata.json path
+func moduleMetadataPath(prefix, namespace, name, provider string) string {
+	return path.Join(modulePathPrefix(prefix, namespace, name, provider), moduleMetadataFile)
+}
+
+// moduleSignaturePath returns the path of the detached OpenPGP signature of the module archive
+func moduleSignaturePath(archivePath string) string {
+	return archivePath + moduleSignatureSuffix
+}
+
+// moduleDigestPath returns the path of the digest of the module directory the archive was created from
+func moduleDigestPath(archivePath string) string {
+	return archivePath + moduleDigestSuffix
+}
+
+// attestationPath returns the path of the Sigstore bundle of the artifact
+func attestationPath(artifactPath string) string {
+	return artifactPath + attestationSuffix
+}
+
+// sbomPath returns the path of the SBOM of the artifact
+func sbomPath(artifactPath string) string {
+	return artifactPath + sbom.Suffix
+}
+
+// providerDocsPath returns the path of the documentation stored next to the SHA256SUMS file of the provider version
+func providerDocsPath(shasumPath string) string {
+	return shasumPath + providerDocsSuffix
+}
+
+// signingKeysPath returns a <prefix>/<type>/<hostname>/<namespace>/<name>/signing-keys.json path,
+// the hostname is only set for mirrored providers and the name only for the keys of a single provider
+func signingKeysPath(prefix string, pt providerType, hostname, namespace, name string) string {
 	return path.Join(
 		prefix,
 		string(pt),
 		hostname,
 		namespace,
-		"signing-keys.json",
+		name,
+		signingKeysFile,
 	)
 }
 
+// downloadStatsPath returns a <prefix>/stats/<artifact>/downloads.json path
+func downloadStatsPath(prefix, artifact string) string {
+	return path.Join(prefix, "stats", artifact, "downloads.json")
+}
+
+// consumersPath returns a <prefix>/consumers/<artifact>/consumers.json path
+func consumersPath(prefix, artifact string) string {
+	return path.Join(prefix, "consumers", artifact, "consumers.json")
+}
+
+// channelsPath returns a <prefix>/channels/<artifact>/channels.json path
+func channelsPath(prefix, artifact string) string {
+	return path.Join(prefix, "channels", artifact, "channels.json")
+}
+
 func readSHASums(r io.Reader, name string) (string, error) {
 	scanner := bufio.NewScanner(r)
 
@@ -112,6 +112,7 @@ func TestSigningKeysPath(t *testing.T) {
 		pt         providerType
 		hostname   string
 		namespace  string
+		name       string
 		expected   string
 	}{
 		{
@@ -121,6 +122,14 @@ func TestSigningKeysPath(t *testing.T) {
 			namespace:  "hashicorp",
 			expected:   "prefix/providers/hashicorp/signing-keys.json",
 		},
+		{
+			annotation: "internal keys of a single provider",
+			prefix:     "prefix",
+			pt:         internalProviderType,
+			namespace:  "hashicorp",
+			name:       "random",
+			expected:   "prefix/providers/hashicorp/random/signing-keys.json",
+		},
 		{
 			annotation: "mirrored keys with prefix and namespace",
 			prefix:     "prefix",
@@ -134,7 +143,7 @@ func TestSigningKeysPath(t *testing.T) {
 	for _, tc := range testCase {
 		tc := tc
 		t.Run(tc.annotation, func(t *testing.T) {
-			result := signingKeysPath(tc.prefix, tc.pt, tc.hostname, tc.namespace)
+			result := signingKeysPath(tc.prefix, tc.pt, tc.hostname, tc.namespace, tc.name)
 			assert.Equal(t, tc.expected, result)
 		})
 	}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
+	"github.com/boring-registry/boring-registry/pkg/scan"
+)
+
+// Drift is an object which doesn't fit the storage layout, or which lacks an object it depends on
+type Drift struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// ReindexResult summarizes a walk over all objects of the storage
+type ReindexResult struct {
+	// Modules and Providers are the number of stored versions, Mirrored the number of versions of the provider network mirror
+	Modules   int     `json:"modules"`
+	Providers int     `json:"providers"`
+	Mirrored  int     `json:"mirrored"`
+	Drift     []Drift `json:"drift"`
+}
+
+// Reindexer walks the storage and reconciles the stored modules and providers with the storage layout
+type Reindexer interface {
+	Reindex(ctx context.Context) (ReindexResult, error)
+}
+
+// Reindex walks all objects of the storage, counts the stored versions, and reports objects deviating from the storage layout,
+// e.g. after objects have been changed manually. The storage itself is the index of the registry, so nothing is written.
+func (s *ObjectStorage) Reindex(ctx context.Context) (ReindexResult, error) {
+	result := ReindexResult{Drift: []Drift{}}
+
+	objects, err := s.backend.List(ctx, s.prefix)
+	if err != nil {
+		return result, fmt.Errorf("failed to list objects to reindex: %w", err)
+	}
+
+	keys := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		keys[obj.Key] = true
+	}
+
+	w := &reindexWalk{
+		s:        s,
+		keys:     keys,
+		result:   &result,
+		formats:  s.archiveFormats(ctx),
+		versions: make(map[string]bool),
+		sums:     make(map[string]*core.Sha256Sums),
+	}
+	for _, obj := range objects {
+		rel := obj.Key
+		if s.prefix != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(obj.Key, strings.TrimSuffix(s.prefix, "/")+"/"); !ok {
+				// The listing may contain objects of sibling prefixes like <prefix>-other
+				continue
+			}
+		}
+		parts := strings.Split(rel, "/")
+
+		switch parts[0] {
+		case string(internalModuleType):
+			w.module(obj.Key, parts[1:])
+		case "providers":
+			w.provider(ctx, obj.Key, parts[1:], internalProviderType)
+		case "mirror":
+			if len(parts) > 2 && parts[1] == "providers" {
+				// The hostname is skipped, so that mirrored providers are checked like internal ones
+				w.provider(ctx, obj.Key, parts[3:], mirrorProviderType)
+				continue
+			}
+			w.drift(obj.Key, "unknown object in the mirror layout")
+		case "stats", "channels", "consumers", quarantineDir, subdirDir:
+			// Download statistics, channels, consumers, quarantined artifacts and extracted sub-directories are managed by the registry itself
+		default:
+			w.drift(obj.Key, "unknown object outside of the storage layout")
+		}
+	}
+
+	slices.SortFunc(result.Drift, func(a, b Drift) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	for _, d := range result.Drift {
+		slog.Warn("storage drift", slog.String("key", d.Key), slog.String("reason", d.Reason))
+	}
+	slog.Info("reindexed storage",
+		slog.Int("modules", result.Modules),
+		slog.Int("providers", result.Providers),
+		slog.Int("mirrored", result.Mirrored),
+		slog.Int("drift", len(result.Drift)),
+	)
+
+	return result, nil
+}
+
+// reindexWalk holds the state of a single Reindex
+type reindexWalk struct {
+	s      *ObjectStorage
+	keys   map[string]bool
+	result *ReindexResult
+
+	// formats are the module archive formats which are detected
+	formats []string
+
+	// versions are the module and provider versions which have been counted already
+	versions map[string]bool
+
+	// sums caches the parsed SHA256SUMS files, which are shared by all platforms of a provider version.
+	// Files which can't be read are cached as nil.
+	sums map[string]*core.Sha256Sums
+}
+
+func (w *reindexWalk) drift(key, reason string) {
+	w.result.Drift = append(w.result.Drift, Drift{Key: key, Reason: reason})
+}
+
+// module checks an object in the form of <namespace>/<name>/<provider>/<file>
+func (w *reindexWalk) module(key string, parts []string) {
+	if len(parts) != 4 {
+		w.drift(key, "unknown object in the module layout")
+		return
+	}
+	if parts[3] == moduleChecksumsFile || parts[3] == moduleMetadataFile {
+		return
+	}
+
+	for _, suffix := range []string{moduleChecksumSuffix, moduleSignatureSuffix, moduleDigestSuffix, attestationSuffix, sbom.Suffix, tombstoneSuffix, stagingSuffix} {
+		if strings.HasSuffix(key, suffix) {
+			if !w.keys[strings.TrimSuffix(key, suffix)] {
+				w.drift(key, "the module archive is missing")
+			}
+			return
+		}
+	}
+
+	m, _, err := moduleFromArchive(key, w.formats)
+	if err != nil {
+		w.drift(key, fmt.Sprintf("unknown object in the module layout: %s", err))
+		return
+	}
+	if !w.keys[moduleChecksumPath(key)] {
+		w.drift(key, "the checksum of the module archive is missing")
+	}
+	// Versions stored in multiple formats are counted once
+	version := path.Join(path.Dir(key), m.Version)
+	if !w.keys[moduleTombstonePath(key)] && !w.keys[moduleStagingPath(key)] && !w.versions[version] {
+		w.versions[version] = true
+		w.result.Modules++
+	}
+}
+
+// provider checks an object in the form of <namespace>/signing-keys.json or <namespace>/<name>/<file>,
+// internal providers can have signing keys of their own in the form of <namespace>/<name>/signing-keys.json
+func (w *reindexWalk) provider(ctx context.Context, key string, parts []string, pt providerType) {
+	if len(parts) == 2 && parts[1] == signingKeysFile {
+		return
+	}
+	if len(parts) == 3 && parts[2] == signingKeysFile && pt == internalProviderType {
+		return
+	}
+	if len(parts) != 3 {
+		w.drift(key, "unknown object in the provider layout")
+		return
+	}
+	namespace, name, file := parts[0], parts[1], parts[2]
+	dir := path.Dir(key)
+
+	if !strings.HasPrefix(file, fmt.Sprintf("%s%s_", core.ProviderPrefix, name)) {
+		w.drift(key, "unknown object in the provider layout")
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(file, tombstoneSuffix):
+		// Tombstones mark all objects of a version, which start with terraform-provider-<name>_<version>_
+		prefix := strings.TrimSuffix(key, tombstoneSuffix) + "_"
+		for k := range w.keys {
+			if strings.HasPrefix(k, prefix) {
+				return
+			}
+		}
+		w.drift(key, "the provider version is missing")
+		return
+	case strings.HasSuffix(file, core.ProviderManifestSuffix):
+		version := providerFileVersion(file)
+		if version == "" || !w.keys[path.Join(dir, (&core.Provider{Name: name, Version: version}).ShasumFileName())] {
+			w.drift(key, "the SHA256SUMS file of the provider version is missing")
+		}
+		return
+	case strings.HasSuffix(file, "_SHA256SUMS"):
+		if !w.keys[key+".sig"] {
+			w.drift(key, "the signature of the SHA256SUMS file is missing")
+		}
+		return
+	}
+	for _, suffix := range []string{".sig", ".hashes", attestationSuffix, sbom.Suffix, scan.ResultSuffix, providerDocsSuffix} {
+		if strings.HasSuffix(file, suffix) {
+			if !w.keys[strings.TrimSuffix(key, suffix)] {
+				w.drift(key, "the signed or hashed file is missing")
+			}
+			return
+		}
+	}
+
+	p, err := core.NewProviderFromArchive(file)
+	if err != nil {
+		w.drift(key, fmt.Sprintf("unknown object in the provider layout: %s", err))
+		return
+	}
+
+	shasumPath := path.Join(dir, p.ShasumFileName())
+	if !w.keys[shasumPath] {
+		w.drift(key, "the SHA256SUMS file of the provider version is missing")
+	} else if sums := w.shaSums(ctx, shasumPath); sums != nil {
+		if _, ok := sums.Entries[file]; !ok {
+			w.drift(key, "the provider archive isn't listed in the SHA256SUMS file")
+		}
+	}
+
+	if pt == internalProviderType && w.keys[providerTombstonePath(w.s.prefix, namespace, name, p.Version)] {
+		return
+	}
+	version := path.Join(dir, p.Version)
+	if w.versions[version] {
+		return
+	}
+	w.versions[version] = true
+	if pt == internalProviderType {
+		w.result.Providers++
+	} else {
+		w.result.Mirrored++
+	}
+}
+
+// shaSums returns the parsed SHA256SUMS file, or nil if it can't be read, which is only reported once
+func (w *reindexWalk) shaSums(ctx context.Context, key string) *core.Sha256Sums {
+	if sums, ok := w.sums[key]; ok {
+		return sums
+	}
+
+	data, err := w.s.backend.Download(ctx, key)
+	var sums *core.Sha256Sums
+	if err == nil {
+		sums, err = core.NewSha256Sums(path.Base(key), bytes.NewReader(data))
+	}
+	if err != nil {
+		w.drift(key, fmt.Sprintf("the SHA256SUMS file can't be read: %s", err))
+		sums = nil
+	}
+	w.sums[key] = sums
+	return sums
+}
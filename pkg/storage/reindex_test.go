@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_Reindex(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err := s.UploadModule(ctx, "acme", "vpc", "aws", version, strings.NewReader("module"))
+		assert.NoError(err)
+	}
+	checksum := sha256.Sum256([]byte("module"))
+	assert.NoError(s.UploadModuleChecksum(ctx, "acme", "vpc", "aws", "1.0.0", hex.EncodeToString(checksum[:])))
+	archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+	shasum := sha256.Sum256([]byte("provider archive"))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", archive, strings.NewReader("provider archive")))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS",
+		strings.NewReader(fmt.Sprintf("%s  %s\n", hex.EncodeToString(shasum[:]), archive))))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS.sig", strings.NewReader("sig")))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_manifest.json",
+		strings.NewReader(`{"version": 1, "metadata": {"protocol_versions": ["5.0"]}}`)))
+	assert.NoError(s.backend.Upload(ctx, "providers/acme/random/"+archive+".scan.json", strings.NewReader(`{"clean": true}`)))
+	assert.NoError(s.UploadModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader(`{"bomFormat": "CycloneDX"}`)))
+	assert.NoError(s.UploadProviderSBOM(ctx, "acme", "random", "2.0.0", strings.NewReader(`{"spdxVersion": "SPDX-2.3"}`)))
+	assert.NoError(s.UploadSigningKeys(ctx, "acme", "random", &core.SigningKeys{GPGPublicKeys: []core.GPGPublicKey{{KeyID: "ABCDEF", ASCIIArmor: "armor"}}}))
+	assert.NoError(s.backend.Upload(ctx, "quarantine/providers/acme/random/terraform-provider-random_2.0.1_linux_amd64.zip", strings.NewReader("EICAR")))
+
+	result, err := s.Reindex(ctx)
+	assert.NoError(err)
+	assert.Equal(ReindexResult{Modules: 2, Providers: 1, Drift: []Drift{}}, result)
+
+	// Objects changed manually are reported as drift
+	assert.NoError(s.backend.Delete(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz.sha256"))
+	assert.NoError(s.backend.Delete(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz"))
+	assert.NoError(s.backend.Upload(ctx, "providers/acme/random/terraform-provider-random_2.0.0_darwin_arm64.zip", strings.NewReader("provider archive")))
+	assert.NoError(s.backend.Upload(ctx, "backup/modules.tar", strings.NewReader("backup")))
+
+	result, err = s.Reindex(ctx)
+	assert.NoError(err)
+	assert.Equal(1, result.Modules)
+	assert.Equal(1, result.Providers)
+	assert.Equal([]Drift{
+		{Key: "backup/modules.tar", Reason: "unknown object outside of the storage layout"},
+		{Key: "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", Reason: "the checksum of the module archive is missing"},
+		{Key: "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz.sha256", Reason: "the module archive is missing"},
+		{Key: "providers/acme/random/terraform-provider-random_2.0.0_darwin_arm64.zip", Reason: "the provider archive isn't listed in the SHA256SUMS file"},
+	}, result.Drift)
+}
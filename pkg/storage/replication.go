@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// ReplicationMode defines when uploads are copied to the replication targets
+type ReplicationMode string
+
+const (
+	// ReplicationModeSync copies uploads to all targets before the upload returns, and fails the upload if a target fails
+	ReplicationModeSync ReplicationMode = "sync"
+
+	// ReplicationModeAsync queues uploads and copies them to the targets in the background
+	ReplicationModeAsync ReplicationMode = "async"
+
+	// DefaultReplicationQueueSize is the number of objects waiting for asynchronous replication
+	DefaultReplicationQueueSize = 1000
+)
+
+// ReplicationResult summarizes a reconciliation of the replication targets
+type ReplicationResult struct {
+	Copied   int
+	UpToDate int
+	Failed   int
+}
+
+// Replicator mirrors the objects of a Backend to one or more secondary Backends, e.g. buckets in other regions.
+// Uploads are replicated by the Decorator, objects missed by the Decorator are copied by Reconcile.
+type Replicator struct {
+	targets   []Backend
+	mode      ReplicationMode
+	queueSize int
+	logger    *slog.Logger
+
+	// source is the decorated Backend, it is set once the Decorator is applied
+	source Backend
+
+	mu     sync.Mutex
+	closed bool
+	queue  chan string
+	wg     sync.WaitGroup
+}
+
+// Decorator returns the Decorator replicating uploads to the targets, it must only be applied to a single Backend
+func (r *Replicator) Decorator() Decorator {
+	return func(next Backend) Backend {
+		r.source = next
+		return &replicationBackend{
+			next:       next,
+			replicator: r,
+		}
+	}
+}
+
+// Reconcile copies all objects of the source which are missing in a target or differ in size
+func (r *Replicator) Reconcile(ctx context.Context) (ReplicationResult, error) {
+	var result ReplicationResult
+	if r.source == nil {
+		return result, errors.New("the replication decorator wasn't applied to a storage backend")
+	}
+
+	objects, err := r.source.List(ctx, "")
+	if err != nil {
+		return result, fmt.Errorf("failed to list objects to replicate: %w", err)
+	}
+
+	var errs []error
+	for i, target := range r.targets {
+		existing, err := target.List(ctx, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list objects of replication target %d: %w", i, err))
+			result.Failed += len(objects)
+			continue
+		}
+
+		sizes := make(map[string]int64, len(existing))
+		for _, obj := range existing {
+			sizes[obj.Key] = obj.Size
+		}
+
+		for _, obj := range objects {
+			if size, ok := sizes[obj.Key]; ok && size == obj.Size {
+				result.UpToDate++
+				continue
+			}
+
+			if err := r.copy(ctx, obj.Key, target); err != nil {
+				errs = append(errs, err)
+				result.Failed++
+				continue
+			}
+			result.Copied++
+		}
+	}
+
+	r.logger.Info("reconciled replication targets",
+		slog.Int("copied", result.Copied),
+		slog.Int("up-to-date", result.UpToDate),
+		slog.Int("failed", result.Failed),
+	)
+
+	return result, errors.Join(errs...)
+}
+
+// Close stops accepting asynchronous replications and waits for the queued ones to finish
+func (r *Replicator) Close() {
+	r.mu.Lock()
+	if !r.closed && r.queue != nil {
+		close(r.queue)
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	r.wg.Wait()
+}
+
+func (r *Replicator) copy(ctx context.Context, key string, target Backend) error {
+	data, err := r.source.Download(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to replicate %s: %w", key, err)
+	}
+
+	if err := target.Upload(ctx, key, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to replicate %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// enqueue schedules the asynchronous replication of the object
+func (r *Replicator) enqueue(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		r.logger.Warn("replicator is closed, the object is replicated by the next reconciliation", slog.String("key", key))
+		return
+	}
+
+	select {
+	case r.queue <- key:
+	default:
+		r.logger.Warn("replication queue is full, the object is replicated by the next reconciliation", slog.String("key", key))
+	}
+}
+
+func (r *Replicator) work(ctx context.Context) {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case key, ok := <-r.queue:
+			if !ok {
+				return
+			}
+
+			for _, target := range r.targets {
+				if err := r.copy(ctx, key, target); err != nil {
+					r.logger.Error("asynchronous replication failed", slog.String("err", err.Error()))
+				}
+			}
+		}
+	}
+}
+
+type replicationBackend struct {
+	next       Backend
+	replicator *Replicator
+}
+
+func (b *replicationBackend) Exists(ctx context.Context, key string) (bool, error) {
+	return b.next.Exists(ctx, key)
+}
+
+func (b *replicationBackend) Download(ctx context.Context, key string) ([]byte, error) {
+	return b.next.Download(ctx, key)
+}
+
+func (b *replicationBackend) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.next.Open(ctx, key)
+}
+
+// Upload stores the object in the decorated Backend and replicates it to the targets
+func (b *replicationBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	if b.replicator.mode == ReplicationModeAsync {
+		if err := b.next.Upload(ctx, key, reader); err != nil {
+			return err
+		}
+		b.replicator.enqueue(key)
+		return nil
+	}
+
+	// The content is spooled, as it's uploaded more than once
+	data, size, cleanup, err := spool(reader)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	defer cleanup()
+
+	if err := b.next.Upload(ctx, key, io.NewSectionReader(data, 0, size)); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, target := range b.replicator.targets {
+		if err := target.Upload(ctx, key, io.NewSectionReader(data, 0, size)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to replicate %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Delete removes the object from the decorated Backend and the targets, which is done synchronously in both modes
+func (b *replicationBackend) Delete(ctx context.Context, key string) error {
+	if err := b.next.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, target := range b.replicator.targets {
+		if err := target.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to delete replicated %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (b *replicationBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	return b.next.List(ctx, prefix)
+}
+
+func (b *replicationBackend) PresignedURL(ctx context.Context, key string) (string, error) {
+	return b.next.PresignedURL(ctx, key)
+}
+
+func (b *replicationBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.next.GetDownloadUrl(ctx, url)
+}
+
+// ReplicatorOption provides additional options for the Replicator.
+type ReplicatorOption func(*Replicator)
+
+// WithReplicationMode configures whether uploads are replicated synchronously or asynchronously.
+func WithReplicationMode(mode ReplicationMode) ReplicatorOption {
+	return func(r *Replicator) {
+		r.mode = mode
+	}
+}
+
+// WithReplicationQueueSize configures the number of objects waiting for asynchronous replication.
+func WithReplicationQueueSize(size int) ReplicatorOption {
+	return func(r *Replicator) {
+		r.queueSize = size
+	}
+}
+
+// NewReplicator returns a Replicator copying objects to the targets.
+// The context bounds the asynchronous replication in the background.
+func NewReplicator(ctx context.Context, targets []Backend, options ...ReplicatorOption) (*Replicator, error) {
+	r := &Replicator{
+		targets:   targets,
+		mode:      ReplicationModeSync,
+		queueSize: DefaultReplicationQueueSize,
+		logger:    slog.Default(),
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	switch r.mode {
+	case ReplicationModeSync:
+	case ReplicationModeAsync:
+		if r.queueSize < 1 {
+			return nil, fmt.Errorf("the replication queue size must be at least 1, but is %d", r.queueSize)
+		}
+		r.queue = make(chan string, r.queueSize)
+		r.wg.Add(1)
+		go r.work(ctx)
+	default:
+		return nil, fmt.Errorf("unknown replication mode %q, expected %q or %q", r.mode, ReplicationModeSync, ReplicationModeAsync)
+	}
+
+	return r, nil
+}
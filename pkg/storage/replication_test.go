@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{
+		objects: make(map[string]memoryObject),
+		now:     time.Now,
+	}
+}
+
+type failingBackend struct {
+	*memoryBackend
+}
+
+func (b *failingBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	return errors.New("region unavailable")
+}
+
+func TestReplicator_Sync(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	target := newMemoryBackend()
+
+	r, err := NewReplicator(ctx, []Backend{target})
+	assert.NoError(err)
+	backend := Decorate(newMemoryBackend(), r.Decorator())
+
+	assert.NoError(backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", strings.NewReader("module")))
+	data, err := target.Download(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz")
+	assert.NoError(err)
+	assert.Equal("module", string(data))
+
+	r, err = NewReplicator(ctx, []Backend{&failingBackend{newMemoryBackend()}})
+	assert.NoError(err)
+	source := newMemoryBackend()
+	backend = Decorate(source, r.Decorator())
+
+	assert.ErrorContains(backend.Upload(ctx, "key", strings.NewReader("data")), "region unavailable")
+	exists, err := source.Exists(ctx, "key")
+	assert.NoError(err)
+	assert.True(exists)
+}
+
+func TestReplicator_Async(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	target := newMemoryBackend()
+
+	r, err := NewReplicator(ctx, []Backend{target}, WithReplicationMode(ReplicationModeAsync))
+	assert.NoError(err)
+	backend := Decorate(newMemoryBackend(), r.Decorator())
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.NoError(backend.Upload(ctx, key, strings.NewReader(key)))
+	}
+	r.Close()
+
+	objects, err := target.List(ctx, "")
+	assert.NoError(err)
+	assert.Len(objects, 3)
+
+	// Uploads after closing are left to the reconciliation
+	assert.NoError(backend.Upload(ctx, "d", strings.NewReader("d")))
+	exists, err := target.Exists(ctx, "d")
+	assert.NoError(err)
+	assert.False(exists)
+}
+
+func TestReplicator_Reconcile(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	source, target := newMemoryBackend(), newMemoryBackend()
+
+	for key, content := range map[string]string{"a": "a", "b": "b", "c": "changed"} {
+		assert.NoError(source.Upload(ctx, key, strings.NewReader(content)))
+	}
+	assert.NoError(target.Upload(ctx, "a", strings.NewReader("a")))
+	assert.NoError(target.Upload(ctx, "c", strings.NewReader("c")))
+
+	r, err := NewReplicator(ctx, []Backend{target})
+	assert.NoError(err)
+
+	_, err = r.Reconcile(ctx)
+	assert.ErrorContains(err, "wasn't applied")
+
+	Decorate(source, r.Decorator())
+	result, err := r.Reconcile(ctx)
+	assert.NoError(err)
+	assert.Equal(ReplicationResult{Copied: 2, UpToDate: 1}, result)
+
+	data, err := target.Download(ctx, "c")
+	assert.NoError(err)
+	assert.Equal("changed", string(data))
+
+	result, err = r.Reconcile(ctx)
+	assert.NoError(err)
+	assert.Equal(ReplicationResult{UpToDate: 3}, result)
+}
+
+func TestNewReplicator_Errors(t *testing.T) {
+	_, err := NewReplicator(context.Background(), nil, WithReplicationMode("eventually"))
+	assertion.ErrorContains(t, err, "unknown replication mode")
+
+	_, err = NewReplicator(context.Background(), nil, WithReplicationMode(ReplicationModeAsync), WithReplicationQueueSize(0))
+	assertion.ErrorContains(t, err, "queue size")
+}
@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RepositoryType selects the API used to list the objects of a RepositoryStorage
+type RepositoryType string
+
+const (
+	// RepositoryTypeArtifactory is a generic repository of JFrog Artifactory
+	RepositoryTypeArtifactory RepositoryType = "artifactory"
+
+	// RepositoryTypeNexus is a raw repository of Sonatype Nexus Repository
+	RepositoryTypeNexus RepositoryType = "nexus"
+)
+
+// repositoryError is returned for unexpected responses of the repository, its status code is evaluated by the RetryDecorator
+type repositoryError struct {
+	operation  string
+	key        string
+	statusCode int
+}
+
+func (e *repositoryError) Error() string {
+	return fmt.Sprintf("failed to %s %s: status code is %d", e.operation, e.key, e.statusCode)
+}
+
+func (e *repositoryError) HTTPStatusCode() int {
+	return e.statusCode
+}
+
+// RepositoryStorage is a Backend implementation storing the objects as artifacts in a repository of a binary repository manager,
+// which are read and written with plain HTTP requests.
+// NewRepositoryStorage wraps it into an ObjectStorage, which implements module.Storage, provider.Storage, and mirror.Storage
+type RepositoryStorage struct {
+	client                *http.Client
+	repoType              RepositoryType
+	repositoryURL         *url.URL
+	apiKey                string
+	token                 string
+	username              string
+	password              string
+	prefix                string
+	moduleArchiveFormat   string
+	convertModuleArchives bool
+	signedURLExpiry       time.Duration
+	decorators            []Decorator
+	objectOptions         []ObjectStorageOption
+
+	// baseURL and repository are derived from the repository URL to call the APIs of the repository manager
+	baseURL    *url.URL
+	repository string
+}
+
+// Exists checks if an artifact with the key exists in the repository
+func (s *RepositoryStorage) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, s.artifactURL(key), nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, &repositoryError{operation: "look up", key: key, statusCode: resp.StatusCode}
+	}
+}
+
+// Download reads the artifact from the repository
+func (s *RepositoryStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.artifactURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &repositoryError{operation: "download", key: key, statusCode: resp.StatusCode}
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (s *RepositoryStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.artifactURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &repositoryError{operation: "download", key: key, statusCode: resp.StatusCode}
+	}
+
+	return resp.Body, nil
+}
+
+// Upload deploys the artifact to the repository
+func (s *RepositoryStorage) Upload(ctx context.Context, key string, reader io.Reader) error {
+	resp, err := s.do(ctx, http.MethodPut, s.artifactURL(key), reader)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return &repositoryError{operation: "upload", key: key, statusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// Delete removes the artifact from the repository
+func (s *RepositoryStorage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.artifactURL(key), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return &repositoryError{operation: "delete", key: key, statusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// List returns all artifacts in the repository with the given prefix
+func (s *RepositoryStorage) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	var err error
+	if s.repoType == RepositoryTypeNexus {
+		objects, err = s.listNexus(ctx)
+	} else {
+		objects, err = s.listArtifactory(ctx, prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := objects[:0]
+	for _, obj := range objects {
+		if strings.HasPrefix(obj.Key, prefix) {
+			filtered = append(filtered, obj)
+		}
+	}
+
+	return filtered, nil
+}
+
+// listArtifactory lists the folder containing the prefix with the File List API.
+// See https://jfrog.com/help/r/jfrog-rest-apis/file-list
+func (s *RepositoryStorage) listArtifactory(ctx context.Context, prefix string) ([]Object, error) {
+	folder := prefix[:strings.LastIndex(prefix, "/")+1]
+
+	u := s.baseURL.JoinPath("api", "storage", s.repository, folder)
+	u.RawQuery = "list&deep=1&listFolders=0"
+	resp, err := s.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// The folder doesn't exist yet
+		return nil, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, &repositoryError{operation: "list", key: prefix, statusCode: resp.StatusCode}
+	}
+
+	var list struct {
+		Files []struct {
+			URI          string    `json:"uri"`
+			Size         int64     `json:"size"`
+			LastModified time.Time `json:"lastModified"`
+			Folder       bool      `json:"folder"`
+		} `json:"files"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode artifacts of %s: %w", prefix, err)
+	}
+
+	var objects []Object
+	for _, f := range list.Files {
+		if f.Folder {
+			continue
+		}
+		objects = append(objects, Object{
+			Key:          folder + strings.TrimPrefix(f.URI, "/"),
+			Size:         f.Size,
+			LastModified: f.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+// listNexus pages through all assets of the repository with the Assets API, as it can't be filtered by a path prefix.
+// See https://help.sonatype.com/en/assets-api.html
+func (s *RepositoryStorage) listNexus(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	continuationToken := ""
+	for {
+		u := s.baseURL.JoinPath("service", "rest", "v1", "assets")
+		query := url.Values{"repository": {s.repository}}
+		if continuationToken != "" {
+			query.Set("continuationToken", continuationToken)
+		}
+		u.RawQuery = query.Encode()
+
+		resp, err := s.do(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Items []struct {
+				Path         string    `json:"path"`
+				FileSize     int64     `json:"fileSize"`
+				LastModified time.Time `json:"lastModified"`
+			} `json:"items"`
+			ContinuationToken string `json:"continuationToken"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, &repositoryError{operation: "list", key: s.repository, statusCode: resp.StatusCode}
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode assets of %s: %w", s.repository, err)
+		}
+
+		for _, item := range page.Items {
+			objects = append(objects, Object{
+				Key:          strings.TrimPrefix(item.Path, "/"),
+				Size:         item.FileSize,
+				LastModified: item.LastModified,
+			})
+		}
+
+		if page.ContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = page.ContinuationToken
+	}
+}
+
+// PresignedURL returns a signed URL created by Artifactory if signed URLs are enabled, otherwise the plain URL of the artifact.
+// The plain URL can only be downloaded by Terraform if the repository allows anonymous read access.
+func (s *RepositoryStorage) PresignedURL(ctx context.Context, key string) (string, error) {
+	if s.repoType != RepositoryTypeArtifactory || s.signedURLExpiry <= 0 {
+		return s.artifactURL(key).String(), nil
+	}
+
+	// See https://jfrog.com/help/r/jfrog-rest-apis/create-signed-url
+	body, err := json.Marshal(map[string]interface{}{
+		"repo_path":      s.repository + "/" + s.key(key),
+		"valid_for_secs": int(s.signedURLExpiry.Seconds()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, s.baseURL.JoinPath("api", "signed", "url"), bytes.NewReader(body), "Content-Type", "application/json")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &repositoryError{operation: "sign URL of", key: key, statusCode: resp.StatusCode}
+	}
+
+	signed, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign URL: %w", err)
+	}
+
+	return strings.TrimSpace(string(signed)), nil
+}
+
+// GetDownloadUrl returns the URL of the artifact below the repository URL
+func (s *RepositoryStorage) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(s.repositoryURL.String(), "/"), url), nil
+}
+
+func (s *RepositoryStorage) key(key string) string {
+	return strings.TrimPrefix(key, "/")
+}
+
+func (s *RepositoryStorage) artifactURL(key string) *url.URL {
+	return s.repositoryURL.JoinPath(s.key(key))
+}
+
+// do sends the request with the credentials, headers are given as pairs of names and values
+func (s *RepositoryStorage) do(ctx context.Context, method string, u *url.URL, body io.Reader, headers ...string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.token))
+	case s.apiKey != "":
+		req.Header.Set("X-JFrog-Art-Api", s.apiKey)
+	case s.username != "":
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to repository: %w", err)
+	}
+
+	return resp, nil
+}
+
+// RepositoryStorageOption provides additional options for the RepositoryStorage.
+type RepositoryStorageOption func(*RepositoryStorage)
+
+// WithRepositoryStorageType configures the repository manager, which defaults to Artifactory.
+func WithRepositoryStorageType(repoType RepositoryType) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.repoType = repoType
+	}
+}
+
+// WithRepositoryStorageAPIKey authenticates with an Artifactory API key.
+func WithRepositoryStorageAPIKey(apiKey string) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.apiKey = apiKey
+	}
+}
+
+// WithRepositoryStorageToken authenticates with a bearer token, e.g. an Artifactory access token.
+func WithRepositoryStorageToken(token string) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.token = token
+	}
+}
+
+// WithRepositoryStorageBasicAuth authenticates with a username and password.
+func WithRepositoryStorageBasicAuth(username, password string) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithRepositoryStoragePrefix configures the repository storage to work under a given prefix.
+func WithRepositoryStoragePrefix(prefix string) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.prefix = prefix
+	}
+}
+
+// WithRepositoryStorageArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
+func WithRepositoryStorageArchiveFormat(archiveFormat string) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.moduleArchiveFormat = archiveFormat
+	}
+}
+
+// WithRepositoryStorageArchiveConversion stores all uploaded module archives in the configured format, archives in another format are repackaged
+func WithRepositoryStorageArchiveConversion(enabled bool) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.convertModuleArchives = enabled
+	}
+}
+
+// WithRepositoryStorageSignedUrlExpiry configures the duration until the signed url expires, signed URLs are disabled if 0.
+// Signed URLs are only supported by Artifactory.
+func WithRepositoryStorageSignedUrlExpiry(t time.Duration) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.signedURLExpiry = t
+	}
+}
+
+// WithRepositoryStorageHTTPClient replaces the HTTP client used for the requests to the repository.
+func WithRepositoryStorageHTTPClient(client *http.Client) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.client = client
+	}
+}
+
+// WithRepositoryStorageDecorators wraps the repository backend with the given decorators.
+func WithRepositoryStorageDecorators(decorators ...Decorator) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.decorators = append(s.decorators, decorators...)
+	}
+}
+
+// WithRepositoryStorageObjectOptions applies the options to the ObjectStorage of the repository storage, e.g. quotas or the admission controller
+func WithRepositoryStorageObjectOptions(options ...ObjectStorageOption) RepositoryStorageOption {
+	return func(s *RepositoryStorage) {
+		s.objectOptions = append(s.objectOptions, options...)
+	}
+}
+
+// newRepositoryBackend returns the RepositoryStorage for the URL of the repository,
+// e.g. https://acme.jfrog.io/artifactory/terraform for Artifactory or https://nexus.example.com/repository/terraform for Nexus.
+func newRepositoryBackend(repositoryURL string, options ...RepositoryStorageOption) (*RepositoryStorage, error) {
+	u, err := url.Parse(strings.TrimSuffix(repositoryURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repository URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("the repository URL %s must be an HTTP(S) URL", u.Redacted())
+	}
+
+	s := &RepositoryStorage{
+		client:        &http.Client{},
+		repoType:      RepositoryTypeArtifactory,
+		repositoryURL: u,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	// The APIs are served below the path preceding the repository name
+	i := strings.LastIndex(u.Path, "/")
+	s.repository = u.Path[i+1:]
+	base := u.Path[:max(i, 0)]
+	switch s.repoType {
+	case RepositoryTypeArtifactory:
+	case RepositoryTypeNexus:
+		var ok bool
+		if base, ok = strings.CutSuffix(base, "/repository"); !ok {
+			return nil, fmt.Errorf("the Nexus repository URL %s must have the form https://<host>/repository/<repository>", u.Redacted())
+		}
+	default:
+		return nil, fmt.Errorf("unknown repository type %q, expected %q or %q", s.repoType, RepositoryTypeArtifactory, RepositoryTypeNexus)
+	}
+	if s.repository == "" {
+		return nil, fmt.Errorf("the repository URL %s doesn't contain a repository", u.Redacted())
+	}
+	s.baseURL = &url.URL{Scheme: u.Scheme, Host: u.Host, Path: base}
+
+	return s, nil
+}
+
+// NewRepositoryStorage returns a fully initialized storage for the repository of Artifactory or Nexus.
+func NewRepositoryStorage(repositoryURL string, options ...RepositoryStorageOption) (Storage, error) {
+	s, err := newRepositoryBackend(repositoryURL, options...)
+	if err != nil {
+		return nil, err
+	}
+	if s.signedURLExpiry > 0 && s.repoType != RepositoryTypeArtifactory {
+		return nil, errors.New("signed URLs are only supported by Artifactory")
+	}
+
+	objectOptions := []ObjectStorageOption{
+		WithObjectStoragePrefix(s.prefix),
+		WithObjectStorageArchiveFormat(s.moduleArchiveFormat),
+		WithObjectStorageArchiveConversion(s.convertModuleArchives),
+		WithObjectStorageDecorators(s.decorators...),
+	}
+	return NewObjectStorage(s, append(objectOptions, s.objectOptions...)...), nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// fakeRepositoryManager serves the artifacts of the repository "terraform" like Artifactory and Nexus
+type fakeRepositoryManager struct {
+	mu        sync.Mutex
+	artifacts map[string][]byte
+	auth      func(r *http.Request) bool
+}
+
+func (f *fakeRepositoryManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.auth(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/artifactory/api/storage/terraform/"):
+		folder := strings.TrimPrefix(r.URL.Path, "/artifactory/api/storage/terraform/")
+		var files []map[string]interface{}
+		for key, data := range f.artifacts {
+			if rel, ok := strings.CutPrefix(key, folder); ok {
+				files = append(files, map[string]interface{}{"uri": "/" + rel, "size": len(data), "folder": false})
+			}
+		}
+		if len(files) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"files": files})
+	case r.URL.Path == "/artifactory/api/signed/url":
+		var req struct {
+			RepoPath     string `json:"repo_path"`
+			ValidForSecs int    `json:"valid_for_secs"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = w.Write([]byte("https://signed.example.com/" + req.RepoPath + "?expires=" + strconv.Itoa(req.ValidForSecs)))
+	case r.URL.Path == "/service/rest/v1/assets":
+		// Every page contains a single asset to exercise the continuation token
+		var keys []string
+		for key := range f.artifacts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		i, _ := strconv.Atoi(r.URL.Query().Get("continuationToken"))
+		page := map[string]interface{}{"items": []interface{}{}}
+		if i < len(keys) {
+			page["items"] = []interface{}{map[string]interface{}{"path": "/" + keys[i], "fileSize": len(f.artifacts[keys[i]])}}
+		}
+		if i+1 < len(keys) {
+			page["continuationToken"] = strconv.Itoa(i + 1)
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	default:
+		key, ok := strings.CutPrefix(r.URL.Path, "/artifactory/terraform/")
+		if !ok {
+			key, ok = strings.CutPrefix(r.URL.Path, "/repository/terraform/")
+		}
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			f.artifacts[key], _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodHead, http.MethodGet:
+			data, ok := f.artifacts[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}
+}
+
+func TestRepositoryStorage_Artifactory(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	fake := &fakeRepositoryManager{
+		artifacts: make(map[string][]byte),
+		auth:      func(r *http.Request) bool { return r.Header.Get("X-JFrog-Art-Api") == "secret" },
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewRepositoryStorage(server.URL+"/artifactory/terraform/",
+		WithRepositoryStorageAPIKey("secret"),
+		WithRepositoryStoragePrefix("registry"),
+	)
+	assert.NoError(err)
+
+	modules, err := s.ListModuleVersions(ctx, "acme", "vpc", "aws")
+	assert.NoError(err)
+	assert.Empty(modules)
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.ErrorIs(err, module.ErrModuleAlreadyExists)
+	_, err = s.UploadModule(ctx, "acme", "vpc-endpoints", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+
+	modules, err = s.ListModuleVersions(ctx, "acme", "vpc", "aws")
+	assert.NoError(err)
+	assert.Len(modules, 1)
+
+	m, err := s.GetModule(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal(server.URL+"/artifactory/terraform/registry/modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz", m.DownloadURL)
+
+	checksum, err := s.GetModuleChecksum(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Len(checksum, 64)
+
+	// Signed URLs are created by Artifactory
+	s, err = NewRepositoryStorage(server.URL+"/artifactory/terraform",
+		WithRepositoryStorageAPIKey("secret"),
+		WithRepositoryStoragePrefix("registry"),
+		WithRepositoryStorageSignedUrlExpiry(5*time.Minute),
+	)
+	assert.NoError(err)
+
+	m, err = s.GetModule(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal("https://signed.example.com/terraform/registry/modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz?expires=300", m.DownloadURL)
+}
+
+func TestRepositoryStorage_Nexus(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+
+	fake := &fakeRepositoryManager{
+		artifacts: make(map[string][]byte),
+		auth: func(r *http.Request) bool {
+			username, password, ok := r.BasicAuth()
+			return ok && username == "registry" && password == "secret"
+		},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewRepositoryStorage(server.URL+"/repository/terraform",
+		WithRepositoryStorageType(RepositoryTypeNexus),
+		WithRepositoryStorageBasicAuth("registry", "secret"),
+	)
+	assert.NoError(err)
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err = s.UploadModule(ctx, "acme", "vpc", "aws", version, strings.NewReader("module"))
+		assert.NoError(err)
+	}
+
+	modules, err := s.ListModuleVersions(ctx, "acme", "vpc", "aws")
+	assert.NoError(err)
+	assert.Len(modules, 2)
+
+	b := s.(*ObjectStorage).backend
+	exists, err := b.Exists(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz")
+	assert.NoError(err)
+	assert.True(exists)
+
+	data, err := b.Download(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz")
+	assert.NoError(err)
+	assert.Equal("module", string(data))
+
+	// Unauthenticated requests fail with the status code of the repository
+	s, err = NewRepositoryStorage(server.URL+"/repository/terraform", WithRepositoryStorageType(RepositoryTypeNexus))
+	assert.NoError(err)
+	_, err = s.(*ObjectStorage).backend.Exists(ctx, "modules")
+	var statusErr interface{ HTTPStatusCode() int }
+	assert.ErrorAs(err, &statusErr)
+	assert.Equal(http.StatusUnauthorized, statusErr.HTTPStatusCode())
+}
+
+func TestNewRepositoryStorage_Errors(t *testing.T) {
+	_, err := NewRepositoryStorage("ftp://example.com/artifactory/terraform")
+	assertion.ErrorContains(t, err, "HTTP(S) URL")
+
+	_, err = NewRepositoryStorage("https://nexus.example.com/terraform", WithRepositoryStorageType(RepositoryTypeNexus))
+	assertion.ErrorContains(t, err, "/repository/<repository>")
+
+	_, err = NewRepositoryStorage("https://nexus.example.com/repository/terraform", WithRepositoryStorageType(RepositoryTypeNexus), WithRepositoryStorageSignedUrlExpiry(time.Minute))
+	assertion.ErrorContains(t, err, "only supported by Artifactory")
+
+	_, err = NewRepositoryStorage("https://example.com/terraform", WithRepositoryStorageType("s3"))
+	assertion.ErrorContains(t, err, "unknown repository type")
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/aws/smithy-go"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryMaxElapsedTime = 30 * time.Second
+)
+
+// awsThrottlingErrorCodes are the error codes AWS returns if requests are throttled
+var awsThrottlingErrorCodes = map[string]bool{
+	"SlowDown":                 true,
+	"Throttling":               true,
+	"ThrottlingException":      true,
+	"RequestLimitExceeded":     true,
+	"RequestThrottled":         true,
+	"TooManyRequestsException": true,
+}
+
+// RetryPolicy configures how often and how long operations against a Backend are retried
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one, retries are disabled if it is smaller than 2
+	MaxAttempts int
+
+	// MaxElapsedTime limits the total time spent on an operation including all backoffs, there is no limit if it is 0
+	MaxElapsedTime time.Duration
+
+	// InitialInterval is the upper bound of the backoff before the first retry
+	InitialInterval time.Duration
+
+	// MaxInterval is the upper bound of the backoff between two attempts
+	MaxInterval time.Duration
+}
+
+// backoff returns the duration to wait before the given retry with exponential backoff and full jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialInterval
+	for i := 1; i < retry && d < p.MaxInterval; i++ {
+		d *= 2
+	}
+	if d > p.MaxInterval {
+		d = p.MaxInterval
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// RetryDecorator retries operations against the Backend which failed with transient errors
+func RetryDecorator(policy RetryPolicy) Decorator {
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = 100 * time.Millisecond
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = 5 * time.Second
+	}
+
+	return func(next Backend) Backend {
+		return &retryBackend{
+			next:   next,
+			policy: policy,
+			sleep:  sleep,
+			logger: slog.Default().With(slog.String(loglevel.ComponentKey, "storage")),
+		}
+	}
+}
+
+type retryBackend struct {
+	next   Backend
+	policy RetryPolicy
+	sleep  func(ctx context.Context, d time.Duration) error
+	logger *slog.Logger
+}
+
+func (b *retryBackend) do(ctx context.Context, operation, key string, fn func() error) error {
+	begin := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransient(err) {
+			return err
+		} else if attempt >= b.policy.MaxAttempts {
+			return throttled(err)
+		}
+
+		d := b.policy.backoff(attempt)
+		if b.policy.MaxElapsedTime > 0 && time.Since(begin)+d > b.policy.MaxElapsedTime {
+			return throttled(err)
+		}
+
+		b.logger.WarnContext(ctx, "retrying storage operation after transient error",
+			slog.String("operation", operation),
+			slog.String("key", key),
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", d),
+			slog.String("err", err.Error()),
+		)
+		if sleepErr := b.sleep(ctx, d); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+func (b *retryBackend) Exists(ctx context.Context, key string) (exists bool, err error) {
+	err = b.do(ctx, operationExists, key, func() error {
+		exists, err = b.next.Exists(ctx, key)
+		return err
+	})
+	return exists, err
+}
+
+func (b *retryBackend) Download(ctx context.Context, key string) (data []byte, err error) {
+	err = b.do(ctx, operationDownload, key, func() error {
+		data, err = b.next.Download(ctx, key)
+		return err
+	})
+	return data, err
+}
+
+// Open only retries opening the object, failures while reading it are returned to the caller
+func (b *retryBackend) Open(ctx context.Context, key string) (r io.ReadCloser, err error) {
+	err = b.do(ctx, operationOpen, key, func() error {
+		r, err = b.next.Open(ctx, key)
+		return err
+	})
+	return r, err
+}
+
+// Upload is only retried if the reader can be rewound, as the content is consumed by the failed attempt otherwise.
+// Conditional uploads aren't retried either, the retry of an upload whose response was lost would fail as the object already exists.
+func (b *retryBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	seeker, ok := reader.(io.Seeker)
+	if !ok || ifNotExists(ctx) {
+		return b.next.Upload(ctx, key, reader)
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return b.next.Upload(ctx, key, reader)
+	}
+
+	attempt := 0
+	return b.do(ctx, operationUpload, key, func() error {
+		attempt++
+		if attempt > 1 {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		return b.next.Upload(ctx, key, reader)
+	})
+}
+
+func (b *retryBackend) Delete(ctx context.Context, key string) error {
+	return b.do(ctx, operationDelete, key, func() error {
+		return b.next.Delete(ctx, key)
+	})
+}
+
+func (b *retryBackend) List(ctx context.Context, prefix string) (objects []Object, err error) {
+	err = b.do(ctx, operationList, prefix, func() error {
+		objects, err = b.next.List(ctx, prefix)
+		return err
+	})
+	return objects, err
+}
+
+func (b *retryBackend) PresignedURL(ctx context.Context, key string) (url string, err error) {
+	err = b.do(ctx, operationPresignedURL, key, func() error {
+		url, err = b.next.PresignedURL(ctx, key)
+		return err
+	})
+	return url, err
+}
+
+func (b *retryBackend) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return b.next.GetDownloadUrl(ctx, url)
+}
+
+// isTransient reports whether the error is caused by throttling, a server-side error, or a network timeout
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// AWS
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if code := apiErr.ErrorCode(); awsThrottlingErrorCodes[code] || code == "InternalError" || code == "ServiceUnavailable" {
+			return true
+		}
+	}
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return isTransientStatusCode(statusErr.HTTPStatusCode())
+	}
+
+	// GCS
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		return isTransientStatusCode(googleErr.Code)
+	}
+
+	// Azure
+	var azureErr *azcore.ResponseError
+	if errors.As(err, &azureErr) {
+		return isTransientStatusCode(azureErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
+
+// throttled marks errors caused by throttling of the storage backend with core.ErrTooManyRequests,
+// so that clients receive a 429 and retry the request later
+func throttled(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && awsThrottlingErrorCodes[apiErr.ErrorCode()] {
+		return fmt.Errorf("%w: %w", core.ErrTooManyRequests, err)
+	}
+
+	code := 0
+	var statusErr interface{ HTTPStatusCode() int }
+	var googleErr *googleapi.Error
+	var azureErr *azcore.ResponseError
+	if errors.As(err, &statusErr) {
+		code = statusErr.HTTPStatusCode()
+	} else if errors.As(err, &googleErr) {
+		code = googleErr.Code
+	} else if errors.As(err, &azureErr) {
+		code = azureErr.StatusCode
+	}
+	if code == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", core.ErrTooManyRequests, err)
+	}
+
+	return err
+}
+
+func isTransientStatusCode(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || (code >= http.StatusInternalServerError && code != http.StatusNotImplemented)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	assertion "github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+)
+
+// flakyBackend fails the first failures calls of every operation with err
+type flakyBackend struct {
+	*mockBackend
+	failures int
+	err      error
+	attempts int
+	uploaded []string
+}
+
+func (f *flakyBackend) fail() error {
+	f.attempts++
+	if f.attempts <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func (f *flakyBackend) List(ctx context.Context, prefix string) ([]Object, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.mockBackend.List(ctx, prefix)
+}
+
+func (f *flakyBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	f.uploaded = append(f.uploaded, string(b))
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.mockBackend.Upload(ctx, key, bytes.NewReader(b))
+}
+
+func awsStatusError(code int) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{
+				Response: &http.Response{
+					StatusCode: code,
+				},
+			},
+			Err: errors.New("mocked error"),
+		},
+	}
+}
+
+func newTestRetryBackend(next Backend, policy RetryPolicy) *retryBackend {
+	b := RetryDecorator(policy)(next).(*retryBackend)
+	b.sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	return b
+}
+
+func TestRetryDecorator(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		policy       RetryPolicy
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "no error",
+			policy:       RetryPolicy{MaxAttempts: 3},
+			wantAttempts: 1,
+		},
+		{
+			name:         "throttled once",
+			failures:     1,
+			err:          awsStatusError(http.StatusServiceUnavailable),
+			policy:       RetryPolicy{MaxAttempts: 3},
+			wantAttempts: 2,
+		},
+		{
+			name:         "attempts are exhausted",
+			failures:     5,
+			err:          awsStatusError(http.StatusTooManyRequests),
+			policy:       RetryPolicy{MaxAttempts: 3},
+			wantErr:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "permanent error",
+			failures:     1,
+			err:          awsStatusError(http.StatusForbidden),
+			policy:       RetryPolicy{MaxAttempts: 3},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries disabled",
+			failures:     1,
+			err:          awsStatusError(http.StatusInternalServerError),
+			policy:       RetryPolicy{MaxAttempts: 1},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "max elapsed time exceeded",
+			failures:     1,
+			err:          awsStatusError(http.StatusInternalServerError),
+			policy:       RetryPolicy{MaxAttempts: 3, MaxElapsedTime: time.Nanosecond, InitialInterval: time.Second},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flaky := &flakyBackend{mockBackend: newMockBackend(), failures: tt.failures, err: tt.err}
+			b := newTestRetryBackend(flaky, tt.policy)
+
+			_, err := b.List(context.Background(), "providers/")
+			if tt.wantErr {
+				assertion.ErrorIs(t, err, tt.err)
+			} else {
+				assertion.NoError(t, err)
+			}
+			assertion.Equal(t, tt.wantAttempts, flaky.attempts)
+		})
+	}
+}
+
+func TestRetryDecorator_Upload(t *testing.T) {
+	ctx := context.Background()
+
+	// Seekable readers are rewound before every attempt
+	flaky := &flakyBackend{mockBackend: newMockBackend(), failures: 1, err: awsStatusError(http.StatusServiceUnavailable)}
+	b := newTestRetryBackend(flaky, RetryPolicy{MaxAttempts: 3})
+	assertion.NoError(t, b.Upload(ctx, "key", strings.NewReader("content")))
+	assertion.Equal(t, []string{"content", "content"}, flaky.uploaded)
+
+	// Other readers can't be retried
+	flaky = &flakyBackend{mockBackend: newMockBackend(), failures: 1, err: awsStatusError(http.StatusServiceUnavailable)}
+	b = newTestRetryBackend(flaky, RetryPolicy{MaxAttempts: 3})
+	assertion.Error(t, b.Upload(ctx, "key", io.MultiReader(strings.NewReader("content"))))
+	assertion.Equal(t, 1, flaky.attempts)
+
+	// Conditional uploads aren't retried
+	flaky = &flakyBackend{mockBackend: newMockBackend(), failures: 1, err: awsStatusError(http.StatusServiceUnavailable)}
+	b = newTestRetryBackend(flaky, RetryPolicy{MaxAttempts: 3})
+	assertion.Error(t, b.Upload(withIfNotExists(ctx), "key", strings.NewReader("content")))
+	assertion.Equal(t, 1, flaky.attempts)
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second}
+	for retry := 1; retry < 10; retry++ {
+		d := p.backoff(retry)
+		assertion.GreaterOrEqual(t, d, time.Duration(0))
+		assertion.Less(t, d, time.Second)
+	}
+	assertion.Less(t, p.backoff(1), 100*time.Millisecond)
+}
+
+func Test_isTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "aws throttling",
+			err:  fmt.Errorf("failed to list: %w", awsStatusError(http.StatusServiceUnavailable)),
+			want: true,
+		},
+		{
+			name: "aws not found",
+			err:  awsStatusError(http.StatusNotFound),
+			want: false,
+		},
+		{
+			name: "gcs rate limit",
+			err:  &googleapi.Error{Code: http.StatusTooManyRequests},
+			want: true,
+		},
+		{
+			name: "azure server busy",
+			err:  &azcore.ResponseError{StatusCode: http.StatusServiceUnavailable},
+			want: true,
+		},
+		{
+			name: "canceled context",
+			err:  context.Canceled,
+			want: false,
+		},
+		{
+			name: "unknown error",
+			err:  errors.New("unknown"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertion.Equal(t, tt.want, isTransient(tt.err))
+		})
+	}
+}
+
+func Test_throttled(t *testing.T) {
+	assertion.ErrorIs(t, throttled(awsStatusError(http.StatusTooManyRequests)), core.ErrTooManyRequests)
+	assertion.ErrorIs(t, throttled(&googleapi.Error{Code: http.StatusTooManyRequests}), core.ErrTooManyRequests)
+	assertion.ErrorIs(t, throttled(&azcore.ResponseError{StatusCode: http.StatusTooManyRequests}), core.ErrTooManyRequests)
+	assertion.NotErrorIs(t, throttled(awsStatusError(http.StatusServiceUnavailable)), core.ErrTooManyRequests)
+
+	// Exhausted retries of throttled requests are reported as too many requests
+	flaky := &flakyBackend{mockBackend: newMockBackend(), failures: 5, err: awsStatusError(http.StatusTooManyRequests)}
+	_, err := newTestRetryBackend(flaky, RetryPolicy{MaxAttempts: 2}).List(context.Background(), "providers/")
+	assertion.ErrorIs(t, err, core.ErrTooManyRequests)
+	assertion.Equal(t, http.StatusTooManyRequests, core.GenericError(err))
+}
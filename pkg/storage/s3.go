@@ -1,34 +1,33 @@
 package storage
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"path"
-	"path/filepath"
 	"time"
 
-	"github.com/boring-registry/boring-registry/pkg/core"
-	"github.com/boring-registry/boring-registry/pkg/module"
-
 	"github.com/aws/aws-sdk-go-v2/aws"
 	signer "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	s3manager "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 )
 
 // s3ClientAPI is used to mock the AWS APIs
 // See https://aws.github.io/aws-sdk-go-v2/docs/unit-testing/
 type s3ClientAPI interface {
 	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
 	ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, f ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
 	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
 }
 
 // s3UploaderAPI is used to mock the AWS APIs
@@ -49,198 +48,154 @@ type s3PresignClientAPI interface {
 	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*signer.PresignedHTTPRequest, error)
 }
 
-// S3Storage is a Storage implementation backed by S3.
-// S3Storage implements module.Storage, provider.Storage, and mirror.Storage
+// S3Storage is a Backend implementation backed by S3.
+// NewS3Storage wraps it into an ObjectStorage, which implements module.Storage, provider.Storage, and mirror.Storage
 type S3Storage struct {
-	client              s3ClientAPI
-	presignClient       s3PresignClientAPI
-	downloader          s3DownloaderAPI
-	uploader            s3UploaderAPI
-	bucket              string
-	bucketPrefix        string
-	bucketRegion        string
-	bucketEndpoint      string
-	moduleArchiveFormat string
-	forcePathStyle      bool
-	signedURLExpiry     time.Duration
-}
-
-// GetModule retrieves information about a module from the S3 storage.
-func (s *S3Storage) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
-	key := modulePath(s.bucketPrefix, namespace, name, provider, version, s.moduleArchiveFormat)
-
-	exists, err := s.objectExists(ctx, key)
-	if err != nil {
-		return core.Module{}, err
-	} else if !exists {
-		return core.Module{}, module.ErrModuleNotFound
-	}
-
-	presigned, err := s.presignedURL(ctx, key)
+	client                s3ClientAPI
+	presignClient         s3PresignClientAPI
+	downloader            s3DownloaderAPI
+	uploader              s3UploaderAPI
+	bucket                string
+	bucketPrefix          string
+	bucketRegion          string
+	bucketEndpoint        string
+	moduleArchiveFormat   string
+	convertModuleArchives bool
+	forcePathStyle        bool
+	accelerate            bool
+	dualStack             bool
+	signedURLExpiry       time.Duration
+	roleARN               string
+	externalID            string
+	sessionName           string
+	requestPayer          bool
+	conditionalWrites     bool
+	decorators            []Decorator
+	objectOptions         []ObjectStorageOption
+	tags                  map[string]string
+	publisher             string
+	httpClient            *HTTPClientConfig
+}
+
+// PresignedURL returns a presigned URL to download the object from S3
+func (s *S3Storage) PresignedURL(ctx context.Context, key string) (string, error) {
+	presignResult, err := s.presignClient.PresignGetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket:       aws.String(s.bucket),
+			Key:          aws.String(key),
+			RequestPayer: s.payer(),
+		},
+		s3.WithPresignExpires(s.signedURLExpiry),
+	)
 	if err != nil {
-		return core.Module{}, err
+		return "", err
 	}
 
-	return core.Module{
-		Namespace:   namespace,
-		Name:        name,
-		Provider:    provider,
-		Version:     version,
-		DownloadURL: presigned,
-	}, nil
+	return presignResult.URL, nil
 }
 
-func (s *S3Storage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
-	input := &s3.ListObjectsV2Input{
-		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(modulePathPrefix(s.bucketPrefix, namespace, name, provider)),
+// Exists checks if an object with the key exists in the S3 bucket
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	input := &s3.HeadObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		RequestPayer: s.payer(),
 	}
 
-	var modules []core.Module
-	paginator := s3.NewListObjectsV2Paginator(s.client, input)
-	for paginator.HasMorePages() {
-		resp, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("%v: %w", module.ErrModuleListFailed, err)
-		}
-
-		for _, obj := range resp.Contents {
-			m, err := moduleFromObject(*obj.Key, s.moduleArchiveFormat)
-			if err != nil {
-				// TODO: we're skipping possible failures silently
-				continue
-			}
-
-			// The download URL is probably not necessary for ListModules
-			m.DownloadURL, err = s.presignedURL(ctx, modulePath(s.bucketPrefix, m.Namespace, m.Name, m.Provider, m.Version, s.moduleArchiveFormat))
-			if err != nil {
-				return []core.Module{}, err
-			}
-
-			modules = append(modules, *m)
+	if _, err := s.client.HeadObject(ctx, input); err != nil {
+		var responseError *awshttp.ResponseError
+		if errors.As(err, &responseError) && responseError.ResponseError.HTTPStatusCode() == http.StatusNotFound {
+			return false, nil
 		}
+		return false, err
 	}
 
-	return modules, nil
+	return true, nil
 }
 
-// UploadModule uploads a module to the S3 storage.
-func (s *S3Storage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
-	if namespace == "" {
-		return core.Module{}, errors.New("namespace not defined")
-	}
-
-	if name == "" {
-		return core.Module{}, errors.New("name not defined")
+// Upload puts the object into the S3 bucket
+func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader) error {
+	input := &s3.PutObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		Body:         reader,
+		RequestPayer: s.payer(),
+		Tagging:      encodeS3Tags(objectTags(ctx)),
 	}
-
-	if provider == "" {
-		return core.Module{}, errors.New("provider not defined")
+	if ifNotExists(ctx) {
+		// The condition is also applied when completing multipart uploads
+		input.IfNoneMatch = aws.String("*")
 	}
 
-	if version == "" {
-		return core.Module{}, errors.New("version not defined")
+	if _, err := s.uploader.Upload(ctx, input); err != nil {
+		// ConditionalRequestConflict is returned while a concurrent conditional upload of the object is in progress
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "PreconditionFailed" || apiErr.ErrorCode() == "ConditionalRequestConflict") {
+			return errAlreadyExists(key)
+		}
+		return fmt.Errorf("failed to upload: %w", err)
 	}
 
-	key := modulePath(s.bucketPrefix, namespace, name, provider, version, DefaultModuleArchiveFormat)
-
-	if _, err := s.GetModule(ctx, namespace, name, provider, version); err == nil {
-		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
-	}
+	return nil
+}
 
-	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
-		Body:   body,
+// Delete removes the object from the S3 bucket, S3 doesn't fail for missing objects
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		RequestPayer: s.payer(),
 	}
 
-	if _, err := s.uploader.Upload(ctx, input); err != nil {
-		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+	if _, err := s.client.DeleteObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
 	}
 
-	return s.GetModule(ctx, namespace, name, provider, version)
+	return nil
 }
 
-// GetProvider retrieves information about a provider from the S3 storage.
-func (s *S3Storage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
-	var archivePath, shasumPath, shasumSigPath string
-	if pt == internalProviderType {
-		archivePath, shasumPath, shasumSigPath = internalProviderPath(s.bucketPrefix, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
-	} else if pt == mirrorProviderType {
-		archivePath, shasumPath, shasumSigPath = mirrorProviderPath(s.bucketPrefix, provider.Hostname, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
-	}
+// Download retrieves the object from the S3 bucket
+func (s *S3Storage) Download(ctx context.Context, key string) ([]byte, error) {
+	buf := s3manager.NewWriteAtBuffer([]byte{})
 
-	if exists, err := s.objectExists(ctx, archivePath); err != nil {
-		return nil, err
-	} else if !exists {
-		return nil, noMatchingProviderFound(provider)
+	input := &s3.GetObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		RequestPayer: s.payer(),
 	}
 
-	var err error
-	provider.DownloadURL, err = s.presignedURL(ctx, archivePath)
-	if err != nil {
-		return nil, err
-	}
-	provider.SHASumsURL, err = s.presignedURL(ctx, shasumPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate presigned url for %s: %w", shasumPath, err)
-	}
-	provider.SHASumsSignatureURL, err = s.presignedURL(ctx, shasumSigPath)
-	if err != nil {
-		return nil, err
+	if _, err := s.downloader.Download(ctx, buf, input); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
 	}
 
-	shasumBytes, err := s.download(ctx, shasumPath)
-	if err != nil {
-		return nil, err
-	}
+	return buf.Bytes(), nil
+}
 
-	provider.Shasum, err = readSHASums(bytes.NewReader(shasumBytes), path.Base(archivePath))
-	if err != nil {
-		return nil, err
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		RequestPayer: s.payer(),
 	}
 
-	var signingKeys *core.SigningKeys
-	if pt == internalProviderType {
-		signingKeys, err = s.SigningKeys(ctx, provider.Namespace)
-	} else if pt == mirrorProviderType {
-		signingKeys, err = s.MirroredSigningKeys(ctx, provider.Hostname, provider.Namespace)
-	}
+	output, err := s.client.GetObject(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
 	}
 
-	provider.Filename = path.Base(archivePath)
-	provider.SigningKeys = *signingKeys
-	return provider, nil
-}
-
-func (s *S3Storage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
-	p, err := s.getProvider(ctx, internalProviderType, &core.Provider{
-		Namespace: namespace,
-		Name:      name,
-		Version:   version,
-		OS:        os,
-		Arch:      arch,
-	})
-
-	return p, err
-}
-
-func (s *S3Storage) GetMirroredProvider(ctx context.Context, provider *core.Provider) (*core.Provider, error) {
-	return s.getProvider(ctx, mirrorProviderType, provider)
+	return output.Body, nil
 }
 
-func (s *S3Storage) listProviderVersions(ctx context.Context, pt providerType, provider *core.Provider) ([]*core.Provider, error) {
-	prefix := providerStoragePrefix(s.bucketPrefix, pt, provider.Hostname, provider.Namespace, provider.Name)
+// List returns all objects in the S3 bucket with the given prefix
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]Object, error) {
 	input := &s3.ListObjectsV2Input{
-		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(fmt.Sprintf("%s/", prefix)),
+		Bucket:       aws.String(s.bucket),
+		Prefix:       aws.String(prefix),
+		RequestPayer: s.payer(),
 	}
 
+	var objects []Object
 	paginator := s3.NewListObjectsV2Paginator(s.client, input)
-
-	var providers []*core.Provider
 	for paginator.HasMorePages() {
 		resp, err := paginator.NextPage(ctx)
 		if err != nil {
@@ -248,244 +203,178 @@ func (s *S3Storage) listProviderVersions(ctx context.Context, pt providerType, p
 		}
 
 		for _, obj := range resp.Contents {
-			p, err := core.NewProviderFromArchive(filepath.Base(*obj.Key))
-			if err != nil {
-				continue
-			}
-
-			if provider.Version != "" && provider.Version != p.Version {
-				// The provider version doesn't match the requested version
-				continue
-			}
-
-			p.Hostname = provider.Hostname
-			p.Namespace = provider.Namespace
-			archiveUrl, err := s.presignedURL(ctx, *obj.Key)
-			if err != nil {
-				return nil, err
-			}
-			p.DownloadURL = archiveUrl
-
-			providers = append(providers, &p)
+			objects = append(objects, Object{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
 		}
 	}
 
-	if len(providers) == 0 {
-		return nil, noMatchingProviderFound(provider)
-	}
-
-	return providers, nil
+	return objects, nil
 }
 
-func (s *S3Storage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
-	providers, err := s.listProviderVersions(ctx, internalProviderType, &core.Provider{Namespace: namespace, Name: name})
-	if err != nil {
-		return nil, err
-	}
-
-	collection := NewCollection()
-	for _, p := range providers {
-		collection.Add(p)
-	}
-	return collection.List(), nil
-}
-
-func (s *S3Storage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
-	return s.listProviderVersions(ctx, mirrorProviderType, provider)
+func (s *S3Storage) GetDownloadUrl(ctx context.Context, url string) (string, error) {
+	return fmt.Sprintf("%s/%s", s.bucketEndpoint, url), nil
 }
 
-func (s *S3Storage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
-	if namespace == "" {
-		return fmt.Errorf("namespace argument is empty")
-	}
-
-	if name == "" {
-		return fmt.Errorf("name argument is empty")
-	}
+// S3StorageOption provides additional options for the S3Storage.
+type S3StorageOption func(*S3Storage)
 
-	if filename == "" {
-		return fmt.Errorf("filename argument is empty")
+// WithS3StorageBucketPrefix configures the s3 storage to work under a given prefix.
+func WithS3StorageBucketPrefix(prefix string) S3StorageOption {
+	return func(s *S3Storage) {
+		s.bucketPrefix = prefix
 	}
-
-	prefix := providerStoragePrefix(s.bucketPrefix, internalProviderType, "", namespace, name)
-	key := filepath.Join(prefix, filename)
-	return s.upload(ctx, key, file, false)
 }
 
-func (s *S3Storage) signingKeys(ctx context.Context, pt providerType, hostname, namespace string) (*core.SigningKeys, error) {
-	if namespace == "" {
-		return nil, fmt.Errorf("namespace argument is empty")
-	}
-	key := signingKeysPath(s.bucketPrefix, pt, hostname, namespace)
-	exists, err := s.objectExists(ctx, key)
-	if err != nil {
-		return nil, err
-	} else if !exists {
-		return nil, core.ErrObjectNotFound
-	}
-
-	signingKeysRaw, err := s.download(ctx, key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download signing_keys.json for namespace %s: %w", namespace, err)
+// WithS3StorageBucketRegion configures the region for a given s3 storage.
+// TODO: the AWS signing region could be another one as the bucket location
+func WithS3StorageBucketRegion(region string) S3StorageOption {
+	return func(s *S3Storage) {
+		s.bucketRegion = region
 	}
-
-	return unmarshalSigningKeys(signingKeysRaw)
 }
 
-// SigningKeys downloads the JSON placed in the namespace in S3 and unmarshals it into a core.SigningKeys
-func (s *S3Storage) SigningKeys(ctx context.Context, namespace string) (*core.SigningKeys, error) {
-	return s.signingKeys(ctx, internalProviderType, "", namespace)
-}
-
-func (s *S3Storage) MirroredSigningKeys(ctx context.Context, hostname, namespace string) (*core.SigningKeys, error) {
-	return s.signingKeys(ctx, mirrorProviderType, hostname, namespace)
-}
-
-func (s *S3Storage) uploadSigningKeys(ctx context.Context, pt providerType, hostname, namespace string, signingKeys *core.SigningKeys) error {
-	b, err := json.Marshal(signingKeys)
-	if err != nil {
-		return err
+// WithS3StorageBucketEndpoint configures the endpoint for a given s3 storage. (needed for MINIO)
+func WithS3StorageBucketEndpoint(endpoint string) S3StorageOption {
+	return func(s *S3Storage) {
+		s.bucketEndpoint = endpoint
 	}
-	key := signingKeysPath(s.bucketPrefix, pt, hostname, namespace)
-	return s.upload(ctx, key, bytes.NewReader(b), true)
 }
 
-func (s *S3Storage) UploadMirroredSigningKeys(ctx context.Context, hostname, namespace string, signingKeys *core.SigningKeys) error {
-	return s.uploadSigningKeys(ctx, mirrorProviderType, hostname, namespace, signingKeys)
-}
-
-func (s *S3Storage) MirroredSha256Sum(ctx context.Context, provider *core.Provider) (*core.Sha256Sums, error) {
-	prefix := providerStoragePrefix(s.bucketPrefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
-	key := filepath.Join(prefix, provider.ShasumFileName())
-	shaSumBytes, err := s.download(ctx, key)
-	if err != nil {
-		return nil, errors.New("failed to download SHA256SUMS")
+// WithS3ArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
+func WithS3ArchiveFormat(archiveFormat string) S3StorageOption {
+	return func(s *S3Storage) {
+		s.moduleArchiveFormat = archiveFormat
 	}
-
-	return core.NewSha256Sums(provider.ShasumFileName(), bytes.NewReader(shaSumBytes))
 }
 
-func (s *S3Storage) UploadMirroredFile(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
-	prefix := providerStoragePrefix(s.bucketPrefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
-	key := filepath.Join(prefix, fileName)
-	return s.upload(ctx, key, reader, true)
-}
-
-func (s *S3Storage) presignedURL(ctx context.Context, key string) (string, error) {
-	presignResult, err := s.presignClient.PresignGetObject(ctx,
-		&s3.GetObjectInput{
-			Bucket: aws.String(s.bucket),
-			Key:    aws.String(key),
-		},
-		s3.WithPresignExpires(s.signedURLExpiry),
-	)
-
-	return presignResult.URL, err
-}
-
-func (s *S3Storage) objectExists(ctx context.Context, key string) (bool, error) {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+// WithS3ArchiveConversion stores all uploaded module archives in the configured format, archives in another format are repackaged
+func WithS3ArchiveConversion(enabled bool) S3StorageOption {
+	return func(s *S3Storage) {
+		s.convertModuleArchives = enabled
 	}
+}
 
-	if _, err := s.client.HeadObject(ctx, input); err != nil {
-		var responseError *awshttp.ResponseError
-		if errors.As(err, &responseError) && responseError.ResponseError.HTTPStatusCode() == http.StatusNotFound {
-			return false, nil
-		}
-		return false, err
+// WithS3StoragePathStyle configures if Path Style is used for a given s3 storage. (needed for MINIO)
+func WithS3StoragePathStyle(forcePathStyle bool) S3StorageOption {
+	return func(s *S3Storage) {
+		s.forcePathStyle = forcePathStyle
 	}
-
-	return true, nil
 }
 
-func (s *S3Storage) upload(ctx context.Context, key string, reader io.Reader, overwrite bool) error {
-	// If we don't want to overwrite, check if the object exists
-	if !overwrite {
-		exists, err := s.objectExists(ctx, key)
-		if err != nil {
-			return err
-		} else if exists {
-			return fmt.Errorf("failed to upload key %s: %w", key, core.ErrObjectAlreadyExists)
-		}
+// WithS3StorageAccelerate configures if S3 Transfer Acceleration is used, which needs to be enabled on the bucket
+func WithS3StorageAccelerate(accelerate bool) S3StorageOption {
+	return func(s *S3Storage) {
+		s.accelerate = accelerate
 	}
+}
 
-	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
-		Body:   reader,
+// WithS3StorageDualStack configures if the dual-stack endpoints supporting IPv6 are used
+func WithS3StorageDualStack(dualStack bool) S3StorageOption {
+	return func(s *S3Storage) {
+		s.dualStack = dualStack
 	}
+}
 
-	if _, err := s.uploader.Upload(ctx, input); err != nil {
-		return fmt.Errorf("failed to upload: %w", err)
+// WithS3StorageSignedUrlExpiry configures the duration until the signed url expires
+func WithS3StorageSignedUrlExpiry(t time.Duration) S3StorageOption {
+	return func(s *S3Storage) {
+		s.signedURLExpiry = t
 	}
-
-	return nil
 }
 
-func (s *S3Storage) download(ctx context.Context, key string) ([]byte, error) {
-	buf := s3manager.NewWriteAtBuffer([]byte{})
-
-	input := &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+// WithS3StorageAssumeRole configures the s3 storage to assume the IAM role, e.g. to access a bucket in another account.
+// The externalID and sessionName are optional.
+func WithS3StorageAssumeRole(roleARN, externalID, sessionName string) S3StorageOption {
+	return func(s *S3Storage) {
+		s.roleARN = roleARN
+		s.externalID = externalID
+		s.sessionName = sessionName
 	}
+}
 
-	if _, err := s.downloader.Download(ctx, buf, input); err != nil {
-		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+// WithS3StorageConditionalWrites rejects uploads of existing objects atomically with the If-None-Match header,
+// which S3-compatible storages may not support.
+func WithS3StorageConditionalWrites(enabled bool) S3StorageOption {
+	return func(s *S3Storage) {
+		s.conditionalWrites = enabled
 	}
-
-	return buf.Bytes(), nil
 }
 
-func (s *S3Storage) GetDownloadUrl(ctx context.Context, url string) (string, error) {
-	return fmt.Sprintf("%s/%s", s.bucketEndpoint, url), nil
+// WithS3StorageRequestPayer configures the s3 storage to pay for the requests to a requester-pays bucket.
+// This applies to presigned URLs as well, so the registry is charged for downloads of its clients.
+func WithS3StorageRequestPayer(requestPayer bool) S3StorageOption {
+	return func(s *S3Storage) {
+		s.requestPayer = requestPayer
+	}
 }
 
-// S3StorageOption provides additional options for the S3Storage.
-type S3StorageOption func(*S3Storage)
-
-// WithS3StorageBucketPrefix configures the s3 storage to work under a given prefix.
-func WithS3StorageBucketPrefix(prefix string) S3StorageOption {
+// WithS3StorageHTTPClient configures the connection pool of the HTTP client shared by all requests to S3
+func WithS3StorageHTTPClient(config HTTPClientConfig) S3StorageOption {
 	return func(s *S3Storage) {
-		s.bucketPrefix = prefix
+		s.httpClient = &config
 	}
 }
 
-// WithS3StorageBucketRegion configures the region for a given s3 storage.
-// TODO: the AWS signing region could be another one as the bucket location
-func WithS3StorageBucketRegion(region string) S3StorageOption {
+// WithS3StorageTags tags uploaded objects with the static tags, the publisher, and the artifact they belong to.
+// Tagging is disabled if tags is nil.
+func WithS3StorageTags(tags map[string]string, publisher string) S3StorageOption {
 	return func(s *S3Storage) {
-		s.bucketRegion = region
+		s.tags = tags
+		s.publisher = publisher
 	}
 }
 
-// WithS3StorageBucketEndpoint configures the endpoint for a given s3 storage. (needed for MINIO)
-func WithS3StorageBucketEndpoint(endpoint string) S3StorageOption {
+// WithS3StorageDecorators wraps the S3 backend with the given decorators.
+func WithS3StorageDecorators(decorators ...Decorator) S3StorageOption {
 	return func(s *S3Storage) {
-		s.bucketEndpoint = endpoint
+		s.decorators = append(s.decorators, decorators...)
 	}
 }
 
-// WithS3ArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
-func WithS3ArchiveFormat(archiveFormat string) S3StorageOption {
+// WithS3StorageObjectOptions applies the options to the ObjectStorage of the S3 storage, e.g. quotas or the admission controller
+func WithS3StorageObjectOptions(options ...ObjectStorageOption) S3StorageOption {
 	return func(s *S3Storage) {
-		s.moduleArchiveFormat = archiveFormat
+		s.objectOptions = append(s.objectOptions, options...)
 	}
 }
 
-// WithS3StoragePathStyle configures if Path Style is used for a given s3 storage. (needed for MINIO)
-func WithS3StoragePathStyle(forcePathStyle bool) S3StorageOption {
-	return func(s *S3Storage) {
-		s.forcePathStyle = forcePathStyle
+// assumeRoleCredentials returns credentials of the assumed IAM role, which are refreshed automatically before they expire
+func (s *S3Storage) assumeRoleCredentials(cfg aws.Config) aws.CredentialsProvider {
+	client := sts.NewFromConfig(cfg, func(o *sts.Options) {
+		// STS is available in all regions, the bucket region might not be known yet
+		if o.Region == "" {
+			o.Region = "us-east-1"
+		}
+	})
+
+	provider := stscreds.NewAssumeRoleProvider(client, s.roleARN, func(o *stscreds.AssumeRoleOptions) {
+		if s.externalID != "" {
+			o.ExternalID = aws.String(s.externalID)
+		}
+		if s.sessionName != "" {
+			o.RoleSessionName = s.sessionName
+		}
+	})
+
+	return aws.NewCredentialsCache(provider)
+}
+
+// payer returns the RequestPayer of all operations, which is only set for requester-pays buckets
+func (s *S3Storage) payer() types.RequestPayer {
+	if s.requestPayer {
+		return types.RequestPayerRequester
 	}
+	return ""
 }
 
-// WithS3StorageSignedUrlExpiry configures the duration until the signed url expires
-func WithS3StorageSignedUrlExpiry(t time.Duration) S3StorageOption {
-	return func(s *S3Storage) {
-		s.signedURLExpiry = t
+// clientOptions applies the endpoint options to the S3 client
+func (s *S3Storage) clientOptions(o *s3.Options) {
+	o.UseAccelerate = s.accelerate
+	if s.dualStack {
+		o.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
 	}
 }
 
@@ -500,6 +389,13 @@ func NewS3Storage(ctx context.Context, bucket string, options ...S3StorageOption
 		option(s)
 	}
 
+	if s.accelerate && (s.bucketEndpoint != "" || s.forcePathStyle) {
+		return nil, errors.New("S3 Transfer Acceleration can't be used with a custom endpoint or path-style addressing")
+	}
+	if err := validateS3Tags(s.tags); err != nil {
+		return nil, err
+	}
+
 	// The EndpointResolver is used for compatibility with MinIO
 	customResolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
 		if s.bucketEndpoint != "" {
@@ -515,24 +411,48 @@ func NewS3Storage(ctx context.Context, bucket string, options ...S3StorageOption
 	})
 
 	// Create the S3 client
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(s.bucketRegion), config.WithEndpointResolverWithOptions(customResolver))
+	loadOptions := []func(*config.LoadOptions) error{
+		config.WithRegion(s.bucketRegion),
+		config.WithEndpointResolverWithOptions(customResolver),
+	}
+	if s.httpClient != nil {
+		// The client is shared by the S3 and STS clients, so that all requests reuse the same connections
+		loadOptions = append(loadOptions, config.WithHTTPClient(awshttp.NewBuildableClient().WithTransportOptions(s.httpClient.configureTransport)))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
 	if err != nil {
 		return nil, err
 	}
 
-	client := s3.NewFromConfig(cfg)
+	if s.roleARN != "" {
+		cfg.Credentials = s.assumeRoleCredentials(cfg)
+	}
+
+	// The presign client inherits the options, so that presigned URLs use the same endpoints
+	client := s3.NewFromConfig(cfg, s.clientOptions)
 	s.client = client
 	s.presignClient = s3.NewPresignClient(client)
 	s.uploader = s3manager.NewUploader(client)
 	s.downloader = s3manager.NewDownloader(client)
 
 	if s.bucketRegion == "" {
-		region, err := s3manager.GetBucketRegion(ctx, client, s.bucket)
+		// The accelerate endpoint is global, so the region is looked up with the regular endpoint
+		region, err := s3manager.GetBucketRegion(ctx, client, s.bucket, func(o *s3.Options) {
+			o.UseAccelerate = false
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to determine bucket region: %w", err)
 		}
 		s.bucketRegion = region
 	}
 
-	return s, nil
+	objectOptions := []ObjectStorageOption{
+		WithObjectStoragePrefix(s.bucketPrefix),
+		WithObjectStorageArchiveFormat(s.moduleArchiveFormat),
+		WithObjectStorageArchiveConversion(s.convertModuleArchives),
+		WithObjectStorageDecorators(s.decorators...),
+		WithObjectStorageConditionalWrites(s.conditionalWrites),
+		WithObjectStorageTags(s.tags, s.publisher),
+	}
+	return NewObjectStorage(s, append(objectOptions, s.objectOptions...)...), nil
 }
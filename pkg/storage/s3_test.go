@@ -8,16 +8,23 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	signer "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	s3manager "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	smithyhttp "github.com/aws/smithy-go/transport/http"
 	assertion "github.com/stretchr/testify/assert"
 )
@@ -30,6 +37,10 @@ func (m *mockS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInpu
 	return m.headObject(ctx, params, optFns...)
 }
 
+func (m *mockS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	panic("not yet implemented, as we don't have tests using it")
+}
+
 func (m *mockS3Client) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, f ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
 	panic("not yet implemented, as we don't have tests using it")
 }
@@ -38,12 +49,18 @@ func (m *mockS3Client) CopyObject(ctx context.Context, params *s3.CopyObjectInpu
 	panic("not yet implemented, as we don't have tests using it")
 }
 
+func (m *mockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	panic("not yet implemented, as we don't have tests using it")
+}
+
 type mockS3Uploader struct {
-	b   *bytes.Buffer
-	err error
+	b     *bytes.Buffer
+	input *s3.PutObjectInput
+	err   error
 }
 
 func (m *mockS3Uploader) Upload(ctx context.Context, input *s3.PutObjectInput, opts ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+	m.input = input
 	m.b = new(bytes.Buffer)
 	if _, err := io.Copy(m.b, input.Body); err != nil {
 		return nil, err
@@ -150,11 +167,10 @@ func TestS3Storage_UploadProviderReleaseFiles(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
 			u := &mockS3Uploader{}
-			s := S3Storage{
+			s := NewObjectStorage(&S3Storage{
 				client:   tc.client,
 				uploader: u,
-			}
-			s.uploader = u
+			})
 			err := s.UploadProviderReleaseFiles(context.Background(), tc.namespace, tc.name, tc.filename, strings.NewReader(tc.content))
 			if tc.wantErr(t, err) {
 				return
@@ -249,14 +265,14 @@ func TestSigningKeys(t *testing.T) {
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.annotation, func(t *testing.T) {
-			s := S3Storage{
+			s := NewObjectStorage(&S3Storage{
 				downloader: &mockS3Downloader{data: tc.data, error: tc.returnError},
 				client: &mockS3Client{
 					headObject: headExistingObject,
 				},
-			}
+			})
 
-			result, err := s.SigningKeys(context.Background(), tc.namespace)
+			result, err := s.SigningKeys(context.Background(), tc.namespace, "")
 
 			if !tc.expectedError {
 				assertion.NoError(t, err)
@@ -310,12 +326,19 @@ func TestS3Storage_getProvider(t *testing.T) {
 			name: "internal provider exists",
 			fields: fields{
 				client: &mockS3Client{
-					headObject: headExistingObject,
+					headObject: func(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+						// The provider version isn't deleted and the provider has no signing keys of its own
+						if strings.HasSuffix(*params.Key, tombstoneSuffix) || *params.Key == "providers/example/dummy/signing-keys.json" {
+							return headNonExistingObject(ctx, params, optFns...)
+						}
+						return headExistingObject(ctx, params, optFns...)
+					},
 				},
 				downloader: &mockS3Downloader{
 					data: map[string][]byte{
-						"providers/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS": []byte("10488a12525ed674359585f83e3ee5e74818b5c98e033798351678b21b2f7d89  terraform-provider-dummy_1.0.0_linux_amd64.zip"),
-						"providers/example/signing-keys.json":                               []byte(`{"gpg_public_keys":[{"key_id":"47422B4AA9FA381B","ascii_armor":"test"}]}`),
+						"providers/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS":    []byte("10488a12525ed674359585f83e3ee5e74818b5c98e033798351678b21b2f7d89  terraform-provider-dummy_1.0.0_linux_amd64.zip"),
+						"providers/example/dummy/terraform-provider-dummy_1.0.0_manifest.json": []byte(`{"version":1,"metadata":{"protocol_versions":["6.0"]}}`),
+						"providers/example/signing-keys.json":                                  []byte(`{"gpg_public_keys":[{"key_id":"47422B4AA9FA381B","ascii_armor":"test"}]}`),
 					},
 				},
 			},
@@ -340,6 +363,8 @@ func TestS3Storage_getProvider(t *testing.T) {
 				Shasum:              "10488a12525ed674359585f83e3ee5e74818b5c98e033798351678b21b2f7d89",
 				SHASumsURL:          "providers/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS?presigned=true",
 				SHASumsSignatureURL: "providers/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS.sig?presigned=true",
+				AttestationURL:      "providers/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS.sigstore.json?presigned=true",
+				Protocols:           []string{"6.0"},
 				SigningKeys: core.SigningKeys{
 					GPGPublicKeys: []core.GPGPublicKey{
 						{
@@ -386,6 +411,7 @@ func TestS3Storage_getProvider(t *testing.T) {
 				Shasum:              "10488a12525ed674359585f83e3ee5e74818b5c98e033798351678b21b2f7d89",
 				SHASumsURL:          "mirror/providers/terraform.example.com/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS?presigned=true",
 				SHASumsSignatureURL: "mirror/providers/terraform.example.com/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS.sig?presigned=true",
+				AttestationURL:      "mirror/providers/terraform.example.com/example/dummy/terraform-provider-dummy_1.0.0_SHA256SUMS.sigstore.json?presigned=true",
 				SigningKeys: core.SigningKeys{
 					GPGPublicKeys: []core.GPGPublicKey{
 						{
@@ -399,11 +425,11 @@ func TestS3Storage_getProvider(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &S3Storage{
+			s := NewObjectStorage(&S3Storage{
 				client:        tt.fields.client,
 				presignClient: &mockS3PresignClient{},
 				downloader:    tt.fields.downloader,
-			}
+			})
 			got, err := s.getProvider(context.Background(), tt.args.pt, tt.args.provider)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("S3Storage.getProvider() error = %v, wantErr %v", err, tt.wantErr)
@@ -415,3 +441,143 @@ func TestS3Storage_getProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestS3Storage_assumeRoleCredentials(t *testing.T) {
+	var requests int
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assertion.NoError(t, r.ParseForm())
+		assertion.Equal(t, "AssumeRole", r.Form.Get("Action"))
+		assertion.Equal(t, "arn:aws:iam::123456789012:role/boring-registry", r.Form.Get("RoleArn"))
+		assertion.Equal(t, "external", r.Form.Get("ExternalId"))
+		assertion.Equal(t, "boring-registry", r.Form.Get("RoleSessionName"))
+
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASSUMED</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`))
+	}))
+	defer sts.Close()
+
+	s := &S3Storage{}
+	WithS3StorageAssumeRole("arn:aws:iam::123456789012:role/boring-registry", "external", "boring-registry")(s)
+
+	provider := s.assumeRoleCredentials(aws.Config{
+		Region:       "eu-central-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("SOURCE", "secret", ""),
+		BaseEndpoint: aws.String(sts.URL),
+	})
+
+	creds, err := provider.Retrieve(context.Background())
+	assertion.NoError(t, err)
+	assertion.Equal(t, "ASSUMED", creds.AccessKeyID)
+	assertion.True(t, creds.CanExpire)
+
+	// The credentials are cached until they are about to expire
+	_, err = provider.Retrieve(context.Background())
+	assertion.NoError(t, err)
+	assertion.Equal(t, 1, requests)
+}
+
+func TestS3Storage_PresignedURL_Endpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []S3StorageOption
+		wantHost string
+	}{
+		{
+			name:     "default",
+			wantHost: "boring-registry.s3.eu-central-1.amazonaws.com",
+		},
+		{
+			name:     "transfer acceleration",
+			options:  []S3StorageOption{WithS3StorageAccelerate(true)},
+			wantHost: "boring-registry.s3-accelerate.amazonaws.com",
+		},
+		{
+			name:     "dual-stack",
+			options:  []S3StorageOption{WithS3StorageDualStack(true)},
+			wantHost: "boring-registry.s3.dualstack.eu-central-1.amazonaws.com",
+		},
+		{
+			name:     "transfer acceleration and dual-stack",
+			options:  []S3StorageOption{WithS3StorageAccelerate(true), WithS3StorageDualStack(true)},
+			wantHost: "boring-registry.s3-accelerate.dualstack.amazonaws.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &S3Storage{bucket: "boring-registry", signedURLExpiry: time.Minute}
+			for _, option := range tt.options {
+				option(s)
+			}
+			client := s3.New(s3.Options{
+				Region:      "eu-central-1",
+				Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+			}, s.clientOptions)
+			s.presignClient = s3.NewPresignClient(client)
+
+			presigned, err := s.PresignedURL(context.Background(), "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz")
+			assertion.NoError(t, err)
+
+			u, err := url.Parse(presigned)
+			assertion.NoError(t, err)
+			assertion.Equal(t, tt.wantHost, u.Host)
+		})
+	}
+}
+
+func TestS3Storage_RequestPayer(t *testing.T) {
+	var headInput *s3.HeadObjectInput
+	s := &S3Storage{
+		bucket:          "boring-registry",
+		signedURLExpiry: time.Minute,
+		client: &mockS3Client{headObject: func(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+			headInput = params
+			return &s3.HeadObjectOutput{}, nil
+		}},
+	}
+	WithS3StorageRequestPayer(true)(s)
+	client := s3.New(s3.Options{
+		Region:      "eu-central-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+	})
+	s.presignClient = s3.NewPresignClient(client)
+
+	_, err := s.Exists(context.Background(), "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz")
+	assertion.NoError(t, err)
+	assertion.Equal(t, types.RequestPayerRequester, headInput.RequestPayer)
+
+	presigned, err := s.PresignedURL(context.Background(), "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz")
+	assertion.NoError(t, err)
+	u, err := url.Parse(presigned)
+	assertion.NoError(t, err)
+	assertion.Equal(t, "requester", u.Query().Get("x-amz-request-payer"))
+}
+
+func TestS3Storage_ConditionalUpload(t *testing.T) {
+	assert := assertion.New(t)
+	u := &mockS3Uploader{}
+	s := &S3Storage{bucket: "boring-registry", uploader: u}
+	key := "providers/hashicorp/dns/terraform-provider-dns_1.0.0_SHA256SUMS"
+
+	assert.NoError(s.Upload(context.Background(), key, strings.NewReader("sums")))
+	assert.Nil(u.input.IfNoneMatch)
+
+	assert.NoError(s.Upload(withIfNotExists(context.Background()), key, strings.NewReader("sums")))
+	assert.Equal("*", aws.ToString(u.input.IfNoneMatch))
+
+	for _, code := range []string{"PreconditionFailed", "ConditionalRequestConflict"} {
+		u.err = &smithy.GenericAPIError{Code: code}
+		assert.ErrorIs(s.Upload(withIfNotExists(context.Background()), key, strings.NewReader("sums")), core.ErrObjectAlreadyExists)
+	}
+	u.err = &smithy.GenericAPIError{Code: "AccessDenied"}
+	assert.NotErrorIs(s.Upload(withIfNotExists(context.Background()), key, strings.NewReader("sums")), core.ErrObjectAlreadyExists)
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
+)
+
+// UploadModuleSBOM stores the SPDX or CycloneDX SBOM next to the module archive, an existing SBOM is replaced
+func (s *ObjectStorage) UploadModuleSBOM(ctx context.Context, namespace, name, provider, version string, document io.Reader) error {
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	data, err := readSBOM(document)
+	if err != nil {
+		return err
+	}
+
+	ctx = s.tagged(ctx, namespace, name, version)
+	if err := s.upload(ctx, sbomPath(key), bytes.NewReader(data), true); err != nil {
+		return fmt.Errorf("%v: failed to upload SBOM: %w", module.ErrModuleUploadFailed, err)
+	}
+	return nil
+}
+
+// ModuleSBOM returns the SBOM of the module version
+func (s *ObjectStorage) ModuleSBOM(ctx context.Context, namespace, name, provider, version string) ([]byte, error) {
+	// Deleted versions have no SBOM until they are purged
+	if _, err := s.GetModule(ctx, namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+	key, err := s.moduleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.downloadSBOM(ctx, sbomPath(key))
+}
+
+// UploadProviderSBOM stores the SPDX or CycloneDX SBOM next to the SHA256SUMS file of the provider version, an existing SBOM is replaced
+func (s *ObjectStorage) UploadProviderSBOM(ctx context.Context, namespace, name, version string, document io.Reader) error {
+	shasumPath, err := s.providerShasumPath(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	data, err := readSBOM(document)
+	if err != nil {
+		return err
+	}
+
+	ctx = s.tagged(ctx, namespace, name, version)
+	if err := s.upload(ctx, sbomPath(shasumPath), bytes.NewReader(data), true); err != nil {
+		return fmt.Errorf("failed to upload SBOM of provider %s/%s %s: %w", namespace, name, version, err)
+	}
+	return nil
+}
+
+// ProviderSBOM returns the SBOM of the provider version
+func (s *ObjectStorage) ProviderSBOM(ctx context.Context, namespace, name, version string) ([]byte, error) {
+	shasumPath, err := s.providerShasumPath(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.downloadSBOM(ctx, sbomPath(shasumPath))
+}
+
+// providerShasumPath returns the path of the SHA256SUMS file of the provider version, if the version exists and isn't deleted
+func (s *ObjectStorage) providerShasumPath(ctx context.Context, namespace, name, version string) (string, error) {
+	p := &core.Provider{Namespace: namespace, Name: name, Version: version}
+	shasumPath := path.Join(providerStoragePrefix(s.prefix, internalProviderType, "", namespace, name), p.ShasumFileName())
+
+	if exists, err := s.backend.Exists(ctx, shasumPath); err != nil {
+		return "", err
+	} else if !exists {
+		return "", noMatchingProviderFound(p)
+	}
+	if deleted, err := s.backend.Exists(ctx, providerTombstonePath(s.prefix, namespace, name, version)); err != nil {
+		return "", err
+	} else if deleted {
+		return "", noMatchingProviderFound(p)
+	}
+	return shasumPath, nil
+}
+
+func (s *ObjectStorage) downloadSBOM(ctx context.Context, key string) ([]byte, error) {
+	if exists, err := s.backend.Exists(ctx, key); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, fmt.Errorf("%w: no SBOM is attached at %s", core.ErrObjectNotFound, key)
+	}
+	return s.backend.Download(ctx, key)
+}
+
+// readSBOM reads the document and rejects it if it's not an SBOM
+func readSBOM(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+	if _, err := sbom.Detect(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/sbom"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+const (
+	testSPDX      = `{"spdxVersion": "SPDX-2.3", "name": "vpc"}`
+	testCycloneDX = `{"bomFormat": "CycloneDX", "specVersion": "1.5"}`
+)
+
+func TestObjectStorage_ModuleSBOM(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	// SBOMs can only be attached to existing versions
+	err := s.UploadModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader(testSPDX))
+	assert.Error(err)
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("module"))
+	assert.NoError(err)
+	_, err = s.ModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+
+	assert.ErrorIs(s.UploadModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader(`{"name": "vpc"}`)), sbom.ErrInvalidSBOM)
+	assert.NoError(s.UploadModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader(testSPDX)))
+	document, err := s.ModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal(testSPDX, string(document))
+
+	// Attached SBOMs are replaced
+	assert.NoError(s.UploadModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader(testCycloneDX)))
+	document, err = s.ModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.Equal(testCycloneDX, string(document))
+
+	// The SBOM of a replaced module version doesn't describe the new archive
+	_, err = s.ReplaceModule(ctx, "acme", "vpc", "aws", "1.0.0", strings.NewReader("replaced"))
+	assert.NoError(err)
+	_, err = s.ModuleSBOM(ctx, "acme", "vpc", "aws", "1.0.0")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+}
+
+func TestObjectStorage_ProviderSBOM(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	// SBOMs can only be attached to existing versions
+	err := s.UploadProviderSBOM(ctx, "acme", "random", "2.0.0", strings.NewReader(testSPDX))
+	assert.Error(err)
+
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS", strings.NewReader("")))
+	_, err = s.ProviderSBOM(ctx, "acme", "random", "2.0.0")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+
+	assert.ErrorIs(s.UploadProviderSBOM(ctx, "acme", "random", "2.0.0", strings.NewReader("not json")), sbom.ErrInvalidSBOM)
+	assert.NoError(s.UploadProviderSBOM(ctx, "acme", "random", "2.0.0", strings.NewReader(testSPDX)))
+	document, err := s.ProviderSBOM(ctx, "acme", "random", "2.0.0")
+	assert.NoError(err)
+	assert.Equal(testSPDX, string(document))
+	exists, err := s.backend.Exists(ctx, "providers/acme/random/terraform-provider-random_2.0.0_SHA256SUMS.sbom.json")
+	assert.NoError(err)
+	assert.True(exists)
+}
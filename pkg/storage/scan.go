@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/scan"
+)
+
+// scanProviderArchive runs the provider archive through the scanner, or returns a nil result if no scanner is configured.
+// Archives in which the scanner found something are rejected, and are moved to the quarantine if it's enabled.
+func (s *ObjectStorage) scanProviderArchive(ctx context.Context, namespace, name, filename string, archive io.ReaderAt, size int64) (*scan.Result, error) {
+	if s.scanner == nil {
+		return nil, nil
+	}
+
+	result, err := s.scanner.Scan(ctx, filename, io.NewSectionReader(archive, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan provider archive %s: %w", filename, err)
+	}
+	if result.Clean {
+		return result, nil
+	}
+
+	slog.Warn("provider archive rejected by the scanner",
+		slog.String("namespace", namespace),
+		slog.String("name", name),
+		slog.String("filename", filename),
+		slog.String("details", result.Details),
+		slog.Bool("quarantined", s.quarantine),
+	)
+	if s.quarantine {
+		// Quarantined archives of the same file name are replaced, as they're kept for inspection only
+		key := providerQuarantinePath(s.prefix, namespace, name, filename)
+		if err := s.upload(ctx, key, io.NewSectionReader(archive, 0, size), true); err != nil {
+			return nil, fmt.Errorf("failed to quarantine provider archive %s: %w", filename, err)
+		}
+		if err := s.uploadScanResult(ctx, key, result); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: %s: %s", core.ErrArtifactRejected, filename, result.Details)
+}
+
+// uploadScanResult stores the scan result next to the artifact, nothing is stored for a nil result
+func (s *ObjectStorage) uploadScanResult(ctx context.Context, key string, result *scan.Result) error {
+	if result == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return s.upload(ctx, key+scan.ResultSuffix, bytes.NewReader(b), true)
+}
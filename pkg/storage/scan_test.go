@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/scan"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// mockScanner rejects archives containing "EICAR"
+type mockScanner struct{}
+
+func (mockScanner) Scan(_ context.Context, _ string, r io.Reader) (*scan.Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(data), "EICAR") {
+		return &scan.Result{Scanner: "mock", Details: "Eicar-Signature"}, nil
+	}
+	return &scan.Result{Clean: true, Scanner: "mock"}, nil
+}
+
+func TestObjectStorage_ScanProviderArchive(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+	key := "providers/hashicorp/random/" + archive
+	quarantined := "quarantine/providers/hashicorp/random/" + archive
+
+	backend := newMockBackend()
+	s := NewObjectStorage(backend, WithObjectStorageScanner(mockScanner{}, false))
+
+	// Clean archives are stored with the scan result
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", archive, strings.NewReader("clean")))
+	var result scan.Result
+	assert.NoError(json.Unmarshal(backend.objects[key+".scan.json"], &result))
+	assert.True(result.Clean)
+	assert.Equal("mock", result.Scanner)
+
+	// Rejected archives are discarded
+	delete(backend.objects, key)
+	err := s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", archive, strings.NewReader("EICAR"))
+	assert.ErrorIs(err, core.ErrArtifactRejected)
+	assert.ErrorContains(err, "Eicar-Signature")
+	assert.NotContains(backend.objects, key)
+	assert.NotContains(backend.objects, quarantined)
+
+	// or moved to the quarantine
+	s = NewObjectStorage(backend, WithObjectStorageScanner(mockScanner{}, true))
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", archive, strings.NewReader("EICAR"))
+	assert.ErrorIs(err, core.ErrArtifactRejected)
+	assert.NotContains(backend.objects, key)
+	assert.Equal([]byte("EICAR"), backend.objects[quarantined])
+	assert.NoError(json.Unmarshal(backend.objects[quarantined+".scan.json"], &result))
+	assert.False(result.Clean)
+	assert.Equal("Eicar-Signature", result.Details)
+}
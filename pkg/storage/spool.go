@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"io"
+	"os"
+)
+
+// spool returns the content of the reader with random access and its size.
+// Readers which aren't files are copied to a temporary file instead of memory, so that large uploads are handled with bounded memory.
+// The returned function removes the temporary file, it has to be called once the content isn't read anymore.
+func spool(r io.Reader) (io.ReaderAt, int64, func(), error) {
+	if f, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return f, size, func() {}, nil
+	}
+
+	f, err := os.CreateTemp("", "boring-registry-upload-*")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+
+	size, err := io.Copy(f, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return f, size, cleanup, nil
+}
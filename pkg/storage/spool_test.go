@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/quota"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// zeros is an endless synthetic body
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// discardBackend discards the content of uploads larger than 1 KiB and only keeps their size
+type discardBackend struct {
+	*memoryBackend
+	mu    sync.Mutex
+	sizes map[string]int64
+}
+
+func (b *discardBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(reader, 1024))
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.sizes[key] = int64(len(data)) + n
+	b.mu.Unlock()
+	return b.memoryBackend.Upload(ctx, key, strings.NewReader(string(data)))
+}
+
+// allocated returns the bytes allocated on the heap while running f
+func allocated(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestObjectStorage_StreamingUpload(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	backend := &discardBackend{memoryBackend: newMemoryBackend(), sizes: make(map[string]int64)}
+	s := NewObjectStorage(backend, WithObjectStorageQuotas(&quota.Policy{
+		Namespaces: map[string]quota.Quota{
+			"limited": {MaxArtifactSize: 16 << 20},
+		},
+	}))
+
+	// The bodies are larger than the memory the uploads may allocate
+	const size = 64 << 20
+	alloc := allocated(func() {
+		_, err := s.UploadModule(ctx, "acme", "vpc", "aws", "1.0.0", core.NewSizedReader(io.LimitReader(zeros{}, size), size))
+		assert.NoError(err)
+	})
+	assert.Equal(int64(size), backend.sizes["modules/acme/vpc/aws/acme-vpc-aws-1.0.0.tar.gz"])
+	assert.Less(alloc, uint64(size/4), "the body must be streamed instead of read into memory")
+
+	alloc = allocated(func() {
+		err := s.UploadProviderReleaseFiles(ctx, "acme", "dummy", "terraform-provider-dummy_1.0.0_linux_amd64.zip", io.LimitReader(zeros{}, size))
+		assert.NoError(err)
+	})
+	assert.Equal(int64(size), backend.sizes["providers/acme/dummy/terraform-provider-dummy_1.0.0_linux_amd64.zip"])
+	assert.Less(alloc, uint64(size/4), "the archive must be spooled instead of read into memory")
+
+	// The declared size is checked before the body is read
+	body := core.NewSizedReader(io.LimitReader(zeros{}, size), size)
+	_, err := s.UploadModule(ctx, "limited", "vpc", "aws", "1.0.0", body)
+	assert.ErrorIs(err, core.ErrArtifactTooLarge)
+	n, _ := io.Copy(io.Discard, io.LimitReader(body, 1))
+	assert.Equal(int64(1), n, "the body must not be read")
+
+	_, err = s.UploadModule(ctx, "acme", "vpc", "aws", "1.1.0", core.NewSizedReader(strings.NewReader("truncated"), size))
+	assert.ErrorIs(err, core.ErrContentLength)
+}
+
+func TestSpool(t *testing.T) {
+	assert := assertion.New(t)
+
+	archive, size, cleanup, err := spool(io.MultiReader(strings.NewReader("module archive")))
+	assert.NoError(err)
+	data, err := io.ReadAll(io.NewSectionReader(archive, 0, size))
+	assert.NoError(err)
+	assert.Equal("module archive", string(data))
+	cleanup()
+
+	// Files are read directly
+	archive, size, cleanup, err = spool(strings.NewReader("module archive"))
+	assert.NoError(err)
+	defer cleanup()
+	assert.Equal(int64(14), size)
+	_, ok := archive.(*strings.Reader)
+	assert.True(ok)
+}
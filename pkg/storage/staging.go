@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+const stagingSuffix = ".staged"
+
+// moduleStagingPath returns the path of the staging marker of the module archive
+func moduleStagingPath(archivePath string) string {
+	return archivePath + stagingSuffix
+}
+
+// ModuleStaging returns the staging marker of the module version, which records the approvals of its reviewers
+func (s *ObjectStorage) ModuleStaging(ctx context.Context, namespace, name, provider, version string) (*module.Staging, error) {
+	key, err := s.stagedModuleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.backend.Download(ctx, moduleStagingPath(key))
+	if err != nil {
+		return nil, err
+	}
+	var staging module.Staging
+	if err := json.Unmarshal(b, &staging); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal staging marker %s: %w", moduleStagingPath(key), err)
+	}
+	return &staging, nil
+}
+
+// UpdateModuleStaging overwrites the staging marker of the staged module version
+func (s *ObjectStorage) UpdateModuleStaging(ctx context.Context, namespace, name, provider, version string, staging *module.Staging) error {
+	key, err := s.stagedModuleArchive(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	return s.uploadStaging(ctx, moduleStagingPath(key), staging)
+}
+
+// PromoteModule publishes the staged module version by removing the staging markers of all its archives
+func (s *ObjectStorage) PromoteModule(ctx context.Context, namespace, name, provider, version string) error {
+	if _, err := s.stagedModuleArchive(ctx, namespace, name, provider, version); err != nil {
+		return err
+	}
+
+	archives, err := s.moduleArchives(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	for _, archive := range archives {
+		if staged, err := s.backend.Exists(ctx, moduleStagingPath(archive)); err != nil {
+			return err
+		} else if staged {
+			if err := s.backend.Delete(ctx, moduleStagingPath(archive)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stagedModuleArchive returns the key of the staged archive of the module version
+func (s *ObjectStorage) stagedModuleArchive(ctx context.Context, namespace, name, provider, version string) (string, error) {
+	archives, err := s.moduleArchives(ctx, namespace, name, provider, version)
+	if err != nil {
+		return "", err
+	}
+	for _, archive := range archives {
+		if staged, err := s.backend.Exists(ctx, moduleStagingPath(archive)); err != nil {
+			return "", err
+		} else if staged {
+			return archive, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s/%s/%s/%s", module.ErrModuleNotStaged, namespace, name, provider, version)
+}
+
+func (s *ObjectStorage) uploadStaging(ctx context.Context, key string, staging *module.Staging) error {
+	b, err := json.Marshal(staging)
+	if err != nil {
+		return err
+	}
+
+	return s.backend.Upload(ctx, key, bytes.NewReader(b))
+}
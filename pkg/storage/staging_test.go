@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_StageModule(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	s := NewObjectStorage(backend)
+
+	_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	_, err = s.ModuleStaging(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotStaged)
+
+	stagingCtx := module.WithStaging(ctx, &module.Staging{StagedAt: time.Now().UTC(), Publisher: "alice"})
+	m, err := s.UploadModule(stagingCtx, "hashicorp", "consul", "aws", "1.1.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	assertion.Equal(t, "1.1.0", m.Version)
+	assertion.Contains(t, backend.objects, "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.1.0.tar.gz.staged")
+
+	// The staged version is hidden and can't be uploaded again
+	_, err = s.GetModule(ctx, "hashicorp", "consul", "aws", "1.1.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+	modules, err := s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assertion.NoError(t, err)
+	assertion.Len(t, modules, 1)
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.1.0", strings.NewReader("other archive"))
+	assertion.ErrorIs(t, err, module.ErrModuleAlreadyExists)
+
+	promoter := module.NewPromoter(s, 2)
+	_, err = promoter.Approve(ctx, "hashicorp", "consul", "aws", "1.1.0", "alice")
+	assertion.ErrorIs(t, err, module.ErrSelfApproval)
+	_, err = promoter.Approve(ctx, "hashicorp", "consul", "aws", "1.1.0", "bob")
+	assertion.NoError(t, err)
+	staging, err := promoter.Approve(ctx, "hashicorp", "consul", "aws", "1.1.0", "bob")
+	assertion.NoError(t, err)
+	assertion.Equal(t, []string{"bob"}, staging.Reviewers())
+
+	// A second reviewer is required
+	_, err = promoter.Promote(ctx, "hashicorp", "consul", "aws", "1.1.0")
+	assertion.ErrorIs(t, err, module.ErrApprovalRequired)
+
+	_, err = promoter.Approve(ctx, "hashicorp", "consul", "aws", "1.1.0", "carol")
+	assertion.NoError(t, err)
+	staging, err = promoter.Promote(ctx, "hashicorp", "consul", "aws", "1.1.0")
+	assertion.NoError(t, err)
+	assertion.Equal(t, "alice", staging.Publisher)
+	assertion.Equal(t, []string{"bob", "carol"}, staging.Reviewers())
+
+	_, err = s.GetModule(ctx, "hashicorp", "consul", "aws", "1.1.0")
+	assertion.NoError(t, err)
+	modules, err = s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assertion.NoError(t, err)
+	assertion.Len(t, modules, 2)
+	_, err = promoter.Promote(ctx, "hashicorp", "consul", "aws", "1.1.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotStaged)
+}
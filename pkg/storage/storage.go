@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/boring-registry/boring-registry/pkg/channel"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/mirror"
 	"github.com/boring-registry/boring-registry/pkg/module"
 	"github.com/boring-registry/boring-registry/pkg/provider"
 	"github.com/boring-registry/boring-registry/pkg/proxy"
+	"github.com/boring-registry/boring-registry/pkg/stats"
 )
 
 const (
@@ -20,6 +22,9 @@ type Storage interface {
 	module.Storage
 	mirror.Storage
 	proxy.Storage
+	stats.Storage
+	stats.ConsumerStorage
+	channel.Storage
 }
 
 // unmarshalSigningKeys tries to unmarshal the byte-array into core.SigningKeys, and if that fails into core.GPGPublicKey.
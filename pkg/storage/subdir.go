@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+// ModuleSubdir returns the cached archive of the sub-directory extracted from the module archive with the checksum.
+// The checksum of the extracted archive is uploaded last, so that archives whose upload was interrupted aren't served.
+func (s *ObjectStorage) ModuleSubdir(ctx context.Context, namespace, name, provider, version, checksum, subdir string) (core.Module, string, error) {
+	key := moduleSubdirPath(s.prefix, namespace, name, provider, version, checksum, subdir)
+	if exists, err := s.backend.Exists(ctx, moduleChecksumPath(key)); err != nil {
+		return core.Module{}, "", err
+	} else if !exists {
+		return core.Module{}, "", fmt.Errorf("%w: %s", core.ErrObjectNotFound, key)
+	}
+
+	subdirChecksum, err := s.backend.Download(ctx, moduleChecksumPath(key))
+	if err != nil {
+		return core.Module{}, "", err
+	}
+	return s.subdirModule(ctx, namespace, name, provider, version, key, strings.TrimSpace(string(subdirChecksum)))
+}
+
+// UploadModuleSubdir caches the archive of the sub-directory, an existing archive is replaced
+func (s *ObjectStorage) UploadModuleSubdir(ctx context.Context, namespace, name, provider, version, checksum, subdir string, archive []byte) (core.Module, string, error) {
+	key := moduleSubdirPath(s.prefix, namespace, name, provider, version, checksum, subdir)
+	sum := sha256.Sum256(archive)
+	subdirChecksum := hex.EncodeToString(sum[:])
+
+	ctx = s.tagged(ctx, namespace, name, version)
+	if err := s.upload(ctx, key, bytes.NewReader(archive), true); err != nil {
+		return core.Module{}, "", fmt.Errorf("%v: failed to upload sub-directory archive: %w", module.ErrModuleUploadFailed, err)
+	}
+	if err := s.upload(ctx, moduleChecksumPath(key), strings.NewReader(subdirChecksum), true); err != nil {
+		return core.Module{}, "", fmt.Errorf("%v: failed to upload sub-directory checksum: %w", module.ErrModuleUploadFailed, err)
+	}
+
+	return s.subdirModule(ctx, namespace, name, provider, version, key, subdirChecksum)
+}
+
+func (s *ObjectStorage) subdirModule(ctx context.Context, namespace, name, provider, version, key, checksum string) (core.Module, string, error) {
+	presigned, err := s.backend.PresignedURL(ctx, key)
+	if err != nil {
+		return core.Module{}, "", err
+	}
+
+	return core.Module{
+		Namespace:   namespace,
+		Name:        name,
+		Provider:    provider,
+		Version:     version,
+		DownloadURL: presigned,
+	}, checksum, nil
+}
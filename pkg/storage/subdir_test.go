@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func testModuleArchive(t *testing.T, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		assertion.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assertion.NoError(t, err)
+	}
+	assertion.NoError(t, tw.Close())
+	assertion.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestObjectStorage_ModuleSubdir(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+
+	_, _, err := s.ModuleSubdir(ctx, "acme", "network", "aws", "1.0.0", "abc", "modules/vpc")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+
+	m, checksum, err := s.UploadModuleSubdir(ctx, "acme", "network", "aws", "1.0.0", "abc", "modules/vpc", []byte("archive"))
+	assert.NoError(err)
+	assert.Equal("1.0.0", m.Version)
+	assert.Contains(m.DownloadURL, "subdirs/modules/acme/network/aws/1.0.0/abc/modules/vpc.tar.gz")
+	sum := sha256.Sum256([]byte("archive"))
+	assert.Equal(hex.EncodeToString(sum[:]), checksum)
+
+	cached, cachedChecksum, err := s.ModuleSubdir(ctx, "acme", "network", "aws", "1.0.0", "abc", "modules/vpc")
+	assert.NoError(err)
+	assert.Equal(m, cached)
+	assert.Equal(checksum, cachedChecksum)
+
+	// The sub-directories of other archives aren't cached
+	_, _, err = s.ModuleSubdir(ctx, "acme", "network", "aws", "1.0.0", "def", "modules/vpc")
+	assert.ErrorIs(err, core.ErrObjectNotFound)
+}
+
+func TestModuleService_Subdir(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+	svc := module.NewService(s, core.NewProxyUrlService(false, "/proxy"))
+
+	archive := testModuleArchive(t, map[string]string{
+		"main.tf":             `module "vpc" {}`,
+		"modules/vpc/main.tf": `resource "aws_vpc" "this" {}`,
+	})
+	_, err := s.UploadModule(ctx, "acme", "network", "aws", "1.0.0", bytes.NewReader(archive))
+	assert.NoError(err)
+
+	subdirCtx := module.WithSubdir(ctx, "modules/vpc")
+	checksum, err := svc.GetModuleChecksum(subdirCtx, "acme", "network", "aws", "1.0.0")
+	assert.NoError(err)
+	m, err := svc.GetModule(subdirCtx, "acme", "network", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(m.DownloadURL, "subdirs/modules/acme/network/aws/1.0.0/"))
+	assert.Contains(m.DownloadURL, "/modules/vpc.tar.gz")
+
+	// The checksum is the one of the extracted archive, which is cached
+	fullChecksum, err := svc.GetModuleChecksum(ctx, "acme", "network", "aws", "1.0.0")
+	assert.NoError(err)
+	assert.NotEqual(fullChecksum, checksum)
+	_, cachedChecksum, err := s.ModuleSubdir(ctx, "acme", "network", "aws", "1.0.0", fullChecksum, "modules/vpc")
+	assert.NoError(err)
+	assert.Equal(checksum, cachedChecksum)
+
+	_, err = svc.GetModule(module.WithSubdir(ctx, "modules/missing"), "acme", "network", "aws", "1.0.0")
+	assert.ErrorIs(err, module.ErrSubdirNotFound)
+
+	// Read-only registries only serve the cached sub-directories
+	readOnly := module.NewService(s, core.NewProxyUrlService(false, "/proxy"), module.WithReadOnly())
+	_, err = readOnly.GetModule(subdirCtx, "acme", "network", "aws", "1.0.0")
+	assert.NoError(err)
+	_, err = readOnly.GetModule(module.WithSubdir(ctx, "modules"), "acme", "network", "aws", "1.0.0")
+	assert.ErrorIs(err, core.ErrReadOnly)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Keys of the tags describing the artifact an uploaded object belongs to
+const (
+	TagNamespace = "namespace"
+	TagName      = "name"
+	TagVersion   = "version"
+	TagPublisher = "publisher"
+)
+
+// maxS3ObjectTags is the maximum number of tags of an S3 object
+const maxS3ObjectTags = 10
+
+type objectTagsKey struct{}
+
+// withObjectTags returns a copy of the context carrying the tags of the uploaded objects.
+// The tags are passed through the context, so that the decorators of the Backend don't need to know about them.
+func withObjectTags(ctx context.Context, tags map[string]string) context.Context {
+	return context.WithValue(ctx, objectTagsKey{}, tags)
+}
+
+// objectTags returns the tags of the objects uploaded with the context, or nil if tagging is disabled
+func objectTags(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(objectTagsKey{}).(map[string]string)
+	return tags
+}
+
+// encodeS3Tags encodes the tags as URL query parameters, as expected by the Tagging header of S3
+func encodeS3Tags(tags map[string]string) *string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	values := url.Values{}
+	for k, v := range tags {
+		values.Set(k, v)
+	}
+	encoded := values.Encode()
+	return &encoded
+}
+
+// tagged returns the context for uploading objects of the artifact with the static and artifact tags,
+// the context is returned unchanged if tagging is disabled
+func (s *ObjectStorage) tagged(ctx context.Context, namespace, name, version string) context.Context {
+	if s.tags == nil {
+		return ctx
+	}
+
+	tags := maps.Clone(s.tags)
+	for k, v := range map[string]string{TagNamespace: namespace, TagName: name, TagVersion: version, TagPublisher: s.publisher} {
+		if v != "" {
+			tags[k] = v
+		}
+	}
+	return withObjectTags(ctx, tags)
+}
+
+// providerFileVersion returns the version of a provider release file, e.g. an archive, the manifest, or the SHA256SUMS file and its signature
+func providerFileVersion(filename string) string {
+	if p, err := core.NewProviderFromArchive(filename); err == nil {
+		return p.Version
+	}
+
+	// terraform-provider-<name>_<version>_SHA256SUMS[.sig] and terraform-provider-<name>_<version>_manifest.json
+	tokens := strings.Split(strings.TrimPrefix(filename, core.ProviderPrefix), "_")
+	if len(tokens) == 3 {
+		return tokens[1]
+	}
+	return ""
+}
+
+// validateS3Tags checks that the static tags leave room for the artifact tags within the limits of S3
+func validateS3Tags(tags map[string]string) error {
+	if len(tags) > maxS3ObjectTags-4 {
+		return fmt.Errorf("at most %d storage tags are supported, as S3 objects have at most %d tags", maxS3ObjectTags-4, maxS3ObjectTags)
+	}
+	for k, v := range tags {
+		if len(k) > 128 || len(v) > 256 {
+			return fmt.Errorf("storage tag %s exceeds the maximum length of 128 characters for keys and 256 characters for values", k)
+		}
+	}
+
+	return nil
+}
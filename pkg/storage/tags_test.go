@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+// taggingBackend records the tags of every uploaded object
+type taggingBackend struct {
+	*mockBackend
+	tags map[string]map[string]string
+}
+
+func (b *taggingBackend) Upload(ctx context.Context, key string, reader io.Reader) error {
+	b.tags[key] = objectTags(ctx)
+	return b.mockBackend.Upload(ctx, key, reader)
+}
+
+func TestObjectStorage_Tags(t *testing.T) {
+	ctx := context.Background()
+	backend := &taggingBackend{mockBackend: newMockBackend(), tags: make(map[string]map[string]string)}
+	s := NewObjectStorage(backend, WithObjectStorageTags(map[string]string{"team": "platform", TagName: "overridden"}, "jane"))
+
+	_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	want := map[string]string{"team": "platform", TagNamespace: "hashicorp", TagName: "consul", TagVersion: "1.0.0", TagPublisher: "jane"}
+	assertion.Equal(t, want, backend.tags["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz"])
+	assertion.Equal(t, want, backend.tags["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz.sha256"])
+
+	err = s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", "terraform-provider-random_2.0.0_SHA256SUMS.sig", strings.NewReader("sig"))
+	assertion.NoError(t, err)
+	assertion.Equal(t, "2.0.0", backend.tags["providers/hashicorp/random/terraform-provider-random_2.0.0_SHA256SUMS.sig"][TagVersion])
+
+	// Objects aren't tagged if tagging is disabled
+	backend = &taggingBackend{mockBackend: newMockBackend(), tags: make(map[string]map[string]string)}
+	s = NewObjectStorage(backend, WithObjectStorageTags(nil, "jane"))
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+	assertion.Nil(t, backend.tags["modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz"])
+}
+
+func TestProviderFileVersion(t *testing.T) {
+	assertion.Equal(t, "1.2.3", providerFileVersion("terraform-provider-random_1.2.3_linux_amd64.zip"))
+	assertion.Equal(t, "1.2.3", providerFileVersion("terraform-provider-random_1.2.3_SHA256SUMS"))
+	assertion.Equal(t, "1.2.3", providerFileVersion("terraform-provider-random_1.2.3_SHA256SUMS.sig"))
+	assertion.Equal(t, "", providerFileVersion("signing-keys.json"))
+}
+
+func TestEncodeS3Tags(t *testing.T) {
+	assertion.Nil(t, encodeS3Tags(nil))
+
+	encoded := encodeS3Tags(map[string]string{"team": "platform & ops", TagVersion: "1.0.0+build"})
+	values, err := url.ParseQuery(*encoded)
+	assertion.NoError(t, err)
+	assertion.Equal(t, "platform & ops", values.Get("team"))
+	assertion.Equal(t, "1.0.0+build", values.Get(TagVersion))
+
+	assertion.NoError(t, validateS3Tags(map[string]string{"team": "platform"}))
+	assertion.Error(t, validateS3Tags(map[string]string{"a": "", "b": "", "c": "", "d": "", "e": "", "f": "", "g": ""}))
+}
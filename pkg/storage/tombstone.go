@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+const tombstoneSuffix = ".tombstone"
+
+// Tombstone marks a deleted version, which is hidden immediately and purged once the grace period has passed
+type Tombstone struct {
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// PurgeResult summarizes a purge of deleted versions
+type PurgeResult struct {
+	Purged  int `json:"purged"`
+	Pending int `json:"pending"`
+	Failed  int `json:"failed"`
+}
+
+// moduleTombstonePath returns the path of the tombstone of the module archive
+func moduleTombstonePath(archivePath string) string {
+	return archivePath + tombstoneSuffix
+}
+
+// providerTombstonePath returns a <prefix>/providers/<namespace>/<name>/terraform-provider-<name>_<version>.tombstone path
+func providerTombstonePath(prefix, namespace, name, version string) string {
+	return path.Join(providerStoragePrefix(prefix, internalProviderType, "", namespace, name), fmt.Sprintf("%s%s_%s%s", core.ProviderPrefix, name, version, tombstoneSuffix))
+}
+
+// DeleteModule hides the module version by writing a tombstone, its objects are kept until they are purged
+func (s *ObjectStorage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	if _, err := s.GetModule(ctx, namespace, name, provider, version); err != nil {
+		return err
+	}
+
+	// All formats of the version are hidden, so that GetModule doesn't fall back to another format
+	archives, err := s.moduleArchives(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+	for _, archive := range archives {
+		if err := s.uploadTombstone(ctx, moduleTombstonePath(archive)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreModule removes the tombstone of a deleted module version, which hasn't been purged yet
+func (s *ObjectStorage) RestoreModule(ctx context.Context, namespace, name, provider, version string) error {
+	archives, err := s.moduleArchives(ctx, namespace, name, provider, version)
+	if err != nil {
+		return err
+	}
+
+	var restored bool
+	for _, archive := range archives {
+		if exists, err := s.backend.Exists(ctx, moduleTombstonePath(archive)); err != nil {
+			return err
+		} else if exists {
+			if err := s.backend.Delete(ctx, moduleTombstonePath(archive)); err != nil {
+				return err
+			}
+			restored = true
+		}
+	}
+	if !restored {
+		return fmt.Errorf("%w: %s/%s/%s/%s isn't deleted", module.ErrModuleNotFound, namespace, name, provider, version)
+	}
+	return nil
+}
+
+// DeleteProvider hides all platforms of the provider version by writing a tombstone, its objects are kept until they are purged
+func (s *ObjectStorage) DeleteProvider(ctx context.Context, namespace, name, version string) error {
+	if _, err := s.listProviderVersions(ctx, internalProviderType, &core.Provider{Namespace: namespace, Name: name, Version: version}); err != nil {
+		return err
+	}
+
+	return s.uploadTombstone(ctx, providerTombstonePath(s.prefix, namespace, name, version))
+}
+
+// RestoreProvider removes the tombstone of a deleted provider version, which hasn't been purged yet
+func (s *ObjectStorage) RestoreProvider(ctx context.Context, namespace, name, version string) error {
+	key := providerTombstonePath(s.prefix, namespace, name, version)
+	if exists, err := s.backend.Exists(ctx, key); err != nil {
+		return err
+	} else if !exists {
+		return noMatchingProviderFound(&core.Provider{Namespace: namespace, Name: name, Version: version})
+	}
+
+	return s.backend.Delete(ctx, key)
+}
+
+func (s *ObjectStorage) uploadTombstone(ctx context.Context, key string) error {
+	b, err := json.Marshal(Tombstone{DeletedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	return s.backend.Upload(ctx, key, bytes.NewReader(b))
+}
+
+// Purge removes all objects of the versions deleted before the given time, and finally their tombstones.
+// A version which failed to be purged keeps its tombstone, so that it's purged by the next run.
+func (s *ObjectStorage) Purge(ctx context.Context, before time.Time) (PurgeResult, error) {
+	var result PurgeResult
+	for _, t := range []string{string(internalModuleType), string(internalProviderType)} {
+		isProvider := t == string(internalProviderType)
+		objects, err := s.backend.List(ctx, path.Join(s.prefix, t)+"/")
+		if err != nil {
+			return result, fmt.Errorf("failed to list %s: %w", t, err)
+		}
+
+		for _, obj := range objects {
+			if !strings.HasSuffix(obj.Key, tombstoneSuffix) {
+				continue
+			}
+
+			tombstone, err := s.tombstone(ctx, obj.Key)
+			if err != nil {
+				slog.Error("failed to read tombstone", slog.String("key", obj.Key), slog.String("err", err.Error()))
+				result.Failed++
+				continue
+			}
+			if !tombstone.DeletedAt.Before(before) {
+				result.Pending++
+				continue
+			}
+
+			if err := s.purge(ctx, obj.Key, isProvider, objects); err != nil {
+				slog.Error("failed to purge deleted version", slog.String("tombstone", obj.Key), slog.String("err", err.Error()))
+				result.Failed++
+				continue
+			}
+			slog.Info("purged deleted version", slog.String("tombstone", obj.Key), slog.Time("deleted-at", tombstone.DeletedAt))
+			result.Purged++
+		}
+	}
+
+	return result, nil
+}
+
+func (s *ObjectStorage) tombstone(ctx context.Context, key string) (*Tombstone, error) {
+	b, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var tombstone Tombstone
+	if err := json.Unmarshal(b, &tombstone); err != nil {
+		return nil, err
+	}
+	return &tombstone, nil
+}
+
+// purge deletes the objects belonging to the tombstone, which are looked up in the objects listed before
+func (s *ObjectStorage) purge(ctx context.Context, tombstoneKey string, isProvider bool, objects []Object) error {
+	// The objects of modules are stored as <archive><suffix> and those of providers as terraform-provider-<name>_<version>_<rest>
+	prefix := strings.TrimSuffix(tombstoneKey, tombstoneSuffix)
+	if isProvider {
+		prefix += "_"
+	}
+
+	for _, obj := range objects {
+		if obj.Key == tombstoneKey || !strings.HasPrefix(obj.Key, prefix) {
+			continue
+		}
+		if err := s.backend.Delete(ctx, obj.Key); err != nil {
+			return err
+		}
+	}
+
+	return s.backend.Delete(ctx, tombstoneKey)
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_DeleteModule(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	s := NewObjectStorage(backend)
+
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", v, strings.NewReader("archive"))
+		assertion.NoError(t, err)
+	}
+	assertion.ErrorIs(t, s.DeleteModule(ctx, "hashicorp", "consul", "aws", "2.0.0"), module.ErrModuleNotFound)
+	assertion.ErrorIs(t, s.RestoreModule(ctx, "hashicorp", "consul", "aws", "1.0.0"), module.ErrModuleNotFound)
+
+	assertion.NoError(t, s.DeleteModule(ctx, "hashicorp", "consul", "aws", "1.0.0"))
+
+	// The deleted version is hidden, but its objects are kept
+	_, err := s.GetModule(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.ErrorIs(t, err, module.ErrModuleNotFound)
+	modules, err := s.ListModuleVersions(ctx, "hashicorp", "consul", "aws")
+	assertion.NoError(t, err)
+	assertion.Len(t, modules, 1)
+	assertion.Equal(t, "1.1.0", modules[0].Version)
+	assertion.Contains(t, backend.objects, "modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.tar.gz")
+
+	// The version can't be published again before it's purged
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("other archive"))
+	assertion.ErrorIs(t, err, module.ErrModuleAlreadyExists)
+
+	assertion.NoError(t, s.RestoreModule(ctx, "hashicorp", "consul", "aws", "1.0.0"))
+	_, err = s.GetModule(ctx, "hashicorp", "consul", "aws", "1.0.0")
+	assertion.NoError(t, err)
+}
+
+func TestObjectStorage_DeleteProvider(t *testing.T) {
+	ctx := context.Background()
+	s := NewObjectStorage(newMockBackend())
+
+	for _, f := range []string{
+		"terraform-provider-random_2.0.0_linux_amd64.zip",
+		"terraform-provider-random_2.0.0_SHA256SUMS",
+		"terraform-provider-random_2.1.0_linux_amd64.zip",
+		"terraform-provider-random_2.1.0_SHA256SUMS",
+	} {
+		assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", f, strings.NewReader("archive")))
+	}
+	var providerErr *core.ProviderError
+	assertion.ErrorAs(t, s.DeleteProvider(ctx, "hashicorp", "random", "3.0.0"), &providerErr)
+
+	assertion.NoError(t, s.DeleteProvider(ctx, "hashicorp", "random", "2.0.0"))
+
+	versions, err := s.ListProviderVersions(ctx, "hashicorp", "random")
+	assertion.NoError(t, err)
+	assertion.Len(t, versions.Versions, 1)
+	assertion.Equal(t, "2.1.0", versions.Versions[0].Version)
+	_, err = s.GetProvider(ctx, "hashicorp", "random", "2.0.0", "linux", "amd64")
+	assertion.ErrorAs(t, err, &providerErr)
+
+	assertion.NoError(t, s.RestoreProvider(ctx, "hashicorp", "random", "2.0.0"))
+	assertion.ErrorAs(t, s.RestoreProvider(ctx, "hashicorp", "random", "2.0.0"), &providerErr)
+	versions, err = s.ListProviderVersions(ctx, "hashicorp", "random")
+	assertion.NoError(t, err)
+	assertion.Len(t, versions.Versions, 2)
+}
+
+func TestObjectStorage_Purge(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockBackend()
+	s := NewObjectStorage(backend)
+
+	for _, v := range []string{"1.0.0", "1.0.0.1"} {
+		_, err := s.UploadModule(ctx, "hashicorp", "consul", "aws", v, strings.NewReader("archive"))
+		assertion.NoError(t, err)
+	}
+	for _, f := range []string{
+		"terraform-provider-random_2.0.0_linux_amd64.zip",
+		"terraform-provider-random_2.0.0_SHA256SUMS",
+		"terraform-provider-random_2.0.0-beta_linux_amd64.zip",
+	} {
+		assertion.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", f, strings.NewReader("archive")))
+	}
+	assertion.NoError(t, s.DeleteModule(ctx, "hashicorp", "consul", "aws", "1.0.0"))
+	assertion.NoError(t, s.DeleteProvider(ctx, "hashicorp", "random", "2.0.0"))
+
+	// Versions within the grace period are kept
+	result, err := s.Purge(ctx, time.Now().Add(-time.Hour))
+	assertion.NoError(t, err)
+	assertion.Equal(t, PurgeResult{Pending: 2}, result)
+
+	result, err = s.Purge(ctx, time.Now().Add(time.Hour))
+	assertion.NoError(t, err)
+	assertion.Equal(t, PurgeResult{Purged: 2}, result)
+
+	var keys []string
+	for key := range backend.objects {
+		keys = append(keys, key)
+	}
+	assertion.ElementsMatch(t, []string{
+		"modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.1.tar.gz",
+		"modules/hashicorp/consul/aws/hashicorp-consul-aws-1.0.0.1.tar.gz.sha256",
+		"providers/hashicorp/random/terraform-provider-random_2.0.0-beta_linux_amd64.zip",
+	}, keys)
+
+	// The purged version can be published again
+	_, err = s.UploadModule(ctx, "hashicorp", "consul", "aws", "1.0.0", strings.NewReader("archive"))
+	assertion.NoError(t, err)
+}
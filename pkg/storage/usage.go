@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// NamespaceUsage is the storage used by the modules and providers of a namespace
+type NamespaceUsage struct {
+	Namespace string `json:"namespace"`
+	Objects   int    `json:"objects"`
+	Bytes     int64  `json:"bytes"`
+
+	// MaxTotalSize and SoftTotalSize are the total size limits of the quota of the namespace, 0 is unlimited
+	MaxTotalSize  int64 `json:"max_total_size,omitempty"`
+	SoftTotalSize int64 `json:"soft_total_size,omitempty"`
+}
+
+// UsageReporter computes the storage used by the namespaces
+type UsageReporter interface {
+	Usage(ctx context.Context) ([]NamespaceUsage, error)
+}
+
+// Usage lists all modules and providers and sums up the objects and bytes of every namespace, sorted by namespace.
+// Like the total size quota, this includes checksums, signatures, and other files stored next to the archives.
+func (s *ObjectStorage) Usage(ctx context.Context) ([]NamespaceUsage, error) {
+	usage := make(map[string]*NamespaceUsage)
+	for _, artifactType := range []string{string(internalModuleType), string(internalProviderType)} {
+		prefix := path.Join(s.prefix, artifactType) + "/"
+		objects, err := s.backend.List(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute usage: %w", err)
+		}
+
+		for _, obj := range objects {
+			namespace, _, ok := strings.Cut(strings.TrimPrefix(obj.Key, prefix), "/")
+			if !ok || namespace == "" {
+				// Objects directly below the prefix don't belong to a namespace
+				continue
+			}
+
+			u, ok := usage[namespace]
+			if !ok {
+				q := s.quotas.Quota(namespace)
+				u = &NamespaceUsage{
+					Namespace:     namespace,
+					MaxTotalSize:  int64(q.MaxTotalSize),
+					SoftTotalSize: int64(q.SoftTotalSize),
+				}
+				usage[namespace] = u
+			}
+			u.Objects++
+			u.Bytes += obj.Size
+		}
+	}
+
+	res := make([]NamespaceUsage, 0, len(usage))
+	for _, u := range usage {
+		res = append(res, *u)
+	}
+	slices.SortFunc(res, func(a, b NamespaceUsage) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return res, nil
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/quota"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_Usage(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage(WithMemoryStorageObjectOptions(WithObjectStorageQuotas(&quota.Policy{
+		Default:    quota.Quota{MaxTotalSize: 1000},
+		Namespaces: map[string]quota.Quota{"acme": {SoftTotalSize: 10}},
+	})))
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err := s.UploadModule(ctx, "acme", "vpc", "aws", version, strings.NewReader("module"))
+		assert.NoError(err)
+	}
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "example", "random", "terraform-provider-random_2.0.0_linux_amd64.zip", strings.NewReader("provider archive")))
+	assert.NoError(s.backend.Upload(ctx, "stats/acme/vpc/aws/downloads.json", strings.NewReader("{}")))
+
+	usage, err := s.Usage(ctx)
+	assert.NoError(err)
+	// Every module version consists of the archive and its checksum
+	assert.Equal([]NamespaceUsage{
+		{Namespace: "acme", Objects: 4, Bytes: 2*6 + 2*64, MaxTotalSize: 1000, SoftTotalSize: 10},
+		{Namespace: "example", Objects: 1, Bytes: 16, MaxTotalSize: 1000},
+	}, usage)
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// VerificationResult summarizes a verification of the stored archives against their recorded digests
+type VerificationResult struct {
+	Verified int
+	Failed   int
+
+	// Unverified archives have no recorded digest, e.g. modules uploaded before checksums were stored
+	Unverified int
+}
+
+// Verifier re-checks the stored archives against the digests recorded on upload
+type Verifier interface {
+	Verify(ctx context.Context) (VerificationResult, error)
+}
+
+// Verify compares the SHA-256 checksums of all module archives with the checksums stored next to them,
+// and the checksums of all provider archives with the SHA256SUMS file of the release.
+// An error is returned if any archive doesn't match or can't be read.
+func (s *ObjectStorage) Verify(ctx context.Context) (VerificationResult, error) {
+	var result VerificationResult
+
+	objects, err := s.backend.List(ctx, s.prefix)
+	if err != nil {
+		return result, fmt.Errorf("failed to list objects to verify: %w", err)
+	}
+
+	keys := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		keys[obj.Key] = true
+	}
+
+	var failures []string
+	verify := func(key, expected string) {
+		if err := s.verifyObject(ctx, key, expected); err != nil {
+			slog.Error("archive failed verification", slog.String("key", key), slog.String("err", err.Error()))
+			failures = append(failures, key)
+			result.Failed++
+			return
+		}
+		result.Verified++
+	}
+
+	verified := make(map[string]bool)
+	manifests := make(map[string]map[string]string)
+	for _, obj := range objects {
+		switch {
+		case strings.HasSuffix(obj.Key, moduleChecksumSuffix):
+			archive := strings.TrimSuffix(obj.Key, moduleChecksumSuffix)
+			if !keys[archive] {
+				continue
+			}
+			checksum, err := s.backend.Download(ctx, obj.Key)
+			if err != nil {
+				return result, fmt.Errorf("failed to download checksum %s: %w", obj.Key, err)
+			}
+			if err := s.verifyListedChecksum(ctx, manifests, keys, archive, strings.TrimSpace(string(checksum))); err != nil {
+				slog.Error("archive failed verification", slog.String("key", archive), slog.String("err", err.Error()))
+				failures = append(failures, archive)
+				result.Failed++
+				verified[archive] = true
+				continue
+			}
+			verify(archive, strings.TrimSpace(string(checksum)))
+			verified[archive] = true
+		case strings.HasSuffix(obj.Key, "_SHA256SUMS"):
+			data, err := s.backend.Download(ctx, obj.Key)
+			if err != nil {
+				return result, fmt.Errorf("failed to download %s: %w", obj.Key, err)
+			}
+			sums, err := core.NewSha256Sums(path.Base(obj.Key), bytes.NewReader(data))
+			if err != nil {
+				slog.Warn("skipping invalid SHA256SUMS file", slog.String("key", obj.Key), slog.String("err", err.Error()))
+				continue
+			}
+			for filename, checksum := range sums.Entries {
+				archive := path.Join(path.Dir(obj.Key), filename)
+				if !keys[archive] {
+					continue
+				}
+				verify(archive, hex.EncodeToString(checksum))
+				verified[archive] = true
+			}
+		}
+	}
+
+	for _, obj := range objects {
+		if verified[obj.Key] {
+			continue
+		}
+		if _, _, err := moduleFromArchive(obj.Key, s.archiveFormats(ctx)); err == nil || strings.HasSuffix(obj.Key, core.ProviderExtension) {
+			result.Unverified++
+		}
+	}
+
+	slog.Info("verified archives",
+		slog.Int("verified", result.Verified),
+		slog.Int("failed", result.Failed),
+		slog.Int("unverified", result.Unverified),
+	)
+
+	if len(failures) > 0 {
+		return result, fmt.Errorf("%d archives don't match their recorded digest: %s", len(failures), strings.Join(failures, ", "))
+	}
+	return result, nil
+}
+
+// verifyObject compares the hex-encoded SHA-256 checksum of the object with the expected one
+func (s *ObjectStorage) verifyObject(ctx context.Context, key, expected string) error {
+	data, err := s.backend.Download(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+
+	checksum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(checksum[:]); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("expected checksum %s but was %s", expected, actual)
+	}
+	return nil
+}
+
+// verifyListedChecksum compares the stored checksum of a module archive with the SHA256SUMS manifest of the module, if the archive is listed.
+// The parsed manifests are cached by directory.
+func (s *ObjectStorage) verifyListedChecksum(ctx context.Context, manifests map[string]map[string]string, keys map[string]bool, archive, checksum string) error {
+	dir := path.Dir(archive)
+	checksums, ok := manifests[dir]
+	if !ok {
+		checksums = map[string]string{}
+		if key := path.Join(dir, moduleChecksumsFile); keys[key] {
+			data, err := s.backend.Download(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to download checksum manifest: %w", err)
+			}
+			if checksums, err = parseModuleChecksums(data); err != nil {
+				return fmt.Errorf("invalid checksum manifest: %w", err)
+			}
+		}
+		manifests[dir] = checksums
+	}
+
+	if expected, ok := checksums[path.Base(archive)]; ok && !strings.EqualFold(expected, checksum) {
+		return fmt.Errorf("stored checksum %s doesn't match the checksum manifest %s", checksum, expected)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestObjectStorage_Verify(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err := s.UploadModule(ctx, "acme", "vpc", "aws", version, strings.NewReader("module"))
+		assert.NoError(err)
+	}
+	// Modules uploaded before checksums were stored can't be verified
+	assert.NoError(s.backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-0.9.0.tar.gz", strings.NewReader("module")))
+
+	archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+	shasum := sha256.Sum256([]byte("provider archive"))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", archive, strings.NewReader("provider archive")))
+	assert.NoError(s.UploadProviderReleaseFiles(ctx, "acme", "random", "terraform-provider-random_2.0.0_SHA256SUMS",
+		strings.NewReader(fmt.Sprintf("%s  %s\n", hex.EncodeToString(shasum[:]), archive))))
+
+	result, err := s.Verify(ctx)
+	assert.NoError(err)
+	assert.Equal(VerificationResult{Verified: 3, Unverified: 1}, result)
+
+	// Archives which were modified after the upload fail the verification
+	assert.NoError(s.backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz", strings.NewReader("tampered")))
+	assert.NoError(s.backend.Upload(ctx, "providers/acme/random/"+archive, strings.NewReader("tampered")))
+
+	result, err = s.Verify(ctx)
+	assert.ErrorContains(err, "2 archives don't match their recorded digest")
+	assert.Equal(VerificationResult{Verified: 1, Failed: 2, Unverified: 1}, result)
+}
+
+func TestObjectStorage_VerifyChecksumManifest(t *testing.T) {
+	assert := assertion.New(t)
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	checksum := sha256.Sum256([]byte("module"))
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err := s.UploadModule(ctx, "acme", "vpc", "aws", version, strings.NewReader("module"))
+		assert.NoError(err)
+		assert.NoError(s.UploadModuleChecksum(ctx, "acme", "vpc", "aws", version, hex.EncodeToString(checksum[:])))
+	}
+
+	result, err := s.Verify(ctx)
+	assert.NoError(err)
+	assert.Equal(VerificationResult{Verified: 2}, result)
+
+	// Archives whose checksum was replaced together with the archive still fail against the manifest
+	tampered := sha256.Sum256([]byte("tampered"))
+	assert.NoError(s.backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz", strings.NewReader("tampered")))
+	assert.NoError(s.backend.Upload(ctx, "modules/acme/vpc/aws/acme-vpc-aws-1.1.0.tar.gz.sha256", strings.NewReader(hex.EncodeToString(tampered[:]))))
+
+	result, err = s.Verify(ctx)
+	assert.ErrorContains(err, "1 archives don't match their recorded digest")
+	assert.Equal(VerificationResult{Verified: 1, Failed: 1}, result)
+}
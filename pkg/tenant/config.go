@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a single tenant, which is an isolated registry served for a distinct hostname, below a path prefix, or both.
+type Config struct {
+	// Host is the hostname the tenant is served for, without a port. The tenant is served for all hostnames if empty.
+	Host string `yaml:"host"`
+
+	// Path is the path prefix the tenant is served below, e.g. /sandbox. The tenant is served at the root if empty.
+	Path string `yaml:"path"`
+
+	// Flags override the server flags for the tenant, e.g. the storage prefix or the auth configuration.
+	// The values are given as strings in the same format as the environment variables.
+	Flags map[string]string `yaml:"flags"`
+}
+
+// Name identifies the tenant by its host and path in logs and errors
+func (c Config) Name() string {
+	host := c.Host
+	if host == "" {
+		host = "*"
+	}
+	return host + c.Path
+}
+
+type configFile struct {
+	Tenants []Config `yaml:"tenants"`
+}
+
+// LoadConfig reads the tenants from a YAML or JSON file
+func LoadConfig(path string) ([]Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	return ParseConfig(b)
+}
+
+// ParseConfig parses and validates the tenants from YAML or JSON
+func ParseConfig(b []byte) ([]Config, error) {
+	var f configFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants: %w", err)
+	}
+
+	tenants := make(map[string]struct{}, len(f.Tenants))
+	for i := range f.Tenants {
+		t := &f.Tenants[i]
+		t.Host = normalizeHost(t.Host)
+
+		var err error
+		if t.Path, err = normalizePath(t.Path); err != nil {
+			return nil, fmt.Errorf("%w: tenant %d: %s", ErrInvalidPath, i, err)
+		}
+		if t.Host == "" && t.Path == "" {
+			return nil, fmt.Errorf("%w: tenant %d", ErrMissingSelector, i)
+		}
+
+		if _, ok := tenants[t.Name()]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateTenant, t.Name())
+		}
+		tenants[t.Name()] = struct{}{}
+	}
+
+	return f.Tenants, nil
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSpace(host))
+}
+
+// normalizePath returns the path prefix without a trailing slash, the registry's own paths can't be used as prefix
+func normalizePath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("%s doesn't start with a slash", p)
+	}
+
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return "", nil
+	}
+	if path.Clean(p) != p {
+		return "", fmt.Errorf("%s isn't a clean path", p)
+	}
+	if first, _, _ := strings.Cut(p[1:], "/"); reservedPaths[first] {
+		return "", fmt.Errorf("%s collides with the paths of the registry", p)
+	}
+	return p, nil
+}
+
+// reservedPaths are the first segments of the paths served by the registry, which would shadow the tenants
+var reservedPaths = map[string]bool{
+	"v1":          true,
+	"admin":       true,
+	"metrics":     true,
+	".well-known": true,
+}
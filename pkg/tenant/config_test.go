@@ -0,0 +1,137 @@
+package tenant
+
+import (
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Config
+		wantErr error
+	}{
+		{
+			name: "yaml",
+			data: `
+tenants:
+  - host: Registry.Team-A.example.com
+    flags:
+      storage-s3-prefix: team-a
+      auth-static-token: token-a,token-b
+  - host: registry.team-b.example.com
+`,
+			want: []Config{
+				{
+					Host: "registry.team-a.example.com",
+					Flags: map[string]string{
+						"storage-s3-prefix": "team-a",
+						"auth-static-token": "token-a,token-b",
+					},
+				},
+				{
+					Host: "registry.team-b.example.com",
+				},
+			},
+		},
+		{
+			name: "json",
+			data: `{"tenants": [{"host": "registry.example.com", "flags": {"storage-gcs-prefix": "example"}}]}`,
+			want: []Config{
+				{
+					Host:  "registry.example.com",
+					Flags: map[string]string{"storage-gcs-prefix": "example"},
+				},
+			},
+		},
+		{
+			name: "missing host",
+			data: `
+tenants:
+  - flags:
+      storage-s3-prefix: team-a
+`,
+			wantErr: ErrMissingSelector,
+		},
+		{
+			name: "duplicate host",
+			data: `
+tenants:
+  - host: registry.example.com
+  - host: REGISTRY.example.com
+`,
+			wantErr: ErrDuplicateTenant,
+		},
+		{
+			name: "path",
+			data: `
+tenants:
+  - host: registry.example.com
+  - host: registry.example.com
+    path: /sandbox/
+    flags:
+      storage-s3-prefix: sandbox
+  - path: /sandbox
+`,
+			want: []Config{
+				{
+					Host: "registry.example.com",
+				},
+				{
+					Host:  "registry.example.com",
+					Path:  "/sandbox",
+					Flags: map[string]string{"storage-s3-prefix": "sandbox"},
+				},
+				{
+					Path: "/sandbox",
+				},
+			},
+		},
+		{
+			name: "duplicate path",
+			data: `
+tenants:
+  - path: /sandbox
+  - path: /sandbox/
+`,
+			wantErr: ErrDuplicateTenant,
+		},
+		{
+			name: "relative path",
+			data: `
+tenants:
+  - path: sandbox
+`,
+			wantErr: ErrInvalidPath,
+		},
+		{
+			name: "path of the registry",
+			data: `
+tenants:
+  - path: /v1/sandbox
+`,
+			wantErr: ErrInvalidPath,
+		},
+		{
+			name: "root path without host",
+			data: `
+tenants:
+  - path: /
+`,
+			wantErr: ErrMissingSelector,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConfig([]byte(tt.data))
+			if tt.wantErr != nil {
+				assertion.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assertion.NoError(t, err)
+			assertion.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package tenant
+
+import "errors"
+
+var (
+	// Tenant errors
+	ErrUnknownTenant   = errors.New("no tenant is configured for the host and path")
+	ErrDuplicateTenant = errors.New("tenant host and path are configured multiple times")
+	ErrMissingSelector = errors.New("tenant host and path are missing")
+	ErrInvalidPath     = errors.New("tenant path is invalid")
+)
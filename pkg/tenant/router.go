@@ -0,0 +1,80 @@
+package tenant
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Router dispatches requests to the handler of the tenant matching the Host header and the path.
+// Tenants of the exact host take precedence over tenants served for all hosts, and longer path prefixes over shorter ones.
+// The handlers receive the full path, as they serve their routes below the path prefix themselves.
+type Router struct {
+	// routes holds the routes by host sorted by the length of their path prefix, the empty host matches all hosts
+	routes map[string][]route
+}
+
+type route struct {
+	path    string
+	handler http.Handler
+}
+
+// NewRouter returns a Router without any tenants.
+func NewRouter() *Router {
+	return &Router{
+		routes: make(map[string][]route),
+	}
+}
+
+// Handle registers the handler of the tenant served for host below the path prefix.
+// The tenant is served for all hosts if host is empty, and at the root if path is empty.
+func (r *Router) Handle(host, path string, handler http.Handler) error {
+	host = normalizeHost(host)
+	path, err := normalizePath(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPath, err)
+	}
+	if host == "" && path == "" {
+		return ErrMissingSelector
+	}
+
+	routes := r.routes[host]
+	for _, rt := range routes {
+		if rt.path == path {
+			return fmt.Errorf("%w: %s", ErrDuplicateTenant, Config{Host: host, Path: path}.Name())
+		}
+	}
+
+	routes = append(routes, route{path: path, handler: handler})
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].path) > len(routes[j].path)
+	})
+	r.routes[host] = routes
+	return nil
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for _, host := range []string{hostname(req.Host), ""} {
+		for _, rt := range r.routes[host] {
+			if rt.path == "" || req.URL.Path == rt.path || strings.HasPrefix(req.URL.Path, rt.path+"/") {
+				rt.handler.ServeHTTP(w, req)
+				return
+			}
+		}
+	}
+
+	core.HandleErrorResponse(fmt.Errorf("%w: %s%s", ErrUnknownTenant, req.Host, req.URL.Path), http.StatusNotFound, w)
+}
+
+// hostname strips the port and a trailing dot from the Host header
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return normalizeHost(strings.TrimSuffix(host, "."))
+}
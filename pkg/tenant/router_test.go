@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	assertion "github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	router := NewRouter()
+	for _, host := range []string{"registry.team-a.example.com", "registry.team-b.example.com"} {
+		assertion.NoError(t, router.Handle(host, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, host)
+		})))
+	}
+	assertion.ErrorIs(t, router.Handle("Registry.Team-A.example.com", "", http.NotFoundHandler()), ErrDuplicateTenant)
+
+	tests := []struct {
+		host       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			host:       "registry.team-a.example.com",
+			wantStatus: http.StatusOK,
+			wantBody:   "registry.team-a.example.com",
+		},
+		{
+			host:       "registry.team-b.example.com:5601",
+			wantStatus: http.StatusOK,
+			wantBody:   "registry.team-b.example.com",
+		},
+		{
+			host:       "REGISTRY.TEAM-B.EXAMPLE.COM.",
+			wantStatus: http.StatusOK,
+			wantBody:   "registry.team-b.example.com",
+		},
+		{
+			host:       "registry.team-c.example.com",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/.well-known/terraform.json", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assertion.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assertion.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_Path(t *testing.T) {
+	router := NewRouter()
+	for _, tenant := range []Config{
+		{Host: "registry.example.com"},
+		{Host: "registry.example.com", Path: "/sandbox"},
+		{Host: "registry.example.com", Path: "/sandbox/team-a"},
+		{Path: "/staging"},
+	} {
+		name := tenant.Name()
+		assertion.NoError(t, router.Handle(tenant.Host, tenant.Path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name)
+		})))
+	}
+	assertion.ErrorIs(t, router.Handle("", "/staging/", http.NotFoundHandler()), ErrDuplicateTenant)
+	assertion.ErrorIs(t, router.Handle("", "", http.NotFoundHandler()), ErrMissingSelector)
+	assertion.ErrorIs(t, router.Handle("", "/admin", http.NotFoundHandler()), ErrInvalidPath)
+
+	tests := []struct {
+		host       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			host:       "registry.example.com",
+			path:       "/.well-known/terraform.json",
+			wantStatus: http.StatusOK,
+			wantBody:   "registry.example.com",
+		},
+		{
+			host:       "registry.example.com",
+			path:       "/sandbox/v1/modules/hashicorp/consul/aws/versions",
+			wantStatus: http.StatusOK,
+			wantBody:   "registry.example.com/sandbox",
+		},
+		{
+			host:       "registry.example.com",
+			path:       "/sandbox/team-a/.well-known/terraform.json",
+			wantStatus: http.StatusOK,
+			wantBody:   "registry.example.com/sandbox/team-a",
+		},
+		{
+			// Path prefixes only match whole segments
+			host:       "registry.example.com",
+			path:       "/sandboxed/v1/modules/",
+			wantStatus: http.StatusOK,
+			wantBody:   "registry.example.com",
+		},
+		{
+			host:       "other.example.com:5601",
+			path:       "/staging/v1/providers/hashicorp/random/versions",
+			wantStatus: http.StatusOK,
+			wantBody:   "*/staging",
+		},
+		{
+			host:       "other.example.com",
+			path:       "/sandbox/v1/modules/",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assertion.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assertion.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
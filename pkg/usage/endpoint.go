@@ -0,0 +1,22 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type usageResponse struct {
+	Report
+}
+
+func usageEndpoint(s *Scanner) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		report, err := s.Report(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return usageResponse{report}, nil
+	}
+}
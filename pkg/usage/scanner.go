@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/loglevel"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Report is the storage usage of all namespaces at the time of a scan
+type Report struct {
+	ScannedAt  time.Time                `json:"scanned_at"`
+	Namespaces []storage.NamespaceUsage `json:"namespaces"`
+}
+
+// Scanner computes the storage usage of the namespaces in the background, as it lists all objects of the storage.
+// The latest report is served by the API and exposed as metrics.
+type Scanner struct {
+	reporter storage.UsageReporter
+	metrics  *o11y.UsageMetrics
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu     sync.RWMutex
+	report *Report
+}
+
+// NewScanner scans the usage right away and then in the given interval until the context is cancelled,
+// a non-positive interval disables the periodic scan, e.g. when Scan is called by the scheduler instead.
+// The metrics are only updated if they aren't nil.
+func NewScanner(ctx context.Context, reporter storage.UsageReporter, metrics *o11y.UsageMetrics, interval time.Duration) *Scanner {
+	s := &Scanner{
+		reporter: reporter,
+		metrics:  metrics,
+		logger:   slog.Default().With(slog.String(loglevel.ComponentKey, "usage")),
+		now:      time.Now,
+	}
+
+	go s.run(ctx, interval)
+
+	return s
+}
+
+func (s *Scanner) run(ctx context.Context, interval time.Duration) {
+	scan := func() {
+		if err := s.Scan(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("failed to scan storage usage", slog.String("err", err.Error()))
+		}
+	}
+	scan()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			scan()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Scan computes the usage of all namespaces and warns about namespaces exceeding their soft quota
+func (s *Scanner) Scan(ctx context.Context) error {
+	namespaces, err := s.reporter.Usage(ctx)
+	if err != nil {
+		return err
+	}
+	report := &Report{ScannedAt: s.now(), Namespaces: namespaces}
+
+	for _, u := range namespaces {
+		if u.SoftTotalSize > 0 && u.Bytes > u.SoftTotalSize {
+			s.logger.Warn("namespace exceeds its soft quota",
+				slog.String("namespace", u.Namespace),
+				slog.Int64("bytes", u.Bytes),
+				slog.Int64("soft_total_size", u.SoftTotalSize),
+			)
+		}
+	}
+
+	if s.metrics != nil {
+		// Namespaces which were deleted since the last scan are removed
+		s.metrics.Objects.Reset()
+		s.metrics.Bytes.Reset()
+		s.metrics.Quota.Reset()
+		for _, u := range namespaces {
+			s.metrics.Objects.With(prometheus.Labels{o11y.NamespaceLabel: u.Namespace}).Set(float64(u.Objects))
+			s.metrics.Bytes.With(prometheus.Labels{o11y.NamespaceLabel: u.Namespace}).Set(float64(u.Bytes))
+			if u.MaxTotalSize > 0 {
+				s.metrics.Quota.With(prometheus.Labels{o11y.NamespaceLabel: u.Namespace, o11y.TypeLabel: "max"}).Set(float64(u.MaxTotalSize))
+			}
+			if u.SoftTotalSize > 0 {
+				s.metrics.Quota.With(prometheus.Labels{o11y.NamespaceLabel: u.Namespace, o11y.TypeLabel: "soft"}).Set(float64(u.SoftTotalSize))
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.report = report
+	s.mu.Unlock()
+
+	s.logger.Info("scanned storage usage", slog.Int("namespaces", len(namespaces)))
+	return nil
+}
+
+// Report returns the latest report, the usage is scanned if there is no report yet
+func (s *Scanner) Report(ctx context.Context) (Report, error) {
+	s.mu.RLock()
+	report := s.report
+	s.mu.RUnlock()
+	if report != nil {
+		return *report, nil
+	}
+
+	if err := s.Scan(ctx); err != nil {
+		return Report{}, err
+	}
+	return s.Report(ctx)
+}
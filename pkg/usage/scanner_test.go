@@ -0,0 +1,44 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReporter struct {
+	usage []storage.NamespaceUsage
+	err   error
+	calls int
+}
+
+func (m *mockReporter) Usage(_ context.Context) ([]storage.NamespaceUsage, error) {
+	m.calls++
+	return m.usage, m.err
+}
+
+func TestScanner_Report(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	reporter := &mockReporter{err: errors.New("listing failed")}
+	s := &Scanner{reporter: reporter, logger: slog.Default(), now: func() time.Time { return now }}
+
+	_, err := s.Report(context.Background())
+	assert.Error(t, err)
+
+	reporter.err = nil
+	reporter.usage = []storage.NamespaceUsage{{Namespace: "acme", Objects: 2, Bytes: 2048, SoftTotalSize: 1024}}
+	report, err := s.Report(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Report{ScannedAt: now, Namespaces: reporter.usage}, report)
+
+	// The latest report is served until the next scan
+	_, err = s.Report(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reporter.calls)
+}
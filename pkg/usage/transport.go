@@ -0,0 +1,40 @@
+package usage
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a fully initialized http.Handler for the storage usage API.
+func MakeHandler(s *Scanner, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	router := mux.NewRouter().StrictSlash(true)
+
+	router.Methods("GET").Path(`/usage`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(usageEndpoint(s)),
+				httptransport.NopRequestDecoder,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return router
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	core.HandleErrorResponse(err, core.GenericError(err), w)
+}
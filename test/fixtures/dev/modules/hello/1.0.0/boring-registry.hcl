@@ -0,0 +1,6 @@
+metadata {
+  namespace = "acme"
+  name      = "hello"
+  provider  = "null"
+  version   = "1.0.0"
+}
@@ -0,0 +1,13 @@
+variable "name" {
+  type    = string
+  default = "world"
+}
+
+variable "greeting" {
+  type    = string
+  default = "Hello"
+}
+
+output "greeting" {
+  value = "${var.greeting}, ${var.name}!"
+}